@@ -19,6 +19,7 @@ import (
 	"github.com/joho/godotenv"
 	configaccess "github.com/router-for-me/CLIProxyAPI/v6/internal/access/config_access"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/auth/kiro"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/authcrypt"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/buildinfo"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/cmd"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
@@ -97,6 +98,7 @@ func main() {
 	var githubCopilotLogin bool
 	var projectID string
 	var vertexImport string
+	var reencryptAuths bool
 	var configPath string
 	var password string
 	var tuiMode bool
@@ -134,6 +136,7 @@ func main() {
 	flag.StringVar(&projectID, "project_id", "", "Project ID (Gemini only, not required)")
 	flag.StringVar(&configPath, "config", DefaultConfigPath, "Configure File Path")
 	flag.StringVar(&vertexImport, "vertex-import", "", "Import Vertex service account key JSON file")
+	flag.BoolVar(&reencryptAuths, "reencrypt-auths", false, "Rewrite stored auth files with the current auth-encryption key")
 	flag.StringVar(&password, "password", "", "")
 	flag.BoolVar(&tuiMode, "tui", false, "Start with terminal management UI")
 	flag.BoolVar(&standalone, "standalone", false, "In TUI mode, start an embedded local server")
@@ -486,6 +489,14 @@ func main() {
 		CallbackPort: oauthCallbackPort,
 	}
 
+	// Install auth file encryption before any token store reads or writes credentials.
+	if authCipher, errCipher := authcrypt.New(cfg.AuthEncryption); errCipher != nil {
+		log.Errorf("failed to configure auth encryption: %v", errCipher)
+		return
+	} else if authCipher != nil {
+		authcrypt.SetDefault(authCipher)
+	}
+
 	// Register the shared token store once so all components use the same persistence backend.
 	if usePostgresStore {
 		sdkAuth.RegisterTokenStore(pgStoreInst)
@@ -505,6 +516,9 @@ func main() {
 	if vertexImport != "" {
 		// Handle Vertex service account import
 		cmd.DoVertexImport(cfg, vertexImport)
+	} else if reencryptAuths {
+		// Handle re-encryption of stored auth files
+		cmd.DoReencryptAuths(cfg)
 	} else if login {
 		// Handle Google/Gemini login
 		cmd.DoLogin(cfg, projectID, options)
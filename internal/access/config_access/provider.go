@@ -2,8 +2,10 @@ package configaccess
 
 import (
 	"context"
+	"fmt"
 	"net/http"
 	"strings"
+	"time"
 
 	sdkaccess "github.com/router-for-me/CLIProxyAPI/v6/sdk/access"
 	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
@@ -30,19 +32,20 @@ func Register(cfg *sdkconfig.SDKConfig) {
 
 type provider struct {
 	name string
-	keys map[string]struct{}
+	keys map[string]sdkconfig.APIKey
+	now  func() time.Time
 }
 
-func newProvider(name string, keys []string) *provider {
+func newProvider(name string, keys []sdkconfig.APIKey) *provider {
 	providerName := strings.TrimSpace(name)
 	if providerName == "" {
 		providerName = sdkaccess.DefaultAccessProviderName
 	}
-	keySet := make(map[string]struct{}, len(keys))
+	keySet := make(map[string]sdkconfig.APIKey, len(keys))
 	for _, key := range keys {
-		keySet[key] = struct{}{}
+		keySet[key.Key] = key
 	}
-	return &provider{name: providerName, keys: keySet}
+	return &provider{name: providerName, keys: keySet, now: time.Now}
 }
 
 func (p *provider) Identifier() string {
@@ -72,7 +75,7 @@ func (p *provider) Authenticate(_ context.Context, r *http.Request) (*sdkaccess.
 		return nil, sdkaccess.NewNoCredentialsError()
 	}
 
-	apiKey := extractBearerToken(authHeader)
+	apiKey := ExtractBearerToken(authHeader)
 
 	candidates := []struct {
 		value  string
@@ -89,21 +92,78 @@ func (p *provider) Authenticate(_ context.Context, r *http.Request) (*sdkaccess.
 		if candidate.value == "" {
 			continue
 		}
-		if _, ok := p.keys[candidate.value]; ok {
-			return &sdkaccess.Result{
-				Provider:  p.Identifier(),
-				Principal: candidate.value,
-				Metadata: map[string]string{
-					"source": candidate.source,
-				},
-			}, nil
+		entry, ok := p.keys[candidate.value]
+		if !ok || !entry.Active(p.now()) {
+			continue
+		}
+		if group := RouteGroup(r); !entry.AllowsRoute(group) {
+			return nil, sdkaccess.NewForbiddenError(RouteForbiddenMessage(group))
+		}
+		metadata := map[string]string{
+			"source": candidate.source,
+		}
+		if entry.Name != "" {
+			metadata["name"] = entry.Name
 		}
+		if entry.Owner != "" {
+			metadata["owner"] = entry.Owner
+		}
+		return &sdkaccess.Result{
+			Provider:  p.Identifier(),
+			Principal: candidate.value,
+			Metadata:  metadata,
+		}, nil
 	}
 
 	return nil, sdkaccess.NewInvalidCredentialError()
 }
 
-func extractBearerToken(header string) string {
+// RouteGroup classifies the request path into an allowed-routes group. Paths outside
+// every group yield "".
+func RouteGroup(r *http.Request) string {
+	if r == nil || r.URL == nil {
+		return ""
+	}
+	path := r.URL.Path
+	switch {
+	case strings.HasPrefix(path, "/api/"), path == "/api":
+		return sdkconfig.RouteGroupAmp
+	case hasPathPrefix(path, "/v1/models"), path == "/v1beta/models":
+		return sdkconfig.RouteGroupModels
+	case hasPathPrefix(path, "/v1/files"):
+		return sdkconfig.RouteGroupFiles
+	case hasPathPrefix(path, "/v1/batches"), hasPathPrefix(path, "/v1/messages/batches"):
+		return sdkconfig.RouteGroupBatches
+	case hasPathPrefix(path, "/v1/messages"):
+		return sdkconfig.RouteGroupClaude
+	case hasPathPrefix(path, "/v1/responses"):
+		return sdkconfig.RouteGroupResponses
+	case hasPathPrefix(path, "/v1/chat/completions"), hasPathPrefix(path, "/v1/completions"), hasPathPrefix(path, "/v1/embeddings"),
+		hasPathPrefix(path, "/v1/images"):
+		return sdkconfig.RouteGroupOpenAI
+	case strings.HasPrefix(path, "/v1beta/models/"), strings.HasPrefix(path, "/v1internal"):
+		return sdkconfig.RouteGroupGemini
+	default:
+		return ""
+	}
+}
+
+// RouteForbiddenMessage describes a request rejected by allowed-routes.
+func RouteForbiddenMessage(group string) string {
+	if group == "" {
+		return "API key is not allowed to access this route"
+	}
+	return fmt.Sprintf("API key is not allowed to access %s routes", group)
+}
+
+// hasPathPrefix reports whether path is prefix or one of its sub-paths.
+func hasPathPrefix(path, prefix string) bool {
+	return path == prefix || strings.HasPrefix(path, prefix+"/")
+}
+
+// ExtractBearerToken returns the token of a "Bearer" authorization header, or the
+// header unchanged when it uses another scheme.
+func ExtractBearerToken(header string) string {
 	if header == "" {
 		return ""
 	}
@@ -117,22 +177,22 @@ func extractBearerToken(header string) string {
 	return strings.TrimSpace(parts[1])
 }
 
-func normalizeKeys(keys []string) []string {
+func normalizeKeys(keys []sdkconfig.APIKey) []sdkconfig.APIKey {
 	if len(keys) == 0 {
 		return nil
 	}
-	normalized := make([]string, 0, len(keys))
+	normalized := make([]sdkconfig.APIKey, 0, len(keys))
 	seen := make(map[string]struct{}, len(keys))
 	for _, key := range keys {
-		trimmedKey := strings.TrimSpace(key)
-		if trimmedKey == "" {
+		key.Key = strings.TrimSpace(key.Key)
+		if key.Key == "" {
 			continue
 		}
-		if _, exists := seen[trimmedKey]; exists {
+		if _, exists := seen[key.Key]; exists {
 			continue
 		}
-		seen[trimmedKey] = struct{}{}
-		normalized = append(normalized, trimmedKey)
+		seen[key.Key] = struct{}{}
+		normalized = append(normalized, key)
 	}
 	if len(normalized) == 0 {
 		return nil
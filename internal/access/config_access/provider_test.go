@@ -0,0 +1,76 @@
+package configaccess
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	sdkaccess "github.com/router-for-me/CLIProxyAPI/v6/sdk/access"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+)
+
+func TestAuthenticateStructuredKeys(t *testing.T) {
+	now := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	p := newProvider("", []sdkconfig.APIKey{
+		{Key: "active", Name: "ci", Owner: "team-a", AllowedRoutes: []string{sdkconfig.RouteGroupOpenAI, sdkconfig.RouteGroupModels}},
+		{Key: "open"},
+		{Key: "expired", ExpiresAt: now.Add(-time.Hour)},
+		{Key: "disabled", Disabled: true},
+	})
+	p.now = func() time.Time { return now }
+
+	authenticate := func(key, path string) (*sdkaccess.Result, *sdkaccess.AuthError) {
+		r := httptest.NewRequest(http.MethodPost, path, nil)
+		r.Header.Set("Authorization", "Bearer "+key)
+		return p.Authenticate(context.Background(), r)
+	}
+
+	result, errAuth := authenticate("active", "/v1/chat/completions")
+	if errAuth != nil {
+		t.Fatalf("active key rejected: %v", errAuth)
+	}
+	if result.Metadata["name"] != "ci" || result.Metadata["owner"] != "team-a" {
+		t.Fatalf("metadata = %v, want name and owner", result.Metadata)
+	}
+	if _, errAuth = authenticate("active", "/v1/models"); errAuth != nil {
+		t.Fatalf("models route rejected: %v", errAuth)
+	}
+	for _, path := range []string{"/v1/messages", "/v1/messages/count_tokens", "/v1/unknown"} {
+		if _, errAuth = authenticate("active", path); errAuth.HTTPStatusCode() != http.StatusForbidden {
+			t.Fatalf("%s outside scope: %v, want 403", path, errAuth)
+		}
+		if _, errAuth = authenticate("open", path); errAuth != nil {
+			t.Fatalf("%s rejected for unrestricted key: %v", path, errAuth)
+		}
+	}
+	for _, key := range []string{"expired", "disabled"} {
+		if _, errAuth = authenticate(key, "/v1/chat/completions"); !sdkaccess.IsAuthErrorCode(errAuth, sdkaccess.AuthErrorCodeInvalidCredential) {
+			t.Fatalf("%s key: %v, want invalid credential", key, errAuth)
+		}
+	}
+}
+
+func TestRouteGroup(t *testing.T) {
+	cases := map[string]string{
+		"/v1/models":                   sdkconfig.RouteGroupModels,
+		"/v1beta/models":               sdkconfig.RouteGroupModels,
+		"/v1beta/models/gemini:stream": sdkconfig.RouteGroupGemini,
+		"/v1/messages/count_tokens":    sdkconfig.RouteGroupClaude,
+		"/v1/messages/batches/b1":      sdkconfig.RouteGroupBatches,
+		"/v1/batches":                  sdkconfig.RouteGroupBatches,
+		"/v1/files/file-1/content":     sdkconfig.RouteGroupFiles,
+		"/v1/chat/completions":         sdkconfig.RouteGroupOpenAI,
+		"/v1/embeddings":               sdkconfig.RouteGroupOpenAI,
+		"/v1/images/generations":       sdkconfig.RouteGroupOpenAI,
+		"/v1/responses/compact":        sdkconfig.RouteGroupResponses,
+		"/v1/modelsx":                  "",
+		"/healthz":                     "",
+	}
+	for path, want := range cases {
+		if got := RouteGroup(httptest.NewRequest(http.MethodGet, path, nil)); got != want {
+			t.Errorf("RouteGroup(%s) = %q, want %q", path, got, want)
+		}
+	}
+}
@@ -0,0 +1,264 @@
+package jwtaccess
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	// maxJWKSBytes bounds the size of a fetched key set.
+	maxJWKSBytes = 1 << 20
+	// jwksFetchTimeout bounds one key set download.
+	jwksFetchTimeout = 10 * time.Second
+	// minJWKSRefreshInterval throttles refreshes triggered by unknown key ids.
+	minJWKSRefreshInterval = 30 * time.Second
+)
+
+// publicKey is one signing key of a JWKS. Exactly one of rsa and ecdsa is set.
+type publicKey struct {
+	kid   string
+	alg   string
+	rsa   *rsa.PublicKey
+	ecdsa *ecdsa.PublicKey
+}
+
+// supports reports whether the key can verify signatures made with alg.
+func (k publicKey) supports(alg string) bool {
+	if k.alg != "" && k.alg != alg {
+		return false
+	}
+	switch alg {
+	case algRS256:
+		return k.rsa != nil
+	case algES256:
+		return k.ecdsa != nil
+	default:
+		return false
+	}
+}
+
+// keySet caches the keys of a JWKS loaded from a URL or a file and reloads them when
+// they are older than the refresh interval or a token names an unknown key id.
+type keySet struct {
+	url     string
+	file    string
+	refresh time.Duration
+	client  *http.Client
+	now     func() time.Time
+
+	// reloads lets concurrent callers share one load, which runs outside mu.
+	reloads singleflight.Group
+
+	mu          sync.Mutex
+	keys        []publicKey
+	loadedAt    time.Time
+	attemptedAt time.Time
+	loadErr     error
+}
+
+func newKeySet(url, file string, refresh time.Duration) *keySet {
+	return &keySet{
+		url:     url,
+		file:    file,
+		refresh: refresh,
+		client:  &http.Client{Timeout: jwksFetchTimeout},
+		now:     time.Now,
+	}
+}
+
+// find returns the keys matching kid and alg, reloading the set when it is stale or has
+// no such key. Stale keys stay in use when a reload fails. The reload is not bound to ctx,
+// so a cancelled request does not fail it for every caller waiting on it.
+func (s *keySet) find(ctx context.Context, kid, alg string) ([]publicKey, error) {
+	s.mu.Lock()
+	matches, done, err := s.cachedLocked(kid, alg)
+	s.mu.Unlock()
+	if done {
+		return matches, err
+	}
+	select {
+	case <-s.reloads.DoChan("", func() (any, error) { return nil, s.reload(context.WithoutCancel(ctx)) }):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.loadedAt.IsZero() {
+		return nil, s.loadErr
+	}
+	return s.matchLocked(kid, alg), nil
+}
+
+// cachedLocked returns the cached keys matching kid and alg and done=true when they are fresh
+// or a reload was attempted too recently to try another one.
+func (s *keySet) cachedLocked(kid, alg string) ([]publicKey, bool, error) {
+	now := s.now()
+	matches := s.matchLocked(kid, alg)
+	fresh := !s.loadedAt.IsZero() && now.Sub(s.loadedAt) < s.refresh
+	if fresh && len(matches) > 0 {
+		return matches, true, nil
+	}
+	if !s.attemptedAt.IsZero() && now.Sub(s.attemptedAt) < minJWKSRefreshInterval {
+		if s.loadedAt.IsZero() {
+			return nil, true, s.loadErr
+		}
+		return matches, true, nil
+	}
+	return nil, false, nil
+}
+
+// reload loads the key set unless a load finished within minJWKSRefreshInterval. The attempt
+// is recorded once the load completes, so callers arriving meanwhile join it instead of being
+// throttled.
+func (s *keySet) reload(ctx context.Context) error {
+	s.mu.Lock()
+	now := s.now()
+	if !s.attemptedAt.IsZero() && now.Sub(s.attemptedAt) < minJWKSRefreshInterval {
+		s.mu.Unlock()
+		return nil
+	}
+	s.mu.Unlock()
+
+	keys, err := s.load(ctx)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.attemptedAt = now
+	if err != nil {
+		s.loadErr = err
+		if !s.loadedAt.IsZero() {
+			log.Warnf("jwt access: reload key set: %v", err)
+		}
+		return err
+	}
+	s.keys = keys
+	s.loadedAt = now
+	s.loadErr = nil
+	return nil
+}
+
+func (s *keySet) matchLocked(kid, alg string) []publicKey {
+	var out []publicKey
+	for _, key := range s.keys {
+		if kid != "" && key.kid != kid {
+			continue
+		}
+		if key.supports(alg) {
+			out = append(out, key)
+		}
+	}
+	return out
+}
+
+func (s *keySet) load(ctx context.Context) ([]publicKey, error) {
+	var data []byte
+	if s.file != "" {
+		raw, err := os.ReadFile(s.file)
+		if err != nil {
+			return nil, fmt.Errorf("read jwks file: %w", err)
+		}
+		data = raw
+	} else {
+		fetchCtx, cancel := context.WithTimeout(ctx, jwksFetchTimeout)
+		defer cancel()
+		req, err := http.NewRequestWithContext(fetchCtx, http.MethodGet, s.url, nil)
+		if err != nil {
+			return nil, fmt.Errorf("build jwks request: %w", err)
+		}
+		req.Header.Set("Accept", "application/json")
+		resp, err := s.client.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("fetch jwks: %w", err)
+		}
+		defer func() { _ = resp.Body.Close() }()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("fetch jwks: unexpected status %d", resp.StatusCode)
+		}
+		data, err = io.ReadAll(io.LimitReader(resp.Body, maxJWKSBytes))
+		if err != nil {
+			return nil, fmt.Errorf("read jwks: %w", err)
+		}
+	}
+	return parseJWKS(data)
+}
+
+// parseJWKS decodes the RSA and P-256 signing keys of a JWKS document. Keys of other
+// types or uses are skipped.
+func parseJWKS(data []byte) ([]publicKey, error) {
+	var doc struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			Use string `json:"use"`
+			Alg string `json:"alg"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+			Crv string `json:"crv"`
+			X   string `json:"x"`
+			Y   string `json:"y"`
+		} `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("decode jwks: %w", err)
+	}
+	keys := make([]publicKey, 0, len(doc.Keys))
+	for _, raw := range doc.Keys {
+		if raw.Use != "" && raw.Use != "sig" {
+			continue
+		}
+		key := publicKey{kid: raw.Kid, alg: raw.Alg}
+		switch raw.Kty {
+		case "RSA":
+			n, errN := decodeBigInt(raw.N)
+			e, errE := decodeBigInt(raw.E)
+			if errN != nil || errE != nil || n.Sign() <= 0 || !e.IsInt64() || e.Int64() < 3 || e.Int64() > 1<<31-1 {
+				log.Warnf("jwt access: skipping malformed RSA key %q", raw.Kid)
+				continue
+			}
+			key.rsa = &rsa.PublicKey{N: n, E: int(e.Int64())}
+		case "EC":
+			if raw.Crv != "P-256" {
+				continue
+			}
+			x, errX := decodeBigInt(raw.X)
+			y, errY := decodeBigInt(raw.Y)
+			if errX != nil || errY != nil || !elliptic.P256().IsOnCurve(x, y) {
+				log.Warnf("jwt access: skipping malformed EC key %q", raw.Kid)
+				continue
+			}
+			key.ecdsa = &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}
+		default:
+			continue
+		}
+		keys = append(keys, key)
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("jwks contains no usable RS256 or ES256 keys")
+	}
+	return keys, nil
+}
+
+func decodeBigInt(value string) (*big.Int, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	if len(raw) == 0 {
+		return nil, fmt.Errorf("empty value")
+	}
+	return new(big.Int).SetBytes(raw), nil
+}
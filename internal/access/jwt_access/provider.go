@@ -0,0 +1,393 @@
+// Package jwtaccess provides the jwt access provider, which authenticates clients with
+// short-lived JWTs issued by an identity provider and verified against its JWKS.
+package jwtaccess
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	configaccess "github.com/router-for-me/CLIProxyAPI/v6/internal/access/config_access"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	sdkaccess "github.com/router-for-me/CLIProxyAPI/v6/sdk/access"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	defaultPrincipalClaim = "sub"
+	defaultLeeway         = 60 * time.Second
+	defaultJWKSRefresh    = time.Hour
+)
+
+// options are the provider-specific settings read from AccessProvider.Config.
+type options struct {
+	// JWKSURL and JWKSFile locate the key set; exactly one must be set.
+	JWKSURL  string `yaml:"jwks-url,omitempty"`
+	JWKSFile string `yaml:"jwks-file,omitempty"`
+	// JWKSRefreshSeconds is how long a loaded key set is trusted before it is reloaded.
+	JWKSRefreshSeconds int `yaml:"jwks-refresh-seconds,omitempty"`
+
+	Issuer   string     `yaml:"issuer"`
+	Audience stringList `yaml:"audience"`
+	// LeewaySeconds tolerates clock skew when checking exp and nbf; defaults to 60.
+	LeewaySeconds *int `yaml:"leeway-seconds,omitempty"`
+
+	// PrincipalClaim names the claim identifying the client; defaults to "sub".
+	PrincipalClaim string `yaml:"principal-claim,omitempty"`
+	// MetadataClaims are copied into the result metadata under their own names.
+	MetadataClaims []string `yaml:"metadata-claims,omitempty"`
+	// Restrictions limit the routes and models of tokens carrying matching claims.
+	Restrictions []restriction `yaml:"restrictions,omitempty"`
+}
+
+// restriction applies to tokens whose Claim contains one of Values, or that carry the
+// claim at all when Values is empty.
+type restriction struct {
+	Claim         string     `yaml:"claim"`
+	Values        stringList `yaml:"values,omitempty"`
+	AllowedModels []string   `yaml:"allowed-models,omitempty"`
+	AllowedRoutes []string   `yaml:"allowed-routes,omitempty"`
+}
+
+// stringList accepts a single string or a list of strings.
+type stringList []string
+
+func (l *stringList) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*l = stringList{node.Value}
+		return nil
+	}
+	var values []string
+	if err := node.Decode(&values); err != nil {
+		return err
+	}
+	*l = values
+	return nil
+}
+
+var (
+	registeredMu sync.Mutex
+	registered   = make(map[string]*provider)
+)
+
+// Register installs a provider for every jwt entry of cfg.Access.Providers and removes
+// the ones no longer configured. Entries whose settings did not change keep their
+// provider, and with it the cached key set.
+func Register(cfg *sdkconfig.SDKConfig) {
+	registeredMu.Lock()
+	defer registeredMu.Unlock()
+
+	desired := make(map[string]*provider)
+	if cfg != nil {
+		for _, entry := range cfg.Access.Providers {
+			if !strings.EqualFold(strings.TrimSpace(entry.Type), sdkaccess.AccessProviderTypeJWT) {
+				continue
+			}
+			name := strings.TrimSpace(entry.Name)
+			if name == "" {
+				name = sdkaccess.AccessProviderTypeJWT
+			}
+			key := sdkaccess.AccessProviderTypeJWT + ":" + name
+			if _, duplicate := desired[key]; duplicate {
+				log.Warnf("jwt access: ignoring duplicate provider %q", name)
+				continue
+			}
+			opts, errOptions := parseOptions(entry.Config)
+			if errOptions != nil {
+				log.Errorf("jwt access: provider %q: %v", name, errOptions)
+			}
+			if existing := registered[key]; existing != nil && reflect.DeepEqual(existing.opts, opts) && (existing.err == nil) == (errOptions == nil) {
+				desired[key] = existing
+				continue
+			}
+			desired[key] = newProvider(name, opts, errOptions)
+		}
+	}
+
+	for key := range registered {
+		if _, keep := desired[key]; !keep {
+			sdkaccess.UnregisterProvider(key)
+		}
+	}
+	for key, p := range desired {
+		sdkaccess.RegisterProvider(key, p)
+	}
+	registered = desired
+}
+
+// parseOptions decodes and validates the provider settings.
+func parseOptions(raw map[string]any) (options, error) {
+	var opts options
+	data, err := yaml.Marshal(raw)
+	if err != nil {
+		return options{}, fmt.Errorf("encode config: %w", err)
+	}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err = decoder.Decode(&opts); err != nil {
+		return options{}, fmt.Errorf("decode config: %w", err)
+	}
+
+	opts.JWKSURL = strings.TrimSpace(opts.JWKSURL)
+	opts.JWKSFile = strings.TrimSpace(opts.JWKSFile)
+	switch {
+	case opts.JWKSURL == "" && opts.JWKSFile == "":
+		return options{}, fmt.Errorf("jwks-url or jwks-file is required")
+	case opts.JWKSURL != "" && opts.JWKSFile != "":
+		return options{}, fmt.Errorf("jwks-url and jwks-file are mutually exclusive")
+	case opts.JWKSURL != "":
+		parsed, errURL := url.Parse(opts.JWKSURL)
+		if errURL != nil || (parsed.Scheme != "https" && parsed.Scheme != "http") || parsed.Host == "" {
+			return options{}, fmt.Errorf("jwks-url must be an http(s) URL")
+		}
+	}
+	if opts.JWKSRefreshSeconds < 0 {
+		return options{}, fmt.Errorf("jwks-refresh-seconds must not be negative")
+	}
+	if opts.LeewaySeconds != nil && *opts.LeewaySeconds < 0 {
+		return options{}, fmt.Errorf("leeway-seconds must not be negative")
+	}
+	opts.Issuer = strings.TrimSpace(opts.Issuer)
+	if opts.Issuer == "" {
+		return options{}, fmt.Errorf("issuer is required")
+	}
+	opts.Audience = stringList(trimStrings(opts.Audience))
+	if len(opts.Audience) == 0 {
+		return options{}, fmt.Errorf("audience is required")
+	}
+	opts.PrincipalClaim = strings.TrimSpace(opts.PrincipalClaim)
+	if opts.PrincipalClaim == "" {
+		opts.PrincipalClaim = defaultPrincipalClaim
+	}
+	opts.MetadataClaims = trimStrings(opts.MetadataClaims)
+	for i := range opts.Restrictions {
+		rule := &opts.Restrictions[i]
+		rule.Claim = strings.TrimSpace(rule.Claim)
+		if rule.Claim == "" {
+			return options{}, fmt.Errorf("restrictions[%d]: claim is required", i)
+		}
+		rule.Values = stringList(trimStrings(rule.Values))
+		rule.AllowedModels = config.NormalizeExcludedModels(rule.AllowedModels)
+		rule.AllowedRoutes = config.NormalizeExcludedModels(rule.AllowedRoutes)
+		for _, group := range rule.AllowedRoutes {
+			switch group {
+			case sdkconfig.RouteGroupOpenAI, sdkconfig.RouteGroupClaude, sdkconfig.RouteGroupGemini, sdkconfig.RouteGroupResponses,
+				sdkconfig.RouteGroupAmp, sdkconfig.RouteGroupModels, sdkconfig.RouteGroupFiles, sdkconfig.RouteGroupBatches:
+			default:
+				return options{}, fmt.Errorf("restrictions[%d]: unknown route group %q", i, group)
+			}
+		}
+	}
+	return opts, nil
+}
+
+func trimStrings(values []string) []string {
+	out := make([]string, 0, len(values))
+	for _, value := range values {
+		if trimmed := strings.TrimSpace(value); trimmed != "" {
+			out = append(out, trimmed)
+		}
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+type provider struct {
+	name   string
+	opts   options
+	err    error
+	keys   *keySet
+	leeway time.Duration
+	now    func() time.Time
+}
+
+// newProvider builds a provider; errOptions marks a misconfigured entry, which rejects
+// every JWT instead of silently letting requests fall through to other providers.
+func newProvider(name string, opts options, errOptions error) *provider {
+	p := &provider{name: name, opts: opts, err: errOptions, leeway: defaultLeeway, now: time.Now}
+	if errOptions != nil {
+		return p
+	}
+	if opts.LeewaySeconds != nil {
+		p.leeway = time.Duration(*opts.LeewaySeconds) * time.Second
+	}
+	refresh := defaultJWKSRefresh
+	if opts.JWKSRefreshSeconds > 0 {
+		refresh = time.Duration(opts.JWKSRefreshSeconds) * time.Second
+	}
+	p.keys = newKeySet(opts.JWKSURL, opts.JWKSFile, refresh)
+	return p
+}
+
+func (p *provider) Identifier() string {
+	if p == nil || p.name == "" {
+		return sdkaccess.AccessProviderTypeJWT
+	}
+	return p.name
+}
+
+func (p *provider) Authenticate(ctx context.Context, r *http.Request) (*sdkaccess.Result, *sdkaccess.AuthError) {
+	if p == nil || r == nil {
+		return nil, sdkaccess.NewNotHandledError()
+	}
+	token, source, present := tokenFromRequest(r)
+	if token == "" {
+		if present {
+			return nil, sdkaccess.NewNotHandledError()
+		}
+		return nil, sdkaccess.NewNoCredentialsError()
+	}
+	if p.err != nil {
+		return nil, sdkaccess.NewInternalAuthError("JWT authentication is misconfigured", nil)
+	}
+
+	claims, err := p.verify(ctx, token)
+	if err != nil {
+		var errKeys *keysUnavailableError
+		if errors.As(err, &errKeys) {
+			return nil, sdkaccess.NewInternalAuthError("JWT signing keys are unavailable", errKeys.err)
+		}
+		log.Debugf("jwt access: provider %s rejected token: %v", p.Identifier(), err)
+		return nil, sdkaccess.NewInvalidCredentialError()
+	}
+	principal := claimString(claims[p.opts.PrincipalClaim])
+	if principal == "" {
+		log.Debugf("jwt access: provider %s rejected token without %s claim", p.Identifier(), p.opts.PrincipalClaim)
+		return nil, sdkaccess.NewInvalidCredentialError()
+	}
+
+	matched := p.matchRestrictions(claims)
+	if group := configaccess.RouteGroup(r); !routeAllowed(matched, group) {
+		message := "token is not allowed to access this route"
+		if group != "" {
+			message = fmt.Sprintf("token is not allowed to access %s routes", group)
+		}
+		return nil, sdkaccess.NewForbiddenError(message)
+	}
+	metadata := map[string]string{
+		"source":  source,
+		"issuer":  claimString(claims["iss"]),
+		"subject": claimString(claims["sub"]),
+	}
+	for _, claim := range p.opts.MetadataClaims {
+		if value := claimString(claims[claim]); value != "" {
+			metadata[claim] = value
+		}
+	}
+	if models := allowedModels(matched); len(models) > 0 {
+		metadata[sdkaccess.MetadataAllowedModels] = strings.Join(models, ",")
+	}
+	return &sdkaccess.Result{
+		Provider:  p.Identifier(),
+		Principal: principal,
+		Metadata:  metadata,
+	}, nil
+}
+
+// tokenFromRequest returns the first credential shaped like a JWT and where it came
+// from. present reports whether the request carried any credential at all.
+func tokenFromRequest(r *http.Request) (token, source string, present bool) {
+	candidates := []struct {
+		value  string
+		source string
+	}{
+		{configaccess.ExtractBearerToken(r.Header.Get("Authorization")), "authorization"},
+		{r.Header.Get("X-Goog-Api-Key"), "x-goog-api-key"},
+		{r.Header.Get("X-Api-Key"), "x-api-key"},
+	}
+	if r.URL != nil {
+		candidates = append(candidates, struct {
+			value  string
+			source string
+		}{r.URL.Query().Get("key"), "query-key"})
+	}
+	for _, candidate := range candidates {
+		value := strings.TrimSpace(candidate.value)
+		if value == "" {
+			continue
+		}
+		present = true
+		if looksLikeJWT(value) {
+			return value, candidate.source, true
+		}
+	}
+	return "", "", present
+}
+
+func looksLikeJWT(value string) bool {
+	return strings.HasPrefix(value, "eyJ") && strings.Count(value, ".") == 2
+}
+
+// matchRestrictions returns the restrictions applying to a token with claims.
+func (p *provider) matchRestrictions(claims map[string]any) []restriction {
+	var matched []restriction
+	for _, rule := range p.opts.Restrictions {
+		value, ok := claims[rule.Claim]
+		if !ok {
+			continue
+		}
+		if len(rule.Values) == 0 {
+			matched = append(matched, rule)
+			continue
+		}
+		for _, candidate := range claimValues(value) {
+			if containsString(rule.Values, candidate) {
+				matched = append(matched, rule)
+				break
+			}
+		}
+	}
+	return matched
+}
+
+// routeAllowed reports whether any matching restriction allows group. Tokens without
+// matching restrictions are always allowed; requests outside any group need a matching
+// restriction without allowed-routes.
+func routeAllowed(matched []restriction, group string) bool {
+	if len(matched) == 0 {
+		return true
+	}
+	for _, rule := range matched {
+		if len(rule.AllowedRoutes) == 0 || containsString(rule.AllowedRoutes, group) {
+			return true
+		}
+	}
+	return false
+}
+
+// allowedModels merges the model patterns of matching restrictions. It returns nil, meaning
+// unrestricted, when any of them allows every model.
+func allowedModels(matched []restriction) []string {
+	var out []string
+	for _, rule := range matched {
+		if len(rule.AllowedModels) == 0 {
+			return nil
+		}
+		for _, model := range rule.AllowedModels {
+			if !containsString(out, model) {
+				out = append(out, model)
+			}
+		}
+	}
+	return out
+}
+
+func containsString(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,382 @@
+package jwtaccess
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	sdkaccess "github.com/router-for-me/CLIProxyAPI/v6/sdk/access"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+)
+
+func b64(data []byte) string { return base64.RawURLEncoding.EncodeToString(data) }
+
+func rsaJWK(kid string, key *rsa.PrivateKey) map[string]any {
+	return map[string]any{"kty": "RSA", "kid": kid, "use": "sig", "n": b64(key.N.Bytes()), "e": b64(big.NewInt(int64(key.E)).Bytes())}
+}
+
+func ecJWK(kid string, key *ecdsa.PrivateKey) map[string]any {
+	return map[string]any{"kty": "EC", "kid": kid, "crv": "P-256", "x": b64(key.X.FillBytes(make([]byte, 32))), "y": b64(key.Y.FillBytes(make([]byte, 32)))}
+}
+
+func jwksJSON(t *testing.T, keys ...map[string]any) []byte {
+	t.Helper()
+	data, err := json.Marshal(map[string]any{"keys": keys})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func signToken(t *testing.T, key crypto.Signer, kid string, claims map[string]any) string {
+	t.Helper()
+	alg := algRS256
+	if _, ok := key.(*ecdsa.PrivateKey); ok {
+		alg = algES256
+	}
+	header, _ := json.Marshal(map[string]any{"alg": alg, "kid": kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signingInput := b64(header) + "." + b64(payload)
+	digest := sha256.Sum256([]byte(signingInput))
+	var signature []byte
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		sig, err := rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		signature = sig
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, k, digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		signature = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	}
+	return signingInput + "." + b64(signature)
+}
+
+func authenticate(p *provider, path, token string) (*sdkaccess.Result, *sdkaccess.AuthError) {
+	r := httptest.NewRequest(http.MethodPost, path, nil)
+	if token != "" {
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+	return p.Authenticate(context.Background(), r)
+}
+
+func TestAuthenticateRS256FromFile(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(t.TempDir(), "jwks.json")
+	if err = os.WriteFile(file, jwksJSON(t, rsaJWK("k1", key)), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	opts, errOptions := parseOptions(map[string]any{
+		"jwks-file":       file,
+		"issuer":          "https://idp.example.com/",
+		"audience":        "cliproxy",
+		"metadata-claims": []any{"email"},
+	})
+	if errOptions != nil {
+		t.Fatalf("parseOptions: %v", errOptions)
+	}
+	p := newProvider("corp", opts, nil)
+	now := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+	p.now = func() time.Time { return now }
+	claims := func(overrides map[string]any) map[string]any {
+		out := map[string]any{
+			"iss":   "https://idp.example.com/",
+			"aud":   []any{"other", "cliproxy"},
+			"sub":   "user-1",
+			"email": "user@example.com",
+			"exp":   now.Add(5 * time.Minute).Unix(),
+		}
+		for k, v := range overrides {
+			out[k] = v
+		}
+		return out
+	}
+
+	result, errAuth := authenticate(p, "/v1/chat/completions", signToken(t, key, "k1", claims(nil)))
+	if errAuth != nil {
+		t.Fatalf("valid token rejected: %v", errAuth)
+	}
+	if result.Provider != "corp" || result.Principal != "user-1" {
+		t.Fatalf("result = %+v, want provider corp and principal user-1", result)
+	}
+	if result.Metadata["email"] != "user@example.com" || result.Metadata["issuer"] != "https://idp.example.com/" {
+		t.Fatalf("metadata = %v", result.Metadata)
+	}
+
+	rejected := map[string]map[string]any{
+		"expired":        {"exp": now.Add(-2 * time.Minute).Unix()},
+		"missing exp":    {"exp": nil},
+		"not yet valid":  {"nbf": now.Add(10 * time.Minute).Unix()},
+		"wrong issuer":   {"iss": "https://evil.example.com/"},
+		"wrong audience": {"aud": "other"},
+	}
+	for name, overrides := range rejected {
+		if _, errAuth = authenticate(p, "/v1/chat/completions", signToken(t, key, "k1", claims(overrides))); !sdkaccess.IsAuthErrorCode(errAuth, sdkaccess.AuthErrorCodeInvalidCredential) {
+			t.Fatalf("%s: error = %v, want invalid credential", name, errAuth)
+		}
+	}
+	if _, errAuth = authenticate(p, "/v1/chat/completions", signToken(t, key, "k1", claims(map[string]any{"exp": now.Add(-30 * time.Second).Unix()}))); errAuth != nil {
+		t.Fatalf("token within leeway rejected: %v", errAuth)
+	}
+
+	other, _ := rsa.GenerateKey(rand.Reader, 2048)
+	if _, errAuth = authenticate(p, "/v1/chat/completions", signToken(t, other, "k1", claims(nil))); !sdkaccess.IsAuthErrorCode(errAuth, sdkaccess.AuthErrorCodeInvalidCredential) {
+		t.Fatalf("foreign signature: error = %v, want invalid credential", errAuth)
+	}
+	if _, errAuth = authenticate(p, "/v1/chat/completions", "plain-api-key"); !sdkaccess.IsAuthErrorCode(errAuth, sdkaccess.AuthErrorCodeNotHandled) {
+		t.Fatalf("non-JWT key: error = %v, want not handled", errAuth)
+	}
+	if _, errAuth = authenticate(p, "/v1/chat/completions", ""); !sdkaccess.IsAuthErrorCode(errAuth, sdkaccess.AuthErrorCodeNoCredentials) {
+		t.Fatalf("no credentials: error = %v, want no credentials", errAuth)
+	}
+}
+
+func TestAuthenticateES256FromURLRefreshesUnknownKeys(t *testing.T) {
+	first, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	second, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	var rotated atomic.Bool
+	var fetches atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		fetches.Add(1)
+		if rotated.Load() {
+			_, _ = w.Write(jwksJSON(t, ecJWK("k1", first), ecJWK("k2", second)))
+			return
+		}
+		_, _ = w.Write(jwksJSON(t, ecJWK("k1", first)))
+	}))
+	defer server.Close()
+
+	opts, errOptions := parseOptions(map[string]any{
+		"jwks-url": server.URL,
+		"issuer":   "idp",
+		"audience": []any{"cliproxy"},
+	})
+	if errOptions != nil {
+		t.Fatalf("parseOptions: %v", errOptions)
+	}
+	p := newProvider("corp", opts, nil)
+	now := time.Now()
+	p.now = func() time.Time { return now }
+	p.keys.now = func() time.Time { return now }
+	claims := map[string]any{"iss": "idp", "aud": "cliproxy", "sub": "svc", "exp": now.Add(time.Hour).Unix()}
+
+	if _, errAuth := authenticate(p, "/v1/messages", signToken(t, first, "k1", claims)); errAuth != nil {
+		t.Fatalf("valid ES256 token rejected: %v", errAuth)
+	}
+	if _, errAuth := authenticate(p, "/v1/messages", signToken(t, first, "k1", claims)); errAuth != nil || fetches.Load() != 1 {
+		t.Fatalf("second request: error %v, fetches %d, want cached keys", errAuth, fetches.Load())
+	}
+
+	rotated.Store(true)
+	now = now.Add(minJWKSRefreshInterval)
+	if _, errAuth := authenticate(p, "/v1/messages", signToken(t, second, "k2", claims)); errAuth != nil {
+		t.Fatalf("token signed with rotated key rejected: %v", errAuth)
+	}
+	if fetches.Load() != 2 {
+		t.Fatalf("fetches = %d, want a refresh for the unknown key id", fetches.Load())
+	}
+}
+
+func TestKeySetReloadSurvivesCancelledCaller(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	release := make(chan struct{})
+	var fetches atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		fetches.Add(1)
+		<-release
+		_, _ = w.Write(jwksJSON(t, ecJWK("k1", key)))
+	}))
+	defer server.Close()
+	set := newKeySet(server.URL, "", time.Hour)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancelled := make(chan error, 1)
+	go func() {
+		_, err := set.find(ctx, "k1", algES256)
+		cancelled <- err
+	}()
+	for fetches.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	if err := <-cancelled; !errors.Is(err, context.Canceled) {
+		t.Fatalf("cancelled caller: error = %v, want context canceled", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if keys, err := set.find(context.Background(), "k1", algES256); err != nil || len(keys) != 1 {
+				t.Errorf("waiting caller: keys %d, error %v; want the loaded key", len(keys), err)
+			}
+		}()
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	if fetches.Load() != 1 {
+		t.Fatalf("fetches = %d, want one load shared by every caller", fetches.Load())
+	}
+}
+
+func TestAuthenticateRestrictions(t *testing.T) {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	file := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(file, jwksJSON(t, rsaJWK("k1", key)), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	opts, errOptions := parseOptions(map[string]any{
+		"jwks-file": file,
+		"issuer":    "idp",
+		"audience":  "cliproxy",
+		"restrictions": []any{
+			map[string]any{"claim": "groups", "values": []any{"contractors"}, "allowed-routes": []any{"openai"}, "allowed-models": []any{"GPT-5-mini*"}},
+			map[string]any{"claim": "scope", "values": "claude", "allowed-routes": []any{"claude"}, "allowed-models": []any{"claude-*"}},
+		},
+	})
+	if errOptions != nil {
+		t.Fatalf("parseOptions: %v", errOptions)
+	}
+	p := newProvider("corp", opts, nil)
+	token := func(extra map[string]any) string {
+		claims := map[string]any{"iss": "idp", "aud": "cliproxy", "sub": "user", "exp": time.Now().Add(time.Hour).Unix()}
+		for k, v := range extra {
+			claims[k] = v
+		}
+		return signToken(t, key, "k1", claims)
+	}
+
+	result, errAuth := authenticate(p, "/v1/messages", token(nil))
+	if errAuth != nil || result.Metadata[sdkaccess.MetadataAllowedModels] != "" {
+		t.Fatalf("unrestricted token: result %+v, error %v", result, errAuth)
+	}
+
+	contractor := token(map[string]any{"groups": []any{"staff", "contractors"}})
+	if _, errAuth = authenticate(p, "/v1/messages", contractor); errAuth.HTTPStatusCode() != http.StatusForbidden {
+		t.Fatalf("route outside restriction: error %v, want 403", errAuth)
+	}
+	result, errAuth = authenticate(p, "/v1/chat/completions", contractor)
+	if errAuth != nil || result.Metadata[sdkaccess.MetadataAllowedModels] != "gpt-5-mini*" {
+		t.Fatalf("allowed route: result %+v, error %v", result, errAuth)
+	}
+	for _, path := range []string{"/v1/models", "/v1/unknown"} {
+		if _, errAuth = authenticate(p, path, contractor); errAuth.HTTPStatusCode() != http.StatusForbidden {
+			t.Fatalf("%s outside restriction: error %v, want 403", path, errAuth)
+		}
+	}
+
+	both := token(map[string]any{"groups": "contractors", "scope": "read claude"})
+	result, errAuth = authenticate(p, "/v1/messages", both)
+	if errAuth != nil || result.Metadata[sdkaccess.MetadataAllowedModels] != "gpt-5-mini*,claude-*" {
+		t.Fatalf("merged restrictions: result %+v, error %v", result, errAuth)
+	}
+}
+
+func TestParseOptionsValidation(t *testing.T) {
+	valid := func() map[string]any {
+		return map[string]any{"jwks-url": "https://idp.example.com/jwks", "issuer": "idp", "audience": "cliproxy"}
+	}
+	if _, err := parseOptions(valid()); err != nil {
+		t.Fatalf("valid options rejected: %v", err)
+	}
+	groups := []string{
+		sdkconfig.RouteGroupOpenAI, sdkconfig.RouteGroupClaude, sdkconfig.RouteGroupGemini, sdkconfig.RouteGroupResponses,
+		sdkconfig.RouteGroupAmp, sdkconfig.RouteGroupModels, sdkconfig.RouteGroupFiles, sdkconfig.RouteGroupBatches,
+	}
+	for _, group := range groups {
+		raw := valid()
+		raw["restrictions"] = []any{map[string]any{"claim": "groups", "allowed-routes": []any{group}}}
+		if _, err := parseOptions(raw); err != nil {
+			t.Fatalf("route group %s rejected: %v", group, err)
+		}
+	}
+	cases := map[string]func(map[string]any){
+		"missing jwks":     func(m map[string]any) { delete(m, "jwks-url") },
+		"both jwks":        func(m map[string]any) { m["jwks-file"] = "jwks.json" },
+		"non-http url":     func(m map[string]any) { m["jwks-url"] = "file:///etc/jwks.json" },
+		"missing issuer":   func(m map[string]any) { delete(m, "issuer") },
+		"missing audience": func(m map[string]any) { m["audience"] = []any{} },
+		"unknown field":    func(m map[string]any) { m["issuers"] = "idp" },
+		"unknown route": func(m map[string]any) {
+			m["restrictions"] = []any{map[string]any{"claim": "groups", "allowed-routes": []any{"admin"}}}
+		},
+	}
+	for name, mutate := range cases {
+		raw := valid()
+		mutate(raw)
+		if _, err := parseOptions(raw); err == nil {
+			t.Fatalf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestRegisterReusesAndRemovesProviders(t *testing.T) {
+	entry := func(name, issuer string) sdkaccess.AccessProvider {
+		return sdkaccess.AccessProvider{Name: name, Type: sdkaccess.AccessProviderTypeJWT, Config: map[string]any{
+			"jwks-url": "https://idp.example.com/jwks", "issuer": issuer, "audience": "cliproxy",
+		}}
+	}
+	registeredProvider := func(name string) sdkaccess.Provider {
+		for _, p := range sdkaccess.RegisteredProviders() {
+			if p.Identifier() == name {
+				return p
+			}
+		}
+		return nil
+	}
+	t.Cleanup(func() { Register(nil) })
+
+	cfg := &sdkconfig.SDKConfig{Access: sdkaccess.AccessConfig{Providers: []sdkaccess.AccessProvider{entry("a", "idp"), entry("b", "idp")}}}
+	Register(cfg)
+	a, b := registeredProvider("a"), registeredProvider("b")
+	if a == nil || b == nil {
+		t.Fatal("expected both providers to be registered")
+	}
+
+	cfg = &sdkconfig.SDKConfig{Access: sdkaccess.AccessConfig{Providers: []sdkaccess.AccessProvider{entry("a", "idp"), entry("b", "other")}}}
+	Register(cfg)
+	if registeredProvider("a") != a {
+		t.Fatal("unchanged provider was rebuilt")
+	}
+	if registeredProvider("b") == b {
+		t.Fatal("changed provider was reused")
+	}
+
+	broken := entry("a", "")
+	Register(&sdkconfig.SDKConfig{Access: sdkaccess.AccessConfig{Providers: []sdkaccess.AccessProvider{broken}}})
+	if registeredProvider("b") != nil {
+		t.Fatal("removed provider is still registered")
+	}
+	p, ok := registeredProvider("a").(*provider)
+	if !ok {
+		t.Fatal("misconfigured provider is not registered")
+	}
+	if _, errAuth := authenticate(p, "/v1/chat/completions", "eyJhbGciOiJSUzI1NiJ9.e30.sig"); !sdkaccess.IsAuthErrorCode(errAuth, sdkaccess.AuthErrorCodeInternal) {
+		t.Fatalf("misconfigured provider: error = %v, want internal error", errAuth)
+	}
+}
@@ -0,0 +1,192 @@
+package jwtaccess
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	algRS256 = "RS256"
+	algES256 = "ES256"
+)
+
+// keysUnavailableError reports that no key set could be loaded, as opposed to a token
+// that failed validation.
+type keysUnavailableError struct {
+	err error
+}
+
+func (e *keysUnavailableError) Error() string {
+	return e.err.Error()
+}
+
+// verify checks the signature, expiry, issuer and audience of token and returns its claims.
+func (p *provider) verify(ctx context.Context, token string) (map[string]any, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("malformed token")
+	}
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("decode header: %w", err)
+	}
+	if header.Alg != algRS256 && header.Alg != algES256 {
+		return nil, fmt.Errorf("unsupported algorithm %q", header.Alg)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("decode signature: %w", err)
+	}
+	keys, err := p.keys.find(ctx, header.Kid, header.Alg)
+	if err != nil {
+		return nil, &keysUnavailableError{err: err}
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no %s key with id %q", header.Alg, header.Kid)
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	verified := false
+	for _, key := range keys {
+		if verifySignature(key, header.Alg, digest[:], signature) {
+			verified = true
+			break
+		}
+	}
+	if !verified {
+		return nil, fmt.Errorf("invalid signature")
+	}
+
+	var claims map[string]any
+	if err = decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("decode claims: %w", err)
+	}
+	if err = p.checkClaims(claims); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+func (p *provider) checkClaims(claims map[string]any) error {
+	now := p.now()
+	expiry, ok := numericDate(claims["exp"])
+	if !ok {
+		return fmt.Errorf("missing exp claim")
+	}
+	if !now.Before(expiry.Add(p.leeway)) {
+		return fmt.Errorf("token expired at %s", expiry.UTC().Format(time.RFC3339))
+	}
+	if notBefore, ok := numericDate(claims["nbf"]); ok && now.Add(p.leeway).Before(notBefore) {
+		return fmt.Errorf("token not valid before %s", notBefore.UTC().Format(time.RFC3339))
+	}
+	if issuer := claimString(claims["iss"]); issuer != p.opts.Issuer {
+		return fmt.Errorf("unexpected issuer %q", issuer)
+	}
+	for _, audience := range claimValues(claims["aud"]) {
+		if containsString(p.opts.Audience, audience) {
+			return nil
+		}
+	}
+	return fmt.Errorf("audience not accepted")
+}
+
+func verifySignature(key publicKey, alg string, digest, signature []byte) bool {
+	switch alg {
+	case algRS256:
+		return key.rsa != nil && rsa.VerifyPKCS1v15(key.rsa, crypto.SHA256, digest, signature) == nil
+	case algES256:
+		// JWS encodes ES256 signatures as the fixed-size concatenation of r and s.
+		if key.ecdsa == nil || len(signature) != 64 {
+			return false
+		}
+		r := new(big.Int).SetBytes(signature[:32])
+		s := new(big.Int).SetBytes(signature[32:])
+		return ecdsa.Verify(key.ecdsa, digest, r, s)
+	default:
+		return false
+	}
+}
+
+func decodeSegment(segment string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return decoder.Decode(v)
+}
+
+// numericDate converts a NumericDate claim, seconds since the epoch, into a time.
+func numericDate(value any) (time.Time, bool) {
+	number, ok := value.(json.Number)
+	if !ok {
+		return time.Time{}, false
+	}
+	seconds, err := number.Float64()
+	if err != nil || math.IsNaN(seconds) || math.IsInf(seconds, 0) {
+		return time.Time{}, false
+	}
+	whole, frac := math.Modf(seconds)
+	return time.Unix(int64(whole), int64(frac*float64(time.Second))), true
+}
+
+// claimString renders a claim for metadata; lists are joined with commas.
+func claimString(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	case []any:
+		return strings.Join(claimValues(v), ",")
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return ""
+		}
+		return string(data)
+	}
+}
+
+// claimValues splits a claim into the values restrictions and audiences match against.
+// Strings are split on whitespace so space-delimited scope claims work too.
+func claimValues(value any) []string {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case string:
+		return strings.Fields(v)
+	case []any:
+		out := make([]string, 0, len(v))
+		for _, item := range v {
+			if s := claimString(item); s != "" {
+				out = append(out, s)
+			}
+		}
+		return out
+	default:
+		if s := claimString(v); s != "" {
+			return []string{s}
+		}
+		return nil
+	}
+}
@@ -0,0 +1,334 @@
+// Package quota enforces the limits configured on client API key entries.
+// Request and stream limits are reserved before execution; token limits are charged
+// from usage records after each request completes.
+package quota
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/usage"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+)
+
+// Limit names reported in errors and usage snapshots.
+const (
+	LimitAllowedModels        = "allowed-models"
+	LimitAllowedPrefixes      = "allowed-prefixes"
+	LimitRequestsPerMinute    = "requests-per-minute"
+	LimitTokensPerDay         = "tokens-per-day"
+	LimitTokensPerMonth       = "tokens-per-month"
+	LimitMaxConcurrentStreams = "max-concurrent-streams"
+)
+
+// Error describes a rejected request.
+type Error struct {
+	// Limit names the limit that rejected the request.
+	Limit string
+	// Message is a client-facing description of the rejection.
+	Message string
+	// RetryAfter is the time until the limit resets; zero when unknown.
+	RetryAfter time.Duration
+}
+
+func (e *Error) Error() string {
+	if e == nil {
+		return ""
+	}
+	return e.Message
+}
+
+// StatusCode returns 403 for model restrictions and 429 for exhausted limits.
+func (e *Error) StatusCode() int {
+	if e != nil && (e.Limit == LimitAllowedModels || e.Limit == LimitAllowedPrefixes) {
+		return http.StatusForbidden
+	}
+	return http.StatusTooManyRequests
+}
+
+// Usage reports the current consumption of one key against its configured limits.
+type Usage struct {
+	APIKey             string             `json:"api-key"`
+	Name               string             `json:"name,omitempty"`
+	Limits             config.APIKeyLimit `json:"limits"`
+	RequestsThisMinute int                `json:"requests-this-minute"`
+	TokensToday        int64              `json:"tokens-today"`
+	TokensThisMonth    int64              `json:"tokens-this-month"`
+	ActiveStreams      int                `json:"active-streams"`
+}
+
+type keyState struct {
+	minute         time.Time
+	minuteRequests int
+	day            string
+	dayTokens      int64
+	month          string
+	monthTokens    int64
+	streams        int
+}
+
+// Limiter tracks per-key counters. Limits are passed in on every call so hot-reloaded
+// configuration applies immediately while counters survive reloads.
+type Limiter struct {
+	mu   sync.Mutex
+	keys map[string]*keyState
+	now  func() time.Time
+}
+
+var defaultLimiter = NewLimiter()
+
+func init() {
+	coreusage.RegisterPlugin(defaultLimiter)
+}
+
+// NewLimiter constructs an empty limiter.
+func NewLimiter() *Limiter {
+	return &Limiter{keys: make(map[string]*keyState), now: time.Now}
+}
+
+// Default returns the shared limiter fed by the usage pipeline.
+func Default() *Limiter { return defaultLimiter }
+
+// Acquire validates a request for model against the scopes and limits of entry and
+// reserves it. Streaming requests also take a concurrency slot; the returned release
+// must be called exactly once when the stream ends. Release is never nil.
+func (l *Limiter) Acquire(entry config.APIKey, model string, stream bool) (func(), *Error) {
+	noop := func() {}
+	if l == nil || entry.Key == "" {
+		return noop, nil
+	}
+	if errScope := CheckScope(entry, model); errScope != nil {
+		return noop, errScope
+	}
+	limit := entry.APIKeyLimit
+	if limit.IsZero() {
+		return noop, nil
+	}
+
+	now := l.now().UTC()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	state := l.stateLocked(entry.Key, now)
+
+	if limit.RequestsPerMinute > 0 && state.minuteRequests >= limit.RequestsPerMinute {
+		return noop, &Error{
+			Limit:      LimitRequestsPerMinute,
+			Message:    fmt.Sprintf("API key request rate limit exceeded (%d requests per minute)", limit.RequestsPerMinute),
+			RetryAfter: state.minute.Add(time.Minute).Sub(now),
+		}
+	}
+	if limit.TokensPerDay > 0 && state.dayTokens >= limit.TokensPerDay {
+		return noop, &Error{
+			Limit:      LimitTokensPerDay,
+			Message:    fmt.Sprintf("API key daily token quota exhausted (%d tokens per day)", limit.TokensPerDay),
+			RetryAfter: startOfDay(now).AddDate(0, 0, 1).Sub(now),
+		}
+	}
+	if limit.TokensPerMonth > 0 && state.monthTokens >= limit.TokensPerMonth {
+		return noop, &Error{
+			Limit:      LimitTokensPerMonth,
+			Message:    fmt.Sprintf("API key monthly token quota exhausted (%d tokens per month)", limit.TokensPerMonth),
+			RetryAfter: startOfMonth(now).AddDate(0, 1, 0).Sub(now),
+		}
+	}
+	if stream && limit.MaxConcurrentStreams > 0 && state.streams >= limit.MaxConcurrentStreams {
+		return noop, &Error{
+			Limit:   LimitMaxConcurrentStreams,
+			Message: fmt.Sprintf("API key concurrent stream limit reached (%d streams)", limit.MaxConcurrentStreams),
+		}
+	}
+
+	state.minuteRequests++
+	if !stream {
+		return noop, nil
+	}
+	state.streams++
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			l.mu.Lock()
+			if state.streams > 0 {
+				state.streams--
+			}
+			l.mu.Unlock()
+		})
+	}, nil
+}
+
+// CheckScope enforces the allowed-prefixes and allowed-models scopes of a key entry.
+// Model patterns match either the full model name or the name without its prefix.
+func CheckScope(entry config.APIKey, model string) *Error {
+	prefix, base := "", model
+	if idx := strings.Index(model, "/"); idx > 0 {
+		prefix, base = model[:idx], model[idx+1:]
+	}
+	if len(entry.AllowedPrefixes) > 0 {
+		allowed := false
+		for _, candidate := range entry.AllowedPrefixes {
+			if strings.EqualFold(candidate, prefix) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return &Error{
+				Limit:   LimitAllowedPrefixes,
+				Message: fmt.Sprintf("model %s does not use a provider prefix allowed for this API key", model),
+			}
+		}
+	}
+	if !modelAllowed(entry.AllowedModels, model) && !modelAllowed(entry.AllowedModels, base) {
+		return &Error{
+			Limit:   LimitAllowedModels,
+			Message: fmt.Sprintf("model %s is not allowed for this API key", model),
+		}
+	}
+	return nil
+}
+
+func modelAllowed(patterns []string, model string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	normalized := strings.ToLower(strings.TrimSpace(model))
+	for _, pattern := range patterns {
+		if util.MatchWildcard(pattern, normalized) {
+			return true
+		}
+	}
+	return false
+}
+
+// AddTokens charges tokens consumed at the given time to key.
+func (l *Limiter) AddTokens(key string, at time.Time, tokens int64) {
+	if l == nil || key == "" || tokens <= 0 {
+		return
+	}
+	now := l.now().UTC()
+	at = at.UTC()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	state := l.stateLocked(key, now)
+	if at.Format("2006-01") == state.month {
+		state.monthTokens += tokens
+		if at.Format("2006-01-02") == state.day {
+			state.dayTokens += tokens
+		}
+	}
+}
+
+// HandleUsage implements coreusage.Plugin by charging reported tokens to the client key.
+func (l *Limiter) HandleUsage(_ context.Context, record coreusage.Record) {
+	tokens := record.Detail.TotalTokens
+	if tokens == 0 {
+		tokens = record.Detail.InputTokens + record.Detail.OutputTokens + record.Detail.ReasoningTokens
+	}
+	at := record.RequestedAt
+	if at.IsZero() {
+		at = l.now()
+	}
+	l.AddTokens(record.APIKey, at, tokens)
+}
+
+// Restore seeds the token counters with month-to-date usage from a persistent store,
+// so quotas are not reset by a restart. Counters only ever grow: a key keeps its
+// in-memory totals when they already exceed the persisted ones. names maps the key
+// names recorded for named client keys back to the keys themselves.
+func (l *Limiter) Restore(ctx context.Context, store usage.Store, names map[string]string) error {
+	if l == nil || store == nil {
+		return nil
+	}
+	now := l.now().UTC()
+	records, err := store.Query(ctx, usage.QueryFilter{From: startOfMonth(now)})
+	if err != nil {
+		return err
+	}
+	today := now.Format("2006-01-02")
+	monthTotals := make(map[string]int64)
+	dayTotals := make(map[string]int64)
+	for _, record := range records {
+		key := record.APIKey
+		if mapped, ok := names[key]; ok {
+			key = mapped
+		}
+		if key == "" || record.Tokens.TotalTokens <= 0 {
+			continue
+		}
+		monthTotals[key] += record.Tokens.TotalTokens
+		if record.Timestamp.UTC().Format("2006-01-02") == today {
+			dayTotals[key] += record.Tokens.TotalTokens
+		}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for key, total := range monthTotals {
+		state := l.stateLocked(key, now)
+		state.monthTokens = max(state.monthTokens, total)
+		state.dayTokens = max(state.dayTokens, dayTotals[key])
+	}
+	return nil
+}
+
+// Usage returns the current counters of each key entry that has limits, in configuration order.
+func (l *Limiter) Usage(entries []config.APIKey) []Usage {
+	out := make([]Usage, 0, len(entries))
+	if l == nil {
+		return out
+	}
+	now := l.now().UTC()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, entry := range entries {
+		if entry.Key == "" || entry.APIKeyLimit.IsZero() {
+			continue
+		}
+		state := l.stateLocked(entry.Key, now)
+		out = append(out, Usage{
+			APIKey:             entry.Key,
+			Name:               entry.Name,
+			Limits:             entry.APIKeyLimit,
+			RequestsThisMinute: state.minuteRequests,
+			TokensToday:        state.dayTokens,
+			TokensThisMonth:    state.monthTokens,
+			ActiveStreams:      state.streams,
+		})
+	}
+	return out
+}
+
+// stateLocked returns the state for key with expired windows reset. Callers must hold l.mu.
+func (l *Limiter) stateLocked(key string, now time.Time) *keyState {
+	state, ok := l.keys[key]
+	if !ok {
+		state = &keyState{}
+		l.keys[key] = state
+	}
+	if minute := now.Truncate(time.Minute); !state.minute.Equal(minute) {
+		state.minute = minute
+		state.minuteRequests = 0
+	}
+	if day := now.Format("2006-01-02"); state.day != day {
+		state.day = day
+		state.dayTokens = 0
+	}
+	if month := now.Format("2006-01"); state.month != month {
+		state.month = month
+		state.monthTokens = 0
+	}
+	return state
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func startOfMonth(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
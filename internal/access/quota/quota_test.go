@@ -0,0 +1,115 @@
+package quota
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+func newTestLimiter(now *time.Time) *Limiter {
+	l := NewLimiter()
+	l.now = func() time.Time { return *now }
+	return l
+}
+
+func TestAcquireRequestsPerMinute(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 15, 0, time.UTC)
+	l := newTestLimiter(&now)
+	limit := config.APIKey{Key: "k", APIKeyLimit: config.APIKeyLimit{RequestsPerMinute: 2}}
+
+	for i := 0; i < 2; i++ {
+		if _, err := l.Acquire(limit, "m", false); err != nil {
+			t.Fatalf("request %d rejected: %v", i, err)
+		}
+	}
+	_, err := l.Acquire(limit, "m", false)
+	if err == nil || err.Limit != LimitRequestsPerMinute || err.StatusCode() != http.StatusTooManyRequests {
+		t.Fatalf("third request error = %+v, want requests-per-minute 429", err)
+	}
+	if err.RetryAfter != 45*time.Second {
+		t.Fatalf("retry after = %v, want 45s", err.RetryAfter)
+	}
+
+	now = now.Add(time.Minute)
+	if _, err = l.Acquire(limit, "m", false); err != nil {
+		t.Fatalf("request after window reset rejected: %v", err)
+	}
+}
+
+func TestAcquireTokenQuotas(t *testing.T) {
+	now := time.Date(2026, 3, 31, 23, 0, 0, 0, time.UTC)
+	l := newTestLimiter(&now)
+	limit := config.APIKey{Key: "k", APIKeyLimit: config.APIKeyLimit{TokensPerDay: 100, TokensPerMonth: 150}}
+
+	l.AddTokens("k", now, 100)
+	if _, err := l.Acquire(limit, "m", false); err == nil || err.Limit != LimitTokensPerDay {
+		t.Fatalf("error = %+v, want tokens-per-day", err)
+	}
+
+	now = time.Date(2026, 4, 1, 0, 30, 0, 0, time.UTC)
+	if _, err := l.Acquire(limit, "m", false); err != nil {
+		t.Fatalf("new month should reset counters: %v", err)
+	}
+	l.AddTokens("k", now, 99)
+	now = now.Add(24 * time.Hour)
+	l.AddTokens("k", now, 60)
+	if _, err := l.Acquire(limit, "m", false); err == nil || err.Limit != LimitTokensPerMonth {
+		t.Fatalf("error = %+v, want tokens-per-month", err)
+	}
+}
+
+func TestAcquireConcurrentStreams(t *testing.T) {
+	now := time.Now()
+	l := newTestLimiter(&now)
+	limit := config.APIKey{Key: "k", APIKeyLimit: config.APIKeyLimit{MaxConcurrentStreams: 1}}
+
+	release, err := l.Acquire(limit, "m", true)
+	if err != nil {
+		t.Fatalf("first stream rejected: %v", err)
+	}
+	if _, err = l.Acquire(limit, "m", true); err == nil || err.Limit != LimitMaxConcurrentStreams {
+		t.Fatalf("error = %+v, want max-concurrent-streams", err)
+	}
+	if _, err = l.Acquire(limit, "m", false); err != nil {
+		t.Fatalf("non-streaming request should not take a stream slot: %v", err)
+	}
+	release()
+	release()
+	if usage := l.Usage([]config.APIKey{limit}); usage[0].ActiveStreams != 0 {
+		t.Fatalf("active streams = %d, want 0 after release", usage[0].ActiveStreams)
+	}
+	if _, err = l.Acquire(limit, "m", true); err != nil {
+		t.Fatalf("stream after release rejected: %v", err)
+	}
+}
+
+func TestCheckScopeAllowedModels(t *testing.T) {
+	entry := config.APIKey{Key: "k", AllowedModels: []string{"gpt-5*", "claude-*-sonnet"}}
+	for _, model := range []string{"gpt-5", "GPT-5-codex", "claude-4-sonnet"} {
+		if err := CheckScope(entry, model); err != nil {
+			t.Fatalf("model %s rejected: %v", model, err)
+		}
+	}
+	err := CheckScope(entry, "gemini-2.5-pro")
+	if err == nil || err.StatusCode() != http.StatusForbidden {
+		t.Fatalf("error = %+v, want 403", err)
+	}
+}
+
+func TestCheckScope(t *testing.T) {
+	entry := config.APIKey{Key: "k", AllowedPrefixes: []string{"teamA"}, AllowedModels: []string{"gemini-*"}}
+	if err := CheckScope(entry, "teama/gemini-3-pro-preview"); err != nil {
+		t.Fatalf("prefixed model rejected: %v", err)
+	}
+	if err := CheckScope(entry, "gemini-3-pro-preview"); err == nil || err.Limit != LimitAllowedPrefixes {
+		t.Fatalf("error = %+v, want allowed-prefixes", err)
+	}
+	if err := CheckScope(entry, "teamA/gpt-5"); err == nil || err.Limit != LimitAllowedModels {
+		t.Fatalf("error = %+v, want allowed-models", err)
+	}
+	if err := CheckScope(config.APIKey{Key: "k"}, "anything"); err != nil {
+		t.Fatalf("unscoped key rejected: %v", err)
+	}
+}
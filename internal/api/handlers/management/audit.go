@@ -0,0 +1,269 @@
+package management
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/audit"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+)
+
+// managementCredentialKey stores the management secret a request authenticated with.
+const managementCredentialKey = "managementCredential"
+
+// Management secrets recorded in audit entries.
+const (
+	credentialLocalPassword = "local-password"
+	credentialEnvSecret     = "env-secret"
+	credentialSecretKey     = "secret-key"
+	// credentialPrincipalPrefix precedes the name of a management principal.
+	credentialPrincipalPrefix = "principal:"
+)
+
+const (
+	defaultAuditQueryLimit = 100
+	maxAuditQueryLimit     = 1000
+	// maxAuditErrorBodyBytes bounds the response body kept to report failures.
+	maxAuditErrorBodyBytes = 4096
+)
+
+// auditResponseWriter keeps the beginning of error responses for the audit entry.
+type auditResponseWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *auditResponseWriter) Write(data []byte) (int, error) {
+	if w.Status() >= http.StatusBadRequest && w.body.Len() < maxAuditErrorBodyBytes {
+		w.body.Write(data[:min(len(data), maxAuditErrorBodyBytes-w.body.Len())])
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *auditResponseWriter) WriteString(data string) (int, error) {
+	return w.Write([]byte(data))
+}
+
+// AuditMiddleware records mutating management requests in the audit log, with the
+// redacted changes they made to the config and the auth files. It must run after
+// Middleware so only authenticated requests are recorded.
+func (h *Handler) AuditMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		auditLog := audit.Default()
+		if auditLog == nil || !isMutatingMethod(c.Request.Method) {
+			c.Next()
+			return
+		}
+		configBefore := h.configSnapshot()
+		authsBefore := h.authSnapshot()
+		writer := &auditResponseWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+
+		c.Next()
+
+		status := writer.Status()
+		entry := audit.Entry{
+			Timestamp:  time.Now().UTC(),
+			RemoteAddr: c.ClientIP(),
+			Credential: c.GetString(managementCredentialKey),
+			Method:     c.Request.Method,
+			Route:      c.FullPath(),
+			Path:       c.Request.URL.RequestURI(),
+			Status:     status,
+			Outcome:    audit.OutcomeSuccess,
+		}
+		if status >= http.StatusBadRequest {
+			entry.Outcome = audit.OutcomeFailure
+			entry.Error = responseErrorMessage(writer.body.Bytes())
+		}
+		entry.Changes = append(audit.Diff("", configBefore, h.configSnapshot()), diffAuthSnapshots(authsBefore, h.authSnapshot())...)
+		if err := auditLog.Append(entry); err != nil {
+			log.Errorf("audit log: %v", err)
+		}
+	}
+}
+
+// GetAudit returns audit log entries, most recent first. Supported query parameters are
+// from and to (RFC 3339 or YYYY-MM-DD), method, route (substring of the route or path),
+// outcome (success or failure), remote-addr, credential (e.g. "principal:<name>") and
+// limit.
+func (h *Handler) GetAudit(c *gin.Context) {
+	auditLog := audit.Default()
+	if auditLog == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "audit log disabled"})
+		return
+	}
+	from, errFrom := parseUsageTime(c.Query("from"))
+	if errFrom != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid from"})
+		return
+	}
+	to, errTo := parseUsageTime(c.Query("to"))
+	if errTo != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid to"})
+		return
+	}
+	if !from.IsZero() && !to.IsZero() && !to.After(from) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "to must be after from"})
+		return
+	}
+	limit, errLimit := parseLimit(c.Query("limit"))
+	if errLimit != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit: " + errLimit.Error()})
+		return
+	}
+	if limit == 0 {
+		limit = defaultAuditQueryLimit
+	}
+	outcome := strings.ToLower(strings.TrimSpace(c.Query("outcome")))
+	if outcome != "" && outcome != audit.OutcomeSuccess && outcome != audit.OutcomeFailure {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "outcome must be success or failure"})
+		return
+	}
+
+	entries, err := auditLog.Query(c.Request.Context(), audit.Filter{
+		From:       from,
+		To:         to,
+		Method:     strings.TrimSpace(c.Query("method")),
+		Route:      strings.TrimSpace(c.Query("route")),
+		Outcome:    outcome,
+		RemoteAddr: strings.TrimSpace(c.Query("remote-addr")),
+		Credential: strings.TrimSpace(c.Query("credential")),
+		Limit:      min(limit, maxAuditQueryLimit),
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"entries":        entries,
+		"retention-days": auditLog.RetentionDays(),
+	})
+}
+
+func isMutatingMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	default:
+		return true
+	}
+}
+
+// configSnapshot decodes the current config into generic values for diffing.
+func (h *Handler) configSnapshot() map[string]any {
+	cfg := h.cfg
+	if cfg == nil {
+		return nil
+	}
+	data, err := yaml.Marshal(cfg)
+	if err != nil {
+		return nil
+	}
+	var out map[string]any
+	if err = yaml.Unmarshal(data, &out); err != nil {
+		return nil
+	}
+	return out
+}
+
+// authSnapshot captures the stored content and state of every auth, keyed by id.
+func (h *Handler) authSnapshot() map[string]map[string]any {
+	if h.authManager == nil {
+		return nil
+	}
+	out := make(map[string]map[string]any)
+	for _, auth := range h.authManager.List() {
+		if auth == nil {
+			continue
+		}
+		out[auth.ID] = authAuditFields(auth)
+	}
+	return out
+}
+
+func authAuditFields(auth *coreauth.Auth) map[string]any {
+	fields := map[string]any{
+		"provider": auth.Provider,
+		"disabled": auth.Disabled,
+		"status":   string(auth.Status),
+	}
+	if auth.Label != "" {
+		fields["label"] = auth.Label
+	}
+	if auth.Prefix != "" {
+		fields["prefix"] = auth.Prefix
+	}
+	if auth.StatusMessage != "" {
+		fields["status_message"] = auth.StatusMessage
+	}
+	if len(auth.Metadata) > 0 {
+		// Round trip through JSON so values compare like the stored file.
+		if data, err := json.Marshal(auth.Metadata); err == nil {
+			var metadata map[string]any
+			if json.Unmarshal(data, &metadata) == nil {
+				fields["metadata"] = metadata
+			}
+		}
+	}
+	return fields
+}
+
+// diffAuthSnapshots reports added, removed and changed auths under "auth-files[<id>]".
+func diffAuthSnapshots(before, after map[string]map[string]any) []audit.Change {
+	ids := make(map[string]struct{}, len(before)+len(after))
+	for id := range before {
+		ids[id] = struct{}{}
+	}
+	for id := range after {
+		ids[id] = struct{}{}
+	}
+	sorted := make([]string, 0, len(ids))
+	for id := range ids {
+		sorted = append(sorted, id)
+	}
+	sort.Strings(sorted)
+	var out []audit.Change
+	for _, id := range sorted {
+		var beforeAuth, afterAuth any
+		if fields, ok := before[id]; ok {
+			beforeAuth = fields
+		}
+		if fields, ok := after[id]; ok {
+			afterAuth = fields
+		}
+		if beforeAuth == nil || afterAuth == nil {
+			// Report whole records when an auth appears or disappears.
+			out = append(out, audit.Change{Path: "auth-files[" + id + "]", Before: audit.Redact(beforeAuth), After: audit.Redact(afterAuth)})
+			continue
+		}
+		out = append(out, audit.Diff("auth-files["+id+"]", beforeAuth, afterAuth)...)
+	}
+	return out
+}
+
+// responseErrorMessage extracts the error reported in a management JSON error body.
+func responseErrorMessage(body []byte) string {
+	var payload struct {
+		Error   string `json:"error"`
+		Message string `json:"message"`
+	}
+	if json.Unmarshal(body, &payload) != nil {
+		return strings.TrimSpace(string(body))
+	}
+	switch {
+	case payload.Error != "" && payload.Message != "":
+		return payload.Error + ": " + payload.Message
+	case payload.Error != "":
+		return payload.Error
+	default:
+		return payload.Message
+	}
+}
@@ -0,0 +1,70 @@
+package management
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/audit"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+func TestAuditMiddlewareRecordsRedactedChanges(t *testing.T) {
+	t.Setenv("MANAGEMENT_PASSWORD", "")
+	gin.SetMode(gin.TestMode)
+
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(configPath, []byte("port: 8317\n"), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	auditLog, err := audit.Open(filepath.Join(dir, "audit"), 0)
+	if err != nil {
+		t.Fatalf("open audit log: %v", err)
+	}
+	previous := audit.SetDefault(auditLog)
+	t.Cleanup(func() {
+		audit.SetDefault(previous)
+		_ = auditLog.Close()
+	})
+
+	h := NewHandler(&config.Config{}, configPath, nil)
+	router := gin.New()
+	router.Use(h.AuditMiddleware())
+	router.GET("/v0/management/api-keys", h.GetAPIKeys)
+	router.PUT("/v0/management/api-keys", h.PutAPIKeys)
+
+	for _, body := range []string{`["sk-audit-secret-1234"]`, `{"items":[]}`} {
+		req := httptest.NewRequest(http.MethodPut, "/v0/management/api-keys", strings.NewReader(body))
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/v0/management/api-keys", nil))
+
+	entries, err := auditLog.Query(context.Background(), audit.Filter{})
+	if err != nil {
+		t.Fatalf("query audit log: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("entries = %d, want 2 (GET must not be recorded)", len(entries))
+	}
+	failed, succeeded := entries[0], entries[1]
+	if failed.Outcome != audit.OutcomeFailure || failed.Status != http.StatusBadRequest || failed.Error != "invalid body" || len(failed.Changes) != 0 {
+		t.Fatalf("unexpected failed entry %+v", failed)
+	}
+	if succeeded.Outcome != audit.OutcomeSuccess || succeeded.Route != "/v0/management/api-keys" || succeeded.Method != http.MethodPut {
+		t.Fatalf("unexpected entry %+v", succeeded)
+	}
+	if len(succeeded.Changes) != 1 || succeeded.Changes[0].Path != "api-keys" {
+		t.Fatalf("changes = %+v, want api-keys", succeeded.Changes)
+	}
+	raw, _ := json.Marshal(succeeded)
+	if strings.Contains(string(raw), "sk-audit-secret-1234") {
+		t.Fatalf("audit entry leaks the api key: %s", raw)
+	}
+}
@@ -0,0 +1,67 @@
+package management
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/batch"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+)
+
+// ListBatches reports the progress of every queued, running or ended batch.
+func (h *Handler) ListBatches(c *gin.Context) {
+	manager := batch.Default()
+	if manager == nil {
+		c.JSON(http.StatusOK, gin.H{"enabled": false, "batches": []gin.H{}})
+		return
+	}
+	batches := manager.List()
+	items := make([]gin.H, 0, len(batches))
+	for _, b := range batches {
+		items = append(items, h.batchProgress(b))
+	}
+	c.JSON(http.StatusOK, gin.H{"enabled": true, "batches": items})
+}
+
+// GetBatch reports the progress of a single batch.
+func (h *Handler) GetBatch(c *gin.Context) {
+	manager := batch.Default()
+	if manager == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "batches are disabled"})
+		return
+	}
+	b, err := manager.Get(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "batch not found"})
+		return
+	}
+	c.JSON(http.StatusOK, h.batchProgress(b))
+}
+
+func (h *Handler) batchProgress(b *batch.Batch) gin.H {
+	apiKey := ""
+	if h.cfg != nil {
+		if entry, ok := util.APIKeyEntryByDigest(&h.cfg.SDKConfig, b.APIKeyDigest); ok {
+			apiKey = util.HideAPIKey(entry.Key)
+		}
+	}
+	progress := gin.H{
+		"id":         b.ID,
+		"format":     b.Format,
+		"endpoint":   b.Endpoint,
+		"api-key":    apiKey,
+		"status":     b.Status,
+		"counts":     b.Counts,
+		"total":      b.Counts.Total(),
+		"created-at": b.CreatedAt.Format(time.RFC3339),
+		"expires-at": b.ExpiresAt.Format(time.RFC3339),
+	}
+	if !b.CancelRequestedAt.IsZero() {
+		progress["cancel-requested-at"] = b.CancelRequestedAt.Format(time.RFC3339)
+	}
+	if !b.EndedAt.IsZero() {
+		progress["ended-at"] = b.EndedAt.Format(time.RFC3339)
+	}
+	return progress
+}
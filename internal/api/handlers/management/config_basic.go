@@ -13,6 +13,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
 	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
 	log "github.com/sirupsen/logrus"
 	"gopkg.in/yaml.v3"
@@ -286,6 +287,8 @@ func normalizeRoutingStrategy(strategy string) (string, bool) {
 		return "round-robin", true
 	case "fill-first", "fillfirst", "ff":
 		return "fill-first", true
+	case "weighted-health", "weightedhealth", "health":
+		return "weighted-health", true
 	default:
 		return "", false
 	}
@@ -317,6 +320,19 @@ func (h *Handler) PutRoutingStrategy(c *gin.Context) {
 	h.persist(c)
 }
 
+// GetRoutingHealth reports the per model health scores used by the weighted-health strategy.
+// Scores are recorded under every strategy.
+func (h *Handler) GetRoutingHealth(c *gin.Context) {
+	strategy, _ := normalizeRoutingStrategy(h.cfg.Routing.Strategy)
+	scores := []coreauth.HealthScore{}
+	if h.authManager != nil {
+		if recorded := h.authManager.HealthScores(); recorded != nil {
+			scores = recorded
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"strategy": strategy, "scores": scores})
+}
+
 // Proxy URL
 func (h *Handler) GetProxyURL(c *gin.Context) { c.JSON(200, gin.H{"proxy-url": h.cfg.ProxyURL}) }
 func (h *Handler) PutProxyURL(c *gin.Context) {
@@ -6,20 +6,25 @@ import (
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/access/quota"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
 )
 
-// Generic helpers for list[string]
-func (h *Handler) putStringList(c *gin.Context, set func([]string), after func()) {
+// api-keys
+func (h *Handler) GetAPIKeys(c *gin.Context) { c.JSON(200, gin.H{"api-keys": h.cfg.APIKeys}) }
+
+// PutAPIKeys replaces the key list. Entries may be plain strings or key objects carrying
+// metadata, scopes and limits.
+func (h *Handler) PutAPIKeys(c *gin.Context) {
 	data, err := c.GetRawData()
 	if err != nil {
 		c.JSON(400, gin.H{"error": "failed to read body"})
 		return
 	}
-	var arr []string
+	var arr []config.APIKey
 	if err = json.Unmarshal(data, &arr); err != nil {
 		var obj struct {
-			Items []string `json:"items"`
+			Items []config.APIKey `json:"items"`
 		}
 		if err2 := json.Unmarshal(data, &obj); err2 != nil || len(obj.Items) == 0 {
 			c.JSON(400, gin.H{"error": "invalid body"})
@@ -27,95 +32,76 @@ func (h *Handler) putStringList(c *gin.Context, set func([]string), after func()
 		}
 		arr = obj.Items
 	}
-	set(arr)
-	if after != nil {
-		after()
-	}
+	h.cfg.APIKeys = append([]config.APIKey(nil), arr...)
+	h.cfg.SanitizeAPIKeys()
 	h.persist(c)
 }
 
-func (h *Handler) patchStringList(c *gin.Context, target *[]string, after func()) {
+// PatchAPIKeys replaces the entry at index with value, or the entry whose key equals old
+// with new (appending new when old is absent). value and new may be strings or key objects.
+func (h *Handler) PatchAPIKeys(c *gin.Context) {
 	var body struct {
-		Old   *string `json:"old"`
-		New   *string `json:"new"`
-		Index *int    `json:"index"`
-		Value *string `json:"value"`
+		Old   *string        `json:"old"`
+		New   *config.APIKey `json:"new"`
+		Index *int           `json:"index"`
+		Value *config.APIKey `json:"value"`
 	}
 	if err := c.ShouldBindJSON(&body); err != nil {
 		c.JSON(400, gin.H{"error": "invalid body"})
 		return
 	}
-	if body.Index != nil && body.Value != nil && *body.Index >= 0 && *body.Index < len(*target) {
-		(*target)[*body.Index] = *body.Value
-		if after != nil {
-			after()
-		}
-		h.persist(c)
-		return
-	}
-	if body.Old != nil && body.New != nil {
-		for i := range *target {
-			if (*target)[i] == *body.Old {
-				(*target)[i] = *body.New
-				if after != nil {
-					after()
+	switch {
+	case body.Index != nil && body.Value != nil && *body.Index >= 0 && *body.Index < len(h.cfg.APIKeys):
+		h.cfg.APIKeys[*body.Index] = *body.Value
+	case body.New != nil:
+		replaced := false
+		if body.Old != nil {
+			for i := range h.cfg.APIKeys {
+				if h.cfg.APIKeys[i].Key == *body.Old {
+					h.cfg.APIKeys[i] = *body.New
+					replaced = true
+					break
 				}
-				h.persist(c)
-				return
 			}
 		}
-		*target = append(*target, *body.New)
-		if after != nil {
-			after()
+		if !replaced {
+			h.cfg.APIKeys = append(h.cfg.APIKeys, *body.New)
 		}
-		h.persist(c)
+	default:
+		c.JSON(400, gin.H{"error": "missing fields"})
 		return
 	}
-	c.JSON(400, gin.H{"error": "missing fields"})
+	h.cfg.SanitizeAPIKeys()
+	h.persist(c)
 }
 
-func (h *Handler) deleteFromStringList(c *gin.Context, target *[]string, after func()) {
+func (h *Handler) DeleteAPIKeys(c *gin.Context) {
 	if idxStr := c.Query("index"); idxStr != "" {
 		var idx int
 		_, err := fmt.Sscanf(idxStr, "%d", &idx)
-		if err == nil && idx >= 0 && idx < len(*target) {
-			*target = append((*target)[:idx], (*target)[idx+1:]...)
-			if after != nil {
-				after()
-			}
+		if err == nil && idx >= 0 && idx < len(h.cfg.APIKeys) {
+			h.cfg.APIKeys = append(h.cfg.APIKeys[:idx], h.cfg.APIKeys[idx+1:]...)
 			h.persist(c)
 			return
 		}
 	}
 	if val := strings.TrimSpace(c.Query("value")); val != "" {
-		out := make([]string, 0, len(*target))
-		for _, v := range *target {
-			if strings.TrimSpace(v) != val {
+		out := make([]config.APIKey, 0, len(h.cfg.APIKeys))
+		for _, v := range h.cfg.APIKeys {
+			if strings.TrimSpace(v.Key) != val {
 				out = append(out, v)
 			}
 		}
-		*target = out
-		if after != nil {
-			after()
-		}
+		h.cfg.APIKeys = out
 		h.persist(c)
 		return
 	}
 	c.JSON(400, gin.H{"error": "missing index or value"})
 }
 
-// api-keys
-func (h *Handler) GetAPIKeys(c *gin.Context) { c.JSON(200, gin.H{"api-keys": h.cfg.APIKeys}) }
-func (h *Handler) PutAPIKeys(c *gin.Context) {
-	h.putStringList(c, func(v []string) {
-		h.cfg.APIKeys = append([]string(nil), v...)
-	}, nil)
-}
-func (h *Handler) PatchAPIKeys(c *gin.Context) {
-	h.patchStringList(c, &h.cfg.APIKeys, func() {})
-}
-func (h *Handler) DeleteAPIKeys(c *gin.Context) {
-	h.deleteFromStringList(c, &h.cfg.APIKeys, func() {})
+// GetAPIKeyUsage reports current consumption against each key's configured limits.
+func (h *Handler) GetAPIKeyUsage(c *gin.Context) {
+	c.JSON(200, gin.H{"api-key-usage": quota.Default().Usage(h.cfg.APIKeys)})
 }
 
 // gemini-api-key: []GeminiKey
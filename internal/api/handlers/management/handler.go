@@ -3,6 +3,7 @@
 package management
 
 import (
+	"crypto/sha256"
 	"crypto/subtle"
 	"fmt"
 	"net/http"
@@ -48,6 +49,8 @@ type Handler struct {
 	envSecret           string
 	logDir              string
 	postAuthHook        coreauth.PostAuthHook
+	principalsMu        sync.Mutex
+	principalKeys       map[[sha256.Size]byte]string // key digest -> matched principal hash
 }
 
 // NewHandler creates a new management handler instance.
@@ -137,6 +140,7 @@ func (h *Handler) SetPostAuthHook(hook coreauth.PostAuthHook) {
 // Middleware enforces access control for management endpoints.
 // All requests (local and remote) require a valid management key.
 // Additionally, remote access requires allow-remote-management=true.
+// Keys of management principals are limited to the routes their roles allow.
 func (h *Handler) Middleware() gin.HandlerFunc {
 	const maxFailures = 5
 	const banDuration = 30 * time.Minute
@@ -152,10 +156,12 @@ func (h *Handler) Middleware() gin.HandlerFunc {
 		var (
 			allowRemote bool
 			secretHash  string
+			principals  []config.ManagementPrincipal
 		)
 		if cfg != nil {
 			allowRemote = cfg.RemoteManagement.AllowRemote
 			secretHash = cfg.RemoteManagement.SecretKey
+			principals = cfg.RemoteManagement.Principals
 		}
 		if h.allowRemoteOverride {
 			allowRemote = true
@@ -202,7 +208,7 @@ func (h *Handler) Middleware() gin.HandlerFunc {
 				h.attemptsMu.Unlock()
 			}
 		}
-		if secretHash == "" && envSecret == "" {
+		if secretHash == "" && envSecret == "" && len(principals) == 0 {
 			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "remote management key not set"})
 			return
 		}
@@ -232,6 +238,7 @@ func (h *Handler) Middleware() gin.HandlerFunc {
 		if localClient {
 			if lp := h.localPassword; lp != "" {
 				if subtle.ConstantTimeCompare([]byte(provided), []byte(lp)) == 1 {
+					c.Set(managementCredentialKey, credentialLocalPassword)
 					c.Next()
 					return
 				}
@@ -247,16 +254,26 @@ func (h *Handler) Middleware() gin.HandlerFunc {
 				}
 				h.attemptsMu.Unlock()
 			}
+			c.Set(managementCredentialKey, credentialEnvSecret)
 			c.Next()
 			return
 		}
 
+		credential := credentialSecretKey
 		if secretHash == "" || bcrypt.CompareHashAndPassword([]byte(secretHash), []byte(provided)) != nil {
-			if !localClient {
-				fail()
+			principal, ok := h.matchPrincipal(principals, provided)
+			if !ok {
+				if !localClient {
+					fail()
+				}
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid management key"})
+				return
 			}
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid management key"})
-			return
+			if !principalAllowed(principal.Roles, c.Request.Method, c.FullPath()) {
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "forbidden", "message": fmt.Sprintf("principal %s has no role allowing %s %s", principal.Name, c.Request.Method, c.FullPath())})
+				return
+			}
+			credential = credentialPrincipalPrefix + principal.Name
 		}
 
 		if !localClient {
@@ -268,6 +285,7 @@ func (h *Handler) Middleware() gin.HandlerFunc {
 			h.attemptsMu.Unlock()
 		}
 
+		c.Set(managementCredentialKey, credential)
 		c.Next()
 	}
 }
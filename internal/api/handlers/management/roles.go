@@ -0,0 +1,106 @@
+package management
+
+import (
+	"crypto/sha256"
+	"net/http"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const managementRoutePrefix = "/v0/management"
+
+// usageReadRoutes are the read-only routes open to the usage-read role. They expose
+// statistics, logs and credential status but no secrets.
+var usageReadRoutes = map[string]struct{}{
+	"/usage":                      {},
+	"/usage/export":               {},
+	"/usage/history":              {},
+	"/api-key-usage":              {},
+	"/batches":                    {},
+	"/batches/:id":                {},
+	"/logs":                       {},
+	"/request-error-logs":         {},
+	"/request-error-logs/:name":   {},
+	"/request-log-by-id/:id":      {},
+	"/routing/health":             {},
+	"/latest-version":             {},
+	"/auth-files":                 {},
+	"/auth-files/models":          {},
+	"/model-definitions/:channel": {},
+}
+
+// managementRouteRoles returns the roles allowed to call route, the registered route
+// pattern, with method. admin is implied. Routes not classified otherwise read or change
+// the configuration and need config-write.
+func managementRouteRoles(method, route string) []string {
+	route = strings.TrimPrefix(route, managementRoutePrefix)
+	read := method == http.MethodGet || method == http.MethodHead
+	switch {
+	case strings.HasSuffix(route, "-auth-url") || route == "/oauth-callback" || route == "/get-auth-status":
+		return []string{config.ManagementRoleOAuthLogin}
+	case route == "/auth-files" || strings.HasPrefix(route, "/auth-files/") || route == "/vertex/import":
+		if _, ok := usageReadRoutes[route]; ok && read {
+			return []string{config.ManagementRoleUsageRead, config.ManagementRoleAuthFilesWrite}
+		}
+		// Downloads return credential contents, so they need the write role too.
+		return []string{config.ManagementRoleAuthFilesWrite}
+	}
+	if _, ok := usageReadRoutes[route]; ok && read {
+		return []string{config.ManagementRoleUsageRead}
+	}
+	return []string{config.ManagementRoleConfigWrite}
+}
+
+// principalAllowed reports whether roles grant access to route with method.
+func principalAllowed(roles []string, method, route string) bool {
+	allowed := managementRouteRoles(method, route)
+	for _, role := range roles {
+		if role == config.ManagementRoleAdmin {
+			return true
+		}
+		for _, candidate := range allowed {
+			if role == candidate {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// matchPrincipal returns the principal whose key is provided. Successful matches are
+// cached by key digest so repeated requests skip the bcrypt comparisons; the cache is
+// keyed by the stored hash so rotated keys stop matching.
+func (h *Handler) matchPrincipal(principals []config.ManagementPrincipal, provided string) (config.ManagementPrincipal, bool) {
+	if len(principals) == 0 || provided == "" {
+		return config.ManagementPrincipal{}, false
+	}
+	digest := sha256.Sum256([]byte(provided))
+	h.principalsMu.Lock()
+	cachedHash, cached := h.principalKeys[digest]
+	h.principalsMu.Unlock()
+	if cached {
+		for _, principal := range principals {
+			if principal.SecretKey == cachedHash {
+				return principal, true
+			}
+		}
+	}
+	for _, principal := range principals {
+		if bcrypt.CompareHashAndPassword([]byte(principal.SecretKey), []byte(provided)) != nil {
+			continue
+		}
+		h.principalsMu.Lock()
+		if h.principalKeys == nil || len(h.principalKeys) >= maxCachedPrincipalKeys {
+			h.principalKeys = make(map[[sha256.Size]byte]string)
+		}
+		h.principalKeys[digest] = principal.SecretKey
+		h.principalsMu.Unlock()
+		return principal, true
+	}
+	return config.ManagementPrincipal{}, false
+}
+
+// maxCachedPrincipalKeys bounds the principal key cache.
+const maxCachedPrincipalKeys = 256
@@ -0,0 +1,97 @@
+package management
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestManagementRouteRoles(t *testing.T) {
+	cases := []struct {
+		roles  []string
+		method string
+		route  string
+		want   bool
+	}{
+		{[]string{config.ManagementRoleUsageRead}, http.MethodGet, "/v0/management/usage", true},
+		{[]string{config.ManagementRoleUsageRead}, http.MethodGet, "/v0/management/auth-files", true},
+		{[]string{config.ManagementRoleUsageRead}, http.MethodPost, "/v0/management/usage/import", false},
+		{[]string{config.ManagementRoleUsageRead}, http.MethodGet, "/v0/management/config", false},
+		{[]string{config.ManagementRoleUsageRead}, http.MethodGet, "/v0/management/auth-files/download", false},
+		{[]string{config.ManagementRoleAuthFilesWrite}, http.MethodPatch, "/v0/management/auth-files/status", true},
+		{[]string{config.ManagementRoleAuthFilesWrite}, http.MethodGet, "/v0/management/auth-files/download", true},
+		{[]string{config.ManagementRoleAuthFilesWrite}, http.MethodPut, "/v0/management/api-keys", false},
+		{[]string{config.ManagementRoleConfigWrite}, http.MethodPut, "/v0/management/config.yaml", true},
+		{[]string{config.ManagementRoleConfigWrite}, http.MethodGet, "/v0/management/codex-auth-url", false},
+		{[]string{config.ManagementRoleOAuthLogin}, http.MethodGet, "/v0/management/codex-auth-url", true},
+		{[]string{config.ManagementRoleOAuthLogin}, http.MethodPost, "/v0/management/oauth-callback", true},
+		{[]string{config.ManagementRoleAdmin}, http.MethodDelete, "/v0/management/logs", true},
+	}
+	for _, tc := range cases {
+		if got := principalAllowed(tc.roles, tc.method, tc.route); got != tc.want {
+			t.Fatalf("principalAllowed(%v, %s %s) = %v, want %v", tc.roles, tc.method, tc.route, got, tc.want)
+		}
+	}
+}
+
+func TestMiddlewareEnforcesPrincipalRoles(t *testing.T) {
+	t.Setenv("MANAGEMENT_PASSWORD", "")
+	gin.SetMode(gin.TestMode)
+
+	cfg := &config.Config{}
+	cfg.RemoteManagement.SecretKey = "admin-secret"
+	cfg.RemoteManagement.Principals = []config.ManagementPrincipal{
+		{Name: "support", SecretKey: "support-secret", Roles: []string{"Usage-Read"}},
+		{Name: "broken", SecretKey: "broken-secret", Roles: []string{"superuser"}},
+	}
+	if err := cfg.SanitizeManagementPrincipals(); err != nil {
+		t.Fatalf("SanitizeManagementPrincipals() error = %v", err)
+	}
+	if len(cfg.RemoteManagement.Principals) != 1 || cfg.RemoteManagement.Principals[0].SecretKey == "support-secret" {
+		t.Fatalf("principals not sanitized: %+v", cfg.RemoteManagement.Principals)
+	}
+	hashed, err := hashForTest("admin-secret")
+	if err != nil {
+		t.Fatalf("hash admin key: %v", err)
+	}
+	cfg.RemoteManagement.SecretKey = hashed
+
+	h := NewHandler(cfg, "", nil)
+	router := gin.New()
+	router.Use(h.Middleware())
+	ok := func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{"credential": c.GetString(managementCredentialKey)}) }
+	router.GET("/v0/management/usage", ok)
+	router.PUT("/v0/management/api-keys", ok)
+
+	cases := []struct {
+		key    string
+		method string
+		path   string
+		want   int
+	}{
+		{"support-secret", http.MethodGet, "/v0/management/usage", http.StatusOK},
+		{"support-secret", http.MethodGet, "/v0/management/usage", http.StatusOK},
+		{"support-secret", http.MethodPut, "/v0/management/api-keys", http.StatusForbidden},
+		{"admin-secret", http.MethodPut, "/v0/management/api-keys", http.StatusOK},
+		{"broken-secret", http.MethodGet, "/v0/management/usage", http.StatusUnauthorized},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest(tc.method, tc.path, nil)
+		req.RemoteAddr = "127.0.0.1:1234"
+		req.Header.Set("Authorization", "Bearer "+tc.key)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		if rec.Code != tc.want {
+			t.Fatalf("%s %s with %s = %d, want %d: %s", tc.method, tc.path, tc.key, rec.Code, tc.want, rec.Body.String())
+		}
+	}
+}
+
+func hashForTest(secret string) (string, error) {
+	hashed, err := bcrypt.GenerateFromPassword([]byte(secret), bcrypt.MinCost)
+	return string(hashed), err
+}
@@ -3,6 +3,7 @@ package management
 import (
 	"encoding/json"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -20,7 +21,8 @@ type usageImportPayload struct {
 	Usage   usage.StatisticsSnapshot `json:"usage"`
 }
 
-// GetUsageStatistics returns the in-memory request statistics snapshot.
+// GetUsageStatistics returns the in-memory request statistics snapshot and the spend of
+// client keys against their budgets.
 func (h *Handler) GetUsageStatistics(c *gin.Context) {
 	var snapshot usage.StatisticsSnapshot
 	if h != nil && h.usageStats != nil {
@@ -29,6 +31,8 @@ func (h *Handler) GetUsageStatistics(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"usage":           snapshot,
 		"failed_requests": snapshot.FailureCount,
+		"total_cost":      snapshot.TotalCost,
+		"budgets":         usage.GetBudgetTracker().Status(),
 	})
 }
 
@@ -77,3 +81,97 @@ func (h *Handler) ImportUsageStatistics(c *gin.Context) {
 		"failed_requests": snapshot.FailureCount,
 	})
 }
+
+// GetUsageHistory aggregates usage records over a time range.
+// Query parameters: from/to (RFC3339 or YYYY-MM-DD, to is exclusive), group-by (comma separated
+// month, day, hour, api_key, model, auth_index, provider) and the api-key, model, auth-index and
+// provider filters. Records come from the persistent store when configured, otherwise from memory.
+func (h *Handler) GetUsageHistory(c *gin.Context) {
+	from, errFrom := parseUsageTime(c.Query("from"))
+	if errFrom != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid from"})
+		return
+	}
+	to, errTo := parseUsageTime(c.Query("to"))
+	if errTo != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid to"})
+		return
+	}
+	if !from.IsZero() && !to.IsZero() && !to.After(from) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "to must be after from"})
+		return
+	}
+
+	var groupBy []string
+	for _, part := range strings.Split(c.Query("group-by"), ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		if !usage.ValidGroupBy(part) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "unsupported group-by: " + part})
+			return
+		}
+		groupBy = append(groupBy, part)
+	}
+	groupBy = usage.NormalizeGroupBy(groupBy)
+
+	query := usage.AggregateQuery{
+		QueryFilter: usage.QueryFilter{
+			From:      from,
+			To:        to,
+			APIKey:    strings.TrimSpace(c.Query("api-key")),
+			Model:     strings.TrimSpace(c.Query("model")),
+			AuthIndex: strings.TrimSpace(c.Query("auth-index")),
+			Provider:  strings.TrimSpace(c.Query("provider")),
+		},
+		GroupBy: groupBy,
+	}
+
+	var buckets []usage.AggregateBucket
+	store, backend := usage.GetPersistence().Store()
+	if store != nil {
+		var err error
+		buckets, err = usage.Aggregate(c.Request.Context(), store, query)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	} else {
+		backend = "memory"
+		var records []usage.StoredRecord
+		if h != nil && h.usageStats != nil {
+			records = h.usageStats.Records()
+		}
+		buckets = usage.AggregateRecords(records, query)
+	}
+	if buckets == nil {
+		buckets = []usage.AggregateBucket{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"backend":  backend,
+		"from":     formatUsageTime(from),
+		"to":       formatUsageTime(to),
+		"group_by": groupBy,
+		"buckets":  buckets,
+	})
+}
+
+func parseUsageTime(raw string) (time.Time, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return time.Time{}, nil
+	}
+	if ts, err := time.Parse(time.RFC3339, raw); err == nil {
+		return ts, nil
+	}
+	return time.Parse("2006-01-02", raw)
+}
+
+func formatUsageTime(ts time.Time) any {
+	if ts.IsZero() {
+		return nil
+	}
+	return ts.UTC().Format(time.RFC3339)
+}
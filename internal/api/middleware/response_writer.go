@@ -12,6 +12,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/policy"
 )
 
 const requestBodyOverrideContextKey = "REQUEST_BODY_OVERRIDE"
@@ -329,15 +330,35 @@ func (w *ResponseWriterWrapper) cloneHeaders() map[string][]string {
 }
 
 func (w *ResponseWriterWrapper) extractAPIRequest(c *gin.Context) []byte {
-	apiRequest, isExist := c.Get("API_REQUEST")
+	var data []byte
+	if apiRequest, isExist := c.Get("API_REQUEST"); isExist {
+		data, _ = apiRequest.([]byte)
+	}
+	return appendContentPolicyHits(c, data)
+}
+
+// appendContentPolicyHits adds the content policy hits of the request, if any, as a
+// section after the upstream requests.
+func appendContentPolicyHits(c *gin.Context, data []byte) []byte {
+	value, isExist := c.Get(policy.RequestLogKey)
 	if !isExist {
-		return nil
+		return data
 	}
-	data, ok := apiRequest.([]byte)
-	if !ok || len(data) == 0 {
-		return nil
+	hits, ok := value.([]policy.Hit)
+	if !ok || len(hits) == 0 {
+		return data
 	}
-	return data
+	var buf bytes.Buffer
+	buf.Write(data)
+	if len(data) > 0 && !bytes.HasSuffix(data, []byte("\n")) {
+		buf.WriteByte('\n')
+	}
+	buf.WriteString("=== CONTENT POLICY ===\n")
+	for _, hit := range hits {
+		buf.WriteString(hit.String())
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes()
 }
 
 func (w *ResponseWriterWrapper) extractAPIResponse(c *gin.Context) []byte {
@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/policy"
 )
 
 func TestExtractRequestBodyPrefersOverride(t *testing.T) {
@@ -41,3 +42,19 @@ func TestExtractRequestBodySupportsStringOverride(t *testing.T) {
 		t.Fatalf("request body = %q, want %q", string(body), "override-as-string")
 	}
 }
+
+func TestExtractAPIRequestAppendsContentPolicyHits(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+
+	wrapper := &ResponseWriterWrapper{}
+	c.Set("API_REQUEST", []byte("=== API REQUEST 1 ===\n{}"))
+	c.Set(policy.RequestLogKey, []policy.Hit{{Rule: "secrets", Detector: "api-key", Action: "mask", Direction: "request", Count: 2}})
+
+	got := string(wrapper.extractAPIRequest(c))
+	want := "=== API REQUEST 1 ===\n{}\n=== CONTENT POLICY ===\nrequest rule=secrets detector=api-key action=mask matches=2\n"
+	if got != want {
+		t.Fatalf("api request = %q, want %q", got, want)
+	}
+}
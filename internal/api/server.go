@@ -28,6 +28,7 @@ import (
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/managementasset"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/metrics"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/usage"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
 	sdkaccess "github.com/router-for-me/CLIProxyAPI/v6/sdk/access"
@@ -262,6 +263,10 @@ func NewServer(cfg *config.Config, authManager *auth.Manager, accessManager *sdk
 	}
 	managementasset.SetCurrentConfig(cfg)
 	auth.SetQuotaCooldownDisabled(cfg.DisableCooling)
+	metrics.SetEnabled(cfg.Metrics.Enable)
+	if authManager != nil {
+		metrics.Default().BindManager(authManager)
+	}
 	// Initialize management handler
 	s.mgmt = managementHandlers.NewHandler(cfg, configFilePath, authManager)
 	if optionState.localPassword != "" {
@@ -324,6 +329,7 @@ func NewServer(cfg *config.Config, authManager *auth.Manager, accessManager *sdk
 // It defines the endpoints and associates them with their respective handlers.
 func (s *Server) setupRoutes() {
 	s.engine.GET("/management.html", s.serveManagementControlPanel)
+	s.engine.GET("/metrics", metrics.Handler(metrics.Default().Registry(), func() *config.Config { return s.cfg }))
 	openaiHandlers := openai.NewOpenAIAPIHandler(s.handlers)
 	geminiHandlers := gemini.NewGeminiAPIHandler(s.handlers)
 	geminiCLIHandlers := gemini.NewGeminiCLIAPIHandler(s.handlers)
@@ -337,11 +343,32 @@ func (s *Server) setupRoutes() {
 		v1.GET("/models", s.unifiedModelsHandler(openaiHandlers, claudeCodeHandlers))
 		v1.POST("/chat/completions", openaiHandlers.ChatCompletions)
 		v1.POST("/completions", openaiHandlers.Completions)
+		v1.POST("/embeddings", openaiHandlers.Embeddings)
+		v1.POST("/images/generations", openaiHandlers.ImageGenerations)
+		v1.POST("/images/edits", openaiHandlers.ImageEdits)
 		v1.POST("/messages", claudeCodeHandlers.ClaudeMessages)
 		v1.POST("/messages/count_tokens", claudeCodeHandlers.ClaudeCountTokens)
+		v1.POST("/messages/batches", claudeCodeHandlers.CreateMessageBatch)
+		v1.GET("/messages/batches", claudeCodeHandlers.ListMessageBatches)
+		v1.GET("/messages/batches/:id", claudeCodeHandlers.GetMessageBatch)
+		v1.POST("/messages/batches/:id/cancel", claudeCodeHandlers.CancelMessageBatch)
+		v1.DELETE("/messages/batches/:id", claudeCodeHandlers.DeleteMessageBatch)
+		v1.GET("/messages/batches/:id/results", claudeCodeHandlers.MessageBatchResults)
+		v1.POST("/batches", openaiHandlers.CreateBatch)
+		v1.GET("/batches", openaiHandlers.ListBatches)
+		v1.GET("/batches/:id", openaiHandlers.GetBatch)
+		v1.POST("/batches/:id/cancel", openaiHandlers.CancelBatch)
+		v1.POST("/files", unifiedFilesHandler(openaiHandlers.UploadFile, claudeCodeHandlers.UploadFile))
+		v1.GET("/files", unifiedFilesHandler(openaiHandlers.ListFiles, claudeCodeHandlers.ListFiles))
+		v1.GET("/files/:id", unifiedFilesHandler(openaiHandlers.GetFile, claudeCodeHandlers.GetFile))
+		v1.GET("/files/:id/content", unifiedFilesHandler(openaiHandlers.GetFileContent, claudeCodeHandlers.GetFileContent))
+		v1.DELETE("/files/:id", unifiedFilesHandler(openaiHandlers.DeleteFile, claudeCodeHandlers.DeleteFile))
 		v1.GET("/responses", openaiResponsesHandlers.ResponsesWebsocket)
 		v1.POST("/responses", openaiResponsesHandlers.Responses)
 		v1.POST("/responses/compact", openaiResponsesHandlers.Compact)
+		v1.GET("/responses/:id", openaiResponsesHandlers.GetResponse)
+		v1.DELETE("/responses/:id", openaiResponsesHandlers.DeleteResponse)
+		v1.GET("/responses/:id/input_items", openaiResponsesHandlers.ResponseInputItems)
 	}
 
 	// Gemini compatible API routes
@@ -524,10 +551,12 @@ func (s *Server) registerManagementRoutes() {
 	log.Info("management routes registered after secret key configuration")
 
 	mgmt := s.engine.Group("/v0/management")
-	mgmt.Use(s.managementAvailabilityMiddleware(), s.mgmt.Middleware())
+	mgmt.Use(s.managementAvailabilityMiddleware(), s.mgmt.Middleware(), s.mgmt.AuditMiddleware())
 	{
+		mgmt.GET("/audit", s.mgmt.GetAudit)
 		mgmt.GET("/usage", s.mgmt.GetUsageStatistics)
 		mgmt.GET("/usage/export", s.mgmt.ExportUsageStatistics)
+		mgmt.GET("/usage/history", s.mgmt.GetUsageHistory)
 		mgmt.POST("/usage/import", s.mgmt.ImportUsageStatistics)
 		mgmt.GET("/config", s.mgmt.GetConfig)
 		mgmt.GET("/config.yaml", s.mgmt.GetConfigYAML)
@@ -573,6 +602,9 @@ func (s *Server) registerManagementRoutes() {
 		mgmt.PUT("/api-keys", s.mgmt.PutAPIKeys)
 		mgmt.PATCH("/api-keys", s.mgmt.PatchAPIKeys)
 		mgmt.DELETE("/api-keys", s.mgmt.DeleteAPIKeys)
+		mgmt.GET("/api-key-usage", s.mgmt.GetAPIKeyUsage)
+		mgmt.GET("/batches", s.mgmt.ListBatches)
+		mgmt.GET("/batches/:id", s.mgmt.GetBatch)
 
 		mgmt.GET("/gemini-api-key", s.mgmt.GetGeminiKeys)
 		mgmt.PUT("/gemini-api-key", s.mgmt.PutGeminiKeys)
@@ -629,6 +661,7 @@ func (s *Server) registerManagementRoutes() {
 		mgmt.GET("/routing/strategy", s.mgmt.GetRoutingStrategy)
 		mgmt.PUT("/routing/strategy", s.mgmt.PutRoutingStrategy)
 		mgmt.PATCH("/routing/strategy", s.mgmt.PutRoutingStrategy)
+		mgmt.GET("/routing/health", s.mgmt.GetRoutingHealth)
 
 		mgmt.GET("/claude-api-key", s.mgmt.GetClaudeKeys)
 		mgmt.PUT("/claude-api-key", s.mgmt.PutClaudeKeys)
@@ -825,6 +858,19 @@ func (s *Server) unifiedModelsHandler(openaiHandler *openai.OpenAIAPIHandler, cl
 	}
 }
 
+// unifiedFilesHandler serves the /v1/files endpoints in the Anthropic Files API shape for
+// clients that send an anthropic-version header and in the OpenAI shape otherwise. Both
+// shapes share the same file store.
+func unifiedFilesHandler(openaiHandler, claudeHandler gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetHeader("anthropic-version") != "" {
+			claudeHandler(c)
+			return
+		}
+		openaiHandler(c)
+	}
+}
+
 // Start begins listening for and serving HTTP or HTTPS requests.
 // It's a blocking call and will only return on an unrecoverable error.
 //
@@ -949,6 +995,10 @@ func (s *Server) UpdateClients(cfg *config.Config) {
 		usage.SetStatisticsEnabled(cfg.UsageStatisticsEnabled)
 	}
 
+	if oldCfg == nil || oldCfg.Metrics.Enable != cfg.Metrics.Enable {
+		metrics.SetEnabled(cfg.Metrics.Enable)
+	}
+
 	if s.requestLogger != nil && (oldCfg == nil || oldCfg.ErrorLogsMaxFiles != cfg.ErrorLogsMaxFiles) {
 		if setter, ok := s.requestLogger.(interface{ SetErrorLogsMaxFiles(int) }); ok {
 			setter.SetErrorLogsMaxFiles(cfg.ErrorLogsMaxFiles)
@@ -30,7 +30,7 @@ func newTestServer(t *testing.T) *Server {
 
 	cfg := &proxyconfig.Config{
 		SDKConfig: sdkconfig.SDKConfig{
-			APIKeys: []string{"test-key"},
+			APIKeys: proxyconfig.PlainAPIKeys("test-key"),
 		},
 		Port:                   0,
 		AuthDir:                authDir,
@@ -0,0 +1,331 @@
+// Package audit keeps an append-only log of management API mutations. Entries are JSON
+// lines partitioned into one file per UTC day so retention can drop whole files.
+package audit
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	filePrefix = "audit-"
+	fileSuffix = ".jsonl"
+	fileLayout = "2006-01-02"
+)
+
+// Entry outcomes.
+const (
+	OutcomeSuccess = "success"
+	OutcomeFailure = "failure"
+)
+
+// Entry records one mutating management request.
+type Entry struct {
+	Timestamp  time.Time `json:"timestamp"`
+	RemoteAddr string    `json:"remote_addr"`
+	// Credential names the management secret the request authenticated with, or the
+	// principal as "principal:<name>".
+	Credential string `json:"credential,omitempty"`
+	Method     string `json:"method"`
+	// Route is the registered route pattern and Path the requested path with its query.
+	Route   string `json:"route"`
+	Path    string `json:"path"`
+	Status  int    `json:"status"`
+	Outcome string `json:"outcome"`
+	// Error carries the error message returned to the caller of a failed request.
+	Error string `json:"error,omitempty"`
+	// Changes lists the redacted config and auth file values changed by the request.
+	Changes []Change `json:"changes,omitempty"`
+}
+
+// Filter narrows queried entries. Empty fields match everything; a zero From/To leaves
+// that side unbounded.
+type Filter struct {
+	From       time.Time
+	To         time.Time
+	Method     string
+	Route      string
+	Outcome    string
+	RemoteAddr string
+	Credential string
+	// Limit keeps only the most recent entries when positive.
+	Limit int
+}
+
+// Matches reports whether entry satisfies the filter. Route matches as a substring of the
+// route pattern or path.
+func (f Filter) Matches(entry Entry) bool {
+	if !f.From.IsZero() && entry.Timestamp.Before(f.From) {
+		return false
+	}
+	if !f.To.IsZero() && !entry.Timestamp.Before(f.To) {
+		return false
+	}
+	if f.Method != "" && !strings.EqualFold(entry.Method, f.Method) {
+		return false
+	}
+	if f.Route != "" && !strings.Contains(entry.Route, f.Route) && !strings.Contains(entry.Path, f.Route) {
+		return false
+	}
+	if f.Outcome != "" && entry.Outcome != f.Outcome {
+		return false
+	}
+	if f.RemoteAddr != "" && entry.RemoteAddr != f.RemoteAddr {
+		return false
+	}
+	if f.Credential != "" && entry.Credential != f.Credential {
+		return false
+	}
+	return true
+}
+
+// Log appends entries to daily files in a directory and prunes files older than the
+// retention period.
+type Log struct {
+	dir       string
+	retention int
+	now       func() time.Time
+
+	mu      sync.Mutex
+	current *os.File
+	day     string
+}
+
+// Open prepares dir for the audit log. retentionDays <= 0 keeps entries forever.
+func Open(dir string, retentionDays int) (*Log, error) {
+	dir = strings.TrimSpace(dir)
+	if dir == "" {
+		return nil, fmt.Errorf("audit log: directory is required")
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("audit log: create directory: %w", err)
+	}
+	return &Log{dir: dir, retention: retentionDays, now: time.Now}, nil
+}
+
+// Dir returns the directory holding the log files.
+func (l *Log) Dir() string {
+	if l == nil {
+		return ""
+	}
+	return l.dir
+}
+
+// RetentionDays returns the retention period in days; 0 keeps entries forever.
+func (l *Log) RetentionDays() int {
+	if l == nil || l.retention < 0 {
+		return 0
+	}
+	return l.retention
+}
+
+// Append writes entry to the file of its day. Opening a new day prunes expired files.
+func (l *Log) Append(entry Entry) error {
+	if l == nil {
+		return fmt.Errorf("audit log: not initialized")
+	}
+	if entry.Timestamp.IsZero() {
+		entry.Timestamp = l.now()
+	}
+	entry.Timestamp = entry.Timestamp.UTC()
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("audit log: encode entry: %w", err)
+	}
+	line = append(line, '\n')
+	day := entry.Timestamp.Format(fileLayout)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.current == nil || l.day != day {
+		if l.current != nil {
+			_ = l.current.Close()
+			l.current = nil
+		}
+		f, errOpen := os.OpenFile(l.path(day), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+		if errOpen != nil {
+			return fmt.Errorf("audit log: open file: %w", errOpen)
+		}
+		l.current = f
+		l.day = day
+		if errPrune := l.pruneLocked(); errPrune != nil {
+			log.Warnf("audit log: %v", errPrune)
+		}
+	}
+	if _, err = l.current.Write(line); err != nil {
+		return fmt.Errorf("audit log: write entry: %w", err)
+	}
+	return nil
+}
+
+// Query returns the entries matching filter, most recent first.
+func (l *Log) Query(ctx context.Context, filter Filter) ([]Entry, error) {
+	if l == nil {
+		return nil, fmt.Errorf("audit log: not initialized")
+	}
+	days, err := l.days()
+	if err != nil {
+		return nil, err
+	}
+	fromDay, toDay := "", ""
+	if !filter.From.IsZero() {
+		fromDay = filter.From.UTC().Format(fileLayout)
+	}
+	if !filter.To.IsZero() {
+		toDay = filter.To.UTC().Format(fileLayout)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	out := make([]Entry, 0)
+	// Walk the newest files first so a limit can stop early.
+	for i := len(days) - 1; i >= 0; i-- {
+		day := days[i]
+		if (fromDay != "" && day < fromDay) || (toDay != "" && day > toDay) {
+			continue
+		}
+		if errCtx := ctx.Err(); errCtx != nil {
+			return nil, errCtx
+		}
+		entries, errRead := readFile(l.path(day), filter)
+		if errRead != nil {
+			return nil, errRead
+		}
+		sort.SliceStable(entries, func(a, b int) bool { return entries[a].Timestamp.After(entries[b].Timestamp) })
+		out = append(out, entries...)
+		if filter.Limit > 0 && len(out) >= filter.Limit {
+			return out[:filter.Limit], nil
+		}
+	}
+	return out, nil
+}
+
+// Prune deletes the files older than the retention period.
+func (l *Log) Prune() error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.pruneLocked()
+}
+
+func (l *Log) pruneLocked() error {
+	if l.retention <= 0 {
+		return nil
+	}
+	days, err := l.days()
+	if err != nil {
+		return err
+	}
+	cutoff := l.now().UTC().AddDate(0, 0, -l.retention).Format(fileLayout)
+	var errs []error
+	for _, day := range days {
+		if day >= cutoff || day == l.day {
+			continue
+		}
+		if errRemove := os.Remove(l.path(day)); errRemove != nil && !errors.Is(errRemove, os.ErrNotExist) {
+			errs = append(errs, fmt.Errorf("remove expired file: %w", errRemove))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Close releases the open file.
+func (l *Log) Close() error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.current == nil {
+		return nil
+	}
+	err := l.current.Close()
+	l.current = nil
+	l.day = ""
+	return err
+}
+
+func (l *Log) path(day string) string {
+	return filepath.Join(l.dir, filePrefix+day+fileSuffix)
+}
+
+// days lists the days that have a log file, in ascending order.
+func (l *Log) days() ([]string, error) {
+	entries, err := os.ReadDir(l.dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("audit log: list files: %w", err)
+	}
+	days := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, filePrefix) || !strings.HasSuffix(name, fileSuffix) {
+			continue
+		}
+		day := strings.TrimSuffix(strings.TrimPrefix(name, filePrefix), fileSuffix)
+		if _, errParse := time.Parse(fileLayout, day); errParse != nil {
+			continue
+		}
+		days = append(days, day)
+	}
+	sort.Strings(days)
+	return days, nil
+}
+
+func readFile(path string, filter Filter) ([]Entry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("audit log: open file: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	out := make([]Entry, 0)
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var entry Entry
+		if errDecode := json.Unmarshal(line, &entry); errDecode != nil {
+			// A torn final line after a crash must not hide the rest of the day.
+			continue
+		}
+		if filter.Matches(entry) {
+			out = append(out, entry)
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, fmt.Errorf("audit log: read file: %w", err)
+	}
+	return out, nil
+}
+
+var defaultLog atomic.Pointer[Log]
+
+// Default returns the process-wide audit log, or nil when auditing is disabled.
+func Default() *Log { return defaultLog.Load() }
+
+// SetDefault installs the process-wide audit log and returns the previous one, which the
+// caller should close.
+func SetDefault(l *Log) *Log { return defaultLog.Swap(l) }
@@ -0,0 +1,97 @@
+package audit
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestLogAppendQueryAndPrune(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	l, err := Open(dir, 7)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer func() { _ = l.Close() }()
+	l.now = func() time.Time { return now }
+
+	expired := filepath.Join(dir, filePrefix+"2026-03-01"+fileSuffix)
+	if err = os.WriteFile(expired, []byte(`{"method":"PUT"}`+"\n"), 0o600); err != nil {
+		t.Fatalf("write expired file: %v", err)
+	}
+
+	entries := []Entry{
+		{Timestamp: now.Add(-26 * time.Hour), Method: "PUT", Route: "/v0/management/api-keys", Outcome: OutcomeSuccess, RemoteAddr: "10.0.0.1"},
+		{Timestamp: now.Add(-time.Hour), Method: "DELETE", Route: "/v0/management/auth-files", Outcome: OutcomeFailure, RemoteAddr: "10.0.0.2"},
+		{Timestamp: now, Method: "PUT", Route: "/v0/management/config.yaml", Outcome: OutcomeSuccess, RemoteAddr: "10.0.0.1"},
+	}
+	for _, entry := range entries {
+		if err = l.Append(entry); err != nil {
+			t.Fatalf("Append() error = %v", err)
+		}
+	}
+	if _, err = os.Stat(expired); !os.IsNotExist(err) {
+		t.Fatalf("expired file not pruned: %v", err)
+	}
+
+	got, err := l.Query(context.Background(), Filter{})
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if len(got) != 3 || got[0].Route != "/v0/management/config.yaml" || got[2].Route != "/v0/management/api-keys" {
+		t.Fatalf("Query() = %+v, want newest first", got)
+	}
+
+	got, _ = l.Query(context.Background(), Filter{Method: "put", RemoteAddr: "10.0.0.1", Limit: 1})
+	if len(got) != 1 || got[0].Route != "/v0/management/config.yaml" {
+		t.Fatalf("filtered Query() = %+v", got)
+	}
+	got, _ = l.Query(context.Background(), Filter{Route: "auth-files", Outcome: OutcomeFailure})
+	if len(got) != 1 || got[0].Method != "DELETE" {
+		t.Fatalf("route Query() = %+v", got)
+	}
+	got, _ = l.Query(context.Background(), Filter{From: now.Add(-2 * time.Hour)})
+	if len(got) != 2 {
+		t.Fatalf("from Query() returned %d entries, want 2", len(got))
+	}
+}
+
+func TestDiffMasksSensitiveValues(t *testing.T) {
+	before := map[string]any{
+		"api-keys": []any{"sk-live-1234567890"},
+		"debug":    false,
+		"remote-management": map[string]any{
+			"allow-remote": false,
+			"secret-key":   "old-secret-value",
+		},
+	}
+	after := map[string]any{
+		"api-keys": []any{"sk-live-1234567890", "sk-live-abcdefghij"},
+		"debug":    false,
+		"remote-management": map[string]any{
+			"allow-remote": true,
+			"secret-key":   "new-secret-value",
+		},
+		"proxy-url": "socks5://proxy:1080",
+	}
+	changes := Diff("", before, after)
+	want := []Change{
+		{Path: "api-keys", Before: []any{"sk-l...7890"}, After: []any{"sk-l...7890", "sk-l...ghij"}},
+		{Path: "proxy-url", After: "socks5://proxy:1080"},
+		{Path: "remote-management.allow-remote", Before: false, After: true},
+		{Path: "remote-management.secret-key", Before: "old-...alue", After: "new-...alue"},
+	}
+	if len(changes) != len(want) {
+		t.Fatalf("Diff() = %+v, want %+v", changes, want)
+	}
+	for i := range want {
+		got, exp := changes[i], want[i]
+		if got.Path != exp.Path || !reflect.DeepEqual(got.Before, exp.Before) || !reflect.DeepEqual(got.After, exp.After) {
+			t.Fatalf("change %d = %+v, want %+v", i, got, exp)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package audit
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+)
+
+// Change is one value changed by a request. Before is omitted for added values and
+// After for removed ones. Values under sensitive keys are masked.
+type Change struct {
+	Path   string `json:"path"`
+	Before any    `json:"before,omitempty"`
+	After  any    `json:"after,omitempty"`
+}
+
+// sensitiveKeyParts mark map keys whose string values are masked.
+var sensitiveKeyParts = []string{"key", "secret", "password", "token", "cookie", "authorization", "credential", "session"}
+
+// Diff compares two snapshots decoded from YAML or JSON and returns the changed values
+// with their dotted paths below prefix, sorted by path. Maps are compared key by key;
+// other values, lists included, are reported whole.
+func Diff(prefix string, before, after any) []Change {
+	var out []Change
+	diffValue(prefix, false, before, after, &out)
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Path < out[j].Path })
+	return out
+}
+
+func diffValue(path string, sensitive bool, before, after any, out *[]Change) {
+	beforeMap, beforeIsMap := before.(map[string]any)
+	afterMap, afterIsMap := after.(map[string]any)
+	if beforeIsMap && afterIsMap {
+		keys := make(map[string]struct{}, len(beforeMap)+len(afterMap))
+		for key := range beforeMap {
+			keys[key] = struct{}{}
+		}
+		for key := range afterMap {
+			keys[key] = struct{}{}
+		}
+		for key := range keys {
+			child := key
+			if path != "" {
+				child = path + "." + key
+			}
+			diffValue(child, sensitive || isSensitiveKey(key), beforeMap[key], afterMap[key], out)
+		}
+		return
+	}
+	if reflect.DeepEqual(before, after) {
+		return
+	}
+	*out = append(*out, Change{Path: path, Before: redact(before, sensitive), After: redact(after, sensitive)})
+}
+
+// Redact masks the string values of v stored under sensitive keys.
+func Redact(v any) any {
+	return redact(v, false)
+}
+
+func redact(v any, sensitive bool) any {
+	switch value := v.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(value))
+		for key, item := range value {
+			out[key] = redact(item, sensitive || isSensitiveKey(key))
+		}
+		return out
+	case []any:
+		out := make([]any, len(value))
+		for i, item := range value {
+			out[i] = redact(item, sensitive)
+		}
+		return out
+	case string:
+		if sensitive && value != "" {
+			return util.HideAPIKey(value)
+		}
+		return value
+	default:
+		return value
+	}
+}
+
+func isSensitiveKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, part := range sensitiveKeyParts {
+		if strings.Contains(key, part) {
+			return true
+		}
+	}
+	return false
+}
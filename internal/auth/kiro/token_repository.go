@@ -12,6 +12,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/authcrypt"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -119,7 +120,7 @@ func (r *FileTokenRepository) UpdateToken(token *Token) error {
 
 	// 读取现有文件内容
 	existingData := make(map[string]any)
-	if data, err := os.ReadFile(filePath); err == nil {
+	if data, err := authcrypt.ReadFile(filePath); err == nil {
 		_ = json.Unmarshal(data, &existingData)
 	}
 
@@ -155,6 +156,11 @@ func (r *FileTokenRepository) UpdateToken(token *Token) error {
 		return fmt.Errorf("token repository: marshal failed: %w", err)
 	}
 
+	// 按 auth-encryption 配置加密
+	if raw, err = authcrypt.Seal(raw); err != nil {
+		return fmt.Errorf("token repository: encrypt failed: %w", err)
+	}
+
 	// 原子写入：先写入临时文件，再重命名
 	tmpPath := filePath + ".tmp"
 	if err := os.WriteFile(tmpPath, raw, 0o600); err != nil {
@@ -171,7 +177,7 @@ func (r *FileTokenRepository) UpdateToken(token *Token) error {
 
 // readTokenFile 从文件读取 token
 func (r *FileTokenRepository) readTokenFile(path string) (*Token, error) {
-	data, err := os.ReadFile(path)
+	data, err := authcrypt.ReadFile(path)
 	if err != nil {
 		return nil, err
 	}
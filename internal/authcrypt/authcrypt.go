@@ -0,0 +1,238 @@
+// Package authcrypt encrypts auth files at rest. A file is sealed into a JSON envelope that
+// holds the content encrypted with AES-256-GCM under a random per-file data key, and the data
+// key wrapped by a key encryption key from a KeyProvider. Content that is not an envelope is
+// returned unchanged, so plaintext files written before encryption was enabled keep loading.
+package authcrypt
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+// envelopeVersion marks sealed content; it doubles as the field that identifies an envelope.
+const envelopeVersion = "v1"
+
+var envelopeMarker = []byte(`"cpa_encrypted"`)
+
+// ErrNoKey is returned when sealed content is read without a configured cipher.
+var ErrNoKey = errors.New("authcrypt: auth file is encrypted but auth-encryption has no keys configured")
+
+type envelope struct {
+	Version string `json:"cpa_encrypted"`
+	KeyID   string `json:"kid"`
+	DataKey []byte `json:"dek"`
+	Data    []byte `json:"data"`
+}
+
+// Cipher seals and opens auth file content. A nil Cipher leaves plaintext untouched and
+// rejects sealed content.
+type Cipher struct {
+	provider KeyProvider
+	encrypt  bool
+}
+
+// NewCipher returns a cipher that seals new content with keys from provider.
+func NewCipher(provider KeyProvider) *Cipher {
+	if provider == nil {
+		return nil
+	}
+	return &Cipher{provider: provider, encrypt: true}
+}
+
+// New builds the cipher described by cfg. It returns nil when no keys are configured.
+// Keys configured while Enable is false only decrypt, so content is written back as plaintext.
+func New(cfg config.AuthEncryptionConfig) (*Cipher, error) {
+	if len(cfg.Keys) == 0 {
+		if cfg.Enable {
+			return nil, fmt.Errorf("authcrypt: auth-encryption is enabled but no keys are configured")
+		}
+		return nil, nil
+	}
+	keys, err := LoadKeys(cfg.Keys)
+	if err != nil {
+		return nil, err
+	}
+	ring, err := NewKeyring(keys...)
+	if err != nil {
+		return nil, err
+	}
+	return &Cipher{provider: ring, encrypt: cfg.Enable}, nil
+}
+
+// Encrypts reports whether the cipher seals new content.
+func (c *Cipher) Encrypts() bool { return c != nil && c.encrypt }
+
+// KeyID returns the id of the key sealing new content, or "" when the cipher does not encrypt.
+func (c *Cipher) KeyID() string {
+	if !c.Encrypts() {
+		return ""
+	}
+	return c.provider.KeyID()
+}
+
+// Seal encrypts plaintext into an envelope. It returns plaintext unchanged when the cipher
+// does not encrypt.
+func (c *Cipher) Seal(plaintext []byte) ([]byte, error) {
+	if !c.Encrypts() {
+		return plaintext, nil
+	}
+	dek := make([]byte, KeySize)
+	if _, err := rand.Read(dek); err != nil {
+		return nil, fmt.Errorf("authcrypt: generate data key: %w", err)
+	}
+	keyID := c.provider.KeyID()
+	wrapped, err := c.provider.WrapKey(context.Background(), dek)
+	if err != nil {
+		return nil, fmt.Errorf("authcrypt: wrap data key: %w", err)
+	}
+	aead, err := newGCM(dek)
+	if err != nil {
+		return nil, fmt.Errorf("authcrypt: %w", err)
+	}
+	data, err := seal(aead, plaintext, []byte(keyID))
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(envelope{Version: envelopeVersion, KeyID: keyID, DataKey: wrapped, Data: data})
+}
+
+// Open decrypts an envelope. Content that is not an envelope is returned unchanged.
+func (c *Cipher) Open(data []byte) ([]byte, error) {
+	env, ok := parseEnvelope(data)
+	if !ok {
+		return data, nil
+	}
+	if c == nil {
+		return nil, ErrNoKey
+	}
+	if env.Version != envelopeVersion {
+		return nil, fmt.Errorf("authcrypt: unsupported envelope version %q", env.Version)
+	}
+	dek, err := c.provider.UnwrapKey(context.Background(), env.KeyID, env.DataKey)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newGCM(dek)
+	if err != nil {
+		return nil, fmt.Errorf("authcrypt: %w", err)
+	}
+	plaintext, err := open(aead, env.Data, []byte(env.KeyID))
+	if err != nil {
+		return nil, fmt.Errorf("authcrypt: decrypt auth file: %w", err)
+	}
+	return plaintext, nil
+}
+
+// Current opens data and reports whether it is already stored the way the cipher would
+// write it: sealed with the current key, or plaintext when the cipher does not encrypt.
+// Stores use it to skip rewriting unchanged content that needs no re-encryption.
+func (c *Cipher) Current(data []byte) ([]byte, bool) {
+	env, sealed := parseEnvelope(data)
+	if !sealed {
+		return data, !c.Encrypts()
+	}
+	plaintext, err := c.Open(data)
+	if err != nil {
+		return nil, false
+	}
+	return plaintext, c.Encrypts() && env.KeyID == c.provider.KeyID()
+}
+
+// IsSealed reports whether data is an envelope.
+func IsSealed(data []byte) bool {
+	_, ok := parseEnvelope(data)
+	return ok
+}
+
+func parseEnvelope(data []byte) (envelope, bool) {
+	var env envelope
+	if !bytes.Contains(data, envelopeMarker) {
+		return env, false
+	}
+	if err := json.Unmarshal(data, &env); err != nil || env.Version == "" {
+		return env, false
+	}
+	return env, true
+}
+
+var defaultCipher atomic.Pointer[Cipher]
+
+// Default returns the process-wide cipher, or nil when auth encryption is not configured.
+func Default() *Cipher { return defaultCipher.Load() }
+
+// SetDefault installs the process-wide cipher used by the token stores; nil disables it.
+func SetDefault(c *Cipher) { defaultCipher.Store(c) }
+
+// Seal seals plaintext with the default cipher.
+func Seal(plaintext []byte) ([]byte, error) { return Default().Seal(plaintext) }
+
+// Open opens data with the default cipher.
+func Open(data []byte) ([]byte, error) { return Default().Open(data) }
+
+// Current checks data against the default cipher; see Cipher.Current.
+func Current(data []byte) ([]byte, bool) { return Default().Current(data) }
+
+// ReadFile reads an auth file and opens it with the default cipher.
+func ReadFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Open(data)
+}
+
+// WriteFile seals data with the default cipher and writes it to path through a temporary
+// file, so readers never observe a partially written envelope.
+func WriteFile(path string, data []byte, perm os.FileMode) error {
+	sealed, err := Seal(data)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmpName := tmp.Name()
+	if _, err = tmp.Write(sealed); err == nil {
+		err = tmp.Chmod(perm)
+	}
+	if errClose := tmp.Close(); err == nil {
+		err = errClose
+	}
+	if err == nil {
+		err = os.Rename(tmpName, path)
+	}
+	if err != nil {
+		_ = os.Remove(tmpName)
+	}
+	return err
+}
+
+// SealFile rewrites the auth file at path with the default cipher unless it is already
+// current. Stores call it after token storages write plaintext JSON themselves.
+func SealFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if len(data) == 0 {
+		return nil
+	}
+	if _, ok := Current(data); ok {
+		return nil
+	}
+	plaintext, err := Open(data)
+	if err != nil {
+		return err
+	}
+	return WriteFile(path, plaintext, 0o600)
+}
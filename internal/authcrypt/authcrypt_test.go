@@ -0,0 +1,131 @@
+package authcrypt
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+func testKey(id string, fill byte) Key {
+	return Key{ID: id, Secret: bytes.Repeat([]byte{fill}, KeySize)}
+}
+
+func newTestCipher(t *testing.T, keys ...Key) *Cipher {
+	t.Helper()
+	ring, err := NewKeyring(keys...)
+	if err != nil {
+		t.Fatalf("NewKeyring: %v", err)
+	}
+	return NewCipher(ring)
+}
+
+func TestCipher_SealOpenRoundTrip(t *testing.T) {
+	c := newTestCipher(t, testKey("k1", 1))
+	plaintext := []byte(`{"type":"claude","refresh_token":"rt-secret"}`)
+
+	sealed, err := c.Seal(plaintext)
+	if err != nil {
+		t.Fatalf("Seal: %v", err)
+	}
+	if bytes.Contains(sealed, []byte("rt-secret")) || !IsSealed(sealed) {
+		t.Fatalf("sealed content leaks plaintext or is not an envelope: %s", sealed)
+	}
+	opened, err := c.Open(sealed)
+	if err != nil || !bytes.Equal(opened, plaintext) {
+		t.Fatalf("Open = %s, %v; want %s", opened, err, plaintext)
+	}
+	if current, ok := c.Current(sealed); !ok || !bytes.Equal(current, plaintext) {
+		t.Fatalf("Current = %s, %v; want sealed content to be current", current, ok)
+	}
+
+	tampered := bytes.Replace(sealed, []byte(`"kid":"k1"`), []byte(`"kid":"k2"`), 1)
+	if _, err = c.Open(tampered); err == nil {
+		t.Fatal("Open accepted an envelope with a rewritten key id")
+	}
+}
+
+func TestCipher_LegacyPlaintextPassesThrough(t *testing.T) {
+	plaintext := []byte(`{"type":"gemini","token":{"access_token":"at"}}`)
+	c := newTestCipher(t, testKey("k1", 1))
+
+	for _, cipher := range []*Cipher{nil, c} {
+		opened, err := cipher.Open(plaintext)
+		if err != nil || !bytes.Equal(opened, plaintext) {
+			t.Fatalf("Open(plaintext) = %s, %v", opened, err)
+		}
+	}
+	if _, ok := c.Current(plaintext); ok {
+		t.Fatal("plaintext reported current while encryption is enabled")
+	}
+	if _, ok := (*Cipher)(nil).Current(plaintext); !ok {
+		t.Fatal("plaintext reported stale without encryption")
+	}
+
+	sealed, _ := c.Seal(plaintext)
+	if _, err := (*Cipher)(nil).Open(sealed); !errors.Is(err, ErrNoKey) {
+		t.Fatalf("Open without keys = %v, want ErrNoKey", err)
+	}
+}
+
+func TestCipher_KeyRotation(t *testing.T) {
+	oldCipher := newTestCipher(t, testKey("old", 1))
+	sealed, err := oldCipher.Seal([]byte(`{"api_key":"secret"}`))
+	if err != nil {
+		t.Fatalf("Seal: %v", err)
+	}
+
+	rotated := newTestCipher(t, testKey("new", 2), testKey("old", 1))
+	opened, ok := rotated.Current(sealed)
+	if ok || string(opened) != `{"api_key":"secret"}` {
+		t.Fatalf("Current after rotation = %s, %v; want readable and stale", opened, ok)
+	}
+	resealed, err := rotated.Seal(opened)
+	if err != nil {
+		t.Fatalf("Seal: %v", err)
+	}
+	if _, ok = rotated.Current(resealed); !ok {
+		t.Fatal("resealed content is not current")
+	}
+	if _, err = oldCipher.Open(resealed); err == nil {
+		t.Fatal("retired keyring opened content sealed with the new key")
+	}
+}
+
+func TestNew_LoadsKeysAndDecryptOnlyMode(t *testing.T) {
+	secret := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{7}, KeySize))
+	t.Setenv("AUTHCRYPT_TEST_KEY", secret)
+	keyFile := filepath.Join(t.TempDir(), "key")
+	if err := os.WriteFile(keyFile, []byte(base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{8}, KeySize))+"\n"), 0o600); err != nil {
+		t.Fatalf("write key file: %v", err)
+	}
+	keys := []config.AuthEncryptionKey{{ID: "env", Env: "AUTHCRYPT_TEST_KEY"}, {ID: "file", File: keyFile}}
+
+	c, err := New(config.AuthEncryptionConfig{Enable: true, Keys: keys})
+	if err != nil || c.KeyID() != "env" {
+		t.Fatalf("New = %v, %v; want cipher with key env", c, err)
+	}
+	sealed, _ := c.Seal([]byte(`{}`))
+
+	decryptOnly, err := New(config.AuthEncryptionConfig{Keys: keys})
+	if err != nil || decryptOnly.Encrypts() {
+		t.Fatalf("New without enable = %v, %v; want decrypt-only cipher", decryptOnly, err)
+	}
+	if opened, errOpen := decryptOnly.Open(sealed); errOpen != nil || string(opened) != `{}` {
+		t.Fatalf("decrypt-only Open = %s, %v", opened, errOpen)
+	}
+	if out, _ := decryptOnly.Seal([]byte(`{}`)); string(out) != `{}` {
+		t.Fatalf("decrypt-only Seal = %s, want plaintext", out)
+	}
+
+	if _, err = New(config.AuthEncryptionConfig{Enable: true}); err == nil {
+		t.Fatal("New accepted enable without keys")
+	}
+	if _, err = New(config.AuthEncryptionConfig{Enable: true, Keys: []config.AuthEncryptionKey{{ID: "x", Env: "AUTHCRYPT_TEST_MISSING"}}}); err == nil {
+		t.Fatal("New accepted a missing environment variable")
+	}
+}
@@ -0,0 +1,140 @@
+package authcrypt
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+// KeySize is the length of key encryption keys and data keys in bytes (AES-256).
+const KeySize = 32
+
+// KeyProvider wraps and unwraps the per-file data keys. Implementations may keep the key
+// encryption keys locally or delegate to a key management service.
+type KeyProvider interface {
+	// KeyID identifies the key that wraps new data keys.
+	KeyID() string
+	// WrapKey encrypts dek with the key named by KeyID.
+	WrapKey(ctx context.Context, dek []byte) ([]byte, error)
+	// UnwrapKey decrypts a data key wrapped by the key named keyID.
+	UnwrapKey(ctx context.Context, keyID string, wrapped []byte) ([]byte, error)
+}
+
+// Key is a named key encryption key.
+type Key struct {
+	ID     string
+	Secret []byte
+}
+
+// Keyring is a KeyProvider backed by local keys. The first key wraps new data keys; the
+// others only unwrap, so files sealed before a key rotation stay readable.
+type Keyring struct {
+	primary string
+	keys    map[string]cipher.AEAD
+}
+
+// NewKeyring builds a keyring from keys, the first of which becomes the primary key.
+func NewKeyring(keys ...Key) (*Keyring, error) {
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("authcrypt: keyring needs at least one key")
+	}
+	ring := &Keyring{primary: keys[0].ID, keys: make(map[string]cipher.AEAD, len(keys))}
+	for _, key := range keys {
+		if key.ID == "" {
+			return nil, fmt.Errorf("authcrypt: key id is empty")
+		}
+		if _, exists := ring.keys[key.ID]; exists {
+			return nil, fmt.Errorf("authcrypt: duplicate key id %q", key.ID)
+		}
+		if len(key.Secret) != KeySize {
+			return nil, fmt.Errorf("authcrypt: key %q must be %d bytes, got %d", key.ID, KeySize, len(key.Secret))
+		}
+		aead, err := newGCM(key.Secret)
+		if err != nil {
+			return nil, fmt.Errorf("authcrypt: key %q: %w", key.ID, err)
+		}
+		ring.keys[key.ID] = aead
+	}
+	return ring, nil
+}
+
+// KeyID returns the id of the primary key.
+func (k *Keyring) KeyID() string { return k.primary }
+
+// WrapKey seals dek with the primary key. The nonce is prepended to the result.
+func (k *Keyring) WrapKey(_ context.Context, dek []byte) ([]byte, error) {
+	return seal(k.keys[k.primary], dek, []byte(k.primary))
+}
+
+// UnwrapKey opens a data key wrapped by the key named keyID.
+func (k *Keyring) UnwrapKey(_ context.Context, keyID string, wrapped []byte) ([]byte, error) {
+	aead, ok := k.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("authcrypt: unknown key %q", keyID)
+	}
+	dek, err := open(aead, wrapped, []byte(keyID))
+	if err != nil {
+		return nil, fmt.Errorf("authcrypt: unwrap data key with %q: %w", keyID, err)
+	}
+	return dek, nil
+}
+
+// LoadKeys reads the configured keys from their environment variables or files.
+func LoadKeys(keys []config.AuthEncryptionKey) ([]Key, error) {
+	out := make([]Key, 0, len(keys))
+	for _, key := range keys {
+		var encoded string
+		switch {
+		case key.Env != "":
+			value, ok := os.LookupEnv(key.Env)
+			if !ok {
+				return nil, fmt.Errorf("authcrypt: key %q: environment variable %s is not set", key.ID, key.Env)
+			}
+			encoded = value
+		default:
+			data, err := os.ReadFile(key.File)
+			if err != nil {
+				return nil, fmt.Errorf("authcrypt: key %q: %w", key.ID, err)
+			}
+			encoded = string(data)
+		}
+		secret, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+		if err != nil {
+			return nil, fmt.Errorf("authcrypt: key %q is not valid base64: %w", key.ID, err)
+		}
+		out = append(out, Key{ID: key.ID, Secret: secret})
+	}
+	return out, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal encrypts plaintext with a random nonce and returns nonce||ciphertext.
+func seal(aead cipher.AEAD, plaintext, additional []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("authcrypt: generate nonce: %w", err)
+	}
+	return aead.Seal(nonce, nonce, plaintext, additional), nil
+}
+
+func open(aead cipher.AEAD, data, additional []byte) ([]byte, error) {
+	if len(data) < aead.NonceSize() {
+		return nil, fmt.Errorf("ciphertext too short")
+	}
+	nonce, ciphertext := data[:aead.NonceSize()], data[aead.NonceSize():]
+	return aead.Open(nil, nonce, ciphertext, additional)
+}
@@ -0,0 +1,166 @@
+// Package batch emulates the Anthropic Message Batches and OpenAI Batch APIs. Submitted
+// requests are queued on disk and executed in the background with bounded concurrency, so
+// batches survive restarts and resume where they stopped.
+package batch
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"sync/atomic"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+)
+
+// Format is the API shape a batch was submitted with.
+type Format string
+
+const (
+	// FormatAnthropic is a batch created through /v1/messages/batches.
+	FormatAnthropic Format = "anthropic"
+	// FormatOpenAI is a batch created through /v1/batches.
+	FormatOpenAI Format = "openai"
+)
+
+// Status is the processing state of a batch.
+type Status string
+
+const (
+	StatusInProgress Status = "in_progress"
+	// StatusCanceling marks a batch whose cancellation was requested while requests are
+	// still running.
+	StatusCanceling Status = "canceling"
+	StatusEnded     Status = "ended"
+)
+
+// Outcome is the final state of a single request.
+type Outcome string
+
+const (
+	OutcomeSucceeded Outcome = "succeeded"
+	OutcomeErrored   Outcome = "errored"
+	OutcomeCanceled  Outcome = "canceled"
+	OutcomeExpired   Outcome = "expired"
+)
+
+var (
+	// ErrNotFound reports an unknown batch id.
+	ErrNotFound = errors.New("batch not found")
+	// ErrNotEnded reports an operation that needs a batch to have ended.
+	ErrNotEnded = errors.New("batch has not ended")
+)
+
+// Request is one queued request of a batch.
+type Request struct {
+	CustomID string `json:"custom_id"`
+	// Body is the request payload in the format of the batch endpoint.
+	Body json.RawMessage `json:"body"`
+}
+
+// Result is the outcome of one request.
+type Result struct {
+	CustomID string  `json:"custom_id"`
+	Outcome  Outcome `json:"outcome"`
+	// StatusCode is the HTTP status the request would have received synchronously.
+	StatusCode int `json:"status_code,omitempty"`
+	// Body is the response payload on success and the error payload otherwise.
+	Body json.RawMessage `json:"body,omitempty"`
+}
+
+// Counts tallies the requests of a batch by state.
+type Counts struct {
+	Processing int `json:"processing"`
+	Succeeded  int `json:"succeeded"`
+	Errored    int `json:"errored"`
+	Canceled   int `json:"canceled"`
+	Expired    int `json:"expired"`
+}
+
+// Total returns the number of requests in the batch.
+func (c Counts) Total() int {
+	return c.Processing + c.Succeeded + c.Errored + c.Canceled + c.Expired
+}
+
+func (c *Counts) add(outcome Outcome) {
+	switch outcome {
+	case OutcomeSucceeded:
+		c.Succeeded++
+	case OutcomeErrored:
+		c.Errored++
+	case OutcomeCanceled:
+		c.Canceled++
+	case OutcomeExpired:
+		c.Expired++
+	}
+}
+
+// Batch is the persisted state of a batch.
+type Batch struct {
+	ID     string `json:"id"`
+	Format Format `json:"format"`
+	// Endpoint is the API path every request of the batch targets, e.g. /v1/messages.
+	Endpoint string `json:"endpoint"`
+	// APIKeyDigest identifies the client key that created the batch, see util.APIKeyDigest.
+	// The scopes and limits of that key apply to the queued requests, and only that key can
+	// see the batch.
+	APIKeyDigest string            `json:"api_key_digest,omitempty"`
+	Status       Status            `json:"status"`
+	Counts       Counts            `json:"counts"`
+	Metadata     map[string]string `json:"metadata,omitempty"`
+	// InputFileID, OutputFileID and ErrorFileID reference /v1/files entries of OpenAI batches.
+	InputFileID       string    `json:"input_file_id,omitempty"`
+	OutputFileID      string    `json:"output_file_id,omitempty"`
+	ErrorFileID       string    `json:"error_file_id,omitempty"`
+	CreatedAt         time.Time `json:"created_at"`
+	ExpiresAt         time.Time `json:"expires_at"`
+	CancelRequestedAt time.Time `json:"cancel_requested_at,omitzero"`
+	EndedAt           time.Time `json:"ended_at,omitzero"`
+}
+
+// OwnedBy reports whether apiKey is the client key that created the batch.
+func (b *Batch) OwnedBy(apiKey string) bool {
+	return b.APIKeyDigest == util.APIKeyDigest(apiKey)
+}
+
+func (b *Batch) clone() *Batch {
+	clone := *b
+	clone.Metadata = maps.Clone(b.Metadata)
+	return &clone
+}
+
+func newID(format Format) (string, error) {
+	buf := make([]byte, 12)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("batch: generate id: %w", err)
+	}
+	prefix := "batch_"
+	if format == FormatAnthropic {
+		prefix = "msgbatch_"
+	}
+	return prefix + hex.EncodeToString(buf), nil
+}
+
+// validID keeps client supplied ids from escaping the batch directory.
+func validID(id string) bool {
+	if id == "" || len(id) > 64 {
+		return false
+	}
+	for _, r := range id {
+		if (r < '0' || r > '9') && (r < 'a' || r > 'z') && r != '_' {
+			return false
+		}
+	}
+	return true
+}
+
+var defaultManager atomic.Pointer[Manager]
+
+// Default returns the process-wide batch manager, or nil when batches are disabled.
+func Default() *Manager { return defaultManager.Load() }
+
+// SetDefault installs the process-wide batch manager; nil disables batches.
+func SetDefault(m *Manager) { defaultManager.Store(m) }
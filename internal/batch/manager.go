@@ -0,0 +1,607 @@
+package batch
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/filestore"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	batchFile    = "batch.json"
+	requestsFile = "requests.jsonl"
+	resultsFile  = "results.jsonl"
+
+	defaultConcurrency = 4
+	defaultExpiry      = 24 * time.Hour
+)
+
+// Executor runs a single batch request. Implementations report failures through the
+// returned Result rather than an error.
+type Executor interface {
+	Execute(ctx context.Context, b *Batch, req Request) Result
+}
+
+// Options configures a Manager.
+type Options struct {
+	// Dir holds one directory per batch.
+	Dir string
+	// Concurrency bounds the requests executed at once across all batches. Defaults to 4.
+	Concurrency int
+	// Expiry is how long requests may wait before they expire. Defaults to 24 hours.
+	Expiry time.Duration
+	// Retention is how long ended batches are kept; zero keeps them until deleted.
+	Retention time.Duration
+	// MaxRequests caps the requests of a single batch; zero leaves it unbounded.
+	MaxRequests int
+	// Files receives the output and error files of OpenAI batches.
+	Files *filestore.Store
+}
+
+// Manager queues batches on disk and executes them in the background.
+type Manager struct {
+	opts     Options
+	executor Executor
+	sem      chan struct{}
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu      sync.Mutex
+	batches map[string]*entry
+}
+
+type entry struct {
+	batch *Batch
+	// stop ends the dispatch of requests that have not started yet.
+	stop context.CancelFunc
+	// resultsMu serializes appends to the results file.
+	resultsMu sync.Mutex
+}
+
+// NewManager opens the batch directory and resumes every batch that had not ended.
+func NewManager(opts Options, executor Executor) (*Manager, error) {
+	if opts.Dir == "" {
+		return nil, fmt.Errorf("batch: directory is empty")
+	}
+	if executor == nil {
+		return nil, fmt.Errorf("batch: executor is nil")
+	}
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = defaultConcurrency
+	}
+	if opts.Expiry <= 0 {
+		opts.Expiry = defaultExpiry
+	}
+	if err := os.MkdirAll(opts.Dir, 0o700); err != nil {
+		return nil, fmt.Errorf("batch: create directory: %w", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	m := &Manager{
+		opts:     opts,
+		executor: executor,
+		sem:      make(chan struct{}, opts.Concurrency),
+		ctx:      ctx,
+		cancel:   cancel,
+		batches:  make(map[string]*entry),
+	}
+	if err := m.load(); err != nil {
+		cancel()
+		return nil, err
+	}
+	m.prune()
+	return m, nil
+}
+
+// Close stops dispatching requests and waits for the running ones. Batches that had not
+// ended resume when a manager is opened on the same directory again.
+func (m *Manager) Close() {
+	if m == nil {
+		return
+	}
+	m.cancel()
+	m.wg.Wait()
+}
+
+// MaxRequests returns the maximum number of requests of a batch, or 0 when unbounded.
+func (m *Manager) MaxRequests() int { return m.opts.MaxRequests }
+
+// Create queues a batch. The id, status, counts and timestamps of b are filled in.
+func (m *Manager) Create(b *Batch, requests []Request) (*Batch, error) {
+	id, err := newID(b.Format)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	b.ID = id
+	b.Status = StatusInProgress
+	b.Counts = Counts{Processing: len(requests)}
+	b.CreatedAt = now
+	b.ExpiresAt = now.Add(m.opts.Expiry)
+
+	if err = os.MkdirAll(m.batchDir(id), 0o700); err != nil {
+		return nil, fmt.Errorf("batch: create directory: %w", err)
+	}
+	if err = m.writeRequests(id, requests); err != nil {
+		_ = os.RemoveAll(m.batchDir(id))
+		return nil, err
+	}
+	if err = m.saveBatch(b); err != nil {
+		_ = os.RemoveAll(m.batchDir(id))
+		return nil, err
+	}
+
+	m.prune()
+	e := &entry{batch: b}
+	m.mu.Lock()
+	m.batches[id] = e
+	snapshot := b.clone()
+	m.mu.Unlock()
+	m.start(e, requests)
+	return snapshot, nil
+}
+
+// Get returns a snapshot of batch id.
+func (m *Manager) Get(id string) (*Batch, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, ok := m.batches[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return e.batch.clone(), nil
+}
+
+// List returns snapshots of every batch, newest first.
+func (m *Manager) List() []*Batch {
+	m.mu.Lock()
+	batches := make([]*Batch, 0, len(m.batches))
+	for _, e := range m.batches {
+		batches = append(batches, e.batch.clone())
+	}
+	m.mu.Unlock()
+	sort.Slice(batches, func(i, j int) bool {
+		if batches[i].CreatedAt.Equal(batches[j].CreatedAt) {
+			return batches[i].ID > batches[j].ID
+		}
+		return batches[i].CreatedAt.After(batches[j].CreatedAt)
+	})
+	return batches
+}
+
+// Cancel stops batch id from starting further requests. Running requests finish and the
+// rest are reported as canceled. Canceling an ended batch returns it unchanged.
+func (m *Manager) Cancel(id string) (*Batch, error) {
+	m.mu.Lock()
+	e, ok := m.batches[id]
+	if !ok {
+		m.mu.Unlock()
+		return nil, ErrNotFound
+	}
+	if e.batch.Status != StatusInProgress {
+		snapshot := e.batch.clone()
+		m.mu.Unlock()
+		return snapshot, nil
+	}
+	e.batch.Status = StatusCanceling
+	e.batch.CancelRequestedAt = time.Now().UTC()
+	snapshot := e.batch.clone()
+	stop := e.stop
+	m.mu.Unlock()
+
+	if err := m.saveBatch(snapshot); err != nil {
+		log.Warnf("batch %s: %v", id, err)
+	}
+	if stop != nil {
+		stop()
+	}
+	return snapshot, nil
+}
+
+// Delete removes an ended batch and its results.
+func (m *Manager) Delete(id string) error {
+	m.mu.Lock()
+	e, ok := m.batches[id]
+	if !ok {
+		m.mu.Unlock()
+		return ErrNotFound
+	}
+	if e.batch.Status != StatusEnded {
+		m.mu.Unlock()
+		return ErrNotEnded
+	}
+	delete(m.batches, id)
+	m.mu.Unlock()
+	if err := os.RemoveAll(m.batchDir(id)); err != nil {
+		return fmt.Errorf("batch: delete batch: %w", err)
+	}
+	return nil
+}
+
+// Results returns the results of an ended batch in completion order.
+func (m *Manager) Results(id string) ([]Result, error) {
+	b, err := m.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if b.Status != StatusEnded {
+		return nil, ErrNotEnded
+	}
+	return m.readResults(id)
+}
+
+// start dispatches the pending requests of e in the background.
+func (m *Manager) start(e *entry, pending []Request) {
+	ctx, stop := context.WithCancel(m.ctx)
+	m.mu.Lock()
+	e.stop = stop
+	canceled := e.batch.Status == StatusCanceling
+	m.mu.Unlock()
+	if canceled {
+		stop()
+	}
+	m.wg.Add(1)
+	go m.run(ctx, e, pending)
+}
+
+func (m *Manager) run(ctx context.Context, e *entry, pending []Request) {
+	defer m.wg.Done()
+	m.mu.Lock()
+	id := e.batch.ID
+	expiresAt := e.batch.ExpiresAt
+	m.mu.Unlock()
+
+	var running sync.WaitGroup
+	next := 0
+	for ; next < len(pending); next++ {
+		acquired := false
+		select {
+		case m.sem <- struct{}{}:
+			acquired = true
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			if acquired {
+				<-m.sem
+			}
+			break
+		}
+		req := pending[next]
+		if !time.Now().Before(expiresAt) {
+			<-m.sem
+			m.record(e, Result{CustomID: req.CustomID, Outcome: OutcomeExpired})
+			continue
+		}
+		running.Add(1)
+		go func() {
+			defer running.Done()
+			defer func() { <-m.sem }()
+			m.mu.Lock()
+			snapshot := e.batch.clone()
+			m.mu.Unlock()
+			result := m.executor.Execute(m.ctx, snapshot, req)
+			if m.ctx.Err() != nil {
+				// Interrupted by shutdown; the request runs again when the batch resumes.
+				return
+			}
+			result.CustomID = req.CustomID
+			m.record(e, result)
+		}()
+	}
+	running.Wait()
+	if m.ctx.Err() != nil {
+		return
+	}
+	for _, req := range pending[next:] {
+		m.record(e, Result{CustomID: req.CustomID, Outcome: OutcomeCanceled})
+	}
+	m.finish(e)
+	log.Infof("batch %s ended", id)
+}
+
+func (m *Manager) record(e *entry, result Result) {
+	line, err := json.Marshal(result)
+	if err != nil {
+		log.Warnf("batch: encode result %s: %v", result.CustomID, err)
+		return
+	}
+	m.mu.Lock()
+	id := e.batch.ID
+	m.mu.Unlock()
+
+	e.resultsMu.Lock()
+	err = appendLine(filepath.Join(m.batchDir(id), resultsFile), line)
+	e.resultsMu.Unlock()
+	if err != nil {
+		log.Warnf("batch %s: record result %s: %v", id, result.CustomID, err)
+	}
+
+	m.mu.Lock()
+	e.batch.Counts.Processing--
+	e.batch.Counts.add(result.Outcome)
+	m.mu.Unlock()
+}
+
+func (m *Manager) finish(e *entry) {
+	m.mu.Lock()
+	e.batch.Status = StatusEnded
+	e.batch.EndedAt = time.Now().UTC()
+	snapshot := e.batch.clone()
+	m.mu.Unlock()
+
+	if snapshot.Format == FormatOpenAI && m.opts.Files != nil {
+		if err := m.writeOpenAIFiles(snapshot); err != nil {
+			log.Warnf("batch %s: write output files: %v", snapshot.ID, err)
+		}
+		m.mu.Lock()
+		e.batch.OutputFileID = snapshot.OutputFileID
+		e.batch.ErrorFileID = snapshot.ErrorFileID
+		m.mu.Unlock()
+	}
+	if err := m.saveBatch(snapshot); err != nil {
+		log.Warnf("batch %s: %v", snapshot.ID, err)
+	}
+}
+
+// writeOpenAIFiles stores the results of an OpenAI batch as its output and error files.
+func (m *Manager) writeOpenAIFiles(b *Batch) error {
+	results, err := m.readResults(b.ID)
+	if err != nil {
+		return err
+	}
+	var output, errorsOut bytes.Buffer
+	for i, result := range results {
+		line, errLine := openAIResultLine(b.ID, i, result)
+		if errLine != nil {
+			return errLine
+		}
+		target := &errorsOut
+		if result.Outcome == OutcomeSucceeded {
+			target = &output
+		}
+		target.Write(line)
+		target.WriteByte('\n')
+	}
+	if output.Len() > 0 {
+		file, errCreate := m.opts.Files.CreateForOwner(b.APIKeyDigest, b.ID+"_output.jsonl", "batch_output", &output)
+		if errCreate != nil {
+			return errCreate
+		}
+		b.OutputFileID = file.ID
+	}
+	if errorsOut.Len() > 0 {
+		file, errCreate := m.opts.Files.CreateForOwner(b.APIKeyDigest, b.ID+"_error.jsonl", "batch_output", &errorsOut)
+		if errCreate != nil {
+			return errCreate
+		}
+		b.ErrorFileID = file.ID
+	}
+	return nil
+}
+
+func openAIResultLine(batchID string, index int, result Result) ([]byte, error) {
+	line := map[string]any{
+		"id":        fmt.Sprintf("%s_req_%d", batchID, index),
+		"custom_id": result.CustomID,
+		"response":  nil,
+		"error":     nil,
+	}
+	switch result.Outcome {
+	case OutcomeSucceeded, OutcomeErrored:
+		body := result.Body
+		if len(body) == 0 || !json.Valid(body) {
+			body = json.RawMessage("null")
+		}
+		line["response"] = map[string]any{"status_code": result.StatusCode, "request_id": "", "body": body}
+	case OutcomeExpired:
+		line["error"] = map[string]string{"code": "batch_expired", "message": "This request could not be executed before the completion window expired."}
+	default:
+		line["error"] = map[string]string{"code": "batch_cancelled", "message": "This request was not executed because the batch was cancelled."}
+	}
+	return json.Marshal(line)
+}
+
+// load reads every batch of the directory and resumes those that had not ended.
+func (m *Manager) load() error {
+	dirEntries, err := os.ReadDir(m.opts.Dir)
+	if err != nil {
+		return fmt.Errorf("batch: list batches: %w", err)
+	}
+	for _, dirEntry := range dirEntries {
+		if !dirEntry.IsDir() || !validID(dirEntry.Name()) {
+			continue
+		}
+		b, errRead := m.readBatch(dirEntry.Name())
+		if errRead != nil {
+			log.Warnf("batch %s: skipped: %v", dirEntry.Name(), errRead)
+			continue
+		}
+		e := &entry{batch: b}
+		m.batches[b.ID] = e
+		if b.Status == StatusEnded {
+			continue
+		}
+
+		requests, errRequests := m.readRequests(b.ID)
+		if errRequests != nil {
+			log.Warnf("batch %s: skipped: %v", b.ID, errRequests)
+			delete(m.batches, b.ID)
+			continue
+		}
+		results, errResults := m.readResults(b.ID)
+		if errResults != nil {
+			log.Warnf("batch %s: read results: %v", b.ID, errResults)
+		}
+		done := make(map[string]struct{}, len(results))
+		b.Counts = Counts{}
+		for _, result := range results {
+			done[result.CustomID] = struct{}{}
+			b.Counts.add(result.Outcome)
+		}
+		pending := make([]Request, 0, len(requests)-len(done))
+		for _, req := range requests {
+			if _, ok := done[req.CustomID]; !ok {
+				pending = append(pending, req)
+			}
+		}
+		b.Counts.Processing = len(pending)
+		log.Infof("batch %s resumed with %d pending requests", b.ID, len(pending))
+		m.start(e, pending)
+	}
+	return nil
+}
+
+// prune deletes ended batches older than the retention period.
+func (m *Manager) prune() {
+	if m.opts.Retention <= 0 {
+		return
+	}
+	cutoff := time.Now().Add(-m.opts.Retention)
+	var expired []string
+	m.mu.Lock()
+	for id, e := range m.batches {
+		if e.batch.Status == StatusEnded && e.batch.EndedAt.Before(cutoff) {
+			expired = append(expired, id)
+			delete(m.batches, id)
+		}
+	}
+	m.mu.Unlock()
+	for _, id := range expired {
+		if err := os.RemoveAll(m.batchDir(id)); err != nil {
+			log.Warnf("batch %s: delete expired batch: %v", id, err)
+		}
+	}
+}
+
+func (m *Manager) batchDir(id string) string { return filepath.Join(m.opts.Dir, id) }
+
+func (m *Manager) saveBatch(b *Batch) error {
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return fmt.Errorf("batch: encode batch: %w", err)
+	}
+	path := filepath.Join(m.batchDir(b.ID), batchFile)
+	tmp := path + ".tmp"
+	if err = os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("batch: save batch: %w", err)
+	}
+	if err = os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("batch: save batch: %w", err)
+	}
+	return nil
+}
+
+func (m *Manager) readBatch(id string) (*Batch, error) {
+	data, err := os.ReadFile(filepath.Join(m.batchDir(id), batchFile))
+	if err != nil {
+		return nil, err
+	}
+	var b Batch
+	if err = json.Unmarshal(data, &b); err != nil {
+		return nil, err
+	}
+	if b.ID != id {
+		return nil, fmt.Errorf("batch id %q does not match its directory", b.ID)
+	}
+	return &b, nil
+}
+
+func (m *Manager) writeRequests(id string, requests []Request) error {
+	file, err := os.OpenFile(filepath.Join(m.batchDir(id), requestsFile), os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("batch: write requests: %w", err)
+	}
+	writer := bufio.NewWriter(file)
+	encoder := json.NewEncoder(writer)
+	for _, req := range requests {
+		if err = encoder.Encode(req); err != nil {
+			_ = file.Close()
+			return fmt.Errorf("batch: write requests: %w", err)
+		}
+	}
+	if err = writer.Flush(); err != nil {
+		_ = file.Close()
+		return fmt.Errorf("batch: write requests: %w", err)
+	}
+	if err = file.Close(); err != nil {
+		return fmt.Errorf("batch: write requests: %w", err)
+	}
+	return nil
+}
+
+func (m *Manager) readRequests(id string) ([]Request, error) {
+	var requests []Request
+	err := readLines(filepath.Join(m.batchDir(id), requestsFile), func(line []byte) error {
+		var req Request
+		if errDecode := json.Unmarshal(line, &req); errDecode != nil {
+			return errDecode
+		}
+		requests = append(requests, req)
+		return nil
+	})
+	return requests, err
+}
+
+func (m *Manager) readResults(id string) ([]Result, error) {
+	var results []Result
+	err := readLines(filepath.Join(m.batchDir(id), resultsFile), func(line []byte) error {
+		var result Result
+		// A line cut short by a crash is skipped; its request runs again.
+		if json.Unmarshal(line, &result) == nil {
+			results = append(results, result)
+		}
+		return nil
+	})
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	return results, err
+}
+
+func readLines(path string, fn func(line []byte) error) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = file.Close() }()
+	reader := bufio.NewReader(file)
+	for {
+		line, errRead := reader.ReadBytes('\n')
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			if err = fn(line); err != nil {
+				return err
+			}
+		}
+		if errRead != nil {
+			if errors.Is(errRead, io.EOF) {
+				return nil
+			}
+			return errRead
+		}
+	}
+}
+
+func appendLine(path string, line []byte) error {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err = file.Write(append(line, '\n')); err != nil {
+		_ = file.Close()
+		return err
+	}
+	return file.Close()
+}
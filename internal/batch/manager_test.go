@@ -0,0 +1,162 @@
+package batch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/filestore"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+)
+
+type fakeExecutor struct {
+	mu    sync.Mutex
+	calls []string
+	// block, when set, holds every execution until it is closed or ctx ends.
+	block chan struct{}
+}
+
+func (e *fakeExecutor) Execute(ctx context.Context, b *Batch, req Request) Result {
+	if e.block != nil {
+		select {
+		case <-e.block:
+		case <-ctx.Done():
+			return Result{Outcome: OutcomeErrored}
+		}
+	}
+	e.mu.Lock()
+	e.calls = append(e.calls, req.CustomID)
+	e.mu.Unlock()
+	if req.CustomID == "bad" {
+		return Result{Outcome: OutcomeErrored, StatusCode: 400, Body: json.RawMessage(`{"error":{"message":"bad request"}}`)}
+	}
+	return Result{Outcome: OutcomeSucceeded, StatusCode: 200, Body: req.Body}
+}
+
+func requestsOf(ids ...string) []Request {
+	requests := make([]Request, 0, len(ids))
+	for _, id := range ids {
+		requests = append(requests, Request{CustomID: id, Body: json.RawMessage(fmt.Sprintf(`{"id":%q}`, id))})
+	}
+	return requests
+}
+
+func waitEnded(t *testing.T, m *Manager, id string) *Batch {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		b, err := m.Get(id)
+		if err != nil {
+			t.Fatalf("Get: %v", err)
+		}
+		if b.Status == StatusEnded {
+			return b
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("batch %s did not end", id)
+	return nil
+}
+
+func TestManagerRunsBatch(t *testing.T) {
+	files, err := filestore.New(t.TempDir(), 0, 0)
+	if err != nil {
+		t.Fatalf("filestore.New: %v", err)
+	}
+	m, err := NewManager(Options{Dir: t.TempDir(), Concurrency: 2, Files: files}, &fakeExecutor{})
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+	defer m.Close()
+
+	created, err := m.Create(&Batch{Format: FormatOpenAI, Endpoint: "/v1/chat/completions", APIKeyDigest: util.APIKeyDigest("key")}, requestsOf("a", "b", "bad"))
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if created.Status != StatusInProgress || created.Counts.Processing != 3 {
+		t.Fatalf("created batch = %+v", created)
+	}
+	ended := waitEnded(t, m, created.ID)
+	if ended.Counts != (Counts{Succeeded: 2, Errored: 1}) {
+		t.Fatalf("counts = %+v", ended.Counts)
+	}
+	results, err := m.Results(created.ID)
+	if err != nil || len(results) != 3 {
+		t.Fatalf("Results = %v, %v", results, err)
+	}
+
+	if ended.OutputFileID == "" || ended.ErrorFileID == "" {
+		t.Fatalf("output files = %q, %q", ended.OutputFileID, ended.ErrorFileID)
+	}
+	_, content, err := files.Open(ended.OutputFileID, "key")
+	if err != nil {
+		t.Fatalf("open output file: %v", err)
+	}
+	data, _ := io.ReadAll(content)
+	_ = content.Close()
+	if n := len(splitLines(data)); n != 2 {
+		t.Fatalf("output file has %d lines, want 2: %s", n, data)
+	}
+}
+
+func TestManagerCancelAndResume(t *testing.T) {
+	dir := t.TempDir()
+	executor := &fakeExecutor{block: make(chan struct{})}
+	m, err := NewManager(Options{Dir: dir, Concurrency: 1}, executor)
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+	first, err := m.Create(&Batch{Format: FormatAnthropic, Endpoint: "/v1/messages"}, requestsOf("a", "b", "c"))
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	second, err := m.Create(&Batch{Format: FormatAnthropic, Endpoint: "/v1/messages"}, requestsOf("x", "y"))
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if _, err = m.Cancel(first.ID); err != nil {
+		t.Fatalf("Cancel: %v", err)
+	}
+	// Shut down while requests are blocked; nothing has completed yet.
+	m.Close()
+
+	executor.block = nil
+	m, err = NewManager(Options{Dir: dir, Concurrency: 1}, executor)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer m.Close()
+
+	canceled := waitEnded(t, m, first.ID)
+	if canceled.Counts.Canceled != 3 || canceled.CancelRequestedAt.IsZero() {
+		t.Fatalf("canceled batch = %+v", canceled)
+	}
+	resumed := waitEnded(t, m, second.ID)
+	if resumed.Counts != (Counts{Succeeded: 2}) {
+		t.Fatalf("resumed counts = %+v", resumed.Counts)
+	}
+	if err = m.Delete(second.ID); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err = m.Get(second.ID); err != ErrNotFound {
+		t.Fatalf("Get after delete error = %v", err)
+	}
+}
+
+func splitLines(data []byte) []string {
+	var lines []string
+	start := 0
+	for i, b := range data {
+		if b == '\n' {
+			if i > start {
+				lines = append(lines, string(data[start:i]))
+			}
+			start = i + 1
+		}
+	}
+	return lines
+}
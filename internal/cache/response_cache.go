@@ -0,0 +1,332 @@
+package cache
+
+import (
+	"bytes"
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ResponseEntry is a cached model response. Non-streaming responses keep the body in
+// Payload; streaming responses keep the chunks exactly as they were handed to the client
+// handler so a replay produces the same SSE framing.
+type ResponseEntry struct {
+	Payload   []byte      `json:"payload,omitempty"`
+	Chunks    [][]byte    `json:"chunks,omitempty"`
+	Headers   http.Header `json:"headers,omitempty"`
+	ExpiresAt time.Time   `json:"expires_at"`
+}
+
+func (e *ResponseEntry) size() int64 {
+	if e == nil {
+		return 0
+	}
+	size := int64(len(e.Payload))
+	for _, chunk := range e.Chunks {
+		size += int64(len(chunk))
+	}
+	for name, values := range e.Headers {
+		size += int64(len(name))
+		for _, value := range values {
+			size += int64(len(value))
+		}
+	}
+	return size
+}
+
+// ResponseStore holds cached responses. Implementations bound their own size and
+// must be safe for concurrent use.
+type ResponseStore interface {
+	Get(key string) (*ResponseEntry, bool)
+	Put(key string, entry *ResponseEntry)
+	Delete(key string)
+}
+
+// ResponseCache serves exact repeats of completion requests from a ResponseStore.
+type ResponseCache struct {
+	store         ResponseStore
+	ttl           time.Duration
+	maxEntryBytes int64
+	now           func() time.Time
+}
+
+// NewResponseCache wraps store with a TTL. Entries larger than maxEntryBytes are not stored;
+// a value <= 0 disables that limit.
+func NewResponseCache(store ResponseStore, ttl time.Duration, maxEntryBytes int64) *ResponseCache {
+	return &ResponseCache{store: store, ttl: ttl, maxEntryBytes: maxEntryBytes, now: time.Now}
+}
+
+// Get returns a live entry for key.
+func (c *ResponseCache) Get(key string) (*ResponseEntry, bool) {
+	if c == nil || c.store == nil || key == "" {
+		return nil, false
+	}
+	entry, ok := c.store.Get(key)
+	if !ok {
+		return nil, false
+	}
+	if !c.now().Before(entry.ExpiresAt) {
+		c.store.Delete(key)
+		return nil, false
+	}
+	return entry, true
+}
+
+// Put stores entry under key for the configured TTL.
+func (c *ResponseCache) Put(key string, entry *ResponseEntry) {
+	if c == nil || c.store == nil || key == "" || entry == nil || !c.Fits(entry.size()) {
+		return
+	}
+	entry.ExpiresAt = c.now().Add(c.ttl)
+	c.store.Put(key, entry)
+}
+
+// Fits reports whether an entry of size bytes may be stored.
+func (c *ResponseCache) Fits(size int64) bool {
+	return c != nil && (c.maxEntryBytes <= 0 || size <= c.maxEntryBytes)
+}
+
+var defaultResponseCache atomic.Pointer[ResponseCache]
+
+// DefaultResponseCache returns the process-wide response cache, or nil when caching is disabled.
+func DefaultResponseCache() *ResponseCache { return defaultResponseCache.Load() }
+
+// SetDefaultResponseCache installs the process-wide response cache; nil disables caching.
+func SetDefaultResponseCache(c *ResponseCache) { defaultResponseCache.Store(c) }
+
+// ResponseCacheKey derives the cache key of a request from the owning client key digest,
+// its source format, model and streaming mode, and the payloads sent upstream with object
+// keys put in canonical order. Requests of different client keys never share entries.
+func ResponseCacheKey(owner, sourceFormat, model, alt string, stream bool, payloads ...[]byte) string {
+	h := sha256.New()
+	h.Write([]byte(owner))
+	h.Write([]byte{0})
+	h.Write([]byte(sourceFormat))
+	h.Write([]byte{0})
+	h.Write([]byte(model))
+	h.Write([]byte{0})
+	h.Write([]byte(alt))
+	h.Write([]byte{0})
+	h.Write([]byte(strconv.FormatBool(stream)))
+	for _, payload := range payloads {
+		h.Write([]byte{0})
+		h.Write(normalizeJSON(payload))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// normalizeJSON re-encodes payload so that formatting and key order do not affect the key.
+// Payloads that are not valid JSON are used as-is.
+func normalizeJSON(payload []byte) []byte {
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return payload
+	}
+	out, err := json.Marshal(value)
+	if err != nil {
+		return payload
+	}
+	return out
+}
+
+// lruIndex tracks entry sizes in recency order and reports which keys fall out when
+// the entry or byte budget is exceeded.
+type lruIndex struct {
+	maxEntries int
+	maxBytes   int64
+	bytes      int64
+	order      *list.List
+	items      map[string]*list.Element
+}
+
+type lruItem struct {
+	key   string
+	size  int64
+	entry *ResponseEntry
+}
+
+func newLRUIndex(maxEntries int, maxBytes int64) *lruIndex {
+	return &lruIndex{maxEntries: maxEntries, maxBytes: maxBytes, order: list.New(), items: make(map[string]*list.Element)}
+}
+
+func (l *lruIndex) get(key string) (*lruItem, bool) {
+	element, ok := l.items[key]
+	if !ok {
+		return nil, false
+	}
+	l.order.MoveToFront(element)
+	return element.Value.(*lruItem), true
+}
+
+// add inserts or replaces key and returns the keys evicted to stay within budget.
+func (l *lruIndex) add(item *lruItem) []string {
+	l.remove(item.key)
+	l.items[item.key] = l.order.PushFront(item)
+	l.bytes += item.size
+	var evicted []string
+	for l.order.Len() > 0 && ((l.maxEntries > 0 && l.order.Len() > l.maxEntries) || (l.maxBytes > 0 && l.bytes > l.maxBytes)) {
+		oldest := l.order.Back().Value.(*lruItem)
+		l.remove(oldest.key)
+		evicted = append(evicted, oldest.key)
+	}
+	return evicted
+}
+
+func (l *lruIndex) remove(key string) {
+	element, ok := l.items[key]
+	if !ok {
+		return
+	}
+	l.bytes -= element.Value.(*lruItem).size
+	l.order.Remove(element)
+	delete(l.items, key)
+}
+
+// MemoryResponseStore keeps responses in process memory with LRU eviction.
+type MemoryResponseStore struct {
+	mu    sync.Mutex
+	index *lruIndex
+}
+
+// NewMemoryResponseStore creates a memory store bounded by entry count and total bytes.
+func NewMemoryResponseStore(maxEntries int, maxBytes int64) *MemoryResponseStore {
+	return &MemoryResponseStore{index: newLRUIndex(maxEntries, maxBytes)}
+}
+
+// Get implements ResponseStore.
+func (s *MemoryResponseStore) Get(key string) (*ResponseEntry, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	item, ok := s.index.get(key)
+	if !ok {
+		return nil, false
+	}
+	return item.entry, true
+}
+
+// Put implements ResponseStore.
+func (s *MemoryResponseStore) Put(key string, entry *ResponseEntry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.index.add(&lruItem{key: key, size: entry.size(), entry: entry})
+}
+
+// Delete implements ResponseStore.
+func (s *MemoryResponseStore) Delete(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.index.remove(key)
+}
+
+// DiskResponseStore keeps one JSON file per response so cached entries survive restarts.
+type DiskResponseStore struct {
+	mu    sync.Mutex
+	dir   string
+	index *lruIndex
+}
+
+// NewDiskResponseStore opens dir, indexing existing entries from oldest to newest.
+func NewDiskResponseStore(dir string, maxEntries int, maxBytes int64) (*DiskResponseStore, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("response cache: create dir: %w", err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("response cache: read dir: %w", err)
+	}
+	type existing struct {
+		key     string
+		size    int64
+		modTime time.Time
+	}
+	var found []existing
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".json") {
+			continue
+		}
+		info, errInfo := entry.Info()
+		if errInfo != nil {
+			continue
+		}
+		found = append(found, existing{key: strings.TrimSuffix(name, ".json"), size: info.Size(), modTime: info.ModTime()})
+	}
+	sort.Slice(found, func(i, j int) bool { return found[i].modTime.Before(found[j].modTime) })
+
+	s := &DiskResponseStore{dir: dir, index: newLRUIndex(maxEntries, maxBytes)}
+	for _, item := range found {
+		s.removeFiles(s.index.add(&lruItem{key: item.key, size: item.size}))
+	}
+	return s, nil
+}
+
+// Get implements ResponseStore.
+func (s *DiskResponseStore) Get(key string) (*ResponseEntry, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.index.get(key); !ok {
+		return nil, false
+	}
+	data, err := os.ReadFile(s.path(key))
+	if err != nil {
+		s.index.remove(key)
+		return nil, false
+	}
+	var entry ResponseEntry
+	if err = json.Unmarshal(data, &entry); err != nil {
+		s.index.remove(key)
+		_ = os.Remove(s.path(key))
+		return nil, false
+	}
+	return &entry, true
+}
+
+// Put implements ResponseStore.
+func (s *DiskResponseStore) Put(key string, entry *ResponseEntry) {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tmp := s.path(key) + ".tmp"
+	if err = os.WriteFile(tmp, data, 0o600); err != nil {
+		return
+	}
+	if err = os.Rename(tmp, s.path(key)); err != nil {
+		_ = os.Remove(tmp)
+		return
+	}
+	s.removeFiles(s.index.add(&lruItem{key: key, size: int64(len(data))}))
+}
+
+// Delete implements ResponseStore.
+func (s *DiskResponseStore) Delete(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.index.remove(key)
+	_ = os.Remove(s.path(key))
+}
+
+func (s *DiskResponseStore) removeFiles(keys []string) {
+	for _, key := range keys {
+		_ = os.Remove(s.path(key))
+	}
+}
+
+func (s *DiskResponseStore) path(key string) string {
+	return filepath.Join(s.dir, key+".json")
+}
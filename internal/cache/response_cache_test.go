@@ -0,0 +1,81 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestResponseCacheKeyIgnoresFormatting(t *testing.T) {
+	a := ResponseCacheKey("owner-a", "openai", "gpt-5", "", false, []byte(`{"model":"gpt-5","temperature":0.0}`))
+	b := ResponseCacheKey("owner-a", "openai", "gpt-5", "", false, []byte("{\n  \"temperature\": 0.0,\n  \"model\": \"gpt-5\"\n}"))
+	if a != b {
+		t.Fatalf("formatting changed the key")
+	}
+	if a == ResponseCacheKey("owner-a", "openai", "gpt-5", "", true, []byte(`{"model":"gpt-5","temperature":0.0}`)) {
+		t.Fatalf("streaming and non-streaming requests share a key")
+	}
+	if a == ResponseCacheKey("owner-a", "claude", "gpt-5", "", false, []byte(`{"model":"gpt-5","temperature":0.0}`)) {
+		t.Fatalf("source formats share a key")
+	}
+	if a == ResponseCacheKey("owner-b", "openai", "gpt-5", "", false, []byte(`{"model":"gpt-5","temperature":0.0}`)) {
+		t.Fatalf("client keys share a key")
+	}
+}
+
+func TestResponseCacheExpiresEntries(t *testing.T) {
+	now := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+	c := NewResponseCache(NewMemoryResponseStore(10, 0), time.Minute, 0)
+	c.now = func() time.Time { return now }
+
+	c.Put("k", &ResponseEntry{Payload: []byte("v")})
+	if entry, ok := c.Get("k"); !ok || string(entry.Payload) != "v" {
+		t.Fatalf("entry not served before expiry")
+	}
+	now = now.Add(time.Minute)
+	if _, ok := c.Get("k"); ok {
+		t.Fatalf("expired entry served")
+	}
+}
+
+func TestMemoryResponseStoreEvictsLeastRecentlyUsed(t *testing.T) {
+	s := NewMemoryResponseStore(2, 0)
+	s.Put("a", &ResponseEntry{Payload: []byte("1")})
+	s.Put("b", &ResponseEntry{Payload: []byte("2")})
+	s.Get("a")
+	s.Put("c", &ResponseEntry{Payload: []byte("3")})
+	if _, ok := s.Get("b"); ok {
+		t.Fatalf("least recently used entry kept")
+	}
+	if _, ok := s.Get("a"); !ok {
+		t.Fatalf("recently used entry evicted")
+	}
+
+	sized := NewMemoryResponseStore(0, 5)
+	sized.Put("a", &ResponseEntry{Payload: []byte("123")})
+	sized.Put("b", &ResponseEntry{Payload: []byte("456")})
+	if _, ok := sized.Get("a"); ok {
+		t.Fatalf("byte budget not enforced")
+	}
+}
+
+func TestDiskResponseStoreSurvivesReopen(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewDiskResponseStore(dir, 10, 0)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	s.Put("k", &ResponseEntry{Chunks: [][]byte{[]byte("a"), []byte("b")}})
+
+	reopened, err := NewDiskResponseStore(dir, 10, 0)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	entry, ok := reopened.Get("k")
+	if !ok || len(entry.Chunks) != 2 || string(entry.Chunks[1]) != "b" {
+		t.Fatalf("entry after reopen = %+v, %v", entry, ok)
+	}
+	reopened.Delete("k")
+	if _, ok = reopened.Get("k"); ok {
+		t.Fatalf("deleted entry served")
+	}
+}
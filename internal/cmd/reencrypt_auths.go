@@ -0,0 +1,58 @@
+// Package cmd contains CLI helpers. This file implements rewriting stored auth files
+// with the current auth-encryption settings after a key rotation or configuration change.
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/authcrypt"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	sdkAuth "github.com/router-for-me/CLIProxyAPI/v6/sdk/auth"
+	log "github.com/sirupsen/logrus"
+)
+
+// DoReencryptAuths saves every auth of the registered token store again. Files in plaintext
+// or sealed with a retired key are sealed with the primary key; with auth-encryption.enable
+// set to false, sealed files are written back as plaintext. Files already current are left alone.
+func DoReencryptAuths(cfg *config.Config) {
+	if cfg == nil {
+		cfg = &config.Config{}
+	}
+	if resolved, errResolve := util.ResolveAuthDir(cfg.AuthDir); errResolve == nil {
+		cfg.AuthDir = resolved
+	}
+	if authcrypt.Default() == nil {
+		log.Errorf("reencrypt-auths: auth-encryption has no keys configured")
+		return
+	}
+
+	store := sdkAuth.GetTokenStore()
+	if setter, ok := store.(interface{ SetBaseDir(string) }); ok {
+		setter.SetBaseDir(cfg.AuthDir)
+	}
+	ctx := context.Background()
+	auths, errList := store.List(ctx)
+	if errList != nil {
+		log.Errorf("reencrypt-auths: list auths failed: %v", errList)
+		return
+	}
+	processed, failed := 0, 0
+	for _, auth := range auths {
+		if auth == nil || auth.Metadata == nil {
+			continue
+		}
+		if _, errSave := store.Save(ctx, auth); errSave != nil {
+			log.Errorf("reencrypt-auths: save %s failed: %v", auth.ID, errSave)
+			failed++
+			continue
+		}
+		processed++
+	}
+	if keyID := authcrypt.Default().KeyID(); keyID != "" {
+		fmt.Printf("Auth files sealed with key %s: %d processed, %d failed\n", keyID, processed, failed)
+	} else {
+		fmt.Printf("Auth files decrypted to plaintext: %d processed, %d failed\n", processed, failed)
+	}
+}
@@ -0,0 +1,217 @@
+package config
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Route groups a client API key can be restricted to via allowed-routes.
+const (
+	RouteGroupOpenAI    = "openai"
+	RouteGroupClaude    = "claude"
+	RouteGroupGemini    = "gemini"
+	RouteGroupResponses = "responses"
+	RouteGroupAmp       = "amp"
+	RouteGroupModels    = "models"
+	RouteGroupFiles     = "files"
+	RouteGroupBatches   = "batches"
+)
+
+// APIKey is a client key accepted by the proxy. In YAML and JSON an entry is either a
+// plain string holding the key, or an object carrying the key together with its metadata,
+// scopes and limits.
+type APIKey struct {
+	// Key is the secret presented by the client.
+	Key string `yaml:"key" json:"key"`
+
+	// Name identifies the key in usage statistics and request logs.
+	Name string `yaml:"name,omitempty" json:"name,omitempty"`
+
+	// Owner records the team or person the key was issued to.
+	Owner string `yaml:"owner,omitempty" json:"owner,omitempty"`
+
+	// CreatedAt records when the key was issued.
+	CreatedAt time.Time `yaml:"created-at,omitempty" json:"created-at,omitzero"`
+
+	// ExpiresAt rejects the key from this instant on. Zero means the key never expires.
+	ExpiresAt time.Time `yaml:"expires-at,omitempty" json:"expires-at,omitzero"`
+
+	// Disabled rejects the key without removing it from the configuration.
+	Disabled bool `yaml:"disabled,omitempty" json:"disabled,omitempty"`
+
+	// AllowedRoutes restricts the key to route groups (openai, claude, gemini, responses, amp,
+	// models, files, batches). Empty allows all routes.
+	AllowedRoutes []string `yaml:"allowed-routes,omitempty" json:"allowed-routes,omitempty"`
+
+	// AllowedModels restricts the key to matching models. Supports '*' wildcards; empty allows all.
+	AllowedModels []string `yaml:"allowed-models,omitempty" json:"allowed-models,omitempty"`
+
+	// AllowedPrefixes restricts the key to models addressed with one of these credential
+	// prefixes (e.g. "teamA" for "teamA/gemini-3-pro-preview"). Empty allows all.
+	AllowedPrefixes []string `yaml:"allowed-prefixes,omitempty" json:"allowed-prefixes,omitempty"`
+
+	// APIKeyLimit holds the key's quotas and rate limits.
+	APIKeyLimit `yaml:",inline"`
+}
+
+// apiKeyObject is APIKey without its custom (un)marshalers.
+type apiKeyObject APIKey
+
+// PlainAPIKeys wraps bare key strings into APIKey entries.
+func PlainAPIKeys(keys ...string) []APIKey {
+	if len(keys) == 0 {
+		return nil
+	}
+	out := make([]APIKey, 0, len(keys))
+	for _, key := range keys {
+		out = append(out, APIKey{Key: key})
+	}
+	return out
+}
+
+// IsPlain reports whether the entry carries nothing but the key.
+func (k APIKey) IsPlain() bool {
+	return k.Name == "" && k.Owner == "" && k.CreatedAt.IsZero() && k.ExpiresAt.IsZero() && !k.Disabled &&
+		len(k.AllowedRoutes) == 0 && len(k.AllowedModels) == 0 && len(k.AllowedPrefixes) == 0 && k.APIKeyLimit.IsZero()
+}
+
+// Active reports whether the key is enabled and not expired at now.
+func (k APIKey) Active(now time.Time) bool {
+	if k.Disabled {
+		return false
+	}
+	return k.ExpiresAt.IsZero() || now.Before(k.ExpiresAt)
+}
+
+// AllowsRoute reports whether the key may call routes of the given group. Keys with
+// allowed-routes are denied requests outside any group.
+func (k APIKey) AllowsRoute(group string) bool {
+	if len(k.AllowedRoutes) == 0 {
+		return true
+	}
+	for _, allowed := range k.AllowedRoutes {
+		if allowed == group {
+			return true
+		}
+	}
+	return false
+}
+
+// MarshalYAML renders plain entries as bare strings to keep existing files unchanged.
+func (k APIKey) MarshalYAML() (any, error) {
+	if k.IsPlain() {
+		return k.Key, nil
+	}
+	return apiKeyObject(k), nil
+}
+
+// UnmarshalYAML accepts either a bare key string or a mapping.
+func (k *APIKey) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		*k = APIKey{Key: value.Value}
+		return nil
+	}
+	var obj apiKeyObject
+	if err := value.Decode(&obj); err != nil {
+		return err
+	}
+	*k = APIKey(obj)
+	return nil
+}
+
+// MarshalJSON renders plain entries as bare strings for compatibility with existing clients.
+func (k APIKey) MarshalJSON() ([]byte, error) {
+	if k.IsPlain() {
+		return json.Marshal(k.Key)
+	}
+	return json.Marshal(apiKeyObject(k))
+}
+
+// UnmarshalJSON accepts either a bare key string or an object.
+func (k *APIKey) UnmarshalJSON(data []byte) error {
+	var key string
+	if err := json.Unmarshal(data, &key); err == nil {
+		*k = APIKey{Key: key}
+		return nil
+	}
+	var obj apiKeyObject
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return err
+	}
+	*k = APIKey(obj)
+	return nil
+}
+
+// APIKeyValues returns the configured key strings in order.
+func (cfg *SDKConfig) APIKeyValues() []string {
+	if cfg == nil || len(cfg.APIKeys) == 0 {
+		return nil
+	}
+	out := make([]string, 0, len(cfg.APIKeys))
+	for _, entry := range cfg.APIKeys {
+		out = append(out, entry.Key)
+	}
+	return out
+}
+
+// APIKeyEntry returns the configured entry for key, if any.
+func (cfg *SDKConfig) APIKeyEntry(key string) (APIKey, bool) {
+	if cfg == nil || key == "" {
+		return APIKey{}, false
+	}
+	for _, entry := range cfg.APIKeys {
+		if entry.Key == key {
+			return entry, true
+		}
+	}
+	return APIKey{}, false
+}
+
+// SanitizeAPIKeys trims entries, drops empty and duplicated keys, normalizes the scope
+// lists and clamps negative limits to zero.
+func (cfg *SDKConfig) SanitizeAPIKeys() {
+	if cfg == nil || len(cfg.APIKeys) == 0 {
+		return
+	}
+	seen := make(map[string]struct{}, len(cfg.APIKeys))
+	out := make([]APIKey, 0, len(cfg.APIKeys))
+	for _, entry := range cfg.APIKeys {
+		entry.Key = strings.TrimSpace(entry.Key)
+		if entry.Key == "" {
+			continue
+		}
+		if _, exists := seen[entry.Key]; exists {
+			continue
+		}
+		seen[entry.Key] = struct{}{}
+		entry.Name = strings.TrimSpace(entry.Name)
+		entry.Owner = strings.TrimSpace(entry.Owner)
+		entry.AllowedRoutes = NormalizeExcludedModels(entry.AllowedRoutes)
+		entry.AllowedModels = NormalizeExcludedModels(entry.AllowedModels)
+		entry.AllowedPrefixes = normalizeAPIKeyPrefixes(entry.AllowedPrefixes)
+		entry.APIKeyLimit = entry.APIKeyLimit.sanitized()
+		out = append(out, entry)
+	}
+	cfg.APIKeys = out
+}
+
+func normalizeAPIKeyPrefixes(prefixes []string) []string {
+	if len(prefixes) == 0 {
+		return nil
+	}
+	out := make([]string, 0, len(prefixes))
+	for _, prefix := range prefixes {
+		trimmed := strings.Trim(strings.TrimSpace(prefix), "/")
+		if trimmed == "" {
+			continue
+		}
+		out = append(out, trimmed)
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
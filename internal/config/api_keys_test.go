@@ -0,0 +1,61 @@
+package config
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestAPIKeysAcceptStringsAndObjects(t *testing.T) {
+	input := `
+api-keys:
+  - plain-key
+  - key: " team-key "
+    name: team-a
+    expires-at: 2027-01-01T00:00:00Z
+    allowed-routes: [OpenAI]
+    allowed-models: [GPT-5*]
+    requests-per-minute: -5
+    tokens-per-day: 1000
+  - plain-key
+`
+	var cfg SDKConfig
+	if err := yaml.Unmarshal([]byte(input), &cfg); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	cfg.SanitizeAPIKeys()
+	if len(cfg.APIKeys) != 2 {
+		t.Fatalf("expected 2 keys, got %d", len(cfg.APIKeys))
+	}
+	if !cfg.APIKeys[0].IsPlain() || cfg.APIKeys[0].Key != "plain-key" {
+		t.Fatalf("unexpected plain entry: %+v", cfg.APIKeys[0])
+	}
+	team, ok := cfg.APIKeyEntry("team-key")
+	if !ok || team.TokensPerDay != 1000 || team.RequestsPerMinute != 0 || len(team.AllowedModels) != 1 || team.AllowedModels[0] != "gpt-5*" {
+		t.Fatalf("unexpected object entry: %+v, %v", team, ok)
+	}
+	if team.Name != "team-a" || team.ExpiresAt.IsZero() || !team.AllowsRoute(RouteGroupOpenAI) || team.AllowsRoute(RouteGroupClaude) || team.AllowsRoute("") {
+		t.Fatalf("unexpected metadata or scopes: %+v", team)
+	}
+
+	out, err := yaml.Marshal(&cfg)
+	if err != nil {
+		t.Fatalf("marshal yaml: %v", err)
+	}
+	if !strings.Contains(string(out), "- plain-key\n") || !strings.Contains(string(out), "tokens-per-day: 1000") {
+		t.Fatalf("unexpected yaml:\n%s", out)
+	}
+	raw, err := json.Marshal(cfg.APIKeys)
+	if err != nil {
+		t.Fatalf("marshal json: %v", err)
+	}
+	var decoded []APIKey
+	if err = json.Unmarshal(raw, &decoded); err != nil {
+		t.Fatalf("unmarshal json %s: %v", raw, err)
+	}
+	if decoded[0].Key != "plain-key" || decoded[1].Key != "team-key" || decoded[1].TokensPerDay != 1000 || !decoded[1].ExpiresAt.Equal(team.ExpiresAt) {
+		t.Fatalf("json round trip mismatch: %s", raw)
+	}
+}
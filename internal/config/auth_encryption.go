@@ -0,0 +1,39 @@
+package config
+
+import (
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// SanitizeAuthEncryption trims auth encryption keys and drops keys without an id, without a
+// key source, or whose id repeats an earlier key.
+func (cfg *Config) SanitizeAuthEncryption() {
+	if cfg == nil || len(cfg.AuthEncryption.Keys) == 0 {
+		return
+	}
+	seen := make(map[string]struct{}, len(cfg.AuthEncryption.Keys))
+	out := make([]AuthEncryptionKey, 0, len(cfg.AuthEncryption.Keys))
+	for i := range cfg.AuthEncryption.Keys {
+		key := cfg.AuthEncryption.Keys[i]
+		key.ID = strings.TrimSpace(key.ID)
+		key.Env = strings.TrimSpace(key.Env)
+		key.File = strings.TrimSpace(key.File)
+		fields := log.Fields{"key_index": i + 1, "key_id": key.ID}
+		switch {
+		case key.ID == "":
+			log.WithFields(fields).Warn("auth encryption key dropped: missing id")
+			continue
+		case key.Env == "" && key.File == "":
+			log.WithFields(fields).Warn("auth encryption key dropped: set env or file")
+			continue
+		}
+		if _, exists := seen[key.ID]; exists {
+			log.WithFields(fields).Warn("auth encryption key dropped: duplicate id")
+			continue
+		}
+		seen[key.ID] = struct{}{}
+		out = append(out, key)
+	}
+	cfg.AuthEncryption.Keys = out
+}
@@ -21,6 +21,27 @@ import (
 const (
 	DefaultPanelGitHubRepository = "https://github.com/router-for-me/Cli-Proxy-API-Management-Center"
 	DefaultPprofAddr             = "127.0.0.1:8316"
+
+	DefaultResponseCacheTTLSeconds = 300
+	DefaultResponseCacheMaxEntries = 1000
+	DefaultResponseCacheMaxSizeMB  = 64
+
+	DefaultResponseStoreTTLSeconds = 86400
+	DefaultResponseStoreMaxEntries = 1000
+
+	DefaultBatchConcurrency   = 4
+	DefaultBatchMaxRequests   = 100000
+	DefaultBatchMaxFileSizeMB = 200
+	DefaultBatchRetentionDays = 29
+	DefaultFilesMaxFileSizeMB = 100
+
+	DefaultSessionAffinityHeader     = "X-Session-ID"
+	DefaultSessionAffinityTTLSeconds = 3600
+
+	DefaultWebhookMaxRetries     = 3
+	DefaultWebhookTimeoutSeconds = 10
+
+	DefaultAuditLogRetentionDays = 90
 )
 
 // Config represents the application's configuration, loaded from a YAML file.
@@ -41,12 +62,18 @@ type Config struct {
 	// AuthDir is the directory where authentication token files are stored.
 	AuthDir string `yaml:"auth-dir" json:"-"`
 
+	// AuthEncryption encrypts auth files at rest in the auth directory and store backends.
+	AuthEncryption AuthEncryptionConfig `yaml:"auth-encryption" json:"-"`
+
 	// Debug enables or disables debug-level logging and other debug features.
 	Debug bool `yaml:"debug" json:"debug"`
 
 	// Pprof config controls the optional pprof HTTP debug server.
 	Pprof PprofConfig `yaml:"pprof" json:"pprof"`
 
+	// Metrics config controls the optional Prometheus/OpenMetrics scrape endpoint.
+	Metrics MetricsConfig `yaml:"metrics" json:"metrics"`
+
 	// CommercialMode disables high-overhead HTTP middleware features to minimize per-request memory usage.
 	CommercialMode bool `yaml:"commercial-mode" json:"commercial-mode"`
 
@@ -64,6 +91,39 @@ type Config struct {
 	// UsageStatisticsEnabled toggles in-memory usage aggregation; when false, usage data is discarded.
 	UsageStatisticsEnabled bool `yaml:"usage-statistics-enabled" json:"usage-statistics-enabled"`
 
+	// UsagePersistence configures durable storage for usage records.
+	UsagePersistence UsagePersistenceConfig `yaml:"usage-persistence" json:"usage-persistence"`
+
+	// SharedState shares credential cooldowns, quota marks, rotation cursors and refresh leases
+	// between instances serving the same auths.
+	SharedState SharedStateConfig `yaml:"shared-state" json:"shared-state"`
+
+	// ResponseCache configures the optional cache of upstream completions.
+	ResponseCache ResponseCacheConfig `yaml:"response-cache" json:"response-cache"`
+
+	// ResponseStore keeps Responses API results for previous_response_id and the
+	// /v1/responses/{id} endpoints.
+	ResponseStore ResponseStoreConfig `yaml:"response-store" json:"response-store"`
+
+	// Batches enables the Message Batches and OpenAI Batch API emulation.
+	Batches BatchConfig `yaml:"batches" json:"batches"`
+
+	// Files enables the /v1/files upload store referenced by file ids in requests.
+	Files FilesConfig `yaml:"files" json:"files"`
+
+	// ContentPolicy configures redaction and blocking of sensitive content in prompts and completions.
+	ContentPolicy ContentPolicyConfig `yaml:"content-policy" json:"content-policy"`
+
+	// Pricing configures the model prices used to compute the cost of usage records and
+	// the optional spend budgets of client keys.
+	Pricing PricingConfig `yaml:"pricing" json:"pricing"`
+
+	// Webhooks deliver credential, quota and model catalog events to external endpoints.
+	Webhooks []WebhookConfig `yaml:"webhooks,omitempty" json:"webhooks,omitempty"`
+
+	// AuditLog records every mutating management API request.
+	AuditLog AuditLogConfig `yaml:"audit-log" json:"audit-log"`
+
 	// DisableCooling disables quota cooldown scheduling when true.
 	DisableCooling bool `yaml:"disable-cooling" json:"disable-cooling"`
 
@@ -134,6 +194,10 @@ type Config struct {
 	// gemini-api-key, codex-api-key, claude-api-key, openai-compatibility, vertex-api-key, and ampcode.
 	OAuthModelAlias map[string][]OAuthModelAlias `yaml:"oauth-model-alias,omitempty" json:"oauth-model-alias,omitempty"`
 
+	// ModelFallbacks defines global fallback chains tried, in order, when every credential
+	// of a model fails with a cooldown, quota or server error before any output is sent.
+	ModelFallbacks []ModelFallback `yaml:"model-fallbacks,omitempty" json:"model-fallbacks,omitempty"`
+
 	// Payload defines default and override rules for provider payload parameters.
 	Payload PayloadConfig `yaml:"payload" json:"payload"`
 
@@ -180,6 +244,258 @@ type PprofConfig struct {
 	Addr string `yaml:"addr" json:"addr"`
 }
 
+// UsagePersistenceConfig configures durable storage for usage records.
+type UsagePersistenceConfig struct {
+	// Backend selects the storage backend: "" (disabled), "file" or "postgres".
+	// The postgres backend reuses the PGSTORE_* connection of the Postgres-backed token store.
+	Backend string `yaml:"backend" json:"backend"`
+	// Dir is the directory used by the file backend. Relative paths resolve against the
+	// config file directory. Defaults to "usage" next to the config file.
+	Dir string `yaml:"dir,omitempty" json:"dir,omitempty"`
+	// Table overrides the Postgres table name (default "usage_records").
+	Table string `yaml:"table,omitempty" json:"table,omitempty"`
+	// RestoreDays reloads this many days of persisted records into the in-memory statistics
+	// at startup so /usage survives restarts. 0 disables restoring.
+	RestoreDays int `yaml:"restore-days" json:"restore-days"`
+}
+
+// SharedStateConfig configures the backend that synchronizes credential state between instances.
+type SharedStateConfig struct {
+	// Backend selects the backend: "" (disabled), "file" or "postgres".
+	// The file backend suits instances on one host or sharing a volume; the postgres backend
+	// reuses the PGSTORE_* connection of the Postgres-backed token store.
+	Backend string `yaml:"backend" json:"backend"`
+	// Dir is the directory used by the file backend. Relative paths resolve against the
+	// config file directory. Defaults to "shared-state" next to the config file.
+	Dir string `yaml:"dir,omitempty" json:"dir,omitempty"`
+	// Table overrides the Postgres table name prefix (default "shared_state").
+	Table string `yaml:"table,omitempty" json:"table,omitempty"`
+	// InstanceID identifies this instance. Defaults to the host name and process id.
+	InstanceID string `yaml:"instance-id,omitempty" json:"instance-id,omitempty"`
+	// SyncIntervalSeconds is how often state published by other instances is pulled. Defaults to 2.
+	SyncIntervalSeconds int `yaml:"sync-interval-seconds,omitempty" json:"sync-interval-seconds,omitempty"`
+	// ShareRoundRobin makes round-robin rotation use cursors shared by all instances, at the
+	// cost of one backend round trip per pick.
+	ShareRoundRobin bool `yaml:"share-round-robin" json:"share-round-robin"`
+}
+
+// ResponseCacheConfig configures the exact-match cache of model responses.
+type ResponseCacheConfig struct {
+	// Enable toggles the cache.
+	Enable bool `yaml:"enable" json:"enable"`
+	// Backend selects where entries live: "memory" (default) or "disk".
+	Backend string `yaml:"backend,omitempty" json:"backend,omitempty"`
+	// Dir is the directory used by the disk backend. Relative paths resolve against the
+	// config file directory. Defaults to "response-cache" next to the config file.
+	Dir string `yaml:"dir,omitempty" json:"dir,omitempty"`
+	// TTLSeconds is how long an entry is served. Defaults to 300.
+	TTLSeconds int `yaml:"ttl-seconds,omitempty" json:"ttl-seconds,omitempty"`
+	// MaxEntries bounds the number of cached responses. Defaults to 1000.
+	MaxEntries int `yaml:"max-entries,omitempty" json:"max-entries,omitempty"`
+	// MaxSizeMB bounds the total size of cached responses. Defaults to 64.
+	MaxSizeMB int `yaml:"max-size-mb,omitempty" json:"max-size-mb,omitempty"`
+}
+
+// ResponseStoreConfig configures server-side storage of Responses API results. Stored
+// responses let requests continue a conversation with previous_response_id whichever
+// provider serves them.
+type ResponseStoreConfig struct {
+	// Enable toggles the store.
+	Enable bool `yaml:"enable" json:"enable"`
+	// Backend selects where responses live: "memory" (default), "disk" or "postgres".
+	// The postgres backend reuses the PGSTORE_* connection of the Postgres-backed token store.
+	Backend string `yaml:"backend,omitempty" json:"backend,omitempty"`
+	// Dir is the directory used by the disk backend. Relative paths resolve against the
+	// config file directory. Defaults to "responses" next to the config file.
+	Dir string `yaml:"dir,omitempty" json:"dir,omitempty"`
+	// Table overrides the Postgres table name (default "responses").
+	Table string `yaml:"table,omitempty" json:"table,omitempty"`
+	// TTLSeconds is how long a response stays retrievable. Defaults to 86400.
+	TTLSeconds int `yaml:"ttl-seconds,omitempty" json:"ttl-seconds,omitempty"`
+	// MaxEntries bounds the number of responses kept by the memory backend. Defaults to 1000.
+	MaxEntries int `yaml:"max-entries,omitempty" json:"max-entries,omitempty"`
+}
+
+// BatchConfig configures the emulated Anthropic Message Batches and OpenAI Batch APIs.
+// Batches are queued on disk and executed in the background through the regular
+// credential rotation, so they survive restarts.
+type BatchConfig struct {
+	// Enable toggles the /v1/messages/batches, /v1/batches and /v1/files endpoints.
+	Enable bool `yaml:"enable" json:"enable"`
+	// Dir holds queued batches, their results and uploaded files. Relative paths resolve
+	// against the config file directory. Defaults to "batches" next to the config file.
+	Dir string `yaml:"dir,omitempty" json:"dir,omitempty"`
+	// Concurrency bounds the batch requests executed at once. Defaults to 4.
+	Concurrency int `yaml:"concurrency,omitempty" json:"concurrency,omitempty"`
+	// MaxRequests caps the requests of a single batch. Defaults to 100000.
+	MaxRequests int `yaml:"max-requests,omitempty" json:"max-requests,omitempty"`
+	// MaxFileSizeMB caps files uploaded through /v1/files. Defaults to 200.
+	MaxFileSizeMB int `yaml:"max-file-size-mb,omitempty" json:"max-file-size-mb,omitempty"`
+	// RetentionDays is how long ended batches and their results are kept. Defaults to 29.
+	RetentionDays int `yaml:"retention-days,omitempty" json:"retention-days,omitempty"`
+}
+
+// FilesConfig configures the /v1/files store shared by the Anthropic Files API, OpenAI file
+// uploads and batches. File ids referenced in requests are resolved to inline data before
+// translation, so they work with every backend.
+type FilesConfig struct {
+	// Enable toggles the /v1/files endpoints independently of batches.
+	Enable bool `yaml:"enable" json:"enable"`
+	// Dir holds the uploaded files. Relative paths resolve against the config file directory.
+	// Defaults to "files" next to the config file.
+	Dir string `yaml:"dir,omitempty" json:"dir,omitempty"`
+	// MaxFileSizeMB caps a single upload. Defaults to 100.
+	MaxFileSizeMB int `yaml:"max-file-size-mb,omitempty" json:"max-file-size-mb,omitempty"`
+	// TTLHours deletes files this long after upload. 0 keeps files until they are deleted.
+	TTLHours int `yaml:"ttl-hours,omitempty" json:"ttl-hours,omitempty"`
+}
+
+// ContentPolicyConfig configures the content policy applied to client payloads before translation.
+type ContentPolicyConfig struct {
+	// Enable toggles the policy engine.
+	Enable bool `yaml:"enable" json:"enable"`
+	// ScanResponses also applies the rules to upstream responses. Streamed text is held back
+	// by up to 256 bytes so matches split across chunks are masked before they are sent.
+	ScanResponses bool `yaml:"scan-responses" json:"scan-responses"`
+	// Rules are evaluated in order against every string value of the payload.
+	Rules []ContentPolicyRule `yaml:"rules" json:"rules"`
+}
+
+// ContentPolicyRule describes what to look for, what to do on a match and which requests it covers.
+type ContentPolicyRule struct {
+	// Name identifies the rule in logs and policy errors.
+	Name string `yaml:"name" json:"name"`
+	// Detectors enables built-in detectors: "api-key", "aws-key", "email", "credit-card".
+	Detectors []string `yaml:"detectors,omitempty" json:"detectors,omitempty"`
+	// Patterns are regular expressions matched against payload text.
+	Patterns []string `yaml:"patterns,omitempty" json:"patterns,omitempty"`
+	// Keywords are matched case-insensitively as literal text.
+	Keywords []string `yaml:"keywords,omitempty" json:"keywords,omitempty"`
+	// Action is "mask" (default), "block" (reject with 400) or "log" (record only).
+	Action string `yaml:"action,omitempty" json:"action,omitempty"`
+	// Replacement overrides the mask text. Defaults to "[REDACTED:<detector>]".
+	Replacement string `yaml:"replacement,omitempty" json:"replacement,omitempty"`
+	// APIKeys restricts the rule to these client keys, given as the key or its configured name.
+	APIKeys []string `yaml:"api-keys,omitempty" json:"api-keys,omitempty"`
+	// Models restricts the rule to matching models; Protocol matches the client API format.
+	Models []PayloadModelRule `yaml:"models,omitempty" json:"models,omitempty"`
+}
+
+// PricingConfig configures cost accounting. Prices are in USD per million tokens.
+type PricingConfig struct {
+	// DisableCatalog ignores the bundled price catalog so only Models are priced.
+	DisableCatalog bool `yaml:"disable-catalog" json:"disable-catalog"`
+	// Models extend or override the bundled catalog. Configured entries always take
+	// precedence over bundled ones.
+	Models []ModelPrice `yaml:"models,omitempty" json:"models,omitempty"`
+	// Budgets alert when the spend of a client key crosses a threshold.
+	Budgets []CostBudget `yaml:"budgets,omitempty" json:"budgets,omitempty"`
+}
+
+// ModelPrice prices the models matching Model, optionally only when served by Provider.
+type ModelPrice struct {
+	// Provider restricts the entry to one provider, e.g. "claude", "codex" or "vertex".
+	// Empty matches every provider.
+	Provider string `yaml:"provider,omitempty" json:"provider,omitempty"`
+	// Model is matched case-insensitively and supports '*' wildcards. When several entries
+	// match, exact names win over patterns and longer patterns win over shorter ones.
+	Model string `yaml:"model" json:"model"`
+	// Input prices uncached prompt tokens.
+	Input float64 `yaml:"input" json:"input"`
+	// Output prices completion tokens.
+	Output float64 `yaml:"output" json:"output"`
+	// Reasoning prices thinking tokens. Defaults to Output.
+	Reasoning float64 `yaml:"reasoning,omitempty" json:"reasoning,omitempty"`
+	// CacheRead prices prompt tokens read from the upstream prompt cache. Defaults to Input.
+	CacheRead float64 `yaml:"cache-read,omitempty" json:"cache-read,omitempty"`
+	// CacheWrite prices prompt tokens written to the upstream prompt cache. Defaults to Input.
+	CacheWrite float64 `yaml:"cache-write,omitempty" json:"cache-write,omitempty"`
+}
+
+// CostBudget alerts when the spend of a client key crosses a limit. Budgets only alert;
+// set limits on api-keys entries to reject requests.
+type CostBudget struct {
+	// APIKey identifies the client key the budget applies to; "*" applies it to every key.
+	APIKey string `yaml:"api-key" json:"api-key"`
+	// USDPerDay is the spend allowed per UTC day.
+	USDPerDay float64 `yaml:"usd-per-day,omitempty" json:"usd-per-day,omitempty"`
+	// USDPerMonth is the spend allowed per UTC calendar month.
+	USDPerMonth float64 `yaml:"usd-per-month,omitempty" json:"usd-per-month,omitempty"`
+	// WarnPercent also alerts once the spend reaches this share of a limit, e.g. 80.
+	// Zero only alerts when the limit itself is crossed.
+	WarnPercent int `yaml:"warn-percent,omitempty" json:"warn-percent,omitempty"`
+}
+
+// WebhookConfig describes one receiver of event notifications.
+type WebhookConfig struct {
+	// Name identifies the webhook in logs.
+	Name string `yaml:"name,omitempty" json:"name,omitempty"`
+	// Type selects the delivery format: "json" (default) posts the event as JSON, "slack"
+	// posts a Slack-compatible {"text": ...} message and "command" runs Command with the
+	// event JSON on stdin.
+	Type string `yaml:"type,omitempty" json:"type,omitempty"`
+	// URL receives the POST requests of the json and slack types.
+	URL string `yaml:"url,omitempty" json:"url,omitempty"`
+	// Command is the program and arguments run by the command type. It is not run
+	// through a shell.
+	Command []string `yaml:"command,omitempty" json:"command,omitempty"`
+	// Headers are added to every request.
+	Headers map[string]string `yaml:"headers,omitempty" json:"-"`
+	// Secret signs request bodies with HMAC-SHA256. The signature is sent in the
+	// X-Webhook-Signature header as "sha256=<hex>" over "<timestamp>.<body>", where the
+	// timestamp is the X-Webhook-Timestamp header.
+	Secret string `yaml:"secret,omitempty" json:"-"`
+	// Events lists the event types delivered; '*' wildcards are supported, e.g. "auth.*".
+	// Empty delivers every event.
+	Events []string `yaml:"events,omitempty" json:"events,omitempty"`
+	// MaxRetries is the number of retries with exponential backoff after a failed
+	// delivery. Defaults to 3; negative disables retries.
+	MaxRetries int `yaml:"max-retries,omitempty" json:"max-retries,omitempty"`
+	// TimeoutSeconds bounds each delivery attempt. Defaults to 10.
+	TimeoutSeconds int `yaml:"timeout-seconds,omitempty" json:"timeout-seconds,omitempty"`
+}
+
+// AuditLogConfig configures the append-only log of management API mutations.
+type AuditLogConfig struct {
+	// Enable records mutating /v0/management requests.
+	Enable bool `yaml:"enable" json:"enable"`
+	// Dir is the directory holding the daily log files. Relative paths resolve against the
+	// config file directory. Defaults to "audit" next to the config file.
+	Dir string `yaml:"dir,omitempty" json:"dir,omitempty"`
+	// RetentionDays deletes log files older than this many days. Defaults to 90; negative
+	// keeps entries forever.
+	RetentionDays int `yaml:"retention-days,omitempty" json:"retention-days,omitempty"`
+}
+
+// AuthEncryptionConfig configures envelope encryption of stored auth files.
+type AuthEncryptionConfig struct {
+	// Enable seals auth files on write. With Enable false, configured keys still decrypt
+	// existing files, which are written back as plaintext.
+	Enable bool `yaml:"enable" json:"enable"`
+	// Keys lists the key encryption keys. The first key encrypts; the others are kept
+	// to read files sealed before a rotation.
+	Keys []AuthEncryptionKey `yaml:"keys,omitempty" json:"-"`
+}
+
+// AuthEncryptionKey names a base64-encoded 256-bit key read from the environment or a file.
+type AuthEncryptionKey struct {
+	// ID is stored in every envelope sealed with this key.
+	ID string `yaml:"id" json:"id"`
+	// Env names the environment variable holding the key.
+	Env string `yaml:"env,omitempty" json:"env,omitempty"`
+	// File is the path of a file holding the key.
+	File string `yaml:"file,omitempty" json:"file,omitempty"`
+}
+
+// MetricsConfig holds Prometheus/OpenMetrics endpoint settings.
+type MetricsConfig struct {
+	// Enable exposes GET /metrics on the main server and turns on metric collection.
+	Enable bool `yaml:"enable" json:"enable"`
+	// SecretKey is the bearer token scrapers must present (plaintext or bcrypt hashed).
+	// It is independent of api-keys and the management key. When empty, only localhost may scrape.
+	SecretKey string `yaml:"secret-key" json:"-"`
+}
+
 // RemoteManagement holds management API configuration under 'remote-management'.
 type RemoteManagement struct {
 	// AllowRemote toggles remote (non-localhost) access to management API.
@@ -191,6 +507,21 @@ type RemoteManagement struct {
 	// PanelGitHubRepository overrides the GitHub repository used to fetch the management panel asset.
 	// Accepts either a repository URL (https://github.com/org/repo) or an API releases endpoint.
 	PanelGitHubRepository string `yaml:"panel-github-repository"`
+	// Principals are additional management users whose access is limited by roles. The
+	// secret-key above keeps full access.
+	Principals []ManagementPrincipal `yaml:"principals,omitempty"`
+}
+
+// ManagementPrincipal is a management user authenticated by its own key.
+type ManagementPrincipal struct {
+	// Name identifies the principal in logs and the audit log.
+	Name string `yaml:"name"`
+	// SecretKey is the principal's management key (plaintext or bcrypt hashed). Plaintext
+	// keys are hashed in memory on load.
+	SecretKey string `yaml:"secret-key"`
+	// Roles grant access to route groups: "usage-read", "auth-files-write",
+	// "config-write", "oauth-login" or "admin" for everything.
+	Roles []string `yaml:"roles"`
 }
 
 // QuotaExceeded defines the behavior when API quota limits are exceeded.
@@ -206,8 +537,27 @@ type QuotaExceeded struct {
 // RoutingConfig configures how credentials are selected for requests.
 type RoutingConfig struct {
 	// Strategy selects the credential selection strategy.
-	// Supported values: "round-robin" (default), "fill-first".
+	// Supported values: "round-robin" (default), "fill-first", "weighted-health".
+	// "weighted-health" favours credentials with a high success rate and low latency for the
+	// requested model and only probes demoted ones periodically.
 	Strategy string `yaml:"strategy,omitempty" json:"strategy,omitempty"`
+
+	// SessionAffinity keeps the turns of one conversation on the same credential so the
+	// upstream prompt cache keeps paying off.
+	SessionAffinity SessionAffinityConfig `yaml:"session-affinity" json:"session-affinity"`
+}
+
+// SessionAffinityConfig configures conversation to credential affinity. A conversation is
+// identified by the Header value, the Claude metadata.user_id, the Responses
+// prompt_cache_key or previous_response_id, or else a hash of its leading messages.
+type SessionAffinityConfig struct {
+	// Enable toggles session affinity.
+	Enable bool `yaml:"enable" json:"enable"`
+	// Header names the client header carrying a session id. Defaults to "X-Session-ID".
+	Header string `yaml:"header,omitempty" json:"header,omitempty"`
+	// TTLSeconds is how long a conversation stays bound to its credential after its last
+	// request. Defaults to 3600.
+	TTLSeconds int `yaml:"ttl-seconds,omitempty" json:"ttl-seconds,omitempty"`
 }
 
 // OAuthModelAlias defines a model ID alias for a specific channel.
@@ -568,6 +918,13 @@ type OpenAICompatibilityModel struct {
 
 	// Alias is the model name alias that clients will use to reference this model.
 	Alias string `yaml:"alias" json:"alias"`
+
+	// Embedding marks the model as an embedding model served through /v1/embeddings.
+	Embedding bool `yaml:"embedding,omitempty" json:"embedding,omitempty"`
+
+	// Completions marks the model as serving the legacy /completions endpoint upstream, so
+	// /v1/completions requests, including suffix (fill-in-the-middle), are forwarded unchanged.
+	Completions bool `yaml:"completions,omitempty" json:"completions,omitempty"`
 }
 
 func (m OpenAICompatibilityModel) GetName() string  { return m.Name }
@@ -660,6 +1017,11 @@ func LoadConfigOptional(configFile string, optional bool) (*Config, error) {
 		_ = SaveConfigPreserveCommentsUpdateNestedScalar(configFile, []string{"remote-management", "secret-key"}, hashed)
 	}
 
+	// Drop unusable management principals and hash their plaintext keys.
+	if errPrincipals := cfg.SanitizeManagementPrincipals(); errPrincipals != nil {
+		return nil, errPrincipals
+	}
+
 	cfg.RemoteManagement.PanelGitHubRepository = strings.TrimSpace(cfg.RemoteManagement.PanelGitHubRepository)
 	if cfg.RemoteManagement.PanelGitHubRepository == "" {
 		cfg.RemoteManagement.PanelGitHubRepository = DefaultPanelGitHubRepository
@@ -670,6 +1032,95 @@ func LoadConfigOptional(configFile string, optional bool) (*Config, error) {
 		cfg.Pprof.Addr = DefaultPprofAddr
 	}
 
+	cfg.Metrics.SecretKey = strings.TrimSpace(cfg.Metrics.SecretKey)
+
+	cfg.UsagePersistence.Backend = strings.ToLower(strings.TrimSpace(cfg.UsagePersistence.Backend))
+	cfg.UsagePersistence.Dir = strings.TrimSpace(cfg.UsagePersistence.Dir)
+	cfg.UsagePersistence.Table = strings.TrimSpace(cfg.UsagePersistence.Table)
+	if cfg.UsagePersistence.RestoreDays < 0 {
+		cfg.UsagePersistence.RestoreDays = 0
+	}
+
+	cfg.SharedState.Backend = strings.ToLower(strings.TrimSpace(cfg.SharedState.Backend))
+	cfg.SharedState.Dir = strings.TrimSpace(cfg.SharedState.Dir)
+	cfg.SharedState.Table = strings.TrimSpace(cfg.SharedState.Table)
+	cfg.SharedState.InstanceID = strings.TrimSpace(cfg.SharedState.InstanceID)
+	if cfg.SharedState.SyncIntervalSeconds < 0 {
+		cfg.SharedState.SyncIntervalSeconds = 0
+	}
+
+	cfg.Routing.SessionAffinity.Header = strings.TrimSpace(cfg.Routing.SessionAffinity.Header)
+	if cfg.Routing.SessionAffinity.Header == "" {
+		cfg.Routing.SessionAffinity.Header = DefaultSessionAffinityHeader
+	}
+	if cfg.Routing.SessionAffinity.TTLSeconds <= 0 {
+		cfg.Routing.SessionAffinity.TTLSeconds = DefaultSessionAffinityTTLSeconds
+	}
+
+	cfg.ResponseCache.Backend = strings.ToLower(strings.TrimSpace(cfg.ResponseCache.Backend))
+	if cfg.ResponseCache.Backend == "" {
+		cfg.ResponseCache.Backend = "memory"
+	}
+	cfg.ResponseCache.Dir = strings.TrimSpace(cfg.ResponseCache.Dir)
+	if cfg.ResponseCache.TTLSeconds <= 0 {
+		cfg.ResponseCache.TTLSeconds = DefaultResponseCacheTTLSeconds
+	}
+	if cfg.ResponseCache.MaxEntries <= 0 {
+		cfg.ResponseCache.MaxEntries = DefaultResponseCacheMaxEntries
+	}
+	if cfg.ResponseCache.MaxSizeMB <= 0 {
+		cfg.ResponseCache.MaxSizeMB = DefaultResponseCacheMaxSizeMB
+	}
+
+	cfg.ResponseStore.Backend = strings.ToLower(strings.TrimSpace(cfg.ResponseStore.Backend))
+	if cfg.ResponseStore.Backend == "" {
+		cfg.ResponseStore.Backend = "memory"
+	}
+	cfg.ResponseStore.Dir = strings.TrimSpace(cfg.ResponseStore.Dir)
+	cfg.ResponseStore.Table = strings.TrimSpace(cfg.ResponseStore.Table)
+	if cfg.ResponseStore.TTLSeconds <= 0 {
+		cfg.ResponseStore.TTLSeconds = DefaultResponseStoreTTLSeconds
+	}
+	if cfg.ResponseStore.MaxEntries <= 0 {
+		cfg.ResponseStore.MaxEntries = DefaultResponseStoreMaxEntries
+	}
+
+	cfg.Batches.Dir = strings.TrimSpace(cfg.Batches.Dir)
+	if cfg.Batches.Concurrency <= 0 {
+		cfg.Batches.Concurrency = DefaultBatchConcurrency
+	}
+	if cfg.Batches.MaxRequests <= 0 {
+		cfg.Batches.MaxRequests = DefaultBatchMaxRequests
+	}
+	if cfg.Batches.MaxFileSizeMB <= 0 {
+		cfg.Batches.MaxFileSizeMB = DefaultBatchMaxFileSizeMB
+	}
+	if cfg.Batches.RetentionDays <= 0 {
+		cfg.Batches.RetentionDays = DefaultBatchRetentionDays
+	}
+
+	cfg.Files.Dir = strings.TrimSpace(cfg.Files.Dir)
+	if cfg.Files.MaxFileSizeMB <= 0 {
+		cfg.Files.MaxFileSizeMB = DefaultFilesMaxFileSizeMB
+	}
+	cfg.Files.TTLHours = max(cfg.Files.TTLHours, 0)
+
+	cfg.SanitizeContentPolicy()
+
+	// Drop unusable model prices and budgets.
+	cfg.SanitizePricing()
+
+	// Drop webhooks without a destination and apply delivery defaults.
+	cfg.SanitizeWebhooks()
+
+	cfg.AuditLog.Dir = strings.TrimSpace(cfg.AuditLog.Dir)
+	if cfg.AuditLog.RetentionDays == 0 {
+		cfg.AuditLog.RetentionDays = DefaultAuditLogRetentionDays
+	}
+
+	// Drop auth encryption keys without an id or key source.
+	cfg.SanitizeAuthEncryption()
+
 	if cfg.LogsMaxTotalSizeMB < 0 {
 		cfg.LogsMaxTotalSizeMB = 0
 	}
@@ -682,6 +1133,9 @@ func LoadConfigOptional(configFile string, optional bool) (*Config, error) {
 		cfg.MaxRetryCredentials = 0
 	}
 
+	// Normalize client API key entries and their limits.
+	cfg.SanitizeAPIKeys()
+
 	// Sanitize Gemini API key configuration and migrate legacy entries.
 	cfg.SanitizeGeminiKeys()
 
@@ -709,6 +1163,9 @@ func LoadConfigOptional(configFile string, optional bool) (*Config, error) {
 	// Normalize global OAuth model name aliases.
 	cfg.SanitizeOAuthModelAlias()
 
+	// Normalize model fallback chains.
+	cfg.SanitizeModelFallbacks()
+
 	// Validate raw payload rules and drop invalid entries.
 	cfg.SanitizePayloadRules()
 
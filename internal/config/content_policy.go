@@ -0,0 +1,77 @@
+package config
+
+import (
+	"regexp"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Content policy actions.
+const (
+	ContentPolicyActionMask  = "mask"
+	ContentPolicyActionBlock = "block"
+	ContentPolicyActionLog   = "log"
+)
+
+// SanitizeContentPolicy normalizes content policy rules and drops rules that cannot be
+// applied: unknown actions, invalid patterns, or rules without anything to match.
+func (cfg *Config) SanitizeContentPolicy() {
+	if cfg == nil || len(cfg.ContentPolicy.Rules) == 0 {
+		return
+	}
+	out := make([]ContentPolicyRule, 0, len(cfg.ContentPolicy.Rules))
+	for i := range cfg.ContentPolicy.Rules {
+		rule := cfg.ContentPolicy.Rules[i]
+		rule.Name = strings.TrimSpace(rule.Name)
+		rule.Action = strings.ToLower(strings.TrimSpace(rule.Action))
+		if rule.Action == "" {
+			rule.Action = ContentPolicyActionMask
+		}
+		fields := log.Fields{"rule_index": i + 1, "rule": rule.Name}
+		switch rule.Action {
+		case ContentPolicyActionMask, ContentPolicyActionBlock, ContentPolicyActionLog:
+		default:
+			log.WithFields(fields).Warnf("content policy rule dropped: unknown action %q", rule.Action)
+			continue
+		}
+		rule.Detectors = normalizeContentPolicyList(rule.Detectors, true)
+		rule.Patterns = normalizeContentPolicyList(rule.Patterns, false)
+		rule.Keywords = normalizeContentPolicyList(rule.Keywords, false)
+		rule.APIKeys = normalizeContentPolicyList(rule.APIKeys, false)
+		invalid := false
+		for _, pattern := range rule.Patterns {
+			if _, err := regexp.Compile(pattern); err != nil {
+				log.WithFields(fields).Warnf("content policy rule dropped: invalid pattern: %v", err)
+				invalid = true
+				break
+			}
+		}
+		if invalid {
+			continue
+		}
+		if len(rule.Detectors) == 0 && len(rule.Patterns) == 0 && len(rule.Keywords) == 0 {
+			log.WithFields(fields).Warn("content policy rule dropped: no detectors, patterns or keywords")
+			continue
+		}
+		out = append(out, rule)
+	}
+	cfg.ContentPolicy.Rules = out
+}
+
+func normalizeContentPolicyList(values []string, lower bool) []string {
+	if len(values) == 0 {
+		return nil
+	}
+	out := make([]string, 0, len(values))
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if lower {
+			value = strings.ToLower(value)
+		}
+		if value != "" {
+			out = append(out, value)
+		}
+	}
+	return out
+}
@@ -0,0 +1,80 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Management roles. Each role except admin grants one group of management routes.
+const (
+	ManagementRoleUsageRead      = "usage-read"
+	ManagementRoleAuthFilesWrite = "auth-files-write"
+	ManagementRoleConfigWrite    = "config-write"
+	ManagementRoleOAuthLogin     = "oauth-login"
+	ManagementRoleAdmin          = "admin"
+)
+
+// IsManagementRole reports whether role is a known management role.
+func IsManagementRole(role string) bool {
+	switch role {
+	case ManagementRoleUsageRead, ManagementRoleAuthFilesWrite, ManagementRoleConfigWrite, ManagementRoleOAuthLogin, ManagementRoleAdmin:
+		return true
+	default:
+		return false
+	}
+}
+
+// SanitizeManagementPrincipals normalizes management principals and hashes plaintext
+// keys with bcrypt. Principals without a name, key or known role are dropped, as are
+// unknown roles and principals repeating an earlier name.
+func (cfg *Config) SanitizeManagementPrincipals() error {
+	if cfg == nil || len(cfg.RemoteManagement.Principals) == 0 {
+		return nil
+	}
+	seen := make(map[string]struct{}, len(cfg.RemoteManagement.Principals))
+	out := make([]ManagementPrincipal, 0, len(cfg.RemoteManagement.Principals))
+	plaintext := 0
+	for i, principal := range cfg.RemoteManagement.Principals {
+		principal.Name = strings.TrimSpace(principal.Name)
+		principal.SecretKey = strings.TrimSpace(principal.SecretKey)
+		fields := log.Fields{"principal_index": i + 1, "principal": principal.Name}
+		if principal.Name == "" || principal.SecretKey == "" {
+			log.WithFields(fields).Warn("management principal dropped: missing name or secret-key")
+			continue
+		}
+		if _, dup := seen[principal.Name]; dup {
+			log.WithFields(fields).Warn("management principal dropped: duplicated name")
+			continue
+		}
+		roles := make([]string, 0, len(principal.Roles))
+		for _, role := range normalizeContentPolicyList(principal.Roles, true) {
+			if !IsManagementRole(role) {
+				log.WithFields(fields).Warnf("management principal role ignored: unknown role %q", role)
+				continue
+			}
+			roles = append(roles, role)
+		}
+		if len(roles) == 0 {
+			log.WithFields(fields).Warn("management principal dropped: no known role")
+			continue
+		}
+		principal.Roles = roles
+		if !looksLikeBcrypt(principal.SecretKey) {
+			hashed, errHash := hashSecret(principal.SecretKey)
+			if errHash != nil {
+				return fmt.Errorf("failed to hash management key of principal %s: %w", principal.Name, errHash)
+			}
+			principal.SecretKey = hashed
+			plaintext++
+		}
+		seen[principal.Name] = struct{}{}
+		out = append(out, principal)
+	}
+	if plaintext > 0 {
+		log.Warnf("%d management principal key(s) are stored in plaintext; replace them with bcrypt hashes", plaintext)
+	}
+	cfg.RemoteManagement.Principals = out
+	return nil
+}
@@ -0,0 +1,65 @@
+package config
+
+import "strings"
+
+// ModelFallback maps a model to the models that serve its requests when it is unavailable.
+type ModelFallback struct {
+	// Model is the requested model name.
+	Model string `yaml:"model" json:"model"`
+	// Fallbacks lists the models to try next, in order. They may belong to other providers.
+	Fallbacks []string `yaml:"fallbacks" json:"fallbacks"`
+}
+
+// SanitizeModelFallbacks trims fallback chains, drops empty entries, self references and
+// duplicates, and merges chains declared more than once for the same model.
+func (cfg *Config) SanitizeModelFallbacks() {
+	if cfg == nil || len(cfg.ModelFallbacks) == 0 {
+		return
+	}
+	out := make([]ModelFallback, 0, len(cfg.ModelFallbacks))
+	index := make(map[string]int, len(cfg.ModelFallbacks))
+	for _, entry := range cfg.ModelFallbacks {
+		model := strings.TrimSpace(entry.Model)
+		if model == "" {
+			continue
+		}
+		key := strings.ToLower(model)
+		pos, exists := index[key]
+		if !exists {
+			pos = len(out)
+			index[key] = pos
+			out = append(out, ModelFallback{Model: model})
+		}
+		seen := make(map[string]struct{}, len(out[pos].Fallbacks)+1)
+		seen[key] = struct{}{}
+		for _, fallback := range out[pos].Fallbacks {
+			seen[strings.ToLower(fallback)] = struct{}{}
+		}
+		for _, fallback := range entry.Fallbacks {
+			fallback = strings.TrimSpace(fallback)
+			if fallback == "" {
+				continue
+			}
+			if _, dup := seen[strings.ToLower(fallback)]; dup {
+				continue
+			}
+			seen[strings.ToLower(fallback)] = struct{}{}
+			out[pos].Fallbacks = append(out[pos].Fallbacks, fallback)
+		}
+	}
+	cfg.ModelFallbacks = out
+}
+
+// ModelFallbackChain returns the fallback models configured for model, matched case-insensitively.
+func (cfg *Config) ModelFallbackChain(model string) []string {
+	if cfg == nil {
+		return nil
+	}
+	model = strings.TrimSpace(model)
+	for i := range cfg.ModelFallbacks {
+		if strings.EqualFold(cfg.ModelFallbacks[i].Model, model) {
+			return cfg.ModelFallbacks[i].Fallbacks
+		}
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package config
+
+import (
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// SanitizePricing normalizes model prices and budgets. Prices without a model or with a
+// negative rate are dropped, as are budgets without an api-key or a limit. Duplicated
+// budget keys keep only the last entry.
+func (cfg *Config) SanitizePricing() {
+	if cfg == nil {
+		return
+	}
+	if len(cfg.Pricing.Models) > 0 {
+		out := make([]ModelPrice, 0, len(cfg.Pricing.Models))
+		for i := range cfg.Pricing.Models {
+			price := cfg.Pricing.Models[i]
+			price.Provider = strings.ToLower(strings.TrimSpace(price.Provider))
+			price.Model = strings.ToLower(strings.TrimSpace(price.Model))
+			fields := log.Fields{"price_index": i + 1, "model": price.Model}
+			switch {
+			case price.Model == "":
+				log.WithFields(fields).Warn("model price dropped: missing model")
+				continue
+			case price.Input < 0 || price.Output < 0 || price.Reasoning < 0 || price.CacheRead < 0 || price.CacheWrite < 0:
+				log.WithFields(fields).Warn("model price dropped: negative rate")
+				continue
+			}
+			out = append(out, price)
+		}
+		cfg.Pricing.Models = out
+	}
+	if len(cfg.Pricing.Budgets) > 0 {
+		index := make(map[string]int, len(cfg.Pricing.Budgets))
+		out := make([]CostBudget, 0, len(cfg.Pricing.Budgets))
+		for _, budget := range cfg.Pricing.Budgets {
+			budget.APIKey = strings.TrimSpace(budget.APIKey)
+			budget.USDPerDay = max(budget.USDPerDay, 0)
+			budget.USDPerMonth = max(budget.USDPerMonth, 0)
+			budget.WarnPercent = min(max(budget.WarnPercent, 0), 100)
+			if budget.APIKey == "" || (budget.USDPerDay == 0 && budget.USDPerMonth == 0) {
+				continue
+			}
+			if existing, ok := index[budget.APIKey]; ok {
+				out[existing] = budget
+				continue
+			}
+			index[budget.APIKey] = len(out)
+			out = append(out, budget)
+		}
+		cfg.Pricing.Budgets = out
+	}
+}
@@ -4,6 +4,8 @@
 // debug settings, proxy configuration, and API keys.
 package config
 
+import sdkaccess "github.com/router-for-me/CLIProxyAPI/v6/sdk/access"
+
 // SDKConfig represents the application's configuration, loaded from a YAML file.
 type SDKConfig struct {
 	// ProxyURL is the URL of an optional proxy server to use for outbound requests.
@@ -18,7 +20,12 @@ type SDKConfig struct {
 	RequestLog bool `yaml:"request-log" json:"request-log"`
 
 	// APIKeys is a list of keys for authenticating clients to this proxy server.
-	APIKeys []string `yaml:"api-keys" json:"api-keys"`
+	// Entries may be plain key strings or objects with metadata, scopes and limits.
+	APIKeys []APIKey `yaml:"api-keys" json:"api-keys"`
+
+	// Access configures additional request authentication providers, such as JWT
+	// validation, consulted alongside APIKeys.
+	Access sdkaccess.AccessConfig `yaml:"access,omitempty" json:"access,omitempty"`
 
 	// PassthroughHeaders controls whether upstream response headers are forwarded to downstream clients.
 	// Default is false (disabled).
@@ -30,6 +37,45 @@ type SDKConfig struct {
 	// NonStreamKeepAliveInterval controls how often blank lines are emitted for non-streaming responses.
 	// <= 0 disables keep-alives. Value is in seconds.
 	NonStreamKeepAliveInterval int `yaml:"nonstream-keepalive-interval,omitempty" json:"nonstream-keepalive-interval,omitempty"`
+
+	// StructuredOutput configures validation of json_schema response formats on Chat Completions.
+	StructuredOutput StructuredOutputConfig `yaml:"structured-output,omitempty" json:"structured-output,omitempty"`
+}
+
+// StructuredOutputConfig controls how json_schema responses are checked against their schema.
+type StructuredOutputConfig struct {
+	// Repair retries a non-streaming request once, quoting the validation errors, when the
+	// answer does not match the requested schema.
+	Repair bool `yaml:"repair,omitempty" json:"repair,omitempty"`
+}
+
+// APIKeyLimit holds the quotas enforced for a single client API key.
+// Zero values disable the corresponding limit.
+type APIKeyLimit struct {
+	// RequestsPerMinute caps the number of generation requests started per calendar minute.
+	RequestsPerMinute int `yaml:"requests-per-minute,omitempty" json:"requests-per-minute,omitempty"`
+
+	// TokensPerDay caps the total tokens consumed per UTC day.
+	TokensPerDay int64 `yaml:"tokens-per-day,omitempty" json:"tokens-per-day,omitempty"`
+
+	// TokensPerMonth caps the total tokens consumed per UTC calendar month.
+	TokensPerMonth int64 `yaml:"tokens-per-month,omitempty" json:"tokens-per-month,omitempty"`
+
+	// MaxConcurrentStreams caps the number of streaming responses open at the same time.
+	MaxConcurrentStreams int `yaml:"max-concurrent-streams,omitempty" json:"max-concurrent-streams,omitempty"`
+}
+
+// IsZero reports whether no limit is set.
+func (l APIKeyLimit) IsZero() bool {
+	return l == APIKeyLimit{}
+}
+
+func (l APIKeyLimit) sanitized() APIKeyLimit {
+	l.RequestsPerMinute = max(l.RequestsPerMinute, 0)
+	l.TokensPerDay = max(l.TokensPerDay, 0)
+	l.TokensPerMonth = max(l.TokensPerMonth, 0)
+	l.MaxConcurrentStreams = max(l.MaxConcurrentStreams, 0)
+	return l
 }
 
 // StreamingConfig holds server streaming behavior configuration.
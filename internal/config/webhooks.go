@@ -0,0 +1,57 @@
+package config
+
+import (
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Webhook delivery types.
+const (
+	WebhookTypeJSON    = "json"
+	WebhookTypeSlack   = "slack"
+	WebhookTypeCommand = "command"
+)
+
+// SanitizeWebhooks normalizes webhook entries, applies delivery defaults and drops
+// entries with an unknown type or without a URL or command.
+func (cfg *Config) SanitizeWebhooks() {
+	if cfg == nil || len(cfg.Webhooks) == 0 {
+		return
+	}
+	out := make([]WebhookConfig, 0, len(cfg.Webhooks))
+	for i := range cfg.Webhooks {
+		hook := cfg.Webhooks[i]
+		hook.Name = strings.TrimSpace(hook.Name)
+		hook.Type = strings.ToLower(strings.TrimSpace(hook.Type))
+		if hook.Type == "" {
+			hook.Type = WebhookTypeJSON
+		}
+		hook.URL = strings.TrimSpace(hook.URL)
+		hook.Events = normalizeContentPolicyList(hook.Events, true)
+		fields := log.Fields{"webhook_index": i + 1, "webhook": hook.Name}
+		switch hook.Type {
+		case WebhookTypeJSON, WebhookTypeSlack:
+			if hook.URL == "" {
+				log.WithFields(fields).Warn("webhook dropped: missing url")
+				continue
+			}
+		case WebhookTypeCommand:
+			if len(hook.Command) == 0 || strings.TrimSpace(hook.Command[0]) == "" {
+				log.WithFields(fields).Warn("webhook dropped: missing command")
+				continue
+			}
+		default:
+			log.WithFields(fields).Warnf("webhook dropped: unknown type %q", hook.Type)
+			continue
+		}
+		if hook.MaxRetries == 0 {
+			hook.MaxRetries = DefaultWebhookMaxRetries
+		}
+		if hook.TimeoutSeconds <= 0 {
+			hook.TimeoutSeconds = DefaultWebhookTimeoutSeconds
+		}
+		out = append(out, hook)
+	}
+	cfg.Webhooks = out
+}
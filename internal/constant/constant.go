@@ -22,6 +22,15 @@ const (
 	// OpenaiResponse represents the OpenAI response format identifier.
 	OpenaiResponse = "openai-response"
 
+	// OpenAIEmbedding represents the OpenAI embeddings request format identifier.
+	OpenAIEmbedding = "openai-embedding"
+
+	// GeminiEmbedding represents the Gemini batchEmbedContents request format identifier.
+	GeminiEmbedding = "gemini-embedding"
+
+	// OpenAIImage represents the OpenAI image generation and edit request format identifier.
+	OpenAIImage = "openai-image"
+
 	// Antigravity represents the Antigravity response format identifier.
 	Antigravity = "antigravity"
 
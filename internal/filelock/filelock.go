@@ -0,0 +1,73 @@
+// Package filelock provides a cross-process lock held through an advisory lock on a lock file.
+// The operating system releases the lock when its holder exits, so a crashed holder never
+// leaves the lock behind.
+package filelock
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const retryInterval = 10 * time.Millisecond
+
+// Lock is an advisory lock on the file at a fixed path. Separate Lock values for the same path
+// exclude each other, within one process as well as across processes.
+type Lock struct {
+	path string
+
+	mu   sync.Mutex
+	file *os.File
+}
+
+// New returns the lock held on path. The file is created when missing and is left in place
+// after Unlock; removing it would let a waiter lock a file that is no longer linked.
+func New(path string) *Lock {
+	return &Lock{path: path}
+}
+
+// Lock blocks until the lock is held or ctx ends.
+func (l *Lock) Lock(ctx context.Context) error {
+	if err := os.MkdirAll(filepath.Dir(l.path), 0o700); err != nil {
+		return fmt.Errorf("file lock: create directory: %w", err)
+	}
+	file, err := os.OpenFile(l.path, os.O_CREATE|os.O_RDWR, 0o600)
+	if err != nil {
+		return fmt.Errorf("file lock: open %s: %w", l.path, err)
+	}
+	for {
+		locked, errLock := tryLock(file)
+		if errLock != nil {
+			_ = file.Close()
+			return fmt.Errorf("file lock: lock %s: %w", l.path, errLock)
+		}
+		if locked {
+			l.mu.Lock()
+			l.file = file
+			l.mu.Unlock()
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			_ = file.Close()
+			return fmt.Errorf("file lock: wait for %s: %w", l.path, ctx.Err())
+		case <-time.After(retryInterval):
+		}
+	}
+}
+
+// Unlock releases the lock. It does nothing when the lock is not held.
+func (l *Lock) Unlock() {
+	l.mu.Lock()
+	file := l.file
+	l.file = nil
+	l.mu.Unlock()
+	if file == nil {
+		return
+	}
+	_ = unlock(file)
+	_ = file.Close()
+}
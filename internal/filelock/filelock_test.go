@@ -0,0 +1,54 @@
+package filelock
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLockExcludesOtherHolders(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "locks", "a.lock")
+	first := New(path)
+	if err := first.Lock(context.Background()); err != nil {
+		t.Fatalf("first Lock: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := New(path).Lock(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("second Lock while held = %v, want deadline exceeded", err)
+	}
+	first.Unlock()
+	first.Unlock()
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		holders int
+	)
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			lock := New(path)
+			if err := lock.Lock(context.Background()); err != nil {
+				t.Errorf("Lock: %v", err)
+				return
+			}
+			mu.Lock()
+			holders++
+			if holders != 1 {
+				t.Errorf("%d holders at once", holders)
+			}
+			mu.Unlock()
+			time.Sleep(time.Millisecond)
+			mu.Lock()
+			holders--
+			mu.Unlock()
+			lock.Unlock()
+		}()
+	}
+	wg.Wait()
+}
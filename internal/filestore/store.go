@@ -0,0 +1,345 @@
+// Package filestore keeps files uploaded through the /v1/files endpoints on local disk.
+// Each file belongs to the client API key that uploaded it and is invisible to other keys,
+// and expires after the store TTL when one is configured.
+package filestore
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+)
+
+var (
+	// ErrNotFound reports an unknown file or a file owned by another client key.
+	ErrNotFound = errors.New("file not found")
+	// ErrTooLarge reports an upload above the configured size limit.
+	ErrTooLarge = errors.New("file exceeds the maximum size")
+)
+
+// File describes a stored file.
+type File struct {
+	ID        string    `json:"id"`
+	Owner     string    `json:"owner,omitempty"`
+	Filename  string    `json:"filename"`
+	Purpose   string    `json:"purpose"`
+	MimeType  string    `json:"mime_type,omitempty"`
+	Bytes     int64     `json:"bytes"`
+	CreatedAt time.Time `json:"created_at"`
+	// ExpiresAt is zero for files kept until deleted.
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Expired reports whether the file has passed its expiry time.
+func (f *File) Expired(now time.Time) bool {
+	return !f.ExpiresAt.IsZero() && !now.Before(f.ExpiresAt)
+}
+
+// purgeInterval spaces out the sweeps that delete expired files.
+const purgeInterval = 10 * time.Minute
+
+// Store keeps one metadata file and one content file per stored file in dir.
+type Store struct {
+	dir      string
+	maxBytes int64
+	ttl      time.Duration
+
+	mu        sync.Mutex
+	lastPurge time.Time
+}
+
+// New opens dir, creating it when missing. maxBytes <= 0 leaves uploads unbounded and
+// ttl <= 0 keeps files until they are deleted.
+func New(dir string, maxBytes int64, ttl time.Duration) (*Store, error) {
+	dir = strings.TrimSpace(dir)
+	if dir == "" {
+		return nil, fmt.Errorf("file store: directory is empty")
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("file store: create directory: %w", err)
+	}
+	return &Store{dir: dir, maxBytes: maxBytes, ttl: max(ttl, 0)}, nil
+}
+
+// MaxBytes returns the upload size limit, or 0 when uploads are unbounded.
+func (s *Store) MaxBytes() int64 { return s.maxBytes }
+
+// Create stores the content of r as a new file owned by apiKey.
+func (s *Store) Create(apiKey, filename, purpose string, r io.Reader) (*File, error) {
+	return s.CreateForOwner(util.APIKeyDigest(apiKey), filename, purpose, r)
+}
+
+// CreateForOwner stores the content of r as a new file owned by the client key with the
+// given util.APIKeyDigest, for callers that only keep the digest.
+func (s *Store) CreateForOwner(owner, filename, purpose string, r io.Reader) (*File, error) {
+	id, err := newID()
+	if err != nil {
+		return nil, err
+	}
+	file := &File{
+		ID:        id,
+		Owner:     owner,
+		Filename:  filepath.Base(strings.TrimSpace(filename)),
+		Purpose:   strings.TrimSpace(purpose),
+		CreatedAt: time.Now().UTC(),
+	}
+	if s.ttl > 0 {
+		file.ExpiresAt = file.CreatedAt.Add(s.ttl)
+	}
+
+	tmp, err := os.CreateTemp(s.dir, id+".*.tmp")
+	if err != nil {
+		return nil, fmt.Errorf("file store: create file: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	reader := r
+	if s.maxBytes > 0 {
+		reader = io.LimitReader(r, s.maxBytes+1)
+	}
+	sniffer := &sniffWriter{}
+	written, err := io.Copy(io.MultiWriter(tmp, sniffer), reader)
+	if errClose := tmp.Close(); err == nil {
+		err = errClose
+	}
+	if err != nil {
+		return nil, fmt.Errorf("file store: write file: %w", err)
+	}
+	if s.maxBytes > 0 && written > s.maxBytes {
+		return nil, ErrTooLarge
+	}
+	file.Bytes = written
+	file.MimeType = detectMimeType(file.Filename, sniffer.head)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.purgeExpiredLocked(file.CreatedAt)
+	if err = os.Rename(tmp.Name(), s.contentPath(id)); err != nil {
+		return nil, fmt.Errorf("file store: write file: %w", err)
+	}
+	if err = s.writeMeta(file); err != nil {
+		_ = os.Remove(s.contentPath(id))
+		return nil, err
+	}
+	return file, nil
+}
+
+// Get returns the file id owned by apiKey.
+func (s *Store) Get(id, apiKey string) (*File, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.getLocked(id, apiKey)
+}
+
+// Open returns the file id owned by apiKey together with its content.
+func (s *Store) Open(id, apiKey string) (*File, io.ReadCloser, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	file, err := s.getLocked(id, apiKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	content, err := os.Open(s.contentPath(file.ID))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil, ErrNotFound
+		}
+		return nil, nil, fmt.Errorf("file store: open file: %w", err)
+	}
+	return file, content, nil
+}
+
+// Delete removes the file id owned by apiKey.
+func (s *Store) Delete(id, apiKey string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	file, err := s.getLocked(id, apiKey)
+	if err != nil {
+		return err
+	}
+	return s.removeLocked(file.ID)
+}
+
+// List returns the files owned by apiKey, newest first. An empty purpose matches every file.
+func (s *Store) List(apiKey, purpose string) ([]*File, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("file store: list files: %w", err)
+	}
+	owner := util.APIKeyDigest(apiKey)
+	now := time.Now()
+	var files []*File
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".json") {
+			continue
+		}
+		file, errRead := s.readMeta(strings.TrimSuffix(name, ".json"))
+		if errRead != nil || file.Owner != owner {
+			continue
+		}
+		if file.Expired(now) {
+			_ = s.removeLocked(file.ID)
+			continue
+		}
+		if purpose != "" && file.Purpose != purpose {
+			continue
+		}
+		files = append(files, file)
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].CreatedAt.After(files[j].CreatedAt) })
+	return files, nil
+}
+
+func (s *Store) getLocked(id, apiKey string) (*File, error) {
+	if !validID(id) {
+		return nil, ErrNotFound
+	}
+	file, err := s.readMeta(id)
+	if err != nil {
+		return nil, err
+	}
+	if file.Owner != util.APIKeyDigest(apiKey) {
+		return nil, ErrNotFound
+	}
+	if file.Expired(time.Now()) {
+		_ = s.removeLocked(file.ID)
+		return nil, ErrNotFound
+	}
+	return file, nil
+}
+
+func (s *Store) removeLocked(id string) error {
+	if err := os.Remove(s.metaPath(id)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("file store: delete file: %w", err)
+	}
+	if err := os.Remove(s.contentPath(id)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("file store: delete file: %w", err)
+	}
+	return nil
+}
+
+// purgeExpiredLocked deletes the expired files of every owner, at most once per purgeInterval.
+func (s *Store) purgeExpiredLocked(now time.Time) {
+	if s.ttl <= 0 || now.Sub(s.lastPurge) < purgeInterval {
+		return
+	}
+	s.lastPurge = now
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".json") {
+			continue
+		}
+		if file, errRead := s.readMeta(strings.TrimSuffix(name, ".json")); errRead == nil && file.Expired(now) {
+			_ = s.removeLocked(file.ID)
+		}
+	}
+}
+
+func (s *Store) readMeta(id string) (*File, error) {
+	data, err := os.ReadFile(s.metaPath(id))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("file store: read file: %w", err)
+	}
+	var file File
+	if err = json.Unmarshal(data, &file); err != nil || file.ID != id {
+		return nil, ErrNotFound
+	}
+	return &file, nil
+}
+
+func (s *Store) writeMeta(file *File) error {
+	data, err := json.Marshal(file)
+	if err != nil {
+		return fmt.Errorf("file store: encode file: %w", err)
+	}
+	tmp := s.metaPath(file.ID) + ".tmp"
+	if err = os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("file store: write file: %w", err)
+	}
+	if err = os.Rename(tmp, s.metaPath(file.ID)); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("file store: write file: %w", err)
+	}
+	return nil
+}
+
+func (s *Store) metaPath(id string) string    { return filepath.Join(s.dir, id+".json") }
+func (s *Store) contentPath(id string) string { return filepath.Join(s.dir, id+".data") }
+
+// sniffWriter keeps the first bytes written to it for content type detection.
+type sniffWriter struct {
+	head []byte
+}
+
+func (w *sniffWriter) Write(p []byte) (int, error) {
+	if room := 512 - len(w.head); room > 0 {
+		w.head = append(w.head, p[:min(room, len(p))]...)
+	}
+	return len(p), nil
+}
+
+// detectMimeType prefers the filename extension and falls back to sniffing the content.
+func detectMimeType(filename string, head []byte) string {
+	mimeType := mime.TypeByExtension(strings.ToLower(filepath.Ext(filename)))
+	if mimeType == "" {
+		mimeType = http.DetectContentType(head)
+	}
+	if mediaType, _, err := mime.ParseMediaType(mimeType); err == nil {
+		return mediaType
+	}
+	return "application/octet-stream"
+}
+
+func newID() (string, error) {
+	buf := make([]byte, 12)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("file store: generate id: %w", err)
+	}
+	return "file-" + hex.EncodeToString(buf), nil
+}
+
+// IsID reports whether id has the shape of a file id issued by this store.
+func IsID(id string) bool { return validID(id) }
+
+// validID keeps client supplied ids from escaping the store directory.
+func validID(id string) bool {
+	if !strings.HasPrefix(id, "file-") || len(id) > 64 {
+		return false
+	}
+	for _, r := range id[len("file-"):] {
+		if (r < '0' || r > '9') && (r < 'a' || r > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+var defaultStore atomic.Pointer[Store]
+
+// Default returns the process-wide file store, or nil when file uploads are disabled.
+func Default() *Store { return defaultStore.Load() }
+
+// SetDefault installs the process-wide file store; nil disables file uploads.
+func SetDefault(s *Store) { defaultStore.Store(s) }
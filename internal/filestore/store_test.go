@@ -0,0 +1,101 @@
+package filestore
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStoreScopesFilesToOwner(t *testing.T) {
+	store, err := New(t.TempDir(), 0, 0)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	file, err := store.Create("alice", "in.jsonl", "batch", strings.NewReader("hello\n"))
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if file.Bytes != 6 || !strings.HasPrefix(file.ID, "file-") {
+		t.Fatalf("file = %+v", file)
+	}
+
+	if _, err = store.Get(file.ID, "bob"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get by another key error = %v", err)
+	}
+	if err = store.Delete(file.ID, "bob"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Delete by another key error = %v", err)
+	}
+	if files, _ := store.List("bob", ""); len(files) != 0 {
+		t.Fatalf("List by another key = %d files", len(files))
+	}
+
+	_, content, err := store.Open(file.ID, "alice")
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	data, _ := io.ReadAll(content)
+	_ = content.Close()
+	if string(data) != "hello\n" {
+		t.Fatalf("content = %q", data)
+	}
+	if files, _ := store.List("alice", "batch"); len(files) != 1 {
+		t.Fatalf("List = %d files, want 1", len(files))
+	}
+	if err = store.Delete(file.ID, "alice"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err = store.Get(file.ID, "alice"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get after delete error = %v", err)
+	}
+}
+
+func TestStoreRejectsOversizedFiles(t *testing.T) {
+	dir := t.TempDir()
+	store, err := New(dir, 4, 0)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if _, err = store.Create("alice", "big.txt", "batch", strings.NewReader("too large")); !errors.Is(err, ErrTooLarge) {
+		t.Fatalf("Create error = %v, want ErrTooLarge", err)
+	}
+	if files, _ := store.List("alice", ""); len(files) != 0 {
+		t.Fatalf("oversized upload left %d files behind", len(files))
+	}
+	if _, err = store.Get("../etc/passwd", "alice"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get with invalid id error = %v", err)
+	}
+}
+
+func TestStoreExpiresFilesAndDetectsMimeType(t *testing.T) {
+	store, err := New(t.TempDir(), 0, time.Hour)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	pdf, err := store.Create("alice", "report.pdf", "user_data", strings.NewReader("%PDF-1.4\n"))
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if pdf.MimeType != "application/pdf" || pdf.ExpiresAt.Sub(pdf.CreatedAt) != time.Hour {
+		t.Fatalf("file = %+v", pdf)
+	}
+	sniffed, err := store.Create("alice", "image", "vision", strings.NewReader("\x89PNG\r\n\x1a\n0000"))
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if sniffed.MimeType != "image/png" {
+		t.Fatalf("sniffed mime type = %q", sniffed.MimeType)
+	}
+
+	pdf.ExpiresAt = time.Now().Add(-time.Second)
+	if err = store.writeMeta(pdf); err != nil {
+		t.Fatalf("writeMeta: %v", err)
+	}
+	if _, err = store.Get(pdf.ID, "alice"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get expired file error = %v", err)
+	}
+	if files, _ := store.List("alice", ""); len(files) != 1 || files[0].ID != sniffed.ID {
+		t.Fatalf("List = %v, want only the unexpired file", files)
+	}
+}
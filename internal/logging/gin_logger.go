@@ -83,6 +83,9 @@ func GinLogrusLogger() gin.HandlerFunc {
 		}
 
 		entry := log.WithField("request_id", requestID)
+		if name := apiKeyName(c); name != "" {
+			entry = entry.WithField("api_key_name", name)
+		}
 
 		switch {
 		case statusCode >= http.StatusInternalServerError:
@@ -95,6 +98,19 @@ func GinLogrusLogger() gin.HandlerFunc {
 	}
 }
 
+// apiKeyName returns the configured name of the authenticated client key, if any.
+func apiKeyName(c *gin.Context) string {
+	value, exists := c.Get("accessMetadata")
+	if !exists {
+		return ""
+	}
+	metadata, ok := value.(map[string]string)
+	if !ok {
+		return ""
+	}
+	return metadata["name"]
+}
+
 // isAIAPIPath checks if the given path is an AI API endpoint that should have request ID tracking.
 func isAIAPIPath(path string) bool {
 	for _, prefix := range aiAPIPrefixes {
@@ -0,0 +1,68 @@
+package metrics
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Handler returns a gin handler that serves registry in text exposition format.
+// The config getter is consulted per request so hot-reloaded settings apply immediately:
+// the endpoint answers 404 while disabled and requires metrics.secret-key when one is set.
+func Handler(registry *Registry, currentConfig func() *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var cfg *config.Config
+		if currentConfig != nil {
+			cfg = currentConfig()
+		}
+		if cfg == nil || !cfg.Metrics.Enable || registry == nil {
+			c.AbortWithStatus(http.StatusNotFound)
+			return
+		}
+		if !authorized(c, cfg.Metrics.SecretKey) {
+			c.Header("WWW-Authenticate", `Bearer realm="metrics"`)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid metrics key"})
+			return
+		}
+
+		openMetrics := strings.Contains(c.GetHeader("Accept"), "application/openmetrics-text")
+		var buf bytes.Buffer
+		if err := registry.Write(&buf, openMetrics); err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		contentType := ContentTypeText
+		if openMetrics {
+			contentType = ContentTypeOpenMetrics
+		}
+		c.Data(http.StatusOK, contentType, buf.Bytes())
+	}
+}
+
+// authorized validates the scrape credentials against secret (plaintext or bcrypt hash).
+// An empty secret restricts scraping to loopback clients.
+func authorized(c *gin.Context, secret string) bool {
+	if secret == "" {
+		clientIP := c.ClientIP()
+		return clientIP == "127.0.0.1" || clientIP == "::1"
+	}
+	provided := ""
+	if ah := c.GetHeader("Authorization"); ah != "" {
+		parts := strings.SplitN(ah, " ", 2)
+		if len(parts) == 2 && strings.EqualFold(parts[0], "bearer") {
+			provided = strings.TrimSpace(parts[1])
+		}
+	}
+	if provided == "" {
+		return false
+	}
+	if strings.HasPrefix(secret, "$2a$") || strings.HasPrefix(secret, "$2b$") || strings.HasPrefix(secret, "$2y$") {
+		return bcrypt.CompareHashAndPassword([]byte(secret), []byte(provided)) == nil
+	}
+	return subtle.ConstantTimeCompare([]byte(provided), []byte(secret)) == 1
+}
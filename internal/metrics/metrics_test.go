@@ -0,0 +1,139 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+)
+
+func TestRegistryWrite_TextAndOpenMetrics(t *testing.T) {
+	registry := NewRegistry()
+	counter := registry.NewCounterVec("demo_requests_total", "Demo requests.", "provider")
+	histogram := registry.NewHistogramVec("demo_latency_seconds", "Demo latency.", []float64{1, 5}, "provider")
+	counter.Add(2, `gem"ini`)
+	histogram.Observe(0.5, "claude")
+	histogram.Observe(3, "claude")
+
+	var text bytes.Buffer
+	if err := registry.Write(&text, false); err != nil {
+		t.Fatalf("write text: %v", err)
+	}
+	for _, want := range []string{
+		"# TYPE demo_requests_total counter\n",
+		`demo_requests_total{provider="gem\"ini"} 2` + "\n",
+		`demo_latency_seconds_bucket{provider="claude",le="1"} 1` + "\n",
+		`demo_latency_seconds_bucket{provider="claude",le="+Inf"} 2` + "\n",
+		`demo_latency_seconds_sum{provider="claude"} 3.5` + "\n",
+	} {
+		if !strings.Contains(text.String(), want) {
+			t.Fatalf("text output missing %q:\n%s", want, text.String())
+		}
+	}
+	if strings.Contains(text.String(), "# EOF") {
+		t.Fatalf("text output must not contain # EOF")
+	}
+
+	var om bytes.Buffer
+	if err := registry.Write(&om, true); err != nil {
+		t.Fatalf("write openmetrics: %v", err)
+	}
+	if !strings.Contains(om.String(), "# TYPE demo_requests counter\n") {
+		t.Fatalf("openmetrics counter family should drop _total:\n%s", om.String())
+	}
+	if !strings.HasSuffix(om.String(), "# EOF\n") {
+		t.Fatalf("openmetrics output must end with # EOF:\n%s", om.String())
+	}
+}
+
+func TestProxyMetrics_RecordsAttemptsAndTokens(t *testing.T) {
+	SetEnabled(true)
+	t.Cleanup(func() { SetEnabled(false) })
+
+	p := NewProxyMetrics(NewRegistry())
+	p.ObserveAttempt(context.Background(), coreauth.ExecutionAttempt{
+		Provider: "gemini", Model: "gemini-2.5-pro", AuthIndex: "abc", Stream: true,
+		Success: false, StatusCode: http.StatusTooManyRequests, Latency: 2 * time.Second, FirstByte: time.Second,
+	})
+	p.ObserveRetry(context.Background(), "gemini", "gemini-2.5-pro")
+	p.ObserveCredentialSwitch(context.Background(), "gemini", "gemini-2.5-pro")
+	p.HandleUsage(context.Background(), coreusage.Record{
+		Provider: "gemini", Model: "gemini-2.5-pro", AuthIndex: "abc",
+		Detail: coreusage.Detail{InputTokens: 10, OutputTokens: 5, ReasoningTokens: 2, CachedTokens: 1},
+	})
+
+	if got := p.requests.Value("gemini", "gemini-2.5-pro", "abc", "4xx"); got != 1 {
+		t.Fatalf("requests = %v, want 1", got)
+	}
+	if got := p.streamFirstByte.Count("gemini", "gemini-2.5-pro", "abc", "4xx"); got != 1 {
+		t.Fatalf("first byte observations = %d, want 1", got)
+	}
+	if got := p.retries.Value("gemini", "gemini-2.5-pro"); got != 1 {
+		t.Fatalf("retries = %v, want 1", got)
+	}
+	if got := p.credentialSwitches.Value("gemini", "gemini-2.5-pro"); got != 1 {
+		t.Fatalf("credential switches = %v, want 1", got)
+	}
+	if got := p.tokens.Value("gemini", "gemini-2.5-pro", "abc", "output"); got != 5 {
+		t.Fatalf("output tokens = %v, want 5", got)
+	}
+
+	SetEnabled(false)
+	p.ObserveRetry(context.Background(), "gemini", "gemini-2.5-pro")
+	if got := p.retries.Value("gemini", "gemini-2.5-pro"); got != 1 {
+		t.Fatalf("retries after disable = %v, want 1", got)
+	}
+}
+
+func TestHandler_RequiresEnableAndSecret(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	registry := NewRegistry()
+	registry.NewCounterVec("demo_total", "Demo.").Inc()
+
+	cfg := &config.Config{}
+	engine := gin.New()
+	engine.GET("/metrics", Handler(registry, func() *config.Config { return cfg }))
+
+	serve := func(token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		req.RemoteAddr = "203.0.113.7:1234"
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		engine.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := serve(""); rec.Code != http.StatusNotFound {
+		t.Fatalf("disabled status = %d, want 404", rec.Code)
+	}
+
+	cfg.Metrics.Enable = true
+	if rec := serve(""); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("remote scrape without secret status = %d, want 401", rec.Code)
+	}
+
+	cfg.Metrics.SecretKey = "scrape-key"
+	if rec := serve("wrong"); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("wrong key status = %d, want 401", rec.Code)
+	}
+	rec := serve("scrape-key")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("valid key status = %d, want 200", rec.Code)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != ContentTypeText {
+		t.Fatalf("content type = %q, want %q", ct, ContentTypeText)
+	}
+	if !strings.Contains(rec.Body.String(), "demo_total 1") {
+		t.Fatalf("body missing counter sample:\n%s", rec.Body.String())
+	}
+}
@@ -0,0 +1,220 @@
+package metrics
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+)
+
+var enabled atomic.Bool
+
+func init() {
+	coreusage.RegisterPlugin(defaultProxyMetrics)
+}
+
+// SetEnabled toggles metric collection. Observations are discarded while disabled.
+func SetEnabled(value bool) { enabled.Store(value) }
+
+// Enabled reports whether metric collection is active.
+func Enabled() bool { return enabled.Load() }
+
+// ProxyMetrics groups the request, credential and token metric families exported by the proxy.
+// It implements coreauth.ExecutionObserver and coreusage.Plugin.
+type ProxyMetrics struct {
+	registry *Registry
+
+	requests           *CounterVec
+	requestDuration    *HistogramVec
+	streamFirstByte    *HistogramVec
+	retries            *CounterVec
+	credentialSwitches *CounterVec
+	tokens             *CounterVec
+	usageRecords       *CounterVec
+
+	managerMu sync.RWMutex
+	manager   *coreauth.Manager
+}
+
+var defaultProxyMetrics = NewProxyMetrics(NewRegistry())
+
+// Default returns the process-wide proxy metrics instance.
+func Default() *ProxyMetrics { return defaultProxyMetrics }
+
+// NewProxyMetrics registers the proxy metric families on registry.
+func NewProxyMetrics(registry *Registry) *ProxyMetrics {
+	p := &ProxyMetrics{registry: registry}
+	p.requests = registry.NewCounterVec(
+		"cliproxy_upstream_requests_total",
+		"Upstream attempts issued by the auth manager.",
+		"provider", "model", "auth_index", "status_class",
+	)
+	p.requestDuration = registry.NewHistogramVec(
+		"cliproxy_upstream_request_duration_seconds",
+		"Wall time of upstream attempts, measured until the response or stream completed.",
+		DefaultBuckets,
+		"provider", "model", "auth_index", "status_class",
+	)
+	p.streamFirstByte = registry.NewHistogramVec(
+		"cliproxy_upstream_stream_first_byte_seconds",
+		"Time until the first payload chunk of streaming upstream attempts.",
+		DefaultBuckets,
+		"provider", "model", "auth_index", "status_class",
+	)
+	p.retries = registry.NewCounterVec(
+		"cliproxy_upstream_retries_total",
+		"Additional upstream attempts issued after a failed attempt within the same request.",
+		"provider", "model",
+	)
+	p.credentialSwitches = registry.NewCounterVec(
+		"cliproxy_credential_switches_total",
+		"Times a request moved on to a different credential after a failure.",
+		"provider", "model",
+	)
+	p.tokens = registry.NewCounterVec(
+		"cliproxy_tokens_total",
+		"Tokens reported by upstream usage, partitioned by token type.",
+		"provider", "model", "auth_index", "type",
+	)
+	p.usageRecords = registry.NewCounterVec(
+		"cliproxy_usage_records_total",
+		"Usage records published by executors.",
+		"provider", "model", "auth_index", "status_class",
+	)
+	registry.NewGaugeFunc(
+		"cliproxy_auths",
+		"Credentials per provider/model grouped by scheduler state (ready, cooldown, blocked, disabled).",
+		p.collectAuthStates,
+		"provider", "model", "state",
+	)
+	registry.NewGaugeFunc(
+		"cliproxy_auths_disabled",
+		"Credentials disabled at the credential level per provider.",
+		p.collectDisabledAuths,
+		"provider",
+	)
+	return p
+}
+
+// Registry returns the registry holding the proxy metric families.
+func (p *ProxyMetrics) Registry() *Registry {
+	if p == nil {
+		return nil
+	}
+	return p.registry
+}
+
+// BindManager attaches the auth manager whose scheduler state backs the credential gauges
+// and installs p as the manager's execution observer.
+func (p *ProxyMetrics) BindManager(manager *coreauth.Manager) {
+	if p == nil {
+		return
+	}
+	p.managerMu.Lock()
+	p.manager = manager
+	p.managerMu.Unlock()
+	if manager != nil {
+		manager.SetExecutionObserver(p)
+	}
+}
+
+// ObserveAttempt implements coreauth.ExecutionObserver.
+func (p *ProxyMetrics) ObserveAttempt(_ context.Context, attempt coreauth.ExecutionAttempt) {
+	if p == nil || !enabled.Load() {
+		return
+	}
+	class := statusClass(attempt.Success, attempt.StatusCode)
+	labels := []string{attempt.Provider, attempt.Model, attempt.AuthIndex, class}
+	p.requests.Inc(labels...)
+	p.requestDuration.Observe(attempt.Latency.Seconds(), labels...)
+	if attempt.Stream && attempt.FirstByte > 0 {
+		p.streamFirstByte.Observe(attempt.FirstByte.Seconds(), labels...)
+	}
+}
+
+// ObserveRetry implements coreauth.ExecutionObserver.
+func (p *ProxyMetrics) ObserveRetry(_ context.Context, provider, model string) {
+	if p == nil || !enabled.Load() {
+		return
+	}
+	p.retries.Inc(provider, model)
+}
+
+// ObserveCredentialSwitch implements coreauth.ExecutionObserver.
+func (p *ProxyMetrics) ObserveCredentialSwitch(_ context.Context, provider, model string) {
+	if p == nil || !enabled.Load() {
+		return
+	}
+	p.credentialSwitches.Inc(provider, model)
+}
+
+// HandleUsage implements coreusage.Plugin.
+func (p *ProxyMetrics) HandleUsage(_ context.Context, record coreusage.Record) {
+	if p == nil || !enabled.Load() {
+		return
+	}
+	provider := strings.TrimSpace(record.Provider)
+	model := strings.TrimSpace(record.Model)
+	authIndex := strings.TrimSpace(record.AuthIndex)
+	p.usageRecords.Inc(provider, model, authIndex, statusClass(!record.Failed, 0))
+	detail := record.Detail
+	p.tokens.Add(float64(detail.InputTokens), provider, model, authIndex, "input")
+	p.tokens.Add(float64(detail.OutputTokens), provider, model, authIndex, "output")
+	p.tokens.Add(float64(detail.ReasoningTokens), provider, model, authIndex, "reasoning")
+	p.tokens.Add(float64(detail.CachedTokens), provider, model, authIndex, "cached")
+	p.tokens.Add(float64(detail.CacheWriteTokens), provider, model, authIndex, "cache_write")
+}
+
+// collectAuthStates converts scheduler state counts into gauge samples.
+func (p *ProxyMetrics) collectAuthStates() []Sample {
+	manager := p.boundManager()
+	if manager == nil {
+		return nil
+	}
+	counts := manager.SchedulerStateCounts()
+	samples := make([]Sample, 0, len(counts)*4)
+	for _, count := range counts {
+		samples = append(samples,
+			Sample{LabelValues: []string{count.Provider, count.Model, "ready"}, Value: float64(count.Ready)},
+			Sample{LabelValues: []string{count.Provider, count.Model, "cooldown"}, Value: float64(count.Cooldown)},
+			Sample{LabelValues: []string{count.Provider, count.Model, "blocked"}, Value: float64(count.Blocked)},
+			Sample{LabelValues: []string{count.Provider, count.Model, "disabled"}, Value: float64(count.Disabled)},
+		)
+	}
+	return samples
+}
+
+// collectDisabledAuths reports credential-level disabled auths per provider.
+func (p *ProxyMetrics) collectDisabledAuths() []Sample {
+	manager := p.boundManager()
+	if manager == nil {
+		return nil
+	}
+	counts := manager.DisabledAuthCounts()
+	samples := make([]Sample, 0, len(counts))
+	for provider, count := range counts {
+		samples = append(samples, Sample{LabelValues: []string{provider}, Value: float64(count)})
+	}
+	return samples
+}
+
+func (p *ProxyMetrics) boundManager() *coreauth.Manager {
+	p.managerMu.RLock()
+	defer p.managerMu.RUnlock()
+	return p.manager
+}
+
+// statusClass maps an attempt outcome to a Prometheus-friendly status class label.
+func statusClass(success bool, statusCode int) string {
+	if statusCode >= 100 && statusCode < 600 {
+		return strconv.Itoa(statusCode/100) + "xx"
+	}
+	if success {
+		return "2xx"
+	}
+	return "error"
+}
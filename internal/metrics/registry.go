@@ -0,0 +1,358 @@
+// Package metrics provides a dependency-free Prometheus/OpenMetrics registry and
+// the proxy's built-in request, credential and token metrics.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	// ContentTypeText is the Prometheus text exposition format content type.
+	ContentTypeText = "text/plain; version=0.0.4; charset=utf-8"
+	// ContentTypeOpenMetrics is the OpenMetrics text exposition format content type.
+	ContentTypeOpenMetrics = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+)
+
+// DefaultBuckets are latency buckets in seconds suited for LLM request durations.
+var DefaultBuckets = []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 20, 30, 60, 120, 300}
+
+// Sample is one gauge value produced by a GaugeFunc collector.
+type Sample struct {
+	LabelValues []string
+	Value       float64
+}
+
+// collector is implemented by every metric family held by Registry.
+type collector interface {
+	name() string
+	write(w *bufio.Writer, openMetrics bool)
+}
+
+// Registry holds metric families and renders them in text exposition format.
+type Registry struct {
+	mu         sync.RWMutex
+	collectors []collector
+	names      map[string]struct{}
+}
+
+// NewRegistry constructs an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{names: make(map[string]struct{})}
+}
+
+// register adds a collector, panicking on duplicate names like the Prometheus client does.
+func (r *Registry) register(c collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.names[c.name()]; exists {
+		panic("metrics: duplicate metric name " + c.name())
+	}
+	r.names[c.name()] = struct{}{}
+	r.collectors = append(r.collectors, c)
+}
+
+// Write renders every registered family. When openMetrics is true the OpenMetrics
+// dialect is produced (counter families without the _total suffix and a trailing # EOF).
+func (r *Registry) Write(w io.Writer, openMetrics bool) error {
+	r.mu.RLock()
+	collectors := append([]collector(nil), r.collectors...)
+	r.mu.RUnlock()
+	sort.Slice(collectors, func(i, j int) bool { return collectors[i].name() < collectors[j].name() })
+
+	bw := bufio.NewWriter(w)
+	for _, c := range collectors {
+		c.write(bw, openMetrics)
+	}
+	if openMetrics {
+		bw.WriteString("# EOF\n")
+	}
+	return bw.Flush()
+}
+
+// metricDesc stores the static description shared by all metric kinds.
+type metricDesc struct {
+	metricName string
+	help       string
+	labels     []string
+}
+
+func (d *metricDesc) name() string { return d.metricName }
+
+func (d *metricDesc) writeHeader(w *bufio.Writer, family, kind string) {
+	fmt.Fprintf(w, "# HELP %s %s\n", family, escapeHelp(d.help))
+	fmt.Fprintf(w, "# TYPE %s %s\n", family, kind)
+}
+
+// CounterVec is a monotonically increasing counter partitioned by label values.
+type CounterVec struct {
+	metricDesc
+	mu     sync.Mutex
+	values map[string]*counterValue
+}
+
+type counterValue struct {
+	labels []string
+	value  float64
+}
+
+// NewCounterVec registers a counter family. Names must end with _total.
+func (r *Registry) NewCounterVec(name, help string, labels ...string) *CounterVec {
+	c := &CounterVec{
+		metricDesc: metricDesc{metricName: name, help: help, labels: labels},
+		values:     make(map[string]*counterValue),
+	}
+	r.register(c)
+	return c
+}
+
+// Add increments the counter for the given label values by delta. Negative deltas are ignored.
+func (c *CounterVec) Add(delta float64, labelValues ...string) {
+	if c == nil || delta < 0 || math.IsNaN(delta) {
+		return
+	}
+	key := labelKey(labelValues)
+	c.mu.Lock()
+	v, ok := c.values[key]
+	if !ok {
+		v = &counterValue{labels: normalizeLabelValues(labelValues, len(c.labels))}
+		c.values[key] = v
+	}
+	v.value += delta
+	c.mu.Unlock()
+}
+
+// Inc increments the counter for the given label values by one.
+func (c *CounterVec) Inc(labelValues ...string) { c.Add(1, labelValues...) }
+
+// Value returns the current counter value for the given label values.
+func (c *CounterVec) Value(labelValues ...string) float64 {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if v, ok := c.values[labelKey(labelValues)]; ok {
+		return v.value
+	}
+	return 0
+}
+
+func (c *CounterVec) write(w *bufio.Writer, openMetrics bool) {
+	family := c.metricName
+	if openMetrics {
+		family = strings.TrimSuffix(family, "_total")
+	}
+	c.writeHeader(w, family, "counter")
+	c.mu.Lock()
+	values := make([]*counterValue, 0, len(c.values))
+	for _, v := range c.values {
+		values = append(values, &counterValue{labels: v.labels, value: v.value})
+	}
+	c.mu.Unlock()
+	sortByLabels(values, func(v *counterValue) []string { return v.labels })
+	for _, v := range values {
+		writeSample(w, c.metricName, c.labels, v.labels, "", "", v.value)
+	}
+}
+
+// HistogramVec samples observations into cumulative buckets partitioned by label values.
+type HistogramVec struct {
+	metricDesc
+	buckets []float64
+	mu      sync.Mutex
+	values  map[string]*histogramValue
+}
+
+type histogramValue struct {
+	labels []string
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+// NewHistogramVec registers a histogram family with the supplied upper bounds.
+func (r *Registry) NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+	sorted := append([]float64(nil), buckets...)
+	sort.Float64s(sorted)
+	h := &HistogramVec{
+		metricDesc: metricDesc{metricName: name, help: help, labels: labels},
+		buckets:    sorted,
+		values:     make(map[string]*histogramValue),
+	}
+	r.register(h)
+	return h
+}
+
+// Observe records one observation for the given label values.
+func (h *HistogramVec) Observe(value float64, labelValues ...string) {
+	if h == nil || math.IsNaN(value) {
+		return
+	}
+	key := labelKey(labelValues)
+	h.mu.Lock()
+	v, ok := h.values[key]
+	if !ok {
+		v = &histogramValue{
+			labels: normalizeLabelValues(labelValues, len(h.labels)),
+			counts: make([]uint64, len(h.buckets)),
+		}
+		h.values[key] = v
+	}
+	for i, upper := range h.buckets {
+		if value <= upper {
+			v.counts[i]++
+		}
+	}
+	v.sum += value
+	v.count++
+	h.mu.Unlock()
+}
+
+// Count returns the number of observations recorded for the given label values.
+func (h *HistogramVec) Count(labelValues ...string) uint64 {
+	if h == nil {
+		return 0
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if v, ok := h.values[labelKey(labelValues)]; ok {
+		return v.count
+	}
+	return 0
+}
+
+func (h *HistogramVec) write(w *bufio.Writer, _ bool) {
+	h.writeHeader(w, h.metricName, "histogram")
+	h.mu.Lock()
+	values := make([]*histogramValue, 0, len(h.values))
+	for _, v := range h.values {
+		values = append(values, &histogramValue{
+			labels: v.labels,
+			counts: append([]uint64(nil), v.counts...),
+			sum:    v.sum,
+			count:  v.count,
+		})
+	}
+	h.mu.Unlock()
+	sortByLabels(values, func(v *histogramValue) []string { return v.labels })
+	for _, v := range values {
+		for i, upper := range h.buckets {
+			writeSample(w, h.metricName+"_bucket", h.labels, v.labels, "le", formatFloat(upper), float64(v.counts[i]))
+		}
+		writeSample(w, h.metricName+"_bucket", h.labels, v.labels, "le", "+Inf", float64(v.count))
+		writeSample(w, h.metricName+"_sum", h.labels, v.labels, "", "", v.sum)
+		writeSample(w, h.metricName+"_count", h.labels, v.labels, "", "", float64(v.count))
+	}
+}
+
+// GaugeFunc is a gauge family whose samples are computed on every scrape.
+type GaugeFunc struct {
+	metricDesc
+	collect func() []Sample
+}
+
+// NewGaugeFunc registers a gauge family backed by collect.
+func (r *Registry) NewGaugeFunc(name, help string, collect func() []Sample, labels ...string) *GaugeFunc {
+	g := &GaugeFunc{
+		metricDesc: metricDesc{metricName: name, help: help, labels: labels},
+		collect:    collect,
+	}
+	r.register(g)
+	return g
+}
+
+func (g *GaugeFunc) write(w *bufio.Writer, _ bool) {
+	g.writeHeader(w, g.metricName, "gauge")
+	if g.collect == nil {
+		return
+	}
+	samples := g.collect()
+	sortByLabels(samples, func(s Sample) []string { return s.LabelValues })
+	for _, sample := range samples {
+		writeSample(w, g.metricName, g.labels, normalizeLabelValues(sample.LabelValues, len(g.labels)), "", "", sample.Value)
+	}
+}
+
+// labelKey joins label values into a map key that cannot collide for distinct tuples.
+func labelKey(values []string) string {
+	return strings.Join(values, "\xff")
+}
+
+// normalizeLabelValues copies values, padding or truncating to the declared label count.
+func normalizeLabelValues(values []string, n int) []string {
+	out := make([]string, n)
+	copy(out, values)
+	return out
+}
+
+func sortByLabels[T any](items []T, labels func(T) []string) {
+	sort.Slice(items, func(i, j int) bool {
+		return labelKey(labels(items[i])) < labelKey(labels(items[j]))
+	})
+}
+
+func writeSample(w *bufio.Writer, name string, labelNames, labelValues []string, extraName, extraValue string, value float64) {
+	w.WriteString(name)
+	if len(labelNames) > 0 || extraName != "" {
+		w.WriteByte('{')
+		first := true
+		for i, labelName := range labelNames {
+			if !first {
+				w.WriteByte(',')
+			}
+			first = false
+			labelValue := ""
+			if i < len(labelValues) {
+				labelValue = labelValues[i]
+			}
+			w.WriteString(labelName)
+			w.WriteString(`="`)
+			w.WriteString(escapeLabelValue(labelValue))
+			w.WriteByte('"')
+		}
+		if extraName != "" {
+			if !first {
+				w.WriteByte(',')
+			}
+			w.WriteString(extraName)
+			w.WriteString(`="`)
+			w.WriteString(extraValue)
+			w.WriteByte('"')
+		}
+		w.WriteByte('}')
+	}
+	w.WriteByte(' ')
+	w.WriteString(formatFloat(value))
+	w.WriteByte('\n')
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var (
+	labelValueEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+	helpEscaper       = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+)
+
+func escapeLabelValue(v string) string { return labelValueEscaper.Replace(v) }
+
+func escapeHelp(v string) string { return helpEscaper.Replace(v) }
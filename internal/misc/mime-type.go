@@ -3,6 +3,8 @@
 // more specific domain packages. It includes a comprehensive MIME type mapping for file operations.
 package misc
 
+import "strings"
+
 // MimeTypes is a comprehensive map of file extensions to their corresponding MIME types.
 // This map is used to determine the Content-Type header for file uploads and other
 // operations where the MIME type needs to be identified from a file extension.
@@ -741,3 +743,22 @@ var MimeTypes = map[string]string{
 	"smv":         "video/x-smv",
 	"ice":         "x-conference/x-cooltalk",
 }
+
+// FileInlineData splits an OpenAI file_data value into its MIME type and base64 payload.
+// The value may be a data URL or raw base64; the MIME type falls back to the filename's
+// extension. ok is false when neither yields a MIME type.
+func FileInlineData(filename, fileData string) (mimeType, data string, ok bool) {
+	data = fileData
+	if rest, found := strings.CutPrefix(fileData, "data:"); found {
+		if header, payload, hasComma := strings.Cut(rest, ","); hasComma {
+			mimeType, _, _ = strings.Cut(header, ";")
+			data = payload
+		}
+	}
+	if mimeType == "" {
+		if idx := strings.LastIndex(filename, "."); idx >= 0 {
+			mimeType = MimeTypes[strings.ToLower(filename[idx+1:])]
+		}
+	}
+	return mimeType, data, mimeType != ""
+}
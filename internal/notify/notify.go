@@ -0,0 +1,321 @@
+// Package notify delivers operational events, such as revoked credentials, exhausted
+// quotas and model catalog changes, to the webhooks configured under webhooks.
+// Deliveries run in the background with retries so publishers never block.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	log "github.com/sirupsen/logrus"
+)
+
+// Event types.
+const (
+	EventAuthRegistered      = "auth.registered"
+	EventAuthRemoved         = "auth.removed"
+	EventAuthDisabled        = "auth.disabled"
+	EventAuthRefreshFailed   = "auth.refresh_failed"
+	EventAuthQuotaExceeded   = "auth.quota_exceeded"
+	EventModelCooldown       = "model.cooldown"
+	EventModelCatalogChanged = "model.catalog_changed"
+	EventBudgetWarning       = "budget.warning"
+	EventBudgetExceeded      = "budget.exceeded"
+)
+
+// Headers sent with HTTP deliveries.
+const (
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderDelivery  = "X-Webhook-Delivery"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+const (
+	queueSize               = 256
+	deliveryWorkers         = 2
+	initialRetryBackoff     = time.Second
+	maxRetryBackoff         = 30 * time.Second
+	maxResponseBodyLogBytes = 512
+)
+
+// Event is the payload delivered to webhooks. Fields that do not apply to an event type
+// are omitted.
+type Event struct {
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	Timestamp time.Time `json:"timestamp"`
+	// Message is a one-line human readable summary, also used as the Slack text.
+	Message   string   `json:"message"`
+	AuthID    string   `json:"auth_id,omitempty"`
+	AuthLabel string   `json:"auth_label,omitempty"`
+	Provider  string   `json:"provider,omitempty"`
+	Model     string   `json:"model,omitempty"`
+	Error     string   `json:"error,omitempty"`
+	Providers []string `json:"providers,omitempty"`
+	APIKey    string   `json:"api_key,omitempty"`
+	// RetryAfterSeconds is the time until a cooling down credential or model recovers.
+	RetryAfterSeconds int64 `json:"retry_after_seconds,omitempty"`
+	// Limit and Spent describe budget events in USD.
+	Limit float64 `json:"limit,omitempty"`
+	Spent float64 `json:"spent,omitempty"`
+}
+
+type target struct {
+	cfg    config.WebhookConfig
+	events []string
+}
+
+func (t *target) accepts(eventType string) bool {
+	if len(t.events) == 0 {
+		return true
+	}
+	for _, pattern := range t.events {
+		if util.MatchWildcard(pattern, eventType) {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *target) label() string {
+	if t.cfg.Name != "" {
+		return t.cfg.Name
+	}
+	if t.cfg.Type == config.WebhookTypeCommand {
+		return t.cfg.Command[0]
+	}
+	return t.cfg.URL
+}
+
+type delivery struct {
+	target *target
+	event  Event
+}
+
+// Dispatcher fans events out to webhooks from a bounded queue. Events published while
+// the queue is full are dropped with a warning.
+type Dispatcher struct {
+	targets []*target
+	client  *http.Client
+	queue   chan delivery
+	ctx     context.Context
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+	// sleep waits between retries; tests shorten it.
+	sleep func(ctx context.Context, d time.Duration) bool
+}
+
+// New starts a dispatcher delivering to webhooks. It returns nil when no webhook is
+// configured. client sends the HTTP deliveries; nil uses a default client.
+func New(webhooks []config.WebhookConfig, client *http.Client) *Dispatcher {
+	if len(webhooks) == 0 {
+		return nil
+	}
+	if client == nil {
+		client = &http.Client{}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	d := &Dispatcher{
+		client: client,
+		queue:  make(chan delivery, queueSize),
+		ctx:    ctx,
+		cancel: cancel,
+		sleep:  sleepContext,
+	}
+	for _, hook := range webhooks {
+		d.targets = append(d.targets, &target{cfg: hook, events: hook.Events})
+	}
+	for i := 0; i < deliveryWorkers; i++ {
+		d.wg.Add(1)
+		go d.run()
+	}
+	return d
+}
+
+// Publish queues event for every webhook subscribed to its type. ID and Timestamp are
+// filled in when empty.
+func (d *Dispatcher) Publish(event Event) {
+	if d == nil || event.Type == "" {
+		return
+	}
+	if event.ID == "" {
+		event.ID = newEventID()
+	}
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now().UTC()
+	}
+	for _, t := range d.targets {
+		if !t.accepts(event.Type) {
+			continue
+		}
+		select {
+		case <-d.ctx.Done():
+			return
+		case d.queue <- delivery{target: t, event: event}:
+		default:
+			log.WithFields(log.Fields{"webhook": t.label(), "event": event.Type}).Warn("webhook queue full, event dropped")
+		}
+	}
+}
+
+// Close stops the workers after the deliveries in progress. Queued events are dropped.
+func (d *Dispatcher) Close() {
+	if d == nil {
+		return
+	}
+	d.cancel()
+	d.wg.Wait()
+}
+
+func (d *Dispatcher) run() {
+	defer d.wg.Done()
+	for {
+		select {
+		case <-d.ctx.Done():
+			return
+		case item := <-d.queue:
+			d.deliver(item.target, item.event)
+		}
+	}
+}
+
+// deliver sends event to t, retrying failed attempts with exponential backoff.
+func (d *Dispatcher) deliver(t *target, event Event) {
+	fields := log.Fields{"webhook": t.label(), "event": event.Type, "event_id": event.ID}
+	backoff := initialRetryBackoff
+	attempts := 1 + max(t.cfg.MaxRetries, 0)
+	for attempt := 1; ; attempt++ {
+		retry, err := d.attempt(t, event)
+		if err == nil {
+			log.WithFields(fields).Debug("webhook delivered")
+			return
+		}
+		if !retry || attempt >= attempts {
+			log.WithFields(fields).Warnf("webhook delivery failed after %d attempt(s): %v", attempt, err)
+			return
+		}
+		log.WithFields(fields).Debugf("webhook delivery attempt %d failed, retrying in %s: %v", attempt, backoff, err)
+		if !d.sleep(d.ctx, backoff) {
+			return
+		}
+		backoff = min(backoff*2, maxRetryBackoff)
+	}
+}
+
+// attempt performs one delivery and reports whether a failure is worth retrying.
+func (d *Dispatcher) attempt(t *target, event Event) (bool, error) {
+	ctx, cancel := context.WithTimeout(d.ctx, time.Duration(t.cfg.TimeoutSeconds)*time.Second)
+	defer cancel()
+	payload, err := encodePayload(t.cfg.Type, event)
+	if err != nil {
+		return false, err
+	}
+	if t.cfg.Type == config.WebhookTypeCommand {
+		return true, runCommand(ctx, t.cfg.Command, event, payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.cfg.URL, bytes.NewReader(payload))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "CLIProxyAPI-Webhook")
+	req.Header.Set(HeaderEvent, event.Type)
+	req.Header.Set(HeaderDelivery, event.ID)
+	for key, value := range t.cfg.Headers {
+		req.Header.Set(key, value)
+	}
+	if t.cfg.Secret != "" {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		req.Header.Set(HeaderTimestamp, timestamp)
+		req.Header.Set(HeaderSignature, Sign(t.cfg.Secret, timestamp, payload))
+	}
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return false, nil
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseBodyLogBytes))
+	retry := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusRequestTimeout || resp.StatusCode >= 500
+	return retry, fmt.Errorf("status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+}
+
+// Sign returns the X-Webhook-Signature value of payload sent at timestamp: the hex
+// HMAC-SHA256 of "<timestamp>.<payload>" keyed by secret, prefixed with "sha256=".
+func Sign(secret, timestamp string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func encodePayload(webhookType string, event Event) ([]byte, error) {
+	if webhookType == config.WebhookTypeSlack {
+		return json.Marshal(map[string]string{"text": fmt.Sprintf("[CLIProxyAPI] %s: %s", event.Type, event.Message)})
+	}
+	return json.Marshal(event)
+}
+
+func runCommand(ctx context.Context, command []string, event Event, payload []byte) error {
+	cmd := exec.CommandContext(ctx, command[0], command[1:]...)
+	cmd.Stdin = bytes.NewReader(payload)
+	cmd.Env = append(os.Environ(), "CLIPROXY_EVENT_TYPE="+event.Type, "CLIPROXY_EVENT_ID="+event.ID)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+func newEventID() string {
+	var buf [12]byte
+	if _, err := rand.Read(buf[:]); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+	return "evt_" + hex.EncodeToString(buf[:])
+}
+
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+var defaultDispatcher atomic.Pointer[Dispatcher]
+
+// Default returns the process-wide dispatcher, or nil when no webhook is configured.
+func Default() *Dispatcher { return defaultDispatcher.Load() }
+
+// SetDefault installs the process-wide dispatcher and returns the previous one, which the
+// caller should close.
+func SetDefault(d *Dispatcher) *Dispatcher { return defaultDispatcher.Swap(d) }
+
+// Publish queues event on the process-wide dispatcher.
+func Publish(event Event) { Default().Publish(event) }
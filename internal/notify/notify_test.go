@@ -0,0 +1,153 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+type received struct {
+	header http.Header
+	body   []byte
+}
+
+func newReceiver(t *testing.T, statuses ...int) (*httptest.Server, chan received, *atomic.Int32) {
+	t.Helper()
+	ch := make(chan received, 16)
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(calls.Add(1))
+		body, _ := io.ReadAll(r.Body)
+		status := http.StatusOK
+		if n <= len(statuses) {
+			status = statuses[n-1]
+		}
+		w.WriteHeader(status)
+		if status < 300 {
+			ch <- received{header: r.Header.Clone(), body: body}
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, ch, &calls
+}
+
+func newTestDispatcher(t *testing.T, hooks ...config.WebhookConfig) *Dispatcher {
+	t.Helper()
+	for i := range hooks {
+		if hooks[i].TimeoutSeconds == 0 {
+			hooks[i].TimeoutSeconds = 5
+		}
+	}
+	d := New(hooks, nil)
+	d.sleep = func(ctx context.Context, _ time.Duration) bool { return ctx.Err() == nil }
+	t.Cleanup(d.Close)
+	return d
+}
+
+func wait(t *testing.T, ch chan received) received {
+	t.Helper()
+	select {
+	case got := <-ch:
+		return got
+	case <-time.After(5 * time.Second):
+		t.Fatal("webhook not delivered")
+		return received{}
+	}
+}
+
+func TestPublishSignsJSONPayload(t *testing.T) {
+	srv, ch, _ := newReceiver(t)
+	d := newTestDispatcher(t, config.WebhookConfig{Type: config.WebhookTypeJSON, URL: srv.URL, Secret: "s3cret", Headers: map[string]string{"X-Team": "oncall"}})
+
+	d.Publish(Event{Type: EventAuthRefreshFailed, AuthID: "a1", Message: "refresh failed"})
+	got := wait(t, ch)
+
+	var event Event
+	if err := json.Unmarshal(got.body, &event); err != nil {
+		t.Fatalf("unmarshal payload: %v", err)
+	}
+	if event.Type != EventAuthRefreshFailed || event.AuthID != "a1" || event.ID == "" {
+		t.Fatalf("unexpected event %+v", event)
+	}
+	if got.header.Get(HeaderEvent) != EventAuthRefreshFailed || got.header.Get(HeaderDelivery) != event.ID {
+		t.Fatalf("unexpected event headers %v", got.header)
+	}
+	if got.header.Get("X-Team") != "oncall" {
+		t.Fatalf("custom header missing")
+	}
+	want := Sign("s3cret", got.header.Get(HeaderTimestamp), got.body)
+	if got.header.Get(HeaderSignature) != want {
+		t.Fatalf("signature = %q, want %q", got.header.Get(HeaderSignature), want)
+	}
+}
+
+func TestPublishRetriesServerErrors(t *testing.T) {
+	srv, ch, calls := newReceiver(t, http.StatusBadGateway, http.StatusTooManyRequests)
+	d := newTestDispatcher(t, config.WebhookConfig{Type: config.WebhookTypeJSON, URL: srv.URL, MaxRetries: 3})
+
+	d.Publish(Event{Type: EventAuthDisabled})
+	wait(t, ch)
+	if n := calls.Load(); n != 3 {
+		t.Fatalf("calls = %d, want 3", n)
+	}
+}
+
+func TestPublishDoesNotRetryClientErrors(t *testing.T) {
+	srv, _, calls := newReceiver(t, http.StatusBadRequest, http.StatusBadRequest)
+	d := newTestDispatcher(t, config.WebhookConfig{Type: config.WebhookTypeJSON, URL: srv.URL, MaxRetries: 3})
+
+	d.Publish(Event{Type: EventAuthDisabled})
+	time.Sleep(200 * time.Millisecond)
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("calls = %d, want 1", n)
+	}
+}
+
+func TestPublishFiltersEvents(t *testing.T) {
+	srv, ch, _ := newReceiver(t)
+	d := newTestDispatcher(t, config.WebhookConfig{Type: config.WebhookTypeSlack, URL: srv.URL, Events: []string{"auth.*", EventModelCooldown}})
+
+	d.Publish(Event{Type: EventModelCatalogChanged, Message: "ignored"})
+	d.Publish(Event{Type: EventAuthQuotaExceeded, Message: "quota hit"})
+	got := wait(t, ch)
+
+	var payload map[string]string
+	if err := json.Unmarshal(got.body, &payload); err != nil {
+		t.Fatalf("unmarshal payload: %v", err)
+	}
+	if payload["text"] != "[CLIProxyAPI] auth.quota_exceeded: quota hit" {
+		t.Fatalf("slack text = %q", payload["text"])
+	}
+	select {
+	case extra := <-ch:
+		t.Fatalf("unexpected delivery %s", extra.body)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestTargetAcceptsEventPatterns(t *testing.T) {
+	cases := []struct {
+		pattern string
+		event   string
+		want    bool
+	}{
+		{"*", EventAuthRemoved, true},
+		{"auth.*", EventAuthRemoved, true},
+		{"auth.*", EventModelCooldown, false},
+		{"*.cooldown", EventModelCooldown, true},
+		{EventBudgetExceeded, EventBudgetWarning, false},
+	}
+	for _, tc := range cases {
+		target := &target{events: []string{tc.pattern}}
+		if got := target.accepts(tc.event); got != tc.want {
+			t.Fatalf("accepts(%q) with pattern %q = %v, want %v", tc.event, tc.pattern, got, tc.want)
+		}
+	}
+}
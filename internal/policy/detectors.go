@@ -0,0 +1,57 @@
+package policy
+
+import "regexp"
+
+// Built-in detector names accepted in content policy rules.
+const (
+	DetectorAPIKey     = "api-key"
+	DetectorAWSKey     = "aws-key"
+	DetectorEmail      = "email"
+	DetectorCreditCard = "credit-card"
+)
+
+var detectors = map[string][]matcher{
+	DetectorAPIKey: {
+		// OpenAI and Anthropic style secret keys.
+		{label: DetectorAPIKey, re: regexp.MustCompile(`\bsk-(?:ant-|proj-)?[A-Za-z0-9_-]{20,}`)},
+		// Google API keys.
+		{label: DetectorAPIKey, re: regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}`)},
+		// GitHub tokens.
+		{label: DetectorAPIKey, re: regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{36,}`)},
+		// Slack tokens.
+		{label: DetectorAPIKey, re: regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}`)},
+	},
+	DetectorAWSKey: {
+		{label: DetectorAWSKey, re: regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	},
+	DetectorEmail: {
+		{label: DetectorEmail, re: regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)},
+	},
+	DetectorCreditCard: {
+		{label: DetectorCreditCard, re: regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`), validate: luhnValid},
+	},
+}
+
+// luhnValid reports whether the digits of s pass the Luhn checksum, which rules out
+// most phone numbers, timestamps and other long digit runs.
+func luhnValid(s string) bool {
+	sum, digits := 0, 0
+	double := false
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		digits++
+		double = !double
+	}
+	return digits >= 13 && sum%10 == 0
+}
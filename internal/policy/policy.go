@@ -0,0 +1,574 @@
+// Package policy implements the content policy engine that masks, blocks or records
+// sensitive content in client payloads before they are translated and sent upstream,
+// and optionally in the responses returned to clients.
+package policy
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"unicode/utf8"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+)
+
+// RequestLogKey is the gin context key under which policy hits of a request are collected
+// for the request log.
+const RequestLogKey = "CONTENT_POLICY_HITS"
+
+// Directions of a scanned payload.
+const (
+	DirectionRequest  = "request"
+	DirectionResponse = "response"
+)
+
+// Scope identifies the request a payload belongs to, for rule selection.
+type Scope struct {
+	// APIKey is the client key that authenticated the request.
+	APIKey string
+	// APIKeyName is the configured name of the client key, if any.
+	APIKeyName string
+	// Model is the model requested by the client.
+	Model string
+	// Protocol is the client API format, e.g. "openai", "claude" or "gemini".
+	Protocol string
+}
+
+// Hit records matches of one rule and detector in a payload. Matched values are never kept.
+type Hit struct {
+	Rule      string
+	Detector  string
+	Action    string
+	Direction string
+	Count     int
+}
+
+// String formats the hit for logs.
+func (h Hit) String() string {
+	return fmt.Sprintf("%s rule=%s detector=%s action=%s matches=%d", h.Direction, h.Rule, h.Detector, h.Action, h.Count)
+}
+
+// Result is the outcome of scanning a payload.
+type Result struct {
+	// Payload is the payload with masked values replaced; it is the input when nothing was masked.
+	Payload []byte
+	// Hits lists every rule that matched, in rule order.
+	Hits []Hit
+	// Blocked is the first hit of a blocking rule, or nil when the payload may pass.
+	Blocked *Hit
+}
+
+// Engine applies compiled content policy rules.
+type Engine struct {
+	rules         []*rule
+	scanResponses bool
+}
+
+type rule struct {
+	name        string
+	action      string
+	replacement string
+	apiKeys     map[string]struct{}
+	models      []config.PayloadModelRule
+	matchers    []matcher
+}
+
+type matcher struct {
+	label    string
+	re       *regexp.Regexp
+	validate func(string) bool
+}
+
+// New compiles the rules of cfg. It returns nil when the policy is disabled or has no rules.
+func New(cfg config.ContentPolicyConfig) (*Engine, error) {
+	if !cfg.Enable || len(cfg.Rules) == 0 {
+		return nil, nil
+	}
+	engine := &Engine{scanResponses: cfg.ScanResponses}
+	for i := range cfg.Rules {
+		compiled, err := compileRule(i, cfg.Rules[i])
+		if err != nil {
+			return nil, err
+		}
+		engine.rules = append(engine.rules, compiled)
+	}
+	return engine, nil
+}
+
+func compileRule(index int, cfg config.ContentPolicyRule) (*rule, error) {
+	r := &rule{
+		name:        cfg.Name,
+		action:      strings.ToLower(strings.TrimSpace(cfg.Action)),
+		replacement: cfg.Replacement,
+		models:      cfg.Models,
+	}
+	if r.name == "" {
+		r.name = fmt.Sprintf("rule-%d", index+1)
+	}
+	switch r.action {
+	case "":
+		r.action = config.ContentPolicyActionMask
+	case config.ContentPolicyActionMask, config.ContentPolicyActionBlock, config.ContentPolicyActionLog:
+	default:
+		return nil, fmt.Errorf("content policy rule %q: unknown action %q", r.name, cfg.Action)
+	}
+	if len(cfg.APIKeys) > 0 {
+		r.apiKeys = make(map[string]struct{}, len(cfg.APIKeys))
+		for _, key := range cfg.APIKeys {
+			r.apiKeys[key] = struct{}{}
+		}
+	}
+	for _, name := range cfg.Detectors {
+		found, ok := detectors[name]
+		if !ok {
+			return nil, fmt.Errorf("content policy rule %q: unknown detector %q", r.name, name)
+		}
+		r.matchers = append(r.matchers, found...)
+	}
+	for _, pattern := range cfg.Patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("content policy rule %q: %w", r.name, err)
+		}
+		r.matchers = append(r.matchers, matcher{label: "pattern", re: re})
+	}
+	if len(cfg.Keywords) > 0 {
+		quoted := make([]string, 0, len(cfg.Keywords))
+		for _, keyword := range cfg.Keywords {
+			quoted = append(quoted, regexp.QuoteMeta(keyword))
+		}
+		r.matchers = append(r.matchers, matcher{label: "keyword", re: regexp.MustCompile("(?i)" + strings.Join(quoted, "|"))})
+	}
+	return r, nil
+}
+
+// ScansResponses reports whether responses must be scanned too.
+func (e *Engine) ScansResponses() bool {
+	return e != nil && e.scanResponses
+}
+
+// ScanRequest applies the rules in scope to a client payload.
+func (e *Engine) ScanRequest(scope Scope, payload []byte) Result {
+	return e.scan(scope, DirectionRequest, payload)
+}
+
+// ScanResponse applies the rules in scope to a response payload or a single stream chunk;
+// use a StreamScanner to catch matches split across chunks. It is a no-op unless
+// scan-responses is enabled.
+func (e *Engine) ScanResponse(scope Scope, payload []byte) Result {
+	if !e.ScansResponses() {
+		return Result{Payload: payload}
+	}
+	return e.scan(scope, DirectionResponse, payload)
+}
+
+func (e *Engine) scan(scope Scope, direction string, payload []byte) Result {
+	result := Result{Payload: payload}
+	if e == nil || len(payload) == 0 {
+		return result
+	}
+	for _, r := range e.rules {
+		if !r.appliesTo(scope) {
+			continue
+		}
+		counts := make(map[string]int)
+		rewritten := rewriteJSONStrings(result.Payload, func(_, _, text string) string {
+			return r.apply(text, counts)
+		})
+		if r.action == config.ContentPolicyActionMask {
+			result.Payload = rewritten
+		}
+		r.report(counts, direction, &result)
+		if result.Blocked != nil {
+			break
+		}
+	}
+	return result
+}
+
+// report appends the hits counted per detector label to result, in matcher order.
+func (r *rule) report(counts map[string]int, direction string, result *Result) {
+	for _, m := range r.matchers {
+		if counts[m.label] == 0 {
+			continue
+		}
+		hit := Hit{Rule: r.name, Detector: m.label, Action: r.action, Direction: direction, Count: counts[m.label]}
+		counts[m.label] = 0
+		result.Hits = append(result.Hits, hit)
+		if r.action == config.ContentPolicyActionBlock && result.Blocked == nil {
+			blocked := hit
+			result.Blocked = &blocked
+		}
+	}
+}
+
+// streamTailSize bounds the text a StreamScanner holds back. Matches longer than this
+// that span chunks can still be sent in part.
+const streamTailSize = 256
+
+// StreamResult is the outcome of scanning one chunk of a stream.
+type StreamResult struct {
+	// Payloads are the chunks to send, in order. Chunks carrying text are sent one chunk
+	// late, with their text replaced by the masked text released so far.
+	Payloads [][]byte
+	// Hits lists every rule that matched, in rule order.
+	Hits []Hit
+	// Blocked is the first hit of a blocking rule, or nil when the stream may go on.
+	Blocked *Hit
+}
+
+// StreamScanner applies the response rules to the chunks of one streamed response. It holds
+// back the last streamTailSize bytes of streamed text, so matches split across chunks are
+// masked before any part of them is sent. Flush releases the rest when the stream ends.
+type StreamScanner struct {
+	engine *Engine
+	scope  Scope
+	// held is the streamed text that has not been released yet.
+	held string
+	// pending is the last chunk carrying text, with its text removed, waiting for the
+	// text released by the next chunk.
+	pending []byte
+}
+
+// NewStreamScanner returns a scanner for one streamed response in scope.
+func (e *Engine) NewStreamScanner(scope Scope) *StreamScanner {
+	return &StreamScanner{engine: e, scope: scope}
+}
+
+// Scan applies the rules in scope to the next chunk of the stream.
+func (s *StreamScanner) Scan(chunk []byte) StreamResult {
+	if !s.engine.ScansResponses() {
+		return StreamResult{Payloads: [][]byte{chunk}}
+	}
+	var text strings.Builder
+	hasText := false
+	visitJSONStrings(chunk, func(_, _ int, parent, key, value string) {
+		if isStreamText(parent, key) {
+			hasText = true
+			text.WriteString(value)
+		}
+	})
+	var acc Result
+	var payloads [][]byte
+	if !hasText {
+		// A chunk without text ends the text streamed before it, e.g. a finish chunk or
+		// the end of a content block, so the held text goes out first.
+		if payload := s.release(len(s.held), &acc); payload != nil {
+			payloads = append(payloads, payload)
+		}
+		if acc.Blocked == nil {
+			scanned := s.engine.scan(s.scope, DirectionResponse, chunk)
+			mergeResult(&acc, scanned)
+			payloads = append(payloads, scanned.Payload)
+		}
+		return streamResult(payloads, acc)
+	}
+
+	frame := s.engine.scan(s.scope, DirectionResponse, setStreamText(chunk, ""))
+	mergeResult(&acc, frame)
+	if acc.Blocked != nil {
+		return streamResult(nil, acc)
+	}
+	s.held += text.String()
+	if s.pending != nil {
+		if payload := s.release(s.engine.releaseCut(s.scope, s.held), &acc); payload != nil {
+			payloads = append(payloads, payload)
+		}
+	}
+	if acc.Blocked == nil {
+		// Block rules stop the stream as soon as the held text matches, not when it is released.
+		s.engine.scanText(s.scope, s.held, config.ContentPolicyActionBlock, &acc)
+	}
+	s.pending = frame.Payload
+	return streamResult(payloads, acc)
+}
+
+// Flush releases the text still held back at the end of the stream in the last chunk
+// that carried text.
+func (s *StreamScanner) Flush() StreamResult {
+	var acc Result
+	var payloads [][]byte
+	if payload := s.release(len(s.held), &acc); payload != nil {
+		payloads = append(payloads, payload)
+	}
+	return streamResult(payloads, acc)
+}
+
+// release masks the first cut bytes of the held text and returns the pending chunk
+// carrying them. It returns nil when no chunk is pending or a rule blocks the text.
+func (s *StreamScanner) release(cut int, acc *Result) []byte {
+	if s.pending == nil {
+		return nil
+	}
+	text := s.engine.scanText(s.scope, s.held[:cut], "", acc)
+	if acc.Blocked != nil {
+		return nil
+	}
+	payload := setStreamText(s.pending, text)
+	s.held = s.held[cut:]
+	s.pending = nil
+	return payload
+}
+
+// releaseCut returns how much of the held text can be released: all but the last
+// streamTailSize bytes, moved back to the start of any match reaching past the cut.
+func (e *Engine) releaseCut(scope Scope, text string) int {
+	cut := len(text) - streamTailSize
+	if cut <= 0 {
+		return 0
+	}
+	for cut > 0 && !utf8.RuneStart(text[cut]) {
+		cut--
+	}
+	for moved := true; moved; {
+		moved = false
+		for _, r := range e.rules {
+			if !r.appliesTo(scope) {
+				continue
+			}
+			for _, m := range r.matchers {
+				for _, loc := range m.re.FindAllStringIndex(text, -1) {
+					if loc[0] < cut && loc[1] > cut {
+						cut = loc[0]
+						moved = true
+					}
+				}
+			}
+		}
+	}
+	return cut
+}
+
+// scanText applies the rules in scope with the given action, or all rules when action
+// is empty, to streamed text and returns it with masked values replaced.
+func (e *Engine) scanText(scope Scope, text, action string, acc *Result) string {
+	if text == "" {
+		return text
+	}
+	for _, r := range e.rules {
+		if !r.appliesTo(scope) || (action != "" && r.action != action) {
+			continue
+		}
+		counts := make(map[string]int)
+		rewritten := r.mask(text, counts)
+		if r.action == config.ContentPolicyActionMask {
+			text = rewritten
+		}
+		r.report(counts, DirectionResponse, acc)
+		if acc.Blocked != nil {
+			break
+		}
+	}
+	return text
+}
+
+func mergeResult(acc *Result, result Result) {
+	acc.Hits = append(acc.Hits, result.Hits...)
+	if acc.Blocked == nil {
+		acc.Blocked = result.Blocked
+	}
+}
+
+func streamResult(payloads [][]byte, acc Result) StreamResult {
+	if acc.Blocked != nil {
+		payloads = nil
+	}
+	return StreamResult{Payloads: payloads, Hits: acc.Hits, Blocked: acc.Blocked}
+}
+
+// isStreamText reports whether a string value with the given member key, inside the
+// member named parent, carries generated text in stream chunks: OpenAI delta.content,
+// Claude delta.text, Responses text deltas and Gemini parts[].text.
+func isStreamText(parent, key string) bool {
+	switch key {
+	case "content":
+		return parent == "delta"
+	case "text":
+		return parent == "delta" || parent == "parts"
+	case "delta":
+		return true
+	}
+	return false
+}
+
+// setStreamText replaces the streamed text of chunk with text, put in its first text value.
+func setStreamText(chunk []byte, text string) []byte {
+	first := true
+	return rewriteJSONStrings(chunk, func(parent, key, value string) string {
+		if !isStreamText(parent, key) {
+			return value
+		}
+		if first {
+			first = false
+			return text
+		}
+		return ""
+	})
+}
+
+func (r *rule) appliesTo(scope Scope) bool {
+	if r.apiKeys != nil {
+		_, byKey := r.apiKeys[scope.APIKey]
+		_, byName := r.apiKeys[scope.APIKeyName]
+		if !byKey && (scope.APIKeyName == "" || !byName) {
+			return false
+		}
+	}
+	if len(r.models) == 0 {
+		return true
+	}
+	for _, entry := range r.models {
+		name := strings.TrimSpace(entry.Name)
+		if name == "" {
+			continue
+		}
+		if ep := strings.TrimSpace(entry.Protocol); ep != "" && scope.Protocol != "" && !strings.EqualFold(ep, scope.Protocol) {
+			continue
+		}
+		if util.MatchWildcard(name, scope.Model) {
+			return true
+		}
+	}
+	return false
+}
+
+// apply runs every matcher of the rule over text, counting matches per detector label
+// and masking them; callers discard the rewritten text for non-mask rules.
+func (r *rule) apply(text string, counts map[string]int) string {
+	if strings.HasPrefix(text, "data:") {
+		// Inline files and images carry base64 that would trip the detectors.
+		return text
+	}
+	return r.mask(text, counts)
+}
+
+// mask runs every matcher of the rule over text, counting and replacing the matches.
+func (r *rule) mask(text string, counts map[string]int) string {
+	for _, m := range r.matchers {
+		text = m.re.ReplaceAllStringFunc(text, func(match string) string {
+			if m.validate != nil && !m.validate(match) {
+				return match
+			}
+			counts[m.label]++
+			if r.replacement != "" {
+				return r.replacement
+			}
+			return "[REDACTED:" + m.label + "]"
+		})
+	}
+	return text
+}
+
+// rewriteJSONStrings passes every JSON string value of data, with its enclosing and own
+// member keys, through fn and re-encodes the values fn changed. Object keys are left untouched, so masking never renames fields.
+// Data that is not JSON, like SSE framing, is copied through around the literals it contains.
+func rewriteJSONStrings(data []byte, fn func(parent, key, text string) string) []byte {
+	var out []byte
+	last := 0
+	visitJSONStrings(data, func(start, end int, parent, key, text string) {
+		if replaced := fn(parent, key, text); replaced != text {
+			out = append(out, data[last:start]...)
+			out = append(out, encodeJSONString(replaced)...)
+			last = end
+		}
+	})
+	if out == nil {
+		return data
+	}
+	return append(out, data[last:]...)
+}
+
+// visitJSONStrings calls fn with the byte range, member key and decoded text of every JSON
+// string value of data, and the key of the member holding the enclosing object or array.
+// Values inside arrays report the key of the enclosing member.
+func visitJSONStrings(data []byte, fn func(start, end int, parent, key, text string)) {
+	key := ""
+	var parents []string
+	for i := 0; i < len(data); i++ {
+		switch data[i] {
+		case '{', '[':
+			parents = append(parents, key)
+			if data[i] == '{' {
+				key = ""
+			}
+			continue
+		case '}', ']':
+			if n := len(parents); n > 0 {
+				key = parents[n-1]
+				parents = parents[:n-1]
+			}
+			continue
+		case '"':
+		default:
+			continue
+		}
+		end := i + 1
+		escaped := false
+		for end < len(data) && data[end] != '"' {
+			if data[end] == '\\' {
+				escaped = true
+				end++
+			}
+			end++
+		}
+		if end >= len(data) {
+			return
+		}
+		literal := data[i : end+1]
+		var text string
+		if escaped {
+			if err := json.Unmarshal(literal, &text); err != nil {
+				i = end
+				continue
+			}
+		} else {
+			text = string(literal[1 : len(literal)-1])
+		}
+		if isObjectKey(data, end+1) {
+			key = text
+		} else {
+			parent := ""
+			if n := len(parents); n > 0 {
+				parent = parents[n-1]
+			}
+			fn(i, end+1, parent, key, text)
+		}
+		i = end
+	}
+}
+
+// isObjectKey reports whether the string literal ending before offset is followed by a
+// colon, which makes it an object key rather than a value.
+func isObjectKey(data []byte, offset int) bool {
+	for ; offset < len(data); offset++ {
+		switch data[offset] {
+		case ' ', '\t', '\r', '\n':
+			continue
+		case ':':
+			return true
+		default:
+			return false
+		}
+	}
+	return false
+}
+
+func encodeJSONString(s string) []byte {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(s)
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n"))
+}
+
+var defaultEngine atomic.Pointer[Engine]
+
+// Default returns the process-wide policy engine, or nil when no policy is configured.
+func Default() *Engine { return defaultEngine.Load() }
+
+// SetDefault installs the process-wide policy engine; nil disables the policy.
+func SetDefault(e *Engine) { defaultEngine.Store(e) }
@@ -0,0 +1,173 @@
+// Package pricing computes the cost of usage records from a price table combining the
+// configured model prices with the catalog bundled in the model registry.
+package pricing
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync/atomic"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+)
+
+// tokensPerUnit is the number of tokens prices are quoted for.
+const tokensPerUnit = 1_000_000
+
+// Tokens is the token usage of one request as reported by the provider executors.
+type Tokens struct {
+	Input      int64
+	Output     int64
+	Reasoning  int64
+	CacheRead  int64
+	CacheWrite int64
+}
+
+// Table resolves model prices. A nil Table prices nothing.
+type Table struct {
+	entries []entry
+}
+
+type entry struct {
+	price    config.ModelPrice
+	bundled  bool
+	wildcard bool
+	literal  int
+}
+
+type catalog struct {
+	Models []config.ModelPrice `json:"models"`
+}
+
+// New builds the price table for cfg. Configured prices take precedence over the bundled
+// catalog, which is skipped when cfg.DisableCatalog is set.
+func New(cfg config.PricingConfig) (*Table, error) {
+	entries := make([]entry, 0, len(cfg.Models))
+	for _, price := range cfg.Models {
+		entries = append(entries, newEntry(price, false))
+	}
+	if !cfg.DisableCatalog {
+		var bundled catalog
+		if err := json.Unmarshal(registry.EmbeddedPricingCatalog(), &bundled); err != nil {
+			return nil, fmt.Errorf("pricing: parse bundled catalog: %w", err)
+		}
+		for _, price := range bundled.Models {
+			entries = append(entries, newEntry(price, true))
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		left, right := entries[i], entries[j]
+		if left.bundled != right.bundled {
+			return !left.bundled
+		}
+		if (left.price.Provider != "") != (right.price.Provider != "") {
+			return left.price.Provider != ""
+		}
+		if left.wildcard != right.wildcard {
+			return !left.wildcard
+		}
+		return left.literal > right.literal
+	})
+	return &Table{entries: entries}, nil
+}
+
+func newEntry(price config.ModelPrice, bundled bool) entry {
+	price.Provider = strings.ToLower(strings.TrimSpace(price.Provider))
+	price.Model = strings.ToLower(strings.TrimSpace(price.Model))
+	return entry{
+		price:    price,
+		bundled:  bundled,
+		wildcard: strings.Contains(price.Model, "*"),
+		literal:  len(strings.ReplaceAll(price.Model, "*", "")),
+	}
+}
+
+// Lookup returns the price of model when served by provider. Thinking suffixes such as
+// "(high)" are ignored.
+func (t *Table) Lookup(provider, model string) (config.ModelPrice, bool) {
+	if t == nil {
+		return config.ModelPrice{}, false
+	}
+	provider = strings.ToLower(strings.TrimSpace(provider))
+	model = strings.ToLower(strings.TrimSpace(thinking.ParseSuffix(strings.TrimSpace(model)).ModelName))
+	if model == "" {
+		return config.ModelPrice{}, false
+	}
+	for _, candidate := range t.entries {
+		if candidate.price.Provider != "" && candidate.price.Provider != provider {
+			continue
+		}
+		if util.MatchWildcard(candidate.price.Model, model) {
+			return candidate.price, true
+		}
+	}
+	return config.ModelPrice{}, false
+}
+
+// Cost returns the cost in USD of tokens consumed by model on provider, or 0 when the
+// model has no price.
+func (t *Table) Cost(provider, model string, tokens Tokens) float64 {
+	price, ok := t.Lookup(provider, model)
+	if !ok {
+		return 0
+	}
+	return priceTokens(price, provider, tokens)
+}
+
+// priceTokens applies price to tokens. Providers differ in what their counters include:
+// Claude reports input tokens without cache reads and writes while the others include
+// cached tokens in the input; the Gemini family reports thinking tokens next to the
+// output tokens while the others include them in the output.
+func priceTokens(price config.ModelPrice, provider string, tokens Tokens) float64 {
+	input := tokens.Input
+	if !cacheExclusiveProvider(provider) {
+		input -= tokens.CacheRead + tokens.CacheWrite
+	}
+	output := tokens.Output
+	if !separateReasoningProvider(provider) {
+		output -= tokens.Reasoning
+	}
+	reasoningRate := price.Reasoning
+	if reasoningRate == 0 {
+		reasoningRate = price.Output
+	}
+	cacheReadRate := price.CacheRead
+	if cacheReadRate == 0 {
+		cacheReadRate = price.Input
+	}
+	cacheWriteRate := price.CacheWrite
+	if cacheWriteRate == 0 {
+		cacheWriteRate = price.Input
+	}
+	total := float64(max(input, 0))*price.Input +
+		float64(max(output, 0))*price.Output +
+		float64(max(tokens.Reasoning, 0))*reasoningRate +
+		float64(max(tokens.CacheRead, 0))*cacheReadRate +
+		float64(max(tokens.CacheWrite, 0))*cacheWriteRate
+	return total / tokensPerUnit
+}
+
+func cacheExclusiveProvider(provider string) bool {
+	return strings.EqualFold(strings.TrimSpace(provider), "claude")
+}
+
+func separateReasoningProvider(provider string) bool {
+	switch strings.ToLower(strings.TrimSpace(provider)) {
+	case "gemini", "gemini-cli", "vertex", "aistudio", "antigravity":
+		return true
+	default:
+		return false
+	}
+}
+
+var defaultTable atomic.Pointer[Table]
+
+// Default returns the process-wide price table, or nil before one is installed.
+func Default() *Table { return defaultTable.Load() }
+
+// SetDefault installs the process-wide price table; nil stops cost accounting.
+func SetDefault(t *Table) { defaultTable.Store(t) }
@@ -0,0 +1,78 @@
+package pricing
+
+import (
+	"math"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+func TestTableLookupPrecedence(t *testing.T) {
+	table, err := New(config.PricingConfig{Models: []config.ModelPrice{
+		{Model: "gpt-5*", Input: 9, Output: 9},
+		{Provider: "codex", Model: "gpt-5*", Input: 7, Output: 7},
+	}})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	cases := []struct {
+		provider string
+		model    string
+		want     float64
+	}{
+		{provider: "codex", model: "gpt-5.1", want: 7},
+		{provider: "openai", model: "gpt-5.1", want: 9},
+		{provider: "claude", model: "claude-opus-4-1-20250805", want: 15},
+		{provider: "claude", model: "claude-opus-4-5-20251101", want: 5},
+		{provider: "antigravity", model: "claude-opus-4-6-thinking", want: 5},
+		{provider: "gemini", model: "gemini-2.5-flash-lite", want: 0.1},
+		{provider: "gemini", model: "Gemini-2.5-Flash(8192)", want: 0.3},
+	}
+	for _, tc := range cases {
+		price, ok := table.Lookup(tc.provider, tc.model)
+		if !ok || price.Input != tc.want {
+			t.Fatalf("Lookup(%q, %q) = %+v, %t; want input %v", tc.provider, tc.model, price, ok, tc.want)
+		}
+	}
+	if _, ok := table.Lookup("iflow", "glm-4.6"); ok {
+		t.Fatal("Lookup(glm-4.6) found a price, want none")
+	}
+
+	withoutCatalog, err := New(config.PricingConfig{DisableCatalog: true})
+	if err != nil {
+		t.Fatalf("New without catalog: %v", err)
+	}
+	if _, ok := withoutCatalog.Lookup("claude", "claude-sonnet-4-5"); ok {
+		t.Fatal("Lookup found a bundled price with the catalog disabled")
+	}
+}
+
+func TestTableCostFollowsProviderTokenSemantics(t *testing.T) {
+	table, err := New(config.PricingConfig{DisableCatalog: true, Models: []config.ModelPrice{
+		{Model: "m", Input: 1, Output: 10, Reasoning: 20, CacheRead: 0.1, CacheWrite: 2},
+	}})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	cases := []struct {
+		name     string
+		provider string
+		tokens   Tokens
+		want     float64
+	}{
+		// Claude input excludes cache reads and writes.
+		{name: "claude", provider: "claude", tokens: Tokens{Input: 1e6, Output: 1e6, CacheRead: 1e6, CacheWrite: 1e6}, want: 1 + 10 + 0.1 + 2},
+		// OpenAI input includes cache reads and output includes reasoning.
+		{name: "codex", provider: "codex", tokens: Tokens{Input: 2e6, Output: 3e6, Reasoning: 1e6, CacheRead: 1e6}, want: 1 + 20 + 20 + 0.1},
+		// Gemini reports thinking tokens next to the output.
+		{name: "gemini", provider: "gemini", tokens: Tokens{Input: 1e6, Output: 1e6, Reasoning: 1e6}, want: 1 + 10 + 20},
+	}
+	for _, tc := range cases {
+		if got := table.Cost(tc.provider, "m", tc.tokens); math.Abs(got-tc.want) > 1e-9 {
+			t.Fatalf("%s: Cost() = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+	if got := (*Table)(nil).Cost("claude", "m", Tokens{Input: 1e6}); got != 0 {
+		t.Fatalf("nil table Cost() = %v, want 0", got)
+	}
+}
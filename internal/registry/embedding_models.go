@@ -0,0 +1,74 @@
+// Package registry provides model definitions for various AI service providers.
+package registry
+
+// EmbeddingEndpoint is the OpenAI endpoint advertised by models that produce embeddings.
+const EmbeddingEndpoint = "/embeddings"
+
+// GetGeminiEmbeddingModels returns the Gemini embedding model definitions shared by the
+// Gemini API and Vertex AI. They are defined in code so catalog refreshes cannot drop them.
+func GetGeminiEmbeddingModels() []*ModelInfo {
+	return []*ModelInfo{
+		{
+			ID:                         "gemini-embedding-001",
+			Object:                     "model",
+			Created:                    1752537600,
+			OwnedBy:                    "google",
+			Type:                       "gemini",
+			DisplayName:                "Gemini Embedding 001",
+			Name:                       "models/gemini-embedding-001",
+			Version:                    "001",
+			Description:                "Gemini text embedding model",
+			InputTokenLimit:            2048,
+			SupportedGenerationMethods: []string{"embedContent", "batchEmbedContents"},
+			SupportedEndpoints:         []string{EmbeddingEndpoint},
+		},
+		{
+			ID:                         "text-embedding-004",
+			Object:                     "model",
+			Created:                    1715731200,
+			OwnedBy:                    "google",
+			Type:                       "gemini",
+			DisplayName:                "Text Embedding 004",
+			Name:                       "models/text-embedding-004",
+			Version:                    "004",
+			Description:                "Legacy text embedding model",
+			InputTokenLimit:            2048,
+			SupportedGenerationMethods: []string{"embedContent", "batchEmbedContents"},
+			SupportedEndpoints:         []string{EmbeddingEndpoint},
+		},
+	}
+}
+
+// SupportsEmbeddings reports whether the model produces embeddings rather than text.
+func (m *ModelInfo) SupportsEmbeddings() bool {
+	if m == nil {
+		return false
+	}
+	for _, endpoint := range m.SupportedEndpoints {
+		if endpoint == EmbeddingEndpoint {
+			return true
+		}
+	}
+	for _, method := range m.SupportedGenerationMethods {
+		if method == "embedContent" {
+			return true
+		}
+	}
+	return false
+}
+
+// withEmbeddingModels appends the embedding models missing from models.
+func withEmbeddingModels(models []*ModelInfo) []*ModelInfo {
+	seen := make(map[string]struct{}, len(models))
+	for _, m := range models {
+		if m != nil {
+			seen[m.ID] = struct{}{}
+		}
+	}
+	for _, m := range GetGeminiEmbeddingModels() {
+		if _, exists := seen[m.ID]; !exists {
+			models = append(models, m)
+		}
+	}
+	return models
+}
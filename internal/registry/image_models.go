@@ -0,0 +1,19 @@
+package registry
+
+import "strings"
+
+// SupportsImageOutput reports whether generateContent on the model can return images.
+// Catalog entries rarely list their output modalities, so Gemini image models are also
+// recognised by the "-image" marker in their ID, and Imagen models by their prefix.
+func (m *ModelInfo) SupportsImageOutput() bool {
+	if m == nil {
+		return false
+	}
+	for _, modality := range m.SupportedOutputModalities {
+		if strings.EqualFold(modality, "IMAGE") {
+			return true
+		}
+	}
+	id := strings.ToLower(m.ID)
+	return strings.Contains(id, "-image") || strings.HasPrefix(id, "imagen-")
+}
@@ -28,14 +28,14 @@ func GetClaudeModels() []*ModelInfo {
 	return cloneModelInfos(getModels().Claude)
 }
 
-// GetGeminiModels returns the standard Gemini model definitions.
+// GetGeminiModels returns the standard Gemini model definitions, including embedding models.
 func GetGeminiModels() []*ModelInfo {
-	return cloneModelInfos(getModels().Gemini)
+	return withEmbeddingModels(cloneModelInfos(getModels().Gemini))
 }
 
-// GetGeminiVertexModels returns Gemini model definitions for Vertex AI.
+// GetGeminiVertexModels returns Gemini model definitions for Vertex AI, including embedding models.
 func GetGeminiVertexModels() []*ModelInfo {
-	return cloneModelInfos(getModels().Vertex)
+	return withEmbeddingModels(cloneModelInfos(getModels().Vertex))
 }
 
 // GetGeminiCLIModels returns Gemini model definitions for the Gemini CLI.
@@ -176,6 +176,7 @@ func LookupStaticModelInfo(modelID string) *ModelInfo {
 		GetKiroModels(),
 		GetKiloModels(),
 		GetAmazonQModels(),
+		GetGeminiEmbeddingModels(),
 	}
 	for _, models := range allModels {
 		for _, m := range models {
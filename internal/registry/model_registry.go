@@ -15,6 +15,10 @@ import (
 	log "github.com/sirupsen/logrus"
 )
 
+// CompletionsEndpoint is the legacy OpenAI text completions endpoint, advertised by models
+// whose upstream serves it natively.
+const CompletionsEndpoint = "/completions"
+
 // ModelInfo represents information about an available model
 type ModelInfo struct {
 	// ID is the unique identifier for the model
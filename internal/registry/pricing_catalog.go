@@ -0,0 +1,10 @@
+package registry
+
+import _ "embed"
+
+//go:embed models/pricing.json
+var embeddedPricingJSON []byte
+
+// EmbeddedPricingCatalog returns the bundled price catalog: a JSON document whose "models"
+// array lists model prices in USD per million tokens, in the format of pricing.models.
+func EmbeddedPricingCatalog() []byte { return embeddedPricingJSON }
@@ -0,0 +1,182 @@
+package responsestore
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemoryBackend keeps records in process memory, dropping the oldest beyond maxEntries.
+type MemoryBackend struct {
+	mu         sync.Mutex
+	maxEntries int
+	order      *list.List
+	items      map[string]*list.Element
+}
+
+// NewMemoryBackend creates a memory backend; maxEntries <= 0 leaves it unbounded.
+func NewMemoryBackend(maxEntries int) *MemoryBackend {
+	return &MemoryBackend{maxEntries: maxEntries, order: list.New(), items: make(map[string]*list.Element)}
+}
+
+// Get implements Backend.
+func (b *MemoryBackend) Get(_ context.Context, id string) (*Record, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	element, ok := b.items[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	record := *element.Value.(*Record)
+	return &record, nil
+}
+
+// Put implements Backend.
+func (b *MemoryBackend) Put(_ context.Context, record *Record) error {
+	stored := *record
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.removeLocked(record.ID)
+	b.items[record.ID] = b.order.PushBack(&stored)
+	for b.maxEntries > 0 && b.order.Len() > b.maxEntries {
+		b.removeLocked(b.order.Front().Value.(*Record).ID)
+	}
+	return nil
+}
+
+// Delete implements Backend.
+func (b *MemoryBackend) Delete(_ context.Context, id string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.items[id]; !ok {
+		return ErrNotFound
+	}
+	b.removeLocked(id)
+	return nil
+}
+
+// DeleteExpired implements Backend.
+func (b *MemoryBackend) DeleteExpired(_ context.Context, now time.Time) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for element := b.order.Front(); element != nil; {
+		next := element.Next()
+		if record := element.Value.(*Record); !now.Before(record.ExpiresAt) {
+			b.removeLocked(record.ID)
+		}
+		element = next
+	}
+	return nil
+}
+
+func (b *MemoryBackend) removeLocked(id string) {
+	if element, ok := b.items[id]; ok {
+		b.order.Remove(element)
+		delete(b.items, id)
+	}
+}
+
+// DiskBackend keeps one JSON file per record so responses survive restarts. File names
+// are digests of the response ids and file modification times carry the expiry, which
+// lets expired records be removed without reading them.
+type DiskBackend struct {
+	dir string
+}
+
+// NewDiskBackend opens dir, creating it when missing.
+func NewDiskBackend(dir string) (*DiskBackend, error) {
+	dir = strings.TrimSpace(dir)
+	if dir == "" {
+		return nil, fmt.Errorf("response store: directory is empty")
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("response store: create directory: %w", err)
+	}
+	return &DiskBackend{dir: dir}, nil
+}
+
+// Get implements Backend.
+func (b *DiskBackend) Get(_ context.Context, id string) (*Record, error) {
+	data, err := os.ReadFile(b.path(id))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("response store: read record: %w", err)
+	}
+	var record Record
+	if err = json.Unmarshal(data, &record); err != nil || record.ID != id {
+		_ = os.Remove(b.path(id))
+		return nil, ErrNotFound
+	}
+	return &record, nil
+}
+
+// Put implements Backend.
+func (b *DiskBackend) Put(_ context.Context, record *Record) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("response store: encode record: %w", err)
+	}
+	path := b.path(record.ID)
+	tmp := path + ".tmp"
+	if err = os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("response store: write record: %w", err)
+	}
+	if err = os.Chtimes(tmp, record.ExpiresAt, record.ExpiresAt); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("response store: set record expiry: %w", err)
+	}
+	if err = os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("response store: write record: %w", err)
+	}
+	return nil
+}
+
+// Delete implements Backend.
+func (b *DiskBackend) Delete(_ context.Context, id string) error {
+	if err := os.Remove(b.path(id)); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return ErrNotFound
+		}
+		return fmt.Errorf("response store: delete record: %w", err)
+	}
+	return nil
+}
+
+// DeleteExpired implements Backend.
+func (b *DiskBackend) DeleteExpired(_ context.Context, now time.Time) error {
+	entries, err := os.ReadDir(b.dir)
+	if err != nil {
+		return fmt.Errorf("response store: list records: %w", err)
+	}
+	var errs []error
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		info, errInfo := entry.Info()
+		if errInfo != nil || now.Before(info.ModTime()) {
+			continue
+		}
+		if errRemove := os.Remove(filepath.Join(b.dir, entry.Name())); errRemove != nil && !errors.Is(errRemove, os.ErrNotExist) {
+			errs = append(errs, errRemove)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (b *DiskBackend) path(id string) string {
+	sum := sha256.Sum256([]byte(id))
+	return filepath.Join(b.dir, hex.EncodeToString(sum[:])+".json")
+}
@@ -0,0 +1,118 @@
+package responsestore
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// ErrInvalidInput reports a request input that is neither a string nor an array.
+var ErrInvalidInput = errors.New("input must be a string or an array")
+
+// Expand replaces previous_response_id in a Responses API request with the conversation it
+// refers to: the stored input and output items of the previous response followed by the
+// request's own input. It returns the request to execute and its complete input items.
+// Requests without previous_response_id are returned unchanged.
+func (s *Store) Expand(ctx context.Context, rawJSON []byte, owner string) ([]byte, json.RawMessage, error) {
+	input, err := InputItems(rawJSON)
+	if err != nil {
+		return nil, nil, err
+	}
+	previousID := strings.TrimSpace(gjson.GetBytes(rawJSON, "previous_response_id").String())
+	if previousID == "" {
+		return rawJSON, input, nil
+	}
+	previous, err := s.Get(ctx, previousID, owner)
+	if err != nil {
+		return nil, nil, err
+	}
+	merged, err := concatArrays(previous.Input, OutputItems(previous.Response), input)
+	if err != nil {
+		return nil, nil, fmt.Errorf("merge input of %s: %w", previousID, err)
+	}
+	expanded, err := sjson.DeleteBytes(rawJSON, "previous_response_id")
+	if err != nil {
+		return nil, nil, err
+	}
+	if expanded, err = sjson.SetRawBytes(expanded, "input", merged); err != nil {
+		return nil, nil, err
+	}
+	return expanded, merged, nil
+}
+
+// InputItems returns the input of a Responses API request as a JSON array of items; a
+// string input becomes a single user message.
+func InputItems(rawJSON []byte) (json.RawMessage, error) {
+	input := gjson.GetBytes(rawJSON, "input")
+	switch {
+	case !input.Exists() || input.Type == gjson.Null:
+		return json.RawMessage("[]"), nil
+	case input.Type == gjson.String:
+		item := map[string]any{
+			"type":    "message",
+			"role":    "user",
+			"content": []any{map[string]any{"type": "input_text", "text": input.String()}},
+		}
+		data, err := json.Marshal([]any{item})
+		if err != nil {
+			return nil, err
+		}
+		return data, nil
+	case input.IsArray():
+		return json.RawMessage(input.Raw), nil
+	default:
+		return nil, ErrInvalidInput
+	}
+}
+
+// OutputItems returns the output items of a response object as a JSON array.
+func OutputItems(response []byte) json.RawMessage {
+	output := gjson.GetBytes(response, "output")
+	if !output.IsArray() {
+		return json.RawMessage("[]")
+	}
+	return json.RawMessage(output.Raw)
+}
+
+// CompletedResponse extracts the response object of a response.completed event from a
+// chunk of a Responses API stream.
+func CompletedResponse(chunk []byte) ([]byte, bool) {
+	for _, line := range bytes.Split(chunk, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if !bytes.HasPrefix(line, []byte("data:")) {
+			continue
+		}
+		payload := bytes.TrimSpace(line[len("data:"):])
+		if gjson.GetBytes(payload, "type").String() != "response.completed" {
+			continue
+		}
+		if response := gjson.GetBytes(payload, "response"); response.IsObject() {
+			return []byte(response.Raw), true
+		}
+	}
+	return nil, false
+}
+
+func concatArrays(arrays ...json.RawMessage) (json.RawMessage, error) {
+	var merged []json.RawMessage
+	for _, raw := range arrays {
+		if len(bytes.TrimSpace(raw)) == 0 {
+			continue
+		}
+		var items []json.RawMessage
+		if err := json.Unmarshal(raw, &items); err != nil {
+			return nil, err
+		}
+		merged = append(merged, items...)
+	}
+	if merged == nil {
+		return json.RawMessage("[]"), nil
+	}
+	return json.Marshal(merged)
+}
@@ -0,0 +1,124 @@
+// Package responsestore keeps Responses API results on the server so later requests can
+// continue them with previous_response_id on any provider, and clients can read them back.
+package responsestore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// sweepInterval is the minimum time between two removals of expired records.
+const sweepInterval = 10 * time.Minute
+
+// ErrNotFound reports an unknown, expired or foreign response id.
+var ErrNotFound = errors.New("response not found")
+
+// Record is a stored response together with the full input it was generated from.
+type Record struct {
+	ID string `json:"id"`
+	// Owner is the util.APIKeyDigest of the client API key that created the response;
+	// requests made with another key cannot see it. Empty when the request was not
+	// authenticated.
+	Owner string `json:"owner,omitempty"`
+	// Input is the JSON array of every input item of the request, including the items
+	// inherited through previous_response_id.
+	Input json.RawMessage `json:"input"`
+	// Response is the response object returned to the client.
+	Response  json.RawMessage `json:"response"`
+	CreatedAt time.Time       `json:"created_at"`
+	ExpiresAt time.Time       `json:"expires_at"`
+}
+
+// Backend persists records. Implementations must be safe for concurrent use and return
+// ErrNotFound for unknown ids.
+type Backend interface {
+	Get(ctx context.Context, id string) (*Record, error)
+	Put(ctx context.Context, record *Record) error
+	Delete(ctx context.Context, id string) error
+	// DeleteExpired removes the records that expired before now.
+	DeleteExpired(ctx context.Context, now time.Time) error
+}
+
+// Store applies a TTL and ownership checks on top of a Backend.
+type Store struct {
+	backend Backend
+	ttl     time.Duration
+	now     func() time.Time
+
+	sweepMu   sync.Mutex
+	lastSweep time.Time
+}
+
+// New wraps backend; records expire ttl after they are saved.
+func New(backend Backend, ttl time.Duration) *Store {
+	return &Store{backend: backend, ttl: ttl, now: time.Now}
+}
+
+// Save stores record for the configured TTL and occasionally removes expired records.
+func (s *Store) Save(ctx context.Context, record *Record) error {
+	if s == nil || s.backend == nil || record == nil || record.ID == "" {
+		return nil
+	}
+	now := s.now()
+	record.CreatedAt = now.UTC()
+	record.ExpiresAt = now.Add(s.ttl).UTC()
+	if err := s.backend.Put(ctx, record); err != nil {
+		return err
+	}
+	s.sweep(ctx, now)
+	return nil
+}
+
+// Get returns the live record id created by owner.
+func (s *Store) Get(ctx context.Context, id, owner string) (*Record, error) {
+	if s == nil || s.backend == nil || id == "" {
+		return nil, ErrNotFound
+	}
+	record, err := s.backend.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !s.now().Before(record.ExpiresAt) {
+		_ = s.backend.Delete(ctx, id)
+		return nil, ErrNotFound
+	}
+	if record.Owner != owner {
+		return nil, ErrNotFound
+	}
+	return record, nil
+}
+
+// Delete removes the record id created by owner.
+func (s *Store) Delete(ctx context.Context, id, owner string) error {
+	if _, err := s.Get(ctx, id, owner); err != nil {
+		return err
+	}
+	return s.backend.Delete(ctx, id)
+}
+
+func (s *Store) sweep(ctx context.Context, now time.Time) {
+	s.sweepMu.Lock()
+	if now.Sub(s.lastSweep) < sweepInterval {
+		s.sweepMu.Unlock()
+		return
+	}
+	s.lastSweep = now
+	s.sweepMu.Unlock()
+	if err := s.backend.DeleteExpired(ctx, now); err != nil {
+		log.Warnf("response store: remove expired responses: %v", err)
+	}
+}
+
+var defaultStore atomic.Pointer[Store]
+
+// Default returns the process-wide response store, or nil when storing is disabled.
+func Default() *Store { return defaultStore.Load() }
+
+// SetDefault installs the process-wide response store; nil disables storing.
+func SetDefault(s *Store) { defaultStore.Store(s) }
@@ -0,0 +1,111 @@
+package responsestore
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestStoreExpiresAndScopesRecords(t *testing.T) {
+	for name, newBackend := range map[string]func(t *testing.T) Backend{
+		"memory": func(*testing.T) Backend { return NewMemoryBackend(0) },
+		"disk": func(t *testing.T) Backend {
+			backend, err := NewDiskBackend(t.TempDir())
+			if err != nil {
+				t.Fatalf("NewDiskBackend: %v", err)
+			}
+			return backend
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+			store := New(newBackend(t), time.Hour)
+			store.now = func() time.Time { return now }
+
+			record := &Record{ID: "resp_1", Owner: "owner-a", Input: []byte(`[]`), Response: []byte(`{"id":"resp_1"}`)}
+			if err := store.Save(ctx, record); err != nil {
+				t.Fatalf("Save: %v", err)
+			}
+			if _, err := store.Get(ctx, "resp_1", "owner-a"); err != nil {
+				t.Fatalf("Get: %v", err)
+			}
+			if _, err := store.Get(ctx, "resp_1", "owner-b"); !errors.Is(err, ErrNotFound) {
+				t.Fatalf("Get with other owner error = %v, want ErrNotFound", err)
+			}
+			if err := store.Delete(ctx, "resp_1", "owner-b"); !errors.Is(err, ErrNotFound) {
+				t.Fatalf("Delete with other owner error = %v, want ErrNotFound", err)
+			}
+
+			now = now.Add(2 * time.Hour)
+			if _, err := store.Get(ctx, "resp_1", "owner-a"); !errors.Is(err, ErrNotFound) {
+				t.Fatalf("Get after expiry error = %v, want ErrNotFound", err)
+			}
+		})
+	}
+}
+
+func TestMemoryBackendEvictsOldest(t *testing.T) {
+	ctx := context.Background()
+	backend := NewMemoryBackend(2)
+	for _, id := range []string{"a", "b", "c"} {
+		if err := backend.Put(ctx, &Record{ID: id}); err != nil {
+			t.Fatalf("Put %s: %v", id, err)
+		}
+	}
+	if _, err := backend.Get(ctx, "a"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get a error = %v, want ErrNotFound", err)
+	}
+	if _, err := backend.Get(ctx, "c"); err != nil {
+		t.Fatalf("Get c: %v", err)
+	}
+}
+
+func TestExpandMergesPreviousConversation(t *testing.T) {
+	ctx := context.Background()
+	store := New(NewMemoryBackend(0), time.Hour)
+	previous := &Record{
+		ID:       "resp_1",
+		Input:    []byte(`[{"type":"message","role":"user","content":[{"type":"input_text","text":"one"}]}]`),
+		Response: []byte(`{"id":"resp_1","output":[{"type":"message","role":"assistant","content":[{"type":"output_text","text":"two"}]}]}`),
+	}
+	if err := store.Save(ctx, previous); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	expanded, input, err := store.Expand(ctx, []byte(`{"model":"m","previous_response_id":"resp_1","input":"three"}`), "")
+	if err != nil {
+		t.Fatalf("Expand: %v", err)
+	}
+	if gjson.GetBytes(expanded, "previous_response_id").Exists() {
+		t.Fatalf("expanded request keeps previous_response_id: %s", expanded)
+	}
+	texts := gjson.GetBytes(expanded, "input.#.content.0.text").Array()
+	if len(texts) != 3 || texts[0].String() != "one" || texts[1].String() != "two" || texts[2].String() != "three" {
+		t.Fatalf("expanded input = %s", gjson.GetBytes(expanded, "input").Raw)
+	}
+	if string(input) != gjson.GetBytes(expanded, "input").Raw {
+		t.Fatalf("input items = %s, want the expanded input", input)
+	}
+
+	if _, _, err = store.Expand(ctx, []byte(`{"previous_response_id":"resp_missing"}`), ""); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Expand unknown id error = %v, want ErrNotFound", err)
+	}
+	if _, _, err = store.Expand(ctx, []byte(`{"input":{"bad":true}}`), ""); !errors.Is(err, ErrInvalidInput) {
+		t.Fatalf("Expand object input error = %v, want ErrInvalidInput", err)
+	}
+}
+
+func TestCompletedResponse(t *testing.T) {
+	chunk := []byte("event: response.completed\ndata: {\"type\":\"response.completed\",\"response\":{\"id\":\"resp_9\"}}\n")
+	response, ok := CompletedResponse(chunk)
+	if !ok || gjson.GetBytes(response, "id").String() != "resp_9" {
+		t.Fatalf("CompletedResponse = %s, %v", response, ok)
+	}
+	if _, ok = CompletedResponse([]byte(`data: {"type":"response.output_text.delta"}`)); ok {
+		t.Fatal("CompletedResponse matched a delta event")
+	}
+}
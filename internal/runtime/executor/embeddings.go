@@ -0,0 +1,236 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// isEmbeddingRequest reports whether the request asks for embeddings rather than generated content.
+func isEmbeddingRequest(opts cliproxyexecutor.Options) bool {
+	return opts.SourceFormat == sdktranslator.FormatOpenAIEmbedding || opts.SourceFormat == sdktranslator.FormatGeminiEmbedding
+}
+
+// errEmbeddingStream rejects streaming calls carrying an embeddings request.
+var errEmbeddingStream = statusErr{code: http.StatusBadRequest, msg: "embeddings requests cannot be streamed"}
+
+// postEmbeddingRequest sends an embeddings request prepared by prepare and returns the
+// upstream body, recording both sides in the request log.
+func postEmbeddingRequest(ctx context.Context, cfg *config.Config, auth *cliproxyauth.Auth, provider, url string, body []byte, prepare func(*http.Request) error) ([]byte, http.Header, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if err = prepare(httpReq); err != nil {
+		return nil, nil, err
+	}
+	var authID, authLabel, authType, authValue string
+	if auth != nil {
+		authID = auth.ID
+		authLabel = auth.Label
+		authType, authValue = auth.AccountInfo()
+	}
+	recordAPIRequest(ctx, cfg, upstreamRequestLog{
+		URL:       url,
+		Method:    http.MethodPost,
+		Headers:   httpReq.Header.Clone(),
+		Body:      body,
+		Provider:  provider,
+		AuthID:    authID,
+		AuthLabel: authLabel,
+		AuthType:  authType,
+		AuthValue: authValue,
+	})
+
+	httpClient := newProxyAwareHTTPClient(ctx, cfg, auth, 0)
+	httpResp, err := httpClient.Do(httpReq)
+	if err != nil {
+		recordAPIResponseError(ctx, cfg, err)
+		return nil, nil, err
+	}
+	defer func() {
+		if errClose := httpResp.Body.Close(); errClose != nil {
+			log.Errorf("%s executor: close embeddings response body error: %v", provider, errClose)
+		}
+	}()
+	recordAPIResponseMetadata(ctx, cfg, httpResp.StatusCode, httpResp.Header.Clone())
+	data, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		recordAPIResponseError(ctx, cfg, err)
+		return nil, nil, err
+	}
+	appendAPIResponseChunk(ctx, cfg, data)
+	if httpResp.StatusCode < 200 || httpResp.StatusCode >= 300 {
+		logWithRequestID(ctx).Debugf("request error, error status: %d, error message: %s", httpResp.StatusCode, summarizeErrorBody(httpResp.Header.Get("Content-Type"), data))
+		return nil, nil, statusErr{code: httpResp.StatusCode, msg: string(data)}
+	}
+	return data, httpResp.Header.Clone(), nil
+}
+
+// setGeminiEmbeddingModel points every entry of a batchEmbedContents request at model,
+// which the API requires to match the model in the URL.
+func setGeminiEmbeddingModel(body []byte, model string) []byte {
+	for i := range gjson.GetBytes(body, "requests").Array() {
+		body, _ = sjson.SetBytes(body, fmt.Sprintf("requests.%d.model", i), "models/"+model)
+	}
+	return body
+}
+
+// executeEmbeddings calls batchEmbedContents on the Gemini API.
+func (e *GeminiExecutor) executeEmbeddings(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (resp cliproxyexecutor.Response, err error) {
+	baseModel := thinking.ParseSuffix(req.Model).ModelName
+	reporter := newUsageReporter(ctx, e.Identifier(), baseModel, auth)
+	defer reporter.trackFailure(ctx, &err)
+
+	from := opts.SourceFormat
+	to := sdktranslator.FormatGeminiEmbedding
+	body := sdktranslator.TranslateRequest(from, to, baseModel, req.Payload, false)
+	body = setGeminiEmbeddingModel(body, baseModel)
+
+	url := fmt.Sprintf("%s/%s/models/%s:batchEmbedContents", resolveGeminiBaseURL(auth), glAPIVersion, baseModel)
+	data, headers, err := postEmbeddingRequest(ctx, e.cfg, auth, e.Identifier(), url, body, func(r *http.Request) error {
+		return e.PrepareRequest(r, auth)
+	})
+	if err != nil {
+		return resp, err
+	}
+	reporter.publish(ctx, parseGeminiUsage(data))
+	reporter.ensurePublished(ctx)
+	var param any
+	out := sdktranslator.TranslateNonStream(ctx, to, from, req.Model, opts.OriginalRequest, body, data, &param)
+	return cliproxyexecutor.Response{Payload: []byte(out), Headers: headers}, nil
+}
+
+// executeEmbeddings calls the predict endpoint of a Vertex AI embedding model. The request
+// is built from the Gemini batch form and the predictions are converted back into it.
+func (e *GeminiVertexExecutor) executeEmbeddings(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (resp cliproxyexecutor.Response, err error) {
+	baseModel := thinking.ParseSuffix(req.Model).ModelName
+	reporter := newUsageReporter(ctx, e.Identifier(), baseModel, auth)
+	defer reporter.trackFailure(ctx, &err)
+
+	var url string
+	if apiKey, baseURL := vertexAPICreds(auth); apiKey != "" {
+		if baseURL == "" {
+			baseURL = "https://aiplatform.googleapis.com"
+		}
+		url = fmt.Sprintf("%s/%s/publishers/google/models/%s:predict", baseURL, vertexAPIVersion, baseModel)
+	} else {
+		projectID, location, _, errCreds := vertexCreds(auth)
+		if errCreds != nil {
+			return resp, errCreds
+		}
+		url = fmt.Sprintf("%s/%s/projects/%s/locations/%s/publishers/google/models/%s:predict", vertexBaseURL(location), vertexAPIVersion, projectID, location, baseModel)
+	}
+
+	from := opts.SourceFormat
+	to := sdktranslator.FormatGeminiEmbedding
+	batch := sdktranslator.TranslateRequest(from, to, baseModel, req.Payload, false)
+	body := convertGeminiEmbeddingToVertexPredict(batch)
+
+	data, headers, err := postEmbeddingRequest(ctx, e.cfg, auth, e.Identifier(), url, body, func(r *http.Request) error {
+		if errPrepare := e.PrepareRequest(r, auth); errPrepare != nil {
+			return errPrepare
+		}
+		applyGeminiHeaders(r, auth)
+		return nil
+	})
+	if err != nil {
+		return resp, err
+	}
+	data = convertVertexPredictToGeminiEmbedding(data)
+	reporter.publish(ctx, parseGeminiUsage(data))
+	reporter.ensurePublished(ctx)
+	var param any
+	out := sdktranslator.TranslateNonStream(ctx, to, from, req.Model, opts.OriginalRequest, batch, data, &param)
+	return cliproxyexecutor.Response{Payload: []byte(out), Headers: headers}, nil
+}
+
+// convertGeminiEmbeddingToVertexPredict maps a batchEmbedContents request onto the Vertex AI
+// text embedding predict schema.
+func convertGeminiEmbeddingToVertexPredict(batch []byte) []byte {
+	out := []byte(`{"instances":[]}`)
+	for _, request := range gjson.GetBytes(batch, "requests").Array() {
+		var parts []string
+		for _, part := range request.Get("content.parts").Array() {
+			if text := part.Get("text"); text.Exists() {
+				parts = append(parts, text.String())
+			}
+		}
+		instance := []byte(`{"content":""}`)
+		instance, _ = sjson.SetBytes(instance, "content", strings.Join(parts, "\n"))
+		if taskType := request.Get("taskType"); taskType.Exists() {
+			instance, _ = sjson.SetBytes(instance, "task_type", taskType.String())
+		}
+		if title := request.Get("title"); title.Exists() {
+			instance, _ = sjson.SetBytes(instance, "title", title.String())
+		}
+		out, _ = sjson.SetRawBytes(out, "instances.-1", instance)
+		if dims := request.Get("outputDimensionality"); dims.Exists() {
+			out, _ = sjson.SetBytes(out, "parameters.outputDimensionality", dims.Int())
+		}
+	}
+	return out
+}
+
+// convertVertexPredictToGeminiEmbedding maps Vertex AI predictions onto a batchEmbedContents
+// response, carrying the summed token counts as usage metadata.
+func convertVertexPredictToGeminiEmbedding(data []byte) []byte {
+	out := []byte(`{"embeddings":[]}`)
+	var tokens int64
+	for _, prediction := range gjson.GetBytes(data, "predictions").Array() {
+		embedding := []byte(`{"values":[]}`)
+		embedding, _ = sjson.SetRawBytes(embedding, "values", []byte(prediction.Get("embeddings.values").Raw))
+		out, _ = sjson.SetRawBytes(out, "embeddings.-1", embedding)
+		tokens += prediction.Get("embeddings.statistics.token_count").Int()
+	}
+	if tokens > 0 {
+		out, _ = sjson.SetBytes(out, "usageMetadata.promptTokenCount", tokens)
+		out, _ = sjson.SetBytes(out, "usageMetadata.totalTokenCount", tokens)
+	}
+	return out
+}
+
+// executeEmbeddings calls the /embeddings endpoint of an OpenAI-compatible provider.
+func (e *OpenAICompatExecutor) executeEmbeddings(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (resp cliproxyexecutor.Response, err error) {
+	baseModel := thinking.ParseSuffix(req.Model).ModelName
+	reporter := newUsageReporter(ctx, e.Identifier(), baseModel, auth)
+	defer reporter.trackFailure(ctx, &err)
+
+	baseURL, _ := e.resolveCredentials(auth)
+	if baseURL == "" {
+		err = statusErr{code: http.StatusUnauthorized, msg: "missing provider baseURL"}
+		return resp, err
+	}
+
+	from := opts.SourceFormat
+	to := sdktranslator.FormatOpenAIEmbedding
+	body := sdktranslator.TranslateRequest(from, to, baseModel, req.Payload, false)
+	body, _ = sjson.SetBytes(body, "model", baseModel)
+
+	url := strings.TrimSuffix(baseURL, "/") + "/embeddings"
+	data, headers, err := postEmbeddingRequest(ctx, e.cfg, auth, e.Identifier(), url, body, func(r *http.Request) error {
+		r.Header.Set("User-Agent", "cli-proxy-openai-compat")
+		return e.PrepareRequest(r, auth)
+	})
+	if err != nil {
+		return resp, err
+	}
+	reporter.publish(ctx, parseOpenAIUsage(data))
+	reporter.ensurePublished(ctx)
+	var param any
+	out := sdktranslator.TranslateNonStream(ctx, to, from, req.Model, opts.OriginalRequest, body, data, &param)
+	return cliproxyexecutor.Response{Payload: []byte(out), Headers: headers}, nil
+}
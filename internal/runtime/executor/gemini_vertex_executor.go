@@ -161,6 +161,9 @@ func convertToImagenRequest(payload []byte) ([]byte, error) {
 	}
 	if sampleCount := gjson.GetBytes(payload, "sampleCount"); sampleCount.Exists() {
 		imagenReq["parameters"].(map[string]any)["sampleCount"] = int(sampleCount.Int())
+	} else if n := gjson.GetBytes(payload, "n"); n.Exists() && n.Int() > 0 {
+		// OpenAI images requests ask for several images with "n".
+		imagenReq["parameters"].(map[string]any)["sampleCount"] = int(n.Int())
 	}
 	if negativePrompt := gjson.GetBytes(payload, "negativePrompt"); negativePrompt.Exists() {
 		imagenReq["instances"].([]map[string]any)[0]["negativePrompt"] = negativePrompt.String()
@@ -236,6 +239,9 @@ func (e *GeminiVertexExecutor) Execute(ctx context.Context, auth *cliproxyauth.A
 	if opts.Alt == "responses/compact" {
 		return resp, statusErr{code: http.StatusNotImplemented, msg: "/responses/compact not supported"}
 	}
+	if isEmbeddingRequest(opts) {
+		return e.executeEmbeddings(ctx, auth, req, opts)
+	}
 	// Try API key authentication first
 	apiKey, baseURL := vertexAPICreds(auth)
 
@@ -257,6 +263,9 @@ func (e *GeminiVertexExecutor) ExecuteStream(ctx context.Context, auth *cliproxy
 	if opts.Alt == "responses/compact" {
 		return nil, statusErr{code: http.StatusNotImplemented, msg: "/responses/compact not supported"}
 	}
+	if isEmbeddingRequest(opts) {
+		return nil, errEmbeddingStream
+	}
 	// Try API key authentication first
 	apiKey, baseURL := vertexAPICreds(auth)
 
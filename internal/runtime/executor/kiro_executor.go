@@ -12,7 +12,6 @@ import (
 	"io"
 	"net"
 	"net/http"
-	"os"
 	"path/filepath"
 	"strings"
 	"sync"
@@ -22,6 +21,7 @@ import (
 
 	"github.com/google/uuid"
 	kiroauth "github.com/router-for-me/CLIProxyAPI/v6/internal/auth/kiro"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/authcrypt"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
 	kiroclaude "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/kiro/claude"
 	kirocommon "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/kiro/common"
@@ -3874,13 +3874,9 @@ func (e *KiroExecutor) persistRefreshedAuth(auth *cliproxyauth.Auth) error {
 		return fmt.Errorf("kiro executor: marshal metadata failed: %w", err)
 	}
 
-	// Write to temp file first, then rename (atomic write)
-	tmp := authPath + ".tmp"
-	if err := os.WriteFile(tmp, raw, 0o600); err != nil {
-		return fmt.Errorf("kiro executor: write temp auth file failed: %w", err)
-	}
-	if err := os.Rename(tmp, authPath); err != nil {
-		return fmt.Errorf("kiro executor: rename auth file failed: %w", err)
+	// Seal per auth-encryption and write atomically
+	if err := authcrypt.WriteFile(authPath, raw, 0o600); err != nil {
+		return fmt.Errorf("kiro executor: write auth file failed: %w", err)
 	}
 
 	log.Debugf("kiro executor: persisted refreshed auth to %s", authPath)
@@ -3962,7 +3958,7 @@ func (e *KiroExecutor) reloadAuthFromFile(auth *cliproxyauth.Auth) (*cliproxyaut
 	}
 
 	// 读取文件
-	raw, err := os.ReadFile(authPath)
+	raw, err := authcrypt.ReadFile(authPath)
 	if err != nil {
 		return nil, fmt.Errorf("kiro executor: failed to read auth file %s: %w", authPath, err)
 	}
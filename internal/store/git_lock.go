@@ -0,0 +1,189 @@
+package store
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v6"
+	"github.com/go-git/go-git/v6/config"
+	"github.com/go-git/go-git/v6/plumbing"
+	"github.com/go-git/go-git/v6/plumbing/object"
+	"github.com/go-git/go-git/v6/plumbing/protocol/packp"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	gitLockRefPrefix  = "refs/cliproxy-locks/"
+	gitLockTTL        = 5 * time.Minute
+	gitLockRetryDelay = 2 * time.Second
+)
+
+var _ cliproxyauth.Locker = (*GitTokenStore)(nil)
+
+// LockAuth implements cliproxyauth.Locker with a lock ref per auth on the remote. Every
+// acquisition and release pushes a commit that extends the ref without force, so the remote
+// accepts exactly one of several concurrent pushes. A lock that is not released within
+// gitLockTTL may be taken over.
+func (s *GitTokenStore) LockAuth(ctx context.Context, id string) (func(), error) {
+	if err := s.EnsureRepository(); err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256([]byte(strings.TrimSpace(id)))
+	ref := plumbing.ReferenceName(gitLockRefPrefix + hex.EncodeToString(sum[:16]))
+	owner := cliproxyauth.DefaultInstanceID()
+	for {
+		held, err := s.tryLockRef(ctx, ref, owner, id)
+		if err != nil {
+			return nil, err
+		}
+		if !held.IsZero() {
+			return func() { s.releaseLockRef(ref, held, owner, id) }, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("git token store: lock auth %s: %w", id, ctx.Err())
+		case <-time.After(gitLockRetryDelay):
+		}
+	}
+}
+
+// tryLockRef pushes a lock commit onto ref unless it records an unexpired lock. It returns the
+// pushed commit, or the zero hash when another process holds the lock.
+func (s *GitTokenStore) tryLockRef(ctx context.Context, ref plumbing.ReferenceName, owner, id string) (plumbing.Hash, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	repo, err := git.PlainOpen(s.repoDirSnapshot())
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("git token store: open repo: %w", err)
+	}
+	current, err := s.fetchLockRef(ctx, repo, ref)
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+	var parents []plumbing.Hash
+	if !current.IsZero() {
+		commit, errCommit := repo.CommitObject(current)
+		if errCommit != nil {
+			return plumbing.ZeroHash, fmt.Errorf("git token store: read lock of %s: %w", id, errCommit)
+		}
+		if expires, locked := parseGitLock(commit.Message); locked && time.Now().Before(expires) {
+			return plumbing.ZeroHash, nil
+		}
+		parents = []plumbing.Hash{current}
+	}
+	message := fmt.Sprintf("lock %s\nowner %s\nexpires %s\n", id, owner, time.Now().Add(gitLockTTL).UTC().Format(time.RFC3339))
+	hash, err := s.pushLockCommit(ctx, repo, ref, message, parents)
+	if err != nil {
+		if isGitLockConflict(err) {
+			return plumbing.ZeroHash, nil
+		}
+		return plumbing.ZeroHash, fmt.Errorf("git token store: lock auth %s: %w", id, err)
+	}
+	return hash, nil
+}
+
+// releaseLockRef pushes a release commit on top of held. The push fails harmlessly when the
+// lock expired and was taken over meanwhile.
+func (s *GitTokenStore) releaseLockRef(ref plumbing.ReferenceName, held plumbing.Hash, owner, id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	repo, err := git.PlainOpen(s.repoDirSnapshot())
+	if err == nil {
+		_, err = s.pushLockCommit(ctx, repo, ref, fmt.Sprintf("release %s\nowner %s\n", id, owner), []plumbing.Hash{held})
+	}
+	if err != nil && !isGitLockConflict(err) {
+		log.WithError(err).Warnf("git token store: release lock of auth %s", id)
+	}
+}
+
+// fetchLockRef returns the commit ref points to on the remote, fetching it when missing
+// locally, or the zero hash when the remote has no such ref.
+func (s *GitTokenStore) fetchLockRef(ctx context.Context, repo *git.Repository, ref plumbing.ReferenceName) (plumbing.Hash, error) {
+	remote, err := repo.Remote("origin")
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("git token store: remote: %w", err)
+	}
+	refs, err := remote.ListContext(ctx, &git.ListOptions{Auth: s.gitAuth()})
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("git token store: list remote refs: %w", err)
+	}
+	for _, candidate := range refs {
+		if candidate.Name() != ref {
+			continue
+		}
+		if _, errObject := repo.CommitObject(candidate.Hash()); errObject == nil {
+			return candidate.Hash(), nil
+		}
+		spec := config.RefSpec(fmt.Sprintf("+%s:%s", ref, ref))
+		errFetch := repo.FetchContext(ctx, &git.FetchOptions{Auth: s.gitAuth(), RemoteName: "origin", RefSpecs: []config.RefSpec{spec}})
+		if errFetch != nil && !errors.Is(errFetch, git.NoErrAlreadyUpToDate) {
+			return plumbing.ZeroHash, fmt.Errorf("git token store: fetch %s: %w", ref, errFetch)
+		}
+		return candidate.Hash(), nil
+	}
+	return plumbing.ZeroHash, nil
+}
+
+// pushLockCommit writes a commit with an empty tree and pushes it to ref without force.
+func (s *GitTokenStore) pushLockCommit(ctx context.Context, repo *git.Repository, ref plumbing.ReferenceName, message string, parents []plumbing.Hash) (plumbing.Hash, error) {
+	treeObject := repo.Storer.NewEncodedObject()
+	if err := (&object.Tree{}).Encode(treeObject); err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("encode lock tree: %w", err)
+	}
+	treeHash, err := repo.Storer.SetEncodedObject(treeObject)
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("write lock tree: %w", err)
+	}
+	signature := object.Signature{Name: "CLIProxyAPI", Email: "cliproxy@local", When: time.Now()}
+	commit := &object.Commit{
+		Author:       signature,
+		Committer:    signature,
+		Message:      message,
+		TreeHash:     treeHash,
+		ParentHashes: parents,
+	}
+	commitObject := repo.Storer.NewEncodedObject()
+	if err = commit.Encode(commitObject); err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("encode lock commit: %w", err)
+	}
+	hash, err := repo.Storer.SetEncodedObject(commitObject)
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("write lock commit: %w", err)
+	}
+	if err = repo.Storer.SetReference(plumbing.NewHashReference(ref, hash)); err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("update lock ref: %w", err)
+	}
+	spec := config.RefSpec(fmt.Sprintf("%s:%s", ref, ref))
+	if err = repo.PushContext(ctx, &git.PushOptions{Auth: s.gitAuth(), RemoteName: "origin", RefSpecs: []config.RefSpec{spec}}); err != nil {
+		return plumbing.ZeroHash, err
+	}
+	return hash, nil
+}
+
+// parseGitLock reads a lock commit message and reports whether it holds the lock and until when.
+func parseGitLock(message string) (time.Time, bool) {
+	if !strings.HasPrefix(message, "lock ") {
+		return time.Time{}, false
+	}
+	for _, line := range strings.Split(message, "\n") {
+		if value, ok := strings.CutPrefix(line, "expires "); ok {
+			expires, err := time.Parse(time.RFC3339, strings.TrimSpace(value))
+			return expires, err == nil
+		}
+	}
+	return time.Time{}, false
+}
+
+// isGitLockConflict reports whether a push was rejected because the lock ref moved.
+func isGitLockConflict(err error) bool {
+	var statusErr packp.CommandStatusErr
+	return errors.As(err, &statusErr) || strings.Contains(err.Error(), "non-fast-forward")
+}
@@ -0,0 +1,51 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"strings"
+	"time"
+
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	log "github.com/sirupsen/logrus"
+)
+
+var _ cliproxyauth.Locker = (*PostgresStore)(nil)
+
+// LockAuth implements cliproxyauth.Locker with a session-level advisory lock keyed on the auth
+// table and the auth id. The lock lives on a dedicated connection, so it is released by the
+// server even when the process dies while holding it.
+func (s *PostgresStore) LockAuth(ctx context.Context, id string) (func(), error) {
+	if s == nil || s.db == nil {
+		return nil, fmt.Errorf("postgres store: not initialized")
+	}
+	id = normalizeAuthID(strings.TrimSpace(id))
+	namespace := s.fullTableName(s.cfg.AuthTable)
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("postgres store: lock auth %s: %w", id, err)
+	}
+	if _, err = conn.ExecContext(ctx, "SELECT pg_advisory_lock(hashtext($1), hashtext($2))", namespace, id); err != nil {
+		discardConn(conn)
+		return nil, fmt.Errorf("postgres store: lock auth %s: %w", id, err)
+	}
+	return func() {
+		unlockCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if _, errUnlock := conn.ExecContext(unlockCtx, "SELECT pg_advisory_unlock(hashtext($1), hashtext($2))", namespace, id); errUnlock != nil {
+			log.WithError(errUnlock).Warnf("postgres store: unlock auth %s", id)
+			discardConn(conn)
+			return
+		}
+		_ = conn.Close()
+	}, nil
+}
+
+// discardConn closes the physical connection behind conn instead of returning it to the pool,
+// which also drops any advisory lock it may hold.
+func discardConn(conn *sql.Conn) {
+	_ = conn.Raw(func(any) error { return driver.ErrBadConn })
+	_ = conn.Close()
+}
@@ -0,0 +1,117 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/responsestore"
+)
+
+const defaultResponseStoreTable = "responses"
+
+// PostgresResponseStore implements responsestore.Backend on top of the connection and
+// schema of an existing PostgresStore.
+type PostgresResponseStore struct {
+	db        *sql.DB
+	table     string
+	indexName string
+}
+
+// ResponseStore returns a responsestore.Backend backed by the same database connection and
+// schema. An empty table name selects the default "responses" table.
+func (s *PostgresStore) ResponseStore(ctx context.Context, table string) (responsestore.Backend, error) {
+	if s == nil || s.db == nil {
+		return nil, fmt.Errorf("postgres store: not initialized")
+	}
+	table = strings.TrimSpace(table)
+	if table == "" {
+		table = defaultResponseStoreTable
+	}
+	store := &PostgresResponseStore{
+		db:        s.db,
+		table:     s.fullTableName(table),
+		indexName: quoteIdentifier(table + "_expires_at_idx"),
+	}
+	if err := store.ensureSchema(ctx); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+func (s *PostgresResponseStore) ensureSchema(ctx context.Context) error {
+	if _, err := s.db.ExecContext(ctx, fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %s (
+			id TEXT PRIMARY KEY,
+			owner TEXT NOT NULL DEFAULT '',
+			input JSONB NOT NULL,
+			response JSONB NOT NULL,
+			created_at TIMESTAMPTZ NOT NULL,
+			expires_at TIMESTAMPTZ NOT NULL
+		)
+	`, s.table)); err != nil {
+		return fmt.Errorf("postgres response store: create table: %w", err)
+	}
+	if _, err := s.db.ExecContext(ctx, fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s ON %s (expires_at)", s.indexName, s.table)); err != nil {
+		return fmt.Errorf("postgres response store: create index: %w", err)
+	}
+	return nil
+}
+
+// Get implements responsestore.Backend.
+func (s *PostgresResponseStore) Get(ctx context.Context, id string) (*responsestore.Record, error) {
+	query := fmt.Sprintf("SELECT id, owner, input, response, created_at, expires_at FROM %s WHERE id = $1", s.table)
+	var (
+		record   responsestore.Record
+		input    []byte
+		response []byte
+	)
+	err := s.db.QueryRowContext(ctx, query, id).Scan(&record.ID, &record.Owner, &input, &response, &record.CreatedAt, &record.ExpiresAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, responsestore.ErrNotFound
+		}
+		return nil, fmt.Errorf("postgres response store: get record: %w", err)
+	}
+	record.Input = input
+	record.Response = response
+	return &record, nil
+}
+
+// Put implements responsestore.Backend.
+func (s *PostgresResponseStore) Put(ctx context.Context, record *responsestore.Record) error {
+	query := fmt.Sprintf(`
+		INSERT INTO %s (id, owner, input, response, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (id)
+		DO UPDATE SET owner = EXCLUDED.owner, input = EXCLUDED.input, response = EXCLUDED.response,
+			created_at = EXCLUDED.created_at, expires_at = EXCLUDED.expires_at
+	`, s.table)
+	if _, err := s.db.ExecContext(ctx, query, record.ID, record.Owner, string(record.Input), string(record.Response), record.CreatedAt, record.ExpiresAt); err != nil {
+		return fmt.Errorf("postgres response store: put record: %w", err)
+	}
+	return nil
+}
+
+// Delete implements responsestore.Backend.
+func (s *PostgresResponseStore) Delete(ctx context.Context, id string) error {
+	result, err := s.db.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s WHERE id = $1", s.table), id)
+	if err != nil {
+		return fmt.Errorf("postgres response store: delete record: %w", err)
+	}
+	if affected, errAffected := result.RowsAffected(); errAffected == nil && affected == 0 {
+		return responsestore.ErrNotFound
+	}
+	return nil
+}
+
+// DeleteExpired implements responsestore.Backend.
+func (s *PostgresResponseStore) DeleteExpired(ctx context.Context, now time.Time) error {
+	if _, err := s.db.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s WHERE expires_at <= $1", s.table), now.UTC()); err != nil {
+		return fmt.Errorf("postgres response store: delete expired records: %w", err)
+	}
+	return nil
+}
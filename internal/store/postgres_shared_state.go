@@ -0,0 +1,184 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+const defaultSharedStateTable = "shared_state"
+
+// PostgresSharedState implements cliproxyauth.SharedState on top of the connection and schema
+// of an existing PostgresStore.
+type PostgresSharedState struct {
+	db       *sql.DB
+	sequence string
+	auths    string
+	cursors  string
+	leases   string
+}
+
+// SharedState returns a cliproxyauth.SharedState backed by the same database connection and
+// schema. The table name is used as prefix for the tables it creates; an empty name selects
+// "shared_state".
+func (s *PostgresStore) SharedState(ctx context.Context, table string) (cliproxyauth.SharedState, error) {
+	if s == nil || s.db == nil {
+		return nil, fmt.Errorf("postgres store: not initialized")
+	}
+	table = strings.TrimSpace(table)
+	if table == "" {
+		table = defaultSharedStateTable
+	}
+	state := &PostgresSharedState{
+		db:       s.db,
+		sequence: s.fullTableName(table + "_revision_seq"),
+		auths:    s.fullTableName(table + "_auths"),
+		cursors:  s.fullTableName(table + "_cursors"),
+		leases:   s.fullTableName(table + "_leases"),
+	}
+	if err := state.ensureSchema(ctx); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+func (s *PostgresSharedState) ensureSchema(ctx context.Context) error {
+	statements := []string{
+		fmt.Sprintf("CREATE SEQUENCE IF NOT EXISTS %s", s.sequence),
+		fmt.Sprintf(`
+			CREATE TABLE IF NOT EXISTS %s (
+				auth_id TEXT PRIMARY KEY,
+				instance TEXT NOT NULL,
+				revision BIGINT NOT NULL,
+				state JSONB NOT NULL,
+				updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+			)
+		`, s.auths),
+		fmt.Sprintf(`
+			CREATE TABLE IF NOT EXISTS %s (
+				key TEXT PRIMARY KEY,
+				value BIGINT NOT NULL
+			)
+		`, s.cursors),
+		fmt.Sprintf(`
+			CREATE TABLE IF NOT EXISTS %s (
+				key TEXT PRIMARY KEY,
+				owner TEXT NOT NULL,
+				expires_at TIMESTAMPTZ NOT NULL
+			)
+		`, s.leases),
+	}
+	for _, statement := range statements {
+		if _, err := s.db.ExecContext(ctx, statement); err != nil {
+			return fmt.Errorf("postgres shared state: create schema: %w", err)
+		}
+	}
+	return nil
+}
+
+// PublishAuthState implements cliproxyauth.SharedState.
+func (s *PostgresSharedState) PublishAuthState(ctx context.Context, state cliproxyauth.SharedAuthState) error {
+	payload, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("postgres shared state: encode state: %w", err)
+	}
+	query := fmt.Sprintf(`
+		INSERT INTO %s (auth_id, instance, revision, state, updated_at)
+		VALUES ($1, $2, nextval('%s'), $3, NOW())
+		ON CONFLICT (auth_id)
+		DO UPDATE SET instance = EXCLUDED.instance, revision = EXCLUDED.revision, state = EXCLUDED.state, updated_at = NOW()
+	`, s.auths, strings.ReplaceAll(s.sequence, "'", "''"))
+	if _, err = s.db.ExecContext(ctx, query, state.AuthID, state.Instance, payload); err != nil {
+		return fmt.Errorf("postgres shared state: publish %s: %w", state.AuthID, err)
+	}
+	return nil
+}
+
+// AuthStatesSince implements cliproxyauth.SharedState. Revisions are taken from a sequence
+// before the publishing transaction commits, so a lower revision can become visible after a
+// higher one. States updated during the last minute are therefore always returned; callers
+// skip the revisions they already applied.
+func (s *PostgresSharedState) AuthStatesSince(ctx context.Context, revision int64) ([]cliproxyauth.SharedAuthState, int64, error) {
+	query := fmt.Sprintf(`
+		SELECT revision, state FROM %s
+		WHERE revision > $1 OR updated_at > NOW() - INTERVAL '1 minute'
+		ORDER BY revision
+	`, s.auths)
+	rows, err := s.db.QueryContext(ctx, query, revision)
+	if err != nil {
+		return nil, revision, fmt.Errorf("postgres shared state: query states: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	latest := revision
+	var states []cliproxyauth.SharedAuthState
+	for rows.Next() {
+		var (
+			rowRevision int64
+			payload     []byte
+		)
+		if err = rows.Scan(&rowRevision, &payload); err != nil {
+			return nil, revision, fmt.Errorf("postgres shared state: scan state: %w", err)
+		}
+		var state cliproxyauth.SharedAuthState
+		if err = json.Unmarshal(payload, &state); err != nil {
+			return nil, revision, fmt.Errorf("postgres shared state: decode state: %w", err)
+		}
+		state.Revision = rowRevision
+		states = append(states, state)
+		if rowRevision > latest {
+			latest = rowRevision
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return nil, revision, fmt.Errorf("postgres shared state: iterate states: %w", err)
+	}
+	return states, latest, nil
+}
+
+// NextCursor implements cliproxyauth.SharedState.
+func (s *PostgresSharedState) NextCursor(ctx context.Context, key string) (int64, error) {
+	query := fmt.Sprintf(`
+		INSERT INTO %s (key, value) VALUES ($1, 1)
+		ON CONFLICT (key) DO UPDATE SET value = %s.value + 1
+		RETURNING value
+	`, s.cursors, s.cursors)
+	var value int64
+	if err := s.db.QueryRowContext(ctx, query, key).Scan(&value); err != nil {
+		return 0, fmt.Errorf("postgres shared state: advance cursor %s: %w", key, err)
+	}
+	return value, nil
+}
+
+// AcquireLease implements cliproxyauth.SharedState. Expiry is judged by the database clock so
+// instances with skewed clocks agree on it.
+func (s *PostgresSharedState) AcquireLease(ctx context.Context, key, owner string, ttl time.Duration) (bool, error) {
+	query := fmt.Sprintf(`
+		INSERT INTO %s (key, owner, expires_at) VALUES ($1, $2, NOW() + $3 * INTERVAL '1 millisecond')
+		ON CONFLICT (key) DO UPDATE SET owner = EXCLUDED.owner, expires_at = EXCLUDED.expires_at
+		WHERE %s.owner = EXCLUDED.owner OR %s.expires_at <= NOW()
+	`, s.leases, s.leases, s.leases)
+	result, err := s.db.ExecContext(ctx, query, key, owner, ttl.Milliseconds())
+	if err != nil {
+		return false, fmt.Errorf("postgres shared state: acquire lease %s: %w", key, err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("postgres shared state: acquire lease %s: %w", key, err)
+	}
+	return affected > 0, nil
+}
+
+// ReleaseLease implements cliproxyauth.SharedState.
+func (s *PostgresSharedState) ReleaseLease(ctx context.Context, key, owner string) error {
+	query := fmt.Sprintf("DELETE FROM %s WHERE key = $1 AND owner = $2", s.leases)
+	if _, err := s.db.ExecContext(ctx, query, key, owner); err != nil {
+		return fmt.Errorf("postgres shared state: release lease %s: %w", key, err)
+	}
+	return nil
+}
@@ -0,0 +1,261 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/usage"
+)
+
+const defaultUsageTable = "usage_records"
+
+// PostgresUsageStore persists usage records in PostgreSQL using the connection
+// and schema of an existing PostgresStore.
+type PostgresUsageStore struct {
+	db        *sql.DB
+	table     string
+	indexName string
+}
+
+// UsageStore returns a usage.Store backed by the same database connection and schema.
+// An empty table name selects the default "usage_records" table.
+func (s *PostgresStore) UsageStore(ctx context.Context, table string) (usage.Store, error) {
+	if s == nil || s.db == nil {
+		return nil, fmt.Errorf("postgres store: not initialized")
+	}
+	table = strings.TrimSpace(table)
+	if table == "" {
+		table = defaultUsageTable
+	}
+	store := &PostgresUsageStore{
+		db:        s.db,
+		table:     s.fullTableName(table),
+		indexName: quoteIdentifier(table + "_requested_at_idx"),
+	}
+	if err := store.ensureSchema(ctx); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+func (s *PostgresUsageStore) ensureSchema(ctx context.Context) error {
+	if _, err := s.db.ExecContext(ctx, fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %s (
+			id BIGSERIAL PRIMARY KEY,
+			requested_at TIMESTAMPTZ NOT NULL,
+			api_key TEXT NOT NULL DEFAULT '',
+			provider TEXT NOT NULL DEFAULT '',
+			model TEXT NOT NULL DEFAULT '',
+			auth_id TEXT NOT NULL DEFAULT '',
+			auth_index TEXT NOT NULL DEFAULT '',
+			source TEXT NOT NULL DEFAULT '',
+			failed BOOLEAN NOT NULL DEFAULT FALSE,
+			input_tokens BIGINT NOT NULL DEFAULT 0,
+			output_tokens BIGINT NOT NULL DEFAULT 0,
+			reasoning_tokens BIGINT NOT NULL DEFAULT 0,
+			cached_tokens BIGINT NOT NULL DEFAULT 0,
+			total_tokens BIGINT NOT NULL DEFAULT 0
+		)
+	`, s.table)); err != nil {
+		return fmt.Errorf("postgres usage store: create table: %w", err)
+	}
+	if _, err := s.db.ExecContext(ctx, fmt.Sprintf("ALTER TABLE %s ADD COLUMN IF NOT EXISTS requested_model TEXT NOT NULL DEFAULT ''", s.table)); err != nil {
+		return fmt.Errorf("postgres usage store: add requested_model column: %w", err)
+	}
+	if _, err := s.db.ExecContext(ctx, fmt.Sprintf("ALTER TABLE %s ADD COLUMN IF NOT EXISTS cache_write_tokens BIGINT NOT NULL DEFAULT 0", s.table)); err != nil {
+		return fmt.Errorf("postgres usage store: add cache_write_tokens column: %w", err)
+	}
+	if _, err := s.db.ExecContext(ctx, fmt.Sprintf("ALTER TABLE %s ADD COLUMN IF NOT EXISTS cost DOUBLE PRECISION NOT NULL DEFAULT 0", s.table)); err != nil {
+		return fmt.Errorf("postgres usage store: add cost column: %w", err)
+	}
+	if _, err := s.db.ExecContext(ctx, fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s ON %s (requested_at)", s.indexName, s.table)); err != nil {
+		return fmt.Errorf("postgres usage store: create index: %w", err)
+	}
+	return nil
+}
+
+// Append implements usage.Store.
+func (s *PostgresUsageStore) Append(ctx context.Context, record usage.StoredRecord) error {
+	if s == nil || s.db == nil {
+		return fmt.Errorf("postgres usage store: not initialized")
+	}
+	if record.Timestamp.IsZero() {
+		record.Timestamp = time.Now()
+	}
+	query := fmt.Sprintf(`
+		INSERT INTO %s (requested_at, api_key, provider, model, auth_id, auth_index, source, failed,
+			input_tokens, output_tokens, reasoning_tokens, cached_tokens, total_tokens, requested_model,
+			cache_write_tokens, cost)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
+	`, s.table)
+	tokens := record.Tokens
+	if _, err := s.db.ExecContext(ctx, query,
+		record.Timestamp.UTC(), record.APIKey, record.Provider, record.Model, record.AuthID, record.AuthIndex, record.Source, record.Failed,
+		tokens.InputTokens, tokens.OutputTokens, tokens.ReasoningTokens, tokens.CachedTokens, tokens.TotalTokens, record.RequestedModel,
+		tokens.CacheWriteTokens, record.Cost,
+	); err != nil {
+		return fmt.Errorf("postgres usage store: insert record: %w", err)
+	}
+	return nil
+}
+
+// Query implements usage.Store.
+func (s *PostgresUsageStore) Query(ctx context.Context, filter usage.QueryFilter) ([]usage.StoredRecord, error) {
+	if s == nil || s.db == nil {
+		return nil, fmt.Errorf("postgres usage store: not initialized")
+	}
+	where, args := usageWhereClause(filter)
+	query := fmt.Sprintf(`
+		SELECT requested_at, api_key, provider, model, auth_id, auth_index, source, failed,
+			input_tokens, output_tokens, reasoning_tokens, cached_tokens, total_tokens, requested_model,
+			cache_write_tokens, cost
+		FROM %s%s
+		ORDER BY requested_at, id
+	`, s.table, where)
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("postgres usage store: query records: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	out := make([]usage.StoredRecord, 0)
+	for rows.Next() {
+		var record usage.StoredRecord
+		if err = rows.Scan(
+			&record.Timestamp, &record.APIKey, &record.Provider, &record.Model, &record.AuthID, &record.AuthIndex, &record.Source, &record.Failed,
+			&record.Tokens.InputTokens, &record.Tokens.OutputTokens, &record.Tokens.ReasoningTokens, &record.Tokens.CachedTokens, &record.Tokens.TotalTokens, &record.RequestedModel,
+			&record.Tokens.CacheWriteTokens, &record.Cost,
+		); err != nil {
+			return nil, fmt.Errorf("postgres usage store: scan record: %w", err)
+		}
+		out = append(out, record)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("postgres usage store: iterate records: %w", err)
+	}
+	return out, nil
+}
+
+// Aggregate implements usage.Aggregator so grouping happens inside PostgreSQL.
+func (s *PostgresUsageStore) Aggregate(ctx context.Context, query usage.AggregateQuery) ([]usage.AggregateBucket, error) {
+	if s == nil || s.db == nil {
+		return nil, fmt.Errorf("postgres usage store: not initialized")
+	}
+	groupBy := usage.NormalizeGroupBy(query.GroupBy)
+	selects := make([]string, 0, len(groupBy))
+	for _, dimension := range groupBy {
+		selects = append(selects, usageGroupExpression(dimension))
+	}
+	where, args := usageWhereClause(query.QueryFilter)
+	keyColumns := ""
+	groupClause := ""
+	if len(selects) > 0 {
+		keyColumns = strings.Join(selects, ", ") + ", "
+		positions := make([]string, len(selects))
+		for i := range selects {
+			positions[i] = fmt.Sprintf("%d", i+1)
+		}
+		groupClause = " GROUP BY " + strings.Join(positions, ", ") + " ORDER BY " + strings.Join(positions, ", ")
+	}
+	statement := fmt.Sprintf(`
+		SELECT %sCOUNT(*), COUNT(*) FILTER (WHERE NOT failed), COUNT(*) FILTER (WHERE failed),
+			COALESCE(SUM(input_tokens), 0), COALESCE(SUM(output_tokens), 0), COALESCE(SUM(reasoning_tokens), 0),
+			COALESCE(SUM(cached_tokens), 0), COALESCE(SUM(total_tokens), 0),
+			COALESCE(SUM(cache_write_tokens), 0), COALESCE(SUM(cost), 0)
+		FROM %s%s%s
+	`, keyColumns, s.table, where, groupClause)
+	rows, err := s.db.QueryContext(ctx, statement, args...)
+	if err != nil {
+		return nil, fmt.Errorf("postgres usage store: aggregate records: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	out := make([]usage.AggregateBucket, 0)
+	for rows.Next() {
+		keys := make([]string, len(groupBy))
+		var bucket usage.AggregateBucket
+		dest := make([]any, 0, len(groupBy)+10)
+		for i := range keys {
+			dest = append(dest, &keys[i])
+		}
+		dest = append(dest,
+			&bucket.TotalRequests, &bucket.SuccessCount, &bucket.FailureCount,
+			&bucket.Tokens.InputTokens, &bucket.Tokens.OutputTokens, &bucket.Tokens.ReasoningTokens,
+			&bucket.Tokens.CachedTokens, &bucket.Tokens.TotalTokens,
+			&bucket.Tokens.CacheWriteTokens, &bucket.Cost,
+		)
+		if err = rows.Scan(dest...); err != nil {
+			return nil, fmt.Errorf("postgres usage store: scan aggregate: %w", err)
+		}
+		if bucket.TotalRequests == 0 {
+			continue
+		}
+		bucket.Keys = make(map[string]string, len(groupBy))
+		for i, dimension := range groupBy {
+			bucket.Keys[dimension] = keys[i]
+		}
+		out = append(out, bucket)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("postgres usage store: iterate aggregate: %w", err)
+	}
+	return out, nil
+}
+
+// Close implements usage.Store. The connection is owned by PostgresStore and stays open.
+func (s *PostgresUsageStore) Close() error { return nil }
+
+func usageGroupExpression(dimension string) string {
+	switch dimension {
+	case usage.GroupByMonth:
+		return "to_char(requested_at AT TIME ZONE 'UTC', 'YYYY-MM')"
+	case usage.GroupByDay:
+		return "to_char(requested_at AT TIME ZONE 'UTC', 'YYYY-MM-DD')"
+	case usage.GroupByHour:
+		return `to_char(requested_at AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24')`
+	case usage.GroupByAPIKey:
+		return "api_key"
+	case usage.GroupByModel:
+		return "model"
+	case usage.GroupByAuthIndex:
+		return "auth_index"
+	case usage.GroupByProvider:
+		return "provider"
+	default:
+		return "''"
+	}
+}
+
+func usageWhereClause(filter usage.QueryFilter) (string, []any) {
+	conditions := make([]string, 0, 6)
+	args := make([]any, 0, 6)
+	add := func(condition string, value any) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+	if !filter.From.IsZero() {
+		add("requested_at >= $%d", filter.From.UTC())
+	}
+	if !filter.To.IsZero() {
+		add("requested_at < $%d", filter.To.UTC())
+	}
+	if filter.APIKey != "" {
+		add("api_key = $%d", filter.APIKey)
+	}
+	if filter.Model != "" {
+		add("model = $%d", filter.Model)
+	}
+	if filter.AuthIndex != "" {
+		add("auth_index = $%d", filter.AuthIndex)
+	}
+	if filter.Provider != "" {
+		add("provider = $%d", filter.Provider)
+	}
+	if len(conditions) == 0 {
+		return "", nil
+	}
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
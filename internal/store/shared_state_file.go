@@ -0,0 +1,157 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/filelock"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+const sharedStateFileName = "shared-state.json"
+
+// FileSharedState implements cliproxyauth.SharedState with a JSON file guarded by a lock file,
+// for instances that share a host or a volume.
+type FileSharedState struct {
+	path string
+	lock *filelock.Lock
+}
+
+type fileSharedStateData struct {
+	Revision int64                                   `json:"revision"`
+	Auths    map[string]cliproxyauth.SharedAuthState `json:"auths,omitempty"`
+	Cursors  map[string]int64                        `json:"cursors,omitempty"`
+	Leases   map[string]fileSharedLease              `json:"leases,omitempty"`
+}
+
+type fileSharedLease struct {
+	Owner     string    `json:"owner"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// NewFileSharedState keeps shared state in dir, creating it when missing.
+func NewFileSharedState(dir string) (*FileSharedState, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("file shared state: directory is empty")
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("file shared state: create directory: %w", err)
+	}
+	path := filepath.Join(dir, sharedStateFileName)
+	return &FileSharedState{path: path, lock: filelock.New(path + ".lock")}, nil
+}
+
+// PublishAuthState implements cliproxyauth.SharedState.
+func (s *FileSharedState) PublishAuthState(ctx context.Context, state cliproxyauth.SharedAuthState) error {
+	return s.update(ctx, func(data *fileSharedStateData) {
+		data.Revision++
+		state.Revision = data.Revision
+		if data.Auths == nil {
+			data.Auths = make(map[string]cliproxyauth.SharedAuthState)
+		}
+		data.Auths[state.AuthID] = state
+	})
+}
+
+// AuthStatesSince implements cliproxyauth.SharedState.
+func (s *FileSharedState) AuthStatesSince(_ context.Context, revision int64) ([]cliproxyauth.SharedAuthState, int64, error) {
+	data, err := s.read()
+	if err != nil {
+		return nil, revision, err
+	}
+	var states []cliproxyauth.SharedAuthState
+	for _, state := range data.Auths {
+		if state.Revision > revision {
+			states = append(states, state)
+		}
+	}
+	return states, data.Revision, nil
+}
+
+// NextCursor implements cliproxyauth.SharedState.
+func (s *FileSharedState) NextCursor(ctx context.Context, key string) (int64, error) {
+	var value int64
+	err := s.update(ctx, func(data *fileSharedStateData) {
+		if data.Cursors == nil {
+			data.Cursors = make(map[string]int64)
+		}
+		data.Cursors[key]++
+		value = data.Cursors[key]
+	})
+	return value, err
+}
+
+// AcquireLease implements cliproxyauth.SharedState.
+func (s *FileSharedState) AcquireLease(ctx context.Context, key, owner string, ttl time.Duration) (bool, error) {
+	acquired := false
+	err := s.update(ctx, func(data *fileSharedStateData) {
+		now := time.Now()
+		if lease, ok := data.Leases[key]; ok && lease.Owner != owner && now.Before(lease.ExpiresAt) {
+			return
+		}
+		if data.Leases == nil {
+			data.Leases = make(map[string]fileSharedLease)
+		}
+		data.Leases[key] = fileSharedLease{Owner: owner, ExpiresAt: now.Add(ttl)}
+		acquired = true
+	})
+	return acquired, err
+}
+
+// ReleaseLease implements cliproxyauth.SharedState.
+func (s *FileSharedState) ReleaseLease(ctx context.Context, key, owner string) error {
+	return s.update(ctx, func(data *fileSharedStateData) {
+		if lease, ok := data.Leases[key]; ok && lease.Owner == owner {
+			delete(data.Leases, key)
+		}
+	})
+}
+
+func (s *FileSharedState) read() (*fileSharedStateData, error) {
+	data := &fileSharedStateData{}
+	raw, err := os.ReadFile(s.path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return data, nil
+		}
+		return nil, fmt.Errorf("file shared state: read: %w", err)
+	}
+	if len(raw) == 0 {
+		return data, nil
+	}
+	if err = json.Unmarshal(raw, data); err != nil {
+		return nil, fmt.Errorf("file shared state: decode: %w", err)
+	}
+	return data, nil
+}
+
+// update applies fn to the state under the lock file and writes the result atomically.
+func (s *FileSharedState) update(ctx context.Context, fn func(*fileSharedStateData)) error {
+	if err := s.lock.Lock(ctx); err != nil {
+		return err
+	}
+	defer s.lock.Unlock()
+	data, err := s.read()
+	if err != nil {
+		return err
+	}
+	fn(data)
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("file shared state: encode: %w", err)
+	}
+	tmp := s.path + ".tmp"
+	if err = os.WriteFile(tmp, raw, 0o600); err != nil {
+		return fmt.Errorf("file shared state: write: %w", err)
+	}
+	if err = os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("file shared state: rename: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+// Package structuredoutput maps OpenAI json_schema response formats onto each upstream's
+// native mechanism and validates the generated output against the original schema.
+package structuredoutput
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tidwall/gjson"
+)
+
+// ToolName is the synthetic tool used to force a schema-shaped answer from Claude.
+const ToolName = "structured_output"
+
+// Format is a json_schema response format taken from an OpenAI Chat Completions request.
+type Format struct {
+	Name   string
+	Schema []byte
+	Strict bool
+}
+
+// FromOpenAIChat extracts the json_schema response format of a Chat Completions request.
+// It reports false for text and json_object formats and for requests without a schema.
+func FromOpenAIChat(rawJSON []byte) (Format, bool) {
+	rf := gjson.GetBytes(rawJSON, "response_format")
+	if rf.Get("type").String() != "json_schema" {
+		return Format{}, false
+	}
+	schema := rf.Get("json_schema.schema")
+	if !schema.Exists() || !schema.IsObject() {
+		return Format{}, false
+	}
+	name := rf.Get("json_schema.name").String()
+	if name == "" {
+		name = "response"
+	}
+	return Format{Name: name, Schema: []byte(schema.Raw), Strict: rf.Get("json_schema.strict").Bool()}, true
+}
+
+// Instructions returns a system prompt hint for upstreams that cannot constrain output natively.
+func (f Format) Instructions() string {
+	return fmt.Sprintf("[INSTRUCTION: You MUST respond with a single JSON value named %q that matches this JSON Schema: %s. Do not include any text before or after the JSON. Do not wrap the JSON in markdown code blocks. Output raw JSON directly.]", f.Name, strings.TrimSpace(string(f.Schema)))
+}
+
+// ClaudeForcesTool reports whether a Chat Completions request routed to Claude can carry its
+// json_schema as a forced tool call. Requests with their own tools keep them usable and fall
+// back to prompt instructions instead.
+func ClaudeForcesTool(rawJSON []byte) bool {
+	if _, ok := FromOpenAIChat(rawJSON); !ok {
+		return false
+	}
+	tools := gjson.GetBytes(rawJSON, "tools")
+	return !tools.IsArray() || len(tools.Array()) == 0
+}
+
+// Enforcement describes how a backend applies a json_schema response format.
+type Enforcement string
+
+const (
+	// EnforcementNative means the upstream constrains decoding to the schema itself.
+	EnforcementNative Enforcement = "native"
+	// EnforcementTool means the schema is sent as a forced tool whose input becomes the answer.
+	EnforcementTool Enforcement = "tool"
+	// EnforcementPrompt means the schema is only described in the prompt.
+	EnforcementPrompt Enforcement = "prompt"
+	// EnforcementNone means the schema cannot be forwarded at all.
+	EnforcementNone Enforcement = "none"
+)
+
+// Strict reports whether the enforcement guarantees schema-shaped output.
+func (e Enforcement) Strict() bool {
+	return e == EnforcementNative || e == EnforcementTool
+}
+
+// EnforcementFor reports how the given provider applies the json_schema of a Chat Completions request.
+func EnforcementFor(provider string, rawJSON []byte) Enforcement {
+	switch strings.ToLower(provider) {
+	case "gemini", "gemini-cli", "vertex", "antigravity", "codex":
+		return EnforcementNative
+	case "aistudio":
+		return EnforcementNone
+	case "claude":
+		if ClaudeForcesTool(rawJSON) {
+			return EnforcementTool
+		}
+		return EnforcementPrompt
+	case "kiro":
+		return EnforcementPrompt
+	default:
+		// OpenAI-compatible upstreams receive response_format unchanged.
+		return EnforcementNative
+	}
+}
@@ -0,0 +1,88 @@
+package structuredoutput
+
+import (
+	"strings"
+	"testing"
+)
+
+const personSchema = `{
+	"type":"object",
+	"properties":{
+		"name":{"type":"string","minLength":1},
+		"age":{"type":"integer","minimum":0},
+		"tags":{"type":"array","items":{"$ref":"#/$defs/tag"},"maxItems":2},
+		"role":{"anyOf":[{"enum":["admin","user"]},{"type":"null"}]}
+	},
+	"required":["name","age"],
+	"additionalProperties":false,
+	"$defs":{"tag":{"type":"string","pattern":"^[a-z]+$"}}
+}`
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		document string
+		want     []string
+	}{
+		{name: "valid", document: `{"name":"Ada","age":36,"tags":["math"],"role":null}`},
+		{name: "not json", document: `Sure! {"name":"Ada"}`, want: []string{"output is not valid JSON"}},
+		{name: "missing required", document: `{"name":"Ada"}`, want: []string{`$: missing required property "age"`}},
+		{name: "wrong type", document: `{"name":"Ada","age":36.5}`, want: []string{"$.age: expected integer, got number"}},
+		{name: "extra property", document: `{"name":"Ada","age":1,"email":"a@b"}`, want: []string{`$: unexpected property "email"`}},
+		{name: "ref pattern", document: `{"name":"Ada","age":1,"tags":["Math"]}`, want: []string{`$.tags[0]: string does not match pattern`}},
+		{name: "max items", document: `{"name":"Ada","age":1,"tags":["a","b","c"]}`, want: []string{"$.tags: array has 3 items"}},
+		{name: "anyOf", document: `{"name":"Ada","age":1,"role":"root"}`, want: []string{"$.role: value does not match any allowed schema"}},
+		{name: "min length", document: `{"name":"","age":-1}`, want: []string{"$.name: string is shorter", "$.age: -1 is less than the minimum 0"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := Validate([]byte(personSchema), []byte(tt.document))
+			if len(errs) != len(tt.want) {
+				t.Fatalf("Validate() = %q, want %d errors", errs, len(tt.want))
+			}
+			for _, want := range tt.want {
+				found := false
+				for _, err := range errs {
+					if strings.Contains(err, want) {
+						found = true
+					}
+				}
+				if !found {
+					t.Fatalf("Validate() = %q, want an error containing %q", errs, want)
+				}
+			}
+		})
+	}
+}
+
+func TestEnforcementFor(t *testing.T) {
+	schemaOnly := []byte(`{"response_format":{"type":"json_schema","json_schema":{"name":"p","strict":true,"schema":{"type":"object"}}}}`)
+	withTools := []byte(`{"tools":[{"type":"function","function":{"name":"lookup"}}],"response_format":{"type":"json_schema","json_schema":{"schema":{"type":"object"}}}}`)
+
+	format, ok := FromOpenAIChat(schemaOnly)
+	if !ok || format.Name != "p" || !format.Strict || string(format.Schema) != `{"type":"object"}` {
+		t.Fatalf("FromOpenAIChat() = %+v, %v", format, ok)
+	}
+	if _, ok = FromOpenAIChat([]byte(`{"response_format":{"type":"json_object"}}`)); ok {
+		t.Fatal("json_object must not be treated as a json_schema format")
+	}
+
+	tests := []struct {
+		provider string
+		raw      []byte
+		want     Enforcement
+	}{
+		{provider: "gemini", raw: schemaOnly, want: EnforcementNative},
+		{provider: "codex", raw: schemaOnly, want: EnforcementNative},
+		{provider: "openai-compatibility", raw: schemaOnly, want: EnforcementNative},
+		{provider: "claude", raw: schemaOnly, want: EnforcementTool},
+		{provider: "claude", raw: withTools, want: EnforcementPrompt},
+		{provider: "kiro", raw: schemaOnly, want: EnforcementPrompt},
+		{provider: "aistudio", raw: schemaOnly, want: EnforcementNone},
+	}
+	for _, tt := range tests {
+		if got := EnforcementFor(tt.provider, tt.raw); got != tt.want {
+			t.Errorf("EnforcementFor(%q) = %q, want %q", tt.provider, got, tt.want)
+		}
+	}
+}
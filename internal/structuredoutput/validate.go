@@ -0,0 +1,312 @@
+package structuredoutput
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxRefDepth bounds $ref resolution so recursive schemas cannot loop forever.
+const maxRefDepth = 32
+
+// maxErrors caps the number of reported violations.
+const maxErrors = 20
+
+// Validate checks document against a JSON Schema and returns the violations found.
+// It covers the keywords used by OpenAI structured outputs: type, enum, const, properties,
+// required, additionalProperties, items, length and range bounds, pattern, the anyOf/oneOf/
+// allOf/not combinators and local $ref pointers. Unknown keywords are ignored.
+func Validate(schema, document []byte) []string {
+	var root any
+	if err := json.Unmarshal(schema, &root); err != nil {
+		return []string{fmt.Sprintf("invalid schema: %v", err)}
+	}
+	var value any
+	if err := json.Unmarshal([]byte(strings.TrimSpace(string(document))), &value); err != nil {
+		return []string{fmt.Sprintf("output is not valid JSON: %v", err)}
+	}
+	v := &validator{root: root}
+	v.validate(root, value, "$", 0)
+	return v.errs
+}
+
+type validator struct {
+	root any
+	errs []string
+}
+
+func (v *validator) fail(path, format string, args ...any) {
+	if len(v.errs) < maxErrors {
+		v.errs = append(v.errs, path+": "+fmt.Sprintf(format, args...))
+	}
+}
+
+func (v *validator) validate(schemaNode, value any, path string, depth int) {
+	switch s := schemaNode.(type) {
+	case bool:
+		if !s {
+			v.fail(path, "no value is allowed here")
+		}
+		return
+	case map[string]any:
+		v.validateObjectSchema(s, value, path, depth)
+	}
+}
+
+func (v *validator) validateObjectSchema(s map[string]any, value any, path string, depth int) {
+	if ref, ok := s["$ref"].(string); ok {
+		if depth >= maxRefDepth {
+			v.fail(path, "schema reference %q nests too deeply", ref)
+			return
+		}
+		target, ok := v.resolve(ref)
+		if !ok {
+			v.fail(path, "unresolvable schema reference %q", ref)
+			return
+		}
+		v.validate(target, value, path, depth+1)
+	}
+
+	if types, ok := s["type"]; ok && !matchesType(types, value) {
+		v.fail(path, "expected %s, got %s", describeTypes(types), typeOf(value))
+		return
+	}
+	if enum, ok := s["enum"].([]any); ok {
+		found := false
+		for _, candidate := range enum {
+			if reflect.DeepEqual(candidate, value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			v.fail(path, "value is not one of the allowed enum values")
+		}
+	}
+	if constant, ok := s["const"]; ok && !reflect.DeepEqual(constant, value) {
+		v.fail(path, "value does not match the required constant")
+	}
+
+	switch typed := value.(type) {
+	case map[string]any:
+		v.validateObject(s, typed, path, depth)
+	case []any:
+		v.validateArray(s, typed, path, depth)
+	case string:
+		v.validateString(s, typed, path)
+	case float64:
+		v.validateNumber(s, typed, path)
+	}
+
+	if all, ok := s["allOf"].([]any); ok {
+		for _, sub := range all {
+			v.validate(sub, value, path, depth+1)
+		}
+	}
+	if anyOf, ok := s["anyOf"].([]any); ok && v.countMatches(anyOf, value, depth) == 0 {
+		v.fail(path, "value does not match any allowed schema")
+	}
+	if oneOf, ok := s["oneOf"].([]any); ok {
+		if n := v.countMatches(oneOf, value, depth); n != 1 {
+			v.fail(path, "value matches %d schemas, want exactly one", n)
+		}
+	}
+	if not, ok := s["not"]; ok && v.matches(not, value, depth) {
+		v.fail(path, "value matches a disallowed schema")
+	}
+}
+
+func (v *validator) validateObject(s map[string]any, obj map[string]any, path string, depth int) {
+	properties, _ := s["properties"].(map[string]any)
+	if required, ok := s["required"].([]any); ok {
+		for _, name := range required {
+			key, _ := name.(string)
+			if _, present := obj[key]; !present {
+				v.fail(path, "missing required property %q", key)
+			}
+		}
+	}
+	for key, item := range obj {
+		childPath := path + "." + key
+		if sub, ok := properties[key]; ok {
+			v.validate(sub, item, childPath, depth+1)
+			continue
+		}
+		switch additional := s["additionalProperties"].(type) {
+		case bool:
+			if !additional {
+				v.fail(path, "unexpected property %q", key)
+			}
+		case map[string]any:
+			v.validate(additional, item, childPath, depth+1)
+		}
+	}
+}
+
+func (v *validator) validateArray(s map[string]any, arr []any, path string, depth int) {
+	if minItems, ok := number(s["minItems"]); ok && float64(len(arr)) < minItems {
+		v.fail(path, "array has %d items, want at least %v", len(arr), minItems)
+	}
+	if maxItems, ok := number(s["maxItems"]); ok && float64(len(arr)) > maxItems {
+		v.fail(path, "array has %d items, want at most %v", len(arr), maxItems)
+	}
+	items, ok := s["items"]
+	if !ok {
+		return
+	}
+	for i, item := range arr {
+		v.validate(items, item, fmt.Sprintf("%s[%d]", path, i), depth+1)
+	}
+}
+
+func (v *validator) validateString(s map[string]any, str string, path string) {
+	length := float64(utf8.RuneCountInString(str))
+	if minLength, ok := number(s["minLength"]); ok && length < minLength {
+		v.fail(path, "string is shorter than %v characters", minLength)
+	}
+	if maxLength, ok := number(s["maxLength"]); ok && length > maxLength {
+		v.fail(path, "string is longer than %v characters", maxLength)
+	}
+	if pattern, ok := s["pattern"].(string); ok {
+		re, err := regexp.Compile(pattern)
+		if err == nil && !re.MatchString(str) {
+			v.fail(path, "string does not match pattern %q", pattern)
+		}
+	}
+}
+
+func (v *validator) validateNumber(s map[string]any, n float64, path string) {
+	if minimum, ok := number(s["minimum"]); ok && n < minimum {
+		v.fail(path, "%v is less than the minimum %v", n, minimum)
+	}
+	if maximum, ok := number(s["maximum"]); ok && n > maximum {
+		v.fail(path, "%v is greater than the maximum %v", n, maximum)
+	}
+	if minimum, ok := number(s["exclusiveMinimum"]); ok && n <= minimum {
+		v.fail(path, "%v must be greater than %v", n, minimum)
+	}
+	if maximum, ok := number(s["exclusiveMaximum"]); ok && n >= maximum {
+		v.fail(path, "%v must be less than %v", n, maximum)
+	}
+}
+
+// matches validates value in isolation so combinator branches do not leak their errors.
+func (v *validator) matches(schemaNode, value any, depth int) bool {
+	sub := &validator{root: v.root}
+	sub.validate(schemaNode, value, "$", depth+1)
+	return len(sub.errs) == 0
+}
+
+func (v *validator) countMatches(schemas []any, value any, depth int) int {
+	count := 0
+	for _, sub := range schemas {
+		if v.matches(sub, value, depth) {
+			count++
+		}
+	}
+	return count
+}
+
+// resolve follows a local JSON pointer reference such as "#/$defs/item".
+func (v *validator) resolve(ref string) (any, bool) {
+	if ref == "#" {
+		return v.root, true
+	}
+	if !strings.HasPrefix(ref, "#/") {
+		return nil, false
+	}
+	node := v.root
+	for _, token := range strings.Split(ref[2:], "/") {
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+		obj, ok := node.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		if node, ok = obj[token]; !ok {
+			return nil, false
+		}
+	}
+	return node, true
+}
+
+func matchesType(types, value any) bool {
+	switch t := types.(type) {
+	case string:
+		return isType(t, value)
+	case []any:
+		for _, item := range t {
+			if name, ok := item.(string); ok && isType(name, value) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+func isType(name string, value any) bool {
+	switch name {
+	case "object":
+		_, ok := value.(map[string]any)
+		return ok
+	case "array":
+		_, ok := value.([]any)
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	case "integer":
+		n, ok := value.(float64)
+		return ok && n == math.Trunc(n)
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "null":
+		return value == nil
+	}
+	return true
+}
+
+func describeTypes(types any) string {
+	if list, ok := types.([]any); ok {
+		names := make([]string, 0, len(list))
+		for _, item := range list {
+			names = append(names, fmt.Sprint(item))
+		}
+		return strings.Join(names, " or ")
+	}
+	return fmt.Sprint(types)
+}
+
+func typeOf(value any) string {
+	switch typed := value.(type) {
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	case string:
+		return "string"
+	case float64:
+		if typed == math.Trunc(typed) {
+			return "integer"
+		}
+		return "number"
+	case bool:
+		return "boolean"
+	case nil:
+		return "null"
+	}
+	return "unknown"
+}
+
+func number(value any) (float64, bool) {
+	n, ok := value.(float64)
+	return n, ok
+}
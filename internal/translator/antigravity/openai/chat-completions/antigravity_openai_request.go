@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/misc"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/structuredoutput"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/translator/gemini/common"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
 	log "github.com/sirupsen/logrus"
@@ -105,6 +106,17 @@ func ConvertOpenAIRequestToAntigravity(modelName string, inputRawJSON []byte, _
 		}
 	}
 
+	// Map OpenAI response_format -> request.generationConfig.responseMimeType/responseJsonSchema
+	switch gjson.GetBytes(rawJSON, "response_format.type").String() {
+	case "json_object":
+		out, _ = sjson.SetBytes(out, "request.generationConfig.responseMimeType", "application/json")
+	case "json_schema":
+		out, _ = sjson.SetBytes(out, "request.generationConfig.responseMimeType", "application/json")
+		if format, ok := structuredoutput.FromOpenAIChat(rawJSON); ok {
+			out, _ = sjson.SetRawBytes(out, "request.generationConfig.responseJsonSchema", []byte(util.CleanJSONSchemaForGemini(string(format.Schema))))
+		}
+	}
+
 	// messages -> systemInstruction + contents
 	messages := gjson.GetBytes(rawJSON, "messages")
 	if messages.IsArray() {
@@ -201,16 +213,12 @@ func ConvertOpenAIRequestToAntigravity(modelName string, inputRawJSON []byte, _
 						case "file":
 							filename := item.Get("file.filename").String()
 							fileData := item.Get("file.file_data").String()
-							ext := ""
-							if sp := strings.Split(filename, "."); len(sp) > 1 {
-								ext = sp[len(sp)-1]
-							}
-							if mimeType, ok := misc.MimeTypes[ext]; ok {
+							if mimeType, data, ok := misc.FileInlineData(filename, fileData); ok {
 								node, _ = sjson.SetBytes(node, "parts."+itoa(p)+".inlineData.mimeType", mimeType)
-								node, _ = sjson.SetBytes(node, "parts."+itoa(p)+".inlineData.data", fileData)
+								node, _ = sjson.SetBytes(node, "parts."+itoa(p)+".inlineData.data", data)
 								p++
 							} else {
-								log.Warnf("Unknown file name extension '%s' in user message, skip", ext)
+								log.Warnf("Unknown file type for '%s' in user message, skip", filename)
 							}
 						case "input_audio":
 							audioData := item.Get("input_audio.data").String()
@@ -0,0 +1,21 @@
+package images
+
+import (
+	"context"
+
+	. "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/antigravity/gemini"
+	. "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/gemini/openai/images"
+	"github.com/tidwall/gjson"
+)
+
+func ConvertOpenAIImageRequestToAntigravity(modelName string, inputRawJSON []byte, stream bool) []byte {
+	rawJSON := ConvertOpenAIImageRequestToGemini(modelName, inputRawJSON, stream)
+	return ConvertGeminiRequestToAntigravity(modelName, rawJSON, stream)
+}
+
+func ConvertAntigravityResponseToOpenAIImages(ctx context.Context, modelName string, originalRequestRawJSON, requestRawJSON, rawJSON []byte, param *any) string {
+	if responseResult := gjson.GetBytes(rawJSON, "response"); responseResult.Exists() {
+		rawJSON = []byte(responseResult.Raw)
+	}
+	return ConvertGeminiResponseToOpenAIImages(ctx, modelName, originalRequestRawJSON, requestRawJSON, rawJSON, param)
+}
@@ -15,6 +15,7 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/structuredoutput"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
 	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
@@ -324,9 +325,37 @@ func ConvertOpenAIRequestToClaude(modelName string, inputRawJSON []byte, stream
 		}
 	}
 
+	// json_schema response format: force a synthetic tool whose input is the answer, or describe
+	// the schema in the prompt when the client brought its own tools.
+	if format, ok := structuredoutput.FromOpenAIChat(rawJSON); ok {
+		if structuredoutput.ClaudeForcesTool(rawJSON) {
+			tool := `{"name":"","description":"Respond with the final answer as the input of this tool."}`
+			tool, _ = sjson.Set(tool, "name", structuredoutput.ToolName)
+			tool, _ = sjson.SetRaw(tool, "input_schema", string(format.Schema))
+			out, _ = sjson.SetRaw(out, "tools", "["+tool+"]")
+			out, _ = sjson.Set(out, "tool_choice", map[string]string{"type": "tool", "name": structuredoutput.ToolName})
+		} else {
+			out = appendClaudeUserText(out, format.Instructions())
+		}
+	}
+
 	return []byte(out)
 }
 
+// appendClaudeUserText adds a text block to the last user message, or a new user message when
+// the conversation does not end with one.
+func appendClaudeUserText(out, text string) string {
+	textPart := `{"type":"text","text":""}`
+	textPart, _ = sjson.Set(textPart, "text", text)
+	messages := gjson.Get(out, "messages").Array()
+	if n := len(messages); n > 0 && messages[n-1].Get("role").String() == "user" && messages[n-1].Get("content").IsArray() {
+		out, _ = sjson.SetRaw(out, fmt.Sprintf("messages.%d.content.-1", n-1), textPart)
+		return out
+	}
+	out, _ = sjson.SetRaw(out, "messages.-1", `{"role":"user","content":[`+textPart+`]}`)
+	return out
+}
+
 func convertOpenAIContentPartToClaudePart(part gjson.Result) string {
 	switch part.Get("type").String() {
 	case "text":
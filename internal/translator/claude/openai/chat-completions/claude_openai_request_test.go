@@ -1,6 +1,7 @@
 package chat_completions
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/tidwall/gjson"
@@ -135,3 +136,39 @@ func TestConvertOpenAIRequestToClaude_ToolResultURLImageOnly(t *testing.T) {
 		t.Fatalf("Unexpected image URL: %q", got)
 	}
 }
+
+func TestConvertOpenAIRequestToClaude_JSONSchemaForcesTool(t *testing.T) {
+	inputJSON := `{
+		"model": "gpt-4.1",
+		"messages": [{"role": "user", "content": "Who wrote Dune?"}],
+		"response_format": {"type": "json_schema", "json_schema": {"name": "book", "strict": true, "schema": {"type": "object", "properties": {"author": {"type": "string"}}, "required": ["author"]}}}
+	}`
+
+	result := gjson.ParseBytes(ConvertOpenAIRequestToClaude("claude-sonnet-4-5", []byte(inputJSON), false))
+	if got := result.Get("tools.#").Int(); got != 1 {
+		t.Fatalf("Expected 1 tool, got %d: %s", got, result.Get("tools").Raw)
+	}
+	if got := result.Get("tools.0.input_schema.required.0").String(); got != "author" {
+		t.Fatalf("Expected the schema as tool input_schema, got %s", result.Get("tools.0").Raw)
+	}
+	if result.Get("tool_choice.type").String() != "tool" || result.Get("tool_choice.name").String() != result.Get("tools.0.name").String() {
+		t.Fatalf("Expected tool_choice to force the schema tool, got %s", result.Get("tool_choice").Raw)
+	}
+}
+
+func TestConvertOpenAIRequestToClaude_JSONSchemaWithToolsUsesPrompt(t *testing.T) {
+	inputJSON := `{
+		"model": "gpt-4.1",
+		"messages": [{"role": "user", "content": "Who wrote Dune?"}],
+		"tools": [{"type": "function", "function": {"name": "search", "parameters": {"type": "object"}}}],
+		"response_format": {"type": "json_schema", "json_schema": {"name": "book", "schema": {"type": "object"}}}
+	}`
+
+	result := gjson.ParseBytes(ConvertOpenAIRequestToClaude("claude-sonnet-4-5", []byte(inputJSON), false))
+	if got := result.Get("tools.#").Int(); got != 1 || result.Get("tool_choice").Exists() {
+		t.Fatalf("Expected only the client tool without tool_choice, got tools=%s tool_choice=%s", result.Get("tools").Raw, result.Get("tool_choice").Raw)
+	}
+	if got := result.Get("messages.0.content.1.text").String(); !strings.Contains(got, `"book"`) {
+		t.Fatalf("Expected schema instructions appended to the user message, got %s", result.Get("messages").Raw)
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/structuredoutput"
 	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
 )
@@ -25,6 +26,8 @@ type ConvertAnthropicResponseToOpenAIParams struct {
 	CreatedAt    int64
 	ResponseID   string
 	FinishReason string
+	// StructuredOutput reports that the forced structured_output tool carries the answer.
+	StructuredOutput bool
 	// Tool calls accumulator for streaming
 	ToolCallsAccumulator map[int]*ToolCallAccumulator
 }
@@ -56,9 +59,10 @@ func ConvertClaudeResponseToOpenAI(_ context.Context, modelName string, original
 	}
 	if *param == nil {
 		*param = &ConvertAnthropicResponseToOpenAIParams{
-			CreatedAt:    0,
-			ResponseID:   "",
-			FinishReason: "",
+			CreatedAt:        0,
+			ResponseID:       "",
+			FinishReason:     "",
+			StructuredOutput: structuredoutput.ClaudeForcesTool(originalRequestRawJSON),
 		}
 	}
 
@@ -112,6 +116,10 @@ func ConvertClaudeResponseToOpenAI(_ context.Context, modelName string, original
 		if contentBlock := root.Get("content_block"); contentBlock.Exists() {
 			blockType := contentBlock.Get("type").String()
 
+			if blockType == "tool_use" && (*param).(*ConvertAnthropicResponseToOpenAIParams).StructuredOutput {
+				// The forced structured_output tool input is streamed as message content
+				return []string{}
+			}
 			if blockType == "tool_use" {
 				// Start of tool call - initialize accumulator to track arguments
 				toolCallID := contentBlock.Get("id").String()
@@ -155,6 +163,13 @@ func ConvertClaudeResponseToOpenAI(_ context.Context, modelName string, original
 			case "input_json_delta":
 				// Tool use input delta - accumulate arguments for tool calls
 				if partialJSON := delta.Get("partial_json"); partialJSON.Exists() {
+					if (*param).(*ConvertAnthropicResponseToOpenAIParams).StructuredOutput {
+						if partialJSON.String() == "" {
+							return []string{}
+						}
+						template, _ = sjson.Set(template, "choices.0.delta.content", partialJSON.String())
+						return []string{template}
+					}
 					index := int(root.Get("index").Int())
 					if (*param).(*ConvertAnthropicResponseToOpenAIParams).ToolCallsAccumulator != nil {
 						if accumulator, exists := (*param).(*ConvertAnthropicResponseToOpenAIParams).ToolCallsAccumulator[index]; exists {
@@ -201,6 +216,9 @@ func ConvertClaudeResponseToOpenAI(_ context.Context, modelName string, original
 		if delta := root.Get("delta"); delta.Exists() {
 			if stopReason := delta.Get("stop_reason"); stopReason.Exists() {
 				(*param).(*ConvertAnthropicResponseToOpenAIParams).FinishReason = mapAnthropicStopReasonToOpenAI(stopReason.String())
+				if (*param).(*ConvertAnthropicResponseToOpenAIParams).StructuredOutput && stopReason.String() == "tool_use" {
+					(*param).(*ConvertAnthropicResponseToOpenAIParams).FinishReason = "stop"
+				}
 				template, _ = sjson.Set(template, "choices.0.finish_reason", (*param).(*ConvertAnthropicResponseToOpenAIParams).FinishReason)
 			}
 		}
@@ -292,6 +310,8 @@ func ConvertClaudeResponseToOpenAINonStream(_ context.Context, _ string, origina
 	var contentParts []string
 	var reasoningParts []string
 	toolCallsAccumulator := make(map[int]*ToolCallAccumulator)
+	structuredOutput := structuredoutput.ClaudeForcesTool(originalRequestRawJSON)
+	structuredIndex := -1
 
 	for _, chunk := range chunks {
 		root := gjson.ParseBytes(chunk)
@@ -313,6 +333,9 @@ func ConvertClaudeResponseToOpenAINonStream(_ context.Context, _ string, origina
 				if blockType == "thinking" {
 					// Start of thinking/reasoning content - skip for now as it's handled in delta
 					continue
+				} else if blockType == "tool_use" && structuredOutput {
+					// The forced structured_output tool input becomes the message content
+					structuredIndex = int(root.Get("index").Int())
 				} else if blockType == "tool_use" {
 					// Initialize tool call accumulator for this index
 					index := int(root.Get("index").Int())
@@ -342,7 +365,9 @@ func ConvertClaudeResponseToOpenAINonStream(_ context.Context, _ string, origina
 					// Accumulate tool call arguments
 					if partialJSON := delta.Get("partial_json"); partialJSON.Exists() {
 						index := int(root.Get("index").Int())
-						if accumulator, exists := toolCallsAccumulator[index]; exists {
+						if index == structuredIndex {
+							contentParts = append(contentParts, partialJSON.String())
+						} else if accumulator, exists := toolCallsAccumulator[index]; exists {
 							accumulator.Arguments.WriteString(partialJSON.String())
 						}
 					}
@@ -363,6 +388,9 @@ func ConvertClaudeResponseToOpenAINonStream(_ context.Context, _ string, origina
 			if delta := root.Get("delta"); delta.Exists() {
 				if sr := delta.Get("stop_reason"); sr.Exists() {
 					stopReason = sr.String()
+					if structuredIndex >= 0 && stopReason == "tool_use" {
+						stopReason = "end_turn"
+					}
 				}
 			}
 			if usage := root.Get("usage"); usage.Exists() {
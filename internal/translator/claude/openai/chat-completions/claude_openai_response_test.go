@@ -0,0 +1,52 @@
+package chat_completions
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+const structuredOutputRequest = `{"messages":[{"role":"user","content":"hi"}],"response_format":{"type":"json_schema","json_schema":{"name":"book","schema":{"type":"object"}}}}`
+
+var structuredOutputEvents = []string{
+	`data: {"type":"message_start","message":{"id":"msg_1","model":"claude-sonnet-4-5"}}`,
+	`data: {"type":"content_block_start","index":0,"content_block":{"type":"tool_use","id":"toolu_1","name":"structured_output","input":{}}}`,
+	`data: {"type":"content_block_delta","index":0,"delta":{"type":"input_json_delta","partial_json":"{\"author\":"}}`,
+	`data: {"type":"content_block_delta","index":0,"delta":{"type":"input_json_delta","partial_json":"\"Herbert\"}"}}`,
+	`data: {"type":"content_block_stop","index":0}`,
+	`data: {"type":"message_delta","delta":{"stop_reason":"tool_use"},"usage":{"output_tokens":5}}`,
+}
+
+func TestConvertClaudeResponseToOpenAI_StructuredOutputAsContent(t *testing.T) {
+	var param any
+	var content strings.Builder
+	var finishReason string
+	for _, event := range structuredOutputEvents {
+		for _, chunk := range ConvertClaudeResponseToOpenAI(context.Background(), "claude-sonnet-4-5", []byte(structuredOutputRequest), nil, []byte(event), &param) {
+			parsed := gjson.Parse(chunk)
+			if parsed.Get("choices.0.delta.tool_calls").Exists() {
+				t.Fatalf("Structured output must not surface as a tool call: %s", chunk)
+			}
+			content.WriteString(parsed.Get("choices.0.delta.content").String())
+			if reason := parsed.Get("choices.0.finish_reason").String(); reason != "" {
+				finishReason = reason
+			}
+		}
+	}
+	if content.String() != `{"author":"Herbert"}` || finishReason != "stop" {
+		t.Fatalf("content = %q, finish_reason = %q", content.String(), finishReason)
+	}
+}
+
+func TestConvertClaudeResponseToOpenAINonStream_StructuredOutputAsContent(t *testing.T) {
+	raw := strings.Join(structuredOutputEvents, "\n")
+	out := gjson.Parse(ConvertClaudeResponseToOpenAINonStream(context.Background(), "", []byte(structuredOutputRequest), nil, []byte(raw), nil))
+	if got := out.Get("choices.0.message.content").String(); got != `{"author":"Herbert"}` {
+		t.Fatalf("content = %q", got)
+	}
+	if out.Get("choices.0.message.tool_calls").Exists() || out.Get("choices.0.finish_reason").String() != "stop" {
+		t.Fatalf("unexpected response: %s", out.Raw)
+	}
+}
@@ -120,6 +120,16 @@ func ConvertClaudeRequestToCodex(modelName string, inputRawJSON []byte, _ bool)
 				hasContent = true
 			}
 
+			appendFileContent := func(filename, dataURL string) {
+				message, _ = sjson.Set(message, fmt.Sprintf("content.%d.type", contentIndex), "input_file")
+				message, _ = sjson.Set(message, fmt.Sprintf("content.%d.file_data", contentIndex), dataURL)
+				if filename != "" {
+					message, _ = sjson.Set(message, fmt.Sprintf("content.%d.filename", contentIndex), filename)
+				}
+				contentIndex++
+				hasContent = true
+			}
+
 			messageContentsResult := messageResult.Get("content")
 			if messageContentsResult.IsArray() {
 				messageContentResults := messageContentsResult.Array()
@@ -149,6 +159,24 @@ func ConvertClaudeRequestToCodex(modelName string, inputRawJSON []byte, _ bool)
 								appendImageContent(dataURL)
 							}
 						}
+					case "document":
+						sourceResult := messageContentResult.Get("source")
+						switch sourceResult.Get("type").String() {
+						case "text":
+							appendTextContent(sourceResult.Get("data").String())
+						case "base64":
+							if data := sourceResult.Get("data").String(); data != "" {
+								mediaType := sourceResult.Get("media_type").String()
+								if mediaType == "" {
+									mediaType = "application/pdf"
+								}
+								filename := messageContentResult.Get("title").String()
+								if filename == "" && mediaType == "application/pdf" {
+									filename = "document.pdf"
+								}
+								appendFileContent(filename, fmt.Sprintf("data:%s;base64,%s", mediaType, data))
+							}
+						}
 					case "tool_use":
 						flushMessage()
 						functionCallMessage := `{"type":"function_call"}`
@@ -87,3 +87,25 @@ func TestConvertClaudeRequestToCodex_SystemMessageScenarios(t *testing.T) {
 		})
 	}
 }
+
+func TestConvertClaudeRequestToCodex_DocumentContent(t *testing.T) {
+	inputJSON := []byte(`{
+		"model": "claude-3-opus",
+		"messages": [{"role": "user", "content": [
+			{"type": "document", "title": "report.pdf", "source": {"type": "base64", "media_type": "application/pdf", "data": "JVBERi0="}}
+		]}]
+	}`)
+
+	output := ConvertClaudeRequestToCodex("test-model", inputJSON, false)
+
+	part := gjson.GetBytes(output, "input.0.content.0")
+	if got := part.Get("type").String(); got != "input_file" {
+		t.Fatalf("Expected input_file part, got %s", part.Raw)
+	}
+	if got := part.Get("file_data").String(); got != "data:application/pdf;base64,JVBERi0=" {
+		t.Fatalf("Unexpected file_data %q", got)
+	}
+	if got := part.Get("filename").String(); got != "report.pdf" {
+		t.Fatalf("Unexpected filename %q", got)
+	}
+}
@@ -0,0 +1,21 @@
+package images
+
+import (
+	"context"
+
+	. "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/gemini-cli/gemini"
+	. "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/gemini/openai/images"
+	"github.com/tidwall/gjson"
+)
+
+func ConvertOpenAIImageRequestToGeminiCLI(modelName string, inputRawJSON []byte, stream bool) []byte {
+	rawJSON := ConvertOpenAIImageRequestToGemini(modelName, inputRawJSON, stream)
+	return ConvertGeminiRequestToGeminiCLI(modelName, rawJSON, stream)
+}
+
+func ConvertGeminiCLIResponseToOpenAIImages(ctx context.Context, modelName string, originalRequestRawJSON, requestRawJSON, rawJSON []byte, param *any) string {
+	if responseResult := gjson.GetBytes(rawJSON, "response"); responseResult.Exists() {
+		rawJSON = []byte(responseResult.Raw)
+	}
+	return ConvertGeminiResponseToOpenAIImages(ctx, modelName, originalRequestRawJSON, requestRawJSON, rawJSON, param)
+}
@@ -115,8 +115,14 @@ func ConvertClaudeRequestToGemini(modelName string, inputRawJSON []byte, _ bool)
 						part, _ = sjson.Set(part, "functionResponse.response.result", responseData)
 						contentJSON, _ = sjson.SetRaw(contentJSON, "parts.-1", part)
 
-					case "image":
+					case "image", "document":
 						source := contentResult.Get("source")
+						if source.Get("type").String() == "text" {
+							part := `{"text":""}`
+							part, _ = sjson.Set(part, "text", source.Get("data").String())
+							contentJSON, _ = sjson.SetRaw(contentJSON, "parts.-1", part)
+							return true
+						}
 						if source.Get("type").String() != "base64" {
 							return true
 						}
@@ -78,3 +78,34 @@ func TestConvertClaudeRequestToGemini_ImageContent(t *testing.T) {
 		t.Fatalf("Expected image data 'aGVsbG8=', got '%s'", got)
 	}
 }
+
+func TestConvertClaudeRequestToGemini_DocumentContent(t *testing.T) {
+	inputJSON := []byte(`{
+		"model": "gemini-3-flash-preview",
+		"messages": [
+			{
+				"role": "user",
+				"content": [
+					{"type": "document", "source": {"type": "base64", "media_type": "application/pdf", "data": "JVBERi0="}},
+					{"type": "document", "source": {"type": "text", "media_type": "text/plain", "data": "plain notes"}}
+				]
+			}
+		]
+	}`)
+
+	output := ConvertClaudeRequestToGemini("gemini-3-flash-preview", inputJSON, false)
+
+	parts := gjson.GetBytes(output, "contents.0.parts").Array()
+	if len(parts) != 2 {
+		t.Fatalf("Expected 2 parts, got %d", len(parts))
+	}
+	if got := parts[0].Get("inline_data.mime_type").String(); got != "application/pdf" {
+		t.Fatalf("Expected document mime type 'application/pdf', got '%s'", got)
+	}
+	if got := parts[0].Get("inline_data.data").String(); got != "JVBERi0=" {
+		t.Fatalf("Expected document data 'JVBERi0=', got '%s'", got)
+	}
+	if got := parts[1].Get("text").String(); got != "plain notes" {
+		t.Fatalf("Expected text document 'plain notes', got '%s'", got)
+	}
+}
@@ -0,0 +1,85 @@
+// Package embeddings translates OpenAI embeddings requests into Gemini batchEmbedContents
+// requests and converts the returned vectors back into the OpenAI list format.
+package embeddings
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"math"
+	"strings"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// ConvertOpenAIEmbeddingRequestToGemini converts an OpenAI embeddings request into a
+// Gemini batchEmbedContents request with one entry per input string.
+func ConvertOpenAIEmbeddingRequestToGemini(modelName string, inputRawJSON []byte, _ bool) []byte {
+	modelRef := modelName
+	if !strings.HasPrefix(modelRef, "models/") {
+		modelRef = "models/" + modelRef
+	}
+	dimensions := gjson.GetBytes(inputRawJSON, "dimensions")
+
+	out := []byte(`{"requests":[]}`)
+	for _, text := range inputTexts(gjson.GetBytes(inputRawJSON, "input")) {
+		entry := []byte(`{"model":"","content":{"parts":[{"text":""}]}}`)
+		entry, _ = sjson.SetBytes(entry, "model", modelRef)
+		entry, _ = sjson.SetBytes(entry, "content.parts.0.text", text)
+		if dimensions.Exists() && dimensions.Int() > 0 {
+			entry, _ = sjson.SetBytes(entry, "outputDimensionality", dimensions.Int())
+		}
+		out, _ = sjson.SetRawBytes(out, "requests.-1", entry)
+	}
+	return out
+}
+
+// inputTexts flattens the OpenAI input field, which is either a string or an array of strings.
+func inputTexts(input gjson.Result) []string {
+	if !input.IsArray() {
+		return []string{input.String()}
+	}
+	items := input.Array()
+	texts := make([]string, 0, len(items))
+	for _, item := range items {
+		texts = append(texts, item.String())
+	}
+	return texts
+}
+
+// ConvertGeminiEmbeddingResponseToOpenAI converts a Gemini batchEmbedContents response into
+// an OpenAI embeddings list, honouring the encoding_format of the original request.
+func ConvertGeminiEmbeddingResponseToOpenAI(_ context.Context, modelName string, originalRequestRawJSON, _ []byte, rawJSON []byte, _ *any) string {
+	useBase64 := gjson.GetBytes(originalRequestRawJSON, "encoding_format").String() == "base64"
+
+	out := []byte(`{"object":"list","data":[],"model":"","usage":{"prompt_tokens":0,"total_tokens":0}}`)
+	out, _ = sjson.SetBytes(out, "model", modelName)
+	for index, embedding := range gjson.GetBytes(rawJSON, "embeddings").Array() {
+		item := []byte(`{"object":"embedding","index":0}`)
+		item, _ = sjson.SetBytes(item, "index", index)
+		values := embedding.Get("values")
+		if useBase64 {
+			item, _ = sjson.SetBytes(item, "embedding", encodeFloat32Base64(values))
+		} else {
+			item, _ = sjson.SetRawBytes(item, "embedding", []byte(values.Raw))
+		}
+		out, _ = sjson.SetRawBytes(out, "data.-1", item)
+	}
+	if tokens := gjson.GetBytes(rawJSON, "usageMetadata.promptTokenCount"); tokens.Exists() {
+		out, _ = sjson.SetBytes(out, "usage.prompt_tokens", tokens.Int())
+		out, _ = sjson.SetBytes(out, "usage.total_tokens", tokens.Int())
+	}
+	return string(out)
+}
+
+// encodeFloat32Base64 packs the vector as little-endian float32 values, as OpenAI does
+// for encoding_format=base64.
+func encodeFloat32Base64(values gjson.Result) string {
+	var buf bytes.Buffer
+	for _, value := range values.Array() {
+		_ = binary.Write(&buf, binary.LittleEndian, math.Float32bits(float32(value.Float())))
+	}
+	return base64.StdEncoding.EncodeToString(buf.Bytes())
+}
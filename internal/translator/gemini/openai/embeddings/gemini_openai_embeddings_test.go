@@ -0,0 +1,69 @@
+package embeddings
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"math"
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestConvertOpenAIEmbeddingRequestToGemini(t *testing.T) {
+	out := ConvertOpenAIEmbeddingRequestToGemini("gemini-embedding-001", []byte(`{"input":["first","second"],"dimensions":256}`), false)
+
+	requests := gjson.GetBytes(out, "requests").Array()
+	if len(requests) != 2 {
+		t.Fatalf("requests = %d, want 2: %s", len(requests), out)
+	}
+	for i, want := range []string{"first", "second"} {
+		if got := requests[i].Get("content.parts.0.text").String(); got != want {
+			t.Errorf("requests[%d] text = %q, want %q", i, got, want)
+		}
+		if got := requests[i].Get("model").String(); got != "models/gemini-embedding-001" {
+			t.Errorf("requests[%d] model = %q", i, got)
+		}
+		if got := requests[i].Get("outputDimensionality").Int(); got != 256 {
+			t.Errorf("requests[%d] outputDimensionality = %d, want 256", i, got)
+		}
+	}
+
+	single := ConvertOpenAIEmbeddingRequestToGemini("text-embedding-004", []byte(`{"input":"hello"}`), false)
+	if got := gjson.GetBytes(single, "requests.#").Int(); got != 1 {
+		t.Fatalf("string input requests = %d, want 1", got)
+	}
+	if gjson.GetBytes(single, "requests.0.outputDimensionality").Exists() {
+		t.Fatalf("outputDimensionality should be omitted without dimensions: %s", single)
+	}
+}
+
+func TestConvertGeminiEmbeddingResponseToOpenAI(t *testing.T) {
+	upstream := []byte(`{"embeddings":[{"values":[0.5,-1]},{"values":[2]}],"usageMetadata":{"promptTokenCount":7}}`)
+
+	out := ConvertGeminiEmbeddingResponseToOpenAI(context.Background(), "gemini-embedding-001", []byte(`{"input":["a","b"]}`), nil, upstream, nil)
+	if got := gjson.Get(out, "data.#").Int(); got != 2 {
+		t.Fatalf("data = %d, want 2: %s", got, out)
+	}
+	if got := gjson.Get(out, "data.1.index").Int(); got != 1 {
+		t.Errorf("data.1.index = %d, want 1", got)
+	}
+	if got := gjson.Get(out, "data.0.embedding").Raw; got != "[0.5,-1]" {
+		t.Errorf("data.0.embedding = %s", got)
+	}
+	if got := gjson.Get(out, "usage.prompt_tokens").Int(); got != 7 {
+		t.Errorf("usage.prompt_tokens = %d, want 7", got)
+	}
+
+	encoded := ConvertGeminiEmbeddingResponseToOpenAI(context.Background(), "gemini-embedding-001", []byte(`{"input":"a","encoding_format":"base64"}`), nil, upstream, nil)
+	raw, err := base64.StdEncoding.DecodeString(gjson.Get(encoded, "data.0.embedding").String())
+	if err != nil {
+		t.Fatalf("decode base64 embedding: %v", err)
+	}
+	if len(raw) != 8 {
+		t.Fatalf("decoded length = %d, want 8", len(raw))
+	}
+	if got := math.Float32frombits(binary.LittleEndian.Uint32(raw[4:])); got != -1 {
+		t.Errorf("second value = %v, want -1", got)
+	}
+}
@@ -0,0 +1,158 @@
+// Package images translates OpenAI image generation and edit requests into Gemini
+// generateContent requests with image output, and converts the returned inline images
+// back into the OpenAI images response format.
+package images
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// geminiAspectRatios lists the aspect ratios accepted by Gemini image models.
+var geminiAspectRatios = []string{"1:1", "2:3", "3:2", "3:4", "4:3", "4:5", "5:4", "9:16", "16:9", "21:9"}
+
+// ConvertOpenAIImageRequestToGemini converts an OpenAI images request into a Gemini
+// generateContent request. Input images of an edit request, given as data URLs in
+// "images" and "mask", are sent as inline data ahead of the prompt.
+func ConvertOpenAIImageRequestToGemini(_ string, inputRawJSON []byte, _ bool) []byte {
+	out := []byte(`{"contents":[{"role":"user","parts":[]}],"generationConfig":{"responseModalities":["TEXT","IMAGE"]}}`)
+
+	for _, image := range gjson.GetBytes(inputRawJSON, "images").Array() {
+		out, _ = appendInlineImage(out, image)
+	}
+	if mask := gjson.GetBytes(inputRawJSON, "mask"); mask.Exists() {
+		var added bool
+		if out, added = appendInlineImage(out, mask); added {
+			out, _ = sjson.SetBytes(out, "contents.0.parts.-1", map[string]string{"text": "The last image is a mask: edit only the areas where it is fully transparent."})
+		}
+	}
+	out, _ = sjson.SetBytes(out, "contents.0.parts.-1", map[string]string{"text": gjson.GetBytes(inputRawJSON, "prompt").String()})
+
+	if n := gjson.GetBytes(inputRawJSON, "n").Int(); n > 1 {
+		out, _ = sjson.SetBytes(out, "generationConfig.candidateCount", n)
+	}
+	size := strings.TrimSpace(gjson.GetBytes(inputRawJSON, "size").String())
+	switch strings.ToUpper(size) {
+	case "", "AUTO":
+	case "1K", "2K", "4K":
+		out, _ = sjson.SetBytes(out, "generationConfig.imageConfig.imageSize", strings.ToUpper(size))
+	default:
+		if ratio := aspectRatioForSize(size); ratio != "" {
+			out, _ = sjson.SetBytes(out, "generationConfig.imageConfig.aspectRatio", ratio)
+		}
+	}
+	return out
+}
+
+// appendInlineImage adds image, either a data URL string or an {"image_url": ...} object,
+// as an inline data part and reports whether it was added. Anything else is skipped.
+func appendInlineImage(out []byte, image gjson.Result) ([]byte, bool) {
+	url := image.String()
+	if image.IsObject() {
+		url = image.Get("image_url").String()
+	}
+	mimeType, data, ok := parseDataURL(url)
+	if !ok {
+		return out, false
+	}
+	part := []byte(`{"inlineData":{"mime_type":"","data":""}}`)
+	part, _ = sjson.SetBytes(part, "inlineData.mime_type", mimeType)
+	part, _ = sjson.SetBytes(part, "inlineData.data", data)
+	out, _ = sjson.SetRawBytes(out, "contents.0.parts.-1", part)
+	return out, true
+}
+
+// parseDataURL splits a base64 data URL into its media type and payload.
+func parseDataURL(url string) (string, string, bool) {
+	rest, ok := strings.CutPrefix(url, "data:")
+	if !ok {
+		return "", "", false
+	}
+	header, data, ok := strings.Cut(rest, ",")
+	if !ok || data == "" {
+		return "", "", false
+	}
+	mimeType, ok := strings.CutSuffix(header, ";base64")
+	if !ok {
+		return "", "", false
+	}
+	if mimeType == "" {
+		mimeType = "image/png"
+	}
+	return mimeType, data, true
+}
+
+// aspectRatioForSize maps a "WIDTHxHEIGHT" size onto the closest supported aspect ratio.
+func aspectRatioForSize(size string) string {
+	widthText, heightText, ok := strings.Cut(strings.ToLower(size), "x")
+	if !ok {
+		return ""
+	}
+	width, errWidth := strconv.ParseFloat(widthText, 64)
+	height, errHeight := strconv.ParseFloat(heightText, 64)
+	if errWidth != nil || errHeight != nil || width <= 0 || height <= 0 {
+		return ""
+	}
+	target := width / height
+	best, bestDiff := "", 0.0
+	for _, ratio := range geminiAspectRatios {
+		w, h, _ := strings.Cut(ratio, ":")
+		rw, _ := strconv.ParseFloat(w, 64)
+		rh, _ := strconv.ParseFloat(h, 64)
+		diff := rw/rh - target
+		if diff < 0 {
+			diff = -diff
+		}
+		if best == "" || diff < bestDiff {
+			best, bestDiff = ratio, diff
+		}
+	}
+	return best
+}
+
+// ConvertGeminiResponseToOpenAIImages collects the inline images of every candidate into an
+// OpenAI images response, as b64_json or, for response_format=url, as data URLs.
+func ConvertGeminiResponseToOpenAIImages(_ context.Context, _ string, originalRequestRawJSON, _ []byte, rawJSON []byte, _ *any) string {
+	asURL := gjson.GetBytes(originalRequestRawJSON, "response_format").String() == "url"
+
+	out := []byte(`{"created":0,"data":[]}`)
+	out, _ = sjson.SetBytes(out, "created", time.Now().Unix())
+	for _, candidate := range gjson.GetBytes(rawJSON, "candidates").Array() {
+		for _, part := range candidate.Get("content.parts").Array() {
+			inlineData := part.Get("inlineData")
+			if !inlineData.Exists() {
+				inlineData = part.Get("inline_data")
+			}
+			data := inlineData.Get("data").String()
+			if data == "" || part.Get("thought").Bool() {
+				continue
+			}
+			item := []byte(`{}`)
+			if asURL {
+				mimeType := inlineData.Get("mimeType").String()
+				if mimeType == "" {
+					mimeType = inlineData.Get("mime_type").String()
+				}
+				if mimeType == "" {
+					mimeType = "image/png"
+				}
+				item, _ = sjson.SetBytes(item, "url", fmt.Sprintf("data:%s;base64,%s", mimeType, data))
+			} else {
+				item, _ = sjson.SetBytes(item, "b64_json", data)
+			}
+			out, _ = sjson.SetRawBytes(out, "data.-1", item)
+		}
+	}
+	if usage := gjson.GetBytes(rawJSON, "usageMetadata"); usage.Exists() {
+		out, _ = sjson.SetBytes(out, "usage.input_tokens", usage.Get("promptTokenCount").Int())
+		out, _ = sjson.SetBytes(out, "usage.output_tokens", usage.Get("candidatesTokenCount").Int()+usage.Get("thoughtsTokenCount").Int())
+		out, _ = sjson.SetBytes(out, "usage.total_tokens", usage.Get("totalTokenCount").Int())
+	}
+	return string(out)
+}
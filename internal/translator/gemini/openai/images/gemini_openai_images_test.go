@@ -0,0 +1,73 @@
+package images
+
+import (
+	"context"
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestConvertOpenAIImageRequestToGemini(t *testing.T) {
+	out := ConvertOpenAIImageRequestToGemini("gemini-2.5-flash-image", []byte(`{
+		"prompt":"a red fox",
+		"n":2,
+		"size":"1792x1024",
+		"images":[{"image_url":"data:image/jpeg;base64,AAAA"},"https://example.com/skip.png"],
+		"mask":{"image_url":"data:image/png;base64,BBBB"}
+	}`), false)
+
+	parts := gjson.GetBytes(out, "contents.0.parts").Array()
+	if len(parts) != 4 {
+		t.Fatalf("parts = %d, want 4: %s", len(parts), out)
+	}
+	if got := parts[0].Get("inlineData.mime_type").String(); got != "image/jpeg" {
+		t.Errorf("first image mime type = %q", got)
+	}
+	if got := parts[1].Get("inlineData.data").String(); got != "BBBB" {
+		t.Errorf("mask data = %q", got)
+	}
+	if got := parts[3].Get("text").String(); got != "a red fox" {
+		t.Errorf("prompt part = %q", got)
+	}
+	if got := gjson.GetBytes(out, "generationConfig.candidateCount").Int(); got != 2 {
+		t.Errorf("candidateCount = %d, want 2", got)
+	}
+	if got := gjson.GetBytes(out, "generationConfig.imageConfig.aspectRatio").String(); got != "16:9" {
+		t.Errorf("aspectRatio = %q, want 16:9", got)
+	}
+	if got := gjson.GetBytes(out, "generationConfig.responseModalities.1").String(); got != "IMAGE" {
+		t.Errorf("responseModalities = %s", gjson.GetBytes(out, "generationConfig.responseModalities").Raw)
+	}
+
+	sized := ConvertOpenAIImageRequestToGemini("gemini-3-pro-image-preview", []byte(`{"prompt":"x","size":"2k"}`), false)
+	if got := gjson.GetBytes(sized, "generationConfig.imageConfig.imageSize").String(); got != "2K" {
+		t.Errorf("imageSize = %q, want 2K", got)
+	}
+}
+
+func TestConvertGeminiResponseToOpenAIImages(t *testing.T) {
+	upstream := []byte(`{
+		"candidates":[{"content":{"parts":[
+			{"text":"Here is your fox."},
+			{"inlineData":{"mimeType":"image/png","data":"thought"},"thought":true},
+			{"inlineData":{"mimeType":"image/webp","data":"IMG1"}}
+		]}}],
+		"usageMetadata":{"promptTokenCount":10,"candidatesTokenCount":1290,"totalTokenCount":1300}
+	}`)
+
+	out := ConvertGeminiResponseToOpenAIImages(context.Background(), "m", []byte(`{"prompt":"fox"}`), nil, upstream, nil)
+	if got := gjson.Get(out, "data.#").Int(); got != 1 {
+		t.Fatalf("data = %d, want 1: %s", got, out)
+	}
+	if got := gjson.Get(out, "data.0.b64_json").String(); got != "IMG1" {
+		t.Errorf("b64_json = %q", got)
+	}
+	if got := gjson.Get(out, "usage.output_tokens").Int(); got != 1290 {
+		t.Errorf("usage.output_tokens = %d", got)
+	}
+
+	asURL := ConvertGeminiResponseToOpenAIImages(context.Background(), "m", []byte(`{"prompt":"fox","response_format":"url"}`), nil, upstream, nil)
+	if got := gjson.Get(asURL, "data.0.url").String(); got != "data:image/webp;base64,IMG1" {
+		t.Errorf("url = %q", got)
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"unicode/utf8"
 
 	"github.com/google/uuid"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/structuredoutput"
 	kiroclaude "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/kiro/claude"
 	kirocommon "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/kiro/common"
 	log "github.com/sirupsen/logrus"
@@ -970,15 +971,9 @@ func extractResponseFormatHint(openaiBody []byte) string {
 	case "json_object":
 		return "[INSTRUCTION: You MUST respond with valid JSON only. Do not include any text before or after the JSON. Do not wrap the JSON in markdown code blocks. Output raw JSON directly.]"
 	case "json_schema":
-		// Extract schema if provided
-		schema := responseFormat.Get("json_schema.schema")
-		if schema.Exists() {
-			schemaStr := schema.Raw
-			// Truncate if too long
-			if len(schemaStr) > 500 {
-				schemaStr = schemaStr[:500] + "..."
-			}
-			return fmt.Sprintf("[INSTRUCTION: You MUST respond with valid JSON that matches this schema: %s. Do not include any text before or after the JSON. Do not wrap the JSON in markdown code blocks. Output raw JSON directly.]", schemaStr)
+		// The full schema is kept so the answer can be validated against it afterwards
+		if format, ok := structuredoutput.FromOpenAIChat(openaiBody); ok {
+			return format.Instructions()
 		}
 		return "[INSTRUCTION: You MUST respond with valid JSON only. Do not include any text before or after the JSON. Do not wrap the JSON in markdown code blocks. Output raw JSON directly.]"
 	case "text":
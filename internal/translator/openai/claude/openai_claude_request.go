@@ -157,7 +157,7 @@ func ConvertClaudeRequestToOpenAI(modelName string, inputRawJSON []byte, stream
 					case "redacted_thinking":
 						// Explicitly ignore redacted_thinking - never map to reasoning_content (AC2)
 
-					case "text", "image":
+					case "text", "image", "document":
 						if contentItem, ok := convertClaudeContentPart(part); ok {
 							contentItems = append(contentItems, contentItem)
 						}
@@ -374,6 +374,35 @@ func convertClaudeContentPart(part gjson.Result) (string, bool) {
 
 		return imageContent, true
 
+	case "document":
+		source := part.Get("source")
+		switch source.Get("type").String() {
+		case "text":
+			textContent := `{"type":"text","text":""}`
+			textContent, _ = sjson.Set(textContent, "text", source.Get("data").String())
+			return textContent, true
+		case "base64":
+			data := source.Get("data").String()
+			if data == "" {
+				return "", false
+			}
+			mediaType := source.Get("media_type").String()
+			if mediaType == "" {
+				mediaType = "application/pdf"
+			}
+			filename := part.Get("title").String()
+			if filename == "" && mediaType == "application/pdf" {
+				filename = "document.pdf"
+			}
+			fileContent := `{"type":"file","file":{"file_data":""}}`
+			fileContent, _ = sjson.Set(fileContent, "file.file_data", "data:"+mediaType+";base64,"+data)
+			if filename != "" {
+				fileContent, _ = sjson.Set(fileContent, "file.filename", filename)
+			}
+			return fileContent, true
+		}
+		return "", false
+
 	default:
 		return "", false
 	}
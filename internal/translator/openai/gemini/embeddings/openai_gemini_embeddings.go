@@ -0,0 +1,50 @@
+// Package embeddings translates Gemini batchEmbedContents requests into OpenAI embeddings
+// requests and converts the returned list back into Gemini embeddings.
+package embeddings
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// ConvertGeminiEmbeddingRequestToOpenAI converts a Gemini batchEmbedContents request into an
+// OpenAI embeddings request. The text parts of each entry are joined into one input.
+func ConvertGeminiEmbeddingRequestToOpenAI(modelName string, inputRawJSON []byte, _ bool) []byte {
+	out := []byte(`{"model":"","input":[]}`)
+	out, _ = sjson.SetBytes(out, "model", modelName)
+	for _, request := range gjson.GetBytes(inputRawJSON, "requests").Array() {
+		var parts []string
+		for _, part := range request.Get("content.parts").Array() {
+			if text := part.Get("text"); text.Exists() {
+				parts = append(parts, text.String())
+			}
+		}
+		out, _ = sjson.SetBytes(out, "input.-1", strings.Join(parts, "\n"))
+		if dims := request.Get("outputDimensionality"); dims.Exists() && !gjson.GetBytes(out, "dimensions").Exists() {
+			out, _ = sjson.SetBytes(out, "dimensions", dims.Int())
+		}
+	}
+	return out
+}
+
+// ConvertOpenAIEmbeddingResponseToGemini converts an OpenAI embeddings list into a Gemini
+// batchEmbedContents response, ordered by the index of each item.
+func ConvertOpenAIEmbeddingResponseToGemini(_ context.Context, _ string, _, _ []byte, rawJSON []byte, _ *any) string {
+	items := gjson.GetBytes(rawJSON, "data").Array()
+	sort.SliceStable(items, func(i, j int) bool { return items[i].Get("index").Int() < items[j].Get("index").Int() })
+
+	out := []byte(`{"embeddings":[]}`)
+	for _, item := range items {
+		embedding := []byte(`{"values":[]}`)
+		embedding, _ = sjson.SetRawBytes(embedding, "values", []byte(item.Get("embedding").Raw))
+		out, _ = sjson.SetRawBytes(out, "embeddings.-1", embedding)
+	}
+	if tokens := gjson.GetBytes(rawJSON, "usage.prompt_tokens"); tokens.Exists() {
+		out, _ = sjson.SetBytes(out, "usageMetadata.promptTokenCount", tokens.Int())
+	}
+	return string(out)
+}
@@ -94,6 +94,14 @@ func ConvertOpenAIResponsesRequestToOpenAIChatCompletions(modelName string, inpu
 							contentPart := `{"type":"image_url","image_url":{"url":""}}`
 							contentPart, _ = sjson.Set(contentPart, "image_url.url", imageURL)
 							message, _ = sjson.SetRaw(message, "content.-1", contentPart)
+						case "input_file":
+							contentPart := `{"type":"file","file":{}}`
+							for _, key := range []string{"file_id", "file_data", "filename"} {
+								if value := contentItem.Get(key); value.Exists() {
+									contentPart, _ = sjson.Set(contentPart, "file."+key, value.String())
+								}
+							}
+							message, _ = sjson.SetRaw(message, "content.-1", contentPart)
 						}
 						return true
 					})
@@ -489,6 +489,11 @@ func (a App) connectWithPassword(password string) tea.Cmd {
 	return func() tea.Msg {
 		a.client.SetSecretKey(password)
 		cfg, errGetConfig := a.client.GetConfig()
+		if isForbidden(errGetConfig) {
+			// The key is valid but its roles do not cover the config; the tabs it may
+			// use still work.
+			return authConnectMsg{}
+		}
 		return authConnectMsg{cfg: cfg, err: errGetConfig}
 	}
 }
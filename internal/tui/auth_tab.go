@@ -95,7 +95,7 @@ func (m authTabModel) Update(msg tea.Msg) (authTabModel, tea.Cmd) {
 
 	case authActionMsg:
 		if msg.err != nil {
-			m.status = errorStyle.Render("✗ " + msg.err.Error())
+			m.status = errorStyle.Render("✗ " + errorText(msg.err))
 		} else {
 			m.status = successStyle.Render("✓ " + msg.action)
 		}
@@ -177,7 +177,7 @@ func (m authTabModel) renderContent() string {
 	sb.WriteString("\n")
 
 	if m.err != nil {
-		sb.WriteString(errorStyle.Render("⚠ Error: " + m.err.Error()))
+		sb.WriteString(errorStyle.Render("⚠ Error: " + errorText(m.err)))
 		sb.WriteString("\n")
 		return sb.String()
 	}
@@ -2,6 +2,7 @@ package tui
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -11,6 +12,32 @@ import (
 	"time"
 )
 
+// errForbidden is wrapped by errors of requests rejected because the management key's
+// roles do not cover the route.
+var errForbidden = errors.New("forbidden")
+
+// isForbidden reports whether err comes from a request the management key may not make.
+func isForbidden(err error) bool {
+	return errors.Is(err, errForbidden)
+}
+
+// statusError converts an HTTP error response into an error.
+func statusError(code int, data []byte) error {
+	body := strings.TrimSpace(string(data))
+	if code == http.StatusForbidden {
+		return fmt.Errorf("%w (HTTP %d): %s", errForbidden, code, body)
+	}
+	return fmt.Errorf("HTTP %d: %s", code, body)
+}
+
+// errorText renders err for display, replacing permission errors with a hint about roles.
+func errorText(err error) string {
+	if isForbidden(err) {
+		return T("permission_denied")
+	}
+	return err.Error()
+}
+
 // Client wraps HTTP calls to the management API.
 type Client struct {
 	baseURL   string
@@ -64,7 +91,7 @@ func (c *Client) get(path string) ([]byte, error) {
 		return nil, err
 	}
 	if code >= 400 {
-		return nil, fmt.Errorf("HTTP %d: %s", code, strings.TrimSpace(string(data)))
+		return nil, statusError(code, data)
 	}
 	return data, nil
 }
@@ -75,7 +102,7 @@ func (c *Client) put(path string, body io.Reader) ([]byte, error) {
 		return nil, err
 	}
 	if code >= 400 {
-		return nil, fmt.Errorf("HTTP %d: %s", code, strings.TrimSpace(string(data)))
+		return nil, statusError(code, data)
 	}
 	return data, nil
 }
@@ -86,7 +113,7 @@ func (c *Client) patch(path string, body io.Reader) ([]byte, error) {
 		return nil, err
 	}
 	if code >= 400 {
-		return nil, fmt.Errorf("HTTP %d: %s", code, strings.TrimSpace(string(data)))
+		return nil, statusError(code, data)
 	}
 	return data, nil
 }
@@ -110,12 +137,12 @@ func (c *Client) postJSON(path string, body any) error {
 	if err != nil {
 		return err
 	}
-	_, code, err := c.doRequest("POST", path, strings.NewReader(string(jsonBody)))
+	data, code, err := c.doRequest("POST", path, strings.NewReader(string(jsonBody)))
 	if err != nil {
 		return err
 	}
 	if code >= 400 {
-		return fmt.Errorf("HTTP %d", code)
+		return statusError(code, data)
 	}
 	return nil
 }
@@ -160,10 +187,13 @@ func (c *Client) DeleteAuthFile(name string) error {
 	query := url.Values{}
 	query.Set("name", name)
 	path := "/v0/management/auth-files?" + query.Encode()
-	_, code, err := c.doRequest("DELETE", path, nil)
+	data, code, err := c.doRequest("DELETE", path, nil)
 	if err != nil {
 		return err
 	}
+	if code == http.StatusForbidden {
+		return statusError(code, data)
+	}
 	if code >= 400 {
 		return fmt.Errorf("delete failed (HTTP %d)", code)
 	}
@@ -240,7 +270,7 @@ func (c *Client) GetLogs(after int64, limit int) ([]string, int64, error) {
 }
 
 // GetAPIKeys fetches the list of API keys.
-// API returns {"api-keys": [...]}.
+// API returns {"api-keys": [...]}; entries are plain strings or objects with a "key" field.
 func (c *Client) GetAPIKeys() ([]string, error) {
 	wrapper, err := c.getJSON("/v0/management/api-keys")
 	if err != nil {
@@ -254,10 +284,25 @@ func (c *Client) GetAPIKeys() ([]string, error) {
 	if err != nil {
 		return nil, err
 	}
-	var result []string
-	if err := json.Unmarshal(raw, &result); err != nil {
+	var entries []json.RawMessage
+	if err := json.Unmarshal(raw, &entries); err != nil {
 		return nil, err
 	}
+	result := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		var key string
+		if err := json.Unmarshal(entry, &key); err == nil {
+			result = append(result, key)
+			continue
+		}
+		var obj struct {
+			Key string `json:"key"`
+		}
+		if err := json.Unmarshal(entry, &obj); err != nil {
+			return nil, err
+		}
+		result = append(result, obj.Key)
+	}
 	return result, nil
 }
 
@@ -279,10 +324,13 @@ func (c *Client) EditAPIKey(index int, newValue string) error {
 
 // DeleteAPIKey deletes an API key by index.
 func (c *Client) DeleteAPIKey(index int) error {
-	_, code, err := c.doRequest("DELETE", fmt.Sprintf("/v0/management/api-keys?index=%d", index), nil)
+	data, code, err := c.doRequest("DELETE", fmt.Sprintf("/v0/management/api-keys?index=%d", index), nil)
 	if err != nil {
 		return err
 	}
+	if code == http.StatusForbidden {
+		return statusError(code, data)
+	}
 	if code >= 400 {
 		return fmt.Errorf("delete failed (HTTP %d)", code)
 	}
@@ -395,6 +443,12 @@ func (c *Client) PutStringField(path string, value string) error {
 
 // DeleteField sends a DELETE request for a config field.
 func (c *Client) DeleteField(path string) error {
-	_, _, err := c.doRequest("DELETE", "/v0/management/"+path, nil)
-	return err
+	data, code, err := c.doRequest("DELETE", "/v0/management/"+path, nil)
+	if err != nil {
+		return err
+	}
+	if code == http.StatusForbidden {
+		return statusError(code, data)
+	}
+	return nil
 }
@@ -82,7 +82,7 @@ func (m configTabModel) Update(msg tea.Msg) (configTabModel, tea.Cmd) {
 
 	case configUpdateMsg:
 		if msg.err != nil {
-			m.message = errorStyle.Render("✗ " + msg.err.Error())
+			m.message = errorStyle.Render("✗ " + errorText(msg.err))
 		} else {
 			m.message = successStyle.Render(T("updated_ok"))
 		}
@@ -263,7 +263,7 @@ func (m configTabModel) renderContent() string {
 	sb.WriteString("\n\n")
 
 	if m.err != nil {
-		sb.WriteString(errorStyle.Render("  ⚠ Error: " + m.err.Error()))
+		sb.WriteString(errorStyle.Render("  ⚠ Error: " + errorText(m.err)))
 		return sb.String()
 	}
 
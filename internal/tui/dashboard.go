@@ -51,12 +51,22 @@ func (m dashboardModel) fetchData() tea.Msg {
 	authFiles, authErr := m.client.GetAuthFiles()
 	apiKeys, keysErr := m.client.GetAPIKeys()
 
-	var err error
+	// Sections the management key may not read are left empty; the error is only shown
+	// when nothing could be read.
+	var err, forbidden error
 	for _, e := range []error{cfgErr, usageErr, authErr, keysErr} {
-		if e != nil {
-			err = e
-			break
+		if e == nil {
+			continue
 		}
+		if isForbidden(e) {
+			forbidden = e
+			continue
+		}
+		err = e
+		break
+	}
+	if err == nil && cfgErr != nil && usageErr != nil && authErr != nil && keysErr != nil {
+		err = forbidden
 	}
 	return dashboardDataMsg{config: cfg, usage: usage, authFiles: authFiles, apiKeys: apiKeys, err: err}
 }
@@ -73,7 +83,7 @@ func (m dashboardModel) Update(msg tea.Msg) (dashboardModel, tea.Cmd) {
 	case dashboardDataMsg:
 		if msg.err != nil {
 			m.err = msg.err
-			m.content = errorStyle.Render("⚠ Error: " + msg.err.Error())
+			m.content = errorStyle.Render("⚠ Error: " + errorText(msg.err))
 		} else {
 			m.err = nil
 			// Cache data for locale switching
@@ -63,24 +63,25 @@ func TabNames() []string {
 
 var zhStrings = map[string]string{
 	// ── Common ──
-	"loading":      "加载中...",
-	"refresh":      "刷新",
-	"save":         "保存",
-	"cancel":       "取消",
-	"confirm":      "确认",
-	"yes":          "是",
-	"no":           "否",
-	"error":        "错误",
-	"success":      "成功",
-	"navigate":     "导航",
-	"scroll":       "滚动",
-	"enter_save":   "Enter: 保存",
-	"esc_cancel":   "Esc: 取消",
-	"enter_submit": "Enter: 提交",
-	"press_r":      "[r] 刷新",
-	"press_scroll": "[↑↓] 滚动",
-	"not_set":      "(未设置)",
-	"error_prefix": "⚠ 错误: ",
+	"loading":           "加载中...",
+	"refresh":           "刷新",
+	"save":              "保存",
+	"cancel":            "取消",
+	"confirm":           "确认",
+	"yes":               "是",
+	"no":                "否",
+	"error":             "错误",
+	"success":           "成功",
+	"navigate":          "导航",
+	"scroll":            "滚动",
+	"enter_save":        "Enter: 保存",
+	"esc_cancel":        "Esc: 取消",
+	"enter_submit":      "Enter: 提交",
+	"press_r":           "[r] 刷新",
+	"press_scroll":      "[↑↓] 滚动",
+	"not_set":           "(未设置)",
+	"error_prefix":      "⚠ 错误: ",
+	"permission_denied": "权限不足：当前管理密钥的角色不允许此操作",
 
 	// ── Status bar ──
 	"status_left":                 " CLIProxyAPI 管理终端",
@@ -201,6 +202,12 @@ var zhStrings = map[string]string{
 	"usage_output":        "输出",
 	"usage_cached":        "缓存",
 	"usage_reasoning":     "思考",
+	"usage_cache_write":   "缓存写入",
+	"usage_cost":          "费用",
+	"usage_budgets":       "预算",
+	"usage_budget_today":  "今日",
+	"usage_budget_month":  "本月",
+	"usage_budget_over":   "已超出",
 
 	// ── Logs ──
 	"logs_title":       "📋 日志",
@@ -214,24 +221,25 @@ var zhStrings = map[string]string{
 
 var enStrings = map[string]string{
 	// ── Common ──
-	"loading":      "Loading...",
-	"refresh":      "Refresh",
-	"save":         "Save",
-	"cancel":       "Cancel",
-	"confirm":      "Confirm",
-	"yes":          "Yes",
-	"no":           "No",
-	"error":        "Error",
-	"success":      "Success",
-	"navigate":     "Navigate",
-	"scroll":       "Scroll",
-	"enter_save":   "Enter: Save",
-	"esc_cancel":   "Esc: Cancel",
-	"enter_submit": "Enter: Submit",
-	"press_r":      "[r] Refresh",
-	"press_scroll": "[↑↓] Scroll",
-	"not_set":      "(not set)",
-	"error_prefix": "⚠ Error: ",
+	"loading":           "Loading...",
+	"refresh":           "Refresh",
+	"save":              "Save",
+	"cancel":            "Cancel",
+	"confirm":           "Confirm",
+	"yes":               "Yes",
+	"no":                "No",
+	"error":             "Error",
+	"success":           "Success",
+	"navigate":          "Navigate",
+	"scroll":            "Scroll",
+	"enter_save":        "Enter: Save",
+	"esc_cancel":        "Esc: Cancel",
+	"enter_submit":      "Enter: Submit",
+	"press_r":           "[r] Refresh",
+	"press_scroll":      "[↑↓] Scroll",
+	"not_set":           "(not set)",
+	"error_prefix":      "⚠ Error: ",
+	"permission_denied": "Permission denied: the roles of this management key do not allow this action",
 
 	// ── Status bar ──
 	"status_left":                 " CLIProxyAPI Management TUI",
@@ -352,6 +360,12 @@ var enStrings = map[string]string{
 	"usage_output":        "Output",
 	"usage_cached":        "Cached",
 	"usage_reasoning":     "Reasoning",
+	"usage_cache_write":   "Cache Write",
+	"usage_cost":          "Cost",
+	"usage_budgets":       "Budgets",
+	"usage_budget_today":  "Today",
+	"usage_budget_month":  "This Month",
+	"usage_budget_over":   "exceeded",
 
 	// ── Logs ──
 	"logs_title":       "📋 Logs",
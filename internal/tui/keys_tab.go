@@ -107,7 +107,7 @@ func (m keysTabModel) Update(msg tea.Msg) (keysTabModel, tea.Cmd) {
 
 	case keyActionMsg:
 		if msg.err != nil {
-			m.status = errorStyle.Render("✗ " + msg.err.Error())
+			m.status = errorStyle.Render("✗ " + errorText(msg.err))
 		} else {
 			m.status = successStyle.Render("✓ " + msg.action)
 		}
@@ -286,7 +286,7 @@ func (m keysTabModel) renderContent() string {
 	sb.WriteString("\n")
 
 	if m.err != nil {
-		sb.WriteString(errorStyle.Render(T("error_prefix") + m.err.Error()))
+		sb.WriteString(errorStyle.Render(T("error_prefix") + errorText(m.err)))
 		sb.WriteString("\n")
 		return sb.String()
 	}
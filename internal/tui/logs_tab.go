@@ -210,7 +210,7 @@ func (m logsTabModel) renderLogs() string {
 	sb.WriteString("\n")
 
 	if m.lastErr != nil {
-		sb.WriteString(errorStyle.Render("⚠ Error: " + m.lastErr.Error()))
+		sb.WriteString(errorStyle.Render("⚠ Error: " + errorText(m.lastErr)))
 		sb.WriteString("\n")
 	}
 
@@ -100,7 +100,7 @@ func (m oauthTabModel) Update(msg tea.Msg) (oauthTabModel, tea.Cmd) {
 		if msg.err != nil {
 			m.state = oauthError
 			m.err = msg.err
-			m.message = errorStyle.Render("✗ " + msg.err.Error())
+			m.message = errorStyle.Render("✗ " + errorText(msg.err))
 			m.viewport.SetContent(m.renderContent())
 			return m, nil
 		}
@@ -120,7 +120,7 @@ func (m oauthTabModel) Update(msg tea.Msg) (oauthTabModel, tea.Cmd) {
 		if msg.err != nil {
 			m.state = oauthError
 			m.err = msg.err
-			m.message = errorStyle.Render("✗ " + msg.err.Error())
+			m.message = errorStyle.Render("✗ " + errorText(msg.err))
 			m.inputActive = false
 			m.callbackInput.Blur()
 		} else if msg.done {
@@ -136,7 +136,7 @@ func (m oauthTabModel) Update(msg tea.Msg) (oauthTabModel, tea.Cmd) {
 
 	case oauthCallbackSubmitMsg:
 		if msg.err != nil {
-			m.message = errorStyle.Render(T("oauth_submit_fail") + ": " + msg.err.Error())
+			m.message = errorStyle.Render(T("oauth_submit_fail") + ": " + errorText(msg.err))
 		} else {
 			m.message = successStyle.Render(T("oauth_submit_ok"))
 		}
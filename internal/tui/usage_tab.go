@@ -99,7 +99,7 @@ func (m usageTabModel) renderContent() string {
 	sb.WriteString("\n\n")
 
 	if m.err != nil {
-		sb.WriteString(errorStyle.Render("⚠ Error: " + m.err.Error()))
+		sb.WriteString(errorStyle.Render("⚠ Error: " + errorText(m.err)))
 		sb.WriteString("\n")
 		return sb.String()
 	}
@@ -121,6 +121,7 @@ func (m usageTabModel) renderContent() string {
 	successCnt := int64(getFloat(usageMap, "success_count"))
 	failureCnt := int64(getFloat(usageMap, "failure_count"))
 	totalTokens := int64(getFloat(usageMap, "total_tokens"))
+	totalCost := getFloat(usageMap, "total_cost")
 
 	// ━━━ Overview Cards ━━━
 	cardWidth := 20
@@ -150,7 +151,7 @@ func (m usageTabModel) renderContent() string {
 		"%s\n%s\n%s",
 		lipgloss.NewStyle().Foreground(colorMuted).Render(T("usage_total_tokens")),
 		lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("214")).Render(formatLargeNumber(totalTokens)),
-		lipgloss.NewStyle().Foreground(colorMuted).Render(fmt.Sprintf("%s: %s", T("usage_total_token_l"), formatLargeNumber(totalTokens))),
+		lipgloss.NewStyle().Foreground(colorMuted).Render(fmt.Sprintf("%s: %s", T("usage_cost"), formatCost(totalCost))),
 	))
 
 	// RPM
@@ -221,7 +222,7 @@ func (m usageTabModel) renderContent() string {
 		sb.WriteString(strings.Repeat("─", minInt(m.width, 80)))
 		sb.WriteString("\n")
 
-		header := fmt.Sprintf("  %-30s %10s %12s", "API", T("requests"), T("tokens"))
+		header := fmt.Sprintf("  %-30s %10s %12s %10s", "API", T("requests"), T("tokens"), T("usage_cost"))
 		sb.WriteString(tableHeaderStyle.Render(header))
 		sb.WriteString("\n")
 
@@ -229,9 +230,10 @@ func (m usageTabModel) renderContent() string {
 			if apiMap, ok := apiSnap.(map[string]any); ok {
 				apiReqs := int64(getFloat(apiMap, "total_requests"))
 				apiToks := int64(getFloat(apiMap, "total_tokens"))
+				apiCost := getFloat(apiMap, "total_cost")
 
-				row := fmt.Sprintf("  %-30s %10d %12s",
-					truncate(maskKey(apiName), 30), apiReqs, formatLargeNumber(apiToks))
+				row := fmt.Sprintf("  %-30s %10d %12s %10s",
+					truncate(maskKey(apiName), 30), apiReqs, formatLargeNumber(apiToks), formatCost(apiCost))
 				sb.WriteString(lipgloss.NewStyle().Bold(true).Render(row))
 				sb.WriteString("\n")
 
@@ -241,8 +243,9 @@ func (m usageTabModel) renderContent() string {
 						if stats, ok := v.(map[string]any); ok {
 							mReqs := int64(getFloat(stats, "total_requests"))
 							mToks := int64(getFloat(stats, "total_tokens"))
-							mRow := fmt.Sprintf("    ├─ %-28s %10d %12s",
-								truncate(model, 28), mReqs, formatLargeNumber(mToks))
+							mCost := getFloat(stats, "total_cost")
+							mRow := fmt.Sprintf("    ├─ %-28s %10d %12s %10s",
+								truncate(model, 28), mReqs, formatLargeNumber(mToks), formatCost(mCost))
 							sb.WriteString(tableCellStyle.Render(mRow))
 							sb.WriteString("\n")
 
@@ -255,10 +258,57 @@ func (m usageTabModel) renderContent() string {
 		}
 	}
 
+	// ━━━ Budgets ━━━
+	if budgets, ok := m.usage["budgets"].([]any); ok && len(budgets) > 0 {
+		sb.WriteString(lipgloss.NewStyle().Bold(true).Foreground(colorHighlight).Render(T("usage_budgets")))
+		sb.WriteString("\n")
+		sb.WriteString(strings.Repeat("─", minInt(m.width, 80)))
+		sb.WriteString("\n")
+
+		header := fmt.Sprintf("  %-30s %21s %21s", "API", T("usage_budget_today"), T("usage_budget_month"))
+		sb.WriteString(tableHeaderStyle.Render(header))
+		sb.WriteString("\n")
+
+		for _, b := range budgets {
+			budget, ok := b.(map[string]any)
+			if !ok {
+				continue
+			}
+			apiKey, _ := budget["api-key"].(string)
+			row := fmt.Sprintf("  %-30s %21s %21s",
+				truncate(maskKey(apiKey), 30),
+				formatBudget(getFloat(budget, "spent-today"), getFloat(budget, "usd-per-day")),
+				formatBudget(getFloat(budget, "spent-this-month"), getFloat(budget, "usd-per-month")))
+			if exceeded, _ := budget["exceeded"].(bool); exceeded {
+				sb.WriteString(errorStyle.Render(row + "  " + T("usage_budget_over")))
+			} else {
+				sb.WriteString(tableCellStyle.Render(row))
+			}
+			sb.WriteString("\n")
+		}
+	}
+
 	sb.WriteString("\n")
 	return sb.String()
 }
 
+// formatCost renders a USD amount, keeping cents for small totals visible.
+func formatCost(usd float64) string {
+	if usd > 0 && usd < 0.01 {
+		return fmt.Sprintf("$%.4f", usd)
+	}
+	return fmt.Sprintf("$%.2f", usd)
+}
+
+// formatBudget renders the spend of a period against its limit; periods without a limit
+// only show the spend.
+func formatBudget(spent, limit float64) string {
+	if limit <= 0 {
+		return formatCost(spent)
+	}
+	return formatCost(spent) + " / " + formatCost(limit)
+}
+
 // renderTokenBreakdown aggregates input/output/cached/reasoning tokens from model details.
 func (m usageTabModel) renderTokenBreakdown(modelStats map[string]any) string {
 	details, ok := modelStats["details"]
@@ -270,7 +320,7 @@ func (m usageTabModel) renderTokenBreakdown(modelStats map[string]any) string {
 		return ""
 	}
 
-	var inputTotal, outputTotal, cachedTotal, reasoningTotal int64
+	var inputTotal, outputTotal, cachedTotal, cacheWriteTotal, reasoningTotal int64
 	for _, d := range detailList {
 		dm, ok := d.(map[string]any)
 		if !ok {
@@ -283,10 +333,11 @@ func (m usageTabModel) renderTokenBreakdown(modelStats map[string]any) string {
 		inputTotal += int64(getFloat(tokens, "input_tokens"))
 		outputTotal += int64(getFloat(tokens, "output_tokens"))
 		cachedTotal += int64(getFloat(tokens, "cached_tokens"))
+		cacheWriteTotal += int64(getFloat(tokens, "cache_write_tokens"))
 		reasoningTotal += int64(getFloat(tokens, "reasoning_tokens"))
 	}
 
-	if inputTotal == 0 && outputTotal == 0 && cachedTotal == 0 && reasoningTotal == 0 {
+	if inputTotal == 0 && outputTotal == 0 && cachedTotal == 0 && cacheWriteTotal == 0 && reasoningTotal == 0 {
 		return ""
 	}
 
@@ -300,6 +351,9 @@ func (m usageTabModel) renderTokenBreakdown(modelStats map[string]any) string {
 	if cachedTotal > 0 {
 		parts = append(parts, fmt.Sprintf("%s:%s", T("usage_cached"), formatLargeNumber(cachedTotal)))
 	}
+	if cacheWriteTotal > 0 {
+		parts = append(parts, fmt.Sprintf("%s:%s", T("usage_cache_write"), formatLargeNumber(cacheWriteTotal)))
+	}
 	if reasoningTotal > 0 {
 		parts = append(parts, fmt.Sprintf("%s:%s", T("usage_reasoning"), formatLargeNumber(reasoningTotal)))
 	}
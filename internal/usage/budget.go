@@ -0,0 +1,304 @@
+package usage
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+	log "github.com/sirupsen/logrus"
+)
+
+// BudgetWildcard applies a budget to every client key separately.
+const BudgetWildcard = "*"
+
+// Budget periods reported in alerts and statuses.
+const (
+	BudgetPeriodDay   = "day"
+	BudgetPeriodMonth = "month"
+)
+
+// BudgetAlert describes a client key whose spend crossed a budget threshold.
+type BudgetAlert struct {
+	APIKey string
+	Period string
+	// Limit is the budget of the period in USD.
+	Limit float64
+	// Spent is the spend of the period in USD when the threshold was crossed.
+	Spent float64
+	// Exceeded is true when Limit was crossed and false for the warn-percent threshold.
+	Exceeded bool
+}
+
+// BudgetStatus reports the spend of one client key against its budget.
+type BudgetStatus struct {
+	APIKey         string  `json:"api-key"`
+	SpentToday     float64 `json:"spent-today"`
+	SpentThisMonth float64 `json:"spent-this-month"`
+	USDPerDay      float64 `json:"usd-per-day,omitempty"`
+	USDPerMonth    float64 `json:"usd-per-month,omitempty"`
+	Exceeded       bool    `json:"exceeded"`
+}
+
+type budgetState struct {
+	day        string
+	daySpend   float64
+	month      string
+	monthSpend float64
+	// dayLevel and monthLevel record the highest threshold already alerted for the
+	// current period: 0 none, 1 warn-percent, 2 limit.
+	dayLevel   int
+	monthLevel int
+}
+
+// BudgetTracker charges the cost of usage records to client keys and alerts once per
+// period and threshold when a key crosses its budget. Spend survives configuration reloads.
+type BudgetTracker struct {
+	mu       sync.Mutex
+	budgets  []config.CostBudget
+	keys     map[string]*budgetState
+	handlers []func(BudgetAlert)
+	now      func() time.Time
+}
+
+var defaultBudgets = NewBudgetTracker()
+
+func init() {
+	coreusage.RegisterPlugin(defaultBudgets)
+}
+
+// NewBudgetTracker constructs a tracker without budgets.
+func NewBudgetTracker() *BudgetTracker {
+	return &BudgetTracker{keys: make(map[string]*budgetState), now: time.Now}
+}
+
+// GetBudgetTracker returns the shared tracker fed by the usage pipeline.
+func GetBudgetTracker() *BudgetTracker { return defaultBudgets }
+
+// SetBudgets replaces the configured budgets.
+func (t *BudgetTracker) SetBudgets(budgets []config.CostBudget) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.budgets = append([]config.CostBudget(nil), budgets...)
+}
+
+// OnAlert registers fn to receive budget alerts in addition to the warning log.
+// fn runs synchronously on the usage pipeline and must not block.
+func (t *BudgetTracker) OnAlert(fn func(BudgetAlert)) {
+	if t == nil || fn == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.handlers = append(t.handlers, fn)
+}
+
+// HandleUsage implements coreusage.Plugin by charging the cost of record to its client key.
+func (t *BudgetTracker) HandleUsage(ctx context.Context, record coreusage.Record) {
+	if t == nil || record.APIKey == "" {
+		return
+	}
+	stored := newStoredRecord(ctx, record)
+	t.Charge(record.APIKey, stored.Timestamp, stored.Cost)
+}
+
+// Charge adds cost spent at the given time to key and raises the alerts it triggers.
+func (t *BudgetTracker) Charge(key string, at time.Time, cost float64) {
+	if t == nil || key == "" || cost <= 0 {
+		return
+	}
+	now := t.now().UTC()
+	at = at.UTC()
+	t.mu.Lock()
+	state := t.stateLocked(key, now)
+	if at.Format("2006-01") == state.month {
+		state.monthSpend += cost
+		if at.Format("2006-01-02") == state.day {
+			state.daySpend += cost
+		}
+	}
+	var alerts []BudgetAlert
+	if budget, ok := t.budgetLocked(key); ok {
+		if alert, ok := crossed(key, BudgetPeriodDay, budget.USDPerDay, budget.WarnPercent, state.daySpend, &state.dayLevel); ok {
+			alerts = append(alerts, alert)
+		}
+		if alert, ok := crossed(key, BudgetPeriodMonth, budget.USDPerMonth, budget.WarnPercent, state.monthSpend, &state.monthLevel); ok {
+			alerts = append(alerts, alert)
+		}
+	}
+	handlers := t.handlers
+	t.mu.Unlock()
+
+	for _, alert := range alerts {
+		fields := log.Fields{
+			"api_key": util.HideAPIKey(alert.APIKey),
+			"period":  alert.Period,
+			"limit":   alert.Limit,
+			"spent":   alert.Spent,
+		}
+		if alert.Exceeded {
+			log.WithFields(fields).Warn("budget exceeded")
+		} else {
+			log.WithFields(fields).Warn("budget threshold reached")
+		}
+		for _, handler := range handlers {
+			handler(alert)
+		}
+	}
+}
+
+// crossed reports the highest threshold of limit reached by spent when it was not alerted
+// yet in the period, and records it in level.
+func crossed(key, period string, limit float64, warnPercent int, spent float64, level *int) (BudgetAlert, bool) {
+	if limit <= 0 {
+		return BudgetAlert{}, false
+	}
+	reached := 0
+	switch {
+	case spent >= limit:
+		reached = 2
+	case warnPercent > 0 && spent >= limit*float64(warnPercent)/100:
+		reached = 1
+	}
+	if reached <= *level {
+		return BudgetAlert{}, false
+	}
+	*level = reached
+	return BudgetAlert{APIKey: key, Period: period, Limit: limit, Spent: spent, Exceeded: reached == 2}, true
+}
+
+// Restore seeds the spend counters with month-to-date costs from a persistent store, so
+// budgets are not reset by a restart. Counters only ever grow, and thresholds already
+// crossed by restored spend are not alerted again. names maps the key names recorded for
+// named client keys back to the keys themselves.
+func (t *BudgetTracker) Restore(ctx context.Context, store Store, names map[string]string) error {
+	if t == nil || store == nil {
+		return nil
+	}
+	now := t.now().UTC()
+	records, err := store.Query(ctx, QueryFilter{From: time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)})
+	if err != nil {
+		return err
+	}
+	today := now.Format("2006-01-02")
+	monthTotals := make(map[string]float64)
+	dayTotals := make(map[string]float64)
+	for _, record := range records {
+		key := record.APIKey
+		if mapped, ok := names[key]; ok {
+			key = mapped
+		}
+		cost := record.Cost
+		if cost == 0 {
+			cost = recordCost(record.Provider, record.Model, record.Tokens)
+		}
+		if key == "" || cost <= 0 {
+			continue
+		}
+		monthTotals[key] += cost
+		if record.Timestamp.UTC().Format("2006-01-02") == today {
+			dayTotals[key] += cost
+		}
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for key, total := range monthTotals {
+		state := t.stateLocked(key, now)
+		state.monthSpend = max(state.monthSpend, total)
+		state.daySpend = max(state.daySpend, dayTotals[key])
+		if budget, ok := t.budgetLocked(key); ok {
+			crossed(key, BudgetPeriodDay, budget.USDPerDay, budget.WarnPercent, state.daySpend, &state.dayLevel)
+			crossed(key, BudgetPeriodMonth, budget.USDPerMonth, budget.WarnPercent, state.monthSpend, &state.monthLevel)
+		}
+	}
+	return nil
+}
+
+// Status reports the spend of every key with a budget, sorted by key. Keys covered by the
+// wildcard budget are listed once they have spent something.
+func (t *BudgetTracker) Status() []BudgetStatus {
+	out := make([]BudgetStatus, 0)
+	if t == nil {
+		return out
+	}
+	now := t.now().UTC()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	keys := make(map[string]struct{})
+	wildcard := false
+	for _, budget := range t.budgets {
+		if budget.APIKey == BudgetWildcard {
+			wildcard = true
+			continue
+		}
+		keys[budget.APIKey] = struct{}{}
+	}
+	if wildcard {
+		for key := range t.keys {
+			keys[key] = struct{}{}
+		}
+	}
+	for key := range keys {
+		budget, ok := t.budgetLocked(key)
+		if !ok {
+			continue
+		}
+		state := t.stateLocked(key, now)
+		out = append(out, BudgetStatus{
+			APIKey:         key,
+			SpentToday:     state.daySpend,
+			SpentThisMonth: state.monthSpend,
+			USDPerDay:      budget.USDPerDay,
+			USDPerMonth:    budget.USDPerMonth,
+			Exceeded: (budget.USDPerDay > 0 && state.daySpend >= budget.USDPerDay) ||
+				(budget.USDPerMonth > 0 && state.monthSpend >= budget.USDPerMonth),
+		})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].APIKey < out[j].APIKey })
+	return out
+}
+
+// budgetLocked returns the budget of key, preferring an entry for the key itself over the
+// wildcard entry. Callers must hold t.mu.
+func (t *BudgetTracker) budgetLocked(key string) (config.CostBudget, bool) {
+	var fallback *config.CostBudget
+	for i := range t.budgets {
+		switch t.budgets[i].APIKey {
+		case key:
+			return t.budgets[i], true
+		case BudgetWildcard:
+			fallback = &t.budgets[i]
+		}
+	}
+	if fallback == nil {
+		return config.CostBudget{}, false
+	}
+	return *fallback, true
+}
+
+// stateLocked returns the state for key with expired periods reset. Callers must hold t.mu.
+func (t *BudgetTracker) stateLocked(key string, now time.Time) *budgetState {
+	state, ok := t.keys[key]
+	if !ok {
+		state = &budgetState{}
+		t.keys[key] = state
+	}
+	if day := now.Format("2006-01-02"); state.day != day {
+		state.day = day
+		state.daySpend = 0
+		state.dayLevel = 0
+	}
+	if month := now.Format("2006-01"); state.month != month {
+		state.month = month
+		state.monthSpend = 0
+		state.monthLevel = 0
+	}
+	return state
+}
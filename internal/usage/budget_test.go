@@ -0,0 +1,92 @@
+package usage
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/pricing"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+)
+
+func TestBudgetTrackerAlertsOncePerThreshold(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	tracker := NewBudgetTracker()
+	tracker.now = func() time.Time { return now }
+	tracker.SetBudgets([]config.CostBudget{
+		{APIKey: "*", USDPerMonth: 100},
+		{APIKey: "team", USDPerDay: 10, WarnPercent: 80},
+	})
+	var alerts []BudgetAlert
+	tracker.OnAlert(func(alert BudgetAlert) { alerts = append(alerts, alert) })
+
+	tracker.Charge("team", now, 7)
+	tracker.Charge("team", now, 1)   // 8 reaches the 80% warning
+	tracker.Charge("team", now, 0.5) // already warned
+	tracker.Charge("team", now, 2)   // 10.5 exceeds the daily limit
+	tracker.Charge("team", now, 3)   // already exceeded
+	tracker.Charge("other", now, 120)
+	tracker.Charge("other", now.AddDate(0, -1, 0), 50) // previous month is not charged
+
+	want := []BudgetAlert{
+		{APIKey: "team", Period: BudgetPeriodDay, Limit: 10, Spent: 8},
+		{APIKey: "team", Period: BudgetPeriodDay, Limit: 10, Spent: 10.5, Exceeded: true},
+		{APIKey: "other", Period: BudgetPeriodMonth, Limit: 100, Spent: 120, Exceeded: true},
+	}
+	if len(alerts) != len(want) {
+		t.Fatalf("alerts = %+v, want %+v", alerts, want)
+	}
+	for i := range want {
+		if alerts[i] != want[i] {
+			t.Fatalf("alert #%d = %+v, want %+v", i, alerts[i], want[i])
+		}
+	}
+
+	status := tracker.Status()
+	if len(status) != 2 || status[0].APIKey != "other" || !status[0].Exceeded || status[1].APIKey != "team" || status[1].SpentToday != 13.5 {
+		t.Fatalf("Status() = %+v, want other exceeded and team at 13.5 today", status)
+	}
+
+	now = now.AddDate(0, 0, 1)
+	tracker.Charge("team", now, 9)
+	if len(alerts) != len(want)+1 || alerts[len(alerts)-1].Spent != 9 {
+		t.Fatalf("alerts after day rollover = %+v, want a new warning at 9", alerts)
+	}
+}
+
+func TestRequestStatisticsRecordsCost(t *testing.T) {
+	table, err := pricing.New(config.PricingConfig{DisableCatalog: true, Models: []config.ModelPrice{
+		{Model: "priced", Input: 2, Output: 8},
+	}})
+	if err != nil {
+		t.Fatalf("pricing.New: %v", err)
+	}
+	previous := pricing.Default()
+	pricing.SetDefault(table)
+	defer pricing.SetDefault(previous)
+
+	stats := NewRequestStatistics()
+	ctx := context.Background()
+	stats.Record(ctx, coreusage.Record{APIKey: "k", Provider: "codex", Model: "priced", Detail: coreusage.Detail{InputTokens: 500_000, OutputTokens: 250_000}})
+	stats.Record(ctx, coreusage.Record{APIKey: "k", Provider: "codex", Model: "unpriced", Detail: coreusage.Detail{InputTokens: 10}})
+
+	snapshot := stats.Snapshot()
+	if math.Abs(snapshot.TotalCost-3) > 1e-9 {
+		t.Fatalf("TotalCost = %v, want 3", snapshot.TotalCost)
+	}
+	api := snapshot.APIs["k"]
+	if math.Abs(api.TotalCost-3) > 1e-9 || api.Models["unpriced"].TotalCost != 0 {
+		t.Fatalf("API snapshot = %+v, want the cost on the priced model only", api)
+	}
+	if details := api.Models["priced"].Details; len(details) != 1 || math.Abs(details[0].Cost-3) > 1e-9 {
+		t.Fatalf("priced details = %+v, want one request costing 3", details)
+	}
+
+	merged := NewRequestStatistics()
+	merged.MergeSnapshot(snapshot)
+	if got := merged.Snapshot().TotalCost; math.Abs(got-3) > 1e-9 {
+		t.Fatalf("merged TotalCost = %v, want 3", got)
+	}
+}
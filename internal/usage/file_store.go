@@ -0,0 +1,204 @@
+package usage
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	fileStorePrefix = "usage-"
+	fileStoreSuffix = ".jsonl"
+	fileStoreLayout = "2006-01-02"
+)
+
+// FileStore persists usage records as JSON lines partitioned into one file per UTC day.
+// Day partitioning keeps appends cheap and lets range queries skip unrelated files.
+type FileStore struct {
+	dir string
+
+	mu      sync.Mutex
+	current *os.File
+	day     string
+}
+
+// NewFileStore prepares dir for usage persistence.
+func NewFileStore(dir string) (*FileStore, error) {
+	dir = strings.TrimSpace(dir)
+	if dir == "" {
+		return nil, fmt.Errorf("usage file store: directory is required")
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("usage file store: create directory: %w", err)
+	}
+	return &FileStore{dir: dir}, nil
+}
+
+// Dir returns the directory holding the partition files.
+func (s *FileStore) Dir() string {
+	if s == nil {
+		return ""
+	}
+	return s.dir
+}
+
+// Append implements Store.
+func (s *FileStore) Append(_ context.Context, record StoredRecord) error {
+	if s == nil {
+		return fmt.Errorf("usage file store: not initialized")
+	}
+	if record.Timestamp.IsZero() {
+		record.Timestamp = time.Now()
+	}
+	line, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("usage file store: encode record: %w", err)
+	}
+	line = append(line, '\n')
+	day := record.Timestamp.UTC().Format(fileStoreLayout)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.current == nil || s.day != day {
+		if s.current != nil {
+			_ = s.current.Close()
+			s.current = nil
+		}
+		f, errOpen := os.OpenFile(s.partitionPath(day), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+		if errOpen != nil {
+			return fmt.Errorf("usage file store: open partition: %w", errOpen)
+		}
+		s.current = f
+		s.day = day
+	}
+	if _, err = s.current.Write(line); err != nil {
+		return fmt.Errorf("usage file store: write record: %w", err)
+	}
+	return nil
+}
+
+// Query implements Store.
+func (s *FileStore) Query(ctx context.Context, filter QueryFilter) ([]StoredRecord, error) {
+	if s == nil {
+		return nil, fmt.Errorf("usage file store: not initialized")
+	}
+	days, err := s.partitionDays()
+	if err != nil {
+		return nil, err
+	}
+	fromDay, toDay := "", ""
+	if !filter.From.IsZero() {
+		fromDay = filter.From.UTC().Format(fileStoreLayout)
+	}
+	if !filter.To.IsZero() {
+		toDay = filter.To.UTC().Format(fileStoreLayout)
+	}
+
+	// Partitions are read through their own handles without s.mu, so appends continue
+	// while a query scans. A line being appended concurrently is skipped as torn.
+	out := make([]StoredRecord, 0)
+	for _, day := range days {
+		if fromDay != "" && day < fromDay {
+			continue
+		}
+		if toDay != "" && day > toDay {
+			continue
+		}
+		if errCtx := ctx.Err(); errCtx != nil {
+			return nil, errCtx
+		}
+		records, errRead := readPartition(s.partitionPath(day), filter)
+		if errRead != nil {
+			return nil, errRead
+		}
+		out = append(out, records...)
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Timestamp.Before(out[j].Timestamp) })
+	return out, nil
+}
+
+// Close implements Store.
+func (s *FileStore) Close() error {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.current == nil {
+		return nil
+	}
+	err := s.current.Close()
+	s.current = nil
+	s.day = ""
+	return err
+}
+
+func (s *FileStore) partitionPath(day string) string {
+	return filepath.Join(s.dir, fileStorePrefix+day+fileStoreSuffix)
+}
+
+// partitionDays lists the days that have a partition file, in ascending order.
+func (s *FileStore) partitionDays() ([]string, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("usage file store: list partitions: %w", err)
+	}
+	days := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, fileStorePrefix) || !strings.HasSuffix(name, fileStoreSuffix) {
+			continue
+		}
+		day := strings.TrimSuffix(strings.TrimPrefix(name, fileStorePrefix), fileStoreSuffix)
+		if _, errParse := time.Parse(fileStoreLayout, day); errParse != nil {
+			continue
+		}
+		days = append(days, day)
+	}
+	sort.Strings(days)
+	return days, nil
+}
+
+func readPartition(path string, filter QueryFilter) ([]StoredRecord, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("usage file store: open partition: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	out := make([]StoredRecord, 0)
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var record StoredRecord
+		if errDecode := json.Unmarshal(line, &record); errDecode != nil {
+			// A torn final line after a crash must not make the whole partition unreadable.
+			continue
+		}
+		if filter.Matches(record) {
+			out = append(out, record)
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, fmt.Errorf("usage file store: read partition: %w", err)
+	}
+	return out, nil
+}
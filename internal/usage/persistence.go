@@ -0,0 +1,444 @@
+package usage
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/pricing"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+	log "github.com/sirupsen/logrus"
+)
+
+// Supported aggregation dimensions for persisted usage queries.
+const (
+	GroupByMonth     = "month"
+	GroupByDay       = "day"
+	GroupByHour      = "hour"
+	GroupByAPIKey    = "api_key"
+	GroupByModel     = "model"
+	GroupByAuthIndex = "auth_index"
+	GroupByProvider  = "provider"
+)
+
+// StoredRecord is the durable representation of a single usage record.
+type StoredRecord struct {
+	Timestamp time.Time  `json:"timestamp"`
+	APIKey    string     `json:"api_key"`
+	Provider  string     `json:"provider"`
+	Model     string     `json:"model"`
+	AuthID    string     `json:"auth_id,omitempty"`
+	AuthIndex string     `json:"auth_index"`
+	Source    string     `json:"source"`
+	Failed    bool       `json:"failed"`
+	Tokens    TokenStats `json:"tokens"`
+	// Cost is the price of the request in USD, computed when the record was stored.
+	Cost float64 `json:"cost,omitempty"`
+	// RequestedModel is the model the client asked for when Model served as its fallback.
+	RequestedModel string `json:"requested_model,omitempty"`
+}
+
+// QueryFilter narrows persisted records by time range and identity.
+// Empty string fields match everything; a zero From/To leaves that side unbounded.
+type QueryFilter struct {
+	From      time.Time
+	To        time.Time
+	APIKey    string
+	Model     string
+	AuthIndex string
+	Provider  string
+}
+
+// Matches reports whether record satisfies the filter.
+func (f QueryFilter) Matches(record StoredRecord) bool {
+	if !f.From.IsZero() && record.Timestamp.Before(f.From) {
+		return false
+	}
+	if !f.To.IsZero() && !record.Timestamp.Before(f.To) {
+		return false
+	}
+	if f.APIKey != "" && record.APIKey != f.APIKey {
+		return false
+	}
+	if f.Model != "" && record.Model != f.Model {
+		return false
+	}
+	if f.AuthIndex != "" && record.AuthIndex != f.AuthIndex {
+		return false
+	}
+	if f.Provider != "" && record.Provider != f.Provider {
+		return false
+	}
+	return true
+}
+
+// AggregateQuery groups filtered records by the requested dimensions.
+// Time buckets (month, day, hour) are computed in UTC.
+type AggregateQuery struct {
+	QueryFilter
+	GroupBy []string
+}
+
+// AggregateBucket holds the totals for one combination of group-by keys.
+type AggregateBucket struct {
+	Keys          map[string]string `json:"keys"`
+	TotalRequests int64             `json:"total_requests"`
+	SuccessCount  int64             `json:"success_count"`
+	FailureCount  int64             `json:"failure_count"`
+	Tokens        TokenStats        `json:"tokens"`
+	Cost          float64           `json:"cost"`
+}
+
+// Store persists usage records and answers time-range queries.
+type Store interface {
+	// Append durably stores one record.
+	Append(ctx context.Context, record StoredRecord) error
+	// Query returns the records matching filter ordered by timestamp.
+	Query(ctx context.Context, filter QueryFilter) ([]StoredRecord, error)
+	// Close releases resources held by the store.
+	Close() error
+}
+
+// Aggregator is implemented by stores that can aggregate natively (e.g. in SQL).
+type Aggregator interface {
+	Aggregate(ctx context.Context, query AggregateQuery) ([]AggregateBucket, error)
+}
+
+// Aggregate groups records from store, delegating to the store when it implements Aggregator.
+func Aggregate(ctx context.Context, store Store, query AggregateQuery) ([]AggregateBucket, error) {
+	if aggregator, ok := store.(Aggregator); ok {
+		return aggregator.Aggregate(ctx, query)
+	}
+	records, err := store.Query(ctx, query.QueryFilter)
+	if err != nil {
+		return nil, err
+	}
+	return AggregateRecords(records, query), nil
+}
+
+// AggregateRecords groups in-memory records according to query.
+func AggregateRecords(records []StoredRecord, query AggregateQuery) []AggregateBucket {
+	groupBy := NormalizeGroupBy(query.GroupBy)
+	buckets := make(map[string]*AggregateBucket)
+	order := make([]string, 0)
+	for _, record := range records {
+		if !query.Matches(record) {
+			continue
+		}
+		keys := make(map[string]string, len(groupBy))
+		parts := make([]string, 0, len(groupBy))
+		for _, dimension := range groupBy {
+			value := groupValue(record, dimension)
+			keys[dimension] = value
+			parts = append(parts, value)
+		}
+		id := strings.Join(parts, "\x00")
+		bucket, ok := buckets[id]
+		if !ok {
+			bucket = &AggregateBucket{Keys: keys}
+			buckets[id] = bucket
+			order = append(order, id)
+		}
+		bucket.add(record.Failed, normaliseTokenStats(record.Tokens), record.Cost)
+	}
+	out := make([]AggregateBucket, 0, len(order))
+	for _, id := range order {
+		out = append(out, *buckets[id])
+	}
+	SortBuckets(out, groupBy)
+	return out
+}
+
+// SortBuckets orders buckets by their group-by keys in dimension order.
+func SortBuckets(buckets []AggregateBucket, groupBy []string) {
+	sort.SliceStable(buckets, func(i, j int) bool {
+		for _, dimension := range groupBy {
+			left, right := buckets[i].Keys[dimension], buckets[j].Keys[dimension]
+			if left != right {
+				return left < right
+			}
+		}
+		return false
+	})
+}
+
+// NormalizeGroupBy lowercases, validates and de-duplicates group-by dimensions.
+func NormalizeGroupBy(groupBy []string) []string {
+	seen := make(map[string]struct{}, len(groupBy))
+	out := make([]string, 0, len(groupBy))
+	for _, raw := range groupBy {
+		dimension := strings.ReplaceAll(strings.ToLower(strings.TrimSpace(raw)), "-", "_")
+		switch dimension {
+		case GroupByMonth, GroupByDay, GroupByHour, GroupByAPIKey, GroupByModel, GroupByAuthIndex, GroupByProvider:
+		default:
+			continue
+		}
+		if _, ok := seen[dimension]; ok {
+			continue
+		}
+		seen[dimension] = struct{}{}
+		out = append(out, dimension)
+	}
+	return out
+}
+
+// ValidGroupBy reports whether dimension is a supported aggregation key.
+func ValidGroupBy(dimension string) bool {
+	return len(NormalizeGroupBy([]string{dimension})) == 1
+}
+
+func (b *AggregateBucket) add(failed bool, tokens TokenStats, cost float64) {
+	b.TotalRequests++
+	if failed {
+		b.FailureCount++
+	} else {
+		b.SuccessCount++
+	}
+	b.Tokens.InputTokens += tokens.InputTokens
+	b.Tokens.OutputTokens += tokens.OutputTokens
+	b.Tokens.ReasoningTokens += tokens.ReasoningTokens
+	b.Tokens.CachedTokens += tokens.CachedTokens
+	b.Tokens.CacheWriteTokens += tokens.CacheWriteTokens
+	b.Tokens.TotalTokens += tokens.TotalTokens
+	b.Cost += cost
+}
+
+func groupValue(record StoredRecord, dimension string) string {
+	ts := record.Timestamp.UTC()
+	switch dimension {
+	case GroupByMonth:
+		return ts.Format("2006-01")
+	case GroupByDay:
+		return ts.Format("2006-01-02")
+	case GroupByHour:
+		return ts.Format("2006-01-02T15")
+	case GroupByAPIKey:
+		return record.APIKey
+	case GroupByModel:
+		return record.Model
+	case GroupByAuthIndex:
+		return record.AuthIndex
+	case GroupByProvider:
+		return record.Provider
+	default:
+		return ""
+	}
+}
+
+// newStoredRecord resolves the API identifier, outcome, token totals and cost for record
+// the same way the in-memory statistics do. Named client keys are recorded by name.
+func newStoredRecord(ctx context.Context, record coreusage.Record) StoredRecord {
+	timestamp := record.RequestedAt
+	if timestamp.IsZero() {
+		timestamp = time.Now()
+	}
+	statsKey := resolveAPIKeyName(ctx)
+	if statsKey == "" {
+		statsKey = record.APIKey
+	}
+	if statsKey == "" {
+		statsKey = resolveAPIIdentifier(ctx, record)
+	}
+	failed := record.Failed
+	if !failed {
+		failed = !resolveSuccess(ctx)
+	}
+	modelName := record.Model
+	if modelName == "" {
+		modelName = "unknown"
+	}
+	tokens := normaliseDetail(record.Detail)
+	return StoredRecord{
+		Timestamp:      timestamp,
+		APIKey:         statsKey,
+		Provider:       record.Provider,
+		Model:          modelName,
+		AuthID:         record.AuthID,
+		AuthIndex:      record.AuthIndex,
+		Source:         record.Source,
+		Failed:         failed,
+		Tokens:         tokens,
+		Cost:           recordCost(record.Provider, modelName, tokens),
+		RequestedModel: record.RequestedModel,
+	}
+}
+
+// recordCost prices tokens with the process-wide price table.
+func recordCost(provider, model string, tokens TokenStats) float64 {
+	return pricing.Default().Cost(provider, model, pricing.Tokens{
+		Input:      tokens.InputTokens,
+		Output:     tokens.OutputTokens,
+		Reasoning:  tokens.ReasoningTokens,
+		CacheRead:  tokens.CachedTokens,
+		CacheWrite: tokens.CacheWriteTokens,
+	})
+}
+
+// persistenceQueueSize bounds the records waiting for the store writer; records beyond it
+// are dropped so a slow store never stalls usage dispatch.
+const persistenceQueueSize = 4096
+
+// PersistencePlugin forwards usage records to the active persistent Store.
+// It implements coreusage.Plugin and is a no-op until a store is configured.
+// Appends run on a dedicated writer goroutine so slow stores do not block other plugins.
+type PersistencePlugin struct {
+	mu    sync.RWMutex
+	store Store
+	name  string
+
+	// writeMu is held by the writer while it appends, so SetStore closes a replaced
+	// store only after the append in flight finished.
+	writeMu   sync.Mutex
+	queue     chan StoredRecord
+	startOnce sync.Once
+	dropped   atomic.Int64
+}
+
+var defaultPersistence = &PersistencePlugin{}
+
+func init() {
+	coreusage.RegisterPlugin(defaultPersistence)
+}
+
+// GetPersistence returns the shared persistence plugin.
+func GetPersistence() *PersistencePlugin { return defaultPersistence }
+
+// SetStore swaps the active store, closing the previous one. Passing nil disables persistence.
+func (p *PersistencePlugin) SetStore(name string, store Store) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	previous := p.store
+	p.store = store
+	p.name = name
+	if store == nil {
+		p.name = ""
+	}
+	p.mu.Unlock()
+	if previous != nil && previous != store {
+		p.writeMu.Lock()
+		errClose := previous.Close()
+		p.writeMu.Unlock()
+		if errClose != nil {
+			log.Warnf("usage persistence: failed to close previous store: %v", errClose)
+		}
+	}
+}
+
+// Store returns the active store and its backend name, or nil when persistence is disabled.
+func (p *PersistencePlugin) Store() (Store, string) {
+	if p == nil {
+		return nil, ""
+	}
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.store, p.name
+}
+
+// HandleUsage implements coreusage.Plugin. The record is queued for the writer goroutine.
+func (p *PersistencePlugin) HandleUsage(ctx context.Context, record coreusage.Record) {
+	store, _ := p.Store()
+	if store == nil {
+		return
+	}
+	p.startOnce.Do(func() {
+		p.queue = make(chan StoredRecord, persistenceQueueSize)
+		go p.run()
+	})
+	select {
+	case p.queue <- newStoredRecord(ctx, record):
+	default:
+		if p.dropped.Add(1) == 1 {
+			log.Warn("usage persistence: write queue full, dropping records")
+		}
+	}
+}
+
+// run appends queued records to the store active when each record is dequeued.
+func (p *PersistencePlugin) run() {
+	for stored := range p.queue {
+		if dropped := p.dropped.Swap(0); dropped > 0 {
+			log.Warnf("usage persistence: dropped %d records while the store was busy", dropped)
+		}
+		p.append(stored)
+	}
+}
+
+func (p *PersistencePlugin) append(stored StoredRecord) {
+	p.writeMu.Lock()
+	defer p.writeMu.Unlock()
+	store, _ := p.Store()
+	if store == nil {
+		return
+	}
+	writeCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if errAppend := store.Append(writeCtx, stored); errAppend != nil {
+		log.Warnf("usage persistence: failed to append record: %v", errAppend)
+	}
+}
+
+// Restore loads persisted records from store into the in-memory statistics.
+// Duplicate details already present in memory are skipped.
+func (s *RequestStatistics) Restore(ctx context.Context, store Store, since time.Time) (MergeResult, error) {
+	if s == nil || store == nil {
+		return MergeResult{}, nil
+	}
+	records, err := store.Query(ctx, QueryFilter{From: since})
+	if err != nil {
+		return MergeResult{}, err
+	}
+	snapshot := StatisticsSnapshot{APIs: make(map[string]APISnapshot)}
+	for _, record := range records {
+		if record.Cost == 0 {
+			// Records stored before cost accounting are priced with the current table.
+			record.Cost = recordCost(record.Provider, record.Model, record.Tokens)
+		}
+		apiSnapshot, ok := snapshot.APIs[record.APIKey]
+		if !ok {
+			apiSnapshot = APISnapshot{Models: make(map[string]ModelSnapshot)}
+		}
+		modelSnapshot := apiSnapshot.Models[record.Model]
+		modelSnapshot.Details = append(modelSnapshot.Details, RequestDetail{
+			Timestamp:      record.Timestamp,
+			Source:         record.Source,
+			AuthIndex:      record.AuthIndex,
+			Tokens:         record.Tokens,
+			Failed:         record.Failed,
+			Cost:           record.Cost,
+			RequestedModel: record.RequestedModel,
+		})
+		apiSnapshot.Models[record.Model] = modelSnapshot
+		snapshot.APIs[record.APIKey] = apiSnapshot
+	}
+	return s.MergeSnapshot(snapshot), nil
+}
+
+// Records flattens the in-memory statistics into stored records so they can be aggregated
+// with the same query API when no persistent store is configured.
+func (s *RequestStatistics) Records() []StoredRecord {
+	snapshot := s.Snapshot()
+	out := make([]StoredRecord, 0)
+	for apiName, apiSnapshot := range snapshot.APIs {
+		for modelName, modelSnapshot := range apiSnapshot.Models {
+			for _, detail := range modelSnapshot.Details {
+				out = append(out, StoredRecord{
+					Timestamp: detail.Timestamp,
+					APIKey:    apiName,
+					Model:     modelName,
+					AuthIndex: detail.AuthIndex,
+					Source:    detail.Source,
+					Failed:    detail.Failed,
+					Tokens:    detail.Tokens,
+					Cost:      detail.Cost,
+				})
+			}
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Timestamp.Before(out[j].Timestamp) })
+	return out
+}
@@ -0,0 +1,158 @@
+package usage
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+)
+
+func TestFileStoreQueryRangeAcrossPartitions(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewFileStore(dir)
+	if err != nil {
+		t.Fatalf("NewFileStore: %v", err)
+	}
+	defer func() { _ = store.Close() }()
+
+	ctx := context.Background()
+	times := []time.Time{
+		time.Date(2026, 1, 31, 23, 0, 0, 0, time.UTC),
+		time.Date(2026, 2, 1, 1, 0, 0, 0, time.UTC),
+		time.Date(2026, 2, 2, 12, 0, 0, 0, time.UTC),
+	}
+	for i, ts := range times {
+		record := StoredRecord{Timestamp: ts, APIKey: "key", Model: "m", Tokens: TokenStats{TotalTokens: int64(i + 1)}}
+		if errAppend := store.Append(ctx, record); errAppend != nil {
+			t.Fatalf("Append: %v", errAppend)
+		}
+	}
+	// A torn line must be skipped rather than failing the partition.
+	f, err := os.OpenFile(filepath.Join(dir, "usage-2026-02-01.jsonl"), os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		t.Fatalf("open partition: %v", err)
+	}
+	_, _ = f.WriteString(`{"timestamp":"2026-02-01T`)
+	_ = f.Close()
+
+	records, err := store.Query(ctx, QueryFilter{
+		From: time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC),
+		To:   time.Date(2026, 2, 2, 12, 0, 0, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+	if len(records) != 1 || records[0].Tokens.TotalTokens != 2 {
+		t.Fatalf("records = %+v, want only the 2026-02-01 record", records)
+	}
+}
+
+func TestAggregateRecordsGroupsByMonthAndModel(t *testing.T) {
+	records := []StoredRecord{
+		{Timestamp: time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC), Model: "a", Tokens: TokenStats{InputTokens: 1, TotalTokens: 1}},
+		{Timestamp: time.Date(2026, 1, 9, 0, 0, 0, 0, time.UTC), Model: "a", Failed: true, Tokens: TokenStats{InputTokens: 2, TotalTokens: 2}},
+		{Timestamp: time.Date(2026, 1, 9, 0, 0, 0, 0, time.UTC), Model: "b", Tokens: TokenStats{OutputTokens: 4, TotalTokens: 4}},
+		{Timestamp: time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC), Model: "a", Tokens: TokenStats{InputTokens: 8, TotalTokens: 8}},
+	}
+	buckets := AggregateRecords(records, AggregateQuery{GroupBy: []string{"Month", "model", "month", "bogus"}})
+	if len(buckets) != 3 {
+		t.Fatalf("buckets = %+v, want 3", buckets)
+	}
+	first := buckets[0]
+	if first.Keys[GroupByMonth] != "2026-01" || first.Keys[GroupByModel] != "a" {
+		t.Fatalf("first bucket keys = %v", first.Keys)
+	}
+	if first.TotalRequests != 2 || first.SuccessCount != 1 || first.FailureCount != 1 || first.Tokens.TotalTokens != 3 {
+		t.Fatalf("first bucket = %+v", first)
+	}
+	if _, ok := first.Keys["bogus"]; ok {
+		t.Fatalf("unknown dimension should be dropped: %v", first.Keys)
+	}
+	if buckets[2].Keys[GroupByMonth] != "2026-02" {
+		t.Fatalf("last bucket keys = %v", buckets[2].Keys)
+	}
+}
+
+func TestRequestStatisticsRestoreSkipsDuplicates(t *testing.T) {
+	store, err := NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFileStore: %v", err)
+	}
+	defer func() { _ = store.Close() }()
+
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Second)
+	for _, ts := range []time.Time{now.Add(-48 * time.Hour), now.Add(-time.Hour), now} {
+		record := StoredRecord{Timestamp: ts, APIKey: "key", Model: "m", Source: "s", Tokens: TokenStats{TotalTokens: 5}}
+		if errAppend := store.Append(ctx, record); errAppend != nil {
+			t.Fatalf("Append: %v", errAppend)
+		}
+	}
+
+	stats := NewRequestStatistics()
+	result, err := stats.Restore(ctx, store, now.Add(-24*time.Hour))
+	if err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	if result.Added != 2 {
+		t.Fatalf("added = %d, want 2", result.Added)
+	}
+	result, err = stats.Restore(ctx, store, now.Add(-24*time.Hour))
+	if err != nil {
+		t.Fatalf("Restore again: %v", err)
+	}
+	if result.Added != 0 || result.Skipped != 2 {
+		t.Fatalf("second restore = %+v, want all skipped", result)
+	}
+	if got := len(stats.Records()); got != 2 {
+		t.Fatalf("records = %d, want 2", got)
+	}
+}
+
+type blockingStore struct {
+	release  chan struct{}
+	appended chan StoredRecord
+}
+
+func (s *blockingStore) Append(_ context.Context, record StoredRecord) error {
+	<-s.release
+	s.appended <- record
+	return nil
+}
+
+func (s *blockingStore) Query(context.Context, QueryFilter) ([]StoredRecord, error) { return nil, nil }
+
+func (s *blockingStore) Close() error { return nil }
+
+func TestPersistencePluginDoesNotBlockOnSlowStore(t *testing.T) {
+	store := &blockingStore{release: make(chan struct{}), appended: make(chan StoredRecord, 2)}
+	plugin := &PersistencePlugin{}
+	plugin.SetStore("test", store)
+
+	done := make(chan struct{})
+	go func() {
+		plugin.HandleUsage(context.Background(), coreusage.Record{Model: "m1"})
+		plugin.HandleUsage(context.Background(), coreusage.Record{Model: "m2"})
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("HandleUsage blocked on a slow store")
+	}
+
+	close(store.release)
+	for _, want := range []string{"m1", "m2"} {
+		select {
+		case record := <-store.appended:
+			if record.Model != want {
+				t.Fatalf("appended %q, want %q", record.Model, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("record %q was never appended", want)
+		}
+	}
+}
@@ -4,6 +4,8 @@
 package util
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"net/url"
 	"strings"
 
@@ -170,6 +172,30 @@ func HideAPIKey(apiKey string) string {
 	return apiKey
 }
 
+// APIKeyDigest returns a stable, non-reversible identifier of a client API key for records
+// that must be scoped to the key without storing it. An empty key yields "".
+func APIKeyDigest(apiKey string) string {
+	apiKey = strings.TrimSpace(apiKey)
+	if apiKey == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(apiKey))
+	return hex.EncodeToString(sum[:])
+}
+
+// APIKeyEntryByDigest returns the entry of cfg.APIKeys whose key has the given APIKeyDigest.
+func APIKeyEntryByDigest(cfg *config.SDKConfig, digest string) (config.APIKey, bool) {
+	if cfg == nil || digest == "" {
+		return config.APIKey{}, false
+	}
+	for _, entry := range cfg.APIKeys {
+		if APIKeyDigest(entry.Key) == digest {
+			return entry, true
+		}
+	}
+	return config.APIKey{}, false
+}
+
 // maskAuthorizationHeader masks the Authorization header value while preserving the auth type prefix.
 // Common formats: "Bearer <token>", "Basic <credentials>", "ApiKey <key>", etc.
 // It preserves the prefix (e.g., "Bearer ") and only masks the token/credential part.
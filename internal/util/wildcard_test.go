@@ -0,0 +1,26 @@
+package util
+
+import "testing"
+
+func TestMatchWildcard(t *testing.T) {
+	tests := []struct {
+		pattern string
+		value   string
+		want    bool
+	}{
+		{"*", "gpt-5", true},
+		{"gpt-*", "gpt-5", true},
+		{"*-5", "gpt-5", true},
+		{"gemini-*-pro", "gemini-2.5-pro", true},
+		{"gemini-*-pro", "gemini-2.5-flash", false},
+		{"auth.*", "auth.removed", true},
+		{"*.cooldown", "model.cooldown", true},
+		{"gpt-5", "gpt-5-mini", false},
+		{"", "gpt-5", false},
+	}
+	for _, tt := range tests {
+		if got := MatchWildcard(tt.pattern, tt.value); got != tt.want {
+			t.Errorf("MatchWildcard(%q, %q) = %v, want %v", tt.pattern, tt.value, got, tt.want)
+		}
+	}
+}
@@ -33,6 +33,84 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 	if strings.TrimSpace(oldCfg.Pprof.Addr) != strings.TrimSpace(newCfg.Pprof.Addr) {
 		changes = append(changes, fmt.Sprintf("pprof.addr: %s -> %s", strings.TrimSpace(oldCfg.Pprof.Addr), strings.TrimSpace(newCfg.Pprof.Addr)))
 	}
+	if oldCfg.SharedState.Backend != newCfg.SharedState.Backend {
+		changes = append(changes, fmt.Sprintf("shared-state.backend: %s -> %s", oldCfg.SharedState.Backend, newCfg.SharedState.Backend))
+	}
+	if oldCfg.SharedState.ShareRoundRobin != newCfg.SharedState.ShareRoundRobin {
+		changes = append(changes, fmt.Sprintf("shared-state.share-round-robin: %t -> %t", oldCfg.SharedState.ShareRoundRobin, newCfg.SharedState.ShareRoundRobin))
+	}
+	if oldCfg.ResponseCache.Enable != newCfg.ResponseCache.Enable {
+		changes = append(changes, fmt.Sprintf("response-cache.enable: %t -> %t", oldCfg.ResponseCache.Enable, newCfg.ResponseCache.Enable))
+	}
+	if oldCfg.ResponseCache.Backend != newCfg.ResponseCache.Backend {
+		changes = append(changes, fmt.Sprintf("response-cache.backend: %s -> %s", oldCfg.ResponseCache.Backend, newCfg.ResponseCache.Backend))
+	}
+	if oldCfg.ResponseCache.TTLSeconds != newCfg.ResponseCache.TTLSeconds {
+		changes = append(changes, fmt.Sprintf("response-cache.ttl-seconds: %d -> %d", oldCfg.ResponseCache.TTLSeconds, newCfg.ResponseCache.TTLSeconds))
+	}
+	if oldCfg.StructuredOutput.Repair != newCfg.StructuredOutput.Repair {
+		changes = append(changes, fmt.Sprintf("structured-output.repair: %t -> %t", oldCfg.StructuredOutput.Repair, newCfg.StructuredOutput.Repair))
+	}
+	if oldCfg.ResponseStore.Enable != newCfg.ResponseStore.Enable {
+		changes = append(changes, fmt.Sprintf("response-store.enable: %t -> %t", oldCfg.ResponseStore.Enable, newCfg.ResponseStore.Enable))
+	}
+	if oldCfg.ResponseStore.Backend != newCfg.ResponseStore.Backend {
+		changes = append(changes, fmt.Sprintf("response-store.backend: %s -> %s", oldCfg.ResponseStore.Backend, newCfg.ResponseStore.Backend))
+	}
+	if oldCfg.ResponseStore.TTLSeconds != newCfg.ResponseStore.TTLSeconds {
+		changes = append(changes, fmt.Sprintf("response-store.ttl-seconds: %d -> %d", oldCfg.ResponseStore.TTLSeconds, newCfg.ResponseStore.TTLSeconds))
+	}
+	if oldCfg.Batches.Enable != newCfg.Batches.Enable {
+		changes = append(changes, fmt.Sprintf("batches.enable: %t -> %t", oldCfg.Batches.Enable, newCfg.Batches.Enable))
+	}
+	if oldCfg.Batches.Concurrency != newCfg.Batches.Concurrency {
+		changes = append(changes, fmt.Sprintf("batches.concurrency: %d -> %d", oldCfg.Batches.Concurrency, newCfg.Batches.Concurrency))
+	}
+	if oldCfg.Files.Enable != newCfg.Files.Enable {
+		changes = append(changes, fmt.Sprintf("files.enable: %t -> %t", oldCfg.Files.Enable, newCfg.Files.Enable))
+	}
+	if oldCfg.Files.TTLHours != newCfg.Files.TTLHours {
+		changes = append(changes, fmt.Sprintf("files.ttl-hours: %d -> %d", oldCfg.Files.TTLHours, newCfg.Files.TTLHours))
+	}
+	if oldCfg.ContentPolicy.Enable != newCfg.ContentPolicy.Enable {
+		changes = append(changes, fmt.Sprintf("content-policy.enable: %t -> %t", oldCfg.ContentPolicy.Enable, newCfg.ContentPolicy.Enable))
+	}
+	if oldCfg.ContentPolicy.ScanResponses != newCfg.ContentPolicy.ScanResponses {
+		changes = append(changes, fmt.Sprintf("content-policy.scan-responses: %t -> %t", oldCfg.ContentPolicy.ScanResponses, newCfg.ContentPolicy.ScanResponses))
+	}
+	if !reflect.DeepEqual(oldCfg.ModelFallbacks, newCfg.ModelFallbacks) {
+		changes = append(changes, fmt.Sprintf("model-fallbacks: updated (%d -> %d chains)", len(oldCfg.ModelFallbacks), len(newCfg.ModelFallbacks)))
+	}
+	if !reflect.DeepEqual(oldCfg.ContentPolicy.Rules, newCfg.ContentPolicy.Rules) {
+		changes = append(changes, fmt.Sprintf("content-policy.rules: updated (%d -> %d rules)", len(oldCfg.ContentPolicy.Rules), len(newCfg.ContentPolicy.Rules)))
+	}
+	if oldCfg.Pricing.DisableCatalog != newCfg.Pricing.DisableCatalog {
+		changes = append(changes, fmt.Sprintf("pricing.disable-catalog: %t -> %t", oldCfg.Pricing.DisableCatalog, newCfg.Pricing.DisableCatalog))
+	}
+	if !reflect.DeepEqual(oldCfg.Pricing.Models, newCfg.Pricing.Models) {
+		changes = append(changes, fmt.Sprintf("pricing.models: updated (%d -> %d prices)", len(oldCfg.Pricing.Models), len(newCfg.Pricing.Models)))
+	}
+	if !reflect.DeepEqual(oldCfg.Pricing.Budgets, newCfg.Pricing.Budgets) {
+		changes = append(changes, fmt.Sprintf("pricing.budgets: updated (%d -> %d budgets)", len(oldCfg.Pricing.Budgets), len(newCfg.Pricing.Budgets)))
+	}
+	if !reflect.DeepEqual(oldCfg.Webhooks, newCfg.Webhooks) {
+		changes = append(changes, fmt.Sprintf("webhooks: updated (%d -> %d webhooks)", len(oldCfg.Webhooks), len(newCfg.Webhooks)))
+	}
+	if oldCfg.AuditLog.Enable != newCfg.AuditLog.Enable {
+		changes = append(changes, fmt.Sprintf("audit-log.enable: %t -> %t", oldCfg.AuditLog.Enable, newCfg.AuditLog.Enable))
+	}
+	if oldCfg.AuditLog.Dir != newCfg.AuditLog.Dir {
+		changes = append(changes, fmt.Sprintf("audit-log.dir: %s -> %s", oldCfg.AuditLog.Dir, newCfg.AuditLog.Dir))
+	}
+	if oldCfg.AuditLog.RetentionDays != newCfg.AuditLog.RetentionDays {
+		changes = append(changes, fmt.Sprintf("audit-log.retention-days: %d -> %d", oldCfg.AuditLog.RetentionDays, newCfg.AuditLog.RetentionDays))
+	}
+	if oldCfg.AuthEncryption.Enable != newCfg.AuthEncryption.Enable {
+		changes = append(changes, fmt.Sprintf("auth-encryption.enable: %t -> %t", oldCfg.AuthEncryption.Enable, newCfg.AuthEncryption.Enable))
+	}
+	if !reflect.DeepEqual(oldCfg.AuthEncryption.Keys, newCfg.AuthEncryption.Keys) {
+		changes = append(changes, fmt.Sprintf("auth-encryption.keys: updated (%d -> %d keys)", len(oldCfg.AuthEncryption.Keys), len(newCfg.AuthEncryption.Keys)))
+	}
 	if oldCfg.LoggingToFile != newCfg.LoggingToFile {
 		changes = append(changes, fmt.Sprintf("logging-to-file: %t -> %t", oldCfg.LoggingToFile, newCfg.LoggingToFile))
 	}
@@ -84,12 +162,23 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 	if oldCfg.Routing.Strategy != newCfg.Routing.Strategy {
 		changes = append(changes, fmt.Sprintf("routing.strategy: %s -> %s", oldCfg.Routing.Strategy, newCfg.Routing.Strategy))
 	}
+	if oldCfg.Routing.SessionAffinity.Enable != newCfg.Routing.SessionAffinity.Enable {
+		changes = append(changes, fmt.Sprintf("routing.session-affinity.enable: %t -> %t", oldCfg.Routing.SessionAffinity.Enable, newCfg.Routing.SessionAffinity.Enable))
+	}
+	if oldCfg.Routing.SessionAffinity.TTLSeconds != newCfg.Routing.SessionAffinity.TTLSeconds {
+		changes = append(changes, fmt.Sprintf("routing.session-affinity.ttl-seconds: %d -> %d", oldCfg.Routing.SessionAffinity.TTLSeconds, newCfg.Routing.SessionAffinity.TTLSeconds))
+	}
 
 	// API keys (redacted) and counts
 	if len(oldCfg.APIKeys) != len(newCfg.APIKeys) {
 		changes = append(changes, fmt.Sprintf("api-keys count: %d -> %d", len(oldCfg.APIKeys), len(newCfg.APIKeys)))
-	} else if !reflect.DeepEqual(trimStrings(oldCfg.APIKeys), trimStrings(newCfg.APIKeys)) {
+	} else if !reflect.DeepEqual(trimStrings(oldCfg.APIKeyValues()), trimStrings(newCfg.APIKeyValues())) {
 		changes = append(changes, "api-keys: values updated (count unchanged, redacted)")
+	} else if !reflect.DeepEqual(oldCfg.APIKeys, newCfg.APIKeys) {
+		changes = append(changes, "api-keys: metadata or limits updated")
+	}
+	if !reflect.DeepEqual(oldCfg.Access, newCfg.Access) {
+		changes = append(changes, fmt.Sprintf("access.providers: updated (%d -> %d providers)", len(oldCfg.Access.Providers), len(newCfg.Access.Providers)))
 	}
 	if len(oldCfg.GeminiKey) != len(newCfg.GeminiKey) {
 		changes = append(changes, fmt.Sprintf("gemini-api-key count: %d -> %d", len(oldCfg.GeminiKey), len(newCfg.GeminiKey)))
@@ -271,6 +360,9 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 			changes = append(changes, "remote-management.secret-key: updated")
 		}
 	}
+	if !reflect.DeepEqual(oldCfg.RemoteManagement.Principals, newCfg.RemoteManagement.Principals) {
+		changes = append(changes, fmt.Sprintf("remote-management.principals: updated (%d -> %d)", len(oldCfg.RemoteManagement.Principals), len(newCfg.RemoteManagement.Principals)))
+	}
 
 	// OpenAI compatibility providers (summarized)
 	if compat := DiffOpenAICompatibility(oldCfg.OpenAICompatibility, newCfg.OpenAICompatibility); len(compat) > 0 {
@@ -28,10 +28,17 @@ const (
 	// AccessProviderTypeConfigAPIKey is the built-in provider validating inline API keys.
 	AccessProviderTypeConfigAPIKey = "config-api-key"
 
+	// AccessProviderTypeJWT is the built-in provider validating JWTs against a JWKS.
+	AccessProviderTypeJWT = "jwt"
+
 	// DefaultAccessProviderName is applied when no provider name is supplied.
 	DefaultAccessProviderName = "config-inline"
 )
 
+// MetadataAllowedModels is the Result metadata key carrying a comma-separated list of
+// model patterns the authenticated client is restricted to.
+const MetadataAllowedModels = "allowed-models"
+
 // MakeInlineAPIKeyProvider constructs an inline API key provider configuration.
 // It returns nil when no keys are supplied.
 func MakeInlineAPIKeyProvider(keys []string) *AccessProvider {
@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/batch"
+	. "github.com/router-for-me/CLIProxyAPI/v6/internal/constant"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// BatchHandlerType returns the handler type that executes the requests of a batch sent to
+// endpoint, or false when batches cannot target that endpoint.
+func BatchHandlerType(format batch.Format, endpoint string) (string, bool) {
+	switch format {
+	case batch.FormatAnthropic:
+		return Claude, endpoint == "/v1/messages"
+	case batch.FormatOpenAI:
+		switch endpoint {
+		case "/v1/chat/completions":
+			return OpenAI, true
+		case "/v1/responses":
+			return OpenaiResponse, true
+		case "/v1/embeddings":
+			return OpenAIEmbedding, true
+		}
+	}
+	return "", false
+}
+
+// CheckModelAllowed reports whether the client of ctx may use modelName without consuming
+// quota, so batch submissions can reject forbidden models before anything is queued.
+func (h *BaseAPIHandler) CheckModelAllowed(ctx context.Context, handlerType, modelName string) *interfaces.ErrorMessage {
+	return h.checkQuotaModel(ctx, handlerType, modelName)
+}
+
+// BatchExecutor runs queued batch requests through the auth manager like synchronous
+// requests of the submitting client key.
+type BatchExecutor struct {
+	handler *BaseAPIHandler
+}
+
+// NewBatchExecutor creates a batch executor on top of handler.
+func NewBatchExecutor(handler *BaseAPIHandler) *BatchExecutor {
+	return &BatchExecutor{handler: handler}
+}
+
+// Execute implements batch.Executor.
+func (e *BatchExecutor) Execute(ctx context.Context, b *batch.Batch, req batch.Request) batch.Result {
+	handlerType, ok := BatchHandlerType(b.Format, b.Endpoint)
+	if !ok {
+		return batchError(http.StatusBadRequest, "unsupported batch endpoint "+b.Endpoint)
+	}
+	payload := []byte(req.Body)
+	if gjson.GetBytes(payload, "stream").Exists() {
+		if updated, err := sjson.DeleteBytes(payload, "stream"); err == nil {
+			payload = updated
+		}
+	}
+	modelName := gjson.GetBytes(payload, "model").String()
+	apiKey, ok := e.handler.batchAPIKey(b)
+	if !ok {
+		return batchError(http.StatusUnauthorized, "the API key that created this batch is no longer configured")
+	}
+	ctx = WithClientAPIKey(ctx, apiKey)
+	resp, _, errMsg := e.handler.ExecuteWithAuthManager(ctx, handlerType, modelName, payload, "")
+	if errMsg != nil {
+		status := errMsg.StatusCode
+		if status <= 0 {
+			status = http.StatusInternalServerError
+		}
+		text := http.StatusText(status)
+		if errMsg.Error != nil && errMsg.Error.Error() != "" {
+			text = errMsg.Error.Error()
+		}
+		return batch.Result{Outcome: batch.OutcomeErrored, StatusCode: status, Body: BuildErrorResponseBody(status, text)}
+	}
+	if !json.Valid(resp) {
+		return batchError(http.StatusBadGateway, "upstream returned an invalid response")
+	}
+	return batch.Result{Outcome: batch.OutcomeSucceeded, StatusCode: http.StatusOK, Body: resp}
+}
+
+// batchAPIKey returns the configured client key that created b. Batches created without a
+// client key run without one.
+func (h *BaseAPIHandler) batchAPIKey(b *batch.Batch) (string, bool) {
+	if b.APIKeyDigest == "" {
+		return "", true
+	}
+	entry, ok := util.APIKeyEntryByDigest(h.Cfg, b.APIKeyDigest)
+	return entry.Key, ok
+}
+
+func batchError(status int, message string) batch.Result {
+	return batch.Result{Outcome: batch.OutcomeErrored, StatusCode: status, Body: BuildErrorResponseBody(status, message)}
+}
@@ -0,0 +1,332 @@
+package claude
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/batch"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	"github.com/tidwall/gjson"
+)
+
+const (
+	defaultBatchListLimit = 20
+	maxBatchListLimit     = 1000
+)
+
+var batchCustomIDPattern = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
+
+// CreateMessageBatch handles POST /v1/messages/batches, queueing the requests of a
+// Message Batch for background execution.
+func (h *ClaudeCodeAPIHandler) CreateMessageBatch(c *gin.Context) {
+	manager, ok := batchManager(c)
+	if !ok {
+		return
+	}
+	rawJSON, err := c.GetRawData()
+	if err != nil {
+		writeBatchError(c, http.StatusBadRequest, "invalid_request_error", fmt.Sprintf("Invalid request: %v", err))
+		return
+	}
+	items := gjson.GetBytes(rawJSON, "requests")
+	if !items.IsArray() || len(items.Array()) == 0 {
+		writeBatchError(c, http.StatusBadRequest, "invalid_request_error", "requests: must be a non-empty array")
+		return
+	}
+	if limit := manager.MaxRequests(); limit > 0 && len(items.Array()) > limit {
+		writeBatchError(c, http.StatusBadRequest, "invalid_request_error", fmt.Sprintf("requests: a batch may contain at most %d requests", limit))
+		return
+	}
+
+	ctx := context.WithValue(c.Request.Context(), "gin", c)
+	seen := make(map[string]struct{})
+	requests := make([]batch.Request, 0, len(items.Array()))
+	for i, item := range items.Array() {
+		customID := item.Get("custom_id").String()
+		if !batchCustomIDPattern.MatchString(customID) {
+			writeBatchError(c, http.StatusBadRequest, "invalid_request_error", fmt.Sprintf("requests.%d.custom_id: must match %s", i, batchCustomIDPattern))
+			return
+		}
+		if _, dup := seen[customID]; dup {
+			writeBatchError(c, http.StatusBadRequest, "invalid_request_error", fmt.Sprintf("requests.%d.custom_id: duplicate custom_id %q", i, customID))
+			return
+		}
+		seen[customID] = struct{}{}
+		params := item.Get("params")
+		modelName := params.Get("model").String()
+		if !params.IsObject() || modelName == "" {
+			writeBatchError(c, http.StatusBadRequest, "invalid_request_error", fmt.Sprintf("requests.%d.params: must be a Messages request with a model", i))
+			return
+		}
+		if errMsg := h.CheckModelAllowed(ctx, h.HandlerType(), modelName); errMsg != nil {
+			h.WriteErrorResponse(c, errMsg)
+			return
+		}
+		requests = append(requests, batch.Request{CustomID: customID, Body: json.RawMessage(params.Raw)})
+	}
+
+	created, err := manager.Create(&batch.Batch{
+		Format:       batch.FormatAnthropic,
+		Endpoint:     "/v1/messages",
+		APIKeyDigest: util.APIKeyDigest(c.GetString("apiKey")),
+	}, requests)
+	if err != nil {
+		writeBatchError(c, http.StatusInternalServerError, "api_error", err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, messageBatchObject(c, created))
+}
+
+// ListMessageBatches handles GET /v1/messages/batches with the limit, before_id and
+// after_id pagination parameters.
+func (h *ClaudeCodeAPIHandler) ListMessageBatches(c *gin.Context) {
+	manager, ok := batchManager(c)
+	if !ok {
+		return
+	}
+	limit, ok := listLimit(c)
+	if !ok {
+		return
+	}
+
+	apiKey := c.GetString("apiKey")
+	var owned []*batch.Batch
+	for _, b := range manager.List() {
+		if b.Format == batch.FormatAnthropic && b.OwnedBy(apiKey) {
+			owned = append(owned, b)
+		}
+	}
+	owned, hasMore := paginate(owned, func(b *batch.Batch) string { return b.ID }, c.Query("before_id"), c.Query("after_id"), limit)
+
+	data := make([]gin.H, 0, len(owned))
+	for _, b := range owned {
+		data = append(data, messageBatchObject(c, b))
+	}
+	body := gin.H{"data": data, "has_more": hasMore, "first_id": nil, "last_id": nil}
+	if len(owned) > 0 {
+		body["first_id"] = owned[0].ID
+		body["last_id"] = owned[len(owned)-1].ID
+	}
+	c.JSON(http.StatusOK, body)
+}
+
+// GetMessageBatch handles GET /v1/messages/batches/{id}.
+func (h *ClaudeCodeAPIHandler) GetMessageBatch(c *gin.Context) {
+	manager, ok := batchManager(c)
+	if !ok {
+		return
+	}
+	b, ok := ownedMessageBatch(c, manager)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, messageBatchObject(c, b))
+}
+
+// CancelMessageBatch handles POST /v1/messages/batches/{id}/cancel.
+func (h *ClaudeCodeAPIHandler) CancelMessageBatch(c *gin.Context) {
+	manager, ok := batchManager(c)
+	if !ok {
+		return
+	}
+	b, ok := ownedMessageBatch(c, manager)
+	if !ok {
+		return
+	}
+	canceled, err := manager.Cancel(b.ID)
+	if err != nil {
+		writeBatchError(c, http.StatusInternalServerError, "api_error", err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, messageBatchObject(c, canceled))
+}
+
+// DeleteMessageBatch handles DELETE /v1/messages/batches/{id}. Only ended batches can be
+// deleted.
+func (h *ClaudeCodeAPIHandler) DeleteMessageBatch(c *gin.Context) {
+	manager, ok := batchManager(c)
+	if !ok {
+		return
+	}
+	b, ok := ownedMessageBatch(c, manager)
+	if !ok {
+		return
+	}
+	if err := manager.Delete(b.ID); err != nil {
+		if errors.Is(err, batch.ErrNotEnded) {
+			writeBatchError(c, http.StatusBadRequest, "invalid_request_error", fmt.Sprintf("Batch %s cannot be deleted while it is processing; cancel it first.", b.ID))
+			return
+		}
+		writeBatchError(c, http.StatusInternalServerError, "api_error", err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"id": b.ID, "type": "message_batch_deleted"})
+}
+
+// MessageBatchResults handles GET /v1/messages/batches/{id}/results, streaming one JSON
+// line per request of an ended batch.
+func (h *ClaudeCodeAPIHandler) MessageBatchResults(c *gin.Context) {
+	manager, ok := batchManager(c)
+	if !ok {
+		return
+	}
+	b, ok := ownedMessageBatch(c, manager)
+	if !ok {
+		return
+	}
+	results, err := manager.Results(b.ID)
+	if err != nil {
+		if errors.Is(err, batch.ErrNotEnded) {
+			writeBatchError(c, http.StatusBadRequest, "invalid_request_error", fmt.Sprintf("Batch %s has not finished processing.", b.ID))
+			return
+		}
+		writeBatchError(c, http.StatusInternalServerError, "api_error", err.Error())
+		return
+	}
+	c.Header("Content-Type", "application/x-jsonl")
+	c.Status(http.StatusOK)
+	for _, result := range results {
+		line, errLine := json.Marshal(gin.H{"custom_id": result.CustomID, "result": messageBatchResult(result)})
+		if errLine != nil {
+			continue
+		}
+		_, _ = c.Writer.Write(append(line, '\n'))
+	}
+}
+
+func messageBatchResult(result batch.Result) gin.H {
+	switch result.Outcome {
+	case batch.OutcomeSucceeded:
+		return gin.H{"type": "succeeded", "message": json.RawMessage(result.Body)}
+	case batch.OutcomeErrored:
+		return gin.H{"type": "errored", "error": claudeErrorBody(result.Body)}
+	default:
+		return gin.H{"type": string(result.Outcome)}
+	}
+}
+
+// claudeErrorBody keeps Anthropic error payloads and converts the others.
+func claudeErrorBody(body []byte) any {
+	if gjson.GetBytes(body, "type").String() == "error" {
+		return json.RawMessage(body)
+	}
+	detail := claudeErrorDetail{Type: gjson.GetBytes(body, "error.type").String(), Message: gjson.GetBytes(body, "error.message").String()}
+	if detail.Type == "" || detail.Type == "server_error" {
+		detail.Type = "api_error"
+	}
+	if detail.Message == "" {
+		detail.Message = string(body)
+	}
+	return claudeErrorResponse{Type: "error", Error: detail}
+}
+
+func messageBatchObject(c *gin.Context, b *batch.Batch) gin.H {
+	object := gin.H{
+		"id":                  b.ID,
+		"type":                "message_batch",
+		"processing_status":   string(b.Status),
+		"request_counts":      b.Counts,
+		"created_at":          b.CreatedAt.Format(time.RFC3339Nano),
+		"expires_at":          b.ExpiresAt.Format(time.RFC3339Nano),
+		"ended_at":            nil,
+		"archived_at":         nil,
+		"cancel_initiated_at": nil,
+		"results_url":         nil,
+	}
+	if !b.CancelRequestedAt.IsZero() {
+		object["cancel_initiated_at"] = b.CancelRequestedAt.Format(time.RFC3339Nano)
+	}
+	if b.Status == batch.StatusEnded {
+		object["ended_at"] = b.EndedAt.Format(time.RFC3339Nano)
+		object["results_url"] = requestBaseURL(c) + "/v1/messages/batches/" + b.ID + "/results"
+	}
+	return object
+}
+
+func requestBaseURL(c *gin.Context) string {
+	scheme := "http"
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+	if proto := strings.TrimSpace(strings.Split(c.GetHeader("X-Forwarded-Proto"), ",")[0]); proto != "" {
+		scheme = proto
+	}
+	return scheme + "://" + c.Request.Host
+}
+
+// listLimit parses the limit query parameter of the list endpoints.
+func listLimit(c *gin.Context) (int, bool) {
+	raw := strings.TrimSpace(c.Query("limit"))
+	if raw == "" {
+		return defaultBatchListLimit, true
+	}
+	parsed, err := strconv.Atoi(raw)
+	if err != nil || parsed < 1 || parsed > maxBatchListLimit {
+		writeBatchError(c, http.StatusBadRequest, "invalid_request_error", fmt.Sprintf("limit: must be an integer between 1 and %d", maxBatchListLimit))
+		return 0, false
+	}
+	return parsed, true
+}
+
+// paginate pages through items listed newest first: after_id moves towards older items
+// and before_id towards newer ones.
+func paginate[T any](items []T, idOf func(T) string, beforeID, afterID string, limit int) ([]T, bool) {
+	index := func(id string) int {
+		for i, item := range items {
+			if idOf(item) == id {
+				return i
+			}
+		}
+		return -1
+	}
+	switch {
+	case afterID != "":
+		i := index(afterID)
+		if i < 0 {
+			return nil, false
+		}
+		items = items[i+1:]
+	case beforeID != "":
+		i := index(beforeID)
+		if i < 0 {
+			return nil, false
+		}
+		start := max(i-limit, 0)
+		return items[start:i], start > 0
+	}
+	if len(items) > limit {
+		return items[:limit], true
+	}
+	return items, false
+}
+
+func batchManager(c *gin.Context) (*batch.Manager, bool) {
+	manager := batch.Default()
+	if manager == nil {
+		writeBatchError(c, http.StatusNotFound, "not_found_error", "Message batches are not enabled on this server.")
+		return nil, false
+	}
+	return manager, true
+}
+
+func ownedMessageBatch(c *gin.Context, manager *batch.Manager) (*batch.Batch, bool) {
+	id := c.Param("id")
+	b, err := manager.Get(id)
+	if err != nil || b.Format != batch.FormatAnthropic || !b.OwnedBy(c.GetString("apiKey")) {
+		writeBatchError(c, http.StatusNotFound, "not_found_error", fmt.Sprintf("Message batch %s not found.", id))
+		return nil, false
+	}
+	return b, true
+}
+
+func writeBatchError(c *gin.Context, status int, errType, message string) {
+	c.JSON(status, claudeErrorResponse{Type: "error", Error: claudeErrorDetail{Type: errType, Message: message}})
+}
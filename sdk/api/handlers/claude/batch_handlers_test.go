@@ -0,0 +1,166 @@
+package claude
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/batch"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+type batchMessageExecutor struct{}
+
+func (batchMessageExecutor) Identifier() string { return "claude" }
+
+func (batchMessageExecutor) Execute(ctx context.Context, auth *coreauth.Auth, req coreexecutor.Request, opts coreexecutor.Options) (coreexecutor.Response, error) {
+	if gjson.GetBytes(req.Payload, "stream").Exists() {
+		return coreexecutor.Response{}, errors.New("stream must be stripped from batch requests")
+	}
+	text := gjson.GetBytes(req.Payload, "messages.0.content").String()
+	body := `{"id":"msg_1","type":"message","role":"assistant","model":"claude-batch-model","content":[{"type":"text","text":"echo ` + text + `"}],"stop_reason":"end_turn","usage":{"input_tokens":1,"output_tokens":1}}`
+	return coreexecutor.Response{Payload: []byte(body)}, nil
+}
+
+func (batchMessageExecutor) ExecuteStream(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (*coreexecutor.StreamResult, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (batchMessageExecutor) Refresh(ctx context.Context, auth *coreauth.Auth) (*coreauth.Auth, error) {
+	return auth, nil
+}
+
+func (batchMessageExecutor) CountTokens(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{}, errors.New("not implemented")
+}
+
+func (batchMessageExecutor) HttpRequest(context.Context, *coreauth.Auth, *http.Request) (*http.Response, error) {
+	return nil, errors.New("not implemented")
+}
+
+func TestMessageBatchLifecycle(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(batchMessageExecutor{})
+	auth := &coreauth.Auth{ID: "auth-batch", Provider: "claude", Status: coreauth.StatusActive}
+	if _, err := manager.Register(context.Background(), auth); err != nil {
+		t.Fatalf("Register auth: %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient(auth.ID, auth.Provider, []*registry.ModelInfo{{ID: "claude-batch-model"}})
+	t.Cleanup(func() {
+		registry.GetGlobalRegistry().UnregisterClient(auth.ID)
+	})
+
+	base := handlers.NewBaseAPIHandlers(&sdkconfig.SDKConfig{APIKeys: []sdkconfig.APIKey{{Key: "key-a"}, {Key: "key-b"}}}, manager)
+	dir := t.TempDir()
+	batches, err := batch.NewManager(batch.Options{Dir: dir}, handlers.NewBatchExecutor(base))
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+	batch.SetDefault(batches)
+	t.Cleanup(func() {
+		batch.SetDefault(nil)
+		batches.Close()
+	})
+
+	h := NewClaudeCodeAPIHandler(base)
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("apiKey", c.GetHeader("X-Test-Key"))
+	})
+	router.POST("/v1/messages/batches", h.CreateMessageBatch)
+	router.GET("/v1/messages/batches/:id", h.GetMessageBatch)
+	router.GET("/v1/messages/batches/:id/results", h.MessageBatchResults)
+
+	serve := func(method, path, body, apiKey string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Test-Key", apiKey)
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		return resp
+	}
+
+	resp := serve(http.MethodPost, "/v1/messages/batches", `{"requests":[
+		{"custom_id":"first","params":{"model":"claude-batch-model","max_tokens":16,"stream":true,"messages":[{"role":"user","content":"one"}]}},
+		{"custom_id":"second","params":{"model":"claude-batch-model","max_tokens":16,"messages":[{"role":"user","content":"two"}]}}
+	]}`, "key-a")
+	if resp.Code != http.StatusOK {
+		t.Fatalf("create status = %d body=%s", resp.Code, resp.Body.String())
+	}
+	id := gjson.Get(resp.Body.String(), "id").String()
+	if !strings.HasPrefix(id, "msgbatch_") || gjson.Get(resp.Body.String(), "request_counts.processing").Int() != 2 {
+		t.Fatalf("unexpected batch object: %s", resp.Body.String())
+	}
+
+	if resp = serve(http.MethodGet, "/v1/messages/batches/"+id, "", "key-b"); resp.Code != http.StatusNotFound {
+		t.Fatalf("other key status = %d, want 404", resp.Code)
+	}
+
+	waitEnded := func(id string) *httptest.ResponseRecorder {
+		deadline := time.Now().Add(5 * time.Second)
+		for {
+			resp := serve(http.MethodGet, "/v1/messages/batches/"+id, "", "key-a")
+			if gjson.Get(resp.Body.String(), "processing_status").String() == "ended" {
+				return resp
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("batch did not end: %s", resp.Body.String())
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	resp = waitEnded(id)
+	if got := gjson.Get(resp.Body.String(), "request_counts.succeeded").Int(); got != 2 {
+		t.Fatalf("succeeded = %d, body=%s", got, resp.Body.String())
+	}
+
+	resp = serve(http.MethodGet, "/v1/messages/batches/"+id+"/results", "", "key-a")
+	if resp.Code != http.StatusOK {
+		t.Fatalf("results status = %d body=%s", resp.Code, resp.Body.String())
+	}
+	lines := strings.Split(strings.TrimSpace(resp.Body.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("results = %q", resp.Body.String())
+	}
+	texts := map[string]string{}
+	for _, line := range lines {
+		if gjson.Get(line, "result.type").String() != "succeeded" {
+			t.Fatalf("result = %s", line)
+		}
+		texts[gjson.Get(line, "custom_id").String()] = gjson.Get(line, "result.message.content.0.text").String()
+	}
+	if texts["first"] != "echo one" || texts["second"] != "echo two" {
+		t.Fatalf("result texts = %v", texts)
+	}
+
+	persisted, err := os.ReadFile(filepath.Join(dir, id, "batch.json"))
+	if err != nil {
+		t.Fatalf("read batch.json: %v", err)
+	}
+	if strings.Contains(string(persisted), "key-a") {
+		t.Fatalf("batch.json stores the client key: %s", persisted)
+	}
+
+	// Requests of a batch whose key was removed from the config no longer run.
+	base.UpdateClients(&sdkconfig.SDKConfig{APIKeys: []sdkconfig.APIKey{{Key: "key-b"}}})
+	resp = serve(http.MethodPost, "/v1/messages/batches", `{"requests":[
+		{"custom_id":"only","params":{"model":"claude-batch-model","max_tokens":16,"messages":[{"role":"user","content":"one"}]}}
+	]}`, "key-a")
+	resp = waitEnded(gjson.Get(resp.Body.String(), "id").String())
+	if got := gjson.Get(resp.Body.String(), "request_counts.errored").Int(); got != 1 {
+		t.Fatalf("errored = %d, body=%s", got, resp.Body.String())
+	}
+}
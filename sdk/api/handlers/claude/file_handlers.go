@@ -0,0 +1,162 @@
+package claude
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/filestore"
+	log "github.com/sirupsen/logrus"
+)
+
+// anthropicFilePurpose marks files uploaded through the Anthropic Files API, which has no
+// purpose field of its own.
+const anthropicFilePurpose = "user_data"
+
+// UploadFile handles POST /v1/files in the Anthropic Files API shape.
+func (h *ClaudeCodeAPIHandler) UploadFile(c *gin.Context) {
+	store, ok := fileStore(c)
+	if !ok {
+		return
+	}
+	header, err := c.FormFile("file")
+	if err != nil {
+		writeBatchError(c, http.StatusBadRequest, "invalid_request_error", "file: field is required")
+		return
+	}
+	if limit := store.MaxBytes(); limit > 0 && header.Size > limit {
+		writeFileTooLarge(c, limit)
+		return
+	}
+	content, err := header.Open()
+	if err != nil {
+		writeBatchError(c, http.StatusBadRequest, "invalid_request_error", fmt.Sprintf("file: %v", err))
+		return
+	}
+	defer func() { _ = content.Close() }()
+
+	file, err := store.Create(c.GetString("apiKey"), header.Filename, anthropicFilePurpose, content)
+	if err != nil {
+		if errors.Is(err, filestore.ErrTooLarge) {
+			writeFileTooLarge(c, store.MaxBytes())
+			return
+		}
+		writeBatchError(c, http.StatusInternalServerError, "api_error", err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, fileMetadata(file))
+}
+
+// ListFiles handles GET /v1/files with the limit, before_id and after_id pagination parameters.
+func (h *ClaudeCodeAPIHandler) ListFiles(c *gin.Context) {
+	store, ok := fileStore(c)
+	if !ok {
+		return
+	}
+	limit, ok := listLimit(c)
+	if !ok {
+		return
+	}
+	files, err := store.List(c.GetString("apiKey"), "")
+	if err != nil {
+		writeBatchError(c, http.StatusInternalServerError, "api_error", err.Error())
+		return
+	}
+	files, hasMore := paginate(files, func(f *filestore.File) string { return f.ID }, c.Query("before_id"), c.Query("after_id"), limit)
+
+	data := make([]gin.H, 0, len(files))
+	for _, file := range files {
+		data = append(data, fileMetadata(file))
+	}
+	body := gin.H{"data": data, "has_more": hasMore, "first_id": nil, "last_id": nil}
+	if len(files) > 0 {
+		body["first_id"] = files[0].ID
+		body["last_id"] = files[len(files)-1].ID
+	}
+	c.JSON(http.StatusOK, body)
+}
+
+// GetFile handles GET /v1/files/{id}.
+func (h *ClaudeCodeAPIHandler) GetFile(c *gin.Context) {
+	store, ok := fileStore(c)
+	if !ok {
+		return
+	}
+	file, err := store.Get(c.Param("id"), c.GetString("apiKey"))
+	if err != nil {
+		writeFileError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, fileMetadata(file))
+}
+
+// GetFileContent handles GET /v1/files/{id}/content.
+func (h *ClaudeCodeAPIHandler) GetFileContent(c *gin.Context) {
+	store, ok := fileStore(c)
+	if !ok {
+		return
+	}
+	file, content, err := store.Open(c.Param("id"), c.GetString("apiKey"))
+	if err != nil {
+		writeFileError(c, err)
+		return
+	}
+	defer func() { _ = content.Close() }()
+	c.Header("Content-Type", file.MimeType)
+	c.Header("Content-Length", strconv.FormatInt(file.Bytes, 10))
+	c.Status(http.StatusOK)
+	if _, err = io.Copy(c.Writer, content); err != nil {
+		log.Debugf("files: send %s: %v", file.ID, err)
+	}
+}
+
+// DeleteFile handles DELETE /v1/files/{id}.
+func (h *ClaudeCodeAPIHandler) DeleteFile(c *gin.Context) {
+	store, ok := fileStore(c)
+	if !ok {
+		return
+	}
+	id := c.Param("id")
+	if err := store.Delete(id, c.GetString("apiKey")); err != nil {
+		writeFileError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"id": id, "type": "file_deleted"})
+}
+
+func fileMetadata(file *filestore.File) gin.H {
+	return gin.H{
+		"id":           file.ID,
+		"type":         "file",
+		"filename":     file.Filename,
+		"mime_type":    file.MimeType,
+		"size_bytes":   file.Bytes,
+		"created_at":   file.CreatedAt.Format(time.RFC3339),
+		"downloadable": true,
+	}
+}
+
+func fileStore(c *gin.Context) (*filestore.Store, bool) {
+	store := filestore.Default()
+	if store == nil {
+		writeBatchError(c, http.StatusNotFound, "not_found_error", "File uploads are not enabled on this server.")
+		return nil, false
+	}
+	return store, true
+}
+
+func writeFileError(c *gin.Context, err error) {
+	if errors.Is(err, filestore.ErrNotFound) {
+		writeBatchError(c, http.StatusNotFound, "not_found_error", fmt.Sprintf("File not found: %s", c.Param("id")))
+		return
+	}
+	writeBatchError(c, http.StatusInternalServerError, "api_error", err.Error())
+}
+
+func writeFileTooLarge(c *gin.Context, limit int64) {
+	writeBatchError(c, http.StatusRequestEntityTooLarge, "request_too_large", fmt.Sprintf("file: exceeds the maximum size of %d bytes", limit))
+}
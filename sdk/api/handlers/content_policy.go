@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/policy"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+	log "github.com/sirupsen/logrus"
+)
+
+// applyRequestPolicy runs the content policy over a client payload before it is translated.
+// It returns the payload to forward, with masked values replaced, or a 400 when a rule blocks it.
+func applyRequestPolicy(ctx context.Context, handlerType, modelName string, rawJSON []byte) ([]byte, *interfaces.ErrorMessage) {
+	engine := policy.Default()
+	if engine == nil {
+		return rawJSON, nil
+	}
+	ginCtx := ginContextFrom(ctx)
+	result := engine.ScanRequest(policyScope(ctx, handlerType, modelName), rawJSON)
+	recordPolicyHits(ginCtx, result.Hits)
+	if result.Blocked != nil {
+		return nil, policyErrorMessage(handlerType, result.Blocked)
+	}
+	return result.Payload, nil
+}
+
+// applyResponsePolicy runs the content policy over a non-streaming response payload
+// when scan-responses is enabled.
+func applyResponsePolicy(ctx context.Context, handlerType, modelName string, payload []byte) ([]byte, *interfaces.ErrorMessage) {
+	engine := policy.Default()
+	if !engine.ScansResponses() {
+		return payload, nil
+	}
+	ginCtx := ginContextFrom(ctx)
+	result := engine.ScanResponse(policyScope(ctx, handlerType, modelName), payload)
+	recordPolicyHits(ginCtx, result.Hits)
+	if result.Blocked != nil {
+		return nil, policyErrorMessage(handlerType, result.Blocked)
+	}
+	return result.Payload, nil
+}
+
+// responsePolicyStream runs the content policy over the chunks of one streamed response.
+// The scanner holds back the tail of the streamed text until matches split across chunks
+// can be masked, so the chunks to send lag the chunks scanned.
+type responsePolicyStream struct {
+	ginCtx      *gin.Context
+	handlerType string
+	scanner     *policy.StreamScanner
+}
+
+// newResponsePolicyStream returns the content policy stream for one streamed response,
+// or nil when responses are not scanned.
+func newResponsePolicyStream(ctx context.Context, handlerType, modelName string) *responsePolicyStream {
+	engine := policy.Default()
+	if !engine.ScansResponses() {
+		return nil
+	}
+	return &responsePolicyStream{
+		ginCtx:      ginContextFrom(ctx),
+		handlerType: handlerType,
+		scanner:     engine.NewStreamScanner(policyScope(ctx, handlerType, modelName)),
+	}
+}
+
+// scan returns the chunks to send for the next chunk of the stream, with masked values
+// replaced, or a 400 when a rule blocks the stream.
+func (s *responsePolicyStream) scan(chunk []byte) ([][]byte, *interfaces.ErrorMessage) {
+	if s == nil {
+		return [][]byte{chunk}, nil
+	}
+	return s.result(s.scanner.Scan(chunk))
+}
+
+// flush returns the chunk carrying the text still held back when the stream ends.
+func (s *responsePolicyStream) flush() ([][]byte, *interfaces.ErrorMessage) {
+	if s == nil {
+		return nil, nil
+	}
+	return s.result(s.scanner.Flush())
+}
+
+func (s *responsePolicyStream) result(result policy.StreamResult) ([][]byte, *interfaces.ErrorMessage) {
+	recordPolicyHits(s.ginCtx, result.Hits)
+	if result.Blocked != nil {
+		return nil, policyErrorMessage(s.handlerType, result.Blocked)
+	}
+	return result.Payloads, nil
+}
+
+func policyScope(ctx context.Context, handlerType, modelName string) policy.Scope {
+	ginCtx := ginContextFrom(ctx)
+	scope := policy.Scope{
+		APIKey:   clientAPIKey(ctx),
+		Model:    thinking.ParseSuffix(modelName).ModelName,
+		Protocol: handlerType,
+	}
+	if ginCtx != nil {
+		if value, exists := ginCtx.Get("accessMetadata"); exists {
+			if metadata, ok := value.(map[string]string); ok {
+				scope.APIKeyName = metadata["name"]
+			}
+		}
+	}
+	return scope
+}
+
+// recordPolicyHits logs policy hits and collects them for the request log.
+func recordPolicyHits(ginCtx *gin.Context, hits []policy.Hit) {
+	if len(hits) == 0 {
+		return
+	}
+	for _, hit := range hits {
+		entry := log.WithFields(log.Fields{
+			"rule":      hit.Rule,
+			"detector":  hit.Detector,
+			"action":    hit.Action,
+			"direction": hit.Direction,
+			"matches":   hit.Count,
+		})
+		if hit.Action == config.ContentPolicyActionMask {
+			entry.Debug("content policy match")
+		} else {
+			entry.Warn("content policy match")
+		}
+	}
+	if ginCtx == nil {
+		return
+	}
+	var recorded []policy.Hit
+	if value, exists := ginCtx.Get(policy.RequestLogKey); exists {
+		recorded, _ = value.([]policy.Hit)
+	}
+	ginCtx.Set(policy.RequestLogKey, append(recorded, hits...))
+}
+
+// policyErrorMessage renders a policy rejection in the error shape of the calling protocol.
+func policyErrorMessage(handlerType string, hit *policy.Hit) *interfaces.ErrorMessage {
+	message := fmt.Sprintf("%s blocked by content policy rule %q (%s)", hit.Direction, hit.Rule, hit.Detector)
+	var payload any
+	switch handlerType {
+	case "claude":
+		payload = map[string]any{
+			"type":  "error",
+			"error": map[string]any{"type": "invalid_request_error", "message": message},
+		}
+	case "gemini", "gemini-cli", "gemini-embedding":
+		payload = map[string]any{
+			"error": map[string]any{"code": http.StatusBadRequest, "message": message, "status": "INVALID_ARGUMENT"},
+		}
+	default:
+		payload = ErrorResponse{Error: ErrorDetail{Message: message, Type: "invalid_request_error", Code: "content_policy_violation"}}
+	}
+	body, _ := json.Marshal(payload)
+	return &interfaces.ErrorMessage{StatusCode: http.StatusBadRequest, Error: errors.New(string(body))}
+}
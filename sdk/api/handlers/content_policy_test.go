@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/filestore"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/policy"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+)
+
+// echoPolicyExecutor returns the payload it receives, so tests see what went upstream.
+type echoPolicyExecutor struct {
+	mu       sync.Mutex
+	payloads []string
+}
+
+func (e *echoPolicyExecutor) Identifier() string { return "codex" }
+
+func (e *echoPolicyExecutor) Execute(_ context.Context, _ *coreauth.Auth, req coreexecutor.Request, _ coreexecutor.Options) (coreexecutor.Response, error) {
+	e.mu.Lock()
+	e.payloads = append(e.payloads, string(req.Payload))
+	e.mu.Unlock()
+	return coreexecutor.Response{Payload: req.Payload}, nil
+}
+
+func (e *echoPolicyExecutor) ExecuteStream(_ context.Context, _ *coreauth.Auth, req coreexecutor.Request, _ coreexecutor.Options) (*coreexecutor.StreamResult, error) {
+	ch := make(chan coreexecutor.StreamChunk, 1)
+	ch <- coreexecutor.StreamChunk{Payload: []byte(`{"delta":"contact ops@example.com"}`)}
+	close(ch)
+	return &coreexecutor.StreamResult{Chunks: ch}, nil
+}
+
+func (e *echoPolicyExecutor) Refresh(_ context.Context, auth *coreauth.Auth) (*coreauth.Auth, error) {
+	return auth, nil
+}
+
+func (e *echoPolicyExecutor) CountTokens(ctx context.Context, auth *coreauth.Auth, req coreexecutor.Request, opts coreexecutor.Options) (coreexecutor.Response, error) {
+	return e.Execute(ctx, auth, req, opts)
+}
+
+func (e *echoPolicyExecutor) HttpRequest(context.Context, *coreauth.Auth, *http.Request) (*http.Response, error) {
+	return nil, &coreauth.Error{Code: "not_implemented", Message: "HttpRequest not implemented", HTTPStatus: http.StatusNotImplemented}
+}
+
+func TestContentPolicy_MasksBlocksAndRecordsHits(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine, err := policy.New(config.ContentPolicyConfig{
+		Enable:        true,
+		ScanResponses: true,
+		Rules: []config.ContentPolicyRule{
+			{Name: "secrets", Detectors: []string{policy.DetectorAPIKey, policy.DetectorEmail}},
+			{Name: "deny", Keywords: []string{"internal only"}, Action: config.ContentPolicyActionBlock},
+		},
+	})
+	if err != nil {
+		t.Fatalf("policy.New: %v", err)
+	}
+	policy.SetDefault(engine)
+	t.Cleanup(func() { policy.SetDefault(nil) })
+
+	executor := &echoPolicyExecutor{}
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(executor)
+	auth := &coreauth.Auth{ID: "policy-auth", Provider: "codex", Status: coreauth.StatusActive}
+	if _, err := manager.Register(context.Background(), auth); err != nil {
+		t.Fatalf("manager.Register: %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient(auth.ID, auth.Provider, []*registry.ModelInfo{{ID: "policy-model"}})
+	t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(auth.ID) })
+	handler := NewBaseAPIHandlers(&sdkconfig.SDKConfig{}, manager)
+
+	newCtx := func() (*gin.Context, context.Context) {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+		return c, context.WithValue(context.Background(), "gin", c)
+	}
+
+	c, ctx := newCtx()
+	resp, _, errMsg := handler.ExecuteWithAuthManager(ctx, "openai", "policy-model", []byte(`{"content":"use sk-abcdefghijklmnopqrstuvwxyz"}`), "")
+	if errMsg != nil {
+		t.Fatalf("execute: %v", errMsg.Error)
+	}
+	if want := `{"content":"use [REDACTED:api-key]"}`; executor.payloads[0] != want || string(resp) != want {
+		t.Fatalf("upstream saw %s, client got %s; want %s", executor.payloads[0], resp, want)
+	}
+	value, _ := c.Get(policy.RequestLogKey)
+	if hits, _ := value.([]policy.Hit); len(hits) != 1 || hits[0].Rule != "secrets" {
+		t.Fatalf("recorded hits = %v", value)
+	}
+
+	_, ctx = newCtx()
+	_, _, errMsg = handler.ExecuteWithAuthManager(ctx, "openai", "policy-model", []byte(`{"content":"Internal Only"}`), "")
+	if errMsg == nil || errMsg.StatusCode != http.StatusBadRequest || !strings.Contains(errMsg.Error.Error(), `"code":"content_policy_violation"`) {
+		t.Fatalf("blocked request error = %+v", errMsg)
+	}
+	if len(executor.payloads) != 1 {
+		t.Fatalf("blocked request reached upstream")
+	}
+
+	_, ctx = newCtx()
+	dataChan, _, errChan := handler.ExecuteStreamWithAuthManager(ctx, "openai", "policy-model", []byte(`{}`), "")
+	var chunks []string
+	for chunk := range dataChan {
+		chunks = append(chunks, string(chunk))
+	}
+	for msg := range errChan {
+		if msg != nil {
+			t.Fatalf("stream error: %v", msg.Error)
+		}
+	}
+	if len(chunks) != 1 || chunks[0] != `{"delta":"contact [REDACTED:email]"}` {
+		t.Fatalf("stream chunks = %q", chunks)
+	}
+
+	store, err := filestore.New(t.TempDir(), 0, 0)
+	if err != nil {
+		t.Fatalf("filestore.New: %v", err)
+	}
+	filestore.SetDefault(store)
+	t.Cleanup(func() { filestore.SetDefault(nil) })
+	notes, err := store.Create("key-a", "notes.txt", "user_data", strings.NewReader("internal only: roadmap"))
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	c, ctx = newCtx()
+	c.Set("apiKey", "key-a")
+	body := `{"messages":[{"role":"user","content":[{"type":"document","source":{"type":"file","file_id":"` + notes.ID + `"}}]}]}`
+	_, _, errMsg = handler.ExecuteWithAuthManager(ctx, "claude", "policy-model", []byte(body), "")
+	if errMsg == nil || !strings.Contains(errMsg.Error.Error(), "content policy") {
+		t.Fatalf("file contents bypassed the policy: %+v", errMsg)
+	}
+	if len(executor.payloads) != 1 {
+		t.Fatalf("blocked file reference reached upstream")
+	}
+}
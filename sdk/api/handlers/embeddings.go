@@ -0,0 +1,25 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+)
+
+// CheckEmbeddingModel rejects models that are registered without an embedding capability,
+// so text-generation models are not sent embeddings payloads. Unknown models are left to
+// the regular routing, which reports them as unavailable.
+func CheckEmbeddingModel(modelName string) *interfaces.ErrorMessage {
+	baseModel := thinking.ParseSuffix(modelName).ModelName
+	info := registry.LookupModelInfo(baseModel)
+	if info == nil || info.SupportsEmbeddings() {
+		return nil
+	}
+	return &interfaces.ErrorMessage{
+		StatusCode: http.StatusBadRequest,
+		Error:      fmt.Errorf("model %s does not support embeddings", baseModel),
+	}
+}
@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	. "github.com/router-for-me/CLIProxyAPI/v6/internal/constant"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/filestore"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// resolveFileReferences replaces references to files of the local /v1/files store with
+// inline data in the client's own request format, so every translator sees base64 content:
+//
+//	claude:          document/image sources of type "file"     -> base64 (or text) sources
+//	openai:          "file" parts with file.file_id             -> file.file_data data URLs
+//	openai-response: input_file/input_image parts with file_id  -> file_data/image_url data URLs
+//
+// Ids that do not look like local file ids are left untouched for upstreams that host them.
+func resolveFileReferences(ctx context.Context, handlerType string, rawJSON []byte) ([]byte, *interfaces.ErrorMessage) {
+	store := filestore.Default()
+	if store == nil || !bytes.Contains(rawJSON, []byte("file_id")) {
+		return rawJSON, nil
+	}
+	r := &fileResolver{store: store, apiKey: clientAPIKey(ctx), out: rawJSON}
+	root := gjson.ParseBytes(rawJSON)
+	switch handlerType {
+	case Claude:
+		for i, message := range root.Get("messages").Array() {
+			for j, block := range message.Get("content").Array() {
+				path := fmt.Sprintf("messages.%d.content.%d", i, j)
+				r.claudeBlock(path, block)
+				if block.Get("type").String() == "tool_result" {
+					for k, nested := range block.Get("content").Array() {
+						r.claudeBlock(fmt.Sprintf("%s.content.%d", path, k), nested)
+					}
+				}
+			}
+		}
+	case OpenAI:
+		for i, message := range root.Get("messages").Array() {
+			for j, part := range message.Get("content").Array() {
+				r.openAIChatPart(fmt.Sprintf("messages.%d.content.%d", i, j), part)
+			}
+		}
+	case OpenaiResponse:
+		input := root.Get("input")
+		if !input.IsArray() {
+			return rawJSON, nil
+		}
+		for i, item := range input.Array() {
+			path := fmt.Sprintf("input.%d", i)
+			r.responsesPart(path, item)
+			for j, part := range item.Get("content").Array() {
+				r.responsesPart(fmt.Sprintf("%s.content.%d", path, j), part)
+			}
+		}
+	default:
+		return rawJSON, nil
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	return r.out, nil
+}
+
+type fileResolver struct {
+	store  *filestore.Store
+	apiKey string
+	out    []byte
+	err    *interfaces.ErrorMessage
+}
+
+// load returns the stored file for id, or ok=false when id is not a local file id.
+func (r *fileResolver) load(id string) (file *filestore.File, data []byte, ok bool) {
+	if r.err != nil || !filestore.IsID(id) {
+		return nil, nil, false
+	}
+	file, content, err := r.store.Open(id, r.apiKey)
+	if err == nil {
+		data, err = io.ReadAll(content)
+		_ = content.Close()
+	}
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, filestore.ErrNotFound) {
+			status = http.StatusBadRequest
+		}
+		r.err = &interfaces.ErrorMessage{StatusCode: status, Error: fmt.Errorf("file %s: %w", id, err)}
+		return nil, nil, false
+	}
+	return file, data, true
+}
+
+func (r *fileResolver) set(path string, value any) {
+	r.out, _ = sjson.SetBytes(r.out, path, value)
+}
+
+func (r *fileResolver) claudeBlock(path string, block gjson.Result) {
+	blockType := block.Get("type").String()
+	if (blockType != "document" && blockType != "image") || block.Get("source.type").String() != "file" {
+		return
+	}
+	file, data, ok := r.load(block.Get("source.file_id").String())
+	if !ok {
+		return
+	}
+	source := map[string]string{"type": "base64", "media_type": file.MimeType, "data": base64.StdEncoding.EncodeToString(data)}
+	if blockType == "document" && isTextMimeType(file.MimeType) {
+		// Claude accepts base64 documents only for PDFs; plain text documents use a text source.
+		source = map[string]string{"type": "text", "media_type": "text/plain", "data": string(data)}
+	}
+	r.set(path+".source", source)
+}
+
+func (r *fileResolver) openAIChatPart(path string, part gjson.Result) {
+	if part.Get("type").String() != "file" || part.Get("file.file_data").Exists() {
+		return
+	}
+	file, data, ok := r.load(part.Get("file.file_id").String())
+	if !ok {
+		return
+	}
+	r.out, _ = sjson.DeleteBytes(r.out, path+".file.file_id")
+	r.set(path+".file.file_data", dataURL(file.MimeType, data))
+	if part.Get("file.filename").String() == "" {
+		r.set(path+".file.filename", file.Filename)
+	}
+}
+
+func (r *fileResolver) responsesPart(path string, part gjson.Result) {
+	var target string
+	switch part.Get("type").String() {
+	case "input_file":
+		target = "file_data"
+	case "input_image":
+		target = "image_url"
+	default:
+		return
+	}
+	if part.Get(target).Exists() {
+		return
+	}
+	file, data, ok := r.load(part.Get("file_id").String())
+	if !ok {
+		return
+	}
+	r.out, _ = sjson.DeleteBytes(r.out, path+".file_id")
+	r.set(path+"."+target, dataURL(file.MimeType, data))
+	if target == "file_data" && part.Get("filename").String() == "" {
+		r.set(path+".filename", file.Filename)
+	}
+}
+
+func dataURL(mimeType string, data []byte) string {
+	return "data:" + mimeType + ";base64," + base64.StdEncoding.EncodeToString(data)
+}
+
+func isTextMimeType(mimeType string) bool {
+	return strings.HasPrefix(mimeType, "text/") || mimeType == "application/json"
+}
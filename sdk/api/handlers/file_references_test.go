@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	. "github.com/router-for-me/CLIProxyAPI/v6/internal/constant"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/filestore"
+	"github.com/tidwall/gjson"
+)
+
+func TestResolveFileReferences(t *testing.T) {
+	store, err := filestore.New(t.TempDir(), 0, 0)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	filestore.SetDefault(store)
+	t.Cleanup(func() { filestore.SetDefault(nil) })
+
+	pdf, err := store.Create("key-a", "report.pdf", "user_data", strings.NewReader("%PDF-1.4 test"))
+	if err != nil {
+		t.Fatalf("Create pdf: %v", err)
+	}
+	notes, err := store.Create("key-a", "notes.txt", "user_data", strings.NewReader("hello notes"))
+	if err != nil {
+		t.Fatalf("Create notes: %v", err)
+	}
+	ctx := WithClientAPIKey(context.Background(), "key-a")
+
+	claude := `{"messages":[{"role":"user","content":[` +
+		`{"type":"document","source":{"type":"file","file_id":"` + pdf.ID + `"}},` +
+		`{"type":"document","source":{"type":"file","file_id":"` + notes.ID + `"}},` +
+		`{"type":"image","source":{"type":"file","file_id":"file_remote"}}]}]}`
+	out, errMsg := resolveFileReferences(ctx, Claude, []byte(claude))
+	if errMsg != nil {
+		t.Fatalf("claude: %v", errMsg.Error)
+	}
+	blocks := gjson.GetBytes(out, "messages.0.content")
+	if got := blocks.Get("0.source.type").String(); got != "base64" || blocks.Get("0.source.media_type").String() != "application/pdf" || blocks.Get("0.source.data").String() != "JVBERi0xLjQgdGVzdA==" {
+		t.Fatalf("pdf source = %s", blocks.Get("0.source").Raw)
+	}
+	if got := blocks.Get("1.source").Raw; got != `{"data":"hello notes","media_type":"text/plain","type":"text"}` {
+		t.Fatalf("text source = %s", got)
+	}
+	if got := blocks.Get("2.source.file_id").String(); got != "file_remote" {
+		t.Fatalf("remote id rewritten: %s", blocks.Get("2.source").Raw)
+	}
+
+	chat := `{"messages":[{"role":"user","content":[{"type":"file","file":{"file_id":"` + pdf.ID + `"}}]}]}`
+	out, errMsg = resolveFileReferences(ctx, OpenAI, []byte(chat))
+	if errMsg != nil {
+		t.Fatalf("openai: %v", errMsg.Error)
+	}
+	part := gjson.GetBytes(out, "messages.0.content.0.file")
+	if part.Get("file_id").Exists() || part.Get("filename").String() != "report.pdf" || part.Get("file_data").String() != "data:application/pdf;base64,JVBERi0xLjQgdGVzdA==" {
+		t.Fatalf("chat file part = %s", part.Raw)
+	}
+
+	responses := `{"input":[{"role":"user","content":[{"type":"input_file","file_id":"` + notes.ID + `"}]}]}`
+	out, errMsg = resolveFileReferences(ctx, OpenaiResponse, []byte(responses))
+	if errMsg != nil {
+		t.Fatalf("responses: %v", errMsg.Error)
+	}
+	if got := gjson.GetBytes(out, "input.0.content.0.file_data").String(); !strings.HasPrefix(got, "data:text/plain") {
+		t.Fatalf("input_file file_data = %q", got)
+	}
+
+	// Files of another API key are not visible.
+	_, errMsg = resolveFileReferences(WithClientAPIKey(context.Background(), "key-b"), OpenAI, []byte(chat))
+	if errMsg == nil || errMsg.StatusCode != http.StatusBadRequest {
+		t.Fatalf("foreign key error = %+v", errMsg)
+	}
+}
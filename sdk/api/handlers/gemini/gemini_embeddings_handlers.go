@@ -0,0 +1,52 @@
+package gemini
+
+import (
+	"context"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	. "github.com/router-for-me/CLIProxyAPI/v6/internal/constant"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// handleEmbedContent handles embedContent and batchEmbedContents requests.
+// Both are executed in the batchEmbedContents form; a single embedContent request is sent
+// as a one-entry batch and its vector is unwrapped from the batch response.
+//
+// Parameters:
+//   - c: The Gin context for the request
+//   - modelName: The name of the embedding model
+//   - method: The Gemini method, embedContent or batchEmbedContents
+//   - rawJSON: The raw JSON request body
+func (h *GeminiAPIHandler) handleEmbedContent(c *gin.Context, modelName, method string, rawJSON []byte) {
+	if errMsg := handlers.CheckEmbeddingModel(modelName); errMsg != nil {
+		h.WriteErrorResponse(c, errMsg)
+		return
+	}
+	payload := rawJSON
+	if method == "embedContent" {
+		entry := rawJSON
+		if !gjson.GetBytes(entry, "model").Exists() {
+			entry, _ = sjson.SetBytes(entry, "model", "models/"+strings.TrimPrefix(modelName, "models/"))
+		}
+		payload, _ = sjson.SetRawBytes([]byte(`{"requests":[]}`), "requests.-1", entry)
+	}
+
+	c.Header("Content-Type", "application/json")
+	cliCtx, cliCancel := h.GetContextWithCancel(h, c, context.Background())
+	resp, upstreamHeaders, errMsg := h.ExecuteWithAuthManager(cliCtx, GeminiEmbedding, modelName, payload, "")
+	if errMsg != nil {
+		h.WriteErrorResponse(c, errMsg)
+		cliCancel(errMsg.Error)
+		return
+	}
+	if method == "embedContent" {
+		single, _ := sjson.SetRawBytes([]byte(`{}`), "embedding", []byte(gjson.GetBytes(resp, "embeddings.0").Raw))
+		resp = single
+	}
+	handlers.WriteUpstreamHeaders(c.Writer.Header(), upstreamHeaders)
+	_, _ = c.Writer.Write(resp)
+	cliCancel()
+}
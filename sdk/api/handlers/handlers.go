@@ -14,6 +14,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/cache"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
@@ -47,6 +48,9 @@ type ErrorDetail struct {
 
 const idempotencyKeyMetadataKey = "idempotency_key"
 
+// ServedModelHeader carries the model that actually served a request.
+const ServedModelHeader = "X-CPA-Served-Model"
+
 const (
 	defaultStreamingKeepAliveSeconds = 0
 	defaultStreamingBootstrapRetries = 0
@@ -55,6 +59,9 @@ const (
 type pinnedAuthContextKey struct{}
 type selectedAuthCallbackContextKey struct{}
 type executionSessionContextKey struct{}
+type clientAPIKeyContextKey struct{}
+type responseCacheBypassContextKey struct{}
+type deferredResponseCacheContextKey struct{}
 
 // WithPinnedAuthID returns a child context that requests execution on a specific auth ID.
 func WithPinnedAuthID(ctx context.Context, authID string) context.Context {
@@ -91,6 +98,38 @@ func WithExecutionSessionID(ctx context.Context, sessionID string) context.Conte
 	return context.WithValue(ctx, executionSessionContextKey{}, sessionID)
 }
 
+// WithClientAPIKey returns a child context that executes on behalf of a client API key, so
+// its scopes and limits apply to requests made without an HTTP request, such as batches.
+func WithClientAPIKey(ctx context.Context, apiKey string) context.Context {
+	if apiKey == "" {
+		return ctx
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, clientAPIKeyContextKey{}, apiKey)
+}
+
+// WithoutResponseCache returns a child context whose requests neither read nor populate the
+// response cache, for callers that sample the same payload more than once.
+func WithoutResponseCache(ctx context.Context) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, responseCacheBypassContextKey{}, true)
+}
+
+// WithDeferredResponseCache returns a child context whose non-streaming response is handed to
+// the returned DeferredResponseCache instead of being cached, so the caller can check the
+// answer before it is served to later requests.
+func WithDeferredResponseCache(ctx context.Context) (context.Context, *DeferredResponseCache) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	deferred := &DeferredResponseCache{}
+	return context.WithValue(ctx, deferredResponseCacheContextKey{}, deferred), deferred
+}
+
 // BuildErrorResponseBody builds an OpenAI-compatible JSON error response body.
 // If errText is already valid JSON, it is returned as-is to preserve upstream error payloads.
 func BuildErrorResponseBody(status int, errText string) []byte {
@@ -473,8 +512,32 @@ func (h *BaseAPIHandler) ExecuteWithAuthManager(ctx context.Context, handlerType
 	if errMsg != nil {
 		return nil, nil, errMsg
 	}
+	// File references are inlined first so the content policy also sees uploaded file contents.
+	if rawJSON, errMsg = resolveFileReferences(ctx, handlerType, rawJSON); errMsg != nil {
+		return nil, nil, errMsg
+	}
+	if rawJSON, errMsg = applyRequestPolicy(ctx, handlerType, normalizedModel, rawJSON); errMsg != nil {
+		return nil, nil, errMsg
+	}
+	// Cache hits are checked against the key's scopes but do not consume its limits.
+	if errMsg = h.checkQuotaModel(ctx, handlerType, normalizedModel); errMsg != nil {
+		return nil, nil, errMsg
+	}
+	cacheKey, cached := responseCacheLookup(ctx, handlerType, normalizedModel, alt, false, providers, rawJSON)
+	if cached != nil {
+		if !PassthroughHeadersEnabled(h.Cfg) {
+			return cloneBytes(cached.Payload), nil, nil
+		}
+		return cloneBytes(cached.Payload), cloneHeader(cached.Headers), nil
+	}
+	release, errMsg := h.acquireQuota(ctx, handlerType, normalizedModel, false)
+	if errMsg != nil {
+		return nil, nil, errMsg
+	}
+	defer release()
 	reqMeta := requestExecutionMetadata(ctx)
 	reqMeta[coreexecutor.RequestedModelMetadataKey] = normalizedModel
+	h.applySessionAffinity(ctx, reqMeta, rawJSON)
 	payload := rawJSON
 	if len(payload) == 0 {
 		payload = nil
@@ -506,6 +569,12 @@ func (h *BaseAPIHandler) ExecuteWithAuthManager(ctx context.Context, handlerType
 		}
 		return nil, nil, &interfaces.ErrorMessage{StatusCode: status, Error: err, Addon: addon}
 	}
+	setServedModelHeader(ctx, reqMeta)
+	h.bindResponseSession(handlerType, normalizedModel, reqMeta, resp.Payload)
+	if resp.Payload, errMsg = applyResponsePolicy(ctx, handlerType, normalizedModel, resp.Payload); errMsg != nil {
+		return nil, nil, errMsg
+	}
+	storeOrDeferResponse(ctx, cacheKey, &cache.ResponseEntry{Payload: cloneBytes(resp.Payload), Headers: cloneHeader(FilterUpstreamHeaders(resp.Headers))})
 	if !PassthroughHeadersEnabled(h.Cfg) {
 		return resp.Payload, nil, nil
 	}
@@ -519,6 +588,15 @@ func (h *BaseAPIHandler) ExecuteCountWithAuthManager(ctx context.Context, handle
 	if errMsg != nil {
 		return nil, nil, errMsg
 	}
+	if errMsg = h.checkQuotaModel(ctx, handlerType, normalizedModel); errMsg != nil {
+		return nil, nil, errMsg
+	}
+	if rawJSON, errMsg = resolveFileReferences(ctx, handlerType, rawJSON); errMsg != nil {
+		return nil, nil, errMsg
+	}
+	if rawJSON, errMsg = applyRequestPolicy(ctx, handlerType, normalizedModel, rawJSON); errMsg != nil {
+		return nil, nil, errMsg
+	}
 	reqMeta := requestExecutionMetadata(ctx)
 	reqMeta[coreexecutor.RequestedModelMetadataKey] = normalizedModel
 	payload := rawJSON
@@ -569,8 +647,41 @@ func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handl
 		close(errChan)
 		return nil, nil, errChan
 	}
+	rawJSON, errMsg = resolveFileReferences(ctx, handlerType, rawJSON)
+	if errMsg == nil {
+		rawJSON, errMsg = applyRequestPolicy(ctx, handlerType, normalizedModel, rawJSON)
+	}
+	if errMsg != nil {
+		errChan := make(chan *interfaces.ErrorMessage, 1)
+		errChan <- errMsg
+		close(errChan)
+		return nil, nil, errChan
+	}
+	// Cache hits are checked against the key's scopes but do not consume its limits.
+	if errMsg = h.checkQuotaModel(ctx, handlerType, normalizedModel); errMsg != nil {
+		errChan := make(chan *interfaces.ErrorMessage, 1)
+		errChan <- errMsg
+		close(errChan)
+		return nil, nil, errChan
+	}
+	cacheKey, cached := responseCacheLookup(ctx, handlerType, normalizedModel, alt, true, providers, rawJSON)
+	if cached != nil {
+		dataChan, errChan := replayStream(ctx, cached)
+		if !PassthroughHeadersEnabled(h.Cfg) {
+			return dataChan, nil, errChan
+		}
+		return dataChan, cloneHeader(cached.Headers), errChan
+	}
+	release, errMsg := h.acquireQuota(ctx, handlerType, normalizedModel, true)
+	if errMsg != nil {
+		errChan := make(chan *interfaces.ErrorMessage, 1)
+		errChan <- errMsg
+		close(errChan)
+		return nil, nil, errChan
+	}
 	reqMeta := requestExecutionMetadata(ctx)
 	reqMeta[coreexecutor.RequestedModelMetadataKey] = normalizedModel
+	h.applySessionAffinity(ctx, reqMeta, rawJSON)
 	payload := rawJSON
 	if len(payload) == 0 {
 		payload = nil
@@ -588,6 +699,7 @@ func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handl
 	opts.Metadata = reqMeta
 	streamResult, err := h.AuthManager.ExecuteStream(ctx, providers, req, opts)
 	if err != nil {
+		release()
 		errChan := make(chan *interfaces.ErrorMessage, 1)
 		status := http.StatusInternalServerError
 		if se, ok := err.(interface{ StatusCode() int }); ok && se != nil {
@@ -605,6 +717,7 @@ func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handl
 		close(errChan)
 		return nil, nil, errChan
 	}
+	setServedModelHeader(ctx, reqMeta)
 	passthroughHeadersEnabled := PassthroughHeadersEnabled(h.Cfg)
 	// Capture upstream headers from the initial connection synchronously before the goroutine starts.
 	// Keep a mutable map so bootstrap retries can replace it before first payload is sent.
@@ -615,13 +728,20 @@ func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handl
 			upstreamHeaders = make(http.Header)
 		}
 	}
+	recorder := newStreamRecorder(cacheKey)
+	if recorder != nil {
+		recorder.headers = cloneHeader(FilterUpstreamHeaders(streamResult.Headers))
+	}
+	policyStream := newResponsePolicyStream(ctx, handlerType, normalizedModel)
 	chunks := streamResult.Chunks
 	dataChan := make(chan []byte)
 	errChan := make(chan *interfaces.ErrorMessage, 1)
 	go func() {
+		defer release()
 		defer close(dataChan)
 		defer close(errChan)
 		sentPayload := false
+		responseBound := false
 		bootstrapRetries := 0
 		maxBootstrapRetries := StreamingBootstrapRetries(h.Cfg)
 
@@ -680,6 +800,18 @@ func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handl
 					chunk, ok = <-chunks
 				}
 				if !ok {
+					payloads, errPolicy := policyStream.flush()
+					if errPolicy != nil {
+						_ = sendErr(errPolicy)
+						return
+					}
+					for _, payload := range payloads {
+						recorder.add(payload)
+						if okSendData := sendData(cloneBytes(payload)); !okSendData {
+							return
+						}
+					}
+					recorder.commit()
 					return
 				}
 				if chunk.Err != nil {
@@ -694,6 +826,9 @@ func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handl
 								if passthroughHeadersEnabled {
 									replaceHeader(upstreamHeaders, FilterUpstreamHeaders(retryResult.Headers))
 								}
+								if recorder != nil {
+									recorder.headers = cloneHeader(FilterUpstreamHeaders(retryResult.Headers))
+								}
 								chunks = retryResult.Chunks
 								continue outer
 							}
@@ -722,11 +857,22 @@ func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handl
 							_ = sendErr(&interfaces.ErrorMessage{StatusCode: http.StatusBadGateway, Error: err})
 							return
 						}
+						if !responseBound {
+							responseBound = h.bindResponseSession(handlerType, normalizedModel, reqMeta, chunk.Payload)
+						}
 					}
-					sentPayload = true
-					if okSendData := sendData(cloneBytes(chunk.Payload)); !okSendData {
+					payloads, errPolicy := policyStream.scan(chunk.Payload)
+					if errPolicy != nil {
+						_ = sendErr(errPolicy)
 						return
 					}
+					sentPayload = true
+					for _, payload := range payloads {
+						recorder.add(payload)
+						if okSendData := sendData(cloneBytes(payload)); !okSendData {
+							return
+						}
+					}
 				}
 			}
 		}
@@ -811,6 +957,18 @@ func (h *BaseAPIHandler) getRequestDetails(modelName string) (providers []string
 	return providers, resolvedModelName, nil
 }
 
+// setServedModelHeader reports the model that served the request, which differs from the
+// requested one when a model-fallbacks chain took over.
+func setServedModelHeader(ctx context.Context, meta map[string]any) {
+	ginCtx := ginContextFrom(ctx)
+	if ginCtx == nil {
+		return
+	}
+	if model, ok := meta[coreexecutor.ServedModelMetadataKey].(string); ok && model != "" {
+		ginCtx.Header(ServedModelHeader, model)
+	}
+}
+
 func cloneBytes(src []byte) []byte {
 	if len(src) == 0 {
 		return nil
@@ -0,0 +1,24 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+)
+
+// CheckImageModel rejects models that are registered without image output, so text-only
+// models are not asked to draw. Unknown models are left to the regular routing.
+func CheckImageModel(modelName string) *interfaces.ErrorMessage {
+	baseModel := thinking.ParseSuffix(modelName).ModelName
+	info := registry.LookupModelInfo(baseModel)
+	if info == nil || info.SupportsImageOutput() {
+		return nil
+	}
+	return &interfaces.ErrorMessage{
+		StatusCode: http.StatusBadRequest,
+		Error:      fmt.Errorf("model %s does not support image generation", baseModel),
+	}
+}
@@ -0,0 +1,312 @@
+package openai
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/batch"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/filestore"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	"github.com/tidwall/gjson"
+)
+
+const (
+	defaultBatchListLimit = 20
+	maxBatchListLimit     = 100
+	// maxBatchLineBytes bounds a single request line of a batch input file.
+	maxBatchLineBytes = 16 << 20
+)
+
+// CreateBatch handles POST /v1/batches, queueing the requests of an uploaded JSONL input
+// file for background execution.
+func (h *OpenAIAPIHandler) CreateBatch(c *gin.Context) {
+	manager, ok := openAIBatchManager(c)
+	if !ok {
+		return
+	}
+	store, ok := fileStore(c)
+	if !ok {
+		return
+	}
+	var body struct {
+		InputFileID      string            `json:"input_file_id"`
+		Endpoint         string            `json:"endpoint"`
+		CompletionWindow string            `json:"completion_window"`
+		Metadata         map[string]string `json:"metadata"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		writeOpenAIError(c, http.StatusBadRequest, "invalid_request_error", fmt.Sprintf("Invalid request: %v", err))
+		return
+	}
+	handlerType, supported := handlers.BatchHandlerType(batch.FormatOpenAI, body.Endpoint)
+	if !supported {
+		writeOpenAIError(c, http.StatusBadRequest, "invalid_request_error", fmt.Sprintf("endpoint: %q is not supported; use /v1/chat/completions, /v1/responses or /v1/embeddings", body.Endpoint))
+		return
+	}
+	if body.CompletionWindow != "24h" {
+		writeOpenAIError(c, http.StatusBadRequest, "invalid_request_error", "completion_window: must be 24h")
+		return
+	}
+
+	apiKey := c.GetString("apiKey")
+	_, content, err := store.Open(body.InputFileID, apiKey)
+	if err != nil {
+		if errors.Is(err, filestore.ErrNotFound) {
+			writeOpenAIError(c, http.StatusBadRequest, "invalid_request_error", fmt.Sprintf("input_file_id: no such File object: %s", body.InputFileID))
+			return
+		}
+		writeOpenAIError(c, http.StatusInternalServerError, "server_error", err.Error())
+		return
+	}
+	defer func() { _ = content.Close() }()
+
+	ctx := context.WithValue(c.Request.Context(), "gin", c)
+	seen := make(map[string]struct{})
+	var requests []batch.Request
+	scanner := bufio.NewScanner(content)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxBatchLineBytes)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if !gjson.Valid(line) {
+			writeOpenAIError(c, http.StatusBadRequest, "invalid_request_error", fmt.Sprintf("input file line %d: invalid JSON", lineNo))
+			return
+		}
+		customID := gjson.Get(line, "custom_id").String()
+		if customID == "" {
+			writeOpenAIError(c, http.StatusBadRequest, "invalid_request_error", fmt.Sprintf("input file line %d: custom_id is required", lineNo))
+			return
+		}
+		if _, dup := seen[customID]; dup {
+			writeOpenAIError(c, http.StatusBadRequest, "invalid_request_error", fmt.Sprintf("input file line %d: duplicate custom_id %q", lineNo, customID))
+			return
+		}
+		seen[customID] = struct{}{}
+		if method := gjson.Get(line, "method").String(); method != "" && !strings.EqualFold(method, http.MethodPost) {
+			writeOpenAIError(c, http.StatusBadRequest, "invalid_request_error", fmt.Sprintf("input file line %d: method must be POST", lineNo))
+			return
+		}
+		if url := gjson.Get(line, "url").String(); url != body.Endpoint {
+			writeOpenAIError(c, http.StatusBadRequest, "invalid_request_error", fmt.Sprintf("input file line %d: url %q does not match the batch endpoint %s", lineNo, url, body.Endpoint))
+			return
+		}
+		requestBody := gjson.Get(line, "body")
+		modelName := requestBody.Get("model").String()
+		if !requestBody.IsObject() || modelName == "" {
+			writeOpenAIError(c, http.StatusBadRequest, "invalid_request_error", fmt.Sprintf("input file line %d: body must be a request with a model", lineNo))
+			return
+		}
+		if errMsg := h.CheckModelAllowed(ctx, handlerType, modelName); errMsg != nil {
+			h.WriteErrorResponse(c, errMsg)
+			return
+		}
+		requests = append(requests, batch.Request{CustomID: customID, Body: json.RawMessage(requestBody.Raw)})
+		if limit := manager.MaxRequests(); limit > 0 && len(requests) > limit {
+			writeOpenAIError(c, http.StatusBadRequest, "invalid_request_error", fmt.Sprintf("input file: a batch may contain at most %d requests", limit))
+			return
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		writeOpenAIError(c, http.StatusBadRequest, "invalid_request_error", fmt.Sprintf("input file: %v", err))
+		return
+	}
+	if len(requests) == 0 {
+		writeOpenAIError(c, http.StatusBadRequest, "invalid_request_error", "input file: contains no requests")
+		return
+	}
+
+	created, err := manager.Create(&batch.Batch{
+		Format:       batch.FormatOpenAI,
+		Endpoint:     body.Endpoint,
+		APIKeyDigest: util.APIKeyDigest(apiKey),
+		Metadata:     body.Metadata,
+		InputFileID:  body.InputFileID,
+	}, requests)
+	if err != nil {
+		writeOpenAIError(c, http.StatusInternalServerError, "server_error", err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, batchObject(created))
+}
+
+// ListBatches handles GET /v1/batches with the limit and after pagination parameters.
+func (h *OpenAIAPIHandler) ListBatches(c *gin.Context) {
+	manager, ok := openAIBatchManager(c)
+	if !ok {
+		return
+	}
+	limit := defaultBatchListLimit
+	if raw := strings.TrimSpace(c.Query("limit")); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 || parsed > maxBatchListLimit {
+			writeOpenAIError(c, http.StatusBadRequest, "invalid_request_error", fmt.Sprintf("limit must be an integer between 1 and %d", maxBatchListLimit))
+			return
+		}
+		limit = parsed
+	}
+
+	apiKey := c.GetString("apiKey")
+	var owned []*batch.Batch
+	for _, b := range manager.List() {
+		if b.Format == batch.FormatOpenAI && b.OwnedBy(apiKey) {
+			owned = append(owned, b)
+		}
+	}
+	if after := c.Query("after"); after != "" {
+		for i, b := range owned {
+			if b.ID == after {
+				owned = owned[i+1:]
+				break
+			}
+		}
+	}
+	hasMore := len(owned) > limit
+	if hasMore {
+		owned = owned[:limit]
+	}
+
+	data := make([]gin.H, 0, len(owned))
+	for _, b := range owned {
+		data = append(data, batchObject(b))
+	}
+	body := gin.H{"object": "list", "data": data, "has_more": hasMore, "first_id": nil, "last_id": nil}
+	if len(owned) > 0 {
+		body["first_id"] = owned[0].ID
+		body["last_id"] = owned[len(owned)-1].ID
+	}
+	c.JSON(http.StatusOK, body)
+}
+
+// GetBatch handles GET /v1/batches/{id}.
+func (h *OpenAIAPIHandler) GetBatch(c *gin.Context) {
+	manager, ok := openAIBatchManager(c)
+	if !ok {
+		return
+	}
+	b, ok := ownedBatch(c, manager)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, batchObject(b))
+}
+
+// CancelBatch handles POST /v1/batches/{id}/cancel.
+func (h *OpenAIAPIHandler) CancelBatch(c *gin.Context) {
+	manager, ok := openAIBatchManager(c)
+	if !ok {
+		return
+	}
+	b, ok := ownedBatch(c, manager)
+	if !ok {
+		return
+	}
+	if b.Status == batch.StatusEnded {
+		writeOpenAIError(c, http.StatusConflict, "invalid_request_error", fmt.Sprintf("Batch %s has already ended and cannot be cancelled.", b.ID))
+		return
+	}
+	canceled, err := manager.Cancel(b.ID)
+	if err != nil {
+		writeOpenAIError(c, http.StatusInternalServerError, "server_error", err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, batchObject(canceled))
+}
+
+// batchObject renders a batch in the shape of the OpenAI Batch object.
+func batchObject(b *batch.Batch) gin.H {
+	object := gin.H{
+		"id":                b.ID,
+		"object":            "batch",
+		"endpoint":          b.Endpoint,
+		"errors":            nil,
+		"input_file_id":     b.InputFileID,
+		"completion_window": "24h",
+		"status":            openAIBatchStatus(b),
+		"output_file_id":    nilIfEmpty(b.OutputFileID),
+		"error_file_id":     nilIfEmpty(b.ErrorFileID),
+		"created_at":        b.CreatedAt.Unix(),
+		"in_progress_at":    b.CreatedAt.Unix(),
+		"expires_at":        b.ExpiresAt.Unix(),
+		"finalizing_at":     nil,
+		"completed_at":      nil,
+		"failed_at":         nil,
+		"expired_at":        nil,
+		"cancelling_at":     nil,
+		"cancelled_at":      nil,
+		"request_counts": gin.H{
+			"total":     b.Counts.Total(),
+			"completed": b.Counts.Succeeded,
+			"failed":    b.Counts.Errored + b.Counts.Expired,
+		},
+		"metadata": b.Metadata,
+	}
+	if !b.CancelRequestedAt.IsZero() {
+		object["cancelling_at"] = b.CancelRequestedAt.Unix()
+	}
+	if b.Status == batch.StatusEnded {
+		ended := b.EndedAt.Unix()
+		object["finalizing_at"] = ended
+		switch openAIBatchStatus(b) {
+		case "cancelled":
+			object["cancelled_at"] = ended
+		case "expired":
+			object["expired_at"] = ended
+		default:
+			object["completed_at"] = ended
+		}
+	}
+	return object
+}
+
+func openAIBatchStatus(b *batch.Batch) string {
+	switch b.Status {
+	case batch.StatusInProgress:
+		return "in_progress"
+	case batch.StatusCanceling:
+		return "cancelling"
+	}
+	switch {
+	case !b.CancelRequestedAt.IsZero():
+		return "cancelled"
+	case b.Counts.Expired > 0 && b.Counts.Succeeded+b.Counts.Errored == 0:
+		return "expired"
+	default:
+		return "completed"
+	}
+}
+
+func nilIfEmpty(value string) any {
+	if value == "" {
+		return nil
+	}
+	return value
+}
+
+func openAIBatchManager(c *gin.Context) (*batch.Manager, bool) {
+	manager := batch.Default()
+	if manager == nil {
+		writeOpenAIError(c, http.StatusNotFound, "invalid_request_error", "Batches are not enabled on this server.")
+		return nil, false
+	}
+	return manager, true
+}
+
+func ownedBatch(c *gin.Context, manager *batch.Manager) (*batch.Batch, bool) {
+	id := c.Param("id")
+	b, err := manager.Get(id)
+	if err != nil || b.Format != batch.FormatOpenAI || !b.OwnedBy(c.GetString("apiKey")) {
+		writeOpenAIError(c, http.StatusNotFound, "invalid_request_error", fmt.Sprintf("No such Batch object: %s", id))
+		return nil, false
+	}
+	return b, true
+}
@@ -0,0 +1,575 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+const (
+	// completionsAlt routes a legacy completions request, unchanged, to the upstream
+	// /completions endpoint of a model that serves it natively.
+	completionsAlt = "completions"
+	// maxCompletionFanOut bounds the upstream requests one /v1/completions request may
+	// fan out to (prompts × best_of).
+	maxCompletionFanOut = 20
+	// maxCompletionLogprobs is the largest logprobs value the completions API accepts.
+	maxCompletionLogprobs = 5
+)
+
+// completionsPlan describes how a legacy completions request is emulated on top of chat
+// completions: one upstream request per prompt and sample, merged into a single response.
+type completionsPlan struct {
+	model   string
+	prompts []string
+	n       int
+	bestOf  int
+	echo    bool
+	// logprobs is the number of alternatives per token the client asked for, or -1 when
+	// log probabilities were not requested.
+	logprobs int
+	stream   bool
+	// chatRequests holds the chat completions request for each prompt.
+	chatRequests [][]byte
+}
+
+// parseCompletionsPlan validates the parameters of a completions request that has to be
+// emulated. It returns an error message for parameters that cannot be honored.
+func parseCompletionsPlan(rawJSON []byte) (*completionsPlan, string) {
+	root := gjson.ParseBytes(rawJSON)
+	plan := &completionsPlan{
+		model:    root.Get("model").String(),
+		n:        1,
+		echo:     root.Get("echo").Bool(),
+		logprobs: -1,
+		stream:   root.Get("stream").Type == gjson.True,
+	}
+
+	prompt := root.Get("prompt")
+	switch {
+	case !prompt.Exists() || prompt.Type == gjson.Null:
+		plan.prompts = []string{""}
+	case prompt.Type == gjson.String:
+		plan.prompts = []string{prompt.String()}
+	case prompt.IsArray():
+		for _, item := range prompt.Array() {
+			if item.Type != gjson.String {
+				return nil, "prompt: token id prompts are not supported; send the prompt as text"
+			}
+			plan.prompts = append(plan.prompts, item.String())
+		}
+		if len(plan.prompts) == 0 {
+			return nil, "prompt: must not be an empty array"
+		}
+	default:
+		return nil, "prompt: must be a string or an array of strings"
+	}
+
+	if suffix := root.Get("suffix"); suffix.Exists() && suffix.String() != "" {
+		return nil, fmt.Sprintf("suffix: model %s does not support fill-in-the-middle", plan.model)
+	}
+
+	var ok bool
+	if plan.n, ok = positiveInt(root.Get("n"), 1); !ok {
+		return nil, "n: must be a positive integer"
+	}
+	if plan.bestOf, ok = positiveInt(root.Get("best_of"), plan.n); !ok {
+		return nil, "best_of: must be a positive integer"
+	}
+	if plan.bestOf < plan.n {
+		return nil, "best_of: must be greater than or equal to n"
+	}
+	if plan.stream && plan.bestOf > plan.n {
+		return nil, "best_of: cannot be greater than n when stream is enabled"
+	}
+	if executions := len(plan.prompts) * plan.bestOf; executions > maxCompletionFanOut {
+		return nil, fmt.Sprintf("n: %d prompt(s) with best_of %d need %d upstream requests; at most %d are allowed", len(plan.prompts), plan.bestOf, executions, maxCompletionFanOut)
+	}
+
+	if logprobs := root.Get("logprobs"); logprobs.Exists() && logprobs.Type != gjson.Null {
+		value, valid := positiveInt(logprobs, 0)
+		if !valid && logprobs.Type == gjson.Number && logprobs.Float() == 0 {
+			value, valid = 0, true
+		}
+		if !valid || value > maxCompletionLogprobs {
+			return nil, fmt.Sprintf("logprobs: must be an integer between 0 and %d", maxCompletionLogprobs)
+		}
+		plan.logprobs = value
+	}
+	if plan.echo && plan.logprobs >= 0 {
+		return nil, "echo: cannot be combined with logprobs because prompt token log probabilities are not available"
+	}
+
+	chatRequest := convertCompletionsRequestToChatCompletions(rawJSON)
+	if plan.bestOf > plan.n {
+		// Ranking the candidates needs their log probabilities even when the client did
+		// not ask for them.
+		chatRequest, _ = sjson.SetBytes(chatRequest, "logprobs", true)
+	}
+	for _, text := range plan.prompts {
+		if text == "" {
+			text = "Complete this:"
+		}
+		request, _ := sjson.SetBytes(chatRequest, "messages.0.content", text)
+		plan.chatRequests = append(plan.chatRequests, request)
+	}
+	return plan, ""
+}
+
+// positiveInt reads an optional integer parameter that must be at least 1.
+func positiveInt(value gjson.Result, fallback int) (int, bool) {
+	if !value.Exists() || value.Type == gjson.Null {
+		return fallback, true
+	}
+	if value.Type != gjson.Number || value.Float() != float64(value.Int()) || value.Int() < 1 {
+		return 0, false
+	}
+	return int(value.Int()), true
+}
+
+// executions returns the number of upstream requests the plan fans out to.
+func (p *completionsPlan) executions() int {
+	return len(p.prompts) * p.bestOf
+}
+
+// supportsNativeCompletions reports whether the upstream of a model serves /completions
+// itself, in which case requests are forwarded unchanged.
+func supportsNativeCompletions(modelName string) bool {
+	if modelName == "" {
+		return false
+	}
+	info := registry.GetGlobalRegistry().GetModelInfo(modelName, "")
+	return info != nil && endpointListContains(info.SupportedEndpoints, registry.CompletionsEndpoint)
+}
+
+// completionSampleContext returns the context for fan-out execution i. Only the first
+// execution carries the gin context, so concurrent executions never write response
+// headers; the others act on behalf of the same client API key.
+func completionSampleContext(ctx context.Context, c *gin.Context, i, executions int) context.Context {
+	if executions > 1 {
+		ctx = handlers.WithoutResponseCache(ctx)
+	}
+	if i == 0 {
+		return ctx
+	}
+	sampleCtx, cancel := context.WithCancel(logging.WithRequestID(context.Background(), logging.GetRequestID(ctx)))
+	context.AfterFunc(ctx, cancel)
+	sampleCtx = handlers.WithoutResponseCache(sampleCtx)
+	return handlers.WithClientAPIKey(sampleCtx, c.GetString("apiKey"))
+}
+
+// logprobsUnavailable reports that the upstream did not return the token log
+// probabilities a request depends on.
+func logprobsUnavailable(modelName string) *interfaces.ErrorMessage {
+	return &interfaces.ErrorMessage{
+		StatusCode: http.StatusBadRequest,
+		Error:      fmt.Errorf("logprobs: model %s did not return token log probabilities", modelName),
+	}
+}
+
+// legacyLogprobs converts chat completions log probabilities into the completions shape,
+// numbering text offsets from offset. It reports false when chat carries none.
+func legacyLogprobs(chat gjson.Result, offset int, withAlternatives bool) (gin.H, int, bool) {
+	content := chat.Get("content")
+	if !content.IsArray() {
+		return nil, offset, false
+	}
+	tokens := make([]string, 0)
+	tokenLogprobs := make([]float64, 0)
+	offsets := make([]int, 0)
+	alternatives := make([]map[string]float64, 0)
+	content.ForEach(func(_, item gjson.Result) bool {
+		token := item.Get("token").String()
+		tokens = append(tokens, token)
+		tokenLogprobs = append(tokenLogprobs, item.Get("logprob").Float())
+		offsets = append(offsets, offset)
+		offset += utf8.RuneCountInString(token)
+		top := make(map[string]float64)
+		item.Get("top_logprobs").ForEach(func(_, alt gjson.Result) bool {
+			top[alt.Get("token").String()] = alt.Get("logprob").Float()
+			return true
+		})
+		alternatives = append(alternatives, top)
+		return true
+	})
+	out := gin.H{"tokens": tokens, "token_logprobs": tokenLogprobs, "top_logprobs": nil, "text_offset": offsets}
+	if withAlternatives {
+		out["top_logprobs"] = alternatives
+	}
+	return out, offset, true
+}
+
+// completionUsage sums the token usage of the fanned-out requests.
+type completionUsage struct {
+	seen             bool
+	promptTokens     int64
+	completionTokens int64
+	totalTokens      int64
+}
+
+func (u *completionUsage) add(usage gjson.Result) {
+	if !usage.IsObject() {
+		return
+	}
+	u.seen = true
+	u.promptTokens += usage.Get("prompt_tokens").Int()
+	u.completionTokens += usage.Get("completion_tokens").Int()
+	u.totalTokens += usage.Get("total_tokens").Int()
+}
+
+// handleNativeCompletionsNonStreamingResponse forwards a completions request unchanged to
+// a model whose upstream serves /completions.
+func (h *OpenAIAPIHandler) handleNativeCompletionsNonStreamingResponse(c *gin.Context, rawJSON []byte) {
+	c.Header("Content-Type", "application/json")
+	modelName := gjson.GetBytes(rawJSON, "model").String()
+	cliCtx, cliCancel := h.GetContextWithCancel(h, c, context.Background())
+	stopKeepAlive := h.StartNonStreamingKeepAlive(c, cliCtx)
+	resp, upstreamHeaders, errMsg := h.ExecuteWithAuthManager(cliCtx, h.HandlerType(), modelName, rawJSON, completionsAlt)
+	stopKeepAlive()
+	if errMsg != nil {
+		h.WriteErrorResponse(c, errMsg)
+		cliCancel(errMsg.Error)
+		return
+	}
+	handlers.WriteUpstreamHeaders(c.Writer.Header(), upstreamHeaders)
+	_, _ = c.Writer.Write(resp)
+	cliCancel()
+}
+
+// handleCompletionsNonStreamingResponse emulates a non-streaming completions request. It
+// runs one chat completions request per prompt and sample in parallel, keeps the best n
+// samples of each prompt and merges them into a single completions response.
+func (h *OpenAIAPIHandler) handleCompletionsNonStreamingResponse(c *gin.Context, plan *completionsPlan) {
+	c.Header("Content-Type", "application/json")
+
+	type sample struct {
+		resp    []byte
+		headers http.Header
+		choice  gin.H
+		score   float64
+	}
+	executions := plan.executions()
+	samples := make([]sample, executions)
+
+	cliCtx, cliCancel := h.GetContextWithCancel(h, c, context.Background())
+	fanCtx, fanCancel := context.WithCancel(cliCtx)
+	defer fanCancel()
+	stopKeepAlive := h.StartNonStreamingKeepAlive(c, cliCtx)
+
+	var (
+		wg       sync.WaitGroup
+		failOnce sync.Once
+		failure  *interfaces.ErrorMessage
+	)
+	for i := range samples {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			ctx := completionSampleContext(fanCtx, c, i, executions)
+			resp, upstreamHeaders, errMsg := h.ExecuteWithAuthManager(ctx, h.HandlerType(), plan.model, plan.chatRequests[i/plan.bestOf], "")
+			if errMsg != nil {
+				failOnce.Do(func() {
+					failure = errMsg
+					fanCancel()
+				})
+				return
+			}
+			samples[i].resp, samples[i].headers = resp, upstreamHeaders
+		}(i)
+	}
+	wg.Wait()
+	stopKeepAlive()
+	if failure != nil {
+		h.WriteErrorResponse(c, failure)
+		cliCancel(failure.Error)
+		return
+	}
+
+	var usage completionUsage
+	for i := range samples {
+		chatChoice := gjson.GetBytes(samples[i].resp, "choices.0")
+		converted := gjson.GetBytes(convertChatCompletionsResponseToCompletions(samples[i].resp), "choices.0")
+		text := converted.Get("text").String()
+		if plan.echo {
+			text = plan.prompts[i/plan.bestOf] + text
+		}
+		choice := gin.H{"text": text, "index": 0, "logprobs": nil, "finish_reason": nil}
+		if finishReason := converted.Get("finish_reason").String(); finishReason != "" {
+			choice["finish_reason"] = finishReason
+		}
+		if plan.logprobs >= 0 || plan.bestOf > plan.n {
+			logprobs, _, ok := legacyLogprobs(chatChoice.Get("logprobs"), 0, plan.logprobs > 0)
+			if !ok {
+				errMsg := logprobsUnavailable(plan.model)
+				h.WriteErrorResponse(c, errMsg)
+				cliCancel(errMsg.Error)
+				return
+			}
+			for _, logprob := range chatChoice.Get("logprobs.content.#.logprob").Array() {
+				samples[i].score += logprob.Float()
+			}
+			if plan.logprobs >= 0 {
+				choice["logprobs"] = logprobs
+			}
+		}
+		samples[i].choice = choice
+		usage.add(gjson.GetBytes(samples[i].resp, "usage"))
+	}
+
+	choices := make([]gin.H, 0, len(plan.prompts)*plan.n)
+	for p := range plan.prompts {
+		group := samples[p*plan.bestOf : (p+1)*plan.bestOf]
+		if plan.bestOf > plan.n {
+			sort.SliceStable(group, func(a, b int) bool { return group[a].score > group[b].score })
+		}
+		for _, s := range group[:plan.n] {
+			s.choice["index"] = len(choices)
+			choices = append(choices, s.choice)
+		}
+	}
+
+	first := gjson.ParseBytes(samples[0].resp)
+	handlers.WriteUpstreamHeaders(c.Writer.Header(), samples[0].headers)
+	_, _ = c.Writer.Write(completionResponse(first.Get("id").String(), first.Get("created").Int(), first.Get("model").String(), choices, &usage))
+	cliCancel()
+}
+
+// completionStream converts the chat completions chunks of one fanned-out stream into
+// completions chunks for its choice index.
+type completionStream struct {
+	plan   *completionsPlan
+	native bool
+	index  int
+	prompt string
+
+	started bool
+	offset  int
+	usage   completionUsage
+	id      string
+	created int64
+	model   string
+}
+
+// convert returns the completions chunk for an upstream chunk, or nil when the chunk
+// carries nothing to forward. Usage is collected and reported once for all streams.
+func (s *completionStream) convert(chunk []byte) ([]byte, *interfaces.ErrorMessage) {
+	if s.native {
+		return chunk, nil
+	}
+	root := gjson.ParseBytes(chunk)
+	s.usage.add(root.Get("usage"))
+	if s.id == "" {
+		s.id, s.created, s.model = root.Get("id").String(), root.Get("created").Int(), root.Get("model").String()
+	}
+	converted := gjson.GetBytes(convertChatCompletionsStreamChunkToCompletions(chunk), "choices.0")
+	if !converted.Exists() {
+		return nil, nil
+	}
+	text := converted.Get("text").String()
+	finishReason := converted.Get("finish_reason").String()
+	if text == "" && finishReason == "" {
+		return nil, nil
+	}
+
+	choice := gin.H{"text": text, "index": s.index, "logprobs": nil, "finish_reason": nil}
+	if finishReason != "" {
+		choice["finish_reason"] = finishReason
+	}
+	if s.plan.logprobs >= 0 && text != "" {
+		logprobs, offset, ok := legacyLogprobs(root.Get("choices.0.logprobs"), s.offset, s.plan.logprobs > 0)
+		if !ok {
+			return nil, logprobsUnavailable(s.plan.model)
+		}
+		s.offset = offset
+		choice["logprobs"] = logprobs
+	}
+	if !s.started && s.plan.echo {
+		choice["text"] = s.prompt + text
+	}
+	s.started = true
+	return completionResponse(s.id, s.created, s.model, []gin.H{choice}, nil), nil
+}
+
+// completionResponse builds a completions response or stream chunk.
+func completionResponse(id string, created int64, model string, choices []gin.H, usage *completionUsage) []byte {
+	out := `{"id":"","object":"text_completion","created":0,"model":"","choices":[]}`
+	out, _ = sjson.Set(out, "id", id)
+	out, _ = sjson.Set(out, "created", created)
+	out, _ = sjson.Set(out, "model", model)
+	if len(choices) > 0 {
+		choicesJSON, _ := json.Marshal(choices)
+		out, _ = sjson.SetRaw(out, "choices", string(choicesJSON))
+	}
+	if usage != nil && usage.seen {
+		out, _ = sjson.Set(out, "usage.prompt_tokens", usage.promptTokens)
+		out, _ = sjson.Set(out, "usage.completion_tokens", usage.completionTokens)
+		out, _ = sjson.Set(out, "usage.total_tokens", usage.totalTokens)
+	}
+	return []byte(out)
+}
+
+// handleCompletionsStreamingResponse streams a completions request. Emulated requests run
+// one chat completions stream per prompt and sample in parallel and interleave their
+// chunks under the choice index of each stream; native requests forward a single stream.
+func (h *OpenAIAPIHandler) handleCompletionsStreamingResponse(c *gin.Context, rawJSON []byte, plan *completionsPlan) {
+	// Get the http.Flusher interface to manually flush the response.
+	flusher, ok := c.Writer.(http.Flusher)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, handlers.ErrorResponse{
+			Error: handlers.ErrorDetail{
+				Message: "Streaming not supported",
+				Type:    "server_error",
+			},
+		})
+		return
+	}
+
+	var streams []*completionStream
+	if plan == nil {
+		streams = []*completionStream{{native: true}}
+	} else {
+		for i := 0; i < plan.executions(); i++ {
+			streams = append(streams, &completionStream{plan: plan, index: i, prompt: plan.prompts[i/plan.bestOf]})
+		}
+	}
+	modelName := gjson.GetBytes(rawJSON, "model").String()
+
+	cliCtx, cliCancel := h.GetContextWithCancel(h, c, context.Background())
+	fanCtx, fanCancel := context.WithCancel(cliCtx)
+	data := make(chan []byte)
+	errs := make(chan *interfaces.ErrorMessage, 1)
+
+	var (
+		wg              sync.WaitGroup
+		failOnce        sync.Once
+		headerOnce      sync.Once
+		upstreamHeaders http.Header
+	)
+	fail := func(errMsg *interfaces.ErrorMessage) {
+		failOnce.Do(func() {
+			errs <- errMsg
+			fanCancel()
+		})
+	}
+	for i, stream := range streams {
+		wg.Add(1)
+		go func(i int, stream *completionStream) {
+			defer wg.Done()
+			payload, alt := rawJSON, completionsAlt
+			if !stream.native {
+				payload, alt = plan.chatRequests[i/plan.bestOf], ""
+			}
+			ctx := completionSampleContext(fanCtx, c, i, len(streams))
+			chunks, headers, upstreamErrs := h.ExecuteStreamWithAuthManager(ctx, h.HandlerType(), modelName, payload, alt)
+			headerOnce.Do(func() { upstreamHeaders = headers })
+			for chunks != nil || upstreamErrs != nil {
+				select {
+				case <-fanCtx.Done():
+					return
+				case chunk, ok := <-chunks:
+					if !ok {
+						chunks = nil
+						continue
+					}
+					converted, errMsg := stream.convert(chunk)
+					if errMsg != nil {
+						fail(errMsg)
+						return
+					}
+					if converted == nil {
+						continue
+					}
+					select {
+					case <-fanCtx.Done():
+						return
+					case data <- converted:
+					}
+				case errMsg, ok := <-upstreamErrs:
+					if !ok {
+						upstreamErrs = nil
+						continue
+					}
+					if errMsg != nil {
+						fail(errMsg)
+						return
+					}
+				}
+			}
+		}(i, stream)
+	}
+	go func() {
+		defer close(data)
+		wg.Wait()
+		var usage completionUsage
+		var last *completionStream
+		for _, stream := range streams {
+			if stream.usage.seen {
+				usage.promptTokens += stream.usage.promptTokens
+				usage.completionTokens += stream.usage.completionTokens
+				usage.totalTokens += stream.usage.totalTokens
+				usage.seen = true
+				last = stream
+			}
+		}
+		if !usage.seen {
+			return
+		}
+		select {
+		case <-fanCtx.Done():
+		case data <- completionResponse(last.id, last.created, last.model, nil, &usage):
+		}
+	}()
+	cancel := func(err error) {
+		fanCancel()
+		cliCancel(err)
+	}
+
+	setSSEHeaders := func() {
+		c.Header("Content-Type", "text/event-stream")
+		c.Header("Cache-Control", "no-cache")
+		c.Header("Connection", "keep-alive")
+		c.Header("Access-Control-Allow-Origin", "*")
+	}
+
+	// Peek at the first chunk so that failures before any output keep their status code.
+	select {
+	case <-c.Request.Context().Done():
+		cancel(c.Request.Context().Err())
+	case errMsg := <-errs:
+		h.WriteErrorResponse(c, errMsg)
+		cancel(errMsg.Error)
+	case chunk, ok := <-data:
+		if !ok {
+			select {
+			case errMsg := <-errs:
+				h.WriteErrorResponse(c, errMsg)
+				cancel(errMsg.Error)
+				return
+			default:
+			}
+		}
+		setSSEHeaders()
+		handlers.WriteUpstreamHeaders(c.Writer.Header(), upstreamHeaders)
+		if !ok {
+			_, _ = fmt.Fprintf(c.Writer, "data: [DONE]\n\n")
+			flusher.Flush()
+			cancel(nil)
+			return
+		}
+		_, _ = fmt.Fprintf(c.Writer, "data: %s\n\n", string(chunk))
+		flusher.Flush()
+		h.handleStreamResult(c, flusher, cancel, data, errs)
+	}
+}
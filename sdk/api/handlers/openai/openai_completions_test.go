@@ -0,0 +1,259 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+// completionsExecutor answers chat completions requests with "sample <k>" for the k-th
+// call, whose single token has log probability -k.
+type completionsExecutor struct {
+	mu       sync.Mutex
+	calls    int
+	payloads []string
+	alts     []string
+	// withoutLogprobs drops log probabilities from every answer.
+	withoutLogprobs bool
+}
+
+func (e *completionsExecutor) Identifier() string { return "completions-provider" }
+
+func (e *completionsExecutor) next(req coreexecutor.Request, opts coreexecutor.Options) int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.calls++
+	e.payloads = append(e.payloads, string(req.Payload))
+	e.alts = append(e.alts, opts.Alt)
+	return e.calls
+}
+
+func (e *completionsExecutor) logprobs(k int) string {
+	if e.withoutLogprobs {
+		return "null"
+	}
+	return fmt.Sprintf(`{"content":[{"token":"sample %d","logprob":-%d,"top_logprobs":[{"token":"sample %d","logprob":-%d}]}]}`, k, k, k, k)
+}
+
+func (e *completionsExecutor) Execute(ctx context.Context, auth *coreauth.Auth, req coreexecutor.Request, opts coreexecutor.Options) (coreexecutor.Response, error) {
+	k := e.next(req, opts)
+	if opts.Alt == completionsAlt {
+		return coreexecutor.Response{Payload: []byte(`{"id":"cmpl-native","object":"text_completion","choices":[{"text":"middle","index":0,"finish_reason":"stop"}]}`)}, nil
+	}
+	body := fmt.Sprintf(`{"id":"chatcmpl-%d","object":"chat.completion","created":1,"model":"completions-model","choices":[{"index":0,"message":{"role":"assistant","content":"sample %d"},"logprobs":%s,"finish_reason":"stop"}],"usage":{"prompt_tokens":2,"completion_tokens":1,"total_tokens":3}}`, k, k, e.logprobs(k))
+	return coreexecutor.Response{Payload: []byte(body)}, nil
+}
+
+func (e *completionsExecutor) ExecuteStream(ctx context.Context, auth *coreauth.Auth, req coreexecutor.Request, opts coreexecutor.Options) (*coreexecutor.StreamResult, error) {
+	k := e.next(req, opts)
+	chunks := make(chan coreexecutor.StreamChunk, 3)
+	chunks <- coreexecutor.StreamChunk{Payload: []byte(fmt.Sprintf(`{"id":"chatcmpl-%d","created":1,"model":"completions-model","choices":[{"index":0,"delta":{"content":"sample %d"},"logprobs":%s,"finish_reason":null}]}`, k, k, e.logprobs(k)))}
+	chunks <- coreexecutor.StreamChunk{Payload: []byte(fmt.Sprintf(`{"id":"chatcmpl-%d","created":1,"model":"completions-model","choices":[{"index":0,"delta":{},"finish_reason":"stop"}]}`, k))}
+	chunks <- coreexecutor.StreamChunk{Payload: []byte(fmt.Sprintf(`{"id":"chatcmpl-%d","created":1,"model":"completions-model","choices":[],"usage":{"prompt_tokens":2,"completion_tokens":1,"total_tokens":3}}`, k))}
+	close(chunks)
+	return &coreexecutor.StreamResult{Chunks: chunks}, nil
+}
+
+func (e *completionsExecutor) Refresh(ctx context.Context, auth *coreauth.Auth) (*coreauth.Auth, error) {
+	return auth, nil
+}
+
+func (e *completionsExecutor) CountTokens(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{}, errors.New("not implemented")
+}
+
+func (e *completionsExecutor) HttpRequest(context.Context, *coreauth.Auth, *http.Request) (*http.Response, error) {
+	return nil, errors.New("not implemented")
+}
+
+func newCompletionsRouter(t *testing.T, executor *completionsExecutor) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(executor)
+
+	auth := &coreauth.Auth{ID: "auth-completions", Provider: executor.Identifier(), Status: coreauth.StatusActive}
+	if _, err := manager.Register(context.Background(), auth); err != nil {
+		t.Fatalf("Register auth: %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient(auth.ID, auth.Provider, []*registry.ModelInfo{
+		{ID: "completions-model"},
+		{ID: "native-completions-model", SupportedEndpoints: []string{"/chat/completions", registry.CompletionsEndpoint}},
+	})
+	t.Cleanup(func() {
+		registry.GetGlobalRegistry().UnregisterClient(auth.ID)
+	})
+
+	h := NewOpenAIAPIHandler(handlers.NewBaseAPIHandlers(&sdkconfig.SDKConfig{}, manager))
+	router := gin.New()
+	router.POST("/v1/completions", h.Completions)
+	return router
+}
+
+func postCompletions(router *gin.Engine, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/v1/completions", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	return resp
+}
+
+func TestCompletionsFansOutPromptsAndSamples(t *testing.T) {
+	executor := &completionsExecutor{}
+	router := newCompletionsRouter(t, executor)
+
+	resp := postCompletions(router, `{"model":"completions-model","prompt":["first","second"],"n":2,"echo":true}`)
+	if resp.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", resp.Code, resp.Body.String())
+	}
+	if executor.calls != 4 {
+		t.Fatalf("upstream calls = %d, want 4", executor.calls)
+	}
+	choices := gjson.Get(resp.Body.String(), "choices").Array()
+	if len(choices) != 4 {
+		t.Fatalf("choices = %s", resp.Body.String())
+	}
+	for i, choice := range choices {
+		if got := choice.Get("index").Int(); got != int64(i) {
+			t.Fatalf("choice %d index = %d", i, got)
+		}
+		prompt := "first"
+		if i >= 2 {
+			prompt = "second"
+		}
+		if text := choice.Get("text").String(); !strings.HasPrefix(text, prompt+"sample ") {
+			t.Fatalf("choice %d text = %q, want echoed prompt %q", i, text, prompt)
+		}
+	}
+	if got := gjson.Get(resp.Body.String(), "usage.total_tokens").Int(); got != 12 {
+		t.Fatalf("usage.total_tokens = %d, want 12", got)
+	}
+	for _, payload := range executor.payloads {
+		if gjson.Get(payload, "echo").Exists() || gjson.Get(payload, "n").Exists() {
+			t.Fatalf("chat payload carries completions-only parameters: %s", payload)
+		}
+	}
+}
+
+func TestCompletionsBestOfKeepsMostLikelySample(t *testing.T) {
+	executor := &completionsExecutor{}
+	router := newCompletionsRouter(t, executor)
+
+	resp := postCompletions(router, `{"model":"completions-model","prompt":"p","best_of":3,"logprobs":1}`)
+	if resp.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", resp.Code, resp.Body.String())
+	}
+	choices := gjson.Get(resp.Body.String(), "choices").Array()
+	if len(choices) != 1 || choices[0].Get("text").String() != "sample 1" {
+		t.Fatalf("choices = %s, want only sample 1", resp.Body.String())
+	}
+	logprobs := choices[0].Get("logprobs")
+	if logprobs.Get("tokens.0").String() != "sample 1" || logprobs.Get("token_logprobs.0").Float() != -1 || logprobs.Get("text_offset.0").Int() != 0 {
+		t.Fatalf("logprobs = %s", logprobs.Raw)
+	}
+	if logprobs.Get(`top_logprobs.0.sample 1`).Float() != -1 {
+		t.Fatalf("top_logprobs = %s", logprobs.Get("top_logprobs").Raw)
+	}
+}
+
+func TestCompletionsRejectsParametersThatCannotBeHonored(t *testing.T) {
+	executor := &completionsExecutor{}
+	router := newCompletionsRouter(t, executor)
+
+	for name, body := range map[string]string{
+		"suffix":          `{"model":"completions-model","prompt":"def f(","suffix":"return x"}`,
+		"token prompt":    `{"model":"completions-model","prompt":[1,2,3]}`,
+		"best_of below n": `{"model":"completions-model","prompt":"p","n":3,"best_of":2}`,
+		"stream best_of":  `{"model":"completions-model","prompt":"p","best_of":2,"stream":true}`,
+		"echo logprobs":   `{"model":"completions-model","prompt":"p","echo":true,"logprobs":0}`,
+		"fan-out limit":   `{"model":"completions-model","prompt":"p","n":21}`,
+	} {
+		if resp := postCompletions(router, body); resp.Code != http.StatusBadRequest {
+			t.Fatalf("%s: status = %d, want 400, body %s", name, resp.Code, resp.Body.String())
+		}
+	}
+	if executor.calls != 0 {
+		t.Fatalf("upstream calls = %d, want 0", executor.calls)
+	}
+
+	executor.withoutLogprobs = true
+	resp := postCompletions(router, `{"model":"completions-model","prompt":"p","logprobs":0}`)
+	if resp.Code != http.StatusBadRequest || !strings.Contains(resp.Body.String(), "log probabilities") {
+		t.Fatalf("missing logprobs: status = %d, body %s", resp.Code, resp.Body.String())
+	}
+}
+
+func TestCompletionsStreamMergesSamples(t *testing.T) {
+	executor := &completionsExecutor{}
+	router := newCompletionsRouter(t, executor)
+
+	resp := postCompletions(router, `{"model":"completions-model","prompt":"p","n":2,"stream":true}`)
+	if resp.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", resp.Code, resp.Body.String())
+	}
+	texts := map[int64]string{}
+	finished := map[int64]bool{}
+	usageChunks := 0
+	var lines []string
+	for _, line := range strings.Split(resp.Body.String(), "\n") {
+		if strings.HasPrefix(line, "data: ") {
+			lines = append(lines, strings.TrimPrefix(line, "data: "))
+		}
+	}
+	if len(lines) == 0 || lines[len(lines)-1] != "[DONE]" {
+		t.Fatalf("stream did not end with [DONE]: %s", resp.Body.String())
+	}
+	for _, line := range lines[:len(lines)-1] {
+		if gjson.Get(line, "usage").Exists() {
+			usageChunks++
+			if got := gjson.Get(line, "usage.total_tokens").Int(); got != 6 {
+				t.Fatalf("usage.total_tokens = %d, want 6", got)
+			}
+		}
+		for _, choice := range gjson.Get(line, "choices").Array() {
+			index := choice.Get("index").Int()
+			texts[index] += choice.Get("text").String()
+			if choice.Get("finish_reason").String() == "stop" {
+				finished[index] = true
+			}
+		}
+	}
+	if usageChunks != 1 {
+		t.Fatalf("usage chunks = %d, want 1", usageChunks)
+	}
+	if len(texts) != 2 || !finished[0] || !finished[1] || texts[0] == texts[1] {
+		t.Fatalf("merged choices = %v finished %v", texts, finished)
+	}
+}
+
+func TestCompletionsForwardsNativeRequests(t *testing.T) {
+	executor := &completionsExecutor{}
+	router := newCompletionsRouter(t, executor)
+
+	resp := postCompletions(router, `{"model":"native-completions-model","prompt":"def f(","suffix":"return x","n":2}`)
+	if resp.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", resp.Code, resp.Body.String())
+	}
+	if executor.calls != 1 || executor.alts[0] != completionsAlt {
+		t.Fatalf("calls = %d alts = %v, want one native call", executor.calls, executor.alts)
+	}
+	if got := gjson.Get(executor.payloads[0], "suffix").String(); got != "return x" {
+		t.Fatalf("native payload suffix = %q, payload %s", got, executor.payloads[0])
+	}
+	if got := gjson.Get(resp.Body.String(), "choices.0.text").String(); got != "middle" {
+		t.Fatalf("response = %s", resp.Body.String())
+	}
+}
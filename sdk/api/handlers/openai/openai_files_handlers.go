@@ -0,0 +1,166 @@
+package openai
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/filestore"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	log "github.com/sirupsen/logrus"
+)
+
+// UploadFile handles POST /v1/files, storing a multipart "file" with its "purpose".
+func (h *OpenAIAPIHandler) UploadFile(c *gin.Context) {
+	store, ok := fileStore(c)
+	if !ok {
+		return
+	}
+	purpose := c.PostForm("purpose")
+	if purpose == "" {
+		writeOpenAIError(c, http.StatusBadRequest, "invalid_request_error", "purpose: field is required")
+		return
+	}
+	header, err := c.FormFile("file")
+	if err != nil {
+		writeOpenAIError(c, http.StatusBadRequest, "invalid_request_error", "file: field is required")
+		return
+	}
+	if limit := store.MaxBytes(); limit > 0 && header.Size > limit {
+		writeFileTooLarge(c, limit)
+		return
+	}
+	content, err := header.Open()
+	if err != nil {
+		writeOpenAIError(c, http.StatusBadRequest, "invalid_request_error", fmt.Sprintf("file: %v", err))
+		return
+	}
+	defer func() { _ = content.Close() }()
+
+	file, err := store.Create(c.GetString("apiKey"), header.Filename, purpose, content)
+	if err != nil {
+		if errors.Is(err, filestore.ErrTooLarge) {
+			writeFileTooLarge(c, store.MaxBytes())
+			return
+		}
+		writeOpenAIError(c, http.StatusInternalServerError, "server_error", err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, fileObject(file))
+}
+
+// ListFiles handles GET /v1/files, optionally filtered by purpose.
+func (h *OpenAIAPIHandler) ListFiles(c *gin.Context) {
+	store, ok := fileStore(c)
+	if !ok {
+		return
+	}
+	files, err := store.List(c.GetString("apiKey"), c.Query("purpose"))
+	if err != nil {
+		writeOpenAIError(c, http.StatusInternalServerError, "server_error", err.Error())
+		return
+	}
+	data := make([]gin.H, 0, len(files))
+	for _, file := range files {
+		data = append(data, fileObject(file))
+	}
+	c.JSON(http.StatusOK, gin.H{"object": "list", "data": data, "has_more": false})
+}
+
+// GetFile handles GET /v1/files/{id}.
+func (h *OpenAIAPIHandler) GetFile(c *gin.Context) {
+	store, ok := fileStore(c)
+	if !ok {
+		return
+	}
+	file, err := store.Get(c.Param("id"), c.GetString("apiKey"))
+	if err != nil {
+		writeFileError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, fileObject(file))
+}
+
+// GetFileContent handles GET /v1/files/{id}/content.
+func (h *OpenAIAPIHandler) GetFileContent(c *gin.Context) {
+	store, ok := fileStore(c)
+	if !ok {
+		return
+	}
+	file, content, err := store.Open(c.Param("id"), c.GetString("apiKey"))
+	if err != nil {
+		writeFileError(c, err)
+		return
+	}
+	defer func() { _ = content.Close() }()
+	c.Header("Content-Type", "application/octet-stream")
+	c.Header("Content-Length", strconv.FormatInt(file.Bytes, 10))
+	c.Status(http.StatusOK)
+	if _, err = io.Copy(c.Writer, content); err != nil {
+		log.Debugf("files: send %s: %v", file.ID, err)
+	}
+}
+
+// DeleteFile handles DELETE /v1/files/{id}.
+func (h *OpenAIAPIHandler) DeleteFile(c *gin.Context) {
+	store, ok := fileStore(c)
+	if !ok {
+		return
+	}
+	id := c.Param("id")
+	if err := store.Delete(id, c.GetString("apiKey")); err != nil {
+		writeFileError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"id": id, "object": "file", "deleted": true})
+}
+
+func fileObject(file *filestore.File) gin.H {
+	var expiresAt any
+	if !file.ExpiresAt.IsZero() {
+		expiresAt = file.ExpiresAt.Unix()
+	}
+	return gin.H{
+		"id":         file.ID,
+		"object":     "file",
+		"bytes":      file.Bytes,
+		"created_at": file.CreatedAt.Unix(),
+		"filename":   file.Filename,
+		"purpose":    file.Purpose,
+		"status":     "processed",
+		"expires_at": expiresAt,
+	}
+}
+
+func fileStore(c *gin.Context) (*filestore.Store, bool) {
+	store := filestore.Default()
+	if store == nil {
+		writeOpenAIError(c, http.StatusNotFound, "invalid_request_error", "File uploads are not enabled on this server.")
+		return nil, false
+	}
+	return store, true
+}
+
+func writeFileError(c *gin.Context, err error) {
+	if errors.Is(err, filestore.ErrNotFound) {
+		writeOpenAIError(c, http.StatusNotFound, "invalid_request_error", fmt.Sprintf("No such File object: %s", c.Param("id")))
+		return
+	}
+	writeOpenAIError(c, http.StatusInternalServerError, "server_error", err.Error())
+}
+
+func writeFileTooLarge(c *gin.Context, limit int64) {
+	writeOpenAIError(c, http.StatusRequestEntityTooLarge, "invalid_request_error", fmt.Sprintf("file: exceeds the maximum size of %d bytes", limit))
+}
+
+func writeOpenAIError(c *gin.Context, status int, errType, message string) {
+	c.JSON(status, handlers.ErrorResponse{
+		Error: handlers.ErrorDetail{
+			Message: message,
+			Type:    errType,
+		},
+	})
+}
@@ -0,0 +1,163 @@
+package openai
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	. "github.com/router-for-me/CLIProxyAPI/v6/internal/constant"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// ImageGenerations handles the /v1/images/generations endpoint.
+// The request is translated to generateContent with image output and routed through the
+// auth manager to Gemini, Vertex AI or Antigravity credentials serving the model.
+//
+// Parameters:
+//   - c: The Gin context containing the HTTP request and response
+func (h *OpenAIAPIHandler) ImageGenerations(c *gin.Context) {
+	rawJSON, err := c.GetRawData()
+	if err != nil {
+		writeImageRequestError(c, fmt.Sprintf("Invalid request: %v", err))
+		return
+	}
+	h.handleImageRequest(c, rawJSON)
+}
+
+// ImageEdits handles the /v1/images/edits endpoint.
+// It accepts the multipart form used by the OpenAI SDKs as well as a JSON body whose
+// "images" and "mask" carry data URLs.
+//
+// Parameters:
+//   - c: The Gin context containing the HTTP request and response
+func (h *OpenAIAPIHandler) ImageEdits(c *gin.Context) {
+	var rawJSON []byte
+	var err error
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		rawJSON, err = imageEditFormToJSON(c)
+	} else {
+		rawJSON, err = c.GetRawData()
+	}
+	if err != nil {
+		writeImageRequestError(c, fmt.Sprintf("Invalid request: %v", err))
+		return
+	}
+	images := gjson.GetBytes(rawJSON, "images").Array()
+	if len(images) == 0 {
+		writeImageRequestError(c, "Invalid request: image is required")
+		return
+	}
+	for _, image := range append(images, gjson.GetBytes(rawJSON, "mask")) {
+		url := image.String()
+		if image.IsObject() {
+			url = image.Get("image_url").String()
+		}
+		if image.Exists() && !strings.HasPrefix(url, "data:") {
+			writeImageRequestError(c, "Invalid request: images must be uploaded or given as base64 data URLs")
+			return
+		}
+	}
+	h.handleImageRequest(c, rawJSON)
+}
+
+func (h *OpenAIAPIHandler) handleImageRequest(c *gin.Context, rawJSON []byte) {
+	modelName := gjson.GetBytes(rawJSON, "model").String()
+	if modelName == "" {
+		writeImageRequestError(c, "Invalid request: model is required")
+		return
+	}
+	if strings.TrimSpace(gjson.GetBytes(rawJSON, "prompt").String()) == "" {
+		writeImageRequestError(c, "Invalid request: prompt is required")
+		return
+	}
+	if errMsg := handlers.CheckImageModel(modelName); errMsg != nil {
+		h.WriteErrorResponse(c, errMsg)
+		return
+	}
+
+	c.Header("Content-Type", "application/json")
+	cliCtx, cliCancel := h.GetContextWithCancel(h, c, context.Background())
+	stopKeepAlive := h.StartNonStreamingKeepAlive(c, cliCtx)
+	resp, upstreamHeaders, errMsg := h.ExecuteWithAuthManager(cliCtx, OpenAIImage, modelName, rawJSON, "")
+	stopKeepAlive()
+	if errMsg != nil {
+		h.WriteErrorResponse(c, errMsg)
+		cliCancel(errMsg.Error)
+		return
+	}
+	handlers.WriteUpstreamHeaders(c.Writer.Header(), upstreamHeaders)
+	_, _ = c.Writer.Write(resp)
+	cliCancel()
+}
+
+// imageEditFormToJSON converts a multipart image edit request into the JSON form, with the
+// uploaded image and mask files encoded as data URLs.
+func imageEditFormToJSON(c *gin.Context) ([]byte, error) {
+	form, err := c.MultipartForm()
+	if err != nil {
+		return nil, err
+	}
+	out := []byte(`{}`)
+	for _, field := range []string{"model", "prompt", "size", "quality", "response_format"} {
+		if values := form.Value[field]; len(values) > 0 {
+			out, _ = sjson.SetBytes(out, field, values[0])
+		}
+	}
+	if values := form.Value["n"]; len(values) > 0 {
+		n, errParse := strconv.Atoi(values[0])
+		if errParse != nil {
+			return nil, fmt.Errorf("n must be an integer")
+		}
+		out, _ = sjson.SetBytes(out, "n", n)
+	}
+	files := append(form.File["image"], form.File["image[]"]...)
+	for _, file := range files {
+		url, errRead := imageFileDataURL(file)
+		if errRead != nil {
+			return nil, errRead
+		}
+		out, _ = sjson.SetBytes(out, "images.-1", map[string]string{"image_url": url})
+	}
+	if masks := form.File["mask"]; len(masks) > 0 {
+		url, errRead := imageFileDataURL(masks[0])
+		if errRead != nil {
+			return nil, errRead
+		}
+		out, _ = sjson.SetBytes(out, "mask", map[string]string{"image_url": url})
+	}
+	return out, nil
+}
+
+func imageFileDataURL(header *multipart.FileHeader) (string, error) {
+	file, err := header.Open()
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = file.Close() }()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return "", err
+	}
+	mimeType := header.Header.Get("Content-Type")
+	if mimeType == "" || mimeType == "application/octet-stream" {
+		mimeType = http.DetectContentType(data)
+	}
+	return "data:" + mimeType + ";base64," + base64.StdEncoding.EncodeToString(data), nil
+}
+
+func writeImageRequestError(c *gin.Context, message string) {
+	c.JSON(http.StatusBadRequest, handlers.ErrorResponse{
+		Error: handlers.ErrorDetail{
+			Message: message,
+			Type:    "invalid_request_error",
+		},
+	})
+}
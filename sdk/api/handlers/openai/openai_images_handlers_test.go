@@ -0,0 +1,63 @@
+package openai
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tidwall/gjson"
+)
+
+func TestImageEditFormToJSON(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	_ = writer.WriteField("model", "gemini-2.5-flash-image")
+	_ = writer.WriteField("prompt", "add a hat")
+	_ = writer.WriteField("n", "2")
+	image, _ := writer.CreateFormFile("image[]", "cat.png")
+	_, _ = image.Write([]byte("\x89PNG\r\n\x1a\nrest"))
+	mask, _ := writer.CreateFormFile("mask", "mask.png")
+	_, _ = mask.Write([]byte("\x89PNG\r\n\x1a\nmask"))
+	_ = writer.Close()
+
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+	c.Request = httptest.NewRequest(http.MethodPost, "/v1/images/edits", &body)
+	c.Request.Header.Set("Content-Type", writer.FormDataContentType())
+
+	out, err := imageEditFormToJSON(c)
+	if err != nil {
+		t.Fatalf("imageEditFormToJSON: %v", err)
+	}
+	if got := gjson.GetBytes(out, "prompt").String(); got != "add a hat" {
+		t.Errorf("prompt = %q", got)
+	}
+	if got := gjson.GetBytes(out, "n").Int(); got != 2 {
+		t.Errorf("n = %d, want 2", got)
+	}
+	if got := gjson.GetBytes(out, "images.0.image_url").String(); got != "data:image/png;base64,iVBORw0KGgpyZXN0" {
+		t.Errorf("image url = %q", got)
+	}
+	if !gjson.GetBytes(out, "mask.image_url").Exists() {
+		t.Errorf("mask missing: %s", out)
+	}
+}
+
+func TestImageEditsRejectsRemoteImages(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+	c.Request = httptest.NewRequest(http.MethodPost, "/v1/images/edits", bytes.NewBufferString(`{"model":"gemini-2.5-flash-image","prompt":"x","images":[{"image_url":"https://example.com/a.png"}]}`))
+	c.Request.Header.Set("Content-Type", "application/json")
+
+	(&OpenAIAPIHandler{}).ImageEdits(c)
+	if recorder.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400", recorder.Code)
+	}
+}
@@ -83,6 +83,11 @@ func (h *OpenAIResponsesAPIHandler) Responses(c *gin.Context) {
 		return
 	}
 
+	rawJSON, ok := prepareResponseStore(c, rawJSON)
+	if !ok {
+		return
+	}
+
 	// Check if the client requested a streaming response.
 	streamResult := gjson.GetBytes(rawJSON, "stream")
 	stream := streamResult.Type == gjson.True
@@ -174,6 +179,7 @@ func (h *OpenAIResponsesAPIHandler) handleNonStreamingResponse(c *gin.Context, r
 	}
 	handlers.WriteUpstreamHeaders(c.Writer.Header(), upstreamHeaders)
 	_, _ = c.Writer.Write(resp)
+	saveResponse(c, resp)
 	cliCancel()
 }
 
@@ -200,6 +206,7 @@ func (h *OpenAIResponsesAPIHandler) handleNonStreamingResponseViaChat(c *gin.Con
 		return
 	}
 	_, _ = c.Writer.Write([]byte(converted))
+	saveResponse(c, []byte(converted))
 	cliCancel()
 }
 
@@ -277,6 +284,7 @@ func (h *OpenAIResponsesAPIHandler) handleStreamingResponse(c *gin.Context, rawJ
 			_, _ = c.Writer.Write(chunk)
 			_, _ = c.Writer.Write([]byte("\n"))
 			flusher.Flush()
+			observeResponseChunk(c, chunk)
 
 			// Continue
 			h.forwardResponsesStream(c, flusher, func(err error) { cliCancel(err) }, dataChan, errChan)
@@ -358,6 +366,7 @@ func writeChatAsResponsesChunk(c *gin.Context, ctx context.Context, modelName st
 		}
 		_, _ = c.Writer.Write([]byte(out))
 		_, _ = c.Writer.Write([]byte("\n"))
+		observeResponseChunk(c, []byte(out))
 	}
 }
 
@@ -374,6 +383,7 @@ func (h *OpenAIResponsesAPIHandler) forwardChatAsResponsesStream(c *gin.Context,
 				}
 				_, _ = c.Writer.Write([]byte(out))
 				_, _ = c.Writer.Write([]byte("\n"))
+				observeResponseChunk(c, []byte(out))
 			}
 		},
 		WriteTerminalError: func(errMsg *interfaces.ErrorMessage) {
@@ -405,6 +415,7 @@ func (h *OpenAIResponsesAPIHandler) forwardResponsesStream(c *gin.Context, flush
 			}
 			_, _ = c.Writer.Write(chunk)
 			_, _ = c.Writer.Write([]byte("\n"))
+			observeResponseChunk(c, chunk)
 		},
 		WriteTerminalError: func(errMsg *interfaces.ErrorMessage) {
 			if errMsg == nil {
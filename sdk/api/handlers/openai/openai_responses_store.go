@@ -0,0 +1,249 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/responsestore"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+)
+
+// pendingResponseKey is the gin context key of the *pendingResponse of a request whose
+// result is saved in the response store.
+const pendingResponseKey = "responseStorePending"
+
+const (
+	defaultInputItemsLimit = 20
+	maxInputItemsLimit     = 100
+)
+
+// pendingResponse carries what is needed to save the result of a request once it completes.
+type pendingResponse struct {
+	store *responsestore.Store
+	owner string
+	input json.RawMessage
+	saved bool
+}
+
+// prepareResponseStore expands previous_response_id from the response store and arranges
+// for the result to be saved unless the request sets "store": false. It writes an error
+// response and returns false when the request cannot proceed.
+func prepareResponseStore(c *gin.Context, rawJSON []byte) ([]byte, bool) {
+	store := responsestore.Default()
+	if store == nil {
+		return rawJSON, true
+	}
+	owner := util.APIKeyDigest(c.GetString("apiKey"))
+	expanded, input, err := store.Expand(c.Request.Context(), rawJSON, owner)
+	switch {
+	case errors.Is(err, responsestore.ErrNotFound):
+		previousID := gjson.GetBytes(rawJSON, "previous_response_id").String()
+		c.JSON(http.StatusBadRequest, handlers.ErrorResponse{
+			Error: handlers.ErrorDetail{
+				Message: fmt.Sprintf("Previous response with id '%s' not found.", previousID),
+				Type:    "invalid_request_error",
+				Code:    "previous_response_not_found",
+			},
+		})
+		return nil, false
+	case errors.Is(err, responsestore.ErrInvalidInput):
+		c.JSON(http.StatusBadRequest, handlers.ErrorResponse{
+			Error: handlers.ErrorDetail{
+				Message: fmt.Sprintf("Invalid request: %v", err),
+				Type:    "invalid_request_error",
+			},
+		})
+		return nil, false
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, handlers.ErrorResponse{
+			Error: handlers.ErrorDetail{
+				Message: fmt.Sprintf("Failed to load previous response: %v", err),
+				Type:    "server_error",
+			},
+		})
+		return nil, false
+	}
+	if gjson.GetBytes(rawJSON, "store").Type != gjson.False {
+		c.Set(pendingResponseKey, &pendingResponse{store: store, owner: owner, input: input})
+	}
+	return expanded, true
+}
+
+// saveResponse stores a complete response object for the current request.
+func saveResponse(c *gin.Context, response []byte) {
+	value, exists := c.Get(pendingResponseKey)
+	if !exists {
+		return
+	}
+	pending, ok := value.(*pendingResponse)
+	if !ok || pending.saved {
+		return
+	}
+	id := gjson.GetBytes(response, "id").String()
+	if id == "" {
+		return
+	}
+	pending.saved = true
+	record := &responsestore.Record{
+		ID:       id,
+		Owner:    pending.owner,
+		Input:    pending.input,
+		Response: append(json.RawMessage(nil), response...),
+	}
+	// The client may already be gone once the last event is written; the record is still
+	// worth keeping for the next turn.
+	ctx := context.WithoutCancel(c.Request.Context())
+	if err := pending.store.Save(ctx, record); err != nil {
+		log.Warnf("response store: save response %s: %v", id, err)
+	}
+}
+
+// observeResponseChunk saves the response carried by a response.completed stream event.
+func observeResponseChunk(c *gin.Context, chunk []byte) {
+	if _, exists := c.Get(pendingResponseKey); !exists {
+		return
+	}
+	if response, ok := responsestore.CompletedResponse(chunk); ok {
+		saveResponse(c, response)
+	}
+}
+
+// GetResponse handles GET /v1/responses/{id}, returning a stored response.
+func (h *OpenAIResponsesAPIHandler) GetResponse(c *gin.Context) {
+	record, ok := loadStoredResponse(c)
+	if !ok {
+		return
+	}
+	c.Data(http.StatusOK, "application/json", record.Response)
+}
+
+// DeleteResponse handles DELETE /v1/responses/{id}, removing a stored response.
+func (h *OpenAIResponsesAPIHandler) DeleteResponse(c *gin.Context) {
+	store := responsestore.Default()
+	id := c.Param("id")
+	if store == nil {
+		writeResponseNotFound(c, id)
+		return
+	}
+	err := store.Delete(c.Request.Context(), id, util.APIKeyDigest(c.GetString("apiKey")))
+	if err != nil {
+		writeStoreError(c, id, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"id": id, "object": "response", "deleted": true})
+}
+
+// ResponseInputItems handles GET /v1/responses/{id}/input_items, listing the input items of
+// a stored response with the limit, order and after query parameters.
+func (h *OpenAIResponsesAPIHandler) ResponseInputItems(c *gin.Context) {
+	limit := defaultInputItemsLimit
+	if raw := strings.TrimSpace(c.Query("limit")); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 || parsed > maxInputItemsLimit {
+			writeInvalidQuery(c, fmt.Sprintf("limit must be an integer between 1 and %d", maxInputItemsLimit))
+			return
+		}
+		limit = parsed
+	}
+	order := strings.ToLower(strings.TrimSpace(c.DefaultQuery("order", "desc")))
+	if order != "asc" && order != "desc" {
+		writeInvalidQuery(c, "order must be asc or desc")
+		return
+	}
+	record, ok := loadStoredResponse(c)
+	if !ok {
+		return
+	}
+
+	var items []json.RawMessage
+	if err := json.Unmarshal(record.Input, &items); err != nil {
+		writeStoreError(c, record.ID, err)
+		return
+	}
+	if order == "desc" {
+		for i, j := 0, len(items)-1; i < j; i, j = i+1, j-1 {
+			items[i], items[j] = items[j], items[i]
+		}
+	}
+	if after := strings.TrimSpace(c.Query("after")); after != "" {
+		for i, item := range items {
+			if gjson.GetBytes(item, "id").String() == after {
+				items = items[i+1:]
+				break
+			}
+		}
+	}
+	hasMore := len(items) > limit
+	if hasMore {
+		items = items[:limit]
+	}
+	if items == nil {
+		items = []json.RawMessage{}
+	}
+
+	body := gin.H{"object": "list", "data": items, "has_more": hasMore, "first_id": nil, "last_id": nil}
+	if len(items) > 0 {
+		if id := gjson.GetBytes(items[0], "id"); id.Exists() {
+			body["first_id"] = id.String()
+		}
+		if id := gjson.GetBytes(items[len(items)-1], "id"); id.Exists() {
+			body["last_id"] = id.String()
+		}
+	}
+	c.JSON(http.StatusOK, body)
+}
+
+func loadStoredResponse(c *gin.Context) (*responsestore.Record, bool) {
+	store := responsestore.Default()
+	id := c.Param("id")
+	if store == nil {
+		writeResponseNotFound(c, id)
+		return nil, false
+	}
+	record, err := store.Get(c.Request.Context(), id, util.APIKeyDigest(c.GetString("apiKey")))
+	if err != nil {
+		writeStoreError(c, id, err)
+		return nil, false
+	}
+	return record, true
+}
+
+func writeStoreError(c *gin.Context, id string, err error) {
+	if errors.Is(err, responsestore.ErrNotFound) {
+		writeResponseNotFound(c, id)
+		return
+	}
+	c.JSON(http.StatusInternalServerError, handlers.ErrorResponse{
+		Error: handlers.ErrorDetail{
+			Message: fmt.Sprintf("Failed to access response store: %v", err),
+			Type:    "server_error",
+		},
+	})
+}
+
+func writeResponseNotFound(c *gin.Context, id string) {
+	c.JSON(http.StatusNotFound, handlers.ErrorResponse{
+		Error: handlers.ErrorDetail{
+			Message: fmt.Sprintf("Response with id '%s' not found.", id),
+			Type:    "invalid_request_error",
+		},
+	})
+}
+
+func writeInvalidQuery(c *gin.Context, message string) {
+	c.JSON(http.StatusBadRequest, handlers.ErrorResponse{
+		Error: handlers.ErrorDetail{
+			Message: message,
+			Type:    "invalid_request_error",
+		},
+	})
+}
@@ -0,0 +1,174 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/responsestore"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+type storeCaptureExecutor struct {
+	payloads []string
+}
+
+func (e *storeCaptureExecutor) Identifier() string { return "test-provider" }
+
+func (e *storeCaptureExecutor) Execute(ctx context.Context, auth *coreauth.Auth, req coreexecutor.Request, opts coreexecutor.Options) (coreexecutor.Response, error) {
+	e.payloads = append(e.payloads, string(req.Payload))
+	n := len(e.payloads)
+	body := fmt.Sprintf(`{"id":"resp_%d","object":"response","status":"completed","output":[{"id":"msg_%d","type":"message","role":"assistant","content":[{"type":"output_text","text":"answer %d"}]}]}`, n, n, n)
+	return coreexecutor.Response{Payload: []byte(body)}, nil
+}
+
+func (e *storeCaptureExecutor) ExecuteStream(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (*coreexecutor.StreamResult, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (e *storeCaptureExecutor) Refresh(ctx context.Context, auth *coreauth.Auth) (*coreauth.Auth, error) {
+	return auth, nil
+}
+
+func (e *storeCaptureExecutor) CountTokens(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{}, errors.New("not implemented")
+}
+
+func (e *storeCaptureExecutor) HttpRequest(context.Context, *coreauth.Auth, *http.Request) (*http.Response, error) {
+	return nil, errors.New("not implemented")
+}
+
+func newResponseStoreRouter(t *testing.T, executor *storeCaptureExecutor) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(executor)
+
+	auth := &coreauth.Auth{ID: "auth-store", Provider: executor.Identifier(), Status: coreauth.StatusActive}
+	if _, err := manager.Register(context.Background(), auth); err != nil {
+		t.Fatalf("Register auth: %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient(auth.ID, auth.Provider, []*registry.ModelInfo{{ID: "test-model"}})
+	t.Cleanup(func() {
+		registry.GetGlobalRegistry().UnregisterClient(auth.ID)
+	})
+
+	h := NewOpenAIResponsesAPIHandler(handlers.NewBaseAPIHandlers(&sdkconfig.SDKConfig{}, manager))
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("apiKey", c.GetHeader("X-Test-Key"))
+	})
+	router.POST("/v1/responses", h.Responses)
+	router.GET("/v1/responses/:id", h.GetResponse)
+	router.DELETE("/v1/responses/:id", h.DeleteResponse)
+	router.GET("/v1/responses/:id/input_items", h.ResponseInputItems)
+	return router
+}
+
+func serveStoreRequest(router *gin.Engine, method, path, body, apiKey string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Test-Key", apiKey)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	return resp
+}
+
+func TestOpenAIResponsesStoreExpandsPreviousResponse(t *testing.T) {
+	responsestore.SetDefault(responsestore.New(responsestore.NewMemoryBackend(0), time.Hour))
+	t.Cleanup(func() { responsestore.SetDefault(nil) })
+
+	executor := &storeCaptureExecutor{}
+	router := newResponseStoreRouter(t, executor)
+
+	resp := serveStoreRequest(router, http.MethodPost, "/v1/responses", `{"model":"test-model","input":"question 1"}`, "key-a")
+	if resp.Code != http.StatusOK {
+		t.Fatalf("first status = %d, body %s", resp.Code, resp.Body.String())
+	}
+	resp = serveStoreRequest(router, http.MethodPost, "/v1/responses", `{"model":"test-model","previous_response_id":"resp_1","input":"question 2"}`, "key-a")
+	if resp.Code != http.StatusOK {
+		t.Fatalf("second status = %d, body %s", resp.Code, resp.Body.String())
+	}
+	payload := executor.payloads[1]
+	for _, want := range []string{"question 1", "answer 1", "question 2"} {
+		if !strings.Contains(payload, want) {
+			t.Fatalf("expanded payload %s does not contain %q", payload, want)
+		}
+	}
+	if strings.Contains(payload, "previous_response_id") {
+		t.Fatalf("expanded payload still carries previous_response_id: %s", payload)
+	}
+
+	resp = serveStoreRequest(router, http.MethodGet, "/v1/responses/resp_2", "", "key-a")
+	if resp.Code != http.StatusOK || gjson.Get(resp.Body.String(), "id").String() != "resp_2" {
+		t.Fatalf("get status = %d, body %s", resp.Code, resp.Body.String())
+	}
+	if resp = serveStoreRequest(router, http.MethodGet, "/v1/responses/resp_2", "", "key-b"); resp.Code != http.StatusNotFound {
+		t.Fatalf("get with other key status = %d, want 404", resp.Code)
+	}
+
+	resp = serveStoreRequest(router, http.MethodGet, "/v1/responses/resp_2/input_items?order=asc&limit=2", "", "key-a")
+	if resp.Code != http.StatusOK {
+		t.Fatalf("input items status = %d, body %s", resp.Code, resp.Body.String())
+	}
+	items := gjson.Get(resp.Body.String(), "data").Array()
+	if len(items) != 2 || !gjson.Get(resp.Body.String(), "has_more").Bool() {
+		t.Fatalf("input items = %s, want 2 items with more", resp.Body.String())
+	}
+	if got := items[1].Get("id").String(); got != "msg_1" {
+		t.Fatalf("second input item id = %q, want msg_1", got)
+	}
+
+	if resp = serveStoreRequest(router, http.MethodDelete, "/v1/responses/resp_2", "", "key-a"); resp.Code != http.StatusOK {
+		t.Fatalf("delete status = %d, body %s", resp.Code, resp.Body.String())
+	}
+	if resp = serveStoreRequest(router, http.MethodGet, "/v1/responses/resp_2", "", "key-a"); resp.Code != http.StatusNotFound {
+		t.Fatalf("get after delete status = %d, want 404", resp.Code)
+	}
+}
+
+func TestOpenAIResponsesStoreRejectsUnknownPreviousResponse(t *testing.T) {
+	responsestore.SetDefault(responsestore.New(responsestore.NewMemoryBackend(0), time.Hour))
+	t.Cleanup(func() { responsestore.SetDefault(nil) })
+
+	executor := &storeCaptureExecutor{}
+	router := newResponseStoreRouter(t, executor)
+
+	resp := serveStoreRequest(router, http.MethodPost, "/v1/responses", `{"model":"test-model","previous_response_id":"resp_missing","input":"hi"}`, "key-a")
+	if resp.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400", resp.Code)
+	}
+	if code := gjson.Get(resp.Body.String(), "error.code").String(); code != "previous_response_not_found" {
+		t.Fatalf("error code = %q, body %s", code, resp.Body.String())
+	}
+	if len(executor.payloads) != 0 {
+		t.Fatalf("executor called %d times, want 0", len(executor.payloads))
+	}
+}
+
+func TestOpenAIResponsesStoreSkipsStoreFalse(t *testing.T) {
+	responsestore.SetDefault(responsestore.New(responsestore.NewMemoryBackend(0), time.Hour))
+	t.Cleanup(func() { responsestore.SetDefault(nil) })
+
+	executor := &storeCaptureExecutor{}
+	router := newResponseStoreRouter(t, executor)
+
+	resp := serveStoreRequest(router, http.MethodPost, "/v1/responses", `{"model":"test-model","input":"hi","store":false}`, "")
+	if resp.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", resp.Code, resp.Body.String())
+	}
+	if resp = serveStoreRequest(router, http.MethodGet, "/v1/responses/resp_1", "", ""); resp.Code != http.StatusNotFound {
+		t.Fatalf("get status = %d, want 404", resp.Code)
+	}
+}
@@ -0,0 +1,120 @@
+package openai
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/structuredoutput"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+const (
+	// StructuredOutputEnforcementHeader reports how the serving backend applied a json_schema
+	// response format: native, tool, prompt or none.
+	StructuredOutputEnforcementHeader = "X-CPA-Structured-Output-Enforcement"
+	// StructuredOutputValidationHeader reports whether a non-streaming answer matched the
+	// requested schema: valid, repaired or invalid.
+	StructuredOutputValidationHeader = "X-CPA-Structured-Output-Validation"
+)
+
+// structuredOutputCheck follows a json_schema Chat Completions request through execution.
+type structuredOutputCheck struct {
+	format  structuredoutput.Format
+	rawJSON []byte
+
+	mu     sync.Mutex
+	authID string
+}
+
+// newStructuredOutputCheck returns nil for requests without a json_schema response format.
+func newStructuredOutputCheck(rawJSON []byte) *structuredOutputCheck {
+	format, ok := structuredoutput.FromOpenAIChat(rawJSON)
+	if !ok {
+		return nil
+	}
+	return &structuredOutputCheck{format: format, rawJSON: rawJSON}
+}
+
+// withContext records the auth selected for the request so its provider can be reported.
+func (s *structuredOutputCheck) withContext(ctx context.Context) context.Context {
+	if s == nil {
+		return ctx
+	}
+	return handlers.WithSelectedAuthIDCallback(ctx, func(authID string) {
+		s.mu.Lock()
+		s.authID = authID
+		s.mu.Unlock()
+	})
+}
+
+// reportStructuredOutputEnforcement sets the enforcement header and warns when a strict
+// schema could not be enforced by the backend that served the request.
+func (h *OpenAIAPIHandler) reportStructuredOutputEnforcement(c *gin.Context, s *structuredOutputCheck, modelName string) {
+	if s == nil || h.AuthManager == nil {
+		return
+	}
+	s.mu.Lock()
+	authID := s.authID
+	s.mu.Unlock()
+	auth, ok := h.AuthManager.GetByID(authID)
+	if !ok {
+		return
+	}
+	enforcement := structuredoutput.EnforcementFor(auth.Provider, s.rawJSON)
+	c.Header(StructuredOutputEnforcementHeader, string(enforcement))
+	if s.format.Strict && !enforcement.Strict() {
+		log.Warnf("structured output: strict schema %q for model %s cannot be enforced by provider %s (enforcement: %s)", s.format.Name, modelName, auth.Provider, enforcement)
+	}
+}
+
+// validateStructuredOutput checks a non-streaming answer against the requested schema and,
+// when repair is enabled, retries once with the validation errors. The last answer is
+// returned even when it still does not match. Only a valid or repaired answer is cached,
+// under the key of the original request.
+func (h *OpenAIAPIHandler) validateStructuredOutput(c *gin.Context, ctx context.Context, s *structuredOutputCheck, modelName string, resp []byte, deferred *handlers.DeferredResponseCache) []byte {
+	errs := s.validate(resp)
+	if len(errs) == 0 {
+		c.Header(StructuredOutputValidationHeader, "valid")
+		deferred.Store(resp)
+		return resp
+	}
+	if h.Cfg != nil && h.Cfg.StructuredOutput.Repair {
+		repaired, _, errMsg := h.ExecuteWithAuthManager(handlers.WithoutResponseCache(ctx), h.HandlerType(), modelName, s.repairRequest(resp, errs), h.GetAlt(c))
+		if errMsg == nil {
+			if repairErrs := s.validate(repaired); len(repairErrs) == 0 {
+				c.Header(StructuredOutputValidationHeader, "repaired")
+				deferred.Store(repaired)
+				return repaired
+			}
+		}
+	}
+	log.Debugf("structured output: answer for model %s does not match schema %q: %s", modelName, s.format.Name, strings.Join(errs, "; "))
+	c.Header(StructuredOutputValidationHeader, "invalid")
+	return resp
+}
+
+func (s *structuredOutputCheck) validate(resp []byte) []string {
+	content := gjson.GetBytes(resp, "choices.0.message.content").String()
+	return structuredoutput.Validate(s.format.Schema, []byte(content))
+}
+
+// repairRequest replays the conversation with the rejected answer and a user turn listing
+// the schema violations.
+func (s *structuredOutputCheck) repairRequest(resp []byte, errs []string) []byte {
+	out, _ := sjson.DeleteBytes(s.rawJSON, "stream")
+	assistant := []byte(`{"role":"assistant","content":""}`)
+	assistant, _ = sjson.SetBytes(assistant, "content", gjson.GetBytes(resp, "choices.0.message.content").String())
+	out, _ = sjson.SetRawBytes(out, "messages.-1", assistant)
+
+	feedback := fmt.Sprintf("Your previous answer does not match the required JSON schema %q:\n- %s\nRespond again with only the corrected JSON.", s.format.Name, strings.Join(errs, "\n- "))
+	user := []byte(`{"role":"user","content":""}`)
+	user, _ = sjson.SetBytes(user, "content", feedback)
+	out, _ = sjson.SetRawBytes(out, "messages.-1", user)
+	return out
+}
@@ -0,0 +1,143 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/cache"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+// structuredExecutor answers with prose first and with schema-shaped JSON afterwards.
+type structuredExecutor struct {
+	mu       sync.Mutex
+	payloads []string
+}
+
+func (e *structuredExecutor) Identifier() string { return "kiro" }
+
+func (e *structuredExecutor) Execute(ctx context.Context, auth *coreauth.Auth, req coreexecutor.Request, opts coreexecutor.Options) (coreexecutor.Response, error) {
+	e.mu.Lock()
+	e.payloads = append(e.payloads, string(req.Payload))
+	call := len(e.payloads)
+	e.mu.Unlock()
+	content := `Sure! The author is Frank Herbert.`
+	if call > 1 {
+		content = `{"author":"Frank Herbert"}`
+	}
+	body := fmt.Sprintf(`{"id":"chatcmpl-%d","object":"chat.completion","created":1,"model":"structured-model","choices":[{"index":0,"message":{"role":"assistant","content":%q},"finish_reason":"stop"}]}`, call, content)
+	return coreexecutor.Response{Payload: []byte(body)}, nil
+}
+
+func (e *structuredExecutor) ExecuteStream(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (*coreexecutor.StreamResult, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (e *structuredExecutor) Refresh(ctx context.Context, auth *coreauth.Auth) (*coreauth.Auth, error) {
+	return auth, nil
+}
+
+func (e *structuredExecutor) CountTokens(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{}, errors.New("not implemented")
+}
+
+func (e *structuredExecutor) HttpRequest(context.Context, *coreauth.Auth, *http.Request) (*http.Response, error) {
+	return nil, errors.New("not implemented")
+}
+
+func TestChatCompletionsStructuredOutputValidation(t *testing.T) {
+	const body = `{"model":"structured-model","messages":[{"role":"user","content":"Who wrote Dune?"}],"response_format":{"type":"json_schema","json_schema":{"name":"book","strict":true,"schema":{"type":"object","properties":{"author":{"type":"string"}},"required":["author"]}}}}`
+
+	tests := []struct {
+		name       string
+		repair     bool
+		validation string
+		calls      int
+		// replayCache is the X-Cache status of the same request sent again.
+		replayCache string
+	}{
+		{name: "invalid without repair", validation: "invalid", calls: 1, replayCache: "MISS"},
+		{name: "repaired", repair: true, validation: "repaired", calls: 2, replayCache: "HIT"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			cache.SetDefaultResponseCache(cache.NewResponseCache(cache.NewMemoryResponseStore(10, 1<<20), time.Minute, 1<<20))
+			t.Cleanup(func() { cache.SetDefaultResponseCache(nil) })
+			executor := &structuredExecutor{}
+			manager := coreauth.NewManager(nil, nil, nil)
+			manager.RegisterExecutor(executor)
+			auth := &coreauth.Auth{ID: "auth-structured", Provider: executor.Identifier(), Status: coreauth.StatusActive}
+			if _, err := manager.Register(context.Background(), auth); err != nil {
+				t.Fatalf("Register auth: %v", err)
+			}
+			registry.GetGlobalRegistry().RegisterClient(auth.ID, auth.Provider, []*registry.ModelInfo{{ID: "structured-model"}})
+			t.Cleanup(func() {
+				registry.GetGlobalRegistry().UnregisterClient(auth.ID)
+			})
+
+			cfg := &sdkconfig.SDKConfig{StructuredOutput: sdkconfig.StructuredOutputConfig{Repair: tt.repair}}
+			h := NewOpenAIAPIHandler(handlers.NewBaseAPIHandlers(cfg, manager))
+			router := gin.New()
+			router.POST("/v1/chat/completions", h.ChatCompletions)
+
+			serve := func() *httptest.ResponseRecorder {
+				req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(body))
+				req.Header.Set("Content-Type", "application/json")
+				resp := httptest.NewRecorder()
+				router.ServeHTTP(resp, req)
+				return resp
+			}
+			resp := serve()
+
+			if resp.Code != http.StatusOK {
+				t.Fatalf("status = %d, body %s", resp.Code, resp.Body.String())
+			}
+			if got := resp.Header().Get(StructuredOutputEnforcementHeader); got != "prompt" {
+				t.Fatalf("enforcement header = %q, want prompt", got)
+			}
+			if got := resp.Header().Get(StructuredOutputValidationHeader); got != tt.validation {
+				t.Fatalf("validation header = %q, want %q", got, tt.validation)
+			}
+			if len(executor.payloads) != tt.calls {
+				t.Fatalf("executor calls = %d, want %d", len(executor.payloads), tt.calls)
+			}
+
+			// An answer that failed validation is never served from the cache; a repaired
+			// one is, under the key of the original request.
+			replay := serve()
+			if got := replay.Header().Get(handlers.ResponseCacheStatusHeader); got != tt.replayCache {
+				t.Fatalf("replay cache status = %q, want %q", got, tt.replayCache)
+			}
+			if got := gjson.Get(replay.Body.String(), "choices.0.message.content").String(); got != `{"author":"Frank Herbert"}` {
+				t.Fatalf("replay content = %q", got)
+			}
+			if !tt.repair {
+				return
+			}
+			if len(executor.payloads) != tt.calls {
+				t.Fatalf("cached replay reached the executor: calls = %d", len(executor.payloads))
+			}
+			if got := gjson.Get(resp.Body.String(), "choices.0.message.content").String(); got != `{"author":"Frank Herbert"}` {
+				t.Fatalf("content = %q", got)
+			}
+			retry := gjson.Parse(executor.payloads[1])
+			if retry.Get("messages.#").Int() != 3 || !strings.Contains(retry.Get("messages.2.content").String(), "output is not valid JSON") {
+				t.Fatalf("repair request = %s", executor.payloads[1])
+			}
+		})
+	}
+}
@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/access/quota"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+	sdkaccess "github.com/router-for-me/CLIProxyAPI/v6/sdk/access"
+)
+
+// acquireQuota enforces the scopes and limits of the authenticated client key entry.
+// The returned release is never nil and must be called once the request finishes.
+func (h *BaseAPIHandler) acquireQuota(ctx context.Context, handlerType, modelName string, stream bool) (func(), *interfaces.ErrorMessage) {
+	noop := func() {}
+	if h == nil || h.Cfg == nil {
+		return noop, nil
+	}
+	ginCtx := ginContextFrom(ctx)
+	key := clientAPIKey(ctx)
+	baseModel := thinking.ParseSuffix(modelName).ModelName
+	if errScope := h.checkKeyScope(ginCtx, key, baseModel); errScope != nil {
+		return noop, quotaErrorMessage(ginCtx, handlerType, errScope)
+	}
+	entry, ok := h.Cfg.APIKeyEntry(key)
+	if !ok {
+		return noop, nil
+	}
+	release, errQuota := quota.Default().Acquire(entry, baseModel, stream)
+	if errQuota != nil {
+		return noop, quotaErrorMessage(ginCtx, handlerType, errQuota)
+	}
+	return release, nil
+}
+
+// checkQuotaModel enforces only the model restrictions; used for requests that do not
+// consume quota, such as token counting and response cache hits.
+func (h *BaseAPIHandler) checkQuotaModel(ctx context.Context, handlerType, modelName string) *interfaces.ErrorMessage {
+	if h == nil || h.Cfg == nil {
+		return nil
+	}
+	ginCtx := ginContextFrom(ctx)
+	key := clientAPIKey(ctx)
+	baseModel := thinking.ParseSuffix(modelName).ModelName
+	if errScope := h.checkKeyScope(ginCtx, key, baseModel); errScope != nil {
+		return quotaErrorMessage(ginCtx, handlerType, errScope)
+	}
+	return nil
+}
+
+// checkKeyScope enforces the scopes of the client key entry, or the model restrictions
+// an access provider attached to the request metadata.
+func (h *BaseAPIHandler) checkKeyScope(ginCtx *gin.Context, key, model string) *quota.Error {
+	entry, ok := h.Cfg.APIKeyEntry(key)
+	if !ok {
+		entry.AllowedModels = metadataAllowedModels(ginCtx)
+		if len(entry.AllowedModels) == 0 {
+			return nil
+		}
+	}
+	return quota.CheckScope(entry, model)
+}
+
+func metadataAllowedModels(ginCtx *gin.Context) []string {
+	if ginCtx == nil {
+		return nil
+	}
+	value, exists := ginCtx.Get("accessMetadata")
+	if !exists {
+		return nil
+	}
+	metadata, ok := value.(map[string]string)
+	if !ok || metadata[sdkaccess.MetadataAllowedModels] == "" {
+		return nil
+	}
+	return strings.Split(metadata[sdkaccess.MetadataAllowedModels], ",")
+}
+
+func ginContextFrom(ctx context.Context) *gin.Context {
+	if ctx == nil {
+		return nil
+	}
+	ginCtx, _ := ctx.Value("gin").(*gin.Context)
+	return ginCtx
+}
+
+// clientAPIKey returns the client key of the request, or the key attached with
+// WithClientAPIKey for work that runs outside an HTTP request.
+func clientAPIKey(ctx context.Context) string {
+	if ginCtx := ginContextFrom(ctx); ginCtx != nil {
+		if v, exists := ginCtx.Get("apiKey"); exists {
+			if key, ok := v.(string); ok {
+				return key
+			}
+		}
+		return ""
+	}
+	if ctx == nil {
+		return ""
+	}
+	key, _ := ctx.Value(clientAPIKeyContextKey{}).(string)
+	return key
+}
+
+// quotaErrorMessage renders a quota rejection in the error shape of the calling protocol.
+// The body is carried as the error text, which WriteErrorResponse passes through verbatim.
+func quotaErrorMessage(ginCtx *gin.Context, handlerType string, errQuota *quota.Error) *interfaces.ErrorMessage {
+	status := errQuota.StatusCode()
+	if errQuota.RetryAfter > 0 && ginCtx != nil {
+		ginCtx.Header("Retry-After", strconv.Itoa(int(math.Ceil(errQuota.RetryAfter.Seconds()))))
+	}
+	return &interfaces.ErrorMessage{
+		StatusCode: status,
+		Error:      errors.New(string(quotaErrorBody(handlerType, status, errQuota))),
+	}
+}
+
+func quotaErrorBody(handlerType string, status int, errQuota *quota.Error) []byte {
+	var payload any
+	switch handlerType {
+	case "claude":
+		errType := "rate_limit_error"
+		if status == http.StatusForbidden {
+			errType = "permission_error"
+		}
+		payload = map[string]any{
+			"type":  "error",
+			"error": map[string]any{"type": errType, "message": errQuota.Message},
+		}
+	case "gemini", "gemini-cli", "gemini-embedding":
+		grpcStatus := "RESOURCE_EXHAUSTED"
+		if status == http.StatusForbidden {
+			grpcStatus = "PERMISSION_DENIED"
+		}
+		payload = map[string]any{
+			"error": map[string]any{"code": status, "message": errQuota.Message, "status": grpcStatus},
+		}
+	default:
+		detail := ErrorDetail{Message: errQuota.Message, Type: "rate_limit_error", Code: "rate_limit_exceeded"}
+		switch errQuota.Limit {
+		case quota.LimitAllowedModels, quota.LimitAllowedPrefixes:
+			detail.Type, detail.Code = "permission_error", "model_not_allowed"
+		case quota.LimitTokensPerDay, quota.LimitTokensPerMonth:
+			detail.Code = "insufficient_quota"
+		}
+		payload = ErrorResponse{Error: detail}
+	}
+	body, _ := json.Marshal(payload)
+	return body
+}
@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tidwall/gjson"
+
+	sdkaccess "github.com/router-for-me/CLIProxyAPI/v6/sdk/access"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+)
+
+func TestAcquireQuotaErrorShapes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := &sdkconfig.SDKConfig{
+		APIKeys: []sdkconfig.APIKey{{Key: "team-a", AllowedModels: []string{"gpt-*"}}, {Key: "team-b"}},
+	}
+	handler := NewBaseAPIHandlers(cfg, nil)
+
+	cases := []struct {
+		handlerType string
+		path        string
+		want        string
+	}{
+		{handlerType: "openai", path: "error.code", want: "model_not_allowed"},
+		{handlerType: "claude", path: "error.type", want: "permission_error"},
+		{handlerType: "gemini", path: "error.status", want: "PERMISSION_DENIED"},
+	}
+	for _, tc := range cases {
+		recorder := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(recorder)
+		c.Request = httptest.NewRequest(http.MethodPost, "/", nil)
+		c.Set("apiKey", "team-a")
+		ctx := context.WithValue(context.Background(), "gin", c)
+
+		release, errMsg := handler.acquireQuota(ctx, tc.handlerType, "claude-sonnet-4(high)", false)
+		release()
+		if errMsg == nil {
+			t.Fatalf("%s: expected rejection", tc.handlerType)
+		}
+		handler.WriteErrorResponse(c, errMsg)
+		if recorder.Code != http.StatusForbidden {
+			t.Fatalf("%s: status = %d, want 403", tc.handlerType, recorder.Code)
+		}
+		if got := gjson.GetBytes(recorder.Body.Bytes(), tc.path).String(); got != tc.want {
+			t.Fatalf("%s: %s = %q, want %q (body %s)", tc.handlerType, tc.path, got, tc.want, recorder.Body.String())
+		}
+	}
+
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Set("apiKey", "team-b")
+	ctx := context.WithValue(context.Background(), "gin", c)
+	if _, errMsg := handler.acquireQuota(ctx, "openai", "claude-sonnet-4", false); errMsg != nil {
+		t.Fatalf("key without limits rejected: %v", errMsg.Error)
+	}
+}
+
+func TestAcquireQuotaEnforcesAccessMetadataModels(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	handler := NewBaseAPIHandlers(&sdkconfig.SDKConfig{}, nil)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Set("apiKey", "user-1")
+	c.Set("accessMetadata", map[string]string{sdkaccess.MetadataAllowedModels: "gpt-5-mini*,claude-*"})
+	ctx := context.WithValue(context.Background(), "gin", c)
+
+	if errMsg := handler.checkQuotaModel(ctx, "openai", "gpt-5-mini(low)"); errMsg != nil {
+		t.Fatalf("allowed model rejected: %v", errMsg.Error)
+	}
+	if errMsg := handler.checkQuotaModel(ctx, "openai", "gpt-5"); errMsg == nil || errMsg.StatusCode != http.StatusForbidden {
+		t.Fatalf("model outside token restrictions: %+v, want 403", errMsg)
+	}
+}
@@ -0,0 +1,203 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/cache"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/usage"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+)
+
+// ResponseCacheStatusHeader reports whether a response was served from the response cache.
+const ResponseCacheStatusHeader = "X-Cache"
+
+// responseCacheLookup resolves the cache key for a request and returns a live entry when
+// one exists. An empty key means the request must neither be served from nor stored in
+// the cache: caching is disabled or the client sent "Cache-Control: no-store".
+// "Cache-Control: no-cache" skips the lookup but still refreshes the entry.
+func responseCacheLookup(ctx context.Context, handlerType, model, alt string, stream bool, providers []string, rawJSON []byte) (string, *cache.ResponseEntry) {
+	responseCache := cache.DefaultResponseCache()
+	if responseCache == nil || len(rawJSON) == 0 {
+		return "", nil
+	}
+	ginCtx := ginContextFrom(ctx)
+	if ctx != nil && ctx.Value(responseCacheBypassContextKey{}) != nil {
+		setResponseCacheStatus(ginCtx, "BYPASS")
+		return "", nil
+	}
+	noCache := false
+	if ginCtx != nil && ginCtx.Request != nil {
+		for _, directive := range strings.Split(strings.ToLower(ginCtx.Request.Header.Get("Cache-Control")), ",") {
+			switch strings.TrimSpace(directive) {
+			case "no-store":
+				setResponseCacheStatus(ginCtx, "BYPASS")
+				return "", nil
+			case "no-cache":
+				noCache = true
+			}
+		}
+	}
+	owner := util.APIKeyDigest(clientAPIKey(ctx))
+	key := cache.ResponseCacheKey(owner, handlerType, model, alt, stream, upstreamPayloads(handlerType, model, stream, providers, rawJSON)...)
+	if !noCache {
+		if entry, ok := responseCache.Get(key); ok {
+			usage.GetRequestStatistics().RecordCacheLookup(true)
+			setResponseCacheStatus(ginCtx, "HIT")
+			return key, entry
+		}
+	}
+	usage.GetRequestStatistics().RecordCacheLookup(false)
+	setResponseCacheStatus(ginCtx, "MISS")
+	return key, nil
+}
+
+// upstreamPayloads translates rawJSON into the request format of every provider that may
+// serve it, so the cache key follows what upstreams receive rather than client-only fields.
+func upstreamPayloads(handlerType, model string, stream bool, providers []string, rawJSON []byte) [][]byte {
+	from := sdktranslator.FromString(handlerType)
+	baseModel := thinking.ParseSuffix(model).ModelName
+	formats := make([]string, 0, len(providers))
+	for _, provider := range providers {
+		formats = append(formats, providerRequestFormat(from, provider).String())
+	}
+	sort.Strings(formats)
+	formats = slices.Compact(formats)
+	payloads := make([][]byte, 0, len(formats))
+	for _, format := range formats {
+		payloads = append(payloads, sdktranslator.TranslateRequest(from, sdktranslator.FromString(format), baseModel, rawJSON, stream))
+	}
+	return payloads
+}
+
+// providerRequestFormat returns the request format the executor of provider translates to.
+// Providers without a format of their own speak the OpenAI chat completions format.
+func providerRequestFormat(from sdktranslator.Format, provider string) sdktranslator.Format {
+	switch provider {
+	case "aistudio", "vertex":
+		return sdktranslator.FormatGemini
+	}
+	to := sdktranslator.FromString(provider)
+	if to == from || sdktranslator.HasRequestTransformer(from, to) {
+		return to
+	}
+	return sdktranslator.FormatOpenAI
+}
+
+func setResponseCacheStatus(ginCtx *gin.Context, status string) {
+	if ginCtx != nil {
+		ginCtx.Header(ResponseCacheStatusHeader, status)
+	}
+}
+
+func storeResponse(key string, entry *cache.ResponseEntry) {
+	if key == "" {
+		return
+	}
+	cache.DefaultResponseCache().Put(key, entry)
+}
+
+// storeOrDeferResponse caches entry under key, or hands it to the DeferredResponseCache of ctx.
+func storeOrDeferResponse(ctx context.Context, key string, entry *cache.ResponseEntry) {
+	if key == "" {
+		return
+	}
+	if ctx != nil {
+		if deferred, ok := ctx.Value(deferredResponseCacheContextKey{}).(*DeferredResponseCache); ok {
+			deferred.mu.Lock()
+			deferred.key, deferred.entry = key, entry
+			deferred.mu.Unlock()
+			return
+		}
+	}
+	storeResponse(key, entry)
+}
+
+// DeferredResponseCache holds the response cache entry of a request made with
+// WithDeferredResponseCache until the caller decides what to cache.
+type DeferredResponseCache struct {
+	mu    sync.Mutex
+	key   string
+	entry *cache.ResponseEntry
+}
+
+// Store caches payload under the key of the deferred request, with the headers of the
+// deferred response. It does nothing when no response was deferred.
+func (d *DeferredResponseCache) Store(payload []byte) {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	key, entry := d.key, d.entry
+	d.mu.Unlock()
+	if key == "" || entry == nil {
+		return
+	}
+	storeResponse(key, &cache.ResponseEntry{Payload: cloneBytes(payload), Headers: entry.Headers})
+}
+
+// streamRecorder collects the chunks of a streaming response for the cache and gives up
+// once they no longer fit in a single entry.
+type streamRecorder struct {
+	key     string
+	headers http.Header
+	chunks  [][]byte
+	size    int64
+}
+
+func newStreamRecorder(key string) *streamRecorder {
+	if key == "" {
+		return nil
+	}
+	return &streamRecorder{key: key}
+}
+
+func (r *streamRecorder) add(chunk []byte) {
+	if r == nil || r.key == "" {
+		return
+	}
+	r.size += int64(len(chunk))
+	if !cache.DefaultResponseCache().Fits(r.size) {
+		r.key, r.chunks = "", nil
+		return
+	}
+	r.chunks = append(r.chunks, cloneBytes(chunk))
+}
+
+func (r *streamRecorder) commit() {
+	if r == nil || r.key == "" || len(r.chunks) == 0 {
+		return
+	}
+	storeResponse(r.key, &cache.ResponseEntry{Chunks: r.chunks, Headers: r.headers})
+}
+
+// replayStream serves a cached streaming response through the same channels a live
+// stream uses, so handlers frame the chunks exactly as they did originally.
+func replayStream(ctx context.Context, entry *cache.ResponseEntry) (<-chan []byte, <-chan *interfaces.ErrorMessage) {
+	dataChan := make(chan []byte)
+	errChan := make(chan *interfaces.ErrorMessage)
+	go func() {
+		defer close(dataChan)
+		defer close(errChan)
+		for _, chunk := range entry.Chunks {
+			if ctx == nil {
+				dataChan <- cloneBytes(chunk)
+				continue
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case dataChan <- cloneBytes(chunk):
+			}
+		}
+	}()
+	return dataChan, errChan
+}
@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/cache"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+)
+
+type countingStreamExecutor struct {
+	mu    sync.Mutex
+	calls int
+}
+
+func (e *countingStreamExecutor) Identifier() string { return "codex" }
+
+func (e *countingStreamExecutor) Execute(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{}, &coreauth.Error{Code: "not_implemented", Message: "Execute not implemented"}
+}
+
+func (e *countingStreamExecutor) ExecuteStream(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (*coreexecutor.StreamResult, error) {
+	e.mu.Lock()
+	e.calls++
+	e.mu.Unlock()
+
+	ch := make(chan coreexecutor.StreamChunk, 2)
+	ch <- coreexecutor.StreamChunk{Payload: []byte(`{"delta":"hel"}`)}
+	ch <- coreexecutor.StreamChunk{Payload: []byte(`{"delta":"lo"}`)}
+	close(ch)
+	return &coreexecutor.StreamResult{Chunks: ch}, nil
+}
+
+func (e *countingStreamExecutor) Refresh(ctx context.Context, auth *coreauth.Auth) (*coreauth.Auth, error) {
+	return auth, nil
+}
+
+func (e *countingStreamExecutor) CountTokens(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{}, &coreauth.Error{Code: "not_implemented", Message: "CountTokens not implemented"}
+}
+
+func (e *countingStreamExecutor) HttpRequest(ctx context.Context, auth *coreauth.Auth, req *http.Request) (*http.Response, error) {
+	return nil, &coreauth.Error{Code: "not_implemented", Message: "HttpRequest not implemented", HTTPStatus: http.StatusNotImplemented}
+}
+
+func TestExecuteStreamWithAuthManager_ReplaysCachedStream(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cache.SetDefaultResponseCache(cache.NewResponseCache(cache.NewMemoryResponseStore(10, 1<<20), time.Minute, 1<<20))
+	t.Cleanup(func() { cache.SetDefaultResponseCache(nil) })
+
+	executor := &countingStreamExecutor{}
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(executor)
+	auth := &coreauth.Auth{ID: "cache-auth", Provider: "codex", Status: coreauth.StatusActive}
+	if _, err := manager.Register(context.Background(), auth); err != nil {
+		t.Fatalf("manager.Register: %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient(auth.ID, auth.Provider, []*registry.ModelInfo{{ID: "cache-model"}})
+	t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(auth.ID) })
+	// Two requests per minute cover the miss and the no-store call; the hit must not count.
+	handler := NewBaseAPIHandlers(&sdkconfig.SDKConfig{APIKeys: []sdkconfig.APIKey{{
+		Key:         "cache-client",
+		APIKeyLimit: sdkconfig.APIKeyLimit{RequestsPerMinute: 2},
+	}}}, manager)
+
+	run := func(apiKey, body, cacheControl string) ([]string, string) {
+		recorder := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(recorder)
+		c.Request = httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+		c.Set("apiKey", apiKey)
+		if cacheControl != "" {
+			c.Request.Header.Set("Cache-Control", cacheControl)
+		}
+		ctx := context.WithValue(context.Background(), "gin", c)
+		dataChan, _, errChan := handler.ExecuteStreamWithAuthManager(ctx, "openai", "cache-model", []byte(body), "")
+		var chunks []string
+		for chunk := range dataChan {
+			chunks = append(chunks, string(chunk))
+		}
+		for errMsg := range errChan {
+			if errMsg != nil {
+				t.Fatalf("unexpected stream error: %v", errMsg.Error)
+			}
+		}
+		return chunks, recorder.Header().Get(ResponseCacheStatusHeader)
+	}
+
+	first, status := run("cache-client", `{"model":"cache-model","messages":[{"role":"user","content":"hi"}]}`, "")
+	if status != "MISS" || len(first) != 2 {
+		t.Fatalf("first call: status %q chunks %v", status, first)
+	}
+	second, status := run("cache-client", `{ "messages":[{"content":"hi","role":"user"}], "model":"cache-model" }`, "")
+	if status != "HIT" || len(second) != 2 || second[0] != first[0] || second[1] != first[1] {
+		t.Fatalf("second call: status %q chunks %v, want replay of %v", status, second, first)
+	}
+	if _, status = run("cache-client", `{"model":"cache-model","messages":[{"role":"user","content":"hi"}]}`, "no-store"); status != "BYPASS" {
+		t.Fatalf("no-store call: status %q, want BYPASS", status)
+	}
+	if _, status = run("other-client", `{"model":"cache-model","messages":[{"role":"user","content":"hi"}]}`, ""); status != "MISS" {
+		t.Fatalf("other client key: status %q, want MISS", status)
+	}
+	if executor.calls != 3 {
+		t.Fatalf("executor calls = %d, want 3", executor.calls)
+	}
+}
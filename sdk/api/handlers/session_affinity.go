@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	"github.com/tidwall/gjson"
+)
+
+// applySessionAffinity tags the execution metadata with the conversation key of the request,
+// so the auth manager keeps the conversation on one credential.
+func (h *BaseAPIHandler) applySessionAffinity(ctx context.Context, meta map[string]any, rawJSON []byte) {
+	if h == nil || h.AuthManager == nil {
+		return
+	}
+	var headers http.Header
+	if ginCtx := ginContextFrom(ctx); ginCtx != nil && ginCtx.Request != nil {
+		headers = ginCtx.Request.Header
+	}
+	if key := h.AuthManager.SessionAffinityKey(headers, rawJSON); key != "" {
+		meta[coreexecutor.SessionAffinityMetadataKey] = key
+	}
+}
+
+// bindResponseSession binds the Responses id found in payload, a response body or stream
+// chunk, to the credential that produced it, so requests continuing it through
+// previous_response_id stay on that credential. It reports whether the id was found.
+func (h *BaseAPIHandler) bindResponseSession(handlerType, model string, meta map[string]any, payload []byte) bool {
+	if handlerType != "openai-response" || h == nil || h.AuthManager == nil {
+		return true
+	}
+	responseID := responseIDFromPayload(payload)
+	if responseID == "" {
+		return false
+	}
+	authID, _ := meta[coreexecutor.SelectedAuthMetadataKey].(string)
+	h.AuthManager.BindSessionAffinity(coreauth.ResponseSessionKey(responseID), model, authID)
+	return true
+}
+
+func responseIDFromPayload(payload []byte) string {
+	trimmed := bytes.TrimSpace(payload)
+	if bytes.HasPrefix(trimmed, []byte("{")) {
+		if gjson.GetBytes(trimmed, "object").String() == "response" {
+			return gjson.GetBytes(trimmed, "id").String()
+		}
+		return ""
+	}
+	for _, line := range bytes.Split(trimmed, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if !bytes.HasPrefix(line, []byte("data:")) {
+			continue
+		}
+		if id := gjson.GetBytes(bytes.TrimSpace(line[5:]), "response.id").String(); id != "" {
+			return id
+		}
+	}
+	return ""
+}
@@ -0,0 +1,21 @@
+package handlers
+
+import "testing"
+
+func TestResponseIDFromPayload(t *testing.T) {
+	cases := []struct {
+		name    string
+		payload string
+		want    string
+	}{
+		{name: "response body", payload: `{"id":"resp_1","object":"response","output":[]}`, want: "resp_1"},
+		{name: "other body", payload: `{"id":"chatcmpl_1","object":"chat.completion"}`, want: ""},
+		{name: "created event", payload: "event: response.created\ndata: {\"type\":\"response.created\",\"response\":{\"id\":\"resp_2\"}}\n\n", want: "resp_2"},
+		{name: "delta event", payload: "event: response.output_text.delta\ndata: {\"type\":\"response.output_text.delta\",\"delta\":\"hi\"}\n\n", want: ""},
+	}
+	for _, tc := range cases {
+		if got := responseIDFromPayload([]byte(tc.payload)); got != tc.want {
+			t.Fatalf("%s: responseIDFromPayload() = %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
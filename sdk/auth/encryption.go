@@ -0,0 +1,13 @@
+package auth
+
+import "github.com/router-for-me/CLIProxyAPI/v6/internal/authcrypt"
+
+// KeyProvider wraps the per-file data keys that encrypt stored auth files, for example
+// through a key management service.
+type KeyProvider = authcrypt.KeyProvider
+
+// SetKeyProvider makes every token store seal auth files with data keys wrapped by provider.
+// Plaintext files keep loading; nil turns encryption off.
+func SetKeyProvider(provider KeyProvider) {
+	authcrypt.SetDefault(authcrypt.NewCipher(provider))
+}
@@ -0,0 +1,84 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/authcrypt"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+// plaintextStorage writes its token as plaintext JSON, like the provider token storages.
+type plaintextStorage struct{ token string }
+
+func (s plaintextStorage) SaveTokenToFile(path string) error {
+	return os.WriteFile(path, []byte(`{"type":"claude","refresh_token":"`+s.token+`"}`), 0o600)
+}
+
+func useTestKeys(t *testing.T, keys ...authcrypt.Key) {
+	t.Helper()
+	ring, err := authcrypt.NewKeyring(keys...)
+	if err != nil {
+		t.Fatalf("NewKeyring: %v", err)
+	}
+	SetKeyProvider(ring)
+	t.Cleanup(func() { SetKeyProvider(nil) })
+}
+
+func TestFileTokenStore_EncryptsAtRest(t *testing.T) {
+	dir := t.TempDir()
+	legacy := filepath.Join(dir, "legacy.json")
+	if err := os.WriteFile(legacy, []byte(`{"type":"codex","email":"old@example.com","api_key":"sk-legacy"}`), 0o600); err != nil {
+		t.Fatalf("write legacy file: %v", err)
+	}
+	primary := authcrypt.Key{ID: "k1", Secret: bytes.Repeat([]byte{1}, authcrypt.KeySize)}
+	useTestKeys(t, primary)
+
+	store := NewFileTokenStore()
+	store.SetBaseDir(dir)
+	ctx := context.Background()
+	if _, err := store.Save(ctx, &cliproxyauth.Auth{ID: "login.json", Storage: plaintextStorage{token: "rt-login"}}); err != nil {
+		t.Fatalf("save storage: %v", err)
+	}
+	if _, err := store.Save(ctx, &cliproxyauth.Auth{ID: "meta.json", Metadata: map[string]any{"type": "gemini", "api_key": "sk-meta"}}); err != nil {
+		t.Fatalf("save metadata: %v", err)
+	}
+	for name, secret := range map[string]string{"login.json": "rt-login", "meta.json": "sk-meta"} {
+		raw, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatalf("read %s: %v", name, err)
+		}
+		if !authcrypt.IsSealed(raw) || bytes.Contains(raw, []byte(secret)) {
+			t.Fatalf("%s is not encrypted at rest: %s", name, raw)
+		}
+	}
+
+	auths, err := store.List(ctx)
+	if err != nil || len(auths) != 3 {
+		t.Fatalf("List = %d auths, %v; want 3", len(auths), err)
+	}
+	byID := map[string]*cliproxyauth.Auth{}
+	for _, auth := range auths {
+		byID[auth.ID] = auth
+	}
+	if byID["legacy.json"].Provider != "codex" || byID["login.json"].Metadata["refresh_token"] != "rt-login" {
+		t.Fatalf("listed auths not decrypted: %+v", byID)
+	}
+
+	// Rotate keys and save the listed auths again, as --reencrypt-auths does.
+	useTestKeys(t, authcrypt.Key{ID: "k2", Secret: bytes.Repeat([]byte{2}, authcrypt.KeySize)}, primary)
+	for _, auth := range auths {
+		if _, err = store.Save(ctx, auth); err != nil {
+			t.Fatalf("resave %s: %v", auth.ID, err)
+		}
+	}
+	for _, name := range []string{"legacy.json", "login.json", "meta.json"} {
+		raw, _ := os.ReadFile(filepath.Join(dir, name))
+		if _, ok := authcrypt.Current(raw); !ok {
+			t.Fatalf("%s not resealed with the new key: %s", name, raw)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package auth
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"io/fs"
@@ -15,6 +16,8 @@ import (
 	"sync"
 	"time"
 
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/authcrypt"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/filelock"
 	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
 )
 
@@ -78,16 +81,25 @@ func (s *FileTokenStore) Save(ctx context.Context, auth *cliproxyauth.Auth) (str
 		if err = auth.Storage.SaveTokenToFile(path); err != nil {
 			return "", err
 		}
+		if err = authcrypt.SealFile(path); err != nil {
+			return "", fmt.Errorf("auth filestore: encrypt file failed: %w", err)
+		}
 	case auth.Metadata != nil:
 		auth.Metadata["disabled"] = auth.Disabled
 		raw, errMarshal := json.Marshal(auth.Metadata)
 		if errMarshal != nil {
 			return "", fmt.Errorf("auth filestore: marshal metadata failed: %w", errMarshal)
 		}
-		if existing, errRead := os.ReadFile(path); errRead == nil {
-			if jsonEqual(existing, raw) {
+		existing, errRead := os.ReadFile(path)
+		if errRead == nil {
+			if current, upToDate := authcrypt.Current(existing); upToDate && jsonEqual(current, raw) {
 				return path, nil
 			}
+		}
+		if raw, err = authcrypt.Seal(raw); err != nil {
+			return "", fmt.Errorf("auth filestore: encrypt metadata failed: %w", err)
+		}
+		if errRead == nil {
 			file, errOpen := os.OpenFile(path, os.O_WRONLY|os.O_TRUNC, 0o600)
 			if errOpen != nil {
 				return "", fmt.Errorf("auth filestore: open existing failed: %w", errOpen)
@@ -170,6 +182,35 @@ func (s *FileTokenStore) Delete(ctx context.Context, id string) error {
 	return nil
 }
 
+var _ cliproxyauth.Locker = (*FileTokenStore)(nil)
+
+// LockAuth implements cliproxyauth.Locker with a lock file in the ".locks" directory of the
+// auth directory, serializing refreshes between processes sharing that directory.
+func (s *FileTokenStore) LockAuth(ctx context.Context, id string) (func(), error) {
+	dir := s.baseDirSnapshot()
+	if dir == "" {
+		return nil, fmt.Errorf("auth filestore: directory not configured")
+	}
+	lock := filelock.New(filepath.Join(dir, ".locks", url.PathEscape(id)+".lock"))
+	if err := lock.Lock(ctx); err != nil {
+		return nil, err
+	}
+	return lock.Unlock, nil
+}
+
+// LoadAuth implements cliproxyauth.Locker by reading the auth file of auth.
+func (s *FileTokenStore) LoadAuth(_ context.Context, auth *cliproxyauth.Auth) (*cliproxyauth.Auth, error) {
+	path, err := s.resolveAuthPath(auth)
+	if err != nil {
+		return nil, err
+	}
+	stored, err := s.readAuthFile(path, s.baseDirSnapshot())
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	return stored, err
+}
+
 func (s *FileTokenStore) resolveDeletePath(id string) (string, error) {
 	if strings.ContainsRune(id, os.PathSeparator) || filepath.IsAbs(id) {
 		return id, nil
@@ -182,7 +223,7 @@ func (s *FileTokenStore) resolveDeletePath(id string) (string, error) {
 }
 
 func (s *FileTokenStore) readAuthFile(path, baseDir string) (*cliproxyauth.Auth, error) {
-	data, err := os.ReadFile(path)
+	data, err := authcrypt.ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("read file: %w", err)
 	}
@@ -218,10 +259,7 @@ func (s *FileTokenStore) readAuthFile(path, baseDir string) (*cliproxyauth.Auth,
 				if errFetch == nil && strings.TrimSpace(fetchedProjectID) != "" {
 					metadata["project_id"] = strings.TrimSpace(fetchedProjectID)
 					if raw, errMarshal := json.Marshal(metadata); errMarshal == nil {
-						if file, errOpen := os.OpenFile(path, os.O_WRONLY|os.O_TRUNC, 0o600); errOpen == nil {
-							_, _ = file.Write(raw)
-							_ = file.Close()
-						}
+						_ = authcrypt.WriteFile(path, raw, 0o600)
 					}
 				}
 			}
@@ -1,6 +1,12 @@
 package auth
 
-import "testing"
+import (
+	"context"
+	"testing"
+	"time"
+
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
 
 func TestExtractAccessToken(t *testing.T) {
 	t.Parallel()
@@ -78,3 +84,57 @@ func TestExtractAccessToken(t *testing.T) {
 		})
 	}
 }
+
+func TestFileTokenStoreLockAuth(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	first := NewFileTokenStore()
+	first.SetBaseDir(dir)
+	second := NewFileTokenStore()
+	second.SetBaseDir(dir)
+
+	unlock, err := first.LockAuth(context.Background(), "team/claude.json")
+	if err != nil {
+		t.Fatalf("first LockAuth: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err = second.LockAuth(ctx, "team/claude.json"); err == nil {
+		t.Fatal("second store acquired a held lock")
+	}
+	unlockOther, err := second.LockAuth(context.Background(), "team/other.json")
+	if err != nil {
+		t.Fatalf("lock of another auth: %v", err)
+	}
+	unlockOther()
+
+	unlock()
+	unlockSecond, err := second.LockAuth(context.Background(), "team/claude.json")
+	if err != nil {
+		t.Fatalf("second LockAuth after unlock: %v", err)
+	}
+	unlockSecond()
+}
+
+func TestFileTokenStoreLoadAuth(t *testing.T) {
+	t.Parallel()
+
+	store := NewFileTokenStore()
+	store.SetBaseDir(t.TempDir())
+	auth := &cliproxyauth.Auth{ID: "codex.json", Provider: "codex", Metadata: map[string]any{"type": "codex", "refresh_token": "rotated"}}
+	if _, err := store.Save(context.Background(), auth); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	stored, err := store.LoadAuth(context.Background(), &cliproxyauth.Auth{ID: "codex.json"})
+	if err != nil {
+		t.Fatalf("LoadAuth: %v", err)
+	}
+	if stored == nil || stored.ID != "codex.json" || stored.Metadata["refresh_token"] != "rotated" {
+		t.Fatalf("LoadAuth = %+v, want the saved auth", stored)
+	}
+	if stored, err = store.LoadAuth(context.Background(), &cliproxyauth.Auth{ID: "missing.json"}); stored != nil || err != nil {
+		t.Fatalf("LoadAuth of a missing auth = %+v, %v; want nil, nil", stored, err)
+	}
+}
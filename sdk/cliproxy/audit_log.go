@@ -0,0 +1,68 @@
+package cliproxy
+
+import (
+	"path/filepath"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/audit"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	log "github.com/sirupsen/logrus"
+)
+
+const defaultAuditLogDir = "audit"
+
+// applyAuditLog opens the management audit log when it is enabled, reopening it when the
+// directory or retention changed.
+func (s *Service) applyAuditLog(cfg *config.Config) {
+	if s == nil || cfg == nil {
+		return
+	}
+	if !cfg.AuditLog.Enable {
+		if s.auditLog != nil {
+			s.shutdownAuditLog()
+			log.Info("audit log disabled")
+		}
+		return
+	}
+	settings := cfg.AuditLog
+	settings.Dir = resolveAuditLogDir(settings.Dir, s.configPath)
+	if s.auditLog != nil && *s.auditLog == settings {
+		return
+	}
+	auditLog, err := audit.Open(settings.Dir, settings.RetentionDays)
+	if err != nil {
+		log.Errorf("audit log: %v", err)
+		return
+	}
+	if errPrune := auditLog.Prune(); errPrune != nil {
+		log.Warnf("audit log: %v", errPrune)
+	}
+	if previous := audit.SetDefault(auditLog); previous != nil {
+		_ = previous.Close()
+	}
+	s.auditLog = &settings
+	log.Infof("audit log enabled (dir=%s, retention=%d days)", settings.Dir, auditLog.RetentionDays())
+}
+
+func (s *Service) shutdownAuditLog() {
+	if s == nil {
+		return
+	}
+	if previous := audit.SetDefault(nil); previous != nil {
+		_ = previous.Close()
+	}
+	s.auditLog = nil
+}
+
+func resolveAuditLogDir(dir, configPath string) string {
+	if dir == "" {
+		dir = defaultAuditLogDir
+	}
+	if filepath.IsAbs(dir) {
+		return dir
+	}
+	base := "."
+	if configPath != "" {
+		base = filepath.Dir(configPath)
+	}
+	return filepath.Join(base, dir)
+}
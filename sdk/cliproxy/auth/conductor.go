@@ -126,6 +126,12 @@ func (NoopHook) OnAuthUpdated(context.Context, *Auth) {}
 // OnResult implements Hook.
 func (NoopHook) OnResult(context.Context, Result) {}
 
+// RefreshFailureHook is an optional interface that hooks can implement to be told when a
+// credential refresh fails. auth is a snapshot taken before the refresh.
+type RefreshFailureHook interface {
+	OnRefreshFailed(ctx context.Context, auth *Auth, err error)
+}
+
 // Manager orchestrates auth lifecycle, selection, execution, and persistence.
 type Manager struct {
 	store     Store
@@ -160,6 +166,21 @@ type Manager struct {
 	// Optional HTTP RoundTripper provider injected by host.
 	rtProvider RoundTripperProvider
 
+	// Optional execution telemetry observer injected by host.
+	observer ExecutionObserver
+
+	// health scores credentials per model for the weighted-health strategy.
+	health *healthTracker
+
+	// sessionAffinity binds conversations to credentials when routing.session-affinity is on.
+	sessionAffinity *sessionAffinity
+
+	// executionHooks intercept each upstream attempt, in registration order.
+	executionHooks []ExecutionHook
+
+	// sharedState synchronizes cooldowns, cursors and refresh leases with other instances.
+	sharedState atomic.Pointer[sharedStateRuntime]
+
 	// Auto refresh state
 	refreshCancel    context.CancelFunc
 	refreshSemaphore chan struct{}
@@ -182,17 +203,21 @@ func NewManager(store Store, selector Selector, hook Hook) *Manager {
 		providerOffsets:  make(map[string]int),
 		modelPoolOffsets: make(map[string]int),
 		refreshSemaphore: make(chan struct{}, refreshMaxConcurrency),
+		health:           newHealthTracker(),
+		sessionAffinity:  newSessionAffinity(),
 	}
 	// atomic.Value requires non-nil initial value.
 	manager.runtimeConfig.Store(&internalconfig.Config{})
 	manager.apiKeyModelAlias.Store(apiKeyModelAliasTable(nil))
 	manager.scheduler = newAuthScheduler(selector)
+	manager.scheduler.health = manager.health
+	manager.attachHealth(selector)
 	return manager
 }
 
 func isBuiltInSelector(selector Selector) bool {
 	switch selector.(type) {
-	case *RoundRobinSelector, *FillFirstSelector:
+	case *RoundRobinSelector, *FillFirstSelector, *WeightedHealthSelector:
 		return true
 	default:
 		return false
@@ -240,6 +265,7 @@ func (m *Manager) SetSelector(selector Selector) {
 	if selector == nil {
 		selector = &RoundRobinSelector{}
 	}
+	m.attachHealth(selector)
 	m.mu.Lock()
 	m.selector = selector
 	m.mu.Unlock()
@@ -483,7 +509,7 @@ func readStreamBootstrap(ctx context.Context, ch <-chan cliproxyexecutor.StreamC
 	}
 }
 
-func (m *Manager) wrapStreamResult(ctx context.Context, auth *Auth, provider, routeModel string, headers http.Header, buffered []cliproxyexecutor.StreamChunk, remaining <-chan cliproxyexecutor.StreamChunk) *cliproxyexecutor.StreamResult {
+func (m *Manager) wrapStreamResult(ctx context.Context, auth *Auth, provider, routeModel string, headers http.Header, buffered []cliproxyexecutor.StreamChunk, remaining <-chan cliproxyexecutor.StreamChunk, started time.Time, firstByte time.Duration) *cliproxyexecutor.StreamResult {
 	out := make(chan cliproxyexecutor.StreamChunk)
 	go func() {
 		defer close(out)
@@ -497,6 +523,7 @@ func (m *Manager) wrapStreamResult(ctx context.Context, auth *Auth, provider, ro
 					rerr.HTTPStatus = se.StatusCode()
 				}
 				m.MarkResult(ctx, Result{AuthID: auth.ID, Provider: provider, Model: routeModel, Success: false, Error: rerr})
+				m.observeAttempt(ctx, auth, provider, routeModel, true, started, firstByte, rerr)
 			}
 			if !forward {
 				return false
@@ -527,25 +554,38 @@ func (m *Manager) wrapStreamResult(ctx context.Context, auth *Auth, provider, ro
 		}
 		if !failed {
 			m.MarkResult(ctx, Result{AuthID: auth.ID, Provider: provider, Model: routeModel, Success: true})
+			m.observeAttempt(ctx, auth, provider, routeModel, true, started, firstByte, nil)
 		}
 	}()
 	return &cliproxyexecutor.StreamResult{Headers: headers, Chunks: out}
 }
 
-func (m *Manager) executeStreamWithModelPool(ctx context.Context, executor ProviderExecutor, auth *Auth, provider string, req cliproxyexecutor.Request, opts cliproxyexecutor.Options, routeModel string) (*cliproxyexecutor.StreamResult, error) {
+func (m *Manager) executeStreamWithModelPool(ctx context.Context, executor ProviderExecutor, auth *Auth, provider string, req cliproxyexecutor.Request, opts cliproxyexecutor.Options, routeModel string, attempts *int) (*cliproxyexecutor.StreamResult, error) {
 	if executor == nil {
 		return nil, &Error{Code: "executor_not_found", Message: "executor not registered"}
 	}
 	execModels := m.prepareExecutionModels(auth, routeModel)
 	var lastErr error
 	for idx, execModel := range execModels {
+		if attempts != nil {
+			if *attempts > 0 {
+				m.observeRetry(ctx, provider, routeModel, idx == 0)
+			}
+			*attempts++
+		}
 		execReq := req
 		execReq.Model = execModel
-		streamResult, errStream := executor.ExecuteStream(ctx, auth, execReq, opts)
+		call, errHook := m.beforeExecute(ctx, auth, execReq, opts)
+		if errHook != nil {
+			return nil, errHook
+		}
+		started := time.Now()
+		streamResult, errStream := executor.ExecuteStream(call.ctx, auth, call.state.Request, call.state.Options)
 		if errStream != nil {
 			if errCtx := ctx.Err(); errCtx != nil {
 				return nil, errCtx
 			}
+			m.observeAttempt(ctx, auth, provider, routeModel, true, started, 0, errStream)
 			rerr := &Error{Message: errStream.Error()}
 			if se, ok := errors.AsType[cliproxyexecutor.StatusError](errStream); ok && se != nil {
 				rerr.HTTPStatus = se.StatusCode()
@@ -553,6 +593,9 @@ func (m *Manager) executeStreamWithModelPool(ctx context.Context, executor Provi
 			result := Result{AuthID: auth.ID, Provider: provider, Model: routeModel, Success: false, Error: rerr}
 			result.RetryAfter = retryAfterFromError(errStream)
 			m.MarkResult(ctx, result)
+			if errHook = m.afterExecute(call, nil, errStream); errHook != nil {
+				return nil, errHook
+			}
 			if isRequestInvalidError(errStream) {
 				return nil, errStream
 			}
@@ -561,12 +604,14 @@ func (m *Manager) executeStreamWithModelPool(ctx context.Context, executor Provi
 		}
 
 		buffered, closed, bootstrapErr := readStreamBootstrap(ctx, streamResult.Chunks)
+		firstByte := time.Since(started)
 		if bootstrapErr != nil {
 			if errCtx := ctx.Err(); errCtx != nil {
 				discardStreamChunks(streamResult.Chunks)
 				return nil, errCtx
 			}
 			if isRequestInvalidError(bootstrapErr) {
+				m.observeAttempt(ctx, auth, provider, routeModel, true, started, 0, bootstrapErr)
 				rerr := &Error{Message: bootstrapErr.Error()}
 				if se, ok := errors.AsType[cliproxyexecutor.StatusError](bootstrapErr); ok && se != nil {
 					rerr.HTTPStatus = se.StatusCode()
@@ -575,6 +620,9 @@ func (m *Manager) executeStreamWithModelPool(ctx context.Context, executor Provi
 				result.RetryAfter = retryAfterFromError(bootstrapErr)
 				m.MarkResult(ctx, result)
 				discardStreamChunks(streamResult.Chunks)
+				if errHook = m.afterExecute(call, nil, bootstrapErr); errHook != nil {
+					return nil, errHook
+				}
 				return nil, bootstrapErr
 			}
 			if idx < len(execModels)-1 {
@@ -585,14 +633,18 @@ func (m *Manager) executeStreamWithModelPool(ctx context.Context, executor Provi
 				result := Result{AuthID: auth.ID, Provider: provider, Model: routeModel, Success: false, Error: rerr}
 				result.RetryAfter = retryAfterFromError(bootstrapErr)
 				m.MarkResult(ctx, result)
+				m.observeAttempt(ctx, auth, provider, routeModel, true, started, 0, bootstrapErr)
 				discardStreamChunks(streamResult.Chunks)
+				if errHook = m.afterExecute(call, nil, bootstrapErr); errHook != nil {
+					return nil, errHook
+				}
 				lastErr = bootstrapErr
 				continue
 			}
 			errCh := make(chan cliproxyexecutor.StreamChunk, 1)
 			errCh <- cliproxyexecutor.StreamChunk{Err: bootstrapErr}
 			close(errCh)
-			return m.wrapStreamResult(ctx, auth.Clone(), provider, routeModel, streamResult.Headers, nil, errCh), nil
+			return m.hookStream(call, m.wrapStreamResult(ctx, auth.Clone(), provider, routeModel, streamResult.Headers, nil, errCh, started, 0)), nil
 		}
 
 		if closed && len(buffered) == 0 {
@@ -600,13 +652,17 @@ func (m *Manager) executeStreamWithModelPool(ctx context.Context, executor Provi
 			result := Result{AuthID: auth.ID, Provider: provider, Model: routeModel, Success: false, Error: emptyErr}
 			m.MarkResult(ctx, result)
 			if idx < len(execModels)-1 {
+				m.observeAttempt(ctx, auth, provider, routeModel, true, started, 0, emptyErr)
+				if errHook = m.afterExecute(call, nil, emptyErr); errHook != nil {
+					return nil, errHook
+				}
 				lastErr = emptyErr
 				continue
 			}
 			errCh := make(chan cliproxyexecutor.StreamChunk, 1)
 			errCh <- cliproxyexecutor.StreamChunk{Err: emptyErr}
 			close(errCh)
-			return m.wrapStreamResult(ctx, auth.Clone(), provider, routeModel, streamResult.Headers, nil, errCh), nil
+			return m.hookStream(call, m.wrapStreamResult(ctx, auth.Clone(), provider, routeModel, streamResult.Headers, nil, errCh, started, 0)), nil
 		}
 
 		remaining := streamResult.Chunks
@@ -615,7 +671,7 @@ func (m *Manager) executeStreamWithModelPool(ctx context.Context, executor Provi
 			close(closedCh)
 			remaining = closedCh
 		}
-		return m.wrapStreamResult(ctx, auth.Clone(), provider, routeModel, streamResult.Headers, buffered, remaining), nil
+		return m.hookStream(call, m.wrapStreamResult(ctx, auth.Clone(), provider, routeModel, streamResult.Headers, buffered, remaining, started, firstByte)), nil
 	}
 	if lastErr == nil {
 		lastErr = &Error{Code: "auth_not_found", Message: "no upstream model available"}
@@ -881,7 +937,38 @@ func (m *Manager) Load(ctx context.Context) error {
 
 // Execute performs a non-streaming execution using the configured selector and executor.
 // It supports multiple providers for the same model and round-robins the starting provider per model.
+// When every credential of the model fails with a cooldown, quota or server error, the
+// configured model-fallbacks chain is tried in order.
 func (m *Manager) Execute(ctx context.Context, providers []string, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	chain := m.modelFallbackChain(req.Model)
+	resp, err := m.executeModel(ctx, providers, req, opts, len(chain) == 0)
+	if err == nil {
+		publishServedModel(opts.Metadata, req.Model)
+		return resp, nil
+	}
+	lastErr := err
+	for _, fallback := range chain {
+		if !shouldFallback(ctx, lastErr) {
+			break
+		}
+		fallbackProviders := providersForModel(fallback)
+		if len(fallbackProviders) == 0 {
+			continue
+		}
+		logModelFallback(ctx, req.Model, fallback, lastErr)
+		fallbackReq := req
+		fallbackReq.Model = fallback
+		if resp, lastErr = m.executeModel(cliproxyexecutor.WithFallbackFrom(ctx, req.Model), fallbackProviders, fallbackReq, opts, true); lastErr == nil {
+			publishServedModel(opts.Metadata, fallback)
+			return resp, nil
+		}
+	}
+	return cliproxyexecutor.Response{}, err
+}
+
+// executeModel runs the request against providers, waiting for cooled-down credentials
+// when awaitCooldown is set.
+func (m *Manager) executeModel(ctx context.Context, providers []string, req cliproxyexecutor.Request, opts cliproxyexecutor.Options, awaitCooldown bool) (cliproxyexecutor.Response, error) {
 	normalized := m.normalizeProviders(providers)
 	if len(normalized) == 0 {
 		return cliproxyexecutor.Response{}, &Error{Code: "provider_not_found", Message: "no provider supplied"}
@@ -897,7 +984,7 @@ func (m *Manager) Execute(ctx context.Context, providers []string, req cliproxye
 		}
 		lastErr = errExec
 		wait, shouldRetry := m.shouldRetryAfterError(errExec, attempt, normalized, req.Model, maxWait)
-		if !shouldRetry {
+		if !shouldRetry || !awaitCooldown {
 			break
 		}
 		if errWait := waitForCooldown(ctx, wait); errWait != nil {
@@ -943,7 +1030,37 @@ func (m *Manager) ExecuteCount(ctx context.Context, providers []string, req clip
 
 // ExecuteStream performs a streaming execution using the configured selector and executor.
 // It supports multiple providers for the same model and round-robins the starting provider per model.
+// Fallback chains apply as for Execute, as long as the stream failed before its first byte.
 func (m *Manager) ExecuteStream(ctx context.Context, providers []string, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (*cliproxyexecutor.StreamResult, error) {
+	chain := m.modelFallbackChain(req.Model)
+	result, err := m.executeStreamModel(ctx, providers, req, opts, len(chain) == 0)
+	if err == nil {
+		publishServedModel(opts.Metadata, req.Model)
+		return result, nil
+	}
+	lastErr := err
+	for _, fallback := range chain {
+		if !shouldFallback(ctx, lastErr) {
+			break
+		}
+		fallbackProviders := providersForModel(fallback)
+		if len(fallbackProviders) == 0 {
+			continue
+		}
+		logModelFallback(ctx, req.Model, fallback, lastErr)
+		fallbackReq := req
+		fallbackReq.Model = fallback
+		if result, lastErr = m.executeStreamModel(cliproxyexecutor.WithFallbackFrom(ctx, req.Model), fallbackProviders, fallbackReq, opts, true); lastErr == nil {
+			publishServedModel(opts.Metadata, fallback)
+			return result, nil
+		}
+	}
+	return nil, err
+}
+
+// executeStreamModel runs the request against providers, waiting for cooled-down credentials
+// when awaitCooldown is set.
+func (m *Manager) executeStreamModel(ctx context.Context, providers []string, req cliproxyexecutor.Request, opts cliproxyexecutor.Options, awaitCooldown bool) (*cliproxyexecutor.StreamResult, error) {
 	normalized := m.normalizeProviders(providers)
 	if len(normalized) == 0 {
 		return nil, &Error{Code: "provider_not_found", Message: "no provider supplied"}
@@ -959,7 +1076,7 @@ func (m *Manager) ExecuteStream(ctx context.Context, providers []string, req cli
 		}
 		lastErr = errStream
 		wait, shouldRetry := m.shouldRetryAfterError(errStream, attempt, normalized, req.Model, maxWait)
-		if !shouldRetry {
+		if !shouldRetry || !awaitCooldown {
 			break
 		}
 		if errWait := waitForCooldown(ctx, wait); errWait != nil {
@@ -979,6 +1096,7 @@ func (m *Manager) executeMixedOnce(ctx context.Context, providers []string, req
 	routeModel := req.Model
 	opts = ensureRequestedModelMetadata(opts, routeModel)
 	tried := make(map[string]struct{})
+	attempts := 0
 	var lastErr error
 	for {
 		if maxRetryCredentials > 0 && len(tried) >= maxRetryCredentials {
@@ -987,7 +1105,7 @@ func (m *Manager) executeMixedOnce(ctx context.Context, providers []string, req
 			}
 			return cliproxyexecutor.Response{}, &Error{Code: "auth_not_found", Message: "no auth available"}
 		}
-		auth, executor, provider, errPick := m.pickNextMixed(ctx, providers, routeModel, opts, tried)
+		auth, executor, provider, errPick := m.pickNextMixedWithAffinity(ctx, providers, routeModel, opts, tried)
 		if errPick != nil {
 			if lastErr != nil {
 				return cliproxyexecutor.Response{}, lastErr
@@ -1008,10 +1126,20 @@ func (m *Manager) executeMixedOnce(ctx context.Context, providers []string, req
 
 		models := m.prepareExecutionModels(auth, routeModel)
 		var authErr error
-		for _, upstreamModel := range models {
+		for idx, upstreamModel := range models {
+			if attempts > 0 {
+				m.observeRetry(ctx, provider, routeModel, idx == 0)
+			}
+			attempts++
 			execReq := req
 			execReq.Model = upstreamModel
-			resp, errExec := executor.Execute(execCtx, auth, execReq, opts)
+			call, errHook := m.beforeExecute(execCtx, auth, execReq, opts)
+			if errHook != nil {
+				return cliproxyexecutor.Response{}, errHook
+			}
+			started := time.Now()
+			resp, errExec := executor.Execute(call.ctx, auth, call.state.Request, call.state.Options)
+			m.observeAttempt(execCtx, auth, provider, routeModel, false, started, 0, errExec)
 			result := Result{AuthID: auth.ID, Provider: provider, Model: routeModel, Success: errExec == nil}
 			if errExec != nil {
 				if errCtx := execCtx.Err(); errCtx != nil {
@@ -1025,6 +1153,9 @@ func (m *Manager) executeMixedOnce(ctx context.Context, providers []string, req
 					result.RetryAfter = ra
 				}
 				m.MarkResult(execCtx, result)
+				if errHook = m.afterExecute(call, &resp, errExec); errHook != nil {
+					return cliproxyexecutor.Response{}, errHook
+				}
 				if isRequestInvalidError(errExec) {
 					return cliproxyexecutor.Response{}, errExec
 				}
@@ -1032,6 +1163,9 @@ func (m *Manager) executeMixedOnce(ctx context.Context, providers []string, req
 				continue
 			}
 			m.MarkResult(execCtx, result)
+			if errHook = m.afterExecute(call, &resp, nil); errHook != nil {
+				return cliproxyexecutor.Response{}, errHook
+			}
 			return resp, nil
 		}
 		if authErr != nil {
@@ -1059,7 +1193,7 @@ func (m *Manager) executeCountMixedOnce(ctx context.Context, providers []string,
 			}
 			return cliproxyexecutor.Response{}, &Error{Code: "auth_not_found", Message: "no auth available"}
 		}
-		auth, executor, provider, errPick := m.pickNextMixed(ctx, providers, routeModel, opts, tried)
+		auth, executor, provider, errPick := m.pickNextMixedWithAffinity(ctx, providers, routeModel, opts, tried)
 		if errPick != nil {
 			if lastErr != nil {
 				return cliproxyexecutor.Response{}, lastErr
@@ -1083,7 +1217,11 @@ func (m *Manager) executeCountMixedOnce(ctx context.Context, providers []string,
 		for _, upstreamModel := range models {
 			execReq := req
 			execReq.Model = upstreamModel
-			resp, errExec := executor.CountTokens(execCtx, auth, execReq, opts)
+			call, errHook := m.beforeExecute(execCtx, auth, execReq, opts)
+			if errHook != nil {
+				return cliproxyexecutor.Response{}, errHook
+			}
+			resp, errExec := executor.CountTokens(call.ctx, auth, call.state.Request, call.state.Options)
 			result := Result{AuthID: auth.ID, Provider: provider, Model: routeModel, Success: errExec == nil}
 			if errExec != nil {
 				if errCtx := execCtx.Err(); errCtx != nil {
@@ -1097,6 +1235,9 @@ func (m *Manager) executeCountMixedOnce(ctx context.Context, providers []string,
 					result.RetryAfter = ra
 				}
 				m.hook.OnResult(execCtx, result)
+				if errHook = m.afterExecute(call, &resp, errExec); errHook != nil {
+					return cliproxyexecutor.Response{}, errHook
+				}
 				if isRequestInvalidError(errExec) {
 					return cliproxyexecutor.Response{}, errExec
 				}
@@ -1104,6 +1245,9 @@ func (m *Manager) executeCountMixedOnce(ctx context.Context, providers []string,
 				continue
 			}
 			m.hook.OnResult(execCtx, result)
+			if errHook = m.afterExecute(call, &resp, nil); errHook != nil {
+				return cliproxyexecutor.Response{}, errHook
+			}
 			return resp, nil
 		}
 		if authErr != nil {
@@ -1123,6 +1267,7 @@ func (m *Manager) executeStreamMixedOnce(ctx context.Context, providers []string
 	routeModel := req.Model
 	opts = ensureRequestedModelMetadata(opts, routeModel)
 	tried := make(map[string]struct{})
+	attempts := 0
 	var lastErr error
 	for {
 		if maxRetryCredentials > 0 && len(tried) >= maxRetryCredentials {
@@ -1131,7 +1276,7 @@ func (m *Manager) executeStreamMixedOnce(ctx context.Context, providers []string
 			}
 			return nil, &Error{Code: "auth_not_found", Message: "no auth available"}
 		}
-		auth, executor, provider, errPick := m.pickNextMixed(ctx, providers, routeModel, opts, tried)
+		auth, executor, provider, errPick := m.pickNextMixedWithAffinity(ctx, providers, routeModel, opts, tried)
 		if errPick != nil {
 			if lastErr != nil {
 				return nil, lastErr
@@ -1149,7 +1294,7 @@ func (m *Manager) executeStreamMixedOnce(ctx context.Context, providers []string
 			execCtx = context.WithValue(execCtx, roundTripperContextKey{}, rt)
 			execCtx = context.WithValue(execCtx, "cliproxy.roundtripper", rt)
 		}
-		streamResult, errStream := m.executeStreamWithModelPool(execCtx, executor, auth, provider, req, opts, routeModel)
+		streamResult, errStream := m.executeStreamWithModelPool(execCtx, executor, auth, provider, req, opts, routeModel, &attempts)
 		if errStream != nil {
 			if errCtx := execCtx.Err(); errCtx != nil {
 				return nil, errCtx
@@ -1592,10 +1737,16 @@ func (m *Manager) MarkResult(ctx context.Context, result Result) {
 	clearModelQuota := false
 	setModelQuota := false
 	var authSnapshot *Auth
+	var sharedUpdate *SharedAuthState
+	sharing := m.sharedState.Load() != nil
 
 	m.mu.Lock()
 	if auth, ok := m.auths[result.AuthID]; ok && auth != nil {
 		now := time.Now()
+		var sharedBefore SharedAuthState
+		if sharing {
+			sharedBefore = sharedAuthStateOf(auth, now)
+		}
 
 		if result.Success {
 			if result.Model != "" {
@@ -1686,11 +1837,19 @@ func (m *Manager) MarkResult(ctx context.Context, result Result) {
 
 		_ = m.persist(ctx, auth)
 		authSnapshot = auth.Clone()
+		if sharing {
+			if sharedAfter := sharedAuthStateOf(auth, now); sharedStateChanged(sharedBefore, sharedAfter) {
+				sharedUpdate = &sharedAfter
+			}
+		}
 	}
 	m.mu.Unlock()
 	if m.scheduler != nil && authSnapshot != nil {
 		m.scheduler.upsertAuth(authSnapshot)
 	}
+	if sharedUpdate != nil {
+		m.publishSharedState(*sharedUpdate)
+	}
 
 	if clearModelQuota && result.Model != "" {
 		registry.GetGlobalRegistry().ClearModelQuotaExceeded(result.AuthID, result.Model)
@@ -2632,6 +2791,25 @@ func (m *Manager) refreshAuth(ctx context.Context, id string) {
 	if auth == nil || exec == nil {
 		return
 	}
+	release, acquired := m.acquireRefreshLease(ctx, id)
+	if !acquired {
+		log.Debugf("refresh of %s, %s is in progress on another instance", auth.Provider, auth.ID)
+		return
+	}
+	defer release()
+	unlock, locked := m.lockAuthForRefresh(ctx, auth)
+	if !locked {
+		return
+	}
+	defer unlock()
+	if stored := m.reloadStoredAuth(ctx, auth); stored != nil {
+		auth = stored
+		if !m.shouldRefresh(auth, time.Now()) {
+			log.Debugf("%s, %s was refreshed by another process", auth.Provider, auth.ID)
+			_, _ = m.Update(ctx, auth)
+			return
+		}
+	}
 	cloned := auth.Clone()
 	updated, err := exec.Refresh(ctx, cloned)
 	if err != nil && errors.Is(err, context.Canceled) {
@@ -2651,6 +2829,9 @@ func (m *Manager) refreshAuth(ctx context.Context, id string) {
 			}
 		}
 		m.mu.Unlock()
+		if hook, ok := m.hook.(RefreshFailureHook); ok {
+			hook.OnRefreshFailed(ctx, auth.Clone(), err)
+		}
 		return
 	}
 	if updated == nil {
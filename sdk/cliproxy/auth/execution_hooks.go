@@ -0,0 +1,157 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+)
+
+// ExecutionContext carries the state of one executor call to execution hooks.
+// sdk/cliproxy/pipeline re-exports it as pipeline.Context.
+type ExecutionContext struct {
+	// Request is the request handed to the executor: the client payload in the source
+	// format, with Model already resolved to the upstream model of the attempt.
+	Request cliproxyexecutor.Request
+	// Options carries execution flags (streaming, headers, etc.).
+	Options cliproxyexecutor.Options
+	// Auth references the credential selected for execution.
+	Auth *Auth
+	// Translator translates payloads between formats, e.g. to inspect the provider body.
+	Translator *sdktranslator.Pipeline
+	// HTTPClient exposes the outbound transport of the attempt. Hooks may replace it;
+	// executors then send the upstream request through its Transport.
+	HTTPClient *http.Client
+}
+
+// ExecutionHook intercepts each upstream attempt made by Manager once a credential has
+// been selected. Hooks run in registration order and must be safe for concurrent use.
+type ExecutionHook interface {
+	// BeforeExecute may rewrite the request, options or HTTP client. A non-nil error vetoes
+	// the request: it is returned to the caller without trying other credentials and
+	// without counting against the selected one.
+	BeforeExecute(ctx context.Context, execCtx *ExecutionContext) error
+	// AfterExecute observes the outcome of an attempt and may rewrite resp. For streams it
+	// runs once the stream ends, with the stream headers and terminal error. A non-nil
+	// error ends the request with that error.
+	AfterExecute(ctx context.Context, execCtx *ExecutionContext, resp *cliproxyexecutor.Response, err error) error
+	// OnStreamChunk observes and may rewrite each chunk before it reaches the caller.
+	// Clearing both Payload and Err drops the chunk; a non-nil error ends the stream.
+	OnStreamChunk(ctx context.Context, execCtx *ExecutionContext, chunk *cliproxyexecutor.StreamChunk) error
+}
+
+// AddExecutionHook registers a hook for all subsequent executions.
+func (m *Manager) AddExecutionHook(hook ExecutionHook) {
+	if m == nil || hook == nil {
+		return
+	}
+	m.mu.Lock()
+	m.executionHooks = append(m.executionHooks, hook)
+	m.mu.Unlock()
+}
+
+func (m *Manager) executionHooksSnapshot() []ExecutionHook {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.executionHooks
+}
+
+// executionCall is one upstream attempt as prepared by the registered hooks.
+type executionCall struct {
+	ctx   context.Context
+	hooks []ExecutionHook
+	state ExecutionContext
+}
+
+// beforeExecute runs BeforeExecute hooks for an attempt. The returned call carries the
+// context, request and options the executor must receive.
+func (m *Manager) beforeExecute(ctx context.Context, auth *Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (*executionCall, error) {
+	call := &executionCall{ctx: ctx, state: ExecutionContext{Request: req, Options: opts, Auth: auth}}
+	call.hooks = m.executionHooksSnapshot()
+	if len(call.hooks) == 0 {
+		return call, nil
+	}
+	rt, _ := ctx.Value(roundTripperContextKey{}).(http.RoundTripper)
+	if rt != nil {
+		call.state.HTTPClient = &http.Client{Transport: rt}
+	}
+	call.state.Translator = sdktranslator.NewPipeline(nil)
+	for _, hook := range call.hooks {
+		if err := hook.BeforeExecute(ctx, &call.state); err != nil {
+			return nil, err
+		}
+	}
+	if client := call.state.HTTPClient; client != nil && client.Transport != nil && client.Transport != rt {
+		call.ctx = context.WithValue(call.ctx, roundTripperContextKey{}, client.Transport)
+		call.ctx = context.WithValue(call.ctx, "cliproxy.roundtripper", client.Transport)
+	}
+	return call, nil
+}
+
+// afterExecute runs AfterExecute hooks and returns the error that replaces the outcome, if any.
+func (m *Manager) afterExecute(call *executionCall, resp *cliproxyexecutor.Response, err error) error {
+	if call == nil || len(call.hooks) == 0 {
+		return nil
+	}
+	if resp == nil {
+		resp = &cliproxyexecutor.Response{}
+	}
+	var hookErr error
+	for _, hook := range call.hooks {
+		if errHook := hook.AfterExecute(call.ctx, &call.state, resp, err); errHook != nil && hookErr == nil {
+			hookErr = errHook
+		}
+	}
+	return hookErr
+}
+
+// hookStream passes every chunk of result through OnStreamChunk hooks and runs
+// AfterExecute hooks once the stream ends.
+func (m *Manager) hookStream(call *executionCall, result *cliproxyexecutor.StreamResult) *cliproxyexecutor.StreamResult {
+	if call == nil || len(call.hooks) == 0 || result == nil {
+		return result
+	}
+	ctx := call.ctx
+	out := make(chan cliproxyexecutor.StreamChunk)
+	go func() {
+		defer close(out)
+		send := func(chunk cliproxyexecutor.StreamChunk) bool {
+			select {
+			case <-ctx.Done():
+				return false
+			case out <- chunk:
+				return true
+			}
+		}
+		var streamErr error
+		for chunk := range result.Chunks {
+			if chunk.Err != nil {
+				streamErr = chunk.Err
+			}
+			var hookErr error
+			for _, hook := range call.hooks {
+				if hookErr = hook.OnStreamChunk(ctx, &call.state, &chunk); hookErr != nil {
+					break
+				}
+			}
+			if hookErr != nil {
+				discardStreamChunks(result.Chunks)
+				_ = m.afterExecute(call, &cliproxyexecutor.Response{Headers: result.Headers}, hookErr)
+				send(cliproxyexecutor.StreamChunk{Err: hookErr})
+				return
+			}
+			if chunk.Payload == nil && chunk.Err == nil {
+				continue
+			}
+			if !send(chunk) {
+				discardStreamChunks(result.Chunks)
+				return
+			}
+		}
+		if hookErr := m.afterExecute(call, &cliproxyexecutor.Response{Headers: result.Headers}, streamErr); hookErr != nil {
+			send(cliproxyexecutor.StreamChunk{Err: hookErr})
+		}
+	}()
+	return &cliproxyexecutor.StreamResult{Headers: result.Headers, Chunks: out}
+}
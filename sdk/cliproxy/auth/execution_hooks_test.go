@@ -0,0 +1,225 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+// hookTestExecutor echoes the request payload, or the body fetched through the context
+// round tripper when fetch is set, the way provider executors pick up transports.
+type hookTestExecutor struct {
+	fetch bool
+
+	mu       sync.Mutex
+	payloads []string
+}
+
+func (e *hookTestExecutor) Identifier() string { return "hooked" }
+
+func (e *hookTestExecutor) Execute(ctx context.Context, _ *Auth, req cliproxyexecutor.Request, _ cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	e.mu.Lock()
+	e.payloads = append(e.payloads, string(req.Payload))
+	e.mu.Unlock()
+	if !e.fetch {
+		return cliproxyexecutor.Response{Payload: req.Payload}, nil
+	}
+	client := &http.Client{}
+	if rt, ok := ctx.Value("cliproxy.roundtripper").(http.RoundTripper); ok && rt != nil {
+		client.Transport = rt
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, "http://upstream.invalid/v1/chat", bytes.NewReader(req.Payload))
+	if err != nil {
+		return cliproxyexecutor.Response{}, err
+	}
+	httpResp, err := client.Do(httpReq)
+	if err != nil {
+		return cliproxyexecutor.Response{}, err
+	}
+	defer func() { _ = httpResp.Body.Close() }()
+	body, err := io.ReadAll(httpResp.Body)
+	return cliproxyexecutor.Response{Payload: body}, err
+}
+
+func (e *hookTestExecutor) ExecuteStream(_ context.Context, _ *Auth, req cliproxyexecutor.Request, _ cliproxyexecutor.Options) (*cliproxyexecutor.StreamResult, error) {
+	ch := make(chan cliproxyexecutor.StreamChunk, 2)
+	ch <- cliproxyexecutor.StreamChunk{Payload: []byte("echo: " + string(req.Payload))}
+	ch <- cliproxyexecutor.StreamChunk{Payload: []byte("token sk-live-123")}
+	close(ch)
+	return &cliproxyexecutor.StreamResult{Chunks: ch}, nil
+}
+
+func (e *hookTestExecutor) Refresh(_ context.Context, auth *Auth) (*Auth, error) { return auth, nil }
+
+func (e *hookTestExecutor) CountTokens(ctx context.Context, auth *Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	return e.Execute(ctx, auth, req, opts)
+}
+
+func (e *hookTestExecutor) HttpRequest(context.Context, *Auth, *http.Request) (*http.Response, error) {
+	return nil, &Error{HTTPStatus: http.StatusNotImplemented, Message: "HttpRequest not implemented"}
+}
+
+type hookFuncs struct {
+	before func(context.Context, *ExecutionContext) error
+	after  func(context.Context, *ExecutionContext, *cliproxyexecutor.Response, error) error
+	chunk  func(context.Context, *ExecutionContext, *cliproxyexecutor.StreamChunk) error
+}
+
+func (h hookFuncs) BeforeExecute(ctx context.Context, execCtx *ExecutionContext) error {
+	if h.before == nil {
+		return nil
+	}
+	return h.before(ctx, execCtx)
+}
+
+func (h hookFuncs) AfterExecute(ctx context.Context, execCtx *ExecutionContext, resp *cliproxyexecutor.Response, err error) error {
+	if h.after == nil {
+		return nil
+	}
+	return h.after(ctx, execCtx, resp, err)
+}
+
+func (h hookFuncs) OnStreamChunk(ctx context.Context, execCtx *ExecutionContext, chunk *cliproxyexecutor.StreamChunk) error {
+	if h.chunk == nil {
+		return nil
+	}
+	return h.chunk(ctx, execCtx, chunk)
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+func newHookTestManager(t *testing.T, executor *hookTestExecutor) *Manager {
+	t.Helper()
+	m := NewManager(nil, nil, nil)
+	m.RegisterExecutor(executor)
+	auth := &Auth{ID: "hooked-auth-" + t.Name(), Provider: "hooked", Status: StatusActive}
+	if _, err := m.Register(context.Background(), auth); err != nil {
+		t.Fatalf("register auth: %v", err)
+	}
+	reg := registry.GetGlobalRegistry()
+	reg.RegisterClient(auth.ID, "hooked", []*registry.ModelInfo{{ID: "hook-model"}})
+	t.Cleanup(func() { reg.UnregisterClient(auth.ID) })
+	return m
+}
+
+func redact(data []byte) []byte {
+	return []byte(strings.ReplaceAll(strings.ReplaceAll(string(data), "sk-live-123", "[REDACTED]"), "secret", "[REDACTED]"))
+}
+
+func TestExecutionHook_RedactsRequestAndStream(t *testing.T) {
+	executor := &hookTestExecutor{}
+	m := newHookTestManager(t, executor)
+	var seenAuth string
+	m.AddExecutionHook(hookFuncs{
+		before: func(_ context.Context, execCtx *ExecutionContext) error {
+			seenAuth = execCtx.Auth.ID
+			execCtx.Request.Payload = redact(execCtx.Request.Payload)
+			return nil
+		},
+		chunk: func(_ context.Context, _ *ExecutionContext, chunk *cliproxyexecutor.StreamChunk) error {
+			chunk.Payload = redact(chunk.Payload)
+			return nil
+		},
+	})
+
+	resp, err := m.Execute(context.Background(), []string{"hooked"}, cliproxyexecutor.Request{Model: "hook-model", Payload: []byte("my secret")}, cliproxyexecutor.Options{})
+	if err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+	if string(resp.Payload) != "my [REDACTED]" || executor.payloads[0] != "my [REDACTED]" {
+		t.Fatalf("executor saw %q and returned %q, want redacted payload", executor.payloads[0], resp.Payload)
+	}
+	if seenAuth != "hooked-auth-"+t.Name() {
+		t.Fatalf("hook saw auth %q", seenAuth)
+	}
+
+	result, err := m.ExecuteStream(context.Background(), []string{"hooked"}, cliproxyexecutor.Request{Model: "hook-model", Payload: []byte("hi")}, cliproxyexecutor.Options{})
+	if err != nil {
+		t.Fatalf("execute stream: %v", err)
+	}
+	var chunks []string
+	for chunk := range result.Chunks {
+		if chunk.Err != nil {
+			t.Fatalf("stream error: %v", chunk.Err)
+		}
+		chunks = append(chunks, string(chunk.Payload))
+	}
+	if strings.Join(chunks, "|") != "echo: hi|token [REDACTED]" {
+		t.Fatalf("chunks = %q", chunks)
+	}
+}
+
+func TestExecutionHook_SwapsTransport(t *testing.T) {
+	executor := &hookTestExecutor{fetch: true}
+	m := newHookTestManager(t, executor)
+	var upstreamCalls int
+	transport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		upstreamCalls++
+		body, _ := io.ReadAll(req.Body)
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{},
+			Body:       io.NopCloser(strings.NewReader("mocked " + string(body))),
+			Request:    req,
+		}, nil
+	})
+	m.AddExecutionHook(hookFuncs{
+		before: func(_ context.Context, execCtx *ExecutionContext) error {
+			execCtx.HTTPClient = &http.Client{Transport: transport}
+			return nil
+		},
+	})
+
+	resp, err := m.Execute(context.Background(), []string{"hooked"}, cliproxyexecutor.Request{Model: "hook-model", Payload: []byte("ping")}, cliproxyexecutor.Options{})
+	if err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+	if upstreamCalls != 1 || string(resp.Payload) != "mocked ping" {
+		t.Fatalf("upstream calls = %d, payload = %q; want the swapped transport to serve the request", upstreamCalls, resp.Payload)
+	}
+}
+
+func TestExecutionHook_VetoSkipsExecutionAndRewritesResponse(t *testing.T) {
+	executor := &hookTestExecutor{}
+	m := newHookTestManager(t, executor)
+	blocked := errors.New("blocked by policy")
+	m.AddExecutionHook(hookFuncs{
+		before: func(_ context.Context, execCtx *ExecutionContext) error {
+			if bytes.Contains(execCtx.Request.Payload, []byte("forbidden")) {
+				return blocked
+			}
+			return nil
+		},
+		after: func(_ context.Context, _ *ExecutionContext, resp *cliproxyexecutor.Response, err error) error {
+			if err == nil {
+				resp.Payload = append(resp.Payload, "!"...)
+			}
+			return nil
+		},
+	})
+
+	if _, err := m.Execute(context.Background(), []string{"hooked"}, cliproxyexecutor.Request{Model: "hook-model", Payload: []byte("forbidden")}, cliproxyexecutor.Options{}); !errors.Is(err, blocked) {
+		t.Fatalf("execute error = %v, want veto", err)
+	}
+	if len(executor.payloads) != 0 {
+		t.Fatalf("executor ran %d times after veto", len(executor.payloads))
+	}
+
+	resp, err := m.Execute(context.Background(), []string{"hooked"}, cliproxyexecutor.Request{Model: "hook-model", Payload: []byte("ok")}, cliproxyexecutor.Options{})
+	if err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+	if string(resp.Payload) != "ok!" {
+		t.Fatalf("payload = %q, want rewritten by AfterExecute", resp.Payload)
+	}
+}
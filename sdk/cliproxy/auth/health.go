@@ -0,0 +1,284 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand/v2"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+const (
+	// healthDecay is the weight of the newest sample in the moving success rate and latency.
+	healthDecay = 0.2
+	// healthDemoteBelow is the score under which a credential is demoted.
+	healthDemoteBelow = 0.5
+	// healthProbeInterval is how long a demoted credential rests before it is probed again.
+	healthProbeInterval = 30 * time.Second
+	// healthMinWeight keeps demoted credentials selectable when every candidate is demoted.
+	healthMinWeight = 0.01
+	// healthMaxFailurePenalty caps how many consecutive failures halve the score.
+	healthMaxFailurePenalty = 10
+)
+
+// WeightedHealthSelector spreads requests across credentials in proportion to how well they
+// perform for the requested model. Each credential is scored from its recent success rate,
+// its latency relative to the fastest candidate and its consecutive failures; credentials
+// scoring below 0.5 are demoted and only receive a probe request every 30 seconds until they
+// recover. Scores come from the Manager the selector is installed in.
+type WeightedHealthSelector struct {
+	health atomic.Pointer[healthTracker]
+}
+
+// Pick implements Selector.
+func (s *WeightedHealthSelector) Pick(ctx context.Context, provider, model string, opts cliproxyexecutor.Options, auths []*Auth) (*Auth, error) {
+	_ = opts
+	available, err := getAvailableAuths(auths, provider, model, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	available = preferCodexWebsocketAuths(ctx, provider, available)
+	ids := make([]string, len(available))
+	for i, auth := range available {
+		ids[i] = auth.ID
+	}
+	return available[s.health.Load().pick(canonicalModelKey(model), ids, time.Now())], nil
+}
+
+// attach makes the selector use the scores recorded by health.
+func (s *WeightedHealthSelector) attach(health *healthTracker) {
+	if s != nil {
+		s.health.Store(health)
+	}
+}
+
+// HealthScore reports how a credential performs for one model.
+type HealthScore struct {
+	AuthID   string `json:"auth_id"`
+	Provider string `json:"provider"`
+	Model    string `json:"model"`
+	// Score is the selection weight between 0 and 1.
+	Score float64 `json:"score"`
+	// Demoted marks credentials that only receive periodic probe requests.
+	Demoted bool `json:"demoted"`
+	// SuccessRate is the exponentially weighted share of successful attempts.
+	SuccessRate float64 `json:"success_rate"`
+	// LatencyMs is the exponentially weighted latency of successful attempts.
+	LatencyMs           float64   `json:"latency_ms"`
+	Requests            int64     `json:"requests"`
+	Failures            int64     `json:"failures"`
+	ConsecutiveFailures int       `json:"consecutive_failures"`
+	LastAttempt         time.Time `json:"last_attempt"`
+}
+
+// HealthScores returns the health of every credential that served a request, grouped by
+// provider and model.
+func (m *Manager) HealthScores() []HealthScore {
+	if m == nil || m.health == nil {
+		return nil
+	}
+	m.mu.RLock()
+	known := make(map[string]struct{}, len(m.auths))
+	for id := range m.auths {
+		known[id] = struct{}{}
+	}
+	m.mu.RUnlock()
+	return m.health.scores(known)
+}
+
+// attachHealth points a weighted-health selector at the scores recorded by the manager.
+func (m *Manager) attachHealth(selector Selector) {
+	if weighted, ok := selector.(*WeightedHealthSelector); ok {
+		weighted.attach(m.health)
+	}
+}
+
+type healthKey struct {
+	authID string
+	model  string
+}
+
+type healthStats struct {
+	provider            string
+	successRate         float64
+	latencyMs           float64
+	requests            int64
+	failures            int64
+	consecutiveFailures int
+	lastAttempt         time.Time
+}
+
+// healthTracker keeps the per auth and model statistics behind the weighted-health strategy.
+// It is recorded for every strategy so scores are available as soon as it is enabled.
+type healthTracker struct {
+	mu    sync.Mutex
+	stats map[healthKey]*healthStats
+}
+
+func newHealthTracker() *healthTracker {
+	return &healthTracker{stats: make(map[healthKey]*healthStats)}
+}
+
+// record adds the outcome of one attempt. Errors caused by the request itself or by the
+// client going away say nothing about the credential and are ignored.
+func (h *healthTracker) record(authID, provider, model string, latency time.Duration, err error, now time.Time) {
+	if h == nil || authID == "" {
+		return
+	}
+	if err != nil && (isRequestInvalidError(err) || errors.Is(err, context.Canceled)) {
+		return
+	}
+	key := healthKey{authID: authID, model: canonicalModelKey(model)}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	stats := h.stats[key]
+	if stats == nil {
+		stats = &healthStats{provider: provider, successRate: 1}
+		h.stats[key] = stats
+	}
+	stats.requests++
+	stats.lastAttempt = now
+	if err != nil {
+		stats.failures++
+		stats.consecutiveFailures++
+		stats.successRate *= 1 - healthDecay
+		return
+	}
+	stats.consecutiveFailures = 0
+	stats.successRate = (1-healthDecay)*stats.successRate + healthDecay
+	ms := float64(latency) / float64(time.Millisecond)
+	if stats.latencyMs == 0 {
+		stats.latencyMs = ms
+	} else {
+		stats.latencyMs = (1-healthDecay)*stats.latencyMs + healthDecay*ms
+	}
+}
+
+// scoresLocked scores candidates relative to each other; the fastest one sets the latency baseline.
+func (h *healthTracker) scoresLocked(model string, ids []string) []float64 {
+	fastest := 0.0
+	for _, id := range ids {
+		if stats := h.stats[healthKey{authID: id, model: model}]; stats != nil && stats.latencyMs > 0 {
+			if fastest == 0 || stats.latencyMs < fastest {
+				fastest = stats.latencyMs
+			}
+		}
+	}
+	scores := make([]float64, len(ids))
+	for i, id := range ids {
+		scores[i] = healthScore(h.stats[healthKey{authID: id, model: model}], fastest)
+	}
+	return scores
+}
+
+func healthScore(stats *healthStats, fastest float64) float64 {
+	if stats == nil {
+		return 1
+	}
+	score := stats.successRate
+	if fastest > 0 && stats.latencyMs > 0 {
+		score *= math.Sqrt(fastest / stats.latencyMs)
+	}
+	failures := min(stats.consecutiveFailures, healthMaxFailurePenalty)
+	return score * math.Pow(0.5, float64(failures))
+}
+
+// pick returns the index of the candidate to use. A demoted candidate that rested for
+// healthProbeInterval is probed first; otherwise candidates are drawn at random weighted by
+// score, leaving demoted ones out unless every candidate is demoted.
+func (h *healthTracker) pick(model string, ids []string, now time.Time) int {
+	if len(ids) <= 1 {
+		return 0
+	}
+	if h == nil {
+		return rand.IntN(len(ids))
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	scores := h.scoresLocked(model, ids)
+	healthy := false
+	for i, score := range scores {
+		if score >= healthDemoteBelow {
+			healthy = true
+			continue
+		}
+		stats := h.stats[healthKey{authID: ids[i], model: model}]
+		if stats != nil && now.Sub(stats.lastAttempt) >= healthProbeInterval {
+			// Count the probe as an attempt so concurrent picks do not probe it again.
+			stats.lastAttempt = now
+			return i
+		}
+	}
+	total := 0.0
+	for i, score := range scores {
+		switch {
+		case healthy && score < healthDemoteBelow:
+			scores[i] = 0
+		case score < healthMinWeight:
+			scores[i] = healthMinWeight
+		}
+		total += scores[i]
+	}
+	target := rand.Float64() * total
+	for i, score := range scores {
+		if target < score {
+			return i
+		}
+		target -= score
+	}
+	return len(ids) - 1
+}
+
+// scores reports the tracked credentials in known, each scored against the other credentials
+// of the same provider and model.
+func (h *healthTracker) scores(known map[string]struct{}) []HealthScore {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	type group struct {
+		provider, model string
+	}
+	groups := make(map[group][]string)
+	for key, stats := range h.stats {
+		if _, ok := known[key.authID]; !ok {
+			delete(h.stats, key)
+			continue
+		}
+		g := group{provider: stats.provider, model: key.model}
+		groups[g] = append(groups[g], key.authID)
+	}
+	out := make([]HealthScore, 0, len(h.stats))
+	for g, ids := range groups {
+		scores := h.scoresLocked(g.model, ids)
+		for i, id := range ids {
+			stats := h.stats[healthKey{authID: id, model: g.model}]
+			out = append(out, HealthScore{
+				AuthID:              id,
+				Provider:            g.provider,
+				Model:               g.model,
+				Score:               scores[i],
+				Demoted:             scores[i] < healthDemoteBelow,
+				SuccessRate:         stats.successRate,
+				LatencyMs:           stats.latencyMs,
+				Requests:            stats.requests,
+				Failures:            stats.failures,
+				ConsecutiveFailures: stats.consecutiveFailures,
+				LastAttempt:         stats.lastAttempt,
+			})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Provider != out[j].Provider {
+			return out[i].Provider < out[j].Provider
+		}
+		if out[i].Model != out[j].Model {
+			return out[i].Model < out[j].Model
+		}
+		return out[i].Score > out[j].Score
+	})
+	return out
+}
@@ -0,0 +1,114 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+func TestHealthTracker_DemotesFailingAuthAndProbesIt(t *testing.T) {
+	t.Parallel()
+
+	health := newHealthTracker()
+	now := time.Now()
+	ids := []string{"healthy", "flaky"}
+	for i := 0; i < 3; i++ {
+		health.record("healthy", "gemini", "m", 100*time.Millisecond, nil, now)
+		health.record("flaky", "gemini", "m", 0, errors.New("upstream failed"), now)
+	}
+
+	for i := 0; i < 50; i++ {
+		if got := ids[health.pick("m", ids, now)]; got != "healthy" {
+			t.Fatalf("pick() #%d = %q, want %q while flaky is demoted", i, got, "healthy")
+		}
+	}
+
+	probeAt := now.Add(healthProbeInterval)
+	if got := ids[health.pick("m", ids, probeAt)]; got != "flaky" {
+		t.Fatalf("pick() after probe interval = %q, want %q", got, "flaky")
+	}
+	if got := ids[health.pick("m", ids, probeAt)]; got != "healthy" {
+		t.Fatalf("pick() right after probe = %q, want %q", got, "healthy")
+	}
+}
+
+func TestHealthTracker_IgnoresInvalidRequestsAndScoresLatency(t *testing.T) {
+	t.Parallel()
+
+	health := newHealthTracker()
+	now := time.Now()
+	health.record("fast", "claude", "m", 100*time.Millisecond, nil, now)
+	health.record("slow", "claude", "m", 900*time.Millisecond, nil, now)
+	health.record("fast", "claude", "m", 0, &Error{HTTPStatus: 400, Message: "invalid_request_error"}, now)
+	health.record("fast", "claude", "m", 0, context.Canceled, now)
+
+	scores := health.scores(map[string]struct{}{"fast": {}, "slow": {}})
+	if len(scores) != 2 {
+		t.Fatalf("scores() len = %d, want 2", len(scores))
+	}
+	fast, slow := scores[0], scores[1]
+	if fast.AuthID != "fast" || fast.Requests != 1 || fast.Score != 1 || fast.Demoted {
+		t.Fatalf("fast score = %+v, want one request scored 1", fast)
+	}
+	if slow.AuthID != "slow" || !slow.Demoted {
+		t.Fatalf("slow score = %+v, want demoted for 9x latency", slow)
+	}
+
+	if got := health.scores(map[string]struct{}{"fast": {}}); len(got) != 1 {
+		t.Fatalf("scores() after removing slow len = %d, want 1", len(got))
+	}
+}
+
+func TestSchedulerPick_WeightedHealthAvoidsDemotedAuth(t *testing.T) {
+	t.Parallel()
+
+	scheduler := newSchedulerForTest(
+		&WeightedHealthSelector{},
+		&Auth{ID: "a", Provider: "gemini"},
+		&Auth{ID: "b", Provider: "gemini"},
+	)
+	scheduler.health = newHealthTracker()
+	for i := 0; i < 3; i++ {
+		scheduler.health.record("a", "gemini", "", 0, errors.New("timeout"), time.Now())
+	}
+
+	for index := 0; index < 20; index++ {
+		got, errPick := scheduler.pickSingle(context.Background(), "gemini", "", cliproxyexecutor.Options{}, nil)
+		if errPick != nil {
+			t.Fatalf("pickSingle() #%d error = %v", index, errPick)
+		}
+		if got == nil || got.ID != "b" {
+			t.Fatalf("pickSingle() #%d auth = %v, want b", index, got)
+		}
+	}
+
+	got, errPick := scheduler.pickSingle(context.Background(), "gemini", "", cliproxyexecutor.Options{}, map[string]struct{}{"b": {}})
+	if errPick != nil {
+		t.Fatalf("pickSingle() with b tried error = %v", errPick)
+	}
+	if got == nil || got.ID != "a" {
+		t.Fatalf("pickSingle() with b tried auth = %v, want a", got)
+	}
+}
+
+func TestManager_WeightedHealthUsesSchedulerAndRecordsAttempts(t *testing.T) {
+	t.Parallel()
+
+	manager := NewManager(nil, &WeightedHealthSelector{}, nil)
+	if !manager.useSchedulerFastPath() {
+		t.Fatal("useSchedulerFastPath() = false, want true for weighted-health")
+	}
+	if _, errRegister := manager.Register(context.Background(), &Auth{ID: "auth-1", Provider: "gemini"}); errRegister != nil {
+		t.Fatalf("Register() error = %v", errRegister)
+	}
+	manager.observeAttempt(context.Background(), &Auth{ID: "auth-1"}, "gemini", "m", false, time.Now(), 0, nil)
+	manager.observeAttempt(context.Background(), &Auth{ID: "removed"}, "gemini", "m", false, time.Now(), 0, nil)
+
+	scores := manager.HealthScores()
+	if len(scores) != 1 || scores[0].AuthID != "auth-1" || scores[0].Requests != 1 {
+		t.Fatalf("HealthScores() = %+v, want one request for auth-1", scores)
+	}
+}
@@ -0,0 +1,73 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+// modelFallbackChain returns the model-fallbacks entry of model. A thinking suffix on the
+// requested model carries over to fallbacks that do not set their own.
+func (m *Manager) modelFallbackChain(model string) []string {
+	if m == nil {
+		return nil
+	}
+	cfg, _ := m.runtimeConfig.Load().(*internalconfig.Config)
+	suffix := thinking.ParseSuffix(model)
+	chain := cfg.ModelFallbackChain(suffix.ModelName)
+	if len(chain) == 0 {
+		return nil
+	}
+	out := make([]string, 0, len(chain))
+	for _, fallback := range chain {
+		out = append(out, preserveResolvedModelSuffix(fallback, suffix))
+	}
+	return out
+}
+
+// shouldFallback reports whether err leaves the request to the next model of its fallback
+// chain: no credential was available, or the last one failed with a quota or server error.
+func shouldFallback(ctx context.Context, err error) bool {
+	if err == nil || ctx.Err() != nil || isRequestInvalidError(err) {
+		return false
+	}
+	if _, ok := errors.AsType[*modelCooldownError](err); ok {
+		return true
+	}
+	if authErr, ok := errors.AsType[*Error](err); ok && authErr != nil {
+		switch authErr.Code {
+		case "auth_not_found", "auth_unavailable":
+			return true
+		}
+	}
+	status := statusCodeFromError(err)
+	return status == http.StatusTooManyRequests || status >= http.StatusInternalServerError
+}
+
+// providersForModel resolves the providers serving model from the model registry.
+func providersForModel(model string) []string {
+	base := strings.TrimSpace(thinking.ParseSuffix(model).ModelName)
+	providers := util.GetProviderName(base)
+	if len(providers) == 0 && base != model {
+		providers = util.GetProviderName(model)
+	}
+	return providers
+}
+
+func logModelFallback(ctx context.Context, model, fallback string, err error) {
+	logEntryWithRequestID(ctx).Infof("model %s unavailable (%v), falling back to %s", model, err, fallback)
+}
+
+// publishServedModel records the model that served the request in the execution metadata.
+func publishServedModel(meta map[string]any, model string) {
+	if meta == nil || strings.TrimSpace(model) == "" {
+		return
+	}
+	meta[cliproxyexecutor.ServedModelMetadataKey] = model
+}
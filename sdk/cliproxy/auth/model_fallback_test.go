@@ -0,0 +1,115 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+func newModelFallbackTestManager(t *testing.T, primary, backup *openAICompatPoolExecutor) *Manager {
+	t.Helper()
+	m := NewManager(nil, nil, nil)
+	m.SetConfig(&internalconfig.Config{ModelFallbacks: []internalconfig.ModelFallback{
+		{Model: "fb-primary", Fallbacks: []string{"fb-missing", "fb-backup"}},
+	}})
+	reg := registry.GetGlobalRegistry()
+	for _, entry := range []struct {
+		executor *openAICompatPoolExecutor
+		model    string
+	}{{primary, "fb-primary"}, {backup, "fb-backup"}} {
+		m.RegisterExecutor(entry.executor)
+		auth := &Auth{ID: entry.executor.id + "-auth-" + t.Name(), Provider: entry.executor.id, Status: StatusActive}
+		if _, err := m.Register(context.Background(), auth); err != nil {
+			t.Fatalf("register auth: %v", err)
+		}
+		reg.RegisterClient(auth.ID, entry.executor.id, []*registry.ModelInfo{{ID: entry.model}})
+		authID := auth.ID
+		t.Cleanup(func() { reg.UnregisterClient(authID) })
+	}
+	return m
+}
+
+func TestManagerExecute_ModelFallbackOnQuotaError(t *testing.T) {
+	primary := &openAICompatPoolExecutor{
+		id:                "fb-primary-provider",
+		executeErrors:     map[string]error{"fb-primary": &Error{HTTPStatus: http.StatusTooManyRequests, Message: "quota exhausted"}},
+		streamFirstErrors: map[string]error{"fb-primary": &Error{HTTPStatus: http.StatusServiceUnavailable, Message: "overloaded"}},
+	}
+	backup := &openAICompatPoolExecutor{id: "fb-backup-provider"}
+	m := newModelFallbackTestManager(t, primary, backup)
+
+	var mu sync.Mutex
+	fallbackFrom := map[string]string{}
+	m.AddExecutionHook(hookFuncs{before: func(ctx context.Context, execCtx *ExecutionContext) error {
+		mu.Lock()
+		fallbackFrom[execCtx.Request.Model] = cliproxyexecutor.FallbackFrom(ctx)
+		mu.Unlock()
+		return nil
+	}})
+
+	meta := map[string]any{cliproxyexecutor.RequestedModelMetadataKey: "fb-primary"}
+	resp, err := m.Execute(context.Background(), []string{primary.id}, cliproxyexecutor.Request{Model: "fb-primary"}, cliproxyexecutor.Options{Metadata: meta})
+	if err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+	if string(resp.Payload) != "fb-backup" || meta[cliproxyexecutor.ServedModelMetadataKey] != "fb-backup" {
+		t.Fatalf("payload = %q, served = %v; want fb-backup", resp.Payload, meta[cliproxyexecutor.ServedModelMetadataKey])
+	}
+	if fallbackFrom["fb-backup"] != "fb-primary" || fallbackFrom["fb-primary"] != "" {
+		t.Fatalf("fallback context = %v", fallbackFrom)
+	}
+
+	streamMeta := map[string]any{cliproxyexecutor.RequestedModelMetadataKey: "fb-primary"}
+	result, err := m.ExecuteStream(context.Background(), []string{primary.id}, cliproxyexecutor.Request{Model: "fb-primary"}, cliproxyexecutor.Options{Stream: true, Metadata: streamMeta})
+	if err != nil {
+		t.Fatalf("execute stream: %v", err)
+	}
+	var payload string
+	for chunk := range result.Chunks {
+		if chunk.Err != nil {
+			t.Fatalf("stream error: %v", chunk.Err)
+		}
+		payload += string(chunk.Payload)
+	}
+	if payload != "fb-backup" || streamMeta[cliproxyexecutor.ServedModelMetadataKey] != "fb-backup" {
+		t.Fatalf("stream payload = %q, served = %v; want fb-backup", payload, streamMeta[cliproxyexecutor.ServedModelMetadataKey])
+	}
+}
+
+func TestManagerExecute_ModelFallbackSkipsInvalidRequests(t *testing.T) {
+	invalidErr := &Error{HTTPStatus: http.StatusBadRequest, Message: "bad request"}
+	primary := &openAICompatPoolExecutor{
+		id:            "fb-invalid-provider",
+		executeErrors: map[string]error{"fb-primary": invalidErr},
+	}
+	backup := &openAICompatPoolExecutor{id: "fb-unused-provider"}
+	m := newModelFallbackTestManager(t, primary, backup)
+
+	_, err := m.Execute(context.Background(), []string{primary.id}, cliproxyexecutor.Request{Model: "fb-primary"}, cliproxyexecutor.Options{})
+	if err == nil || err.Error() != invalidErr.Error() {
+		t.Fatalf("execute error = %v, want %v", err, invalidErr)
+	}
+	if got := backup.ExecuteModels(); len(got) != 0 {
+		t.Fatalf("fallback ran for an invalid request: %v", got)
+	}
+}
+
+func TestManagerModelFallbackChain_KeepsThinkingSuffix(t *testing.T) {
+	m := NewManager(nil, nil, nil)
+	cfg := &internalconfig.Config{ModelFallbacks: []internalconfig.ModelFallback{
+		{Model: "Claude-Opus", Fallbacks: []string{"gemini-pro", "gpt-5(low)", "claude-opus", "gemini-pro"}},
+	}}
+	cfg.SanitizeModelFallbacks()
+	m.SetConfig(cfg)
+
+	got := m.modelFallbackChain("claude-opus(high)")
+	want := []string{"gemini-pro(high)", "gpt-5(low)"}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Fatalf("chain = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,228 @@
+package auth
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+)
+
+// ExecutionAttempt describes a single upstream call performed by Manager.
+type ExecutionAttempt struct {
+	// Provider is the provider key that served the attempt.
+	Provider string
+	// Model is the client-facing route model.
+	Model string
+	// AuthID identifies the credential used for the attempt.
+	AuthID string
+	// AuthIndex is the stable runtime index of the credential.
+	AuthIndex string
+	// Stream reports whether the attempt was a streaming execution.
+	Stream bool
+	// Success reports whether the attempt completed without error.
+	Success bool
+	// StatusCode carries the upstream HTTP status when known (0 otherwise).
+	StatusCode int
+	// Latency is the wall time from dispatch until the attempt completed.
+	Latency time.Duration
+	// FirstByte is the time until the first payload chunk for streaming attempts.
+	FirstByte time.Duration
+}
+
+// ExecutionObserver receives execution telemetry from Manager.
+// Implementations must be safe for concurrent use and should return quickly.
+type ExecutionObserver interface {
+	// ObserveAttempt fires after each upstream attempt completes.
+	ObserveAttempt(ctx context.Context, attempt ExecutionAttempt)
+	// ObserveRetry fires when a request issues another upstream attempt after a failure.
+	ObserveRetry(ctx context.Context, provider, model string)
+	// ObserveCredentialSwitch fires when a request moves on to a different credential.
+	ObserveCredentialSwitch(ctx context.Context, provider, model string)
+}
+
+// SchedulerStateCount summarizes scheduler entries for one provider/model shard.
+type SchedulerStateCount struct {
+	Provider string
+	Model    string
+	Ready    int
+	Cooldown int
+	Blocked  int
+	Disabled int
+}
+
+// SetExecutionObserver installs an observer for execution telemetry. Passing nil disables it.
+func (m *Manager) SetExecutionObserver(observer ExecutionObserver) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	m.observer = observer
+	m.mu.Unlock()
+}
+
+// executionObserver returns the installed observer, if any.
+func (m *Manager) executionObserver() ExecutionObserver {
+	if m == nil {
+		return nil
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.observer
+}
+
+// observeAttempt records one upstream attempt in the credential health scores and reports it
+// to the installed observer. Streams are scored by their time to first byte.
+func (m *Manager) observeAttempt(ctx context.Context, auth *Auth, provider, model string, stream bool, started time.Time, firstByte time.Duration, err error) {
+	if auth != nil {
+		latency := time.Since(started)
+		if stream && firstByte > 0 {
+			latency = firstByte
+		}
+		m.health.record(auth.ID, provider, model, latency, err, time.Now())
+	}
+	observer := m.executionObserver()
+	if observer == nil || auth == nil {
+		return
+	}
+	attempt := ExecutionAttempt{
+		Provider:  provider,
+		Model:     model,
+		AuthID:    auth.ID,
+		AuthIndex: auth.Index,
+		Stream:    stream,
+		Success:   err == nil,
+		Latency:   time.Since(started),
+		FirstByte: firstByte,
+	}
+	if err != nil {
+		attempt.StatusCode = statusCodeFromError(err)
+	}
+	observer.ObserveAttempt(ctx, attempt)
+}
+
+// observeRetry reports an additional upstream attempt and, when the credential changed, a switch.
+func (m *Manager) observeRetry(ctx context.Context, provider, model string, credentialSwitch bool) {
+	observer := m.executionObserver()
+	if observer == nil {
+		return
+	}
+	observer.ObserveRetry(ctx, provider, model)
+	if credentialSwitch {
+		observer.ObserveCredentialSwitch(ctx, provider, model)
+	}
+}
+
+// SchedulerStateCounts returns per provider/model entry counts from the scheduler queues.
+// Only model shards that have been materialized by a request are reported.
+func (m *Manager) SchedulerStateCounts() []SchedulerStateCount {
+	if m == nil || m.scheduler == nil {
+		return nil
+	}
+	return m.scheduler.stateCounts()
+}
+
+// DisabledAuthCounts returns the number of credential-level disabled auths per provider.
+// Disabled auths are never scheduled, so they are counted from the manager snapshot.
+func (m *Manager) DisabledAuthCounts() map[string]int {
+	if m == nil {
+		return nil
+	}
+	out := make(map[string]int)
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, auth := range m.auths {
+		if auth == nil || !auth.Disabled {
+			continue
+		}
+		out[strings.ToLower(strings.TrimSpace(auth.Provider))]++
+	}
+	return out
+}
+
+// ModelCoolingDown reports whether every enabled credential serving model is cooling down
+// after exhausting its quota, and when the first of them recovers. It reports false when
+// no credential serves model.
+func (m *Manager) ModelCoolingDown(model string) (time.Time, bool) {
+	if m == nil {
+		return time.Time{}, false
+	}
+	modelKey := strings.TrimSpace(thinking.ParseSuffix(strings.TrimSpace(model)).ModelName)
+	if modelKey == "" {
+		return time.Time{}, false
+	}
+	registryRef := registry.GetGlobalRegistry()
+	now := time.Now()
+	var recoverAt time.Time
+	serving := 0
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, auth := range m.auths {
+		if auth == nil || auth.Disabled {
+			continue
+		}
+		if registryRef != nil && !registryRef.ClientSupportsModel(auth.ID, modelKey) {
+			continue
+		}
+		blocked, reason, next := isAuthBlockedForModel(auth, model, now)
+		if blocked && reason == blockReasonDisabled {
+			continue
+		}
+		if !blocked || reason != blockReasonCooldown {
+			return time.Time{}, false
+		}
+		serving++
+		if recoverAt.IsZero() || next.Before(recoverAt) {
+			recoverAt = next
+		}
+	}
+	return recoverAt, serving > 0
+}
+
+// stateCounts snapshots the ready and blocked queues for every materialized model shard.
+func (s *authScheduler) stateCounts() []SchedulerStateCount {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	out := make([]SchedulerStateCount, 0)
+	for providerKey, providerState := range s.providers {
+		if providerState == nil {
+			continue
+		}
+		for modelKey, shard := range providerState.modelShards {
+			if shard == nil {
+				continue
+			}
+			shard.promoteExpiredLocked(now)
+			count := SchedulerStateCount{Provider: providerKey, Model: modelKey}
+			for _, entry := range shard.entries {
+				if entry == nil {
+					continue
+				}
+				switch entry.state {
+				case scheduledStateReady:
+					count.Ready++
+				case scheduledStateCooldown:
+					count.Cooldown++
+				case scheduledStateBlocked:
+					count.Blocked++
+				case scheduledStateDisabled:
+					count.Disabled++
+				}
+			}
+			out = append(out, count)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Provider != out[j].Provider {
+			return out[i].Provider < out[j].Provider
+		}
+		return out[i].Model < out[j].Model
+	})
+	return out
+}
@@ -0,0 +1,164 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+type recordingObserver struct {
+	mu       sync.Mutex
+	attempts []ExecutionAttempt
+	retries  int
+	switches int
+}
+
+func (o *recordingObserver) ObserveAttempt(_ context.Context, attempt ExecutionAttempt) {
+	o.mu.Lock()
+	o.attempts = append(o.attempts, attempt)
+	o.mu.Unlock()
+}
+
+func (o *recordingObserver) ObserveRetry(context.Context, string, string) {
+	o.mu.Lock()
+	o.retries++
+	o.mu.Unlock()
+}
+
+func (o *recordingObserver) ObserveCredentialSwitch(context.Context, string, string) {
+	o.mu.Lock()
+	o.switches++
+	o.mu.Unlock()
+}
+
+func TestManagerExecute_ObserverReportsAttemptsAndRetries(t *testing.T) {
+	alias := "claude-opus-4.66"
+	executor := &openAICompatPoolExecutor{
+		id:            "pool",
+		executeErrors: map[string]error{"qwen3.5-plus": &Error{HTTPStatus: http.StatusTooManyRequests, Message: "quota"}},
+	}
+	m := newOpenAICompatPoolTestManager(t, alias, []internalconfig.OpenAICompatibilityModel{
+		{Name: "qwen3.5-plus", Alias: alias},
+		{Name: "glm-5", Alias: alias},
+	}, executor)
+	observer := &recordingObserver{}
+	m.SetExecutionObserver(observer)
+
+	if _, err := m.Execute(context.Background(), []string{"pool"}, cliproxyexecutor.Request{Model: alias}, cliproxyexecutor.Options{}); err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+
+	observer.mu.Lock()
+	defer observer.mu.Unlock()
+	if len(observer.attempts) != 2 {
+		t.Fatalf("attempts = %d, want 2", len(observer.attempts))
+	}
+	if observer.attempts[0].Success || observer.attempts[0].StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("first attempt = %+v, want failed 429", observer.attempts[0])
+	}
+	if !observer.attempts[1].Success || observer.attempts[1].Provider != "pool" || observer.attempts[1].Model != alias {
+		t.Fatalf("second attempt = %+v, want successful pool/%s", observer.attempts[1], alias)
+	}
+	if observer.retries != 1 {
+		t.Fatalf("retries = %d, want 1", observer.retries)
+	}
+	if observer.switches != 0 {
+		t.Fatalf("credential switches = %d, want 0", observer.switches)
+	}
+}
+
+func TestManagerExecuteStream_ObserverReportsFirstByte(t *testing.T) {
+	alias := "claude-opus-4.66"
+	executor := &openAICompatPoolExecutor{id: "pool"}
+	m := newOpenAICompatPoolTestManager(t, alias, []internalconfig.OpenAICompatibilityModel{
+		{Name: "qwen3.5-plus", Alias: alias},
+	}, executor)
+	observer := &recordingObserver{}
+	m.SetExecutionObserver(observer)
+
+	result, err := m.ExecuteStream(context.Background(), []string{"pool"}, cliproxyexecutor.Request{Model: alias}, cliproxyexecutor.Options{})
+	if err != nil {
+		t.Fatalf("execute stream: %v", err)
+	}
+	for range result.Chunks {
+	}
+
+	observer.mu.Lock()
+	defer observer.mu.Unlock()
+	if len(observer.attempts) != 1 {
+		t.Fatalf("attempts = %d, want 1", len(observer.attempts))
+	}
+	attempt := observer.attempts[0]
+	if !attempt.Stream || !attempt.Success || attempt.FirstByte <= 0 {
+		t.Fatalf("attempt = %+v, want successful stream with first byte timing", attempt)
+	}
+}
+
+func TestManagerSchedulerStateCounts_ReportsShardStates(t *testing.T) {
+	alias := "claude-opus-4.66"
+	m := newOpenAICompatPoolTestManager(t, alias, []internalconfig.OpenAICompatibilityModel{
+		{Name: "qwen3.5-plus", Alias: alias},
+	}, nil)
+	if _, err := m.Execute(context.Background(), []string{"pool"}, cliproxyexecutor.Request{Model: alias}, cliproxyexecutor.Options{}); err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+
+	counts := m.SchedulerStateCounts()
+	if len(counts) != 1 {
+		t.Fatalf("counts = %+v, want one shard", counts)
+	}
+	if counts[0].Provider != "pool" || counts[0].Ready != 1 || counts[0].Cooldown != 0 {
+		t.Fatalf("counts[0] = %+v, want one ready pool auth", counts[0])
+	}
+}
+
+func TestManagerModelCoolingDown(t *testing.T) {
+	m := NewManager(nil, nil, nil)
+	model := "cooldown-notify-model"
+	now := time.Now()
+	auth1 := &Auth{ID: "cooldown-notify-1", Provider: "claude"}
+	auth2 := &Auth{ID: "cooldown-notify-2", Provider: "claude"}
+	reg := registry.GetGlobalRegistry()
+	reg.RegisterClient(auth1.ID, "claude", []*registry.ModelInfo{{ID: model}})
+	reg.RegisterClient(auth2.ID, "claude", []*registry.ModelInfo{{ID: model}})
+	t.Cleanup(func() {
+		reg.UnregisterClient(auth1.ID)
+		reg.UnregisterClient(auth2.ID)
+	})
+	for _, auth := range []*Auth{auth1, auth2} {
+		if _, err := m.Register(context.Background(), auth); err != nil {
+			t.Fatalf("register %s: %v", auth.ID, err)
+		}
+	}
+
+	coolDown := func(id string, until time.Time) {
+		m.mu.Lock()
+		m.auths[id].ModelStates = map[string]*ModelState{model: {
+			Unavailable:    true,
+			NextRetryAfter: until,
+			Quota:          QuotaState{Exceeded: true, NextRecoverAt: until},
+		}}
+		m.mu.Unlock()
+	}
+	coolDown(auth1.ID, now.Add(time.Minute))
+	if _, ok := m.ModelCoolingDown(model); ok {
+		t.Fatal("ModelCoolingDown() = true with one credential available")
+	}
+	coolDown(auth2.ID, now.Add(30*time.Second))
+	recoverAt, ok := m.ModelCoolingDown(model)
+	if !ok {
+		t.Fatal("ModelCoolingDown() = false with every credential cooling down")
+	}
+	if !recoverAt.Equal(now.Add(30 * time.Second)) {
+		t.Fatalf("recoverAt = %v, want %v", recoverAt, now.Add(30*time.Second))
+	}
+	if _, ok := m.ModelCoolingDown("unknown-notify-model"); ok {
+		t.Fatal("ModelCoolingDown() = true for a model without credentials")
+	}
+}
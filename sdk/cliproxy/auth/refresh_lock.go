@@ -0,0 +1,77 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// refreshLockWait bounds how long a refresh waits for another process holding the lock of the
+// same auth; the refresh is retried on a later tick when it expires.
+const refreshLockWait = 2 * time.Minute
+
+// lockAuthForRefresh takes the store lock of auth when the store is a Locker. It reports false
+// when the lock could not be taken in time. Other lock errors do not block the refresh.
+func (m *Manager) lockAuthForRefresh(ctx context.Context, auth *Auth) (func(), bool) {
+	m.mu.RLock()
+	locker, ok := m.store.(Locker)
+	m.mu.RUnlock()
+	if !ok || locker == nil {
+		return func() {}, true
+	}
+	lockCtx, cancel := context.WithTimeout(ctx, refreshLockWait)
+	defer cancel()
+	unlock, err := locker.LockAuth(lockCtx, auth.ID)
+	if err != nil {
+		if lockCtx.Err() != nil {
+			log.Debugf("refresh of %s, %s skipped: lock is held by another process", auth.Provider, auth.ID)
+			return nil, false
+		}
+		log.Warnf("refresh lock for %s, %s failed, refreshing without it: %v", auth.Provider, auth.ID, err)
+		return func() {}, true
+	}
+	if unlock == nil {
+		unlock = func() {}
+	}
+	return unlock, true
+}
+
+// reloadStoredAuth returns auth with the metadata currently held by the store, or nil when
+// the stored metadata is unchanged or cannot be read. Another process may have refreshed the
+// auth while this one waited for the lock.
+func (m *Manager) reloadStoredAuth(ctx context.Context, auth *Auth) *Auth {
+	m.mu.RLock()
+	locker, ok := m.store.(Locker)
+	m.mu.RUnlock()
+	if !ok || locker == nil {
+		return nil
+	}
+	stored, err := locker.LoadAuth(ctx, auth)
+	if err != nil {
+		log.Warnf("refresh of %s, %s: reload from store failed: %v", auth.Provider, auth.ID, err)
+		return nil
+	}
+	if stored == nil || stored.Metadata == nil || metadataEqual(stored.Metadata, auth.Metadata) {
+		return nil
+	}
+	fresh := auth.Clone()
+	fresh.Metadata = stored.Metadata
+	// The token storage still holds the tokens read before; saving it would overwrite
+	// the stored tokens with stale ones.
+	fresh.Storage = nil
+	// Drop the pending marker so shouldRefresh judges the stored tokens.
+	fresh.NextRefreshAfter = time.Time{}
+	if ts, ok := authLastRefreshTimestamp(fresh); ok {
+		fresh.LastRefreshedAt = ts
+	}
+	return fresh
+}
+
+func metadataEqual(a, b map[string]any) bool {
+	rawA, errA := json.Marshal(a)
+	rawB, errB := json.Marshal(b)
+	return errA == nil && errB == nil && bytes.Equal(rawA, rawB)
+}
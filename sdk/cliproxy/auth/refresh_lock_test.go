@@ -0,0 +1,144 @@
+package auth
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+type lockingTestStore struct {
+	mu      sync.Mutex
+	auths   map[string]*Auth
+	locks   int
+	unlocks int
+	lists   int
+}
+
+func (s *lockingTestStore) List(context.Context) ([]*Auth, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lists++
+	out := make([]*Auth, 0, len(s.auths))
+	for _, auth := range s.auths {
+		out = append(out, auth.Clone())
+	}
+	return out, nil
+}
+
+func (s *lockingTestStore) Save(_ context.Context, auth *Auth) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.auths[auth.ID] = auth.Clone()
+	return auth.ID, nil
+}
+
+func (s *lockingTestStore) Delete(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.auths, id)
+	return nil
+}
+
+func (s *lockingTestStore) LoadAuth(_ context.Context, auth *Auth) (*Auth, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if stored := s.auths[auth.ID]; stored != nil {
+		return stored.Clone(), nil
+	}
+	return nil, nil
+}
+
+func (s *lockingTestStore) LockAuth(context.Context, string) (func(), error) {
+	s.mu.Lock()
+	s.locks++
+	s.mu.Unlock()
+	return func() {
+		s.mu.Lock()
+		s.unlocks++
+		s.mu.Unlock()
+	}, nil
+}
+
+type countingRefreshExecutor struct {
+	schedulerProviderTestExecutor
+	mu        sync.Mutex
+	refreshed []string
+}
+
+func (e *countingRefreshExecutor) Refresh(_ context.Context, auth *Auth) (*Auth, error) {
+	e.mu.Lock()
+	e.refreshed = append(e.refreshed, auth.Metadata["refresh_token"].(string))
+	e.mu.Unlock()
+	updated := auth.Clone()
+	updated.Metadata["refresh_token"] = "rotated"
+	return updated, nil
+}
+
+func newRefreshLockTestManager(t *testing.T, stored map[string]any) (*Manager, *lockingTestStore, *countingRefreshExecutor) {
+	t.Helper()
+	store := &lockingTestStore{auths: make(map[string]*Auth)}
+	executor := &countingRefreshExecutor{schedulerProviderTestExecutor: schedulerProviderTestExecutor{provider: "lock-provider"}}
+	m := NewManager(store, nil, nil)
+	m.RegisterExecutor(executor)
+	auth := &Auth{ID: "lock-auth", Provider: "lock-provider", Metadata: map[string]any{"refresh_token": "original"}}
+	if _, err := m.Register(context.Background(), auth); err != nil {
+		t.Fatalf("register auth: %v", err)
+	}
+	if stored != nil {
+		store.auths[auth.ID] = &Auth{ID: auth.ID, Provider: auth.Provider, Metadata: stored}
+	}
+	return m, store, executor
+}
+
+func TestRefreshAuth_HoldsStoreLock(t *testing.T) {
+	m, store, executor := newRefreshLockTestManager(t, nil)
+
+	m.refreshAuth(context.Background(), "lock-auth")
+
+	if store.locks != 1 || store.unlocks != 1 {
+		t.Fatalf("locks = %d, unlocks = %d; want 1, 1", store.locks, store.unlocks)
+	}
+	if len(executor.refreshed) != 1 || executor.refreshed[0] != "original" {
+		t.Fatalf("refreshed = %v, want [original]", executor.refreshed)
+	}
+	if got := store.auths["lock-auth"].Metadata["refresh_token"]; got != "rotated" {
+		t.Fatalf("stored refresh token = %v, want rotated", got)
+	}
+}
+
+type alwaysRefreshEvaluator struct{}
+
+func (alwaysRefreshEvaluator) ShouldRefresh(time.Time, *Auth) bool { return true }
+
+func TestRefreshAuth_AdoptsTokenRefreshedByAnotherProcess(t *testing.T) {
+	// Another process rotated the token while this one waited for the lock; it no longer
+	// needs a refresh.
+	m, store, executor := newRefreshLockTestManager(t, map[string]any{"refresh_token": "from-other-process"})
+
+	m.refreshAuth(context.Background(), "lock-auth")
+
+	if len(executor.refreshed) != 0 {
+		t.Fatalf("refreshed = %v, want no refresh", executor.refreshed)
+	}
+	if store.lists != 0 {
+		t.Fatalf("store listed %d times, want the auth loaded by itself", store.lists)
+	}
+	auth, _ := m.GetByID("lock-auth")
+	if got := auth.Metadata["refresh_token"]; got != "from-other-process" {
+		t.Fatalf("refresh token = %v, want the token read from the store", got)
+	}
+}
+
+func TestRefreshAuth_RefreshesTokenReadAfterLocking(t *testing.T) {
+	m, _, executor := newRefreshLockTestManager(t, map[string]any{"refresh_token": "from-other-process"})
+	m.mu.Lock()
+	m.auths["lock-auth"].Runtime = alwaysRefreshEvaluator{}
+	m.mu.Unlock()
+
+	m.refreshAuth(context.Background(), "lock-auth")
+
+	if len(executor.refreshed) != 1 || executor.refreshed[0] != "from-other-process" {
+		t.Fatalf("refreshed = %v, want the token read from the store", executor.refreshed)
+	}
+}
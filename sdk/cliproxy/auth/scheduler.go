@@ -18,6 +18,7 @@ const (
 	schedulerStrategyCustom schedulerStrategy = iota
 	schedulerStrategyRoundRobin
 	schedulerStrategyFillFirst
+	schedulerStrategyWeightedHealth
 )
 
 // scheduledState describes how an auth currently participates in a model shard.
@@ -37,6 +38,10 @@ type authScheduler struct {
 	providers     map[string]*providerScheduler
 	authProviders map[string]string
 	mixedCursors  map[string]int
+	// sharedCursor, when set, supplies round-robin cursors shared with other instances.
+	sharedCursor func(context.Context, string) (int, bool)
+	// health scores candidates for the weighted-health strategy.
+	health *healthTracker
 }
 
 // providerScheduler stores auth metadata and model shards for a single provider.
@@ -112,6 +117,8 @@ func selectorStrategy(selector Selector) schedulerStrategy {
 	switch selector.(type) {
 	case *FillFirstSelector:
 		return schedulerStrategyFillFirst
+	case *WeightedHealthSelector:
+		return schedulerStrategyWeightedHealth
 	case nil, *RoundRobinSelector:
 		return schedulerStrategyRoundRobin
 	default:
@@ -130,6 +137,29 @@ func (s *authScheduler) setSelector(selector Selector) {
 	clear(s.mixedCursors)
 }
 
+// setSharedCursor installs the source of round-robin cursors shared with other instances.
+func (s *authScheduler) setSharedCursor(cursor func(context.Context, string) (int, bool)) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sharedCursor = cursor
+}
+
+// nextSharedCursor returns the next shared round-robin cursor for key, when one is installed
+// and the round-robin strategy is active. It is called without holding the scheduler lock.
+func (s *authScheduler) nextSharedCursor(ctx context.Context, key string) (int, bool) {
+	s.mu.Lock()
+	cursor := s.sharedCursor
+	strategy := s.strategy
+	s.mu.Unlock()
+	if cursor == nil || strategy != schedulerStrategyRoundRobin {
+		return 0, false
+	}
+	return cursor(ctx, key)
+}
+
 // rebuild recreates the complete scheduler state from an auth snapshot.
 func (s *authScheduler) rebuild(auths []*Auth) {
 	if s == nil {
@@ -179,6 +209,11 @@ func (s *authScheduler) pickSingle(ctx context.Context, provider, model string,
 	modelKey := canonicalModelKey(model)
 	pinnedAuthID := pinnedAuthIDFromMetadata(opts.Metadata)
 	preferWebsocket := cliproxyexecutor.DownstreamWebsocket(ctx) && providerKey == "codex" && pinnedAuthID == ""
+	var sharedCursor int
+	sharedCursorOK := false
+	if pinnedAuthID == "" {
+		sharedCursor, sharedCursorOK = s.nextSharedCursor(ctx, providerKey+":"+modelKey)
+	}
 
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -190,6 +225,9 @@ func (s *authScheduler) pickSingle(ctx context.Context, provider, model string,
 	if shard == nil {
 		return nil, &Error{Code: "auth_not_found", Message: "no auth available"}
 	}
+	if sharedCursorOK {
+		shard.seedCursorsLocked(sharedCursor)
+	}
 	predicate := func(entry *scheduledAuth) bool {
 		if entry == nil || entry.auth == nil {
 			return false
@@ -204,7 +242,7 @@ func (s *authScheduler) pickSingle(ctx context.Context, provider, model string,
 		}
 		return true
 	}
-	if picked := shard.pickReadyLocked(preferWebsocket, s.strategy, predicate); picked != nil {
+	if picked := shard.pickReadyLocked(preferWebsocket, s.strategy, s.health, predicate); picked != nil {
 		return picked, nil
 	}
 	return nil, shard.unavailableErrorLocked(provider, model, predicate)
@@ -221,6 +259,12 @@ func (s *authScheduler) pickMixed(ctx context.Context, providers []string, model
 	}
 	pinnedAuthID := pinnedAuthIDFromMetadata(opts.Metadata)
 	modelKey := canonicalModelKey(model)
+	cursorKey := strings.Join(normalized, ",") + ":" + modelKey
+	var sharedCursor int
+	sharedCursorOK := false
+	if pinnedAuthID == "" {
+		sharedCursor, sharedCursorOK = s.nextSharedCursor(ctx, cursorKey)
+	}
 
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -244,7 +288,7 @@ func (s *authScheduler) pickMixed(ctx context.Context, providers []string, model
 			_, ok := tried[pinnedAuthID]
 			return !ok
 		}
-		if picked := shard.pickReadyLocked(false, s.strategy, predicate); picked != nil {
+		if picked := shard.pickReadyLocked(false, s.strategy, s.health, predicate); picked != nil {
 			return picked, providerKey, nil
 		}
 		return nil, "", shard.unavailableErrorLocked("mixed", model, predicate)
@@ -284,7 +328,7 @@ func (s *authScheduler) pickMixed(ctx context.Context, providers []string, model
 			if shard == nil {
 				continue
 			}
-			picked := shard.pickReadyAtPriorityLocked(false, bestPriority, s.strategy, predicate)
+			picked := shard.pickReadyAtPriorityLocked(false, bestPriority, s.strategy, s.health, predicate)
 			if picked != nil {
 				return picked, providerKey, nil
 			}
@@ -292,7 +336,34 @@ func (s *authScheduler) pickMixed(ctx context.Context, providers []string, model
 		return nil, "", s.mixedUnavailableErrorLocked(normalized, model, tried)
 	}
 
-	cursorKey := strings.Join(normalized, ",") + ":" + modelKey
+	if s.strategy == schedulerStrategyWeightedHealth {
+		var candidates []*scheduledAuth
+		var candidateProviders []string
+		for providerIndex, providerKey := range normalized {
+			shard := candidateShards[providerIndex]
+			if shard == nil {
+				continue
+			}
+			bucket := shard.readyByPriority[bestPriority]
+			if bucket == nil {
+				continue
+			}
+			for _, entry := range bucket.all.flat {
+				if predicate(entry) {
+					candidates = append(candidates, entry)
+					candidateProviders = append(candidateProviders, providerKey)
+				}
+			}
+		}
+		if index, ok := pickHealthiest(s.health, modelKey, candidates); ok {
+			return candidates[index].auth, candidateProviders[index], nil
+		}
+		return nil, "", s.mixedUnavailableErrorLocked(normalized, model, tried)
+	}
+
+	if sharedCursorOK {
+		s.mixedCursors[cursorKey] = sharedCursor
+	}
 	start := 0
 	if len(normalized) > 0 {
 		start = s.mixedCursors[cursorKey] % len(normalized)
@@ -304,7 +375,7 @@ func (s *authScheduler) pickMixed(ctx context.Context, providers []string, model
 		if shard == nil {
 			continue
 		}
-		picked := shard.pickReadyAtPriorityLocked(false, bestPriority, schedulerStrategyRoundRobin, predicate)
+		picked := shard.pickReadyAtPriorityLocked(false, bestPriority, schedulerStrategyRoundRobin, nil, predicate)
 		if picked == nil {
 			continue
 		}
@@ -643,8 +714,22 @@ func (m *modelScheduler) promoteExpiredLocked(now time.Time) {
 	}
 }
 
+// seedCursorsLocked positions every round-robin view of the shard at cursor.
+func (m *modelScheduler) seedCursorsLocked(cursor int) {
+	if m == nil {
+		return
+	}
+	for _, bucket := range m.readyByPriority {
+		if bucket == nil {
+			continue
+		}
+		bucket.all.cursor, bucket.all.parentCursor = cursor, cursor
+		bucket.ws.cursor, bucket.ws.parentCursor = cursor, cursor
+	}
+}
+
 // pickReadyLocked selects the next ready auth from the highest available priority bucket.
-func (m *modelScheduler) pickReadyLocked(preferWebsocket bool, strategy schedulerStrategy, predicate func(*scheduledAuth) bool) *Auth {
+func (m *modelScheduler) pickReadyLocked(preferWebsocket bool, strategy schedulerStrategy, health *healthTracker, predicate func(*scheduledAuth) bool) *Auth {
 	if m == nil {
 		return nil
 	}
@@ -653,7 +738,7 @@ func (m *modelScheduler) pickReadyLocked(preferWebsocket bool, strategy schedule
 	if !okPriority {
 		return nil
 	}
-	return m.pickReadyAtPriorityLocked(preferWebsocket, priorityReady, strategy, predicate)
+	return m.pickReadyAtPriorityLocked(preferWebsocket, priorityReady, strategy, health, predicate)
 }
 
 // highestReadyPriorityLocked returns the highest priority bucket that still has a matching ready auth.
@@ -679,8 +764,9 @@ func (m *modelScheduler) highestReadyPriorityLocked(preferWebsocket bool, predic
 }
 
 // pickReadyAtPriorityLocked selects the next ready auth from a specific priority bucket.
-// The caller must ensure expired entries are already promoted when needed.
-func (m *modelScheduler) pickReadyAtPriorityLocked(preferWebsocket bool, priority int, strategy schedulerStrategy, predicate func(*scheduledAuth) bool) *Auth {
+// The caller must ensure expired entries are already promoted when needed. health is only
+// consulted by the weighted-health strategy.
+func (m *modelScheduler) pickReadyAtPriorityLocked(preferWebsocket bool, priority int, strategy schedulerStrategy, health *healthTracker, predicate func(*scheduledAuth) bool) *Auth {
 	if m == nil {
 		return nil
 	}
@@ -693,9 +779,12 @@ func (m *modelScheduler) pickReadyAtPriorityLocked(preferWebsocket bool, priorit
 		view = &bucket.ws
 	}
 	var picked *scheduledAuth
-	if strategy == schedulerStrategyFillFirst {
+	switch strategy {
+	case schedulerStrategyFillFirst:
 		picked = view.pickFirst(predicate)
-	} else {
+	case schedulerStrategyWeightedHealth:
+		picked = view.pickWeighted(m.modelKey, health, predicate)
+	default:
 		picked = view.pickRoundRobin(predicate)
 	}
 	if picked == nil || picked.auth == nil {
@@ -851,6 +940,32 @@ func (v *readyView) pickFirst(predicate func(*scheduledAuth) bool) *scheduledAut
 	return nil
 }
 
+// pickWeighted draws a matching ready entry weighted by its health score for model.
+func (v *readyView) pickWeighted(model string, health *healthTracker, predicate func(*scheduledAuth) bool) *scheduledAuth {
+	candidates := make([]*scheduledAuth, 0, len(v.flat))
+	for _, entry := range v.flat {
+		if predicate == nil || predicate(entry) {
+			candidates = append(candidates, entry)
+		}
+	}
+	if index, ok := pickHealthiest(health, model, candidates); ok {
+		return candidates[index]
+	}
+	return nil
+}
+
+// pickHealthiest returns the index of the candidate health picks for model.
+func pickHealthiest(health *healthTracker, model string, candidates []*scheduledAuth) (int, bool) {
+	if len(candidates) == 0 {
+		return 0, false
+	}
+	ids := make([]string, len(candidates))
+	for i, entry := range candidates {
+		ids[i] = entry.auth.ID
+	}
+	return health.pick(model, ids, time.Now()), true
+}
+
 // pickRoundRobin returns the next ready entry using flat or grouped round-robin traversal.
 func (v *readyView) pickRoundRobin(predicate func(*scheduledAuth) bool) *scheduledAuth {
 	if len(v.parentOrder) > 1 && len(v.children) > 0 {
@@ -0,0 +1,222 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	"github.com/tidwall/gjson"
+)
+
+// sessionAffinityPruneInterval is how often expired bindings are swept.
+const sessionAffinityPruneInterval = time.Minute
+
+// sessionAffinity maps conversation keys to the credential serving them.
+type sessionAffinity struct {
+	mu       sync.Mutex
+	bindings map[string]sessionBinding
+	pruned   time.Time
+}
+
+type sessionBinding struct {
+	authID  string
+	expires time.Time
+}
+
+func newSessionAffinity() *sessionAffinity {
+	return &sessionAffinity{bindings: make(map[string]sessionBinding)}
+}
+
+func (a *sessionAffinity) lookup(key string, now time.Time) (string, bool) {
+	if a == nil {
+		return "", false
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	binding, ok := a.bindings[key]
+	if !ok || now.After(binding.expires) {
+		return "", false
+	}
+	return binding.authID, true
+}
+
+func (a *sessionAffinity) bind(key, authID string, now time.Time, ttl time.Duration) {
+	if a == nil || key == "" || authID == "" {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.bindings[key] = sessionBinding{authID: authID, expires: now.Add(ttl)}
+	if now.Sub(a.pruned) < sessionAffinityPruneInterval {
+		return
+	}
+	a.pruned = now
+	for k, binding := range a.bindings {
+		if now.After(binding.expires) {
+			delete(a.bindings, k)
+		}
+	}
+}
+
+// sessionAffinityConfig returns the session affinity settings when they are enabled.
+func (m *Manager) sessionAffinityConfig() (internalconfig.SessionAffinityConfig, bool) {
+	if m == nil || m.sessionAffinity == nil {
+		return internalconfig.SessionAffinityConfig{}, false
+	}
+	cfg, _ := m.runtimeConfig.Load().(*internalconfig.Config)
+	if cfg == nil || !cfg.Routing.SessionAffinity.Enable {
+		return internalconfig.SessionAffinityConfig{}, false
+	}
+	affinity := cfg.Routing.SessionAffinity
+	if strings.TrimSpace(affinity.Header) == "" {
+		affinity.Header = internalconfig.DefaultSessionAffinityHeader
+	}
+	if affinity.TTLSeconds <= 0 {
+		affinity.TTLSeconds = internalconfig.DefaultSessionAffinityTTLSeconds
+	}
+	return affinity, true
+}
+
+// SessionAffinityKey identifies the conversation a request belongs to, preferring the
+// configured session header, then the Claude metadata.user_id, the Responses prompt_cache_key
+// and previous_response_id, and finally a hash of the leading messages. It returns "" when
+// session affinity is disabled or nothing identifies the conversation.
+func (m *Manager) SessionAffinityKey(headers http.Header, payload []byte) string {
+	cfg, ok := m.sessionAffinityConfig()
+	if !ok {
+		return ""
+	}
+	if value := strings.TrimSpace(headers.Get(cfg.Header)); value != "" {
+		return "header:" + value
+	}
+	return sessionKeyFromPayload(payload)
+}
+
+// ResponseSessionKey returns the session key of requests continuing the Responses response id.
+func ResponseSessionKey(responseID string) string {
+	return "response:" + responseID
+}
+
+// BindSessionAffinity binds key to authID for model. Handlers use it to bind a Responses id
+// to the credential that produced it, so requests continuing it stay on that credential.
+func (m *Manager) BindSessionAffinity(key, model, authID string) {
+	cfg, ok := m.sessionAffinityConfig()
+	if !ok || strings.TrimSpace(key) == "" {
+		return
+	}
+	m.sessionAffinity.bind(sessionAffinityScope(key, model), strings.TrimSpace(authID), time.Now(), time.Duration(cfg.TTLSeconds)*time.Second)
+}
+
+func sessionKeyFromPayload(payload []byte) string {
+	if len(payload) == 0 || !gjson.ValidBytes(payload) {
+		return ""
+	}
+	root := gjson.ParseBytes(payload)
+	if userID := strings.TrimSpace(root.Get("metadata.user_id").String()); userID != "" {
+		return "user:" + userID
+	}
+	if cacheKey := strings.TrimSpace(root.Get("prompt_cache_key").String()); cacheKey != "" {
+		return "cache:" + cacheKey
+	}
+	if previous := strings.TrimSpace(root.Get("previous_response_id").String()); previous != "" {
+		return ResponseSessionKey(previous)
+	}
+	return leadingMessagesKey(root)
+}
+
+// leadingMessagesKey hashes the system prompt and first message of the Claude, OpenAI,
+// Responses and Gemini request shapes; both stay the same for every turn of a conversation.
+func leadingMessagesKey(root gjson.Result) string {
+	var first gjson.Result
+	for _, path := range []string{"messages.0", "input.0", "contents.0"} {
+		if first = root.Get(path); first.Exists() {
+			break
+		}
+	}
+	if !first.Exists() {
+		if input := root.Get("input"); input.Type == gjson.String {
+			first = input
+		}
+	}
+	if !first.Exists() {
+		return ""
+	}
+	hash := sha256.New()
+	for _, path := range []string{"system", "instructions", "systemInstruction", "system_instruction"} {
+		if system := root.Get(path); system.Exists() {
+			hash.Write([]byte(system.Raw))
+			break
+		}
+	}
+	hash.Write([]byte{0})
+	hash.Write([]byte(first.Raw))
+	return "prefix:" + hex.EncodeToString(hash.Sum(nil)[:16])
+}
+
+func sessionAffinityScope(key, model string) string {
+	return key + "|" + canonicalModelKey(model)
+}
+
+func sessionAffinityKeyFromMetadata(meta map[string]any) string {
+	if len(meta) == 0 {
+		return ""
+	}
+	key, _ := meta[cliproxyexecutor.SessionAffinityMetadataKey].(string)
+	return strings.TrimSpace(key)
+}
+
+// pickNextMixedWithAffinity prefers the credential bound to the conversation of the request.
+// When that credential is cooling down, was already tried or cannot serve the model, the
+// request is routed normally and the conversation moves to the newly picked credential.
+func (m *Manager) pickNextMixedWithAffinity(ctx context.Context, providers []string, model string, opts cliproxyexecutor.Options, tried map[string]struct{}) (*Auth, ProviderExecutor, string, error) {
+	key := sessionAffinityKeyFromMetadata(opts.Metadata)
+	if key == "" || pinnedAuthIDFromMetadata(opts.Metadata) != "" {
+		return m.pickNextMixed(ctx, providers, model, opts, tried)
+	}
+	cfg, ok := m.sessionAffinityConfig()
+	if !ok {
+		return m.pickNextMixed(ctx, providers, model, opts, tried)
+	}
+	scope := sessionAffinityScope(key, model)
+	ttl := time.Duration(cfg.TTLSeconds) * time.Second
+	now := time.Now()
+	if authID, bound := m.sessionAffinity.lookup(scope, now); bound && m.sessionAuthReady(authID, model, tried, now) {
+		pinned := opts
+		pinned.Metadata = make(map[string]any, len(opts.Metadata)+1)
+		for k, v := range opts.Metadata {
+			pinned.Metadata[k] = v
+		}
+		pinned.Metadata[cliproxyexecutor.PinnedAuthMetadataKey] = authID
+		auth, executor, provider, errPick := m.pickNextMixed(ctx, providers, model, pinned, tried)
+		if errPick == nil {
+			m.sessionAffinity.bind(scope, auth.ID, now, ttl)
+			return auth, executor, provider, nil
+		}
+	}
+	auth, executor, provider, errPick := m.pickNextMixed(ctx, providers, model, opts, tried)
+	if errPick == nil {
+		m.sessionAffinity.bind(scope, auth.ID, now, ttl)
+	}
+	return auth, executor, provider, errPick
+}
+
+// sessionAuthReady reports whether the bound credential can take the request right away.
+func (m *Manager) sessionAuthReady(authID, model string, tried map[string]struct{}, now time.Time) bool {
+	if _, used := tried[authID]; used {
+		return false
+	}
+	m.mu.RLock()
+	auth := m.auths[authID]
+	blocked := true
+	if auth != nil {
+		blocked, _, _ = isAuthBlockedForModel(auth, model, now)
+	}
+	m.mu.RUnlock()
+	return !blocked
+}
@@ -0,0 +1,112 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+func newSessionAffinityTestManager(t *testing.T, model string, authIDs ...string) *Manager {
+	t.Helper()
+	m := NewManager(nil, &RoundRobinSelector{}, nil)
+	m.SetConfig(&internalconfig.Config{Routing: internalconfig.RoutingConfig{
+		SessionAffinity: internalconfig.SessionAffinityConfig{Enable: true},
+	}})
+	m.RegisterExecutor(schedulerTestExecutor{})
+	for _, id := range authIDs {
+		if _, err := m.Register(context.Background(), &Auth{ID: id, Provider: "test", Status: StatusActive}); err != nil {
+			t.Fatalf("register %s: %v", id, err)
+		}
+	}
+	registerSchedulerModels(t, "test", model, authIDs...)
+	m.syncScheduler()
+	return m
+}
+
+func executeWithSessionKey(t *testing.T, m *Manager, model, key string) string {
+	t.Helper()
+	meta := map[string]any{
+		cliproxyexecutor.RequestedModelMetadataKey:  model,
+		cliproxyexecutor.SessionAffinityMetadataKey: key,
+	}
+	if _, err := m.Execute(context.Background(), []string{"test"}, cliproxyexecutor.Request{Model: model}, cliproxyexecutor.Options{Metadata: meta}); err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+	authID, _ := meta[cliproxyexecutor.SelectedAuthMetadataKey].(string)
+	return authID
+}
+
+func TestManagerSessionAffinity_KeepsConversationOnCredential(t *testing.T) {
+	model := "affinity-model"
+	m := newSessionAffinityTestManager(t, model, "affinity-a-"+t.Name(), "affinity-b-"+t.Name(), "affinity-c-"+t.Name())
+
+	first := executeWithSessionKey(t, m, model, "conversation-1")
+	for i := 0; i < 5; i++ {
+		if got := executeWithSessionKey(t, m, model, "conversation-1"); got != first {
+			t.Fatalf("request #%d used %q, want %q", i, got, first)
+		}
+	}
+	other := executeWithSessionKey(t, m, model, "conversation-2")
+	if other == first {
+		t.Fatalf("second conversation reused %q, want round-robin to move on", first)
+	}
+
+	retryAfter := time.Minute
+	m.MarkResult(context.Background(), Result{
+		AuthID:     first,
+		Provider:   "test",
+		Model:      model,
+		RetryAfter: &retryAfter,
+		Error:      &Error{HTTPStatus: http.StatusTooManyRequests, Message: "quota"},
+	})
+	moved := executeWithSessionKey(t, m, model, "conversation-1")
+	if moved == first {
+		t.Fatalf("conversation stayed on cooling down credential %q", first)
+	}
+	if got := executeWithSessionKey(t, m, model, "conversation-1"); got != moved {
+		t.Fatalf("conversation used %q after moving, want %q", got, moved)
+	}
+}
+
+func TestManagerSessionAffinityKey(t *testing.T) {
+	m := NewManager(nil, nil, nil)
+	if got := m.SessionAffinityKey(http.Header{"X-Session-Id": {"s1"}}, nil); got != "" {
+		t.Fatalf("key while disabled = %q, want empty", got)
+	}
+	m.SetConfig(&internalconfig.Config{Routing: internalconfig.RoutingConfig{
+		SessionAffinity: internalconfig.SessionAffinityConfig{Enable: true},
+	}})
+
+	cases := []struct {
+		name    string
+		headers http.Header
+		payload string
+		want    string
+	}{
+		{name: "header", headers: http.Header{"X-Session-Id": {"s1"}}, payload: `{"metadata":{"user_id":"u"}}`, want: "header:s1"},
+		{name: "claude user", payload: `{"metadata":{"user_id":"u"},"messages":[{"role":"user","content":"hi"}]}`, want: "user:u"},
+		{name: "prompt cache key", payload: `{"prompt_cache_key":"pk","previous_response_id":"resp_1"}`, want: "cache:pk"},
+		{name: "previous response", payload: `{"previous_response_id":"resp_1","input":"next"}`, want: ResponseSessionKey("resp_1")},
+		{name: "no messages", payload: `{"model":"m"}`, want: ""},
+	}
+	for _, tc := range cases {
+		if got := m.SessionAffinityKey(tc.headers, []byte(tc.payload)); got != tc.want {
+			t.Fatalf("%s: key = %q, want %q", tc.name, got, tc.want)
+		}
+	}
+
+	turn1 := m.SessionAffinityKey(nil, []byte(`{"system":"be brief","messages":[{"role":"user","content":"hi"}]}`))
+	turn2 := m.SessionAffinityKey(nil, []byte(`{"system":"be brief","messages":[{"role":"user","content":"hi"},{"role":"assistant","content":"hello"},{"role":"user","content":"more"}]}`))
+	otherSystem := m.SessionAffinityKey(nil, []byte(`{"system":"be verbose","messages":[{"role":"user","content":"hi"}]}`))
+	if !strings.HasPrefix(turn1, "prefix:") || turn1 != turn2 {
+		t.Fatalf("leading message keys = %q, %q; want equal prefix keys", turn1, turn2)
+	}
+	if otherSystem == turn1 {
+		t.Fatalf("different system prompts share key %q", turn1)
+	}
+}
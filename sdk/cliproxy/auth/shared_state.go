@@ -0,0 +1,343 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	log "github.com/sirupsen/logrus"
+)
+
+// SharedState synchronizes the runtime state of credentials between instances serving the
+// same auths, so a cooldown or quota mark observed by one replica is honoured by the others.
+type SharedState interface {
+	// PublishAuthState records state as the latest runtime state of its auth.
+	PublishAuthState(ctx context.Context, state SharedAuthState) error
+	// AuthStatesSince returns the states published after revision, together with the latest
+	// revision known to the backend.
+	AuthStatesSince(ctx context.Context, revision int64) ([]SharedAuthState, int64, error)
+	// NextCursor increments the named rotation cursor and returns its new value.
+	NextCursor(ctx context.Context, key string) (int64, error)
+	// AcquireLease takes or renews the named lease for owner until ttl elapses. It reports
+	// false while another owner holds an unexpired lease.
+	AcquireLease(ctx context.Context, key, owner string, ttl time.Duration) (bool, error)
+	// ReleaseLease drops the named lease when owner holds it.
+	ReleaseLease(ctx context.Context, key, owner string) error
+}
+
+// SharedAuthState is the cooldown and quota state of one auth as exchanged between instances.
+type SharedAuthState struct {
+	AuthID         string                 `json:"auth_id"`
+	Instance       string                 `json:"instance"`
+	Revision       int64                  `json:"revision,omitempty"`
+	Status         Status                 `json:"status"`
+	StatusMessage  string                 `json:"status_message,omitempty"`
+	Unavailable    bool                   `json:"unavailable"`
+	NextRetryAfter time.Time              `json:"next_retry_after"`
+	Quota          QuotaState             `json:"quota"`
+	ModelStates    map[string]*ModelState `json:"model_states,omitempty"`
+	UpdatedAt      time.Time              `json:"updated_at"`
+}
+
+// SharedStateOptions configures how a Manager uses a SharedState.
+type SharedStateOptions struct {
+	// InstanceID identifies this instance. States it published are not applied back.
+	// Defaults to the host name and process id.
+	InstanceID string
+	// SyncInterval is how often states published by other instances are pulled. Defaults to 2s.
+	SyncInterval time.Duration
+	// ShareRoundRobin rotates credentials with cursors shared by all instances. Each pick
+	// then costs one backend round trip.
+	ShareRoundRobin bool
+}
+
+const (
+	defaultSharedStateSyncInterval = 2 * time.Second
+	sharedStateTimeout             = 5 * time.Second
+	refreshLeaseTTL                = 2 * time.Minute
+)
+
+type sharedStateRuntime struct {
+	state  SharedState
+	opts   SharedStateOptions
+	cancel context.CancelFunc
+
+	// pending holds the latest unpublished state per auth. A burst of results for one auth
+	// collapses into a single publish of its newest state.
+	pendingMu sync.Mutex
+	pending   map[string]SharedAuthState
+	wake      chan struct{}
+}
+
+// DefaultInstanceID returns the instance id used when none is configured.
+func DefaultInstanceID() string {
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		host = "cliproxy"
+	}
+	return fmt.Sprintf("%s-%d", host, os.Getpid())
+}
+
+// SetSharedState makes the manager publish credential state changes to state and apply the
+// changes published by other instances. Nil stops sharing.
+func (m *Manager) SetSharedState(state SharedState, opts SharedStateOptions) {
+	if m == nil {
+		return
+	}
+	var next *sharedStateRuntime
+	if state != nil {
+		if opts.InstanceID == "" {
+			opts.InstanceID = DefaultInstanceID()
+		}
+		if opts.SyncInterval <= 0 {
+			opts.SyncInterval = defaultSharedStateSyncInterval
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		next = &sharedStateRuntime{
+			state:   state,
+			opts:    opts,
+			cancel:  cancel,
+			pending: make(map[string]SharedAuthState),
+			wake:    make(chan struct{}, 1),
+		}
+		go m.runSharedStateSync(ctx, next)
+		go next.runPublisher(ctx)
+	}
+	if previous := m.sharedState.Swap(next); previous != nil {
+		previous.cancel()
+	}
+	if m.scheduler != nil {
+		var cursor func(context.Context, string) (int, bool)
+		if next != nil && opts.ShareRoundRobin {
+			cursor = next.nextCursor
+		}
+		m.scheduler.setSharedCursor(cursor)
+	}
+}
+
+func (r *sharedStateRuntime) nextCursor(ctx context.Context, key string) (int, bool) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), sharedStateTimeout)
+	defer cancel()
+	value, err := r.state.NextCursor(ctx, "round-robin:"+key)
+	if err != nil {
+		log.Warnf("shared state: next cursor %s: %v", key, err)
+		return 0, false
+	}
+	if value < 0 {
+		value = -value
+	}
+	return int(value), true
+}
+
+func (m *Manager) runSharedStateSync(ctx context.Context, runtime *sharedStateRuntime) {
+	ticker := time.NewTicker(runtime.opts.SyncInterval)
+	defer ticker.Stop()
+	var revision int64
+	applied := make(map[string]int64)
+	for {
+		revision = m.pullSharedState(ctx, runtime, revision, applied)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// pullSharedState applies the states other instances published after revision and returns
+// the revision to continue from. Backends may return states again; applied tracks the last
+// revision applied per auth so they are skipped.
+func (m *Manager) pullSharedState(ctx context.Context, runtime *sharedStateRuntime, revision int64, applied map[string]int64) int64 {
+	pullCtx, cancel := context.WithTimeout(ctx, sharedStateTimeout)
+	defer cancel()
+	states, latest, err := runtime.state.AuthStatesSince(pullCtx, revision)
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Warnf("shared state: pull failed: %v", err)
+		}
+		return revision
+	}
+	for i := range states {
+		state := states[i]
+		if state.Instance == runtime.opts.InstanceID || (state.Revision != 0 && state.Revision <= applied[state.AuthID]) {
+			continue
+		}
+		applied[state.AuthID] = state.Revision
+		m.applySharedAuthState(state)
+	}
+	return latest
+}
+
+// publishSharedState queues the state of auth for the other instances without waiting for
+// the backend; a newer state of the same auth replaces one still queued.
+func (m *Manager) publishSharedState(state SharedAuthState) {
+	runtime := m.sharedState.Load()
+	if runtime == nil {
+		return
+	}
+	state.Instance = runtime.opts.InstanceID
+	runtime.pendingMu.Lock()
+	runtime.pending[state.AuthID] = state
+	runtime.pendingMu.Unlock()
+	select {
+	case runtime.wake <- struct{}{}:
+	default:
+	}
+}
+
+// runPublisher sends queued states until ctx ends. States still queued then are dropped.
+func (r *sharedStateRuntime) runPublisher(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-r.wake:
+		}
+		r.pendingMu.Lock()
+		queued := r.pending
+		r.pending = make(map[string]SharedAuthState, len(queued))
+		r.pendingMu.Unlock()
+		for _, state := range queued {
+			publishCtx, cancel := context.WithTimeout(ctx, sharedStateTimeout)
+			err := r.state.PublishAuthState(publishCtx, state)
+			cancel()
+			if err != nil && ctx.Err() == nil {
+				log.Warnf("shared state: publish %s failed: %v", state.AuthID, err)
+			}
+		}
+	}
+}
+
+// sharedAuthStateOf captures the shareable runtime state of auth.
+func sharedAuthStateOf(auth *Auth, now time.Time) SharedAuthState {
+	state := SharedAuthState{
+		AuthID:         auth.ID,
+		Status:         auth.Status,
+		StatusMessage:  auth.StatusMessage,
+		Unavailable:    auth.Unavailable,
+		NextRetryAfter: auth.NextRetryAfter,
+		Quota:          auth.Quota,
+		UpdatedAt:      now,
+	}
+	if len(auth.ModelStates) > 0 {
+		state.ModelStates = make(map[string]*ModelState, len(auth.ModelStates))
+		for model, modelState := range auth.ModelStates {
+			if modelState == nil {
+				continue
+			}
+			copied := *modelState
+			copied.LastError = cloneError(modelState.LastError)
+			state.ModelStates[model] = &copied
+		}
+	}
+	return state
+}
+
+// sharedStateChanged reports whether the cooldown relevant parts of two states differ.
+func sharedStateChanged(a, b SharedAuthState) bool {
+	if a.Status != b.Status || a.Unavailable != b.Unavailable || !a.NextRetryAfter.Equal(b.NextRetryAfter) || !quotaStateEqual(a.Quota, b.Quota) {
+		return true
+	}
+	if len(a.ModelStates) != len(b.ModelStates) {
+		return true
+	}
+	for model, stateA := range a.ModelStates {
+		stateB, ok := b.ModelStates[model]
+		if !ok || stateA == nil || stateB == nil {
+			return true
+		}
+		if stateA.Status != stateB.Status || stateA.Unavailable != stateB.Unavailable || !stateA.NextRetryAfter.Equal(stateB.NextRetryAfter) || !quotaStateEqual(stateA.Quota, stateB.Quota) {
+			return true
+		}
+	}
+	return false
+}
+
+func quotaStateEqual(a, b QuotaState) bool {
+	return a.Exceeded == b.Exceeded && a.Reason == b.Reason && a.BackoffLevel == b.BackoffLevel && a.NextRecoverAt.Equal(b.NextRecoverAt)
+}
+
+// applySharedAuthState adopts a state published by another instance. Models the other
+// instance has no state for keep their local state.
+func (m *Manager) applySharedAuthState(state SharedAuthState) {
+	now := time.Now()
+	m.mu.Lock()
+	auth, ok := m.auths[state.AuthID]
+	if !ok || auth == nil || auth.Disabled {
+		m.mu.Unlock()
+		return
+	}
+	if state.Status == StatusActive || state.Status == StatusError {
+		auth.Status = state.Status
+	}
+	auth.StatusMessage = state.StatusMessage
+	auth.Unavailable = state.Unavailable
+	auth.NextRetryAfter = state.NextRetryAfter
+	auth.Quota = state.Quota
+	for model, remote := range state.ModelStates {
+		if remote == nil {
+			continue
+		}
+		local := ensureModelState(auth, model)
+		local.Status = remote.Status
+		local.StatusMessage = remote.StatusMessage
+		local.Unavailable = remote.Unavailable
+		local.NextRetryAfter = remote.NextRetryAfter
+		local.LastError = cloneError(remote.LastError)
+		local.Quota = remote.Quota
+		local.UpdatedAt = remote.UpdatedAt
+	}
+	if len(state.ModelStates) > 0 {
+		updateAggregatedAvailability(auth, now)
+	}
+	auth.UpdatedAt = now
+	snapshot := auth.Clone()
+	m.mu.Unlock()
+
+	if m.scheduler != nil {
+		m.scheduler.upsertAuth(snapshot)
+	}
+	reg := registry.GetGlobalRegistry()
+	for model, remote := range state.ModelStates {
+		if remote == nil {
+			continue
+		}
+		if remote.Quota.Exceeded {
+			reg.SetModelQuotaExceeded(state.AuthID, model)
+			reg.SuspendClientModel(state.AuthID, model, "quota")
+		} else if !remote.Unavailable {
+			reg.ClearModelQuotaExceeded(state.AuthID, model)
+			reg.ResumeClientModel(state.AuthID, model)
+		}
+	}
+}
+
+// acquireRefreshLease keeps other instances from refreshing auth id at the same time. It
+// reports false when another instance holds the lease; backend errors do not block refreshes.
+func (m *Manager) acquireRefreshLease(ctx context.Context, id string) (func(), bool) {
+	runtime := m.sharedState.Load()
+	if runtime == nil {
+		return func() {}, true
+	}
+	key := "refresh:" + id
+	leaseCtx, cancel := context.WithTimeout(ctx, sharedStateTimeout)
+	defer cancel()
+	acquired, err := runtime.state.AcquireLease(leaseCtx, key, runtime.opts.InstanceID, refreshLeaseTTL)
+	if err != nil {
+		log.Warnf("shared state: acquire refresh lease for %s failed: %v", id, err)
+		return func() {}, true
+	}
+	if !acquired {
+		return nil, false
+	}
+	return func() {
+		releaseCtx, cancelRelease := context.WithTimeout(context.Background(), sharedStateTimeout)
+		defer cancelRelease()
+		if errRelease := runtime.state.ReleaseLease(releaseCtx, key, runtime.opts.InstanceID); errRelease != nil {
+			log.Warnf("shared state: release refresh lease for %s failed: %v", id, errRelease)
+		}
+	}, true
+}
@@ -0,0 +1,225 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type memorySharedState struct {
+	mu       sync.Mutex
+	revision int64
+	auths    map[string]SharedAuthState
+	cursors  map[string]int64
+	leases   map[string]string
+}
+
+func newMemorySharedState() *memorySharedState {
+	return &memorySharedState{
+		auths:   make(map[string]SharedAuthState),
+		cursors: make(map[string]int64),
+		leases:  make(map[string]string),
+	}
+}
+
+func (s *memorySharedState) PublishAuthState(_ context.Context, state SharedAuthState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.revision++
+	state.Revision = s.revision
+	s.auths[state.AuthID] = state
+	return nil
+}
+
+func (s *memorySharedState) AuthStatesSince(_ context.Context, revision int64) ([]SharedAuthState, int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []SharedAuthState
+	for _, state := range s.auths {
+		if state.Revision > revision {
+			out = append(out, state)
+		}
+	}
+	return out, s.revision, nil
+}
+
+func (s *memorySharedState) NextCursor(_ context.Context, key string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cursors[key]++
+	return s.cursors[key], nil
+}
+
+func (s *memorySharedState) AcquireLease(_ context.Context, key, owner string, _ time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if holder, ok := s.leases[key]; ok && holder != owner {
+		return false, nil
+	}
+	s.leases[key] = owner
+	return true, nil
+}
+
+func (s *memorySharedState) ReleaseLease(_ context.Context, key, owner string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.leases[key] == owner {
+		delete(s.leases, key)
+	}
+	return nil
+}
+
+func newSharedStateTestManager(t *testing.T, state SharedState, instance string) *Manager {
+	t.Helper()
+	m := NewManager(nil, nil, nil)
+	if _, err := m.Register(context.Background(), &Auth{ID: "shared-auth", Provider: "shared-provider", Status: StatusActive}); err != nil {
+		t.Fatalf("register auth: %v", err)
+	}
+	m.SetSharedState(state, SharedStateOptions{InstanceID: instance, SyncInterval: time.Hour})
+	t.Cleanup(func() { m.SetSharedState(nil, SharedStateOptions{}) })
+	return m
+}
+
+// waitPublished waits until the publisher of a manager has sent a state of authID.
+func waitPublished(t *testing.T, state *memorySharedState, authID string) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		state.mu.Lock()
+		_, ok := state.auths[authID]
+		state.mu.Unlock()
+		if ok {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("state of %s was not published", authID)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestSharedState_CooldownPropagatesBetweenInstances(t *testing.T) {
+	state := newMemorySharedState()
+	first := newSharedStateTestManager(t, state, "first")
+	second := newSharedStateTestManager(t, state, "second")
+
+	retryAfter := 10 * time.Minute
+	first.MarkResult(context.Background(), Result{
+		AuthID:     "shared-auth",
+		Provider:   "shared-provider",
+		Model:      "shared-model",
+		RetryAfter: &retryAfter,
+		Error:      &Error{HTTPStatus: http.StatusTooManyRequests, Message: "quota exhausted"},
+	})
+
+	waitPublished(t, state, "shared-auth")
+	applied := make(map[string]int64)
+	revision := second.pullSharedState(context.Background(), second.sharedState.Load(), 0, applied)
+	if revision == 0 || applied["shared-auth"] != revision {
+		t.Fatalf("revision = %d, applied = %v; want the published state applied", revision, applied)
+	}
+	auth, ok := second.GetByID("shared-auth")
+	if !ok {
+		t.Fatal("auth not found on second instance")
+	}
+	modelState := auth.ModelStates["shared-model"]
+	if modelState == nil || !modelState.Unavailable || !modelState.Quota.Exceeded {
+		t.Fatalf("model state = %+v, want quota cooldown from first instance", modelState)
+	}
+	if time.Until(modelState.NextRetryAfter) < 9*time.Minute {
+		t.Fatalf("next retry after = %v, want about %v from now", modelState.NextRetryAfter, retryAfter)
+	}
+
+	// The first instance skips its own state.
+	firstApplied := make(map[string]int64)
+	first.pullSharedState(context.Background(), first.sharedState.Load(), 0, firstApplied)
+	if len(firstApplied) != 0 {
+		t.Fatalf("first instance applied its own state: %v", firstApplied)
+	}
+}
+
+// blockingSharedState holds every publish until release is closed.
+type blockingSharedState struct {
+	*memorySharedState
+	release   chan struct{}
+	publishes atomic.Int32
+}
+
+func (s *blockingSharedState) PublishAuthState(ctx context.Context, state SharedAuthState) error {
+	s.publishes.Add(1)
+	select {
+	case <-s.release:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return s.memorySharedState.PublishAuthState(ctx, state)
+}
+
+func TestSharedState_MarkResultDoesNotWaitForBackend(t *testing.T) {
+	state := &blockingSharedState{memorySharedState: newMemorySharedState(), release: make(chan struct{})}
+	m := newSharedStateTestManager(t, state, "first")
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 20; i++ {
+			retryAfter := time.Duration(i+1) * time.Minute
+			m.MarkResult(context.Background(), Result{
+				AuthID:     "shared-auth",
+				Provider:   "shared-provider",
+				Model:      "shared-model",
+				RetryAfter: &retryAfter,
+				Error:      &Error{HTTPStatus: http.StatusTooManyRequests, Message: "quota exhausted"},
+			})
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("MarkResult blocked on a slow shared state backend")
+	}
+
+	close(state.release)
+	waitPublished(t, state.memorySharedState, "shared-auth")
+	if got := state.publishes.Load(); got > 2 {
+		t.Fatalf("publishes = %d, want queued states of the auth coalesced", got)
+	}
+}
+
+func TestSharedState_RefreshLeaseExcludesOtherInstances(t *testing.T) {
+	state := newMemorySharedState()
+	first := newSharedStateTestManager(t, state, "first")
+	second := newSharedStateTestManager(t, state, "second")
+
+	release, ok := first.acquireRefreshLease(context.Background(), "shared-auth")
+	if !ok {
+		t.Fatal("first instance did not acquire the refresh lease")
+	}
+	if _, okSecond := second.acquireRefreshLease(context.Background(), "shared-auth"); okSecond {
+		t.Fatal("second instance acquired a lease held by the first")
+	}
+	release()
+	releaseSecond, okSecond := second.acquireRefreshLease(context.Background(), "shared-auth")
+	if !okSecond {
+		t.Fatal("second instance did not acquire the released lease")
+	}
+	releaseSecond()
+}
+
+func TestSharedState_RoundRobinUsesSharedCursor(t *testing.T) {
+	state := newMemorySharedState()
+	m := NewManager(nil, nil, nil)
+	m.SetSharedState(state, SharedStateOptions{InstanceID: "only", SyncInterval: time.Hour, ShareRoundRobin: true})
+	t.Cleanup(func() { m.SetSharedState(nil, SharedStateOptions{}) })
+
+	cursor, ok := m.scheduler.nextSharedCursor(context.Background(), "provider:model")
+	if !ok || cursor != 1 {
+		t.Fatalf("cursor = %d, %v; want 1, true", cursor, ok)
+	}
+	if state.cursors["round-robin:provider:model"] != 1 {
+		t.Fatalf("shared cursors = %v", state.cursors)
+	}
+}
@@ -11,3 +11,15 @@ type Store interface {
 	// Delete removes the auth record identified by id.
 	Delete(ctx context.Context, id string) error
 }
+
+// Locker is implemented by stores shared between processes that can serialize token refreshes.
+// Providers that rotate refresh tokens on use invalidate the token another process is about to
+// spend, so the manager holds the lock of an auth around ProviderExecutor.Refresh and re-reads
+// the auth with LoadAuth once the lock is held.
+type Locker interface {
+	// LockAuth blocks until the lock of auth id is held or ctx ends, and returns the function
+	// that releases it.
+	LockAuth(ctx context.Context, id string) (unlock func(), err error)
+	// LoadAuth returns the record currently stored for auth, or nil when it is not stored.
+	LoadAuth(ctx context.Context, auth *Auth) (*Auth, error)
+}
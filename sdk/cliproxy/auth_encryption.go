@@ -0,0 +1,43 @@
+package cliproxy
+
+import (
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/authcrypt"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	log "github.com/sirupsen/logrus"
+)
+
+// applyAuthEncryption installs the auth file cipher described by cfg. Without configured
+// keys a cipher installed through sdk/auth.SetKeyProvider is kept, while one installed from
+// an earlier configuration is removed, so files are written as plaintext again. A cipher
+// that fails to load keeps the previous one.
+func (s *Service) applyAuthEncryption(cfg *config.Config) {
+	if s == nil || cfg == nil {
+		return
+	}
+	previous := authcrypt.Default()
+	if !cfg.AuthEncryption.Enable && len(cfg.AuthEncryption.Keys) == 0 {
+		if s.authCipher == nil {
+			return
+		}
+		if previous == s.authCipher {
+			authcrypt.SetDefault(nil)
+			log.Warn("auth encryption removed from the configuration, auth files are written as plaintext and encrypted files can no longer be read")
+		}
+		s.authCipher = nil
+		return
+	}
+	cipher, err := authcrypt.New(cfg.AuthEncryption)
+	if err != nil {
+		log.Errorf("auth encryption: %v", err)
+		return
+	}
+	authcrypt.SetDefault(cipher)
+	s.authCipher = cipher
+	if keyID := cipher.KeyID(); keyID != previous.KeyID() {
+		if keyID == "" {
+			log.Info("auth encryption disabled, auth files are written as plaintext")
+		} else {
+			log.Infof("auth encryption enabled with key %s", keyID)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package cliproxy
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/authcrypt"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+func TestApplyAuthEncryptionDisableTransition(t *testing.T) {
+	t.Setenv("CLIPROXY_TEST_AUTH_KEY", base64.StdEncoding.EncodeToString(make([]byte, 32)))
+	t.Cleanup(func() { authcrypt.SetDefault(nil) })
+	enabled := &config.Config{AuthEncryption: config.AuthEncryptionConfig{
+		Enable: true,
+		Keys:   []config.AuthEncryptionKey{{ID: "k1", Env: "CLIPROXY_TEST_AUTH_KEY"}},
+	}}
+
+	s := &Service{}
+	s.applyAuthEncryption(enabled)
+	if !authcrypt.Default().Encrypts() {
+		t.Fatal("configured cipher was not installed")
+	}
+	s.applyAuthEncryption(&config.Config{})
+	if authcrypt.Default() != nil {
+		t.Fatal("cipher from the previous configuration is still installed")
+	}
+
+	ring, err := authcrypt.NewKeyring(authcrypt.Key{ID: "sdk", Secret: make([]byte, 32)})
+	if err != nil {
+		t.Fatalf("NewKeyring: %v", err)
+	}
+	provided := authcrypt.NewCipher(ring)
+	authcrypt.SetDefault(provided)
+	s.applyAuthEncryption(&config.Config{})
+	if authcrypt.Default() != provided {
+		t.Fatal("cipher installed through SetKeyProvider was removed")
+	}
+}
@@ -0,0 +1,106 @@
+package cliproxy
+
+import (
+	"path/filepath"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/batch"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/filestore"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	log "github.com/sirupsen/logrus"
+)
+
+const defaultBatchDir = "batches"
+
+// applyBatches starts the batch manager when batches are enabled. Changed settings or a
+// replaced shared file store restart it; batches that had not ended resume on the new manager.
+func (s *Service) applyBatches(cfg *config.Config) {
+	if s == nil || cfg == nil {
+		return
+	}
+	if !cfg.Batches.Enable {
+		if s.batches != nil {
+			s.shutdownBatches()
+			log.Info("batches disabled")
+		}
+		return
+	}
+	settings := cfg.Batches
+	settings.Dir = resolveDataDir(settings.Dir, defaultBatchDir, s.configPath)
+	if s.batches != nil && *s.batches == settings && s.batchFilesCurrent() {
+		s.batchHandler.UpdateClients(&cfg.SDKConfig)
+		return
+	}
+	s.shutdownBatches()
+
+	files := s.fileStore
+	if files == nil {
+		var err error
+		files, err = filestore.New(filepath.Join(settings.Dir, "files"), int64(settings.MaxFileSizeMB)<<20, 0)
+		if err != nil {
+			log.Errorf("batches: %v", err)
+			return
+		}
+	}
+	batchHandler := handlers.NewBaseAPIHandlers(&cfg.SDKConfig, s.coreManager)
+	manager, err := batch.NewManager(batch.Options{
+		Dir:         filepath.Join(settings.Dir, "batches"),
+		Concurrency: settings.Concurrency,
+		Retention:   time.Duration(settings.RetentionDays) * 24 * time.Hour,
+		MaxRequests: settings.MaxRequests,
+		Files:       files,
+	}, handlers.NewBatchExecutor(batchHandler))
+	if err != nil {
+		log.Errorf("batches: %v", err)
+		return
+	}
+	if files != s.fileStore {
+		filestore.SetDefault(files)
+	}
+	batch.SetDefault(manager)
+	s.batches = &settings
+	s.batchFiles = files
+	s.batchHandler = batchHandler
+	log.Infof("batches enabled (dir=%s, concurrency=%d)", settings.Dir, settings.Concurrency)
+}
+
+// batchFilesCurrent reports whether the running batch manager uses the shared file store,
+// or its private store while no shared store is installed.
+func (s *Service) batchFilesCurrent() bool {
+	if s.fileStore != nil {
+		return s.batchFiles == s.fileStore
+	}
+	return s.batchFiles != nil && filestore.Default() == s.batchFiles
+}
+
+// shutdownBatches stops dispatching batch requests and waits for the running ones.
+func (s *Service) shutdownBatches() {
+	if s == nil || s.batches == nil {
+		return
+	}
+	manager := batch.Default()
+	batch.SetDefault(nil)
+	if s.batchFiles != s.fileStore && filestore.Default() == s.batchFiles {
+		filestore.SetDefault(nil)
+	}
+	manager.Close()
+	s.batches = nil
+	s.batchFiles = nil
+	s.batchHandler = nil
+}
+
+// resolveDataDir resolves dir, or fallback when empty, against the config file directory.
+func resolveDataDir(dir, fallback, configPath string) string {
+	if dir == "" {
+		dir = fallback
+	}
+	if filepath.IsAbs(dir) {
+		return dir
+	}
+	base := "."
+	if configPath != "" {
+		base = filepath.Dir(configPath)
+	}
+	return filepath.Join(base, dir)
+}
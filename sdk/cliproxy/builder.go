@@ -12,6 +12,7 @@ import (
 	sdkaccess "github.com/router-for-me/CLIProxyAPI/v6/sdk/access"
 	sdkAuth "github.com/router-for-me/CLIProxyAPI/v6/sdk/auth"
 	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/pipeline"
 	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
 )
 
@@ -48,6 +49,9 @@ type Builder struct {
 
 	// serverOptions contains additional server configuration options.
 	serverOptions []api.ServerOption
+
+	// pipelineHooks intercept upstream executions of the core manager.
+	pipelineHooks []pipeline.Hook
 }
 
 // Hooks allows callers to plug into service lifecycle stages.
@@ -163,6 +167,16 @@ func (b *Builder) WithPostAuthHook(hook coreauth.PostAuthHook) *Builder {
 	return b
 }
 
+// WithPipelineHook registers a hook that runs around every upstream execution of the
+// core auth manager. Hooks run in registration order.
+func (b *Builder) WithPipelineHook(hook pipeline.Hook) *Builder {
+	if hook == nil {
+		return b
+	}
+	b.pipelineHooks = append(b.pipelineHooks, hook)
+	return b
+}
+
 // Build validates inputs, applies defaults, and returns a ready-to-run service.
 func (b *Builder) Build() (*Service, error) {
 	if b.cfg == nil {
@@ -201,6 +215,7 @@ func (b *Builder) Build() (*Service, error) {
 	accessManager.SetProviders(sdkaccess.RegisteredProviders())
 
 	coreManager := b.coreManager
+	var notifications *notificationHook
 	if coreManager == nil {
 		tokenStore := sdkAuth.GetTokenStore()
 		if dirSetter, ok := tokenStore.(interface{ SetBaseDir(string) }); ok && b.cfg != nil {
@@ -215,16 +230,23 @@ func (b *Builder) Build() (*Service, error) {
 		switch strategy {
 		case "fill-first", "fillfirst", "ff":
 			selector = &coreauth.FillFirstSelector{}
+		case "weighted-health", "weightedhealth", "health":
+			selector = &coreauth.WeightedHealthSelector{}
 		default:
 			selector = &coreauth.RoundRobinSelector{}
 		}
 
-		coreManager = coreauth.NewManager(tokenStore, selector, nil)
+		notifications = newNotificationHook()
+		coreManager = coreauth.NewManager(tokenStore, selector, notifications)
+		notifications.manager = coreManager
 	}
 	// Attach a default RoundTripper provider so providers can opt-in per-auth transports.
 	coreManager.SetRoundTripperProvider(newDefaultRoundTripperProvider())
 	coreManager.SetConfig(b.cfg)
 	coreManager.SetOAuthModelAlias(b.cfg.OAuthModelAlias)
+	for _, hook := range b.pipelineHooks {
+		coreManager.AddExecutionHook(hook)
+	}
 
 	service := &Service{
 		cfg:            b.cfg,
@@ -236,6 +258,7 @@ func (b *Builder) Build() (*Service, error) {
 		authManager:    authManager,
 		accessManager:  accessManager,
 		coreManager:    coreManager,
+		notifications:  notifications,
 		serverOptions:  append([]api.ServerOption(nil), b.serverOptions...),
 	}
 	return service, nil
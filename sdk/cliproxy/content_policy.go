@@ -0,0 +1,35 @@
+package cliproxy
+
+import (
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/policy"
+	log "github.com/sirupsen/logrus"
+)
+
+// applyContentPolicy compiles the content policy of cfg and installs it for the handlers.
+// A policy that fails to compile keeps the previous one in place.
+func (s *Service) applyContentPolicy(cfg *config.Config) {
+	if s == nil || cfg == nil {
+		return
+	}
+	engine, err := policy.New(cfg.ContentPolicy)
+	if err != nil {
+		log.Errorf("content policy: %v", err)
+		return
+	}
+	previous := policy.Default()
+	policy.SetDefault(engine)
+	switch {
+	case engine != nil && previous == nil:
+		log.Infof("content policy enabled (%d rules)", len(cfg.ContentPolicy.Rules))
+	case engine == nil && previous != nil:
+		log.Info("content policy disabled")
+	}
+}
+
+func (s *Service) shutdownContentPolicy() {
+	if s == nil {
+		return
+	}
+	policy.SetDefault(nil)
+}
@@ -1,6 +1,7 @@
 package executor
 
 import (
+	"context"
 	"net/http"
 	"net/url"
 
@@ -19,8 +20,30 @@ const (
 	SelectedAuthCallbackMetadataKey = "selected_auth_callback"
 	// ExecutionSessionMetadataKey identifies a long-lived downstream execution session.
 	ExecutionSessionMetadataKey = "execution_session_id"
+	// SessionAffinityMetadataKey carries the conversation key used to keep a conversation on
+	// one credential.
+	SessionAffinityMetadataKey = "session_affinity_key"
+	// ServedModelMetadataKey receives the model that served the request, which differs from
+	// the requested model when a fallback chain took over.
+	ServedModelMetadataKey = "served_model"
 )
 
+type fallbackFromContextKey struct{}
+
+// WithFallbackFrom marks ctx as executing a fallback for requestedModel.
+func WithFallbackFrom(ctx context.Context, requestedModel string) context.Context {
+	return context.WithValue(ctx, fallbackFromContextKey{}, requestedModel)
+}
+
+// FallbackFrom returns the requested model a fallback execution stands in for, or "".
+func FallbackFrom(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	model, _ := ctx.Value(fallbackFromContextKey{}).(string)
+	return model
+}
+
 // Request encapsulates the translated payload that will be sent to a provider executor.
 type Request struct {
 	// Model is the upstream model identifier after translation.
@@ -0,0 +1,54 @@
+package cliproxy
+
+import (
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/filestore"
+	log "github.com/sirupsen/logrus"
+)
+
+const defaultFilesDir = "files"
+
+// applyFiles opens the /v1/files store when files are enabled. Batches started afterwards
+// keep their input and output files in it; without it they fall back to a private store.
+func (s *Service) applyFiles(cfg *config.Config) {
+	if s == nil || cfg == nil {
+		return
+	}
+	if !cfg.Files.Enable {
+		if s.files != nil {
+			s.shutdownFiles()
+			log.Info("files disabled")
+		}
+		return
+	}
+	settings := cfg.Files
+	settings.Dir = resolveDataDir(settings.Dir, defaultFilesDir, s.configPath)
+	if s.files != nil && *s.files == settings {
+		return
+	}
+	s.shutdownFiles()
+
+	store, err := filestore.New(settings.Dir, int64(settings.MaxFileSizeMB)<<20, time.Duration(settings.TTLHours)*time.Hour)
+	if err != nil {
+		log.Errorf("files: %v", err)
+		return
+	}
+	filestore.SetDefault(store)
+	s.files = &settings
+	s.fileStore = store
+	log.Infof("files enabled (dir=%s, max-file-size-mb=%d, ttl-hours=%d)", settings.Dir, settings.MaxFileSizeMB, settings.TTLHours)
+}
+
+// shutdownFiles uninstalls the shared file store.
+func (s *Service) shutdownFiles() {
+	if s == nil || s.files == nil {
+		return
+	}
+	if filestore.Default() == s.fileStore {
+		filestore.SetDefault(nil)
+	}
+	s.files = nil
+	s.fileStore = nil
+}
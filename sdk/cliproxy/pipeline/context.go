@@ -6,56 +6,45 @@ import (
 
 	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
 	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
-	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
 )
 
 // Context encapsulates execution state shared across middleware, translators, and executors.
-type Context struct {
-	// Request encapsulates the provider facing request payload.
-	Request cliproxyexecutor.Request
-	// Options carries execution flags (streaming, headers, etc.).
-	Options cliproxyexecutor.Options
-	// Auth references the credential selected for execution.
-	Auth *cliproxyauth.Auth
-	// Translator represents the pipeline responsible for schema adaptation.
-	Translator *sdktranslator.Pipeline
-	// HTTPClient allows middleware to customise the outbound transport per request.
-	HTTPClient *http.Client
-}
+// Hooks receive it for every upstream attempt once a credential has been selected.
+type Context = cliproxyauth.ExecutionContext
 
-// Hook captures middleware callbacks around execution.
-type Hook interface {
-	BeforeExecute(ctx context.Context, execCtx *Context)
-	AfterExecute(ctx context.Context, execCtx *Context, resp cliproxyexecutor.Response, err error)
-	OnStreamChunk(ctx context.Context, execCtx *Context, chunk cliproxyexecutor.StreamChunk)
-}
+// Hook captures middleware callbacks around execution. Register hooks with
+// cliproxy.Builder.WithPipelineHook; see cliproxyauth.ExecutionHook for the contract.
+type Hook = cliproxyauth.ExecutionHook
 
 // HookFunc aggregates optional hook implementations.
 type HookFunc struct {
-	Before func(context.Context, *Context)
-	After  func(context.Context, *Context, cliproxyexecutor.Response, error)
-	Stream func(context.Context, *Context, cliproxyexecutor.StreamChunk)
+	Before func(context.Context, *Context) error
+	After  func(context.Context, *Context, *cliproxyexecutor.Response, error) error
+	Stream func(context.Context, *Context, *cliproxyexecutor.StreamChunk) error
 }
 
 // BeforeExecute implements Hook.
-func (h HookFunc) BeforeExecute(ctx context.Context, execCtx *Context) {
+func (h HookFunc) BeforeExecute(ctx context.Context, execCtx *Context) error {
 	if h.Before != nil {
-		h.Before(ctx, execCtx)
+		return h.Before(ctx, execCtx)
 	}
+	return nil
 }
 
 // AfterExecute implements Hook.
-func (h HookFunc) AfterExecute(ctx context.Context, execCtx *Context, resp cliproxyexecutor.Response, err error) {
+func (h HookFunc) AfterExecute(ctx context.Context, execCtx *Context, resp *cliproxyexecutor.Response, err error) error {
 	if h.After != nil {
-		h.After(ctx, execCtx, resp, err)
+		return h.After(ctx, execCtx, resp, err)
 	}
+	return nil
 }
 
 // OnStreamChunk implements Hook.
-func (h HookFunc) OnStreamChunk(ctx context.Context, execCtx *Context, chunk cliproxyexecutor.StreamChunk) {
+func (h HookFunc) OnStreamChunk(ctx context.Context, execCtx *Context, chunk *cliproxyexecutor.StreamChunk) error {
 	if h.Stream != nil {
-		h.Stream(ctx, execCtx, chunk)
+		return h.Stream(ctx, execCtx, chunk)
 	}
+	return nil
 }
 
 // RoundTripperProvider allows injection of custom HTTP transports per auth entry.
@@ -0,0 +1,30 @@
+package cliproxy
+
+import (
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/pricing"
+	internalusage "github.com/router-for-me/CLIProxyAPI/v6/internal/usage"
+	log "github.com/sirupsen/logrus"
+)
+
+// applyPricing installs the price table of cfg for cost accounting and updates the budgets
+// of client keys. A table that fails to build keeps the previous one in place.
+func (s *Service) applyPricing(cfg *config.Config) {
+	if s == nil || cfg == nil {
+		return
+	}
+	internalusage.GetBudgetTracker().SetBudgets(cfg.Pricing.Budgets)
+	table, err := pricing.New(cfg.Pricing)
+	if err != nil {
+		log.Errorf("pricing: %v", err)
+		return
+	}
+	pricing.SetDefault(table)
+}
+
+func (s *Service) shutdownPricing() {
+	if s == nil {
+		return
+	}
+	pricing.SetDefault(nil)
+}
@@ -0,0 +1,76 @@
+package cliproxy
+
+import (
+	"path/filepath"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/cache"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	log "github.com/sirupsen/logrus"
+)
+
+const defaultResponseCacheDir = "response-cache"
+
+// applyResponseCache installs a response cache matching cfg, rebuilding it only when the
+// settings changed so reloads keep cached entries.
+func (s *Service) applyResponseCache(cfg *config.Config) {
+	if s == nil || cfg == nil {
+		return
+	}
+	settings := cfg.ResponseCache
+	if settings.Backend == "disk" {
+		settings.Dir = resolveResponseCacheDir(settings.Dir, s.configPath)
+	}
+	if !settings.Enable {
+		if s.responseCache != nil {
+			s.shutdownResponseCache()
+			log.Info("response cache disabled")
+		}
+		return
+	}
+	if s.responseCache != nil && *s.responseCache == settings {
+		return
+	}
+
+	maxBytes := int64(settings.MaxSizeMB) << 20
+	var store cache.ResponseStore
+	switch settings.Backend {
+	case "disk":
+		diskStore, err := cache.NewDiskResponseStore(settings.Dir, settings.MaxEntries, maxBytes)
+		if err != nil {
+			log.Errorf("response cache: %v", err)
+			return
+		}
+		store = diskStore
+	case "memory":
+		store = cache.NewMemoryResponseStore(settings.MaxEntries, maxBytes)
+	default:
+		log.Errorf("response cache: unsupported backend %q", settings.Backend)
+		return
+	}
+	cache.SetDefaultResponseCache(cache.NewResponseCache(store, time.Duration(settings.TTLSeconds)*time.Second, maxBytes))
+	s.responseCache = &settings
+	log.Infof("response cache enabled (backend=%s, ttl=%ds)", settings.Backend, settings.TTLSeconds)
+}
+
+func (s *Service) shutdownResponseCache() {
+	if s == nil || s.responseCache == nil {
+		return
+	}
+	cache.SetDefaultResponseCache(nil)
+	s.responseCache = nil
+}
+
+func resolveResponseCacheDir(dir, configPath string) string {
+	if dir == "" {
+		dir = defaultResponseCacheDir
+	}
+	if filepath.IsAbs(dir) {
+		return dir
+	}
+	base := "."
+	if configPath != "" {
+		base = filepath.Dir(configPath)
+	}
+	return filepath.Join(base, dir)
+}
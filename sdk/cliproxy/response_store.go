@@ -0,0 +1,93 @@
+package cliproxy
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/responsestore"
+	sdkAuth "github.com/router-for-me/CLIProxyAPI/v6/sdk/auth"
+	log "github.com/sirupsen/logrus"
+)
+
+const defaultResponseStoreDir = "responses"
+
+// responseStoreProvider is implemented by token stores that can host stored responses on
+// their own connection (e.g. the Postgres-backed store).
+type responseStoreProvider interface {
+	ResponseStore(ctx context.Context, table string) (responsestore.Backend, error)
+}
+
+// applyResponseStore installs a response store matching cfg, rebuilding it only when the
+// settings changed so reloads keep stored responses.
+func (s *Service) applyResponseStore(cfg *config.Config) {
+	if s == nil || cfg == nil {
+		return
+	}
+	settings := cfg.ResponseStore
+	if settings.Backend == "disk" {
+		settings.Dir = resolveResponseStoreDir(settings.Dir, s.configPath)
+	}
+	if !settings.Enable {
+		if s.responseStore != nil {
+			s.shutdownResponseStore()
+			log.Info("response store disabled")
+		}
+		return
+	}
+	if s.responseStore != nil && *s.responseStore == settings {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	backend, err := openResponseStoreBackend(ctx, settings)
+	if err != nil {
+		log.Errorf("response store: %v", err)
+		return
+	}
+	responsestore.SetDefault(responsestore.New(backend, time.Duration(settings.TTLSeconds)*time.Second))
+	s.responseStore = &settings
+	log.Infof("response store enabled (backend=%s, ttl=%ds)", settings.Backend, settings.TTLSeconds)
+}
+
+func (s *Service) shutdownResponseStore() {
+	if s == nil || s.responseStore == nil {
+		return
+	}
+	responsestore.SetDefault(nil)
+	s.responseStore = nil
+}
+
+func openResponseStoreBackend(ctx context.Context, settings config.ResponseStoreConfig) (responsestore.Backend, error) {
+	switch settings.Backend {
+	case "memory":
+		return responsestore.NewMemoryBackend(settings.MaxEntries), nil
+	case "disk":
+		return responsestore.NewDiskBackend(settings.Dir)
+	case "postgres":
+		provider, ok := sdkAuth.GetTokenStore().(responseStoreProvider)
+		if !ok {
+			return nil, fmt.Errorf("postgres backend requires the Postgres token store (PGSTORE_DSN)")
+		}
+		return provider.ResponseStore(ctx, settings.Table)
+	default:
+		return nil, fmt.Errorf("unsupported backend %q", settings.Backend)
+	}
+}
+
+func resolveResponseStoreDir(dir, configPath string) string {
+	if dir == "" {
+		dir = defaultResponseStoreDir
+	}
+	if filepath.IsAbs(dir) {
+		return dir
+	}
+	base := "."
+	if configPath != "" {
+		base = filepath.Dir(configPath)
+	}
+	return filepath.Join(base, dir)
+}
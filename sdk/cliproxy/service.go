@@ -14,12 +14,15 @@ import (
 
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/api"
 	kiroauth "github.com/router-for-me/CLIProxyAPI/v6/internal/auth/kiro"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/authcrypt"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/filestore"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/runtime/executor"
 	_ "github.com/router-for-me/CLIProxyAPI/v6/internal/usage"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/watcher"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/wsrelay"
 	sdkaccess "github.com/router-for-me/CLIProxyAPI/v6/sdk/access"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
 	sdkAuth "github.com/router-for-me/CLIProxyAPI/v6/sdk/auth"
 	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
 	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
@@ -61,6 +64,44 @@ type Service struct {
 	// pprofServer manages the optional pprof HTTP debug server.
 	pprofServer *pprofServer
 
+	// usagePersistence tracks the active persistent usage statistics backend.
+	usagePersistence *usagePersistence
+
+	// sharedState remembers the settings of the connected shared state backend.
+	sharedState *config.SharedStateConfig
+
+	// responseCache remembers the settings of the installed response cache.
+	responseCache *config.ResponseCacheConfig
+
+	// responseStore remembers the settings of the installed Responses API store.
+	responseStore *config.ResponseStoreConfig
+
+	// batches remembers the settings of the running batch manager.
+	batches *config.BatchConfig
+	// batchFiles is the file store the running batch manager writes results to.
+	batchFiles *filestore.Store
+	// batchHandler executes batch requests on behalf of the batch manager.
+	batchHandler *handlers.BaseAPIHandler
+
+	// files remembers the settings of the shared /v1/files store.
+	files *config.FilesConfig
+	// fileStore is the shared /v1/files store, or nil when files are disabled.
+	fileStore *filestore.Store
+
+	// notifications reports core auth lifecycle events to webhooks; nil when the core
+	// manager was supplied by the caller.
+	notifications *notificationHook
+
+	// webhooks remembers the settings of the installed webhook dispatcher.
+	webhooks *webhookSettings
+
+	// auditLog remembers the settings of the open management audit log.
+	auditLog *config.AuditLogConfig
+
+	// authCipher is the auth file cipher installed from the configuration, or nil when the
+	// current one came from sdk/auth.SetKeyProvider or encryption is not configured.
+	authCipher *authcrypt.Cipher
+
 	// serverErr channel for server startup/shutdown errors.
 	serverErr chan error
 
@@ -341,6 +382,7 @@ func (s *Service) applyCoreAuthRemoval(ctx context.Context, id string) {
 	if existing, ok := s.coreManager.GetByID(id); ok && existing != nil {
 		existing.Disabled = true
 		existing.Status = coreauth.StatusDisabled
+		existing.StatusMessage = removedStatusMessage
 		if _, err := s.coreManager.Update(ctx, existing); err != nil {
 			log.Errorf("failed to disable auth %s: %v", id, err)
 		}
@@ -514,6 +556,7 @@ func (s *Service) Run(ctx context.Context) error {
 		return err
 	}
 
+	s.applyAuthEncryption(s.cfg)
 	s.applyRetryConfig(s.cfg)
 
 	if s.coreManager != nil {
@@ -540,6 +583,15 @@ func (s *Service) Run(ctx context.Context) error {
 
 	// legacy clients removed; no caches to refresh
 
+	s.applyPricing(s.cfg)
+	s.applyUsagePersistence(s.cfg)
+	s.applySharedState(s.cfg)
+	s.applyResponseCache(s.cfg)
+	s.applyResponseStore(s.cfg)
+	s.applyContentPolicy(s.cfg)
+	s.applyWebhooks(s.cfg)
+	s.applyAuditLog(s.cfg)
+
 	// handlers no longer depend on legacy clients; pass nil slice initially
 	s.server = api.NewServer(s.cfg, s.coreManager, s.accessManager, s.configPath, s.serverOptions...)
 
@@ -608,6 +660,7 @@ func (s *Service) Run(ctx context.Context) error {
 		if refreshed > 0 {
 			log.Infof("re-registered models for %d auth(s) due to model catalog changes: %v", refreshed, changedProviders)
 		}
+		notifyModelCatalogChanged(changedProviders)
 	})
 
 	s.serverErr = make(chan error, 1)
@@ -651,6 +704,8 @@ func (s *Service) Run(ctx context.Context) error {
 			switch strategy {
 			case "fill-first", "fillfirst", "ff":
 				return "fill-first"
+			case "weighted-health", "weightedhealth", "health":
+				return "weighted-health"
 			default:
 				return "round-robin"
 			}
@@ -662,6 +717,8 @@ func (s *Service) Run(ctx context.Context) error {
 			switch nextStrategy {
 			case "fill-first":
 				selector = &coreauth.FillFirstSelector{}
+			case "weighted-health":
+				selector = &coreauth.WeightedHealthSelector{}
 			default:
 				selector = &coreauth.RoundRobinSelector{}
 			}
@@ -670,6 +727,17 @@ func (s *Service) Run(ctx context.Context) error {
 
 		s.applyRetryConfig(newCfg)
 		s.applyPprofConfig(newCfg)
+		s.applyPricing(newCfg)
+		s.applyUsagePersistence(newCfg)
+		s.applySharedState(newCfg)
+		s.applyResponseCache(newCfg)
+		s.applyResponseStore(newCfg)
+		s.applyFiles(newCfg)
+		s.applyBatches(newCfg)
+		s.applyContentPolicy(newCfg)
+		s.applyWebhooks(newCfg)
+		s.applyAuditLog(newCfg)
+		s.applyAuthEncryption(newCfg)
 		if s.server != nil {
 			s.server.UpdateClients(newCfg)
 		}
@@ -713,6 +781,10 @@ func (s *Service) Run(ctx context.Context) error {
 	}
 	log.Info("file watcher started for config and auth directory changes")
 
+	// Batches start once credentials are loaded so resumed requests find their providers.
+	s.applyFiles(s.cfg)
+	s.applyBatches(s.cfg)
+
 	// Prefer core auth manager auto refresh if available.
 	if s.coreManager != nil {
 		interval := 15 * time.Minute
@@ -782,6 +854,17 @@ func (s *Service) Shutdown(ctx context.Context) error {
 			}
 		}
 
+		s.shutdownUsagePersistence()
+		s.shutdownSharedState()
+		s.shutdownResponseCache()
+		s.shutdownResponseStore()
+		s.shutdownBatches()
+		s.shutdownFiles()
+		s.shutdownContentPolicy()
+		s.shutdownPricing()
+		s.shutdownWebhooks()
+		s.shutdownAuditLog()
+
 		// no legacy clients to persist
 
 		if s.server != nil {
@@ -1006,7 +1089,7 @@ func (s *Service) registerModelsForAuth(a *coreauth.Auth) {
 						if modelID == "" {
 							modelID = m.Name
 						}
-						ms = append(ms, &ModelInfo{
+						info := &ModelInfo{
 							ID:          modelID,
 							Object:      "model",
 							Created:     time.Now().Unix(),
@@ -1014,7 +1097,13 @@ func (s *Service) registerModelsForAuth(a *coreauth.Auth) {
 							Type:        "openai-compatibility",
 							DisplayName: modelID,
 							UserDefined: true,
-						})
+						}
+						if m.Embedding {
+							info.SupportedEndpoints = []string{registry.EmbeddingEndpoint}
+						} else if m.Completions {
+							info.SupportedEndpoints = []string{"/chat/completions", registry.CompletionsEndpoint}
+						}
+						ms = append(ms, info)
 					}
 					// Register and return
 					if len(ms) > 0 {
@@ -1396,8 +1485,14 @@ func buildConfigModels[T modelEntry](models []T, ownedBy, modelType string) []*M
 			UserDefined: true,
 		}
 		if name != "" {
-			if upstream := registry.LookupStaticModelInfo(name); upstream != nil && upstream.Thinking != nil {
-				info.Thinking = upstream.Thinking
+			if upstream := registry.LookupStaticModelInfo(name); upstream != nil {
+				if upstream.Thinking != nil {
+					info.Thinking = upstream.Thinking
+				}
+				if upstream.SupportsEmbeddings() {
+					info.SupportedGenerationMethods = upstream.SupportedGenerationMethods
+					info.SupportedEndpoints = upstream.SupportedEndpoints
+				}
 			}
 		}
 		out = append(out, info)
@@ -0,0 +1,97 @@
+package cliproxy
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/store"
+	sdkAuth "github.com/router-for-me/CLIProxyAPI/v6/sdk/auth"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	log "github.com/sirupsen/logrus"
+)
+
+const defaultSharedStateDir = "shared-state"
+
+// sharedStateProvider is implemented by token stores that can host the shared credential
+// state on their own connection (e.g. the Postgres-backed store).
+type sharedStateProvider interface {
+	SharedState(ctx context.Context, table string) (coreauth.SharedState, error)
+}
+
+// applySharedState connects the core manager to the configured shared state backend when
+// the settings changed since the last call.
+func (s *Service) applySharedState(cfg *config.Config) {
+	if s == nil || cfg == nil || s.coreManager == nil {
+		return
+	}
+	settings := cfg.SharedState
+	if settings.Backend == "file" {
+		settings.Dir = resolveSharedStateDir(settings.Dir, s.configPath)
+	}
+	if s.sharedState != nil && *s.sharedState == settings {
+		return
+	}
+	if settings.Backend == "" {
+		if s.sharedState != nil && s.sharedState.Backend != "" {
+			log.Info("shared state disabled")
+		}
+		s.coreManager.SetSharedState(nil, coreauth.SharedStateOptions{})
+		s.sharedState = &settings
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	state, err := openSharedState(ctx, settings)
+	if err != nil {
+		log.Errorf("shared state: %v", err)
+		return
+	}
+	s.coreManager.SetSharedState(state, coreauth.SharedStateOptions{
+		InstanceID:      settings.InstanceID,
+		SyncInterval:    time.Duration(settings.SyncIntervalSeconds) * time.Second,
+		ShareRoundRobin: settings.ShareRoundRobin,
+	})
+	s.sharedState = &settings
+	log.Infof("shared state enabled (backend=%s)", settings.Backend)
+}
+
+func (s *Service) shutdownSharedState() {
+	if s == nil || s.coreManager == nil || s.sharedState == nil {
+		return
+	}
+	s.coreManager.SetSharedState(nil, coreauth.SharedStateOptions{})
+	s.sharedState = nil
+}
+
+func openSharedState(ctx context.Context, settings config.SharedStateConfig) (coreauth.SharedState, error) {
+	switch settings.Backend {
+	case "file":
+		return store.NewFileSharedState(settings.Dir)
+	case "postgres":
+		provider, ok := sdkAuth.GetTokenStore().(sharedStateProvider)
+		if !ok {
+			return nil, fmt.Errorf("postgres backend requires the Postgres token store (PGSTORE_DSN)")
+		}
+		return provider.SharedState(ctx, settings.Table)
+	default:
+		return nil, fmt.Errorf("unsupported backend %q", settings.Backend)
+	}
+}
+
+func resolveSharedStateDir(dir, configPath string) string {
+	if dir == "" {
+		dir = defaultSharedStateDir
+	}
+	if filepath.IsAbs(dir) {
+		return dir
+	}
+	base := "."
+	if configPath != "" {
+		base = filepath.Dir(configPath)
+	}
+	return filepath.Join(base, dir)
+}
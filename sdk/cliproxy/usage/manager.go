@@ -19,6 +19,9 @@ type Record struct {
 	RequestedAt time.Time
 	Failed      bool
 	Detail      Detail
+	// RequestedModel is the model the client asked for when a fallback model served the
+	// request instead; empty otherwise.
+	RequestedModel string
 }
 
 // Detail holds the token usage breakdown.
@@ -27,7 +30,10 @@ type Detail struct {
 	OutputTokens    int64
 	ReasoningTokens int64
 	CachedTokens    int64
-	TotalTokens     int64
+	// CacheWriteTokens counts prompt tokens written to the upstream prompt cache,
+	// reported separately by providers that bill cache writes at their own rate.
+	CacheWriteTokens int64
+	TotalTokens      int64
 }
 
 // Plugin consumes usage records emitted by the proxy runtime.
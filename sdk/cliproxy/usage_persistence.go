@@ -0,0 +1,149 @@
+package cliproxy
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/access/quota"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	internalusage "github.com/router-for-me/CLIProxyAPI/v6/internal/usage"
+	sdkAuth "github.com/router-for-me/CLIProxyAPI/v6/sdk/auth"
+	log "github.com/sirupsen/logrus"
+)
+
+const defaultUsagePersistenceDir = "usage"
+
+// usageStoreProvider is implemented by token stores that can host usage records
+// on their own connection (e.g. the Postgres-backed store).
+type usageStoreProvider interface {
+	UsageStore(ctx context.Context, table string) (internalusage.Store, error)
+}
+
+type usagePersistence struct {
+	mu       sync.Mutex
+	backend  string
+	location string
+	restored bool
+}
+
+func (s *Service) applyUsagePersistence(cfg *config.Config) {
+	if s == nil || cfg == nil {
+		return
+	}
+	if s.usagePersistence == nil {
+		s.usagePersistence = &usagePersistence{}
+	}
+	s.usagePersistence.Apply(cfg, s.configPath)
+}
+
+func (s *Service) shutdownUsagePersistence() {
+	if s == nil || s.usagePersistence == nil {
+		return
+	}
+	s.usagePersistence.mu.Lock()
+	defer s.usagePersistence.mu.Unlock()
+	internalusage.GetPersistence().SetStore("", nil)
+	s.usagePersistence.backend = ""
+	s.usagePersistence.location = ""
+}
+
+// Apply opens the configured backend when it changed since the last call and
+// restores recent history into the in-memory statistics on first activation.
+func (p *usagePersistence) Apply(cfg *config.Config, configPath string) {
+	if p == nil || cfg == nil {
+		return
+	}
+	settings := cfg.UsagePersistence
+	backend := settings.Backend
+	location := ""
+	switch backend {
+	case "file":
+		location = resolveUsagePersistenceDir(settings.Dir, configPath)
+	case "postgres":
+		location = settings.Table
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if backend == p.backend && location == p.location {
+		return
+	}
+
+	plugin := internalusage.GetPersistence()
+	if backend == "" {
+		plugin.SetStore("", nil)
+		p.backend, p.location = "", ""
+		log.Info("usage persistence disabled")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	store, err := openUsageStore(ctx, backend, location)
+	if err != nil {
+		log.Errorf("usage persistence: %v", err)
+		return
+	}
+	plugin.SetStore(backend, store)
+	p.backend, p.location = backend, location
+	log.Infof("usage persistence enabled (backend=%s)", backend)
+
+	keyNames := make(map[string]string)
+	for _, entry := range cfg.APIKeys {
+		if entry.Name != "" {
+			keyNames[entry.Name] = entry.Key
+		}
+	}
+	if errQuota := quota.Default().Restore(ctx, store, keyNames); errQuota != nil {
+		log.Warnf("usage persistence: failed to restore api key quota counters: %v", errQuota)
+	}
+	if errBudgets := internalusage.GetBudgetTracker().Restore(ctx, store, keyNames); errBudgets != nil {
+		log.Warnf("usage persistence: failed to restore budget spend: %v", errBudgets)
+	}
+
+	if p.restored || settings.RestoreDays <= 0 {
+		return
+	}
+	p.restored = true
+	since := time.Now().AddDate(0, 0, -settings.RestoreDays)
+	result, errRestore := internalusage.GetRequestStatistics().Restore(ctx, store, since)
+	if errRestore != nil {
+		log.Warnf("usage persistence: failed to restore statistics: %v", errRestore)
+		return
+	}
+	if result.Added > 0 {
+		log.Infof("usage persistence: restored %d request record(s) from the last %d day(s)", result.Added, settings.RestoreDays)
+	}
+}
+
+func openUsageStore(ctx context.Context, backend, location string) (internalusage.Store, error) {
+	switch backend {
+	case "file":
+		return internalusage.NewFileStore(location)
+	case "postgres":
+		provider, ok := sdkAuth.GetTokenStore().(usageStoreProvider)
+		if !ok {
+			return nil, fmt.Errorf("postgres backend requires the Postgres token store (PGSTORE_DSN)")
+		}
+		return provider.UsageStore(ctx, location)
+	default:
+		return nil, fmt.Errorf("unsupported backend %q", backend)
+	}
+}
+
+func resolveUsagePersistenceDir(dir, configPath string) string {
+	if dir == "" {
+		dir = defaultUsagePersistenceDir
+	}
+	if filepath.IsAbs(dir) {
+		return dir
+	}
+	base := "."
+	if configPath != "" {
+		base = filepath.Dir(configPath)
+	}
+	return filepath.Join(base, dir)
+}
@@ -0,0 +1,278 @@
+package cliproxy
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/notify"
+	internalusage "github.com/router-for-me/CLIProxyAPI/v6/internal/usage"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	log "github.com/sirupsen/logrus"
+)
+
+// quotaNotifyWindow suppresses repeated quota events of one credential and model when the
+// provider gave no recovery time.
+const quotaNotifyWindow = 5 * time.Minute
+
+// removedStatusMessage marks auths disabled because their source was deleted.
+const removedStatusMessage = "removed"
+
+var budgetAlertsOnce sync.Once
+
+// webhookSettings remembers what the installed dispatcher was built from.
+type webhookSettings struct {
+	webhooks []config.WebhookConfig
+	proxyURL string
+}
+
+// applyWebhooks installs a notification dispatcher for the webhooks of cfg, replacing the
+// previous one when the webhooks or the outbound proxy changed.
+func (s *Service) applyWebhooks(cfg *config.Config) {
+	if s == nil || cfg == nil {
+		return
+	}
+	budgetAlertsOnce.Do(func() {
+		internalusage.GetBudgetTracker().OnAlert(publishBudgetAlert)
+	})
+	if s.notifications != nil && s.coreManager != nil {
+		s.notifications.seed(s.coreManager.List())
+	}
+	if len(cfg.Webhooks) == 0 {
+		if s.webhooks != nil {
+			s.shutdownWebhooks()
+			log.Info("webhooks disabled")
+		}
+		return
+	}
+	settings := &webhookSettings{webhooks: cfg.Webhooks, proxyURL: cfg.ProxyURL}
+	if s.webhooks != nil && reflect.DeepEqual(*s.webhooks, *settings) {
+		return
+	}
+	client := &http.Client{}
+	util.SetProxy(&cfg.SDKConfig, client)
+	if previous := notify.SetDefault(notify.New(cfg.Webhooks, client)); previous != nil {
+		previous.Close()
+	}
+	s.webhooks = settings
+	log.Infof("webhooks enabled (%d receiver(s))", len(cfg.Webhooks))
+}
+
+func (s *Service) shutdownWebhooks() {
+	if s == nil {
+		return
+	}
+	if previous := notify.SetDefault(nil); previous != nil {
+		previous.Close()
+	}
+	s.webhooks = nil
+}
+
+// notifyModelCatalogChanged reports providers whose model catalog changed upstream.
+func notifyModelCatalogChanged(providers []string) {
+	notify.Publish(notify.Event{
+		Type:      notify.EventModelCatalogChanged,
+		Message:   fmt.Sprintf("model catalog changed for %s", strings.Join(providers, ", ")),
+		Providers: append([]string(nil), providers...),
+	})
+}
+
+func publishBudgetAlert(alert internalusage.BudgetAlert) {
+	event := notify.Event{
+		Type:    notify.EventBudgetWarning,
+		APIKey:  util.HideAPIKey(alert.APIKey),
+		Limit:   alert.Limit,
+		Spent:   alert.Spent,
+		Message: fmt.Sprintf("client key %s spent $%.2f of its $%.2f %s budget", util.HideAPIKey(alert.APIKey), alert.Spent, alert.Limit, alert.Period),
+	}
+	if alert.Exceeded {
+		event.Type = notify.EventBudgetExceeded
+	}
+	notify.Publish(event)
+}
+
+// notificationHook turns core auth lifecycle callbacks into webhook events. It remembers
+// which auths are disabled so only transitions are reported, and suppresses repeated
+// quota and cooldown events until the reported recovery time.
+type notificationHook struct {
+	coreauth.NoopHook
+
+	manager *coreauth.Manager
+
+	mu       sync.Mutex
+	seeded   bool
+	disabled map[string]bool
+	quota    map[string]time.Time
+	cooldown map[string]time.Time
+}
+
+func newNotificationHook() *notificationHook {
+	return &notificationHook{
+		disabled: make(map[string]bool),
+		quota:    make(map[string]time.Time),
+		cooldown: make(map[string]time.Time),
+	}
+}
+
+// seed records the state of auths loaded without registration callbacks. Only the first
+// call has an effect.
+func (h *notificationHook) seed(auths []*coreauth.Auth) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.seeded {
+		return
+	}
+	h.seeded = true
+	for _, auth := range auths {
+		if auth != nil {
+			h.disabled[auth.ID] = auth.Disabled
+		}
+	}
+}
+
+// OnAuthRegistered implements coreauth.Hook.
+func (h *notificationHook) OnAuthRegistered(_ context.Context, auth *coreauth.Auth) {
+	if auth == nil {
+		return
+	}
+	h.mu.Lock()
+	h.disabled[auth.ID] = auth.Disabled
+	h.mu.Unlock()
+	event := authEvent(notify.EventAuthRegistered, auth)
+	event.Message = fmt.Sprintf("credential %s registered", event.AuthLabel)
+	notify.Publish(event)
+}
+
+// OnAuthUpdated implements coreauth.Hook.
+func (h *notificationHook) OnAuthUpdated(_ context.Context, auth *coreauth.Auth) {
+	if auth == nil {
+		return
+	}
+	h.mu.Lock()
+	wasDisabled, known := h.disabled[auth.ID]
+	h.disabled[auth.ID] = auth.Disabled
+	h.mu.Unlock()
+	if !auth.Disabled || (known && wasDisabled) {
+		return
+	}
+	removed := strings.HasPrefix(strings.ToLower(strings.TrimSpace(auth.StatusMessage)), removedStatusMessage)
+	if !known && !removed {
+		return
+	}
+	event := authEvent(notify.EventAuthDisabled, auth)
+	event.Message = fmt.Sprintf("credential %s disabled", event.AuthLabel)
+	if removed {
+		event.Type = notify.EventAuthRemoved
+		event.Message = fmt.Sprintf("credential %s removed", event.AuthLabel)
+	}
+	if auth.StatusMessage != "" {
+		event.Message += ": " + auth.StatusMessage
+	}
+	notify.Publish(event)
+}
+
+// OnRefreshFailed implements coreauth.RefreshFailureHook.
+func (h *notificationHook) OnRefreshFailed(_ context.Context, auth *coreauth.Auth, err error) {
+	if auth == nil || err == nil {
+		return
+	}
+	event := authEvent(notify.EventAuthRefreshFailed, auth)
+	event.Error = err.Error()
+	event.Message = fmt.Sprintf("refresh of credential %s failed: %v", event.AuthLabel, err)
+	notify.Publish(event)
+}
+
+// OnResult implements coreauth.Hook.
+func (h *notificationHook) OnResult(_ context.Context, result coreauth.Result) {
+	if h.manager == nil || result.Success || result.Error == nil || result.Error.HTTPStatus != http.StatusTooManyRequests {
+		return
+	}
+	now := time.Now()
+	auth, _ := h.manager.GetByID(result.AuthID)
+	recoverAt := quotaRecoverAt(auth, result, now)
+	if h.markNotified(h.quota, result.AuthID+"|"+result.Model, recoverAt, now) {
+		event := authEvent(notify.EventAuthQuotaExceeded, auth)
+		event.AuthID = result.AuthID
+		if event.AuthLabel == "" {
+			event.AuthLabel = result.AuthID
+		}
+		event.Provider = result.Provider
+		event.Model = result.Model
+		event.Error = result.Error.Message
+		event.RetryAfterSeconds = secondsUntil(recoverAt, now)
+		event.Message = fmt.Sprintf("credential %s exceeded its quota for %s", event.AuthLabel, result.Model)
+		notify.Publish(event)
+	}
+
+	cooldownUntil, cooling := h.manager.ModelCoolingDown(result.Model)
+	if !cooling || !h.markNotified(h.cooldown, result.Model, cooldownUntil, now) {
+		return
+	}
+	notify.Publish(notify.Event{
+		Type:              notify.EventModelCooldown,
+		Provider:          result.Provider,
+		Model:             result.Model,
+		RetryAfterSeconds: secondsUntil(cooldownUntil, now),
+		Message:           fmt.Sprintf("all credentials for %s are cooling down until %s", result.Model, cooldownUntil.UTC().Format(time.RFC3339)),
+	})
+}
+
+// markNotified reports whether key was not notified yet for a window still open, and
+// records a notification lasting until the given time.
+func (h *notificationHook) markNotified(seen map[string]time.Time, key string, until, now time.Time) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if previous, ok := seen[key]; ok && previous.After(now) {
+		return false
+	}
+	for k, expiry := range seen {
+		if !expiry.After(now) {
+			delete(seen, k)
+		}
+	}
+	seen[key] = until
+	return true
+}
+
+// quotaRecoverAt returns when the credential of result recovers from its quota error.
+func quotaRecoverAt(auth *coreauth.Auth, result coreauth.Result, now time.Time) time.Time {
+	if auth != nil {
+		if state := auth.ModelStates[result.Model]; state != nil && state.NextRetryAfter.After(now) {
+			return state.NextRetryAfter
+		}
+		if auth.NextRetryAfter.After(now) {
+			return auth.NextRetryAfter
+		}
+	}
+	if result.RetryAfter != nil && *result.RetryAfter > 0 {
+		return now.Add(*result.RetryAfter)
+	}
+	return now.Add(quotaNotifyWindow)
+}
+
+func secondsUntil(at, now time.Time) int64 {
+	if !at.After(now) {
+		return 0
+	}
+	return int64(at.Sub(now).Round(time.Second) / time.Second)
+}
+
+func authEvent(eventType string, auth *coreauth.Auth) notify.Event {
+	event := notify.Event{Type: eventType}
+	if auth == nil {
+		return event
+	}
+	event.AuthID = auth.ID
+	event.Provider = auth.Provider
+	event.AuthLabel = auth.Label
+	if event.AuthLabel == "" {
+		event.AuthLabel = auth.ID
+	}
+	return event
+}
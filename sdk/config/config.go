@@ -11,10 +11,32 @@ type SDKConfig = internalconfig.SDKConfig
 type Config = internalconfig.Config
 
 type StreamingConfig = internalconfig.StreamingConfig
+type StructuredOutputConfig = internalconfig.StructuredOutputConfig
 type TLSConfig = internalconfig.TLSConfig
 type RemoteManagement = internalconfig.RemoteManagement
+type ManagementPrincipal = internalconfig.ManagementPrincipal
+type APIKey = internalconfig.APIKey
+type APIKeyLimit = internalconfig.APIKeyLimit
+type MetricsConfig = internalconfig.MetricsConfig
+type UsagePersistenceConfig = internalconfig.UsagePersistenceConfig
+type SharedStateConfig = internalconfig.SharedStateConfig
+type ResponseCacheConfig = internalconfig.ResponseCacheConfig
+type ResponseStoreConfig = internalconfig.ResponseStoreConfig
+type BatchConfig = internalconfig.BatchConfig
+type FilesConfig = internalconfig.FilesConfig
+type ContentPolicyConfig = internalconfig.ContentPolicyConfig
+type ContentPolicyRule = internalconfig.ContentPolicyRule
+type PricingConfig = internalconfig.PricingConfig
+type ModelPrice = internalconfig.ModelPrice
+type CostBudget = internalconfig.CostBudget
+type WebhookConfig = internalconfig.WebhookConfig
+type AuditLogConfig = internalconfig.AuditLogConfig
 type AmpCode = internalconfig.AmpCode
 type OAuthModelAlias = internalconfig.OAuthModelAlias
+type ModelFallback = internalconfig.ModelFallback
+type SessionAffinityConfig = internalconfig.SessionAffinityConfig
+type AuthEncryptionConfig = internalconfig.AuthEncryptionConfig
+type AuthEncryptionKey = internalconfig.AuthEncryptionKey
 type PayloadConfig = internalconfig.PayloadConfig
 type PayloadRule = internalconfig.PayloadRule
 type PayloadFilterRule = internalconfig.PayloadFilterRule
@@ -31,6 +53,17 @@ type OpenAICompatibilityModel = internalconfig.OpenAICompatibilityModel
 
 type TLS = internalconfig.TLSConfig
 
+const (
+	RouteGroupOpenAI    = internalconfig.RouteGroupOpenAI
+	RouteGroupClaude    = internalconfig.RouteGroupClaude
+	RouteGroupGemini    = internalconfig.RouteGroupGemini
+	RouteGroupResponses = internalconfig.RouteGroupResponses
+	RouteGroupAmp       = internalconfig.RouteGroupAmp
+	RouteGroupModels    = internalconfig.RouteGroupModels
+	RouteGroupFiles     = internalconfig.RouteGroupFiles
+	RouteGroupBatches   = internalconfig.RouteGroupBatches
+)
+
 const (
 	DefaultPanelGitHubRepository = internalconfig.DefaultPanelGitHubRepository
 )
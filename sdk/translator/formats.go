@@ -9,4 +9,13 @@ const (
 	FormatGeminiCLI      Format = "gemini-cli"
 	FormatCodex          Format = "codex"
 	FormatAntigravity    Format = "antigravity"
+
+	// FormatOpenAIEmbedding and FormatGeminiEmbedding identify embeddings requests, which
+	// executors route to the provider's embedding endpoint instead of text generation.
+	FormatOpenAIEmbedding Format = "openai-embedding"
+	FormatGeminiEmbedding Format = "gemini-embedding"
+
+	// FormatOpenAIImage identifies image generation and edit requests, which translate to
+	// generateContent with image output.
+	FormatOpenAIImage Format = "openai-image"
 )